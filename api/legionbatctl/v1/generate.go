@@ -0,0 +1,4 @@
+// Package legionbatctlv1 is the generated Go code of the daemon's gRPC API
+package legionbatctlv1
+
+//go:generate protoc --proto_path=../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative api/legionbatctl/v1/legionbatctl.proto
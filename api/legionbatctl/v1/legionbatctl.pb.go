@@ -0,0 +1,1085 @@
+// gRPC definition of the legionbatctl daemon API, mirroring the JSON socket
+// protocol in internal/protocol. The daemon serves it on the Unix socket set
+// by grpc.socket; the Go code next to this file is generated from it (see
+// generate.go).
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: api/legionbatctl/v1/legionbatctl.proto
+
+package legionbatctlv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Read the hardware instead of serving the cached snapshot
+	Fresh         bool `protobuf:"varint,1,opt,name=fresh,proto3" json:"fresh,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetStatusRequest) GetFresh() bool {
+	if x != nil {
+		return x.Fresh
+	}
+	return false
+}
+
+type Status struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	ConservationEnabled bool                   `protobuf:"varint,1,opt,name=conservation_enabled,json=conservationEnabled,proto3" json:"conservation_enabled,omitempty"`
+	Threshold           int32                  `protobuf:"varint,2,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	StartThreshold      int32                  `protobuf:"varint,3,opt,name=start_threshold,json=startThreshold,proto3" json:"start_threshold,omitempty"`
+	CurrentMode         string                 `protobuf:"bytes,4,opt,name=current_mode,json=currentMode,proto3" json:"current_mode,omitempty"`
+	BatteryLevel        int32                  `protobuf:"varint,5,opt,name=battery_level,json=batteryLevel,proto3" json:"battery_level,omitempty"`
+	ConservationMode    bool                   `protobuf:"varint,6,opt,name=conservation_mode,json=conservationMode,proto3" json:"conservation_mode,omitempty"`
+	Charging            bool                   `protobuf:"varint,7,opt,name=charging,proto3" json:"charging,omitempty"`
+	LastAction          string                 `protobuf:"bytes,8,opt,name=last_action,json=lastAction,proto3" json:"last_action,omitempty"`
+	DaemonUptime        string                 `protobuf:"bytes,9,opt,name=daemon_uptime,json=daemonUptime,proto3" json:"daemon_uptime,omitempty"`
+	HardwareSupported   bool                   `protobuf:"varint,10,opt,name=hardware_supported,json=hardwareSupported,proto3" json:"hardware_supported,omitempty"`
+	ReenableAt          *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=reenable_at,json=reenableAt,proto3" json:"reenable_at,omitempty"`
+	Schedule            string                 `protobuf:"bytes,12,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	StorageMode         bool                   `protobuf:"varint,13,opt,name=storage_mode,json=storageMode,proto3" json:"storage_mode,omitempty"`
+	StorageTarget       int32                  `protobuf:"varint,14,opt,name=storage_target,json=storageTarget,proto3" json:"storage_target,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Status) GetConservationEnabled() bool {
+	if x != nil {
+		return x.ConservationEnabled
+	}
+	return false
+}
+
+func (x *Status) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *Status) GetStartThreshold() int32 {
+	if x != nil {
+		return x.StartThreshold
+	}
+	return 0
+}
+
+func (x *Status) GetCurrentMode() string {
+	if x != nil {
+		return x.CurrentMode
+	}
+	return ""
+}
+
+func (x *Status) GetBatteryLevel() int32 {
+	if x != nil {
+		return x.BatteryLevel
+	}
+	return 0
+}
+
+func (x *Status) GetConservationMode() bool {
+	if x != nil {
+		return x.ConservationMode
+	}
+	return false
+}
+
+func (x *Status) GetCharging() bool {
+	if x != nil {
+		return x.Charging
+	}
+	return false
+}
+
+func (x *Status) GetLastAction() string {
+	if x != nil {
+		return x.LastAction
+	}
+	return ""
+}
+
+func (x *Status) GetDaemonUptime() string {
+	if x != nil {
+		return x.DaemonUptime
+	}
+	return ""
+}
+
+func (x *Status) GetHardwareSupported() bool {
+	if x != nil {
+		return x.HardwareSupported
+	}
+	return false
+}
+
+func (x *Status) GetReenableAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReenableAt
+	}
+	return nil
+}
+
+func (x *Status) GetSchedule() string {
+	if x != nil {
+		return x.Schedule
+	}
+	return ""
+}
+
+func (x *Status) GetStorageMode() bool {
+	if x != nil {
+		return x.StorageMode
+	}
+	return false
+}
+
+func (x *Status) GetStorageTarget() int32 {
+	if x != nil {
+		return x.StorageTarget
+	}
+	return 0
+}
+
+type EnableRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnableRequest) Reset() {
+	*x = EnableRequest{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnableRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnableRequest) ProtoMessage() {}
+
+func (x *EnableRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnableRequest.ProtoReflect.Descriptor instead.
+func (*EnableRequest) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{2}
+}
+
+type EnableResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Threshold     int32                  `protobuf:"varint,2,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	CurrentMode   string                 `protobuf:"bytes,3,opt,name=current_mode,json=currentMode,proto3" json:"current_mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnableResponse) Reset() {
+	*x = EnableResponse{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnableResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnableResponse) ProtoMessage() {}
+
+func (x *EnableResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnableResponse.ProtoReflect.Descriptor instead.
+func (*EnableResponse) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EnableResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *EnableResponse) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *EnableResponse) GetCurrentMode() string {
+	if x != nil {
+		return x.CurrentMode
+	}
+	return ""
+}
+
+type DisableRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Re-enable management after this long, e.g. "2h"; empty disables until enabled
+	For           string `protobuf:"bytes,1,opt,name=for,proto3" json:"for,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisableRequest) Reset() {
+	*x = DisableRequest{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisableRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisableRequest) ProtoMessage() {}
+
+func (x *DisableRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisableRequest.ProtoReflect.Descriptor instead.
+func (*DisableRequest) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DisableRequest) GetFor() string {
+	if x != nil {
+		return x.For
+	}
+	return ""
+}
+
+type DisableResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	CurrentMode   string                 `protobuf:"bytes,2,opt,name=current_mode,json=currentMode,proto3" json:"current_mode,omitempty"`
+	ReenableAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=reenable_at,json=reenableAt,proto3" json:"reenable_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisableResponse) Reset() {
+	*x = DisableResponse{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisableResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisableResponse) ProtoMessage() {}
+
+func (x *DisableResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisableResponse.ProtoReflect.Descriptor instead.
+func (*DisableResponse) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DisableResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DisableResponse) GetCurrentMode() string {
+	if x != nil {
+		return x.CurrentMode
+	}
+	return ""
+}
+
+func (x *DisableResponse) GetReenableAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReenableAt
+	}
+	return nil
+}
+
+type SetThresholdRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Threshold int32                  `protobuf:"varint,1,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	// Charging resumes below this level; 0 uses the hysteresis
+	StartThreshold int32 `protobuf:"varint,2,opt,name=start_threshold,json=startThreshold,proto3" json:"start_threshold,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SetThresholdRequest) Reset() {
+	*x = SetThresholdRequest{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetThresholdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetThresholdRequest) ProtoMessage() {}
+
+func (x *SetThresholdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetThresholdRequest.ProtoReflect.Descriptor instead.
+func (*SetThresholdRequest) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetThresholdRequest) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *SetThresholdRequest) GetStartThreshold() int32 {
+	if x != nil {
+		return x.StartThreshold
+	}
+	return 0
+}
+
+type SetThresholdResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Message        string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Threshold      int32                  `protobuf:"varint,2,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	StartThreshold int32                  `protobuf:"varint,3,opt,name=start_threshold,json=startThreshold,proto3" json:"start_threshold,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SetThresholdResponse) Reset() {
+	*x = SetThresholdResponse{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetThresholdResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetThresholdResponse) ProtoMessage() {}
+
+func (x *SetThresholdResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetThresholdResponse.ProtoReflect.Descriptor instead.
+func (*SetThresholdResponse) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SetThresholdResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *SetThresholdResponse) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *SetThresholdResponse) GetStartThreshold() int32 {
+	if x != nil {
+		return x.StartThreshold
+	}
+	return 0
+}
+
+type GetHealthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHealthRequest) Reset() {
+	*x = GetHealthRequest{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHealthRequest) ProtoMessage() {}
+
+func (x *GetHealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHealthRequest.ProtoReflect.Descriptor instead.
+func (*GetHealthRequest) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{8}
+}
+
+type Health struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Full          int64                  `protobuf:"varint,1,opt,name=full,proto3" json:"full,omitempty"`
+	FullDesign    int64                  `protobuf:"varint,2,opt,name=full_design,json=fullDesign,proto3" json:"full_design,omitempty"`
+	Unit          string                 `protobuf:"bytes,3,opt,name=unit,proto3" json:"unit,omitempty"`
+	CycleCount    int32                  `protobuf:"varint,4,opt,name=cycle_count,json=cycleCount,proto3" json:"cycle_count,omitempty"`
+	Wear          float64                `protobuf:"fixed64,5,opt,name=wear,proto3" json:"wear,omitempty"`
+	WearWarning   int32                  `protobuf:"varint,6,opt,name=wear_warning,json=wearWarning,proto3" json:"wear_warning,omitempty"`
+	Degraded      bool                   `protobuf:"varint,7,opt,name=degraded,proto3" json:"degraded,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Health) Reset() {
+	*x = Health{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Health) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Health) ProtoMessage() {}
+
+func (x *Health) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Health.ProtoReflect.Descriptor instead.
+func (*Health) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Health) GetFull() int64 {
+	if x != nil {
+		return x.Full
+	}
+	return 0
+}
+
+func (x *Health) GetFullDesign() int64 {
+	if x != nil {
+		return x.FullDesign
+	}
+	return 0
+}
+
+func (x *Health) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *Health) GetCycleCount() int32 {
+	if x != nil {
+		return x.CycleCount
+	}
+	return 0
+}
+
+func (x *Health) GetWear() float64 {
+	if x != nil {
+		return x.Wear
+	}
+	return 0
+}
+
+func (x *Health) GetWearWarning() int32 {
+	if x != nil {
+		return x.WearWarning
+	}
+	return 0
+}
+
+func (x *Health) GetDegraded() bool {
+	if x != nil {
+		return x.Degraded
+	}
+	return false
+}
+
+type GetHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	Until         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=until,proto3" json:"until,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHistoryRequest) Reset() {
+	*x = GetHistoryRequest{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHistoryRequest) ProtoMessage() {}
+
+func (x *GetHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetHistoryRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *GetHistoryRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+type HistoryEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Event         string                 `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	Level         int32                  `protobuf:"varint,3,opt,name=level,proto3" json:"level,omitempty"`
+	Ac            bool                   `protobuf:"varint,4,opt,name=ac,proto3" json:"ac,omitempty"`
+	Conservation  bool                   `protobuf:"varint,5,opt,name=conservation,proto3" json:"conservation,omitempty"`
+	Threshold     int32                  `protobuf:"varint,6,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryEntry) Reset() {
+	*x = HistoryEntry{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryEntry) ProtoMessage() {}
+
+func (x *HistoryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryEntry.ProtoReflect.Descriptor instead.
+func (*HistoryEntry) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *HistoryEntry) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *HistoryEntry) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *HistoryEntry) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *HistoryEntry) GetAc() bool {
+	if x != nil {
+		return x.Ac
+	}
+	return false
+}
+
+func (x *HistoryEntry) GetConservation() bool {
+	if x != nil {
+		return x.Conservation
+	}
+	return false
+}
+
+func (x *HistoryEntry) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+type WatchEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Event types to stream (e.g. "threshold-reached"); empty streams all
+	Events        []string `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchEventsRequest) GetEvents() []string {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Event         string                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	BatteryLevel  int32                  `protobuf:"varint,3,opt,name=battery_level,json=batteryLevel,proto3" json:"battery_level,omitempty"`
+	Threshold     int32                  `protobuf:"varint,4,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_api_legionbatctl_v1_legionbatctl_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Event) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetBatteryLevel() int32 {
+	if x != nil {
+		return x.BatteryLevel
+	}
+	return 0
+}
+
+func (x *Event) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *Event) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_api_legionbatctl_v1_legionbatctl_proto protoreflect.FileDescriptor
+
+const file_api_legionbatctl_v1_legionbatctl_proto_rawDesc = "" +
+	"\n" +
+	"&api/legionbatctl/v1/legionbatctl.proto\x12\x0flegionbatctl.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"(\n" +
+	"\x10GetStatusRequest\x12\x14\n" +
+	"\x05fresh\x18\x01 \x01(\bR\x05fresh\"\xab\x04\n" +
+	"\x06Status\x121\n" +
+	"\x14conservation_enabled\x18\x01 \x01(\bR\x13conservationEnabled\x12\x1c\n" +
+	"\tthreshold\x18\x02 \x01(\x05R\tthreshold\x12'\n" +
+	"\x0fstart_threshold\x18\x03 \x01(\x05R\x0estartThreshold\x12!\n" +
+	"\fcurrent_mode\x18\x04 \x01(\tR\vcurrentMode\x12#\n" +
+	"\rbattery_level\x18\x05 \x01(\x05R\fbatteryLevel\x12+\n" +
+	"\x11conservation_mode\x18\x06 \x01(\bR\x10conservationMode\x12\x1a\n" +
+	"\bcharging\x18\a \x01(\bR\bcharging\x12\x1f\n" +
+	"\vlast_action\x18\b \x01(\tR\n" +
+	"lastAction\x12#\n" +
+	"\rdaemon_uptime\x18\t \x01(\tR\fdaemonUptime\x12-\n" +
+	"\x12hardware_supported\x18\n" +
+	" \x01(\bR\x11hardwareSupported\x12;\n" +
+	"\vreenable_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"reenableAt\x12\x1a\n" +
+	"\bschedule\x18\f \x01(\tR\bschedule\x12!\n" +
+	"\fstorage_mode\x18\r \x01(\bR\vstorageMode\x12%\n" +
+	"\x0estorage_target\x18\x0e \x01(\x05R\rstorageTarget\"\x0f\n" +
+	"\rEnableRequest\"k\n" +
+	"\x0eEnableResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1c\n" +
+	"\tthreshold\x18\x02 \x01(\x05R\tthreshold\x12!\n" +
+	"\fcurrent_mode\x18\x03 \x01(\tR\vcurrentMode\"\"\n" +
+	"\x0eDisableRequest\x12\x10\n" +
+	"\x03for\x18\x01 \x01(\tR\x03for\"\x8b\x01\n" +
+	"\x0fDisableResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12!\n" +
+	"\fcurrent_mode\x18\x02 \x01(\tR\vcurrentMode\x12;\n" +
+	"\vreenable_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"reenableAt\"\\\n" +
+	"\x13SetThresholdRequest\x12\x1c\n" +
+	"\tthreshold\x18\x01 \x01(\x05R\tthreshold\x12'\n" +
+	"\x0fstart_threshold\x18\x02 \x01(\x05R\x0estartThreshold\"w\n" +
+	"\x14SetThresholdResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1c\n" +
+	"\tthreshold\x18\x02 \x01(\x05R\tthreshold\x12'\n" +
+	"\x0fstart_threshold\x18\x03 \x01(\x05R\x0estartThreshold\"\x12\n" +
+	"\x10GetHealthRequest\"\xc5\x01\n" +
+	"\x06Health\x12\x12\n" +
+	"\x04full\x18\x01 \x01(\x03R\x04full\x12\x1f\n" +
+	"\vfull_design\x18\x02 \x01(\x03R\n" +
+	"fullDesign\x12\x12\n" +
+	"\x04unit\x18\x03 \x01(\tR\x04unit\x12\x1f\n" +
+	"\vcycle_count\x18\x04 \x01(\x05R\n" +
+	"cycleCount\x12\x12\n" +
+	"\x04wear\x18\x05 \x01(\x01R\x04wear\x12!\n" +
+	"\fwear_warning\x18\x06 \x01(\x05R\vwearWarning\x12\x1a\n" +
+	"\bdegraded\x18\a \x01(\bR\bdegraded\"w\n" +
+	"\x11GetHistoryRequest\x120\n" +
+	"\x05since\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\"\xbc\x01\n" +
+	"\fHistoryEntry\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x14\n" +
+	"\x05event\x18\x02 \x01(\tR\x05event\x12\x14\n" +
+	"\x05level\x18\x03 \x01(\x05R\x05level\x12\x0e\n" +
+	"\x02ac\x18\x04 \x01(\bR\x02ac\x12\"\n" +
+	"\fconservation\x18\x05 \x01(\bR\fconservation\x12\x1c\n" +
+	"\tthreshold\x18\x06 \x01(\x05R\tthreshold\",\n" +
+	"\x12WatchEventsRequest\x12\x16\n" +
+	"\x06events\x18\x01 \x03(\tR\x06events\"\xaa\x01\n" +
+	"\x05Event\x12\x14\n" +
+	"\x05event\x18\x01 \x01(\tR\x05event\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12#\n" +
+	"\rbattery_level\x18\x03 \x01(\x05R\fbatteryLevel\x12\x1c\n" +
+	"\tthreshold\x18\x04 \x01(\x05R\tthreshold\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage2\xb9\x04\n" +
+	"\x0eBatteryService\x12G\n" +
+	"\tGetStatus\x12!.legionbatctl.v1.GetStatusRequest\x1a\x17.legionbatctl.v1.Status\x12I\n" +
+	"\x06Enable\x12\x1e.legionbatctl.v1.EnableRequest\x1a\x1f.legionbatctl.v1.EnableResponse\x12L\n" +
+	"\aDisable\x12\x1f.legionbatctl.v1.DisableRequest\x1a .legionbatctl.v1.DisableResponse\x12[\n" +
+	"\fSetThreshold\x12$.legionbatctl.v1.SetThresholdRequest\x1a%.legionbatctl.v1.SetThresholdResponse\x12G\n" +
+	"\tGetHealth\x12!.legionbatctl.v1.GetHealthRequest\x1a\x17.legionbatctl.v1.Health\x12Q\n" +
+	"\n" +
+	"GetHistory\x12\".legionbatctl.v1.GetHistoryRequest\x1a\x1d.legionbatctl.v1.HistoryEntry0\x01\x12L\n" +
+	"\vWatchEvents\x12#.legionbatctl.v1.WatchEventsRequest\x1a\x16.legionbatctl.v1.Event0\x01BDZBgithub.com/dom1nux/legionbatctl/api/legionbatctl/v1;legionbatctlv1b\x06proto3"
+
+var (
+	file_api_legionbatctl_v1_legionbatctl_proto_rawDescOnce sync.Once
+	file_api_legionbatctl_v1_legionbatctl_proto_rawDescData []byte
+)
+
+func file_api_legionbatctl_v1_legionbatctl_proto_rawDescGZIP() []byte {
+	file_api_legionbatctl_v1_legionbatctl_proto_rawDescOnce.Do(func() {
+		file_api_legionbatctl_v1_legionbatctl_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_legionbatctl_v1_legionbatctl_proto_rawDesc), len(file_api_legionbatctl_v1_legionbatctl_proto_rawDesc)))
+	})
+	return file_api_legionbatctl_v1_legionbatctl_proto_rawDescData
+}
+
+var file_api_legionbatctl_v1_legionbatctl_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_api_legionbatctl_v1_legionbatctl_proto_goTypes = []any{
+	(*GetStatusRequest)(nil),      // 0: legionbatctl.v1.GetStatusRequest
+	(*Status)(nil),                // 1: legionbatctl.v1.Status
+	(*EnableRequest)(nil),         // 2: legionbatctl.v1.EnableRequest
+	(*EnableResponse)(nil),        // 3: legionbatctl.v1.EnableResponse
+	(*DisableRequest)(nil),        // 4: legionbatctl.v1.DisableRequest
+	(*DisableResponse)(nil),       // 5: legionbatctl.v1.DisableResponse
+	(*SetThresholdRequest)(nil),   // 6: legionbatctl.v1.SetThresholdRequest
+	(*SetThresholdResponse)(nil),  // 7: legionbatctl.v1.SetThresholdResponse
+	(*GetHealthRequest)(nil),      // 8: legionbatctl.v1.GetHealthRequest
+	(*Health)(nil),                // 9: legionbatctl.v1.Health
+	(*GetHistoryRequest)(nil),     // 10: legionbatctl.v1.GetHistoryRequest
+	(*HistoryEntry)(nil),          // 11: legionbatctl.v1.HistoryEntry
+	(*WatchEventsRequest)(nil),    // 12: legionbatctl.v1.WatchEventsRequest
+	(*Event)(nil),                 // 13: legionbatctl.v1.Event
+	(*timestamppb.Timestamp)(nil), // 14: google.protobuf.Timestamp
+}
+var file_api_legionbatctl_v1_legionbatctl_proto_depIdxs = []int32{
+	14, // 0: legionbatctl.v1.Status.reenable_at:type_name -> google.protobuf.Timestamp
+	14, // 1: legionbatctl.v1.DisableResponse.reenable_at:type_name -> google.protobuf.Timestamp
+	14, // 2: legionbatctl.v1.GetHistoryRequest.since:type_name -> google.protobuf.Timestamp
+	14, // 3: legionbatctl.v1.GetHistoryRequest.until:type_name -> google.protobuf.Timestamp
+	14, // 4: legionbatctl.v1.HistoryEntry.time:type_name -> google.protobuf.Timestamp
+	14, // 5: legionbatctl.v1.Event.time:type_name -> google.protobuf.Timestamp
+	0,  // 6: legionbatctl.v1.BatteryService.GetStatus:input_type -> legionbatctl.v1.GetStatusRequest
+	2,  // 7: legionbatctl.v1.BatteryService.Enable:input_type -> legionbatctl.v1.EnableRequest
+	4,  // 8: legionbatctl.v1.BatteryService.Disable:input_type -> legionbatctl.v1.DisableRequest
+	6,  // 9: legionbatctl.v1.BatteryService.SetThreshold:input_type -> legionbatctl.v1.SetThresholdRequest
+	8,  // 10: legionbatctl.v1.BatteryService.GetHealth:input_type -> legionbatctl.v1.GetHealthRequest
+	10, // 11: legionbatctl.v1.BatteryService.GetHistory:input_type -> legionbatctl.v1.GetHistoryRequest
+	12, // 12: legionbatctl.v1.BatteryService.WatchEvents:input_type -> legionbatctl.v1.WatchEventsRequest
+	1,  // 13: legionbatctl.v1.BatteryService.GetStatus:output_type -> legionbatctl.v1.Status
+	3,  // 14: legionbatctl.v1.BatteryService.Enable:output_type -> legionbatctl.v1.EnableResponse
+	5,  // 15: legionbatctl.v1.BatteryService.Disable:output_type -> legionbatctl.v1.DisableResponse
+	7,  // 16: legionbatctl.v1.BatteryService.SetThreshold:output_type -> legionbatctl.v1.SetThresholdResponse
+	9,  // 17: legionbatctl.v1.BatteryService.GetHealth:output_type -> legionbatctl.v1.Health
+	11, // 18: legionbatctl.v1.BatteryService.GetHistory:output_type -> legionbatctl.v1.HistoryEntry
+	13, // 19: legionbatctl.v1.BatteryService.WatchEvents:output_type -> legionbatctl.v1.Event
+	13, // [13:20] is the sub-list for method output_type
+	6,  // [6:13] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_api_legionbatctl_v1_legionbatctl_proto_init() }
+func file_api_legionbatctl_v1_legionbatctl_proto_init() {
+	if File_api_legionbatctl_v1_legionbatctl_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_legionbatctl_v1_legionbatctl_proto_rawDesc), len(file_api_legionbatctl_v1_legionbatctl_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_legionbatctl_v1_legionbatctl_proto_goTypes,
+		DependencyIndexes: file_api_legionbatctl_v1_legionbatctl_proto_depIdxs,
+		MessageInfos:      file_api_legionbatctl_v1_legionbatctl_proto_msgTypes,
+	}.Build()
+	File_api_legionbatctl_v1_legionbatctl_proto = out.File
+	file_api_legionbatctl_v1_legionbatctl_proto_goTypes = nil
+	file_api_legionbatctl_v1_legionbatctl_proto_depIdxs = nil
+}
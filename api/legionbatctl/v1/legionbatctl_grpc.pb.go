@@ -0,0 +1,371 @@
+// gRPC definition of the legionbatctl daemon API, mirroring the JSON socket
+// protocol in internal/protocol. The daemon serves it on the Unix socket set
+// by grpc.socket; the Go code next to this file is generated from it (see
+// generate.go).
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             (unknown)
+// source: api/legionbatctl/v1/legionbatctl.proto
+
+package legionbatctlv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	BatteryService_GetStatus_FullMethodName    = "/legionbatctl.v1.BatteryService/GetStatus"
+	BatteryService_Enable_FullMethodName       = "/legionbatctl.v1.BatteryService/Enable"
+	BatteryService_Disable_FullMethodName      = "/legionbatctl.v1.BatteryService/Disable"
+	BatteryService_SetThreshold_FullMethodName = "/legionbatctl.v1.BatteryService/SetThreshold"
+	BatteryService_GetHealth_FullMethodName    = "/legionbatctl.v1.BatteryService/GetHealth"
+	BatteryService_GetHistory_FullMethodName   = "/legionbatctl.v1.BatteryService/GetHistory"
+	BatteryService_WatchEvents_FullMethodName  = "/legionbatctl.v1.BatteryService/WatchEvents"
+)
+
+// BatteryServiceClient is the client API for BatteryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BatteryService controls charge management. Error codes of the socket
+// protocol map to gRPC status codes (e.g. INVALID_THRESHOLD to
+// INVALID_ARGUMENT, PERMISSION_DENIED to PERMISSION_DENIED).
+type BatteryServiceClient interface {
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error)
+	Enable(ctx context.Context, in *EnableRequest, opts ...grpc.CallOption) (*EnableResponse, error)
+	Disable(ctx context.Context, in *DisableRequest, opts ...grpc.CallOption) (*DisableResponse, error)
+	SetThreshold(ctx context.Context, in *SetThresholdRequest, opts ...grpc.CallOption) (*SetThresholdResponse, error)
+	GetHealth(ctx context.Context, in *GetHealthRequest, opts ...grpc.CallOption) (*Health, error)
+	GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HistoryEntry], error)
+	// WatchEvents streams daemon events as they happen
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type batteryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBatteryServiceClient(cc grpc.ClientConnInterface) BatteryServiceClient {
+	return &batteryServiceClient{cc}
+}
+
+func (c *batteryServiceClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, BatteryService_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *batteryServiceClient) Enable(ctx context.Context, in *EnableRequest, opts ...grpc.CallOption) (*EnableResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EnableResponse)
+	err := c.cc.Invoke(ctx, BatteryService_Enable_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *batteryServiceClient) Disable(ctx context.Context, in *DisableRequest, opts ...grpc.CallOption) (*DisableResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DisableResponse)
+	err := c.cc.Invoke(ctx, BatteryService_Disable_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *batteryServiceClient) SetThreshold(ctx context.Context, in *SetThresholdRequest, opts ...grpc.CallOption) (*SetThresholdResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetThresholdResponse)
+	err := c.cc.Invoke(ctx, BatteryService_SetThreshold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *batteryServiceClient) GetHealth(ctx context.Context, in *GetHealthRequest, opts ...grpc.CallOption) (*Health, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Health)
+	err := c.cc.Invoke(ctx, BatteryService_GetHealth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *batteryServiceClient) GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HistoryEntry], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BatteryService_ServiceDesc.Streams[0], BatteryService_GetHistory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetHistoryRequest, HistoryEntry]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BatteryService_GetHistoryClient = grpc.ServerStreamingClient[HistoryEntry]
+
+func (c *batteryServiceClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BatteryService_ServiceDesc.Streams[1], BatteryService_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BatteryService_WatchEventsClient = grpc.ServerStreamingClient[Event]
+
+// BatteryServiceServer is the server API for BatteryService service.
+// All implementations must embed UnimplementedBatteryServiceServer
+// for forward compatibility.
+//
+// BatteryService controls charge management. Error codes of the socket
+// protocol map to gRPC status codes (e.g. INVALID_THRESHOLD to
+// INVALID_ARGUMENT, PERMISSION_DENIED to PERMISSION_DENIED).
+type BatteryServiceServer interface {
+	GetStatus(context.Context, *GetStatusRequest) (*Status, error)
+	Enable(context.Context, *EnableRequest) (*EnableResponse, error)
+	Disable(context.Context, *DisableRequest) (*DisableResponse, error)
+	SetThreshold(context.Context, *SetThresholdRequest) (*SetThresholdResponse, error)
+	GetHealth(context.Context, *GetHealthRequest) (*Health, error)
+	GetHistory(*GetHistoryRequest, grpc.ServerStreamingServer[HistoryEntry]) error
+	// WatchEvents streams daemon events as they happen
+	WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedBatteryServiceServer()
+}
+
+// UnimplementedBatteryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBatteryServiceServer struct{}
+
+func (UnimplementedBatteryServiceServer) GetStatus(context.Context, *GetStatusRequest) (*Status, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedBatteryServiceServer) Enable(context.Context, *EnableRequest) (*EnableResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Enable not implemented")
+}
+func (UnimplementedBatteryServiceServer) Disable(context.Context, *DisableRequest) (*DisableResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Disable not implemented")
+}
+func (UnimplementedBatteryServiceServer) SetThreshold(context.Context, *SetThresholdRequest) (*SetThresholdResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetThreshold not implemented")
+}
+func (UnimplementedBatteryServiceServer) GetHealth(context.Context, *GetHealthRequest) (*Health, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetHealth not implemented")
+}
+func (UnimplementedBatteryServiceServer) GetHistory(*GetHistoryRequest, grpc.ServerStreamingServer[HistoryEntry]) error {
+	return status.Error(codes.Unimplemented, "method GetHistory not implemented")
+}
+func (UnimplementedBatteryServiceServer) WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Error(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedBatteryServiceServer) mustEmbedUnimplementedBatteryServiceServer() {}
+func (UnimplementedBatteryServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeBatteryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BatteryServiceServer will
+// result in compilation errors.
+type UnsafeBatteryServiceServer interface {
+	mustEmbedUnimplementedBatteryServiceServer()
+}
+
+func RegisterBatteryServiceServer(s grpc.ServiceRegistrar, srv BatteryServiceServer) {
+	// If the following call panics, it indicates UnimplementedBatteryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BatteryService_ServiceDesc, srv)
+}
+
+func _BatteryService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BatteryServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BatteryService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BatteryServiceServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BatteryService_Enable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BatteryServiceServer).Enable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BatteryService_Enable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BatteryServiceServer).Enable(ctx, req.(*EnableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BatteryService_Disable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BatteryServiceServer).Disable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BatteryService_Disable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BatteryServiceServer).Disable(ctx, req.(*DisableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BatteryService_SetThreshold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetThresholdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BatteryServiceServer).SetThreshold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BatteryService_SetThreshold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BatteryServiceServer).SetThreshold(ctx, req.(*SetThresholdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BatteryService_GetHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BatteryServiceServer).GetHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BatteryService_GetHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BatteryServiceServer).GetHealth(ctx, req.(*GetHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BatteryService_GetHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetHistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BatteryServiceServer).GetHistory(m, &grpc.GenericServerStream[GetHistoryRequest, HistoryEntry]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BatteryService_GetHistoryServer = grpc.ServerStreamingServer[HistoryEntry]
+
+func _BatteryService_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BatteryServiceServer).WatchEvents(m, &grpc.GenericServerStream[WatchEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BatteryService_WatchEventsServer = grpc.ServerStreamingServer[Event]
+
+// BatteryService_ServiceDesc is the grpc.ServiceDesc for BatteryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BatteryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "legionbatctl.v1.BatteryService",
+	HandlerType: (*BatteryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _BatteryService_GetStatus_Handler,
+		},
+		{
+			MethodName: "Enable",
+			Handler:    _BatteryService_Enable_Handler,
+		},
+		{
+			MethodName: "Disable",
+			Handler:    _BatteryService_Disable_Handler,
+		},
+		{
+			MethodName: "SetThreshold",
+			Handler:    _BatteryService_SetThreshold_Handler,
+		},
+		{
+			MethodName: "GetHealth",
+			Handler:    _BatteryService_GetHealth_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetHistory",
+			Handler:       _BatteryService_GetHistory_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _BatteryService_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/legionbatctl/v1/legionbatctl.proto",
+}
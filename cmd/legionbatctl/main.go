@@ -1,37 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/dom1nux/legionbatctl/internal/cli"
-	"github.com/dom1nux/legionbatctl/internal/daemon"
+	"github.com/dom1nux/legionbatctl/internal/cli/commands"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
 )
 
 func main() {
-	// Fast path for daemon mode - check if first argument is "daemon"
-	if len(os.Args) > 1 && os.Args[1] == "daemon" {
-		// Support environment variables for testing
-		socketPath := os.Getenv("SOCKET_PATH")
-		statePath := os.Getenv("STATE_PATH")
-
-		// Use defaults if not set (for production)
-		if socketPath == "" {
-			socketPath = "/var/run/legionbatctl.sock"
-		}
-		if statePath == "" {
-			statePath = "/etc/legionbatctl.state"
-		}
-
-		if err := daemon.RunDaemon(socketPath, statePath); err != nil {
-			fmt.Fprintf(os.Stderr, "Daemon failed: %v\n", err)
-			os.Exit(1)
-		}
-		return
-	}
-
-	// Full CLI initialization for interactive use
 	if err := cli.Run(); err != nil {
 		// Handle help/version flags specially
 		if strings.Contains(err.Error(), "help requested") ||
@@ -39,7 +19,19 @@ func main() {
 			return // These are not errors, just exit cleanly
 		}
 
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		// Command failures have already been rendered with a suggested fix
+		var reported *commands.ReportedError
+		if !errors.As(err, &reported) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if hint := protocol.ErrorHint(err); hint != "" {
+				fmt.Fprintf(os.Stderr, "Fix:   %s\n", hint)
+			}
+		}
+
+		var exit *commands.ExitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.Code)
+		}
 		os.Exit(1)
 	}
 }
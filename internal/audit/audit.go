@@ -0,0 +1,106 @@
+// Package audit keeps an append-only record of commands that changed the
+// battery management settings.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// UnknownUID marks an entry whose caller couldn't be identified
+const UnknownUID = -1
+
+// Entry records one control command
+type Entry struct {
+	Time    time.Time `json:"time"`
+	UID     int       `json:"uid"`
+	PID     int       `json:"pid,omitempty"`
+	Command string    `json:"command"`
+	Old     string    `json:"old"`             // Setting before the command
+	New     string    `json:"new"`             // Setting after the command
+	Error   string    `json:"error,omitempty"` // Why the command failed, if it did
+}
+
+// Log is an audit log backed by a JSON lines file. Entries are only ever
+// appended; the file is never rewritten or truncated.
+type Log struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// New returns the audit log at path, which is created on the first append
+func New(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the location of the log file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append records an entry and syncs it to disk
+func (l *Log) Append(entry Entry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return file.Sync()
+}
+
+// Query returns the entries within [since, until], oldest first. A zero bound
+// is open-ended. Lines that fail to parse are skipped.
+func (l *Log) Query(since, until time.Time) ([]Entry, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	file, err := os.Open(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if (!since.IsZero() && entry.Time.Before(since)) || (!until.IsZero() && entry.Time.After(until)) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return entries, nil
+}
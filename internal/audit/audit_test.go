@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogAppendQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	log := New(path)
+
+	entries, err := log.Query(time.Time{}, time.Time{})
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Expected an empty log before the first append, got %v, %v", entries, err)
+	}
+
+	base := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	appended := []Entry{
+		{Time: base, UID: 1000, PID: 42, Command: "enable", Old: "disabled", New: "enabled"},
+		{Time: base.Add(time.Hour), UID: 0, Command: "set_threshold", Old: "80%", New: "85%"},
+		{Time: base.Add(2 * time.Hour), UID: UnknownUID, Command: "disable", Old: "enabled", New: "enabled", Error: "permission denied"},
+	}
+	for _, entry := range appended {
+		if err := log.Append(entry); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	// A torn write is skipped rather than failing the whole log
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	file.WriteString(`{"time":"2026-10`)
+	file.Close()
+
+	entries, err = log.Query(base.Add(30*time.Minute), time.Time{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Command != "set_threshold" || entries[1].Error != "permission denied" {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+
+	entries, _ = log.Query(time.Time{}, base)
+	if len(entries) != 1 || entries[0].UID != 1000 || entries[0].PID != 42 {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+}
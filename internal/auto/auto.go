@@ -1,38 +1,88 @@
+// Package auto runs a single pass of battery management and exits, for
+// systemd timers and cron jobs on systems that don't run the daemon.
 package auto
 
 import (
-	"fmt"
+	"errors"
 	"os"
-	"os/signal"
-	"syscall"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/direct"
+)
+
+// Exit codes of a run, so timers and scripts can tell outcomes apart
+const (
+	ExitOK            = 0 // Conservation mode matches the settings
+	ExitFailure       = 1 // The run failed, e.g. the state file could not be written
+	ExitDaemonRunning = 2 // The daemon manages the battery, nothing was done
+	ExitHardware      = 3 // The battery could not be read or controlled
+	ExitNotRoot       = 4 // The run needs root to write sysfs and the state file
+)
+
+var (
+	// ErrNotRoot is returned when a run that changes the battery is not root
+	ErrNotRoot = errors.New("auto mode writes sysfs and the state file, run it as root")
+
+	// ErrDaemonRunning is returned when the daemon already manages the battery
+	ErrDaemonRunning = errors.New("the daemon is running and manages the battery")
 )
 
-// Run executes the auto mode logic
-func Run() error {
-	// Check if running as root
-	if os.Geteuid() != 0 {
-		return fmt.Errorf("auto mode requires root privileges")
+// Options configures a run
+type Options struct {
+	StatePath   string
+	RuntimePath string // Empty keeps the runtime state in the state file
+	ConfigPath  string
+	DryRun      bool // Report what would change without changing anything
+
+	// DaemonRunning reports whether the daemon is running; a run never
+	// competes with the daemon for the state file
+	DaemonRunning func() bool
+}
+
+// Run loads the configuration and state, reads the battery and switches
+// conservation mode the way one check of the daemon would
+func Run(opts Options) (direct.Result, error) {
+	if !opts.DryRun && os.Geteuid() != 0 {
+		return direct.Result{}, ErrNotRoot
+	}
+	if opts.DaemonRunning != nil && opts.DaemonRunning() {
+		return direct.Result{}, ErrDaemonRunning
 	}
 
-	// TODO: Implement actual auto mode logic
-	// For now, just a placeholder that shows the concept
-	fmt.Printf("Auto mode running at %s\n", getCurrentTime())
+	cfg, err := config.Load(opts.ConfigPath)
+	if err != nil {
+		return direct.Result{}, err
+	}
 
-	// Set up signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	controller, err := direct.Open(opts.StatePath, opts.RuntimePath, cfg)
+	if err != nil {
+		return direct.Result{}, err
+	}
 
-	// For now, just return immediately
-	// In the full implementation, this will:
-	// 1. Read configuration
-	// 2. Check if on AC power
-	// 3. Read battery level
-	// 4. Determine if conservation mode should be enabled/disabled
-	// 5. Apply the change if needed
+	return run(controller, opts.DryRun, time.Now())
+}
 
-	return nil
+// run makes one pass with an opened controller
+func run(controller *direct.Controller, dryRun bool, now time.Time) (direct.Result, error) {
+	if dryRun {
+		return controller.Preview()
+	}
+	return controller.Check(now)
 }
 
-func getCurrentTime() string {
-	return "placeholder_time" // TODO: Use actual time formatting
+// ExitCode returns the process exit code for the outcome of a run
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrDaemonRunning):
+		return ExitDaemonRunning
+	case errors.As(err, new(*direct.HardwareError)):
+		return ExitHardware
+	case errors.Is(err, ErrNotRoot):
+		return ExitNotRoot
+	default:
+		return ExitFailure
+	}
 }
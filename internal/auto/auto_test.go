@@ -0,0 +1,117 @@
+package auto
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/direct"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+type fakeBackend struct {
+	battery hardware.Battery
+	err     error
+}
+
+func (f *fakeBackend) Name() string { return "fake" }
+
+func (f *fakeBackend) ReadBattery() (hardware.Battery, error) { return f.battery, f.err }
+
+func (f *fakeBackend) SetConservationMode(enable bool) error {
+	f.battery.ConservationMode = enable
+	return nil
+}
+
+func (f *fakeBackend) ReadLimits() (hardware.Limits, error) { return hardware.Limits{}, nil }
+
+func (f *fakeBackend) SetLimits(limits hardware.Limits) error { return nil }
+
+func newTestController(t *testing.T, backend *fakeBackend) (*direct.Controller, *state.Manager) {
+	t.Helper()
+
+	manager := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := manager.EnableConservation(); err != nil {
+		t.Fatalf("EnableConservation() error = %v", err)
+	}
+	return direct.NewController(manager, backend), manager
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		battery      hardware.Battery
+		dryRun       bool
+		wantMode     bool
+		wantHardware bool
+	}{
+		{"switches on above threshold", hardware.Battery{Level: 85, ACOnline: true}, false, true, true},
+		{"dry run leaves hardware alone", hardware.Battery{Level: 85, ACOnline: true}, true, true, false},
+		{"on battery", hardware.Battery{Level: 85}, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{battery: tt.battery}
+			controller, _ := newTestController(t, backend)
+
+			result, err := run(controller, tt.dryRun, time.Now())
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if result.ConservationMode != tt.wantMode {
+				t.Errorf("ConservationMode = %v, want %v", result.ConservationMode, tt.wantMode)
+			}
+			if backend.battery.ConservationMode != tt.wantHardware {
+				t.Errorf("Hardware conservation mode = %v, want %v", backend.battery.ConservationMode, tt.wantHardware)
+			}
+		})
+	}
+}
+
+func TestRunReenablesAfterTemporaryDisable(t *testing.T) {
+	backend := &fakeBackend{battery: hardware.Battery{Level: 85, ACOnline: true}}
+	controller, manager := newTestController(t, backend)
+
+	now := time.Now()
+	if err := manager.DisableConservationUntil(now.Add(-time.Minute)); err != nil {
+		t.Fatalf("DisableConservationUntil() error = %v", err)
+	}
+
+	result, err := run(controller, false, now)
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !result.Managed || !backend.battery.ConservationMode {
+		t.Errorf("Expected management re-enabled and conservation mode on, got managed %v mode %v",
+			result.Managed, backend.battery.ConservationMode)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, ExitOK},
+		{"daemon running", ErrDaemonRunning, ExitDaemonRunning},
+		{"not root", ErrNotRoot, ExitNotRoot},
+		{"hardware", fmt.Errorf("run: %w", &direct.HardwareError{Op: "read battery", Err: errors.New("no battery")}), ExitHardware},
+		{"other", errors.New("failed to load state"), ExitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// NewAdaptiveCommand creates the adaptive command
+func NewAdaptiveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "adaptive",
+		Short: "Show the charge threshold learned from your usage",
+		Long: `Show the charge threshold the daemon learns from how often and how deep the
+battery is discharged, from the history of the last days. Machines that rarely
+leave AC power get the lowest threshold, mobile ones a threshold that covers
+9 in 10 of their discharges with 15% to spare.
+
+The [adaptive] section of the config file opts in: mode = "suggest" shows the
+learned threshold in the status, mode = "apply" has the daemon set it once a
+day, within min_threshold and max_threshold. --apply sets it once.`,
+		Example: `  legionbatctl adaptive
+  legionbatctl adaptive --apply`,
+		Args: cobra.NoArgs,
+		RunE: runAdaptive,
+	}
+
+	cmd.Flags().Bool("apply", false, "Set the learned threshold as the charge threshold")
+
+	return cmd
+}
+
+func runAdaptive(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteAdaptive()
+	if apply, _ := cmd.Flags().GetBool("apply"); !apply || !result.Success {
+		fmt.Print(client.FormatAdaptiveResult(result))
+		return resultError(result)
+	}
+
+	data := result.Data.(*protocol.AdaptiveData)
+	if data.Suggested == 0 {
+		return fmt.Errorf("no threshold learned yet: %s", data.Reason)
+	}
+
+	result = executor.ExecuteSetThreshold(data.Suggested)
+	printResult(cmd, result, client.FormatSetThresholdResult(result))
+
+	return resultError(result)
+}
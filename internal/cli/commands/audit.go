@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewAuditCommand creates the audit command
+func NewAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show who changed the battery settings",
+		Long: `Show the enable, disable and set-threshold commands recorded by the daemon,
+with the user who ran them and the setting before and after. Denied and
+failed attempts are recorded as well.`,
+		Example: `  legionbatctl audit
+  legionbatctl audit --since 2026-01-01`,
+		RunE: runAudit,
+	}
+
+	cmd.Flags().String("since", "30d", "Show commands from this time on (e.g. 24h, 7d, 2006-01-02)")
+	cmd.Flags().String("until", "", "Show commands up to this time (default now)")
+	registerCompletion(cmd, "since", completeValues(sinceCompletions...))
+	registerCompletion(cmd, "until", completeValues(sinceCompletions...))
+
+	return cmd
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	since, until, err := timeRangeFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteAudit(since, until)
+	fmt.Print(client.FormatAuditResult(result))
+
+	return resultError(result)
+}
@@ -1,21 +1,32 @@
 package commands
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/auto"
+	"github.com/dom1nux/legionbatctl/internal/paths"
 )
 
-// NewAutoCommand creates the auto command (for manual testing)
+// NewAutoCommand creates the auto command
 func NewAutoCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "auto",
-		Short: "Run auto mode (usually called by systemd timer)",
-		Long: `Run the automatic battery management mode. This command is typically
-called by the systemd timer every minute to check battery status and
-enable/disable conservation mode as needed.
+		Short: "Check the battery once and switch conservation mode (for timers)",
+		Long: `Run a single check of battery management and exit: read the battery,
+end an expired temporary disable or a finished charge-full, and switch
+conservation mode to match the saved threshold. It uses the daemon's state
+file (STATE_PATH, default /etc/legionbatctl.state), so it can run from a
+systemd timer or cron instead of the long-running daemon.
 
-You can also run this manually for testing purposes.`,
+Storage mode and the schedule need the daemon and are not applied.
+
+Exit codes:
+  0  conservation mode matches the settings
+  1  the run failed, e.g. the state file could not be written
+  2  the daemon is running and manages the battery, nothing was done
+  3  the battery could not be read or controlled
+  4  not run as root`,
+		Args: cobra.NoArgs,
 		RunE: runAuto,
 	}
 
@@ -26,15 +37,53 @@ You can also run this manually for testing purposes.`,
 
 func runAuto(cmd *cobra.Command, args []string) error {
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	configPath, _ := cmd.Flags().GetString("config")
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	result, err := auto.Run(auto.Options{
+		StatePath:     paths.StatePath(),
+		RuntimePath:   paths.RuntimePath(),
+		ConfigPath:    configPath,
+		DryRun:        dryRun,
+		DaemonRunning: c.IsDaemonRunning,
+	})
+	if err != nil {
+		return &ExitError{Code: auto.ExitCode(err), Err: err}
+	}
 
-	if dryRun {
-		fmt.Println("DRY RUN: Would check battery level and adjust conservation mode")
-		fmt.Println("Current state: Battery at 75%, threshold 80%, conservation disabled")
-		fmt.Println("Action: No change needed (below threshold)")
+	power := "on battery"
+	if result.Charging {
+		power = "on AC"
+	}
+	mode := "off"
+	if result.ConservationMode {
+		mode = "on"
+	}
+
+	switch {
+	case dryRun && result.Switched:
+		mode = "would switch " + mode
+	case dryRun:
+		mode = "would stay " + mode
+	case result.Switched:
+		mode = "switched " + mode
+	}
+
+	printSuccess(cmd, "Battery Level: %d%% (%s)\n", result.BatteryLevel, power)
+	if result.Managed {
+		printSuccess(cmd, "Charge Threshold: %d%% (charging resumes below %d%%)\n",
+			result.Threshold, result.StartThreshold)
 	} else {
-		// TODO: Implement actual auto mode logic
-		fmt.Println("Auto mode: Battery management check completed")
+		printSuccess(cmd, "Battery Management: disabled\n")
+	}
+	if result.Paused {
+		printSuccess(cmd, "Automation: paused\n")
 	}
+	printSuccess(cmd, "Conservation Mode: %s\n", mode)
 
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewBatteryCommand creates the battery command
+func NewBatteryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "battery",
+		Short: "Inspect the battery",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "info",
+		Short: "Show detailed battery diagnostics",
+		Long: `Show the battery's live electrical readings (voltage, current, power and
+temperature) and identification (manufacturer, model, serial number and
+technology) next to the managed thresholds. Readings the battery doesn't
+report are left out.`,
+		RunE: runBatteryInfo,
+	})
+
+	return cmd
+}
+
+func runBatteryInfo(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteBatteryInfo()
+	fmt.Print(client.FormatBatteryInfoResult(result))
+
+	return resultError(result)
+}
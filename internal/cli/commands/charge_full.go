@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewChargeFullCommand creates the charge-full command
+func NewChargeFullCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "charge-full",
+		Short: "Charge to 100% once, then restore battery management",
+		Long: `Disable conservation mode and let the battery charge to 100% once, e.g.
+before travelling. The daemon re-enables battery management automatically once
+the battery is full, even across daemon restarts, unless it was disabled
+before. Running enable or disable in the meantime cancels the override.
+
+With --by, the battery stays managed and the daemon starts charging just in
+time to be full at the given time, based on the charge rate it has observed.
+
+On a terminal it asks for confirmation first; --yes skips the question.`,
+		Example: `  legionbatctl charge-full
+  legionbatctl charge-full --by 07:30
+  legionbatctl charge-full -y`,
+		RunE: runChargeFull,
+	}
+
+	cmd.Flags().String("by", "", "Be full by this time of day (HH:MM), e.g. 07:30")
+	registerCompletion(cmd, "by", completeValues("06:00", "07:00", "07:30", "08:00"))
+	addYesFlag(cmd)
+
+	return cmd
+}
+
+func runChargeFull(cmd *cobra.Command, args []string) error {
+	var by time.Time
+	if spec, _ := cmd.Flags().GetString("by"); spec != "" {
+		var err error
+		if by, err = nextClockTime(spec, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	summary := "Conservation mode will be switched off until the battery is full; management resumes then."
+	if !by.IsZero() {
+		summary = fmt.Sprintf("The battery will charge to 100%% in time to be full by %s; management resumes then.", by.Format("15:04"))
+	}
+	if err := confirmAction(cmd, summary); err != nil {
+		return err
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteChargeFull(by)
+
+	output := client.FormatChargeFullResult(result)
+	printResult(cmd, result, output)
+
+	return resultError(result)
+}
+
+// nextClockTime returns the next occurrence of the "HH:MM" time of day after now
+func nextClockTime(spec string, now time.Time) (time.Time, error) {
+	clock, err := time.Parse("15:04", spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (expected HH:MM)", spec)
+	}
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
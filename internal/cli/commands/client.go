@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// TimeoutEnv sets the daemon request timeout when --timeout isn't given
+const TimeoutEnv = "LEGIONBATCTL_TIMEOUT"
+
+// newClient creates a daemon client with the timeout from the --timeout
+// flag, LEGIONBATCTL_TIMEOUT or the default, in that order
+func newClient(cmd *cobra.Command) (*client.Client, error) {
+	timeout, err := clientTimeout(cmd)
+	if err != nil {
+		return nil, err
+	}
+	c := client.NewClientWithTimeout("", timeout)
+
+	// Request IDs match the daemon's log lines for the same requests
+	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+		c.SetTrace(os.Stderr)
+	}
+	return c, nil
+}
+
+// clientTimeout resolves the daemon request timeout. The environment
+// variable accepts a duration ("2s", "1m") or plain seconds ("30").
+func clientTimeout(cmd *cobra.Command) (time.Duration, error) {
+	if flag := cmd.Flags().Lookup("timeout"); flag != nil && flag.Changed {
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil || timeout <= 0 {
+			return 0, fmt.Errorf("invalid --timeout %q (expected a positive duration such as 2s)", flag.Value)
+		}
+		return timeout, nil
+	}
+
+	if value := os.Getenv(TimeoutEnv); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			seconds, convErr := strconv.ParseFloat(value, 64)
+			err = convErr
+			timeout = time.Duration(seconds * float64(time.Second))
+		}
+		if err != nil || timeout <= 0 {
+			return 0, fmt.Errorf("invalid %s %q (expected a positive duration such as 2s)", TimeoutEnv, value)
+		}
+		return timeout, nil
+	}
+
+	return client.DefaultTimeout, nil
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NoColorEnv disables colored output when set to any value (no-color.org)
+const NoColorEnv = "NO_COLOR"
+
+// ConfigureColor sets up colored output from the --no-color and
+// --color-theme flags, falling back to the display preferences of the config
+// files. Colors are only used when stdout is a terminal, so piped output and
+// status bars stay plain.
+func ConfigureColor(cmd *cobra.Command) error {
+	loadDisplay(cmd)
+
+	theme := display.ColorTheme
+	if cmd.Flags().Changed("color-theme") {
+		theme, _ = cmd.Flags().GetString("color-theme")
+	}
+	if err := client.SetColorTheme(theme); err != nil {
+		return err
+	}
+
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	if noColor || !display.Color || os.Getenv(NoColorEnv) != "" || !isTerminal(os.Stdout) {
+		client.DisableColor()
+	}
+	return nil
+}
+
+// CompleteColorTheme suggests the available color themes
+func CompleteColorTheme(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return client.ThemeNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// isTerminal reports whether f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
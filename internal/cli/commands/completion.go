@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// sinceCompletions are suggested for --since and --until flags
+var sinceCompletions = []string{"6h", "24h", "7d", "30d"}
+
+// percentCompletions returns every step-th level from min to max
+func percentCompletions(min, max, step int) []string {
+	var values []string
+	for level := min; level <= max; level += step {
+		values = append(values, strconv.Itoa(level))
+	}
+	return values
+}
+
+// presetCompletions lists the threshold presets with their descriptions
+func presetCompletions() []string {
+	var values []string
+	for _, preset := range protocol.ThresholdPresets {
+		values = append(values, fmt.Sprintf("%s\t%d%%, %s", preset.Name, preset.Threshold, preset.Description))
+	}
+	return values
+}
+
+// completeValues suggests fixed values without falling back to file names
+func completeValues(values ...string) cobra.CompletionFunc {
+	return cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp)
+}
+
+// registerCompletion attaches a completion to a flag of cmd
+func registerCompletion(cmd *cobra.Command, flag string, complete cobra.CompletionFunc) {
+	cobra.CheckErr(cmd.RegisterFlagCompletionFunc(flag, complete))
+}
+
+// completeThreshold suggests common thresholds for the first argument
+func completeThreshold(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || cmd.Flags().Changed("preset") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return percentCompletions(60, 100, 5), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeGroups suggests the configured access groups that exist
+func completeGroups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var groups []string
+	for _, group := range cfg.Access.Groups {
+		if firstExistingGroup([]string{group}) != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,257 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+)
+
+// NewConfigCommand creates the config command group
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show, change and check the configuration file",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the settings and their values",
+		Long: `Lists every setting config get and config set handle, with its value from the
+configuration file or its default. Log sinks, profiles, schedule entries and
+webhooks are edited in the file.`,
+		Args: cobra.NoArgs,
+		RunE: runConfigList,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:               "get <key>",
+		Short:             "Show the value of a setting",
+		Example:           `  legionbatctl config get management.hysteresis`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE:              runConfigGet,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change a setting and reload the daemon",
+		Long: `Changes a setting in the configuration file, keeping the rest of the file and
+its comments, and makes the running daemon reload it. The change is only
+written if the resulting configuration is valid. Lists are given comma
+separated.`,
+		Example: `  sudo legionbatctl config set management.hysteresis 5
+  sudo legionbatctl config set notifications.events threshold-reached,error`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeConfigKeys,
+		RunE:              runConfigSet,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:     "export",
+		Short:   "Print the configuration file, e.g. to move it to another machine",
+		Example: `  legionbatctl config export > legionbatctl.conf`,
+		Args:    cobra.NoArgs,
+		RunE:    runConfigExport,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "import <file>",
+		Short: "Replace the configuration file and reload the daemon",
+		Long: `Replaces the configuration file with an exported one ("-" reads it from
+standard input) and makes the running daemon reload it. The file is checked
+first and rejected if it has errors; the file it replaces is kept as a
+timestamped backup next to it.`,
+		Example: `  sudo legionbatctl config import legionbatctl.conf`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runConfigImport,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate [path]",
+		Short: "Check a configuration file for errors",
+		Long: `Parses the configuration file and checks every setting: values out of range,
+settings that don't exist (e.g. misspelled) and paths that don't exist, such
+as the hooks directory or the directory of a log file. Each problem is
+reported with its line and setting, and the command exits non-zero if any
+are found.
+
+The daemon runs the same checks when it starts or reloads its configuration:
+it refuses invalid values and logs unknown settings and missing paths as
+warnings.
+
+Without a path, the file given by --config is checked.`,
+		Example: `  legionbatctl config validate
+  legionbatctl config validate ./legionbatctl.conf`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runConfigValidate,
+	})
+
+	return cmd
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	path := configPath(cmd)
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	_, problems, err := config.Check(path)
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		printSuccess(cmd, "✓ %s is valid\n", path)
+		return nil
+	}
+
+	for _, problem := range problems {
+		location := path
+		if problem.Line > 0 {
+			location = fmt.Sprintf("%s:%d", path, problem.Line)
+		}
+		kind := "error"
+		if problem.Warning {
+			kind = "warning"
+		}
+		field := ""
+		if problem.Field != "" {
+			field = problem.Field + ": "
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s: %s%v\n", location, kind, field, problem.Err)
+	}
+	return &ReportedError{Err: fmt.Errorf("%d problems in %s", len(problems), path)}
+}
+
+// configPath returns the configuration file given by --config
+func configPath(cmd *cobra.Command) string {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		return config.DefaultConfigPath
+	}
+	return path
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(configPath(cmd))
+	if err != nil {
+		return err
+	}
+
+	for _, setting := range cfg.Settings() {
+		value := setting.Value
+		// Tokens grant write access over HTTP; config get still shows them
+		if strings.HasSuffix(setting.Key, "token") && value != `""` {
+			value = `"********"`
+		}
+		fmt.Printf("%s = %s\n", setting.Key, value)
+	}
+	return nil
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(configPath(cmd))
+	if err != nil {
+		return err
+	}
+
+	value, err := cfg.Get(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	path := configPath(cmd)
+	key, value := args[0], args[1]
+	if err := config.SetInFile(path, key, value); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	written, _ := cfg.Get(key)
+	printSuccess(cmd, "✓ Set %s = %s in %s\n", key, written, path)
+
+	return reloadDaemonConfig(cmd, path)
+}
+
+func runConfigExport(cmd *cobra.Command, args []string) error {
+	path := configPath(cmd)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no configuration file at %s, the defaults are in use", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+func runConfigImport(cmd *cobra.Command, args []string) error {
+	var data []byte
+	var err error
+	if args[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+
+	path := configPath(cmd)
+	backup, err := config.Import(path, data)
+	if backup != "" {
+		printSuccess(cmd, "Backed up %s to %s\n", path, backup)
+	}
+	if err != nil {
+		return err
+	}
+	printSuccess(cmd, "✓ Imported %s to %s\n", args[0], path)
+
+	return reloadDaemonConfig(cmd, path)
+}
+
+// reloadDaemonConfig makes the running daemon reload its configuration after
+// the file at path changed
+func reloadDaemonConfig(cmd *cobra.Command, path string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	if !c.IsDaemonRunning() {
+		return nil
+	}
+	data, err := c.ReloadConfig()
+	if err != nil {
+		return fmt.Errorf("the daemon failed to reload its configuration: %w", err)
+	}
+	if data.Path != path {
+		printSuccess(cmd, "The daemon reads %s, not %s; reloaded it anyway\n", data.Path, path)
+		return nil
+	}
+	printSuccess(cmd, "✓ Daemon reloaded its configuration\n")
+	return nil
+}
+
+// completeConfigKeys suggests the setting keys for the first argument
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, setting := range config.Default().Settings() {
+		keys = append(keys, setting.Key)
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var errNotConfirmed = errors.New("cancelled, nothing was changed")
+
+// addYesFlag adds the --yes flag to a command that asks for confirmation
+func addYesFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+}
+
+// confirmAction describes the effect of a command and asks whether to go
+// ahead. It only asks on a terminal, so scripts and pipes aren't held up,
+// and not with --yes.
+func confirmAction(cmd *cobra.Command, summary string) error {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes || !isTerminal(os.Stdin) || !isTerminal(os.Stderr) {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, summary)
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+	ok, err := p.confirm("Continue?", false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNotConfirmed
+	}
+	return nil
+}
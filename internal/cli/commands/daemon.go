@@ -0,0 +1,292 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/daemon"
+	"github.com/dom1nux/legionbatctl/internal/paths"
+	"github.com/dom1nux/legionbatctl/internal/systemd"
+)
+
+// ConfigPathEnv overrides the configuration file of the daemon
+const ConfigPathEnv = "CONFIG_PATH"
+
+// errNotRoot is returned when installing without root privileges
+var errNotRoot = errors.New("installing the service requires root, try again with sudo")
+
+// NewDaemonCommand creates the daemon command. Without a subcommand it runs
+// the daemon itself.
+func NewDaemonCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the daemon or manage its systemd service",
+		Long: `Without a subcommand, runs the battery management daemon in the foreground.
+
+The state file keeps the settings and survives reboots; battery readings and
+daemon details go to the runtime file under /run instead, so they don't keep
+rewriting it. The socket, state, runtime and configuration paths fall back to
+the SOCKET_PATH, STATE_PATH, RUNTIME_PATH and CONFIG_PATH environment
+variables when their flags are not given. Run as another user than root,
+e.g. while developing, the socket and runtime file default to
+$XDG_RUNTIME_DIR and the state file to $XDG_STATE_HOME/legionbatctl. By default the check interval adapts to how close the battery is to
+the threshold; --check-interval fixes it (10s to 10m).
+
+A socket left behind by a crashed daemon is removed at startup. If the socket
+still accepts connections but nothing answers, the daemon refuses to start
+unless --force-takeover is given.
+
+--detach starts the daemon in the background instead, with its output
+appended to --log-file, writes the PID file and returns once the daemon
+answers on the socket. Stop it with: kill $(cat <pid file>)
+
+The install and uninstall subcommands manage the systemd service running it.`,
+		Args: cobra.NoArgs,
+		RunE: runDaemon,
+	}
+	defaults := paths.Defaults()
+	cmd.Flags().String("socket", defaults.Socket, "Socket path (also set by "+paths.SocketPathEnv+")")
+	cmd.Flags().String("state", defaults.State, "State file path (also set by "+paths.StatePathEnv+")")
+	cmd.Flags().String("runtime", defaults.Runtime, "Runtime state file path, e.g. under /run (also set by "+paths.RuntimePathEnv+")")
+	cmd.Flags().String("pid-file", "", "PID file path (default: legionbatctl.pid next to the socket)")
+	cmd.Flags().Duration("check-interval", 0, "Fixed battery check interval (default: adaptive)")
+	cmd.Flags().String("log-level", "", "Log level for every log sink: debug, info, warn or error (default: from the config)")
+	cmd.Flags().Bool("foreground", true, "Run in the foreground")
+	cmd.Flags().Bool("force-takeover", false, "Replace the socket of a daemon that no longer answers")
+	cmd.Flags().Bool("detach", false, "Run in the background, for inits without service supervision")
+	cmd.Flags().String("log-file", defaultDaemonLog, "File the output goes to with --detach")
+	cmd.MarkFlagsMutuallyExclusive("foreground", "detach")
+	registerCompletion(cmd, "log-level", completeValues("debug", "info", "warn", "error"))
+	registerCompletion(cmd, "check-interval", completeValues("15s", "30s", "1m", "2m"))
+
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install and start the systemd service",
+		Long: `Generates a hardened systemd unit for the daemon, installs it and enables it
+so the daemon starts now and on every boot. Use --print to review the unit
+without installing it.`,
+		RunE: runDaemonInstall,
+	}
+	addUnitFlags(installCmd)
+	installCmd.Flags().Bool("no-start", false, "Install and enable the service without starting it")
+	installCmd.Flags().Bool("print", false, "Print the unit instead of installing it")
+
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop, disable and remove the systemd service",
+		Long: `Stops and disables the daemon and removes its unit. The configuration, state
+file and history are kept.`,
+		RunE: runDaemonUninstall,
+	}
+	uninstallCmd.Flags().String("unit-dir", systemd.DefaultUnitDir, "Directory the unit was installed to")
+
+	setLogLevelCmd := &cobra.Command{
+		Use:   "set-log-level <debug|info|warn|error|reset>",
+		Short: "Change the running daemon's log level",
+		Long: `Changes the level of every log sink of the running daemon without restarting
+it, e.g. to capture debug output while diagnosing a flapping conservation
+mode. The level holds across configuration reloads until the daemon
+restarts; "reset" returns to the levels in the configuration file.`,
+		Example: `  sudo legionbatctl daemon set-log-level debug
+  legionbatctl logs -f
+  sudo legionbatctl daemon set-log-level reset`,
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"debug", "info", "warn", "error", "reset"},
+		RunE:      runDaemonSetLogLevel,
+	}
+
+	cmd.AddCommand(installCmd)
+	cmd.AddCommand(uninstallCmd)
+	cmd.AddCommand(setLogLevelCmd)
+
+	return cmd
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	checkInterval, _ := cmd.Flags().GetDuration("check-interval")
+	pidPath, _ := cmd.Flags().GetString("pid-file")
+	logLevel, _ := cmd.Flags().GetString("log-level")
+	forceTakeover, _ := cmd.Flags().GetBool("force-takeover")
+
+	opts := daemon.Options{
+		SocketPath:    flagOrEnv(cmd, "socket", paths.SocketPathEnv),
+		StatePath:     flagOrEnv(cmd, "state", paths.StatePathEnv),
+		RuntimePath:   flagOrEnv(cmd, "runtime", paths.RuntimePathEnv),
+		ConfigPath:    flagOrEnv(cmd, "config", ConfigPathEnv),
+		PIDPath:       pidPath,
+		CheckInterval: checkInterval,
+		LogLevel:      logLevel,
+		ForceTakeover: forceTakeover,
+	}
+
+	if detach, _ := cmd.Flags().GetBool("detach"); detach {
+		logPath, _ := cmd.Flags().GetString("log-file")
+		return detachDaemon(opts, logPath)
+	}
+
+	if err := daemon.Run(opts); err != nil {
+		return fmt.Errorf("daemon failed: %w", err)
+	}
+	return nil
+}
+
+func runDaemonSetLogLevel(cmd *cobra.Command, args []string) error {
+	level := args[0]
+	if level == "reset" {
+		level = ""
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteSetLogLevel(level)
+	printResult(cmd, result, client.FormatSetLogLevelResult(result))
+	return resultError(result)
+}
+
+// flagOrEnv returns a string flag if it was given, otherwise the environment
+// variable if set, otherwise the flag's default
+func flagOrEnv(cmd *cobra.Command, name, env string) string {
+	value, _ := cmd.Flags().GetString(name)
+	if cmd.Flags().Changed(name) {
+		return value
+	}
+	if fromEnv := os.Getenv(env); fromEnv != "" {
+		return fromEnv
+	}
+	return value
+}
+
+func runDaemonInstall(cmd *cobra.Command, args []string) error {
+	opts, err := unitOptions(cmd)
+	if err != nil {
+		return err
+	}
+	unit := systemd.Unit(opts)
+
+	if printOnly, _ := cmd.Flags().GetBool("print"); printOnly {
+		fmt.Print(unit)
+		return nil
+	}
+
+	if os.Geteuid() != 0 {
+		return errNotRoot
+	}
+
+	noStart, _ := cmd.Flags().GetBool("no-start")
+	return installUnit(cmd, unit, !noStart)
+}
+
+// addUnitFlags adds the flags describing the installed unit
+func addUnitFlags(cmd *cobra.Command) {
+	cmd.Flags().String("socket", paths.SystemSocketPath, "Socket path for the daemon")
+	cmd.Flags().String("state", paths.SystemStatePath, "State file path for the daemon")
+	cmd.Flags().String("runtime", paths.SystemRuntimePath, "Runtime state file path for the daemon")
+	cmd.Flags().String("binary", "", "Path of the legionbatctl binary (default: this executable)")
+	cmd.Flags().String("unit-dir", systemd.DefaultUnitDir, "Directory to install the unit to")
+}
+
+// installUnit writes the unit to --unit-dir and enables it, starting it now
+// if start is set
+func installUnit(cmd *cobra.Command, unit string, start bool) error {
+	unitDir, _ := cmd.Flags().GetString("unit-dir")
+	path := filepath.Join(unitDir, systemd.ServiceName)
+	if err := os.WriteFile(path, []byte(unit), 0644); err != nil {
+		return fmt.Errorf("failed to write unit: %w", err)
+	}
+	printSuccess(cmd, "Installed %s\n", path)
+
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+
+	enableArgs := []string{"enable", systemd.ServiceName}
+	if start {
+		enableArgs = []string{"enable", "--now", systemd.ServiceName}
+	}
+	if err := systemctl(enableArgs...); err != nil {
+		return err
+	}
+
+	printSuccess(cmd, "Enabled %s\n", systemd.ServiceName)
+	return nil
+}
+
+func runDaemonUninstall(cmd *cobra.Command, args []string) error {
+	if os.Geteuid() != 0 {
+		return errNotRoot
+	}
+
+	unitDir, _ := cmd.Flags().GetString("unit-dir")
+	path := filepath.Join(unitDir, systemd.ServiceName)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("service is not installed: %w", err)
+	}
+
+	if err := systemctl("disable", "--now", systemd.ServiceName); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove unit: %w", err)
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+
+	printSuccess(cmd, "Removed %s\n", path)
+	return nil
+}
+
+// unitOptions collects the unit settings from the flags
+func unitOptions(cmd *cobra.Command) (systemd.UnitOptions, error) {
+	socketPath, _ := cmd.Flags().GetString("socket")
+	statePath, _ := cmd.Flags().GetString("state")
+	runtimePath, _ := cmd.Flags().GetString("runtime")
+	configPath, _ := cmd.Flags().GetString("config")
+	binary, _ := cmd.Flags().GetString("binary")
+
+	if binary == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return systemd.UnitOptions{}, fmt.Errorf("failed to locate executable: %w", err)
+		}
+		binary = executable
+	}
+
+	opts := systemd.UnitOptions{
+		Binary:      binary,
+		SocketPath:  socketPath,
+		StatePath:   statePath,
+		RuntimePath: runtimePath,
+		ConfigPath:  configPath,
+	}
+	for _, path := range []*string{&opts.Binary, &opts.SocketPath, &opts.StatePath, &opts.RuntimePath, &opts.ConfigPath} {
+		abs, err := filepath.Abs(*path)
+		if err != nil {
+			return systemd.UnitOptions{}, fmt.Errorf("invalid path %q: %w", *path, err)
+		}
+		*path = abs
+	}
+
+	return opts, nil
+}
+
+// systemctl runs a systemctl command, passing its output through
+func systemctl(args ...string) error {
+	cmd := exec.Command("systemctl", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("systemctl %s failed: %w", args[0], err)
+	}
+	return nil
+}
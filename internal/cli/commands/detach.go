@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/daemon"
+)
+
+const (
+	// defaultDaemonLog is where a detached daemon writes its output
+	defaultDaemonLog = "/var/log/legionbatctl.log"
+
+	// detachStartTimeout is how long to wait for a detached daemon to listen
+	detachStartTimeout = 5 * time.Second
+)
+
+// errDetachedExited is returned when a detached daemon exits during startup
+var errDetachedExited = errors.New("the daemon exited during startup")
+
+// detachDaemon starts the daemon again as a background process in its own
+// session with its output going to logPath, writes its PID file and waits
+// until it listens on the socket
+func detachDaemon(opts daemon.Options, logPath string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the legionbatctl binary: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer logFile.Close()
+
+	process := exec.Command(executable, detachedArgs(opts)...)
+	process.Stdout = logFile
+	process.Stderr = logFile
+	process.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := process.Start(); err != nil {
+		return fmt.Errorf("failed to start the daemon: %w", err)
+	}
+
+	pidPath := opts.PIDPath
+	if pidPath == "" {
+		pidPath = daemon.PIDPathFor(opts.SocketPath)
+	}
+	pid := []byte(strconv.Itoa(process.Process.Pid) + "\n")
+	if err := os.WriteFile(pidPath, pid, 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- process.Wait() }()
+
+	if err := waitForSocket(opts.SocketPath, exited); err != nil {
+		os.Remove(pidPath)
+		return fmt.Errorf("%w (see %s): %w", errDetachedExited, logPath, err)
+	}
+
+	fmt.Printf("Daemon started in the background (PID %d), logging to %s\n", process.Process.Pid, logPath)
+	return nil
+}
+
+// detachedArgs returns the arguments that run the daemon in the foreground
+// with the resolved options
+func detachedArgs(opts daemon.Options) []string {
+	args := []string{"daemon",
+		"--socket", opts.SocketPath,
+		"--state", opts.StatePath,
+		"--runtime", opts.RuntimePath,
+		"--config", opts.ConfigPath,
+	}
+	if opts.PIDPath != "" {
+		args = append(args, "--pid-file", opts.PIDPath)
+	}
+	if opts.CheckInterval > 0 {
+		args = append(args, "--check-interval", opts.CheckInterval.String())
+	}
+	if opts.LogLevel != "" {
+		args = append(args, "--log-level", opts.LogLevel)
+	}
+	if opts.ForceTakeover {
+		args = append(args, "--force-takeover")
+	}
+	return args
+}
+
+// waitForSocket waits until the daemon accepts connections, failing if it
+// exits first or does not come up in time
+func waitForSocket(socketPath string, exited <-chan error) error {
+	deadline := time.After(detachStartTimeout)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("exit status 0")
+			}
+			return err
+		case <-deadline:
+			return fmt.Errorf("no answer on %s after %s", socketPath, detachStartTimeout)
+		case <-ticker.C:
+			if conn, err := net.Dial("unix", socketPath); err == nil {
+				conn.Close()
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/direct"
+	"github.com/dom1nux/legionbatctl/internal/paths"
+)
+
+var (
+	errDirectNotRoot = errors.New("--direct writes sysfs and the state file, run it as root")
+	errDaemonRunning = errors.New("the daemon is running, run the command without --direct")
+)
+
+// addDirectFlag adds the --direct flag to a command that changes settings
+func addDirectFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("direct", false, "Apply the change without the daemon (requires root, daemon stopped)")
+}
+
+// isDirect reports whether --direct was given
+func isDirect(cmd *cobra.Command) bool {
+	direct, _ := cmd.Flags().GetBool("direct")
+	return direct
+}
+
+// openDirect prepares a change without the daemon. It refuses while the
+// daemon runs, as the daemon would overwrite the state file.
+func openDirect(cmd *cobra.Command) (*direct.Controller, error) {
+	if os.Geteuid() != 0 {
+		return nil, errDirectNotRoot
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsDaemonRunning() {
+		return nil, errDaemonRunning
+	}
+
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return direct.Open(paths.StatePath(), paths.RuntimePath(), cfg)
+}
+
+// printDirectResult prints the outcome of a change made without the daemon
+func printDirectResult(cmd *cobra.Command, summary string, result direct.Result) {
+	power := "on battery"
+	if result.Charging {
+		power = "on AC"
+	}
+	mode := "off"
+	if result.ConservationMode {
+		mode = "on"
+	}
+	if result.Switched {
+		mode = "switched " + mode
+	}
+
+	printSuccess(cmd, "✓ %s (direct, without the daemon).\n", summary)
+	printSuccess(cmd, "  Battery Level: %d%% (%s)\n", result.BatteryLevel, power)
+	if result.Managed {
+		printSuccess(cmd, "  Charge Threshold: %d%% (charging resumes below %d%%)\n",
+			result.Threshold, result.StartThreshold)
+	}
+	printSuccess(cmd, "  Conservation Mode: %s\n", mode)
+}
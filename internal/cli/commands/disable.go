@@ -2,9 +2,12 @@ package commands
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
+
 	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
 )
 
 // NewDisableCommand creates the disable command
@@ -14,30 +17,81 @@ func NewDisableCommand() *cobra.Command {
 		Short: "Disable battery management (allow charging to 100%)",
 		Long: `Disable battery management, allowing the battery to charge to 100%.
 This disables the automatic threshold management and allows normal
-charging behavior.`,
+charging behavior.
+
+With --for, the daemon re-enables battery management automatically once the
+duration has elapsed, even across daemon restarts.
+
+On a terminal it asks for confirmation first; --yes skips the question.`,
+		Example: `  legionbatctl disable
+  legionbatctl disable --for 2h
+  legionbatctl disable --yes`,
 		RunE: runDisable,
 	}
 
+	cmd.Flags().Duration("for", 0, "Re-enable battery management after this duration (e.g. 90m, 2h)")
+	registerCompletion(cmd, "for", completeValues("30m", "1h", "2h", "4h", "8h"))
+	addDirectFlag(cmd)
+	addDryRunFlag(cmd)
+	addYesFlag(cmd)
+
 	return cmd
 }
 
 func runDisable(cmd *cobra.Command, args []string) error {
+	forDuration, _ := cmd.Flags().GetDuration("for")
+	if cmd.Flags().Changed("for") && forDuration <= 0 {
+		return fmt.Errorf("--for must be a positive duration")
+	}
+
+	if isDryRun(cmd) {
+		var params map[string]interface{}
+		if forDuration > 0 {
+			params = map[string]interface{}{"for": forDuration.String()}
+		}
+		return runDryRun(cmd, protocol.CmdDisable, params)
+	}
+
+	summary := "Battery management will be disabled and the battery will charge to 100%."
+	if forDuration > 0 {
+		summary = fmt.Sprintf("Battery management will be disabled until %s and the battery will charge to 100%% meanwhile.",
+			time.Now().Add(forDuration).Format("15:04"))
+	}
+	if err := confirmAction(cmd, summary); err != nil {
+		return err
+	}
+
+	if isDirect(cmd) {
+		if cmd.Flags().Changed("for") {
+			return fmt.Errorf("--for needs the daemon to re-enable management, it can't be used with --direct")
+		}
+		controller, err := openDirect(cmd)
+		if err != nil {
+			return err
+		}
+		result, err := controller.Disable()
+		if err != nil {
+			return err
+		}
+		printDirectResult(cmd, "Battery management disabled", result)
+		return nil
+	}
+
 	// Create client with default socket path
-	c := client.NewClient("")
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
 
 	// Create command executor
 	executor := client.NewCommandExecutor(c)
 
 	// Execute disable command
-	result := executor.ExecuteDisable()
+	result := executor.ExecuteDisable(forDuration)
 
 	// Format and output result
 	output := client.FormatDisableResult(result)
-	fmt.Print(output)
-
-	if !result.Success {
-		return fmt.Errorf(result.Error)
-	}
+	printResult(cmd, result, output)
 
-	return nil
+	return resultError(result)
 }
\ No newline at end of file
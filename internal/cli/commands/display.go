@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/config"
+)
+
+// display holds the display preferences of the system config and the user's
+// config overlay, loaded before every command
+var display = config.Default().Display
+
+// loadDisplay loads the display preferences. Problems with the system config
+// are left to the daemon and config validate to report; problems with the
+// user's overlay are reported, and it is ignored.
+func loadDisplay(cmd *cobra.Command) {
+	cfg, err := config.Load(configPath(cmd))
+	if err != nil {
+		cfg = config.Default()
+	}
+
+	if err := cfg.ApplyOverlay(config.UserConfigPath()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring user config: %v\n", err)
+	}
+	display = cfg.Display
+}
+
+// addIconsFlag adds the --icons flag to a command printing status glyphs
+func addIconsFlag(cmd *cobra.Command) {
+	cmd.Flags().String("icons", "", "Icon theme: ascii, unicode or nerd-font (default from the config, unicode)")
+	registerCompletion(cmd, "icons", completeValues(client.IconThemeNames()...))
+}
+
+// iconGlyphs returns the glyphs of the icon theme chosen with --icons or in
+// the display preferences
+func iconGlyphs(cmd *cobra.Command) (client.ShortGlyphs, error) {
+	name := display.Icons
+	if cmd.Flags().Changed("icons") {
+		name, _ = cmd.Flags().GetString("icons")
+	}
+	if name == "" {
+		name = client.DefaultIconTheme
+	}
+	return client.IconTheme(name)
+}
@@ -1,12 +1,17 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
+
 	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
 )
 
+var errEnableThresholdDryRun = errors.New("--threshold can't be combined with --dry-run, preview it with: legionbatctl set-threshold --dry-run")
+
 // NewEnableCommand creates the enable command
 func NewEnableCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -14,30 +19,74 @@ func NewEnableCommand() *cobra.Command {
 		Short: "Enable battery management (limit to configured threshold)",
 		Long: `Enable battery management, which will limit charging to the configured
 threshold by using conservation mode. When enabled, the system will stop
-charging the battery once it reaches the configured threshold.`,
+charging the battery once it reaches the configured threshold.
+
+Use --threshold to set the threshold at the same time. Both changes are made
+in a single request, so management never runs with the previous threshold.`,
+		Example: `  legionbatctl enable
+  legionbatctl enable --threshold 80`,
 		RunE: runEnable,
 	}
 
+	cmd.Flags().Int("threshold", 0, "Also set the charge threshold (60-100, or 40-100 with a native end threshold)")
+	registerCompletion(cmd, "threshold", completeValues(percentCompletions(60, 100, 5)...))
+	addDirectFlag(cmd)
+	addDryRunFlag(cmd)
+
 	return cmd
 }
 
 func runEnable(cmd *cobra.Command, args []string) error {
+	withThreshold := cmd.Flags().Changed("threshold")
+	threshold, _ := cmd.Flags().GetInt("threshold")
+
+	if isDryRun(cmd) {
+		if withThreshold {
+			return errEnableThresholdDryRun
+		}
+		return runDryRun(cmd, protocol.CmdEnable, nil)
+	}
+
+	if isDirect(cmd) {
+		controller, err := openDirect(cmd)
+		if err != nil {
+			return err
+		}
+		summary := "Battery management enabled"
+		if withThreshold {
+			if _, err := controller.SetThreshold(threshold, 0); err != nil {
+				return err
+			}
+			summary = fmt.Sprintf("Battery management enabled with a threshold of %d%%", threshold)
+		}
+		result, err := controller.Enable()
+		if err != nil {
+			return err
+		}
+		printDirectResult(cmd, summary, result)
+		return nil
+	}
+
 	// Create client with default socket path
-	c := client.NewClient("")
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
 
 	// Create command executor
 	executor := client.NewCommandExecutor(c)
 
-	// Execute enable command
-	result := executor.ExecuteEnable()
+	// Execute enable command, setting the threshold in the same request
+	var result *client.CommandResult
+	if withThreshold {
+		result = executor.ExecuteEnableWithThreshold(threshold)
+	} else {
+		result = executor.ExecuteEnable()
+	}
 
 	// Format and output result
 	output := client.FormatEnableResult(result)
-	fmt.Print(output)
-
-	if !result.Success {
-		return fmt.Errorf(result.Error)
-	}
+	printResult(cmd, result, output)
 
-	return nil
+	return resultError(result)
 }
\ No newline at end of file
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// ReportedError wraps a command failure whose details were already rendered
+// to the user, so the caller only needs to set the exit status
+type ReportedError struct {
+	Err error
+}
+
+func (e *ReportedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ReportedError) Unwrap() error {
+	return e.Err
+}
+
+// ExitError sets a specific exit status for commands that document their
+// exit codes
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// resultError returns the error for a command result, or nil on success
+func resultError(result *client.CommandResult) error {
+	if result.Success {
+		return nil
+	}
+	return &ReportedError{Err: errors.New(result.Error)}
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewExplainCommand creates the explain command
+func NewExplainCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain",
+		Short: "Explain why conservation mode is currently on or off",
+		Long: `Walks through the checks the daemon makes before switching conservation
+mode: whether management is enabled, whether AC is connected, and where the
+battery level is compared to the threshold and the level charging resumes
+below (the threshold minus the hysteresis, or the --start threshold).
+
+Useful when the battery keeps charging, or doesn't, against expectations.`,
+		Args: cobra.NoArgs,
+		RunE: runExplain,
+	}
+
+	return cmd
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteExplain()
+	fmt.Print(client.FormatExplainResult(result))
+
+	return resultError(result)
+}
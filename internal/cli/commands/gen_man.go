@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/manpage"
+	"github.com/dom1nux/legionbatctl/pkg/version"
+)
+
+// NewGenManCommand creates the hidden gen-man command used when packaging
+func NewGenManCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "gen-man",
+		Short:  "Generate man pages for all commands",
+		Hidden: true,
+		Long: `Writes a man page for every command to --dir. The page date is taken from
+SOURCE_DATE_EPOCH when set, for reproducible builds.`,
+		Args: cobra.NoArgs,
+		RunE: runGenMan,
+	}
+
+	cmd.Flags().String("dir", "man", "Directory to write the pages to")
+
+	return cmd
+}
+
+func runGenMan(cmd *cobra.Command, args []string) error {
+	dir, _ := cmd.Flags().GetString("dir")
+
+	date := time.Now()
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid SOURCE_DATE_EPOCH: %s", epoch)
+		}
+		date = time.Unix(seconds, 0).UTC()
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	header := manpage.Header{
+		Section: "1",
+		Date:    date,
+		Source:  "legionbatctl " + version.GetVersionInfo().Version,
+		Manual:  "legionbatctl Manual",
+	}
+	if err := manpage.GenerateTree(cmd.Root(), dir, header); err != nil {
+		return err
+	}
+
+	printSuccess(cmd, "Wrote man pages to %s\n", dir)
+	return nil
+}
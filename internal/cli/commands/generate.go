@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/paths"
+	"github.com/dom1nux/legionbatctl/internal/udev"
+)
+
+// errNoGroup is returned when none of the configured access groups exist
+var errNoGroup = errors.New("none of the configured access groups exist, pass --group")
+
+// NewGenerateCommand creates the generate command
+func NewGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate system integration files",
+	}
+
+	rulesCmd := &cobra.Command{
+		Use:   "udev-rules",
+		Short: "Generate udev rules for the charge controls",
+		Long: `Generates udev rules that give a group write access to the charge control
+sysfs attributes, so its members can change them without the daemon, and
+that make the daemon re-check the battery as soon as AC is plugged in or
+unplugged.
+
+The group defaults to the first existing group from access.groups in the
+configuration file. Install the rules with:
+
+  legionbatctl generate udev-rules --output ` + udev.DefaultRulesPath + `
+  udevadm control --reload && udevadm trigger`,
+		RunE: runGenerateUdevRules,
+	}
+	rulesCmd.Flags().String("group", "", "Group granted write access (default: from access.groups)")
+	rulesCmd.Flags().String("socket", paths.SystemSocketPath, "Socket path of the daemon, its PID file is next to it")
+	rulesCmd.Flags().StringP("output", "o", "", "Write the rules to a file instead of stdout")
+	registerCompletion(rulesCmd, "group", completeGroups)
+
+	cmd.AddCommand(rulesCmd)
+
+	return cmd
+}
+
+func runGenerateUdevRules(cmd *cobra.Command, args []string) error {
+	group, _ := cmd.Flags().GetString("group")
+	socketPath, _ := cmd.Flags().GetString("socket")
+	output, _ := cmd.Flags().GetString("output")
+
+	if group == "" {
+		configPath, _ := cmd.Flags().GetString("config")
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		if group = firstExistingGroup(cfg.Access.Groups); group == "" {
+			return errNoGroup
+		}
+	}
+
+	rules := udev.Rules(udev.RulesOptions{
+		Group:   group,
+		PIDPath: filepath.Join(filepath.Dir(socketPath), "legionbatctl.pid"),
+	})
+
+	if output == "" {
+		fmt.Print(rules)
+		return nil
+	}
+
+	if err := os.WriteFile(output, []byte(rules), 0644); err != nil {
+		return fmt.Errorf("failed to write rules: %w", err)
+	}
+	printSuccess(cmd, "Wrote %s\n", output)
+	return nil
+}
+
+// firstExistingGroup returns the first group that exists on the system
+func firstExistingGroup(groups []string) string {
+	for _, name := range groups {
+		if _, err := user.LookupGroup(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// NewGraphCommand creates the graph command
+func NewGraphCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Chart the battery level and threshold over time",
+		Long: `Draw the battery level recorded by the daemon as a chart in the terminal,
+with the charge threshold marked as a dotted line. Periods without history,
+e.g. while the system was suspended, are left blank.`,
+		RunE: runGraph,
+	}
+
+	cmd.Flags().String("since", "24h", "Chart from this time on (e.g. 6h, 7d, 2006-01-02)")
+	registerCompletion(cmd, "since", completeValues(sinceCompletions...))
+	cmd.Flags().Int("width", 72, "Chart width in columns")
+	cmd.Flags().Int("height", 10, "Chart height in rows")
+
+	return cmd
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	since, _, err := timeRangeFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	width, _ := cmd.Flags().GetInt("width")
+	height, _ := cmd.Flags().GetInt("height")
+	if width < 10 || height < 2 {
+		return fmt.Errorf("chart must be at least 10 columns wide and 2 rows high")
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	until := time.Now()
+	result := executor.ExecuteHistory(since, until)
+	if !result.Success {
+		fmt.Print(client.FormatHistoryResult(result))
+		return resultError(result)
+	}
+
+	if data, ok := result.Data.(*protocol.HistoryData); ok {
+		fmt.Print(client.RenderGraph(data.Entries, since, until, width, height))
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewHealthCommand creates the health command
+func NewHealthCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "health",
+		Short: "Show battery wear and cycle count",
+		Long: `Show the battery's full charge capacity compared to its design capacity,
+the resulting wear, and the cycle count where the battery reports it.
+
+Health is flagged once the wear reaches the level configured with
+wear_warning in the [health] section of the config file (default 20%).
+
+--trend adds the capacity fade over the weeks and months recorded in the
+history, with a projection of when the wear reaches wear_warning at the
+current rate.`,
+		RunE: runHealth,
+	}
+
+	cmd.Flags().Bool("trend", false, "Show the capacity fade over time")
+
+	return cmd
+}
+
+func runHealth(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	trend, _ := cmd.Flags().GetBool("trend")
+	result := executor.ExecuteHealth(trend)
+	fmt.Print(client.FormatHealthResult(result))
+
+	return resultError(result)
+}
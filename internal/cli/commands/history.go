@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// NewHistoryCommand creates the history command
+func NewHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show recorded battery samples and conservation toggles",
+		Long: `Show the battery history recorded by the daemon: a sample whenever the
+level, power source or conservation mode changes (and at least every five
+minutes), plus every conservation mode toggle.
+
+--since and --until take a duration before now (90m, 24h, 7d) or a local
+date and time (2006-01-02 or "2006-01-02 15:04").`,
+		RunE: runHistory,
+	}
+
+	cmd.Flags().String("since", "24h", "Show entries from this time on")
+	cmd.Flags().String("until", "", "Show entries up to this time (default now)")
+	registerCompletion(cmd, "since", completeValues(sinceCompletions...))
+	registerCompletion(cmd, "until", completeValues(sinceCompletions...))
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the battery history as CSV or JSON lines",
+		Long: `Export the battery history for analysis in spreadsheets or scripts. CSV
+output has a header row; JSON lines output has one entry per line with the
+same fields as the daemon's history file. Times are in RFC 3339.`,
+		Args: cobra.NoArgs,
+		RunE: runHistoryExport,
+	}
+	exportCmd.Flags().String("format", "csv", "Output format (csv or jsonl)")
+	exportCmd.Flags().StringP("out", "o", "-", "Output file, - for stdout")
+	exportCmd.Flags().String("since", "", "Export entries from this time on (default all)")
+	exportCmd.Flags().String("until", "", "Export entries up to this time (default now)")
+	registerCompletion(exportCmd, "format", completeValues("csv", "jsonl"))
+	registerCompletion(exportCmd, "since", completeValues(sinceCompletions...))
+	registerCompletion(exportCmd, "until", completeValues(sinceCompletions...))
+
+	cmd.AddCommand(exportCmd)
+
+	return cmd
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	since, until, err := timeRangeFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteHistory(since, until)
+	fmt.Print(client.FormatHistoryResult(result))
+
+	return resultError(result)
+}
+
+func runHistoryExport(cmd *cobra.Command, args []string) error {
+	since, until, err := timeRangeFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("format")
+	if !slices.Contains(client.ExportFormats, format) {
+		return fmt.Errorf("unsupported format %q (expected csv or jsonl)", format)
+	}
+
+	// Exports can be large, so have truncated responses detected
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	c.SetFraming(protocol.FramingLength)
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteHistory(since, until)
+	if !result.Success {
+		fmt.Print(client.FormatHistoryResult(result))
+		return resultError(result)
+	}
+	data, ok := result.Data.(*protocol.HistoryData)
+	if !ok {
+		return fmt.Errorf("unexpected history response")
+	}
+
+	out, _ := cmd.Flags().GetString("out")
+	if out == "-" {
+		return client.ExportHistory(os.Stdout, data.Entries, format)
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", out, err)
+	}
+	if err := client.ExportHistory(file, data.Entries, format); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d entries to %s\n", len(data.Entries), out)
+	return nil
+}
+
+// timeRangeFlags parses the --since and --until flags; an empty value leaves
+// that end of the range open
+func timeRangeFlags(cmd *cobra.Command) (time.Time, time.Time, error) {
+	now := time.Now()
+
+	var since, until time.Time
+	if spec, _ := cmd.Flags().GetString("since"); spec != "" {
+		t, err := client.ParseTimeSpec(spec, now)
+		if err != nil {
+			return since, until, fmt.Errorf("--since: %w", err)
+		}
+		since = t
+	}
+	if cmd.Flags().Lookup("until") != nil {
+		if spec, _ := cmd.Flags().GetString("until"); spec != "" {
+			t, err := client.ParseTimeSpec(spec, now)
+			if err != nil {
+				return since, until, fmt.Errorf("--until: %w", err)
+			}
+			until = t
+		}
+	}
+
+	return since, until, nil
+}
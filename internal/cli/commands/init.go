@@ -0,0 +1,302 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/schedule"
+	"github.com/dom1nux/legionbatctl/internal/systemd"
+)
+
+var (
+	errInitNotRoot         = errors.New("setting up legionbatctl requires root, try again with sudo")
+	errUnsupportedHardware = errors.New("no conservation mode control found, this laptop is not supported (is the ideapad_acpi module loaded?)")
+)
+
+// NewInitCommand creates the init command
+func NewInitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Set up battery management in one go",
+		Long: `Walks through setting up legionbatctl: checks that the laptop's charge
+controls are present, asks for a charge threshold, an optional daily window
+charging to 100% and desktop notifications, writes the configuration file,
+installs and starts the systemd service and enables management.
+
+--yes accepts the suggested answers without asking. An existing configuration
+file is only replaced with --force.`,
+		Example: `  sudo legionbatctl init
+  sudo legionbatctl init --yes --threshold balanced`,
+		Args: cobra.NoArgs,
+		RunE: runInit,
+	}
+
+	cmd.Flags().BoolP("yes", "y", false, "Accept the suggested answers without asking")
+	cmd.Flags().String("threshold", "80", "Suggested charge threshold, a percentage or preset")
+	cmd.Flags().Bool("force", false, "Replace an existing configuration file")
+	cmd.Flags().Bool("no-service", false, "Only write the configuration file, don't install the service")
+	addUnitFlags(cmd)
+	registerCompletion(cmd, "threshold", completeValues(presetCompletions()...))
+
+	return cmd
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	if os.Geteuid() != 0 {
+		return errInitNotRoot
+	}
+
+	configPath, _ := cmd.Flags().GetString("config")
+	if configPath == "" {
+		configPath = config.DefaultConfigPath
+	}
+	if force, _ := cmd.Flags().GetBool("force"); !force {
+		if _, err := os.Stat(configPath); err == nil {
+			return fmt.Errorf("%s already exists, pass --force to replace it", configPath)
+		}
+	}
+
+	yes, _ := cmd.Flags().GetBool("yes")
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout, yes: yes}
+
+	// Hardware
+	detection := hardware.Detect(hardware.DefaultPaths.WithRoot(hardware.SysfsRoot("")))
+	printDetection(detection)
+	if !detection.Supported() {
+		return errUnsupportedHardware
+	}
+
+	// Questions
+	suggested, _ := cmd.Flags().GetString("threshold")
+	threshold, err := askThreshold(p, suggested, detection.ThresholdRange())
+	if err != nil {
+		return err
+	}
+	opts, err := askStarterOptions(p)
+	if err != nil {
+		return err
+	}
+
+	// Configuration
+	if err := os.WriteFile(configPath, []byte(config.Starter(opts)), 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if _, err := config.Load(configPath); err != nil {
+		return err
+	}
+	printSuccess(cmd, "Wrote %s\n", configPath)
+
+	if noService, _ := cmd.Flags().GetBool("no-service"); noService {
+		printSuccess(cmd, "Start the daemon, then run: legionbatctl enable --threshold %d\n", threshold)
+		return nil
+	}
+
+	// Service
+	unitOpts, err := unitOptions(cmd)
+	if err != nil {
+		return err
+	}
+	if err := installUnit(cmd, systemd.Unit(unitOpts), true); err != nil {
+		return err
+	}
+	if err := waitForSocket(unitOpts.SocketPath, nil); err != nil {
+		return fmt.Errorf("daemon did not start: %w", err)
+	}
+
+	// Management
+	c := client.NewClient(unitOpts.SocketPath)
+	if err := c.EnableWithThreshold(threshold); err != nil {
+		return fmt.Errorf("failed to enable battery management: %w", err)
+	}
+	printSuccess(cmd, "✓ Battery management enabled, charging stops at %d%%\n", threshold)
+	return nil
+}
+
+// printDetection lists the charge controls found
+func printDetection(detection hardware.Detection) {
+	found := func(present bool) string {
+		if present {
+			return "found"
+		}
+		return "not found"
+	}
+
+	fmt.Println("Hardware:")
+	battery := found(detection.Battery)
+	if detection.BatteryModel != "" {
+		battery += " (" + detection.BatteryModel + ")"
+	}
+	fmt.Printf("  Battery:           %s\n", battery)
+	fmt.Printf("  AC adapter:        %s\n", found(detection.ACAdapter))
+	fmt.Printf("  Conservation mode: %s\n", found(detection.ConservationMode))
+	fmt.Printf("  Rapid charge:      %s\n", found(detection.RapidCharge))
+	fmt.Printf("  Platform profile:  %s\n", found(detection.PlatformProfile))
+	fmt.Printf("  Force discharge:   %s\n", found(detection.ForceDischarge))
+	fmt.Printf("  End threshold:     %s\n", found(detection.EndThreshold))
+	fmt.Println()
+}
+
+// askThreshold asks for the charge threshold until a valid one is given
+func askThreshold(p *prompter, suggested string, bounds hardware.ThresholdRange) (int, error) {
+	names := strings.Join(presetNames(), ", ")
+	for {
+		answer, err := p.ask(fmt.Sprintf("Charge threshold (%s, or %s)", protocol.ThresholdRangeData(bounds), names), suggested)
+		if err != nil {
+			return 0, err
+		}
+		threshold, err := parseThreshold(answer, bounds)
+		if err == nil {
+			return threshold, nil
+		}
+		if p.yes {
+			return 0, err
+		}
+		fmt.Fprintf(p.out, "  %v\n", err)
+	}
+}
+
+// parseThreshold reads a threshold given as a percentage or preset name,
+// within the range the hardware can hold
+func parseThreshold(value string, bounds hardware.ThresholdRange) (int, error) {
+	if preset, ok := protocol.FindThresholdPreset(value); ok {
+		return preset.Threshold, nil
+	}
+	threshold, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid threshold %q, use a percentage or one of: %s", value, strings.Join(presetNames(), ", "))
+	}
+	if err := protocol.ValidateThresholdRange(threshold, protocol.ThresholdRangeData(bounds)); err != nil {
+		return 0, err
+	}
+	return threshold, nil
+}
+
+// askStarterOptions asks for the schedule and notification settings
+func askStarterOptions(p *prompter) (config.StarterOptions, error) {
+	var opts config.StarterOptions
+
+	full, err := p.confirm("Charge to 100% during a daily time window, e.g. before leaving in the morning?", false)
+	if err != nil {
+		return opts, err
+	}
+	if full {
+		entry, err := askWindow(p)
+		if err != nil {
+			return opts, err
+		}
+		opts.Schedule = append(opts.Schedule, entry)
+	}
+
+	opts.Notifications, err = p.confirm("Show desktop notifications, e.g. when the threshold is reached?", false)
+	if err != nil {
+		return opts, err
+	}
+	if opts.Notifications {
+		// sudo keeps the name of the user who ran it
+		opts.NotifyUser, err = p.ask("Desktop user to notify", os.Getenv("SUDO_USER"))
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
+
+// askWindow asks for the days and times of the full charge window until
+// they parse
+func askWindow(p *prompter) (config.ScheduleConfig, error) {
+	for {
+		days, err := p.ask("  Days (e.g. mon-fri, weekends, daily)", "mon-fri")
+		if err != nil {
+			return config.ScheduleConfig{}, err
+		}
+		window, err := p.ask("  Time window", "06:00-08:00")
+		if err != nil {
+			return config.ScheduleConfig{}, err
+		}
+
+		entry := config.ScheduleConfig{Name: "full-charge", Threshold: 100}
+		if days != "daily" {
+			entry.Days = []string{days}
+		}
+		entry.From, entry.To, _ = strings.Cut(window, "-")
+		_, err = schedule.ParseWindow(entry.Days, entry.From, entry.To)
+		if err == nil {
+			return entry, nil
+		}
+		if p.yes {
+			return config.ScheduleConfig{}, err
+		}
+		fmt.Fprintf(p.out, "  %v\n", err)
+	}
+}
+
+// prompter asks questions on the terminal, offering a suggested answer that
+// an empty reply accepts
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	yes bool // Accept every suggested answer without asking
+}
+
+// ask asks a question and returns the answer, or the suggestion if the
+// reply is empty
+func (p *prompter) ask(question, suggested string) (string, error) {
+	if suggested != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, suggested)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	if p.yes {
+		fmt.Fprintln(p.out, suggested)
+		return suggested, nil
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		fmt.Fprintln(p.out)
+		return "", fmt.Errorf("no answer, pass --yes to accept the suggested answers: %w", err)
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return suggested, nil
+}
+
+// confirm asks a yes or no question
+func (p *prompter) confirm(question string, suggested bool) (bool, error) {
+	choices, answer := "y/N", "n"
+	if suggested {
+		choices, answer = "Y/n", "y"
+	}
+	if p.yes {
+		fmt.Fprintf(p.out, "%s (%s): %s\n", question, choices, answer)
+		return suggested, nil
+	}
+
+	for {
+		answer, err := p.ask(question+" ("+choices+")", "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return suggested, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
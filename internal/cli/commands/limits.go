@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewLimitsCommand creates the limits command
+func NewLimitsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "limits",
+		Short: "Show all charge-related hardware controls",
+		Long: `Show every charge-related control supported by the hardware backend in one
+table: conservation mode, start/end charge thresholds, rapid charge and the
+charge rate. Controls the hardware does not expose are shown as unsupported.
+
+Use "limits set" to change one or more controls at once.`,
+		RunE: runLimits,
+	}
+
+	cmd.AddCommand(newLimitsSetCommand())
+
+	return cmd
+}
+
+func newLimitsSetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Change charge-related hardware controls",
+		Long: `Change one or more charge-related hardware controls. Only the given flags
+are applied. Note that while battery management is enabled, the daemon keeps
+switching conservation mode according to the configured threshold.`,
+		Example: `  legionbatctl limits set --start 40 --end 80
+  legionbatctl limits set --rapid-charge off --charge-type Standard`,
+		RunE: runLimitsSet,
+	}
+
+	cmd.Flags().String("conservation", "", "Conservation mode: on or off")
+	cmd.Flags().Int("start", 0, "Start charging below this level (0-100)")
+	cmd.Flags().Int("end", 0, "Stop charging at this level (0-100)")
+	cmd.Flags().String("rapid-charge", "", "Rapid charge: on or off")
+	cmd.Flags().String("charge-type", "", "Charge rate (e.g. Standard, Fast, Trickle)")
+	registerCompletion(cmd, "conservation", completeValues("on", "off"))
+	registerCompletion(cmd, "start", completeValues(percentCompletions(40, 95, 5)...))
+	registerCompletion(cmd, "end", completeValues(percentCompletions(60, 100, 5)...))
+	registerCompletion(cmd, "rapid-charge", completeValues("on", "off"))
+	registerCompletion(cmd, "charge-type", completeValues("Standard", "Fast", "Trickle"))
+
+	return cmd
+}
+
+func runLimits(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteGetLimits()
+	fmt.Print(client.FormatLimitsResult(result))
+
+	return resultError(result)
+}
+
+func runLimitsSet(cmd *cobra.Command, args []string) error {
+	changes := map[string]interface{}{}
+	flags := cmd.Flags()
+
+	for flag, key := range map[string]string{"conservation": "conservation_mode", "rapid-charge": "rapid_charge"} {
+		if !flags.Changed(flag) {
+			continue
+		}
+		value, _ := flags.GetString(flag)
+		enabled, err := parseOnOff(value)
+		if err != nil {
+			return fmt.Errorf("--%s: %w", flag, err)
+		}
+		changes[key] = enabled
+	}
+
+	for flag, key := range map[string]string{"start": "start_threshold", "end": "end_threshold"} {
+		if flags.Changed(flag) {
+			value, _ := flags.GetInt(flag)
+			changes[key] = value
+		}
+	}
+
+	if flags.Changed("charge-type") {
+		value, _ := flags.GetString("charge-type")
+		changes["charge_type"] = value
+	}
+
+	if len(changes) == 0 {
+		return fmt.Errorf("no limits given, see 'legionbatctl limits set --help'")
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteSetLimits(changes)
+	printResult(cmd, result, client.FormatLimitsResult(result))
+
+	return resultError(result)
+}
+
+// parseOnOff parses an on/off flag value
+func parseOnOff(value string) (bool, error) {
+	switch value {
+	case "on", "true", "1":
+		return true, nil
+	case "off", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected on or off, got %q", value)
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// logsFollowInterval is how often logs --follow asks the daemon for new lines
+const logsFollowInterval = time.Second
+
+// NewLogsCommand creates the logs command
+func NewLogsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show the daemon's recent log",
+		Long: `Show the most recent lines of the daemon's log, which the daemon keeps in
+memory at the level of its most verbose log sink. Unlike journalctl this
+needs no special permissions. With --follow new lines are printed as they
+are logged; press Ctrl+C to stop.`,
+		Example: `  legionbatctl logs
+  legionbatctl logs -n 200
+  legionbatctl logs -f`,
+		Args: cobra.NoArgs,
+		RunE: runLogs,
+	}
+
+	cmd.Flags().IntP("lines", "n", 50, "Number of lines to show, 0 for all the daemon keeps")
+	cmd.Flags().BoolP("follow", "f", false, "Keep printing new lines as they are logged")
+
+	return cmd
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	lines, _ := cmd.Flags().GetInt("lines")
+	follow, _ := cmd.Flags().GetBool("follow")
+	if lines < 0 {
+		return fmt.Errorf("lines must not be negative")
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteLogs(lines)
+	fmt.Print(client.FormatLogsResult(result))
+	if !result.Success || !follow {
+		return resultError(result)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(logsFollowInterval)
+	defer ticker.Stop()
+
+	seq := client.LastLogSeq(result.Data.(*protocol.LogsData), 0)
+	var lastErr string
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		result := executor.ExecuteLogsSince(seq)
+		if data, ok := result.Data.(*protocol.LogsData); result.Success && ok {
+			fmt.Print(client.FormatLogs(data))
+			seq = client.LastLogSeq(data, seq)
+			lastErr = ""
+		} else if result.Error != lastErr {
+			// Report each distinct failure once and keep following until the daemon is back
+			lastErr = result.Error
+			fmt.Fprintf(os.Stderr, "error: %s\n", lastErr)
+		}
+	}
+}
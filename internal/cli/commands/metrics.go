@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/metrics"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// NewMetricsCommand creates the metrics command
+func NewMetricsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Print battery metrics in the OpenMetrics text format",
+		Long: `Print the battery status as OpenMetrics gauges, or write them to a file
+for node_exporter's textfile collector with --textfile. The file is replaced
+atomically, so this can run from cron or a systemd timer.
+
+To have the daemon keep the file up to date instead, set textfile in the
+[metrics] section of the config file.`,
+		RunE: runMetrics,
+	}
+
+	cmd.Flags().String("textfile", "", "Write to this .prom file instead of stdout")
+
+	return cmd
+}
+
+func runMetrics(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteStatus(false)
+	if !result.Success {
+		fmt.Print(client.FormatStatusResult(result))
+		return resultError(result)
+	}
+	status, ok := result.Data.(*protocol.StatusData)
+	if !ok {
+		return fmt.Errorf("unexpected status response")
+	}
+
+	textfile, _ := cmd.Flags().GetString("textfile")
+	if textfile == "" {
+		return metrics.Render(os.Stdout, status)
+	}
+
+	return metrics.WriteTextfile(textfile, status)
+}
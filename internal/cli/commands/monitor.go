@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// minMonitorInterval keeps monitor from hammering the daemon with hardware reads
+const minMonitorInterval = time.Second
+
+// NewMonitorCommand creates the monitor command
+func NewMonitorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "monitor",
+		Short: "Print battery changes as they happen",
+		Long: `Poll the daemon and print a timestamped line whenever the battery level,
+charging status or conservation mode changes. Useful for watching the daemon's
+automation at work. Press Ctrl+C to stop.`,
+		Example: `  legionbatctl monitor
+  legionbatctl monitor --interval 30s`,
+		RunE: runMonitor,
+	}
+
+	cmd.Flags().Duration("interval", 5*time.Second, "How often to read the battery")
+
+	return cmd
+}
+
+func runMonitor(cmd *cobra.Command, args []string) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval < minMonitorInterval {
+		return fmt.Errorf("interval must be at least %v", minMonitorInterval)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Polls reuse one connection while they come often enough
+	if err := c.EnablePool(1, 2*interval); err != nil {
+		return err
+	}
+	defer c.Close()
+	executor := client.NewCommandExecutor(c)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev *protocol.StatusData
+	var lastErr string
+
+	for {
+		result := executor.ExecuteStatus(true)
+		now := time.Now()
+
+		if status, ok := result.Data.(*protocol.StatusData); result.Success && ok {
+			if changes := client.MonitorChanges(prev, status); len(changes) > 0 {
+				fmt.Print(client.FormatMonitorLine(now, changes))
+			}
+			prev = status
+			lastErr = ""
+		} else if result.Error != lastErr {
+			// Report each distinct failure once and keep polling until the daemon is back
+			lastErr = result.Error
+			fmt.Print(client.FormatMonitorLine(now, []string{"error: " + lastErr}))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
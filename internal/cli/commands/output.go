@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// isQuiet reports whether --quiet was given
+func isQuiet(cmd *cobra.Command) bool {
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	return quiet
+}
+
+// printResult prints the formatted result of a command that changes
+// settings; with --quiet only failures are printed
+func printResult(cmd *cobra.Command, result *client.CommandResult, output string) {
+	if result.Success && isQuiet(cmd) {
+		return
+	}
+	fmt.Print(output)
+}
+
+// printSuccess prints a confirmation unless --quiet was given
+func printSuccess(cmd *cobra.Command, format string, args ...any) {
+	if isQuiet(cmd) {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// addDryRunFlag adds the --dry-run flag to a command that changes settings
+func addDryRunFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Show what would change without changing anything")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "direct")
+}
+
+// isDryRun reports whether --dry-run was given
+func isDryRun(cmd *cobra.Command) bool {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	return dryRun
+}
+
+// runDryRun asks the daemon what a command would change and prints it
+func runDryRun(cmd *cobra.Command, command string, params map[string]interface{}) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteDryRun(command, params)
+	fmt.Print(client.FormatDryRunResult(result))
+
+	return resultError(result)
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewPauseCommand creates the pause command
+func NewPauseCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Stop switching conservation mode for a while, keeping the settings",
+		Long: `Pause the daemon's automatic switching. Conservation mode is left as it is
+and the settings are kept, unlike disable, which switches conservation mode off
+so the battery charges to 100%. The daemon keeps reading the battery and
+recording history while paused.
+
+With --for, automation resumes once the duration has elapsed, even across
+daemon restarts; otherwise it stays paused until legionbatctl resume. The
+status shows the pause and the time left.`,
+		Example: `  legionbatctl pause
+  legionbatctl pause --for 1h`,
+		RunE: runPause,
+	}
+
+	cmd.Flags().Duration("for", 0, "Resume automation after this duration (e.g. 90m, 2h)")
+	registerCompletion(cmd, "for", completeValues("30m", "1h", "2h", "4h", "8h"))
+
+	return cmd
+}
+
+// NewResumeCommand creates the resume command
+func NewResumeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "Resume automatic switching after pause",
+		RunE:  runResume,
+	}
+}
+
+func runPause(cmd *cobra.Command, args []string) error {
+	forDuration, _ := cmd.Flags().GetDuration("for")
+	if cmd.Flags().Changed("for") && forDuration <= 0 {
+		return fmt.Errorf("--for must be a positive duration")
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecutePause(forDuration)
+	printResult(cmd, result, client.FormatPauseResult(result))
+
+	return resultError(result)
+}
+
+func runResume(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteResume()
+	printResult(cmd, result, client.FormatPauseResult(result))
+
+	return resultError(result)
+}
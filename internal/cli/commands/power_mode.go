@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewPowerModeCommand creates the power-mode command
+func NewPowerModeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "power-mode [quiet|balanced|performance]",
+		Short: "Show or switch the platform power profile",
+		Long: `Show or switch the platform power profile (ACPI platform_profile), the
+mode Fn+Q cycles through on Legion laptops. Without an argument the active
+profile is shown along with the ones the hardware offers.
+
+The power_modes section of the configuration can couple a charge profile with
+a power mode, e.g. a lower threshold in quiet mode; it applies whichever way
+the mode was switched.`,
+		Example: `  legionbatctl power-mode quiet
+  legionbatctl power-mode`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"quiet", "balanced", "performance"},
+		RunE:      runPowerMode,
+	}
+}
+
+func runPowerMode(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	if len(args) == 0 {
+		result := executor.ExecuteGetPowerMode()
+		fmt.Print(client.FormatPowerModeResult(result))
+		return resultError(result)
+	}
+
+	result := executor.ExecuteSetPowerMode(args[0])
+	printResult(cmd, result, client.FormatPowerModeResult(result))
+
+	return resultError(result)
+}
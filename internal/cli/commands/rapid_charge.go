@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewRapidChargeCommand creates the rapid-charge command
+func NewRapidChargeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rapid-charge",
+		Short: "Turn rapid charge on or off",
+		Long: `Rapid charge charges the battery faster at the cost of more heat, which
+wears the battery. It is available on Legion laptops with the legion-laptop
+kernel module loaded.
+
+Without a subcommand, the current state is shown.`,
+		Example: `  legionbatctl rapid-charge on
+  legionbatctl rapid-charge status`,
+		RunE: runRapidChargeStatus,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "on",
+		Short: "Enable rapid charge",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRapidCharge(cmd, true)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "off",
+		Short: "Disable rapid charge",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRapidCharge(cmd, false)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show whether rapid charge is on",
+		Args:  cobra.NoArgs,
+		RunE:  runRapidChargeStatus,
+	})
+
+	return cmd
+}
+
+func runRapidChargeStatus(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteGetRapidCharge()
+	fmt.Print(client.FormatRapidChargeResult(result))
+
+	return resultError(result)
+}
+
+// runRapidCharge switches rapid charge and prints the result
+func runRapidCharge(cmd *cobra.Command, enable bool) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteSetRapidCharge(enable)
+	printResult(cmd, result, client.FormatRapidChargeResult(result))
+
+	return resultError(result)
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/report"
+)
+
+// NewReportCommand creates the report command
+func NewReportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Write an HTML or Markdown battery report",
+		Long: `Write a battery report from the history recorded by the daemon: a summary
+of time on AC and battery power, every charge session with the time
+conservation mode held the charge, the time spent at each battery level and
+the battery's wear with its capacity trend.
+
+The format follows the extension of --out (.md for Markdown), unless given
+with --format.`,
+		Example: `  legionbatctl report --out battery-report.html
+  legionbatctl report --since 90d --out battery-report.md`,
+		Args: cobra.NoArgs,
+		RunE: runReport,
+	}
+
+	cmd.Flags().StringP("out", "o", "battery-report.html", "Output file, - for stdout")
+	cmd.Flags().String("format", "", "Output format (html or markdown; default from --out)")
+	cmd.Flags().String("since", "30d", "Report from this time on (e.g. 7d, 90d, 2006-01-02)")
+	cmd.Flags().String("until", "", "Report up to this time (default now)")
+	registerCompletion(cmd, "format", completeValues(report.Formats...))
+	registerCompletion(cmd, "since", completeValues(sinceCompletions...))
+	registerCompletion(cmd, "until", completeValues(sinceCompletions...))
+
+	return cmd
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	since, until, err := timeRangeFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	out, _ := cmd.Flags().GetString("out")
+	format, _ := cmd.Flags().GetString("format")
+	if format == "" {
+		format = report.FormatFor(out)
+	}
+	if !slices.Contains(report.Formats, format) {
+		return fmt.Errorf("unsupported format %q (expected html or markdown)", format)
+	}
+
+	// The history can be large, so have truncated responses detected
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	c.SetFraming(protocol.FramingLength)
+
+	history, err := c.GetHistory(since, until)
+	if err != nil {
+		return err
+	}
+	stats, err := c.GetStats(since, until)
+	if err != nil {
+		return err
+	}
+
+	// The health section is left out on backends that can't read it
+	health, err := c.GetHealth(true)
+	if err != nil && protocol.ErrorCode(err) != protocol.CodeHardwareNotSupported {
+		return err
+	}
+
+	r := report.Build(history.Entries, stats, health, since, until)
+	if out == "-" {
+		return r.Render(os.Stdout, format)
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", out, err)
+	}
+	if err := r.Render(file, format); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+
+	printSuccess(cmd, "✓ Wrote battery report to %s\n", out)
+	return nil
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewScheduleCommand creates the schedule command group
+func NewScheduleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Show and control time-based threshold rules",
+		Long: `Schedule rules apply a charge threshold or profile during recurring time
+windows, e.g. 60% on weekdays 09:00-18:00 and the configured threshold
+otherwise. Rules are defined in the config file:
+
+  [profiles.desk]
+  threshold = 60
+
+  [[schedule]]
+  name = "workdays"
+  days = ["mon-fri"]
+  from = "09:00"
+  to = "18:00"
+  profile = "desk"    # or: threshold = 60
+
+When rules overlap, the first one listed wins. Without a subcommand the
+rules are listed.`,
+		RunE: runScheduleList,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List schedule rules and the active rule",
+		RunE:  runScheduleList,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "pause",
+		Short: "Ignore schedule rules and use the configured threshold",
+		RunE:  runSchedulePause,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "resume",
+		Short: "Apply schedule rules again",
+		RunE:  runScheduleResume,
+	})
+
+	return cmd
+}
+
+func runScheduleList(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteGetSchedule()
+	fmt.Print(client.FormatScheduleResult(result))
+
+	return resultError(result)
+}
+
+func runSchedulePause(cmd *cobra.Command, args []string) error {
+	return setSchedulePaused(cmd, true)
+}
+
+func runScheduleResume(cmd *cobra.Command, args []string) error {
+	return setSchedulePaused(cmd, false)
+}
+
+// setSchedulePaused pauses or resumes the schedule and prints the result
+func setSchedulePaused(cmd *cobra.Command, paused bool) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteSetSchedulePaused(paused)
+	if result.Success {
+		printSuccess(cmd, "✓ %s.\n", result.Message)
+	}
+	printResult(cmd, result, client.FormatScheduleResult(result))
+
+	return resultError(result)
+}
@@ -1,56 +1,158 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
+
 	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+var (
+	errThresholdRequired  = errors.New("a threshold or --preset is required, see: legionbatctl set-threshold --list-presets")
+	errThresholdAndPreset = errors.New("give either a threshold or --preset, not both")
 )
 
 // NewSetThresholdCommand creates the set-threshold command
 func NewSetThresholdCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "set-threshold <percentage>",
-		Short: "Set battery charge threshold (60-100)",
+		Use:   "set-threshold [percentage]",
+		Short: "Set battery charge threshold (40-100)",
 		Long: `Set the maximum battery charge threshold. When battery management is enabled,
 the system will stop charging once the battery reaches this percentage by
 enabling conservation mode.
 
 NOTE: Due to hardware limitations on Lenovo Legion Slim 7 (2021), the threshold
 must be between 60-100%. The native conservation mode is fixed at 60%, but this
-utility allows you to effectively achieve higher charge limits.
+utility allows you to effectively achieve higher charge limits. Batteries with
+a native end threshold (charge_control_end_threshold) accept 40-100%.
+
+For optimal battery health, thresholds between 75-85% are recommended.
+Instead of a percentage, --preset picks a recommended threshold by name;
+--list-presets shows them.
 
-For optimal battery health, thresholds between 75-85% are recommended.`,
-		Args: cobra.ExactArgs(1),
-		RunE: runSetThreshold,
+Use --start to also set where charging resumes, so the battery is not topped
+up for every small dip while docked (e.g. stop at 80%, start below 70%).
+Without it, charging resumes below the threshold minus the configured
+hysteresis.`,
+		Example: `  legionbatctl set-threshold 80
+  legionbatctl set-threshold 80 --start 70
+  legionbatctl set-threshold --preset balanced
+  legionbatctl set-threshold --list-presets`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeThreshold,
+		RunE:              runSetThreshold,
 	}
 
+	cmd.Flags().String("preset", "", "Use a recommended threshold: "+strings.Join(presetNames(), ", "))
+	cmd.Flags().Bool("list-presets", false, "List the threshold presets and exit")
+	cmd.Flags().Int("start", 0, "Resume charging below this level (0 uses the configured hysteresis)")
+	registerCompletion(cmd, "preset", completeValues(presetCompletions()...))
+	registerCompletion(cmd, "start", completeValues(percentCompletions(50, 95, 5)...))
+	addDirectFlag(cmd)
+	addDryRunFlag(cmd)
+
 	return cmd
 }
 
 func runSetThreshold(cmd *cobra.Command, args []string) error {
-	threshold, err := strconv.Atoi(args[0])
+	if list, _ := cmd.Flags().GetBool("list-presets"); list {
+		printPresets()
+		return nil
+	}
+
+	threshold, err := thresholdArg(cmd, args)
 	if err != nil {
-		return fmt.Errorf("invalid threshold value: %s", args[0])
+		return err
+	}
+
+	if isDryRun(cmd) {
+		params := map[string]interface{}{"threshold": threshold}
+		if cmd.Flags().Changed("start") {
+			params["start_threshold"], _ = cmd.Flags().GetInt("start")
+		}
+		return runDryRun(cmd, protocol.CmdSetThreshold, params)
+	}
+
+	if isDirect(cmd) {
+		start, _ := cmd.Flags().GetInt("start")
+		controller, err := openDirect(cmd)
+		if err != nil {
+			return err
+		}
+		result, err := controller.SetThreshold(threshold, start)
+		if err != nil {
+			return err
+		}
+		printDirectResult(cmd, fmt.Sprintf("Charge threshold set to %d%%", threshold), result)
+		return nil
 	}
 
 	// Create client with default socket path
-	c := client.NewClient("")
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
 
 	// Create command executor
 	executor := client.NewCommandExecutor(c)
 
 	// Execute set threshold command
-	result := executor.ExecuteSetThreshold(threshold)
+	var result *client.CommandResult
+	if cmd.Flags().Changed("start") {
+		start, _ := cmd.Flags().GetInt("start")
+		result = executor.ExecuteSetThresholds(threshold, start)
+	} else {
+		result = executor.ExecuteSetThreshold(threshold)
+	}
 
 	// Format and output result
 	output := client.FormatSetThresholdResult(result)
-	fmt.Print(output)
+	printResult(cmd, result, output)
+
+	return resultError(result)
+}
 
-	if !result.Success {
-		return fmt.Errorf(result.Error)
+// thresholdArg returns the threshold given as the argument or by --preset
+func thresholdArg(cmd *cobra.Command, args []string) (int, error) {
+	name, _ := cmd.Flags().GetString("preset")
+	switch {
+	case name != "" && len(args) > 0:
+		return 0, errThresholdAndPreset
+	case name != "":
+		preset, ok := protocol.FindThresholdPreset(name)
+		if !ok {
+			return 0, fmt.Errorf("unknown preset %q, use one of: %s", name, strings.Join(presetNames(), ", "))
+		}
+		return preset.Threshold, nil
+	case len(args) == 0:
+		return 0, errThresholdRequired
 	}
 
-	return nil
+	threshold, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid threshold value: %s", args[0])
+	}
+	return threshold, nil
+}
+
+// printPresets lists the threshold presets
+func printPresets() {
+	fmt.Println("Threshold presets:")
+	for _, preset := range protocol.ThresholdPresets {
+		fmt.Printf("  %-10s %3d%%  %s\n", preset.Name, preset.Threshold, preset.Description)
+	}
+}
+
+// presetNames returns the names of the threshold presets
+func presetNames() []string {
+	names := make([]string, 0, len(protocol.ThresholdPresets))
+	for _, preset := range protocol.ThresholdPresets {
+		names = append(names, preset.Name)
+	}
+	return names
 }
\ No newline at end of file
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/simulate"
+)
+
+// errScenarioFailed is returned when a scenario has unmet expectations
+var errScenarioFailed = errors.New("scenario failed")
+
+// NewSimulateCommand creates the simulate command
+func NewSimulateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Play a battery scenario against the mock backend",
+		Long: `Plays a scripted scenario of battery and AC transitions against the mock
+backend in simulated time and checks the conservation mode decisions, to
+validate policy changes before they touch real hardware. Neither the daemon
+nor the hardware is involved, and the real state file is left alone.
+
+Scenarios are TOML files like the config file:
+
+  name = "charge to threshold"
+  interval = "5m"          # simulated time between checks
+
+  [settings]
+  threshold = 80
+  hysteresis = 5
+
+  [battery]
+  level = 70
+  ac = true
+
+  [[step]]
+  name = "charges up to the threshold"
+  after = "30m"            # simulated time the step lasts
+  expect = { conservation_mode = true, level_min = 80, switches = 1 }
+
+Steps may also set level, ac, threshold and managed before their time
+passes, and expect level_max. The command fails if an expectation is not met.`,
+		Example: `  legionbatctl simulate --scenario examples/scenarios/charge-to-threshold.toml`,
+		Args:    cobra.NoArgs,
+		RunE:    runSimulate,
+	}
+
+	cmd.Flags().String("scenario", "", "Scenario file to play")
+	cmd.MarkFlagRequired("scenario")
+
+	return cmd
+}
+
+func runSimulate(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("scenario")
+
+	scenario, err := simulate.Load(path)
+	if err != nil {
+		return err
+	}
+
+	report, err := simulate.Run(scenario)
+	if err != nil {
+		return fmt.Errorf("simulation failed: %w", err)
+	}
+
+	fmt.Print(simulate.FormatReport(report))
+	if !report.Passed() {
+		return errScenarioFailed
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+var errInvalidSnapshotID = errors.New("snapshot ID must be a positive integer, see: legionbatctl snapshot list")
+
+// NewSnapshotCommand creates the snapshot command group
+func NewSnapshotCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save the settings and roll back to them later",
+		Long: `A snapshot saves the charge threshold, whether management is enabled,
+whether schedule rules are paused, and the config file with its profiles and
+schedule. Create one before experimenting with the settings and roll back to
+it in one command afterwards.
+
+Rolling back replaces the config file only if it changed since, keeping the
+replaced file as a timestamped backup next to it, and ends temporary changes
+such as disable --for, charge-full and storage mode. The daemon keeps
+snapshots in /var/lib/legionbatctl/snapshots.`,
+		Example: `  legionbatctl snapshot create --note "before tuning"
+  legionbatctl snapshot list
+  legionbatctl snapshot rollback 3`,
+		RunE: runSnapshotList,
+	}
+
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Save the current settings as a snapshot",
+		RunE:  runSnapshotCreate,
+	}
+	create.Flags().String("note", "", "Describe the snapshot, e.g. why it was taken")
+	cmd.AddCommand(create)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the saved snapshots",
+		RunE:  runSnapshotList,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "rollback <id>",
+		Short: "Restore the settings saved in a snapshot",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runSnapshotRollback,
+	})
+
+	return cmd
+}
+
+func runSnapshotCreate(cmd *cobra.Command, args []string) error {
+	note, _ := cmd.Flags().GetString("note")
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteSnapshotCreate(note)
+	printResult(cmd, result, client.FormatSnapshotResult(result))
+
+	return resultError(result)
+}
+
+func runSnapshotList(cmd *cobra.Command, args []string) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteSnapshotList()
+	printResult(cmd, result, client.FormatSnapshotResult(result))
+
+	return resultError(result)
+}
+
+func runSnapshotRollback(cmd *cobra.Command, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id < 1 {
+		return errInvalidSnapshotID
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteSnapshotRollback(id)
+	printResult(cmd, result, client.FormatSnapshotResult(result))
+
+	return resultError(result)
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/paths"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+var (
+	errRestoreNotRoot        = errors.New("restoring the state file requires root, try again with sudo")
+	errRestoreDaemonRunning  = errors.New("the daemon is running and would overwrite the restored state, stop it first")
+	errInvalidBackupPosition = errors.New("backup number must be a positive integer, see: legionbatctl state backups list")
+)
+
+// NewStateCommand creates the state command group
+func NewStateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Manage the daemon's state file",
+	}
+
+	backupsCmd := &cobra.Command{
+		Use:   "backups",
+		Short: "List and restore backups of the state file",
+		Long: `The daemon backs up the state file each time it loads it intact, keeping the
+newest ones ([state] backups in the config file, 5 by default). A corrupted
+state file is replaced by the newest usable backup automatically.
+
+Without a subcommand the backups are listed.`,
+		Args: cobra.NoArgs,
+		RunE: runStateBackupsList,
+	}
+	backupsCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List backups of the state file, newest first",
+		Args:  cobra.NoArgs,
+		RunE:  runStateBackupsList,
+	})
+	backupsCmd.AddCommand(&cobra.Command{
+		Use:   "restore <n>",
+		Short: "Restore the nth newest backup (requires root, daemon stopped)",
+		Long: `Restores the state file from a backup, numbered as in "state backups list".
+The current state file is backed up first, so the restore can be undone.
+The daemon must be stopped, as it would overwrite the restored state.`,
+		Example: `  legionbatctl state backups restore 2`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runStateBackupsRestore,
+	})
+
+	cmd.AddCommand(backupsCmd)
+
+	return cmd
+}
+
+func runStateBackupsList(cmd *cobra.Command, args []string) error {
+	manager := state.NewManager(paths.StatePath())
+	backups, err := manager.Backups()
+	if err != nil {
+		return err
+	}
+
+	if len(backups) == 0 {
+		fmt.Printf("No backups of %s\n", manager.GetStatePath())
+		return nil
+	}
+
+	fmt.Printf("Backups of %s, newest first:\n", manager.GetStatePath())
+	for i, backup := range backups {
+		fmt.Printf("  %2d  %s  %s\n", i+1, backup.Time.Local().Format("2006-01-02 15:04:05"), backup.Path)
+	}
+	return nil
+}
+
+func runStateBackupsRestore(cmd *cobra.Command, args []string) error {
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return errInvalidBackupPosition
+	}
+
+	if os.Geteuid() != 0 {
+		return errRestoreNotRoot
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	if c.IsDaemonRunning() {
+		return errRestoreDaemonRunning
+	}
+
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+
+	manager := state.NewManager(paths.StatePath())
+	manager.SetRuntimePath(paths.RuntimePath())
+	manager.SetBackupLimit(cfg.State.Backups)
+	if err := manager.RestoreBackup(n); err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	restored := manager.GetState()
+	printSuccess(cmd, "Restored backup %d: threshold %d%%, management %s\n",
+		n, restored.ChargeThreshold, restored.CurrentMode)
+	return nil
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// NewStatsCommand creates the stats command
+func NewStatsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Summarize the battery history",
+		Long: `Summarize the battery history recorded by the daemon: time spent in
+conservation mode, number of conservation toggles, average battery level and
+time on AC versus battery power.
+
+Only time the daemon was observing the battery is counted; periods where it
+wasn't running or the system was suspended are left out.`,
+		RunE: runStats,
+	}
+
+	cmd.Flags().String("since", "7d", "Summarize from this time on (e.g. 24h, 7d, 2006-01-02)")
+	cmd.Flags().String("until", "", "Summarize up to this time (default now)")
+	registerCompletion(cmd, "since", completeValues(sinceCompletions...))
+	registerCompletion(cmd, "until", completeValues(sinceCompletions...))
+
+	return cmd
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	since, until, err := timeRangeFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteStats(since, until)
+	fmt.Print(client.FormatStatsResult(result))
+
+	return resultError(result)
+}
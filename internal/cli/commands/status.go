@@ -2,9 +2,13 @@ package commands
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
+
 	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/paths"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
 )
 
 // NewStatusCommand creates the status command
@@ -14,30 +18,102 @@ func NewStatusCommand() *cobra.Command {
 		Short: "Show current battery management and conservation mode status",
 		Long: `Display the current status of battery management, conservation mode, and
 charge threshold settings. This shows both the hardware conservation mode
-status and the software battery management configuration.`,
+status and the software battery management configuration.
+
+Battery readings are served from the daemon's cached snapshot, which keeps
+frequent polling (e.g. from status bars) fast. Use --fresh to force a live
+hardware read. With --quiet only the battery percentage is printed.
+
+With --changes only what changed since the previous --changes run is
+printed, as one timestamped line, and nothing if nothing did. The status is
+remembered in ~/.cache/legionbatctl, or in --changes-file so several cron
+jobs or hooks each see their own changes.`,
+		Example: `  legionbatctl status --short
+  legionbatctl status --short --icons nerd-font
+  legionbatctl status --changes >> ~/battery.log`,
 		RunE: runStatus,
 	}
 
+	cmd.Flags().Bool("fresh", false, "Read the hardware now (or reuse a reading under a second old) instead of the cached snapshot")
+	cmd.Flags().BoolP("short", "s", false, "Print a one-line summary (e.g. for shell prompts)")
+	cmd.Flags().String("glyphs", "", "Glyphs for --short as \"charging,discharging,held\", replacing the icon theme's")
+	addIconsFlag(cmd)
+	cmd.Flags().Bool("changes", false, "Print only what changed since the previous --changes run")
+	cmd.Flags().String("changes-file", "", "File remembering the status for --changes (default in the user cache directory)")
+	cmd.MarkFlagsMutuallyExclusive("changes", "short")
+
 	return cmd
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	fresh, _ := cmd.Flags().GetBool("fresh")
+	short, _ := cmd.Flags().GetBool("short")
+	glyphSpec, _ := cmd.Flags().GetString("glyphs")
+	if glyphSpec == "" {
+		glyphSpec = display.Glyphs
+	}
+
+	glyphs, err := iconGlyphs(cmd)
+	if err != nil {
+		return err
+	}
+	if glyphSpec != "" {
+		if glyphs, err = client.ParseShortGlyphs(glyphSpec); err != nil {
+			return err
+		}
+	}
+
 	// Create client with default socket path
-	c := client.NewClient("")
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
 
 	// Create command executor
 	executor := client.NewCommandExecutor(c)
 
 	// Execute status command
-	result := executor.ExecuteStatus()
+	result := executor.ExecuteStatus(fresh)
+
+	if changes, _ := cmd.Flags().GetBool("changes"); changes {
+		return printStatusChanges(cmd, result)
+	}
 
 	// Format and output result
-	output := client.FormatStatusResult(result)
+	var output string
+	if status, ok := result.Data.(*protocol.StatusData); ok && isQuiet(cmd) {
+		output = fmt.Sprintf("%d\n", status.BatteryLevel)
+	} else if short {
+		output = client.FormatStatusShortResult(result, glyphs)
+	} else {
+		output = client.FormatStatusResult(result)
+	}
 	fmt.Print(output)
 
-	if !result.Success {
-		return fmt.Errorf(result.Error)
+	return resultError(result)
+}
+
+// printStatusChanges prints what changed since the status saved by the
+// previous run, then saves the current one
+func printStatusChanges(cmd *cobra.Command, result *client.CommandResult) error {
+	status, ok := result.Data.(*protocol.StatusData)
+	if !result.Success || !ok {
+		fmt.Print(client.FormatStatusResult(result))
+		return resultError(result)
+	}
+
+	path, _ := cmd.Flags().GetString("changes-file")
+	if path == "" {
+		path = paths.StatusCachePath()
 	}
 
-	return nil
-}
\ No newline at end of file
+	prev, err := client.LoadLastStatus(path)
+	if err != nil {
+		return err
+	}
+	if changes := client.StatusChanges(prev, status); len(changes) > 0 {
+		fmt.Print(client.FormatChangesLine(time.Now(), changes))
+	}
+
+	return client.SaveLastStatus(path, status)
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// NewStatuslineCommand creates the statusline command
+func NewStatuslineCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "statusline",
+		Short: "Print battery status for waybar, polybar or i3blocks",
+		Long: `Print the battery status in the format expected by status bars, using a
+single daemon query. Drop it into your bar configuration directly:
+
+  waybar:   "exec": "legionbatctl statusline --style waybar", "return-type": "json"
+  polybar:  exec = legionbatctl statusline --style polybar
+  i3blocks: command=legionbatctl statusline --style i3blocks
+
+The waybar output provides "held", "charging", "discharging" and "error"
+classes for styling. --icons nerd-font shows battery icons by level, for bars
+using a Nerd Font.`,
+		RunE: runStatusline,
+	}
+
+	cmd.Flags().String("style", client.StatuslineWaybar, "Output style: waybar, polybar or i3blocks")
+	registerCompletion(cmd, "style",
+		completeValues(client.StatuslineWaybar, client.StatuslinePolybar, client.StatuslineI3blocks))
+	addIconsFlag(cmd)
+
+	return cmd
+}
+
+func runStatusline(cmd *cobra.Command, args []string) error {
+	style, _ := cmd.Flags().GetString("style")
+	if !cmd.Flags().Changed("style") {
+		style = display.StatuslineStyle
+	}
+	if !client.IsValidStatuslineStyle(style) {
+		return fmt.Errorf("invalid style %q (expected waybar, polybar or i3blocks)", style)
+	}
+	glyphs, err := iconGlyphs(cmd)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+	result := executor.ExecuteStatus(false)
+
+	// Bars expect output on every run, so failures are rendered rather than returned
+	var output string
+	if status, ok := result.Data.(*protocol.StatusData); result.Success && ok {
+		output, err = client.FormatStatusline(status, style, glyphs)
+	} else {
+		output, err = client.FormatStatuslineError(style, result.Error, glyphs)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(output)
+	return nil
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// NewStorageCommand creates the storage command
+func NewStorageCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Hold the battery at a storage level for long-term storage",
+		Long: `Storage mode holds the battery around 50% for laptops put away for weeks,
+which is the least stressful level for lithium-ion cells. The battery stops
+charging at the target and, on hardware supporting forced discharge, is
+discharged down to it even on AC power.
+
+Storage mode takes precedence over schedule rules until turned off, or until
+management is changed with enable, disable or charge-full.`,
+	}
+
+	onCmd := &cobra.Command{
+		Use:   "on",
+		Short: "Enable storage mode",
+		RunE:  runStorageOn,
+	}
+	onCmd.Flags().Int("target", protocol.DefaultStorageTarget,
+		fmt.Sprintf("Charge level to hold (%d-%d)", protocol.MinStorageTarget, protocol.MaxStorageTarget))
+	registerCompletion(onCmd, "target",
+		completeValues(percentCompletions(protocol.MinStorageTarget, protocol.MaxStorageTarget, 5)...))
+
+	cmd.AddCommand(onCmd)
+	cmd.AddCommand(&cobra.Command{
+		Use:   "off",
+		Short: "Disable storage mode and resume regular management",
+		RunE:  runStorageOff,
+	})
+
+	return cmd
+}
+
+func runStorageOn(cmd *cobra.Command, args []string) error {
+	target, _ := cmd.Flags().GetInt("target")
+	if err := protocol.ValidateStorageTarget(target); err != nil {
+		return err
+	}
+
+	return runStorage(cmd, true, target)
+}
+
+func runStorageOff(cmd *cobra.Command, args []string) error {
+	return runStorage(cmd, false, 0)
+}
+
+// runStorage switches storage mode and prints the result
+func runStorage(cmd *cobra.Command, enable bool, target int) error {
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+	executor := client.NewCommandExecutor(c)
+
+	result := executor.ExecuteStorage(enable, target)
+	printResult(cmd, result, client.FormatStorageResult(result))
+
+	return resultError(result)
+}
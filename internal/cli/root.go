@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"github.com/spf13/cobra"
+
 	"github.com/dom1nux/legionbatctl/internal/cli/commands"
+	"github.com/dom1nux/legionbatctl/internal/client"
 	"github.com/dom1nux/legionbatctl/pkg/version"
-	"github.com/spf13/cobra"
 )
 
 // Run initializes and runs the CLI application
@@ -18,20 +20,61 @@ within configured thresholds.
 This is particularly useful for laptops with fixed conservation mode limits (e.g., 60%),
 allowing you to effectively achieve higher charge limits (e.g., 80%).`,
 		Version: version.GetVersionInfo().String(),
+		// Errors are reported by main, command failures render their own hints
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return commands.ConfigureColor(cmd)
+		},
 	}
 
 	// Add global flags
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Print only failures and essential values (for scripts)")
 	rootCmd.PersistentFlags().String("config", "/etc/legionbatctl.conf", "Path to configuration file")
+	rootCmd.PersistentFlags().Duration("timeout", client.DefaultTimeout,
+		"Timeout for daemon requests (also set by "+commands.TimeoutEnv+")")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output (also set by "+commands.NoColorEnv+")")
+	rootCmd.PersistentFlags().String("color-theme", client.DefaultTheme, "Color theme: default, bright or mono")
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("color-theme", commands.CompleteColorTheme))
+	cobra.CheckErr(rootCmd.RegisterFlagCompletionFunc("timeout",
+		cobra.FixedCompletions([]string{"5s", "10s", "30s", "1m"}, cobra.ShellCompDirectiveNoFileComp)))
 
 	// Add subcommands
+	rootCmd.AddCommand(commands.NewInitCommand())
 	rootCmd.AddCommand(commands.NewStatusCommand())
+	rootCmd.AddCommand(commands.NewExplainCommand())
 	rootCmd.AddCommand(commands.NewEnableCommand())
 	rootCmd.AddCommand(commands.NewDisableCommand())
+	rootCmd.AddCommand(commands.NewPauseCommand())
+	rootCmd.AddCommand(commands.NewResumeCommand())
 	rootCmd.AddCommand(commands.NewSetThresholdCommand())
-
-	// Set completion
-	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(commands.NewStatuslineCommand())
+	rootCmd.AddCommand(commands.NewLimitsCommand())
+	rootCmd.AddCommand(commands.NewRapidChargeCommand())
+	rootCmd.AddCommand(commands.NewPowerModeCommand())
+	rootCmd.AddCommand(commands.NewMonitorCommand())
+	rootCmd.AddCommand(commands.NewChargeFullCommand())
+	rootCmd.AddCommand(commands.NewScheduleCommand())
+	rootCmd.AddCommand(commands.NewStorageCommand())
+	rootCmd.AddCommand(commands.NewSnapshotCommand())
+	rootCmd.AddCommand(commands.NewHealthCommand())
+	rootCmd.AddCommand(commands.NewAdaptiveCommand())
+	rootCmd.AddCommand(commands.NewBatteryCommand())
+	rootCmd.AddCommand(commands.NewHistoryCommand())
+	rootCmd.AddCommand(commands.NewStatsCommand())
+	rootCmd.AddCommand(commands.NewReportCommand())
+	rootCmd.AddCommand(commands.NewAuditCommand())
+	rootCmd.AddCommand(commands.NewLogsCommand())
+	rootCmd.AddCommand(commands.NewGraphCommand())
+	rootCmd.AddCommand(commands.NewMetricsCommand())
+	rootCmd.AddCommand(commands.NewDaemonCommand())
+	rootCmd.AddCommand(commands.NewAutoCommand())
+	rootCmd.AddCommand(commands.NewConfigCommand())
+	rootCmd.AddCommand(commands.NewStateCommand())
+	rootCmd.AddCommand(commands.NewSimulateCommand())
+	rootCmd.AddCommand(commands.NewGenerateCommand())
+	rootCmd.AddCommand(commands.NewGenManCommand())
 
 	// Customize help output
 	rootCmd.SetUsageTemplate(usageTemplate())
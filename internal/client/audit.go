@@ -0,0 +1,66 @@
+package client
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// FormatAudit formats audit log entries as a table, oldest first
+func FormatAudit(data *protocol.AuditData) string {
+	if len(data.Entries) == 0 {
+		return "No control commands recorded in this range.\n"
+	}
+
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TIME\tUSER\tCOMMAND\tCHANGE\tRESULT\n")
+	for _, entry := range data.Entries {
+		result := "ok"
+		if entry.Error != "" {
+			result = entry.Error
+		}
+
+		change := entry.New
+		if entry.Old != entry.New {
+			change = fmt.Sprintf("%s → %s", entry.Old, entry.New)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			formatUID(entry.UID),
+			strings.ReplaceAll(entry.Command, "_", "-"),
+			change,
+			result)
+	}
+	w.Flush()
+
+	return buf.String()
+}
+
+// FormatAuditResult formats the result of audit command
+func FormatAuditResult(result *CommandResult) string {
+	if result.Success {
+		if data, ok := result.Data.(*protocol.AuditData); ok {
+			return FormatAudit(data)
+		}
+		return result.Message
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// formatUID shows a user name where the UID can be resolved
+func formatUID(uid int) string {
+	if uid < 0 {
+		return "unknown"
+	}
+	if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+		return u.Username
+	}
+	return strconv.Itoa(uid)
+}
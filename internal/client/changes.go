@@ -0,0 +1,122 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// StatusChanges describes what changed between two status readings: the
+// battery values MonitorChanges tracks plus the settings shaping them. The
+// first reading (prev == nil) reports every tracked value.
+func StatusChanges(prev, cur *protocol.StatusData) []string {
+	changes := MonitorChanges(prev, cur)
+
+	if prev == nil || prev.Threshold != cur.Threshold {
+		changes = append(changes, fmt.Sprintf("threshold %s", formatTransition(prev, cur, func(s *protocol.StatusData) string {
+			return fmt.Sprintf("%d%%", s.Threshold)
+		})))
+	}
+
+	if prev == nil || prev.ConservationEnabled != cur.ConservationEnabled {
+		changes = append(changes, fmt.Sprintf("management %s", formatTransition(prev, cur, func(s *protocol.StatusData) string {
+			return formatBool(s.ConservationEnabled)
+		})))
+	}
+
+	if prev == nil || prev.ChargeFull != cur.ChargeFull {
+		changes = append(changes, fmt.Sprintf("charge full %s", formatTransition(prev, cur, func(s *protocol.StatusData) string {
+			return formatBool(s.ChargeFull)
+		})))
+	}
+
+	if prev == nil || prev.StorageMode != cur.StorageMode {
+		changes = append(changes, fmt.Sprintf("storage mode %s", formatTransition(prev, cur, func(s *protocol.StatusData) string {
+			return formatBool(s.StorageMode)
+		})))
+	}
+
+	if prev == nil || prev.Paused != cur.Paused {
+		changes = append(changes, fmt.Sprintf("paused %s", formatTransition(prev, cur, func(s *protocol.StatusData) string {
+			return formatBool(s.Paused)
+		})))
+	}
+
+	if prev == nil || prev.Schedule != cur.Schedule {
+		changes = append(changes, fmt.Sprintf("schedule %s", formatTransition(prev, cur, func(s *protocol.StatusData) string {
+			return formatOptional(s.Schedule)
+		})))
+	}
+
+	if prev == nil || prev.PowerMode != cur.PowerMode {
+		changes = append(changes, fmt.Sprintf("power mode %s", formatTransition(prev, cur, func(s *protocol.StatusData) string {
+			return formatOptional(s.PowerMode)
+		})))
+	}
+
+	return changes
+}
+
+// FormatChangesLine formats a line for status changes. Unlike monitor lines
+// it carries the date, as the lines are usually appended to a log.
+func FormatChangesLine(t time.Time, changes []string) string {
+	return fmt.Sprintf("%s  %s\n", t.Format(time.DateTime), strings.Join(changes, ", "))
+}
+
+// formatOptional formats a value that may be unset
+func formatOptional(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+// LoadLastStatus reads the status saved by SaveLastStatus. It returns nil
+// without an error if none was saved yet.
+func LoadLastStatus(path string) (*protocol.StatusData, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read last status: %w", err)
+	}
+
+	status := &protocol.StatusData{}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, fmt.Errorf("invalid last status in %s: %w", path, err)
+	}
+	return status, nil
+}
+
+// SaveLastStatus atomically replaces the status saved at path, so an
+// interrupted run never leaves a partial file for the next one
+func SaveLastStatus(path string, status *protocol.StatusData) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create status cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to encode status: %w", err)
+	}
+
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write last status: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to replace last status: %w", err)
+	}
+
+	return nil
+}
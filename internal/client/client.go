@@ -1,16 +1,21 @@
 package client
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"net"
-	"os"
+	"syscall"
 	"time"
 
+	"github.com/dom1nux/legionbatctl/internal/paths"
 	"github.com/dom1nux/legionbatctl/internal/protocol"
 )
 
 const (
-	DefaultSocketPath = "/var/run/legionbatctl.sock"
+	DefaultSocketPath = paths.SystemSocketPath
 	DefaultTimeout    = 10 * time.Second
 )
 
@@ -18,16 +23,16 @@ const (
 type Client struct {
 	socketPath string
 	timeout    time.Duration
+	framing    string    // Framing negotiated for each connection; empty means line framing
+	trace      io.Writer // Receives a line per request with its ID, if set
+	pool       *pool     // Connections shared between requests, if enabled
 }
 
-// NewClient creates a new client instance
+// NewClient creates a new client instance. An empty socket path uses
+// SOCKET_PATH, or the default socket for the current user.
 func NewClient(socketPath string) *Client {
 	if socketPath == "" {
-		// Check environment variable first
-		socketPath = os.Getenv("SOCKET_PATH")
-		if socketPath == "" {
-			socketPath = DefaultSocketPath
-		}
+		socketPath = paths.SocketPath()
 	}
 
 	return &Client{
@@ -38,14 +43,9 @@ func NewClient(socketPath string) *Client {
 
 // NewClientWithTimeout creates a new client with custom timeout
 func NewClientWithTimeout(socketPath string, timeout time.Duration) *Client {
-	if socketPath == "" {
-		socketPath = DefaultSocketPath
-	}
-
-	return &Client{
-		socketPath: socketPath,
-		timeout:    timeout,
-	}
+	c := NewClient(socketPath)
+	c.timeout = timeout
+	return c
 }
 
 // SetTimeout sets the connection timeout
@@ -53,6 +53,19 @@ func (c *Client) SetTimeout(timeout time.Duration) {
 	c.timeout = timeout
 }
 
+// SetFraming selects the framing to negotiate with the daemon, e.g.
+// protocol.FramingLength for large responses. Daemons that don't support it
+// keep using line framing.
+func (c *Client) SetFraming(framing string) {
+	c.framing = framing
+}
+
+// SetTrace makes the client write a line to w for every request and
+// response, with the request ID the daemon logs them under; nil disables it
+func (c *Client) SetTrace(w io.Writer) {
+	c.trace = w
+}
+
 // GetTimeout returns the current timeout
 func (c *Client) GetTimeout() time.Duration {
 	return c.timeout
@@ -76,24 +89,49 @@ func (c *Client) IsDaemonRunning() bool {
 
 // SendRequest sends a request to the daemon and returns the response
 func (c *Client) SendRequest(command string, params map[string]interface{}) (*protocol.Response, error) {
+	if c.pool != nil {
+		return c.pool.send(c, command, params)
+	}
+
 	conn, err := c.connect()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
 	}
 	defer conn.Close()
 
+	codec, err := c.newCodec(conn)
+	if err != nil {
+		return nil, err
+	}
+	return c.exchange(codec, command, params)
+}
+
+// newCodec returns the codec for a new connection, switched to the client's
+// framing
+func (c *Client) newCodec(conn net.Conn) (*protocol.Codec, error) {
 	codec := protocol.NewCodec(conn)
 
+	if c.framing != "" && c.framing != protocol.FramingLine {
+		if err := negotiateFraming(codec, c.framing); err != nil {
+			return nil, err
+		}
+	}
+	return codec, nil
+}
+
+// exchange sends a request over codec and receives its response
+func (c *Client) exchange(codec *protocol.Codec, command string, params map[string]interface{}) (*protocol.Response, error) {
 	// Send request
-	_, err = codec.SendRequest(command, params)
+	request, err := codec.SendRequest(command, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", classifyConnError(err))
 	}
+	c.tracef("-> %s request_id=%s", command, request.ID)
 
 	// Receive response
 	msg, err := codec.ReceiveMessage()
 	if err != nil {
-		return nil, fmt.Errorf("failed to receive response: %w", err)
+		return nil, fmt.Errorf("failed to receive response: %w", classifyConnError(err))
 	}
 
 	if !msg.IsResponse() {
@@ -105,14 +143,51 @@ func (c *Client) SendRequest(command string, params map[string]interface{}) (*pr
 		return nil, fmt.Errorf("missing response data")
 	}
 
+	if response.Success {
+		c.tracef("<- %s request_id=%s ok", command, msg.ID)
+	} else {
+		c.tracef("<- %s request_id=%s error=%q", command, msg.ID, response.Error)
+	}
 	return response, nil
 }
 
+// tracef writes a trace line if tracing is enabled
+func (c *Client) tracef(format string, args ...any) {
+	if c.trace != nil {
+		fmt.Fprintf(c.trace, format+"\n", args...)
+	}
+}
+
+// negotiateFraming asks the daemon to switch the connection to framing and
+// switches the codec to the framing it agreed on
+func negotiateFraming(codec *protocol.Codec, framing string) error {
+	if _, err := codec.SendRequest(protocol.CmdHello, map[string]interface{}{"framing": framing}); err != nil {
+		return fmt.Errorf("failed to send request: %w", classifyConnError(err))
+	}
+
+	msg, err := codec.ReceiveMessage()
+	if err != nil {
+		return fmt.Errorf("failed to receive response: %w", classifyConnError(err))
+	}
+
+	// Older daemons don't know hello and stay line framed
+	response := msg.GetResponse()
+	if response == nil || !response.Success {
+		return nil
+	}
+
+	hello := &protocol.HelloData{}
+	if err := decodeData(response.Data, hello); err != nil {
+		return err
+	}
+	return codec.SetFraming(hello.Framing)
+}
+
 // connect creates a connection to the daemon with timeout
 func (c *Client) connect() (net.Conn, error) {
 	conn, err := net.DialTimeout("unix", c.socketPath, c.timeout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to dial socket %s: %w", c.socketPath, err)
+		return nil, fmt.Errorf("failed to dial socket %s: %w", c.socketPath, classifyConnError(err))
 	}
 
 	// Set socket timeout
@@ -124,6 +199,20 @@ func (c *Client) connect() (net.Conn, error) {
 	return conn, nil
 }
 
+// classifyConnError attaches a protocol error code to socket-level failures
+func classifyConnError(err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, syscall.ECONNREFUSED):
+		return fmt.Errorf("%w: %v", protocol.ErrDaemonNotRunning, err)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("%w: %v", protocol.ErrSocketPermission, err)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("%w: %v", protocol.NewCodedError(protocol.CodeDaemonNotResponding, "daemon not responding"), err)
+	}
+	return err
+}
+
 // Enable enables battery management
 func (c *Client) Enable() error {
 	response, err := c.SendRequest(protocol.CmdEnable, nil)
@@ -132,24 +221,61 @@ func (c *Client) Enable() error {
 	}
 
 	if !response.Success {
-		return fmt.Errorf("enable command failed: %s", response.Error)
+		return fmt.Errorf("enable command failed: %w", protocol.ResponseError(response))
 	}
 
 	return nil
 }
 
-// Disable disables battery management
-func (c *Client) Disable() error {
-	response, err := c.SendRequest(protocol.CmdDisable, nil)
+// Disable disables battery management. A positive duration has the daemon
+// re-enable management automatically once it has elapsed.
+func (c *Client) Disable(duration time.Duration) (*protocol.DisableData, error) {
+	var params map[string]interface{}
+	if duration > 0 {
+		params = map[string]interface{}{"for": duration.String()}
+	}
+
+	response, err := c.SendRequest(protocol.CmdDisable, params)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !response.Success {
-		return fmt.Errorf("disable command failed: %s", response.Error)
+		return nil, fmt.Errorf("disable command failed: %w", protocol.ResponseError(response))
 	}
 
-	return nil
+	data := &protocol.DisableData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ChargeFull charges the battery to 100% once, after which the daemon
+// re-enables battery management. A non-zero deadline has the daemon delay
+// charging so the battery is full by then.
+func (c *Client) ChargeFull(by time.Time) (*protocol.ChargeFullData, error) {
+	var params map[string]interface{}
+	if !by.IsZero() {
+		params = map[string]interface{}{"by": by.Format(time.RFC3339)}
+	}
+
+	response, err := c.SendRequest(protocol.CmdChargeFull, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("charge_full command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.ChargeFullData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
 }
 
 // SetThreshold sets the charge threshold
@@ -164,21 +290,72 @@ func (c *Client) SetThreshold(threshold int) error {
 	}
 
 	if !response.Success {
-		return fmt.Errorf("set_threshold command failed: %s", response.Error)
+		return fmt.Errorf("set_threshold command failed: %w", protocol.ResponseError(response))
+	}
+
+	return nil
+}
+
+// SetChargeThresholds sets the charge threshold together with the start
+// threshold below which charging resumes (0 falls back to the hysteresis)
+func (c *Client) SetChargeThresholds(threshold, start int) error {
+	params := map[string]interface{}{
+		"threshold":       threshold,
+		"start_threshold": start,
+	}
+
+	response, err := c.SendRequest(protocol.CmdSetThreshold, params)
+	if err != nil {
+		return err
+	}
+
+	if !response.Success {
+		return fmt.Errorf("set_threshold command failed: %w", protocol.ResponseError(response))
 	}
 
 	return nil
 }
 
-// GetStatus retrieves the current system status
-func (c *Client) GetStatus() (*protocol.StatusData, error) {
-	response, err := c.SendRequest(protocol.CmdStatus, nil)
+// DryRun asks the daemon what a command (enable, disable or set_threshold)
+// would change, without applying it
+func (c *Client) DryRun(command string, params map[string]interface{}) (*protocol.PlanData, error) {
+	request := map[string]interface{}{"dry_run": true}
+	for key, value := range params {
+		request[key] = value
+	}
+
+	response, err := c.SendRequest(command, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("%s dry run failed: %w", command, protocol.ResponseError(response))
+	}
+
+	data := &protocol.PlanData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetStatus retrieves the current system status. When fresh is set the daemon
+// reads the hardware instead of serving its cached snapshot.
+func (c *Client) GetStatus(fresh bool) (*protocol.StatusData, error) {
+	var params map[string]interface{}
+	if fresh {
+		params = map[string]interface{}{"fresh": true}
+	}
+
+	response, err := c.SendRequest(protocol.CmdStatus, params)
 	if err != nil {
 		return nil, err
 	}
 
 	if !response.Success {
-		return nil, fmt.Errorf("status command failed: %s", response.Error)
+		return nil, fmt.Errorf("status command failed: %w", protocol.ResponseError(response))
 	}
 
 	// Parse response data
@@ -197,6 +374,10 @@ func (c *Client) GetStatus() (*protocol.StatusData, error) {
 		status.Threshold = int(threshold)
 	}
 
+	if startThreshold, ok := data["start_threshold"].(float64); ok {
+		status.StartThreshold = int(startThreshold)
+	}
+
 	if currentMode, ok := data["current_mode"].(string); ok {
 		status.CurrentMode = currentMode
 	}
@@ -225,6 +406,107 @@ func (c *Client) GetStatus() (*protocol.StatusData, error) {
 		status.HardwareSupported = hardwareSupported
 	}
 
+	if fresh, ok := data["fresh"].(bool); ok {
+		status.Fresh = fresh
+	}
+
+	if readingAge, ok := data["reading_age"].(string); ok {
+		status.ReadingAge = readingAge
+	}
+
+	if uncleanShutdowns, ok := data["unclean_shutdowns"].(float64); ok {
+		status.UncleanShutdowns = int(uncleanShutdowns)
+	}
+
+	if chargeFull, ok := data["charge_full"].(bool); ok {
+		status.ChargeFull = chargeFull
+	}
+
+	if chargeFullBy, ok := data["charge_full_by"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, chargeFullBy); err == nil {
+			status.ChargeFullBy = t
+		}
+	}
+
+	if chargeFullStart, ok := data["charge_full_start"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, chargeFullStart); err == nil {
+			status.ChargeFullStart = t
+		}
+	}
+
+	if schedule, ok := data["schedule"].(string); ok {
+		status.Schedule = schedule
+	}
+
+	if storageMode, ok := data["storage_mode"].(bool); ok {
+		status.StorageMode = storageMode
+	}
+
+	if storageTarget, ok := data["storage_target"].(float64); ok {
+		status.StorageTarget = int(storageTarget)
+	}
+
+	if forceDischarging, ok := data["force_discharging"].(bool); ok {
+		status.ForceDischarging = forceDischarging
+	}
+
+	if lowChargePower, ok := data["low_charge_power"].(bool); ok {
+		status.LowChargePower = lowChargePower
+	}
+
+	if chargePower, ok := data["charge_power"].(float64); ok {
+		status.ChargePower = chargePower
+	}
+
+	if typicalChargePower, ok := data["typical_charge_power"].(float64); ok {
+		status.TypicalChargePower = typicalChargePower
+	}
+
+	if rapidCharge, ok := data["rapid_charge"].(string); ok {
+		status.RapidCharge = rapidCharge
+	}
+
+	if powerMode, ok := data["power_mode"].(string); ok {
+		status.PowerMode = powerMode
+	}
+
+	if docking, ok := data["docking"].(string); ok {
+		status.Docking = docking
+	}
+
+	if suggested, ok := data["suggested_threshold"].(float64); ok {
+		status.SuggestedThreshold = int(suggested)
+	}
+
+	if adapter, ok := data["adapter"].(map[string]interface{}); ok {
+		status.Adapter = &protocol.AdapterData{}
+		if err := decodeData(adapter, status.Adapter); err != nil {
+			status.Adapter = nil
+		}
+	}
+
+	if reenableAt, ok := data["reenable_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, reenableAt); err == nil {
+			status.ReenableAt = t
+		}
+	}
+
+	if paused, ok := data["paused"].(bool); ok {
+		status.Paused = paused
+	}
+
+	if pausedUntil, ok := data["paused_until"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, pausedUntil); err == nil {
+			status.PausedUntil = t
+		}
+	}
+
+	if lastUnclean, ok := data["last_unclean_shutdown"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, lastUnclean); err == nil {
+			status.LastUncleanShutdown = t
+		}
+	}
+
 	return status, nil
 }
 
@@ -236,7 +518,7 @@ func (c *Client) GetDaemonStatus() (*protocol.DaemonStatusData, error) {
 	}
 
 	if !response.Success {
-		return nil, fmt.Errorf("daemon_status command failed: %s", response.Error)
+		return nil, fmt.Errorf("daemon_status command failed: %w", protocol.ResponseError(response))
 	}
 
 	// Parse response data
@@ -274,15 +556,538 @@ func (c *Client) GetDaemonStatus() (*protocol.DaemonStatusData, error) {
 	return status, nil
 }
 
+// GetLimits retrieves every charge-related hardware control
+func (c *Client) GetLimits() (*protocol.LimitsData, error) {
+	return c.requestLimits(protocol.CmdGetLimits, nil)
+}
+
+// SetLimits changes the given charge-related hardware controls, keyed by their
+// protocol names (e.g. "end_threshold"), and returns the resulting limits
+func (c *Client) SetLimits(changes map[string]interface{}) (*protocol.LimitsData, error) {
+	return c.requestLimits(protocol.CmdSetLimits, changes)
+}
+
+// requestLimits sends a limits command and decodes the returned limits
+func (c *Client) requestLimits(command string, params map[string]interface{}) (*protocol.LimitsData, error) {
+	response, err := c.SendRequest(command, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("%s command failed: %w", command, protocol.ResponseError(response))
+	}
+
+	limits := &protocol.LimitsData{}
+	if err := decodeData(response.Data, limits); err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// GetPowerMode retrieves the platform power profile
+func (c *Client) GetPowerMode() (*protocol.PowerModeData, error) {
+	return c.requestPowerMode(protocol.CmdGetPowerMode, nil)
+}
+
+// SetPowerMode switches the platform power profile
+func (c *Client) SetPowerMode(mode string) (*protocol.PowerModeData, error) {
+	return c.requestPowerMode(protocol.CmdSetPowerMode, map[string]interface{}{"mode": mode})
+}
+
+// requestPowerMode sends a power mode command and decodes the returned mode
+func (c *Client) requestPowerMode(command string, params map[string]interface{}) (*protocol.PowerModeData, error) {
+	response, err := c.SendRequest(command, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("%s command failed: %w", command, protocol.ResponseError(response))
+	}
+
+	data := &protocol.PowerModeData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetRapidCharge retrieves whether rapid charge is on
+func (c *Client) GetRapidCharge() (*protocol.RapidChargeData, error) {
+	return c.requestRapidCharge(protocol.CmdGetRapidCharge, nil)
+}
+
+// SetRapidCharge switches rapid charge on or off
+func (c *Client) SetRapidCharge(enable bool) (*protocol.RapidChargeData, error) {
+	return c.requestRapidCharge(protocol.CmdSetRapidCharge, map[string]interface{}{"enable": enable})
+}
+
+// requestRapidCharge sends a rapid charge command and decodes the returned state
+func (c *Client) requestRapidCharge(command string, params map[string]interface{}) (*protocol.RapidChargeData, error) {
+	response, err := c.SendRequest(command, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("%s command failed: %w", command, protocol.ResponseError(response))
+	}
+
+	data := &protocol.RapidChargeData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// SetStorageMode switches storage mode on or off. A positive target sets the
+// level to hold; otherwise the daemon default is used.
+func (c *Client) SetStorageMode(enable bool, target int) (*protocol.StorageData, error) {
+	params := map[string]interface{}{"enable": enable}
+	if target > 0 {
+		params["target"] = target
+	}
+
+	response, err := c.SendRequest(protocol.CmdStorage, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("storage command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.StorageData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Pause stops the daemon from switching the hardware until Resume, or for
+// the duration if it is positive
+func (c *Client) Pause(duration time.Duration) (*protocol.PauseData, error) {
+	var params map[string]interface{}
+	if duration > 0 {
+		params = map[string]interface{}{"for": duration.String()}
+	}
+	return c.requestPause(protocol.CmdPause, params)
+}
+
+// Resume has the daemon switch the hardware again after Pause
+func (c *Client) Resume() (*protocol.PauseData, error) {
+	return c.requestPause(protocol.CmdResume, nil)
+}
+
+// requestPause sends a pause or resume command
+func (c *Client) requestPause(command string, params map[string]interface{}) (*protocol.PauseData, error) {
+	response, err := c.SendRequest(command, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("%s command failed: %w", command, protocol.ResponseError(response))
+	}
+
+	data := &protocol.PauseData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// CreateSnapshot saves the current settings as a snapshot, with an optional
+// note
+func (c *Client) CreateSnapshot(note string) (*protocol.SnapshotData, error) {
+	var params map[string]interface{}
+	if note != "" {
+		params = map[string]interface{}{"note": note}
+	}
+
+	response, err := c.SendRequest(protocol.CmdSnapshotCreate, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("snapshot_create command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.SnapshotData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ListSnapshots retrieves the saved snapshots, oldest first
+func (c *Client) ListSnapshots() (*protocol.SnapshotListData, error) {
+	response, err := c.SendRequest(protocol.CmdSnapshotList, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("snapshot_list command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.SnapshotListData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// RollbackSnapshot restores the settings saved in the snapshot with the given
+// ID
+func (c *Client) RollbackSnapshot(id int) (*protocol.SnapshotRollbackData, error) {
+	response, err := c.SendRequest(protocol.CmdSnapshotRollback, map[string]interface{}{"id": id})
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("snapshot_rollback command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.SnapshotRollbackData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetHealth retrieves the battery health, with the capacity trend if trend
+// is set
+func (c *Client) GetHealth(trend bool) (*protocol.HealthData, error) {
+	var params map[string]interface{}
+	if trend {
+		params = map[string]interface{}{"trend": true}
+	}
+
+	response, err := c.SendRequest(protocol.CmdHealth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("health command failed: %w", protocol.ResponseError(response))
+	}
+
+	health := &protocol.HealthData{}
+	if err := decodeData(response.Data, health); err != nil {
+		return nil, err
+	}
+
+	return health, nil
+}
+
+// GetAdaptive retrieves the threshold learned from the battery's discharges
+func (c *Client) GetAdaptive() (*protocol.AdaptiveData, error) {
+	response, err := c.SendRequest(protocol.CmdAdaptive, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("adaptive command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.AdaptiveData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetBatteryInfo retrieves detailed battery diagnostics
+func (c *Client) GetBatteryInfo() (*protocol.BatteryInfoData, error) {
+	response, err := c.SendRequest(protocol.CmdBatteryInfo, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("battery info command failed: %w", protocol.ResponseError(response))
+	}
+
+	info := &protocol.BatteryInfoData{}
+	if err := decodeData(response.Data, info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// GetHistory retrieves the recorded battery history between since and
+// until; zero times leave the range open
+func (c *Client) GetHistory(since, until time.Time) (*protocol.HistoryData, error) {
+	params := map[string]interface{}{}
+	if !since.IsZero() {
+		params["since"] = since.Format(time.RFC3339)
+	}
+	if !until.IsZero() {
+		params["until"] = until.Format(time.RFC3339)
+	}
+
+	response, err := c.SendRequest(protocol.CmdHistory, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("history command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.HistoryData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetStats retrieves aggregates of the battery history between since and
+// until; a zero until means now
+func (c *Client) GetStats(since, until time.Time) (*protocol.StatsData, error) {
+	params := map[string]interface{}{}
+	if !since.IsZero() {
+		params["since"] = since.Format(time.RFC3339)
+	}
+	if !until.IsZero() {
+		params["until"] = until.Format(time.RFC3339)
+	}
+
+	response, err := c.SendRequest(protocol.CmdStats, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("stats command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.StatsData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetAudit retrieves the control commands recorded between since and until;
+// zero times leave the range open
+func (c *Client) GetAudit(since, until time.Time) (*protocol.AuditData, error) {
+	params := map[string]interface{}{}
+	if !since.IsZero() {
+		params["since"] = since.Format(time.RFC3339)
+	}
+	if !until.IsZero() {
+		params["until"] = until.Format(time.RFC3339)
+	}
+
+	response, err := c.SendRequest(protocol.CmdAudit, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("audit command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.AuditData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetLogs retrieves the last n lines of the daemon's log; n <= 0 retrieves
+// every line the daemon keeps
+func (c *Client) GetLogs(n int) (*protocol.LogsData, error) {
+	params := map[string]interface{}{}
+	if n > 0 {
+		params["lines"] = n
+	}
+	return c.requestLogs(params)
+}
+
+// GetLogsSince retrieves the lines of the daemon's log after the line
+// numbered seq, for following the log
+func (c *Client) GetLogsSince(seq uint64) (*protocol.LogsData, error) {
+	return c.requestLogs(map[string]interface{}{"since": seq})
+}
+
+// SetLogLevel overrides the level of every daemon log sink until the daemon
+// restarts; an empty level returns them to their configured levels
+func (c *Client) SetLogLevel(level string) (*protocol.LogLevelData, error) {
+	response, err := c.SendRequest(protocol.CmdSetLogLevel, map[string]interface{}{"level": level})
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("set_log_level command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.LogLevelData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ReloadConfig makes the daemon reread its configuration file
+func (c *Client) ReloadConfig() (*protocol.ReloadConfigData, error) {
+	response, err := c.SendRequest(protocol.CmdReloadConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("reload_config command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.ReloadConfigData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Batch sends several commands in one request. The daemon runs them in order
+// with no other request in between, skipping those after the first failure
+// and rolling back those before it, and answers with a result per command.
+func (c *Client) Batch(items []protocol.BatchItem) (*protocol.BatchData, error) {
+	response, err := c.SendRequest(protocol.CmdBatch, map[string]interface{}{"requests": items})
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("batch command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.BatchData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// EnableWithThreshold sets the charge threshold and enables battery
+// management in a single request, so management never runs with the old
+// threshold
+func (c *Client) EnableWithThreshold(threshold int) error {
+	data, err := c.Batch([]protocol.BatchItem{
+		{Command: protocol.CmdSetThreshold, Params: map[string]interface{}{"threshold": threshold}},
+		{Command: protocol.CmdEnable},
+	})
+	if err != nil {
+		return err
+	}
+	return batchError(data)
+}
+
+// batchError returns the error of the failed command of a batch, if any
+func batchError(data *protocol.BatchData) error {
+	for _, result := range data.Results {
+		if !result.Success && !result.Skipped && !result.RolledBack {
+			return fmt.Errorf("%s command failed: %w", result.Command, protocol.NewCodedError(result.Code, result.Error))
+		}
+	}
+	return nil
+}
+
+// requestLogs sends a logs command and decodes the returned lines
+func (c *Client) requestLogs(params map[string]interface{}) (*protocol.LogsData, error) {
+	response, err := c.SendRequest(protocol.CmdLogs, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("logs command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.LogsData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetSchedule retrieves the schedule rules and the currently active rule
+func (c *Client) GetSchedule() (*protocol.ScheduleData, error) {
+	return c.requestSchedule(protocol.CmdGetSchedule, nil)
+}
+
+// SetSchedulePaused pauses or resumes schedule rules
+func (c *Client) SetSchedulePaused(paused bool) (*protocol.ScheduleData, error) {
+	return c.requestSchedule(protocol.CmdSetSchedule, map[string]interface{}{"paused": paused})
+}
+
+// requestSchedule sends a schedule command and decodes the returned schedule
+func (c *Client) requestSchedule(command string, params map[string]interface{}) (*protocol.ScheduleData, error) {
+	response, err := c.SendRequest(command, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("%s command failed: %w", command, protocol.ResponseError(response))
+	}
+
+	schedule := &protocol.ScheduleData{}
+	if err := decodeData(response.Data, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// decodeData converts generic response data into a typed protocol struct
+func decodeData(data interface{}, out interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("invalid response data format: %w", err)
+	}
+
+	if err := json.Unmarshal(encoded, out); err != nil {
+		return fmt.Errorf("invalid response data format: %w", err)
+	}
+
+	return nil
+}
+
 // Ping sends a ping to the daemon to check if it's responsive
 func (c *Client) Ping() error {
 	_, err := c.SendRequest(protocol.CmdDaemonStatus, nil)
 	return err
 }
 
-// Close closes the client (no-op as connections are short-lived)
+// Close closes the client's pooled connections. Without a pool it is a no-op,
+// as connections are short-lived.
 func (c *Client) Close() error {
-	// No persistent connection to close
+	if c.pool != nil {
+		c.pool.close()
+	}
 	return nil
 }
 
@@ -290,3 +1095,22 @@ func (c *Client) Close() error {
 func (c *Client) String() string {
 	return fmt.Sprintf("legionbatctl Client{socket: %s, timeout: %v}", c.socketPath, c.timeout)
 }
+
+// Explain asks the daemon why conservation mode is currently on or off
+func (c *Client) Explain() (*protocol.ExplainData, error) {
+	response, err := c.SendRequest(protocol.CmdExplain, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("explain command failed: %w", protocol.ResponseError(response))
+	}
+
+	data := &protocol.ExplainData{}
+	if err := decodeData(response.Data, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
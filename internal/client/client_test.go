@@ -1,12 +1,16 @@
 package client
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/dom1nux/legionbatctl/internal/daemon"
+	"github.com/dom1nux/legionbatctl/internal/paths"
 	"github.com/dom1nux/legionbatctl/internal/protocol"
 )
 
@@ -28,10 +32,11 @@ func TestNewClient(t *testing.T) {
 }
 
 func TestNewClientWithDefaults(t *testing.T) {
+	t.Setenv(paths.SocketPathEnv, "")
 	client := NewClient("")
 
-	if client.GetSocketPath() != DefaultSocketPath {
-		t.Errorf("Expected default socket path %s, got %s", DefaultSocketPath, client.GetSocketPath())
+	if want := paths.Defaults().Socket; client.GetSocketPath() != want {
+		t.Errorf("Expected default socket path %s, got %s", want, client.GetSocketPath())
 	}
 }
 
@@ -260,6 +265,168 @@ func TestFormatStatus(t *testing.T) {
 	if !contains(formatted, "Battery Level: 75%") {
 		t.Error("Expected battery level in formatted output")
 	}
+
+	now := time.Now()
+	pause := &protocol.StatusData{Paused: true, PausedUntil: now.Add(90 * time.Minute)}
+	if got := formatPause(pause, now); !contains(got, "(1h30m left)") {
+		t.Errorf("Expected the time left in the pause, got %q", got)
+	}
+	pause.PausedUntil = time.Time{}
+	if got := formatPause(pause, now); !contains(got, "until resumed") {
+		t.Errorf("Expected an indefinite pause, got %q", got)
+	}
+
+	status.Adapter = &protocol.AdapterData{Name: "ucsi-source-psy-USBC000:001", Type: "usb-c", PD: true, Watts: 100}
+	if formatted := FormatStatus(status); !contains(formatted, "Power Adapter: USB-C PD, 100 W") {
+		t.Errorf("Expected the power adapter in formatted output:\n%s", formatted)
+	}
+}
+
+func TestFormatStatusColor(t *testing.T) {
+	if err := SetColorTheme("unknown"); err == nil {
+		t.Error("Expected error for unknown theme")
+	}
+	if err := SetColorTheme(DefaultTheme); err != nil {
+		t.Fatalf("SetColorTheme() error = %v", err)
+	}
+	t.Cleanup(DisableColor)
+
+	tests := []struct {
+		name   string
+		status protocol.StatusData
+		want   []string
+	}{
+		{
+			name:   "healthy",
+			status: protocol.StatusData{ConservationEnabled: true, CurrentMode: "enabled", BatteryLevel: 75, HardwareSupported: true},
+			want:   []string{"Battery Level: \x1b[32m75%\x1b[0m", "Current Mode: \x1b[32menabled\x1b[0m"},
+		},
+		{
+			name:   "low and unmanaged",
+			status: protocol.StatusData{CurrentMode: "disabled", BatteryLevel: 15},
+			want: []string{
+				"Battery Level: \x1b[31m15%\x1b[0m",
+				"Conservation Management: \x1b[33mdisabled\x1b[0m",
+				"Hardware Supported: \x1b[31mdisabled\x1b[0m",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted := FormatStatus(&tt.status)
+			for _, want := range tt.want {
+				if !contains(formatted, want) {
+					t.Errorf("Expected %q in output, got: %q", want, formatted)
+				}
+			}
+		})
+	}
+
+	DisableColor()
+	if formatted := FormatStatus(&tests[0].status); contains(formatted, "\x1b[") {
+		t.Errorf("Expected no escape codes with color disabled, got: %q", formatted)
+	}
+}
+
+func TestFormatPlan(t *testing.T) {
+	tests := []struct {
+		name string
+		plan protocol.PlanData
+		want []string
+	}{
+		{
+			name: "switches on",
+			plan: protocol.PlanData{Changes: []string{"battery management: disabled → enabled"}, ConservationAfter: true},
+			want: []string{"  battery management: disabled → enabled\n", "Conservation mode would switch on now"},
+		},
+		{
+			name: "no change",
+			plan: protocol.PlanData{Notes: []string{"battery management is disabled"}},
+			want: []string{"No settings would change", "Conservation mode would stay off", "Note: battery management is disabled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted := FormatPlan(&tt.plan)
+			for _, want := range tt.want {
+				if !contains(formatted, want) {
+					t.Errorf("Expected %q in output, got: %s", want, formatted)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatSnapshotResult(t *testing.T) {
+	snapshot := protocol.SnapshotData{ID: 3, Threshold: 80, StartThreshold: 70, Enabled: true, Profiles: []string{"desk", "travel"}}
+	tests := []struct {
+		name   string
+		result *CommandResult
+		want   []string
+	}{
+		{
+			name:   "created",
+			result: &CommandResult{Success: true, Message: "Created snapshot 3", Data: &snapshot},
+			want:   []string{"✓ Created snapshot 3: 80% (start 70%), enabled.", "legionbatctl snapshot rollback 3"},
+		},
+		{
+			name:   "list",
+			result: &CommandResult{Success: true, Data: &protocol.SnapshotListData{Snapshots: []protocol.SnapshotData{snapshot}}},
+			want:   []string{"ID", "80% (start 70%), enabled", "desk,travel"},
+		},
+		{
+			name:   "empty list",
+			result: &CommandResult{Success: true, Data: &protocol.SnapshotListData{}},
+			want:   []string{"No snapshots saved"},
+		},
+		{
+			name: "rolled back",
+			result: &CommandResult{Success: true, Data: &protocol.SnapshotRollbackData{
+				Message: "Rolled back to snapshot 3", Snapshot: snapshot, ConfigRestored: true, ConfigBackup: "/etc/legionbatctl.conf.bak",
+			}},
+			want: []string{"✓ Rolled back to snapshot 3", "keeping the replaced one as /etc/legionbatctl.conf.bak"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted := FormatSnapshotResult(tt.result)
+			for _, want := range tt.want {
+				if !contains(formatted, want) {
+					t.Errorf("Expected %q in output, got: %s", want, formatted)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatExplain(t *testing.T) {
+	data := &protocol.ExplainData{
+		ConservationMode:  true,
+		ManagementEnabled: true,
+		ACOnline:          true,
+		BatteryLevel:      81,
+		Threshold:         80,
+		StartThreshold:    75,
+		Hysteresis:        5,
+		Steps:             []string{"Battery management is enabled", "Battery is at 81%, at or above the 80% threshold"},
+	}
+
+	formatted := FormatExplain(data)
+	for _, want := range []string{
+		"Conservation mode is on because:",
+		"  1. Battery management is enabled\n",
+		"  2. Battery is at 81%",
+		"AC Power: connected",
+		"Charging Resumes Below: 75%",
+		"Last Switch: never",
+	} {
+		if !contains(formatted, want) {
+			t.Errorf("Expected %q in output, got: %s", want, formatted)
+		}
+	}
 }
 
 func TestFormatEnableResult(t *testing.T) {
@@ -356,3 +523,384 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestFormatStatusShort(t *testing.T) {
+	status := &protocol.StatusData{
+		ConservationEnabled: true,
+		Threshold:           80,
+		BatteryLevel:        76,
+		ConservationMode:    true,
+		Charging:            true,
+	}
+
+	if got := FormatStatusShort(status, DefaultShortGlyphs); got != "76% = held@80 (conservation on)\n" {
+		t.Errorf("Unexpected short status: %q", got)
+	}
+
+	glyphs, err := ParseShortGlyphs("+,-,#")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing glyphs: %v", err)
+	}
+
+	status.ConservationEnabled = false
+	status.ConservationMode = false
+	status.Charging = false
+	if got := FormatStatusShort(status, glyphs); got != "76% - unmanaged (conservation off)\n" {
+		t.Errorf("Unexpected short status: %q", got)
+	}
+
+	if _, err := ParseShortGlyphs("+,-"); err == nil {
+		t.Error("Expected error for incomplete glyph list")
+	}
+}
+
+func TestFormatFailure(t *testing.T) {
+	client := NewClient(filepath.Join(t.TempDir(), "missing.sock"))
+	result := NewCommandExecutor(client).ExecuteStatus(false)
+
+	if result.Code != protocol.CodeDaemonNotRunning {
+		t.Fatalf("Expected code %s, got %q", protocol.CodeDaemonNotRunning, result.Code)
+	}
+
+	formatted := FormatStatusResult(result)
+	if !contains(formatted, "Try:   sudo systemctl start legionbatctl") {
+		t.Errorf("Expected suggested command in failure output, got: %s", formatted)
+	}
+
+	// Unknown codes render without a suggestion
+	formatted = FormatFailure("Failed", &CommandResult{Error: "boom"})
+	if contains(formatted, "Try:") {
+		t.Errorf("Expected no suggestion for unknown code, got: %s", formatted)
+	}
+
+	// Thresholds outside the hardware's range are shown with it
+	formatted = FormatFailure("Failed to set threshold to 50", &CommandResult{
+		Error: "threshold must be between 60 and 100",
+		Code:  protocol.CodeInvalidThreshold,
+		Range: &protocol.ThresholdRangeData{Min: 60, Max: 100, Step: 1},
+	})
+	if !contains(formatted, "Range: 60-100% on this hardware") || !contains(formatted, "Try:   legionbatctl set-threshold 80") {
+		t.Errorf("Expected the allowed range in failure output, got: %s", formatted)
+	}
+
+	// A fix carried by the error replaces the generic suggestion
+	formatted = FormatFailure("Failed", &CommandResult{
+		Error: "permission denied",
+		Code:  protocol.CodePermissionDenied,
+		Hint:  "run as root",
+	})
+	if !contains(formatted, "Fix:   run as root") || contains(formatted, "Try:") {
+		t.Errorf("Expected the error's fix in failure output, got: %s", formatted)
+	}
+}
+
+func TestFormatStatusline(t *testing.T) {
+	status := &protocol.StatusData{
+		ConservationEnabled: true,
+		Threshold:           80,
+		BatteryLevel:        80,
+		ConservationMode:    true,
+		Charging:            true,
+	}
+
+	waybar, err := FormatStatusline(status, StatuslineWaybar, DefaultShortGlyphs)
+	if err != nil {
+		t.Fatalf("Unexpected error formatting waybar output: %v", err)
+	}
+	if !contains(waybar, `"text":"80% ="`) || !contains(waybar, `"class":"held"`) {
+		t.Errorf("Unexpected waybar output: %s", waybar)
+	}
+
+	i3blocks, err := FormatStatusline(status, StatuslineI3blocks, DefaultShortGlyphs)
+	if err != nil {
+		t.Fatalf("Unexpected error formatting i3blocks output: %v", err)
+	}
+	if i3blocks != "80% =\n80% =\n#a6e3a1\n" {
+		t.Errorf("Unexpected i3blocks output: %q", i3blocks)
+	}
+
+	if _, err := FormatStatusline(status, "dwm", DefaultShortGlyphs); err == nil {
+		t.Error("Expected error for unsupported style")
+	}
+}
+
+func TestIconThemes(t *testing.T) {
+	tests := []struct {
+		theme    string
+		status   protocol.StatusData
+		expected string
+	}{
+		{IconsASCII, protocol.StatusData{BatteryLevel: 64, Charging: true}, "64% +\n"},
+		{IconsUnicode, protocol.StatusData{BatteryLevel: 64}, "64% ⇣\n"},
+		{IconsNerdFont, protocol.StatusData{BatteryLevel: 64}, "64% \U000F007F\n"},
+		{IconsNerdFont, protocol.StatusData{BatteryLevel: 3}, "3% \U000F008E\n"},
+		{IconsNerdFont, protocol.StatusData{BatteryLevel: 100}, "100% \U000F0079\n"},
+		{IconsNerdFont, protocol.StatusData{BatteryLevel: 80, Charging: true, ConservationMode: true}, "80% \uF1E6\n"},
+	}
+
+	for _, tt := range tests {
+		glyphs, err := IconTheme(tt.theme)
+		if err != nil {
+			t.Fatalf("IconTheme(%q) failed: %v", tt.theme, err)
+		}
+		output, err := FormatStatusline(&tt.status, StatuslinePolybar, glyphs)
+		if err != nil {
+			t.Fatalf("FormatStatusline failed: %v", err)
+		}
+		if output != tt.expected {
+			t.Errorf("%s at %d%%: expected %q, got %q", tt.theme, tt.status.BatteryLevel, tt.expected, output)
+		}
+	}
+
+	ascii, _ := IconTheme(IconsASCII)
+	if output, _ := FormatStatuslineError(StatuslinePolybar, "daemon not running", ascii); output != "! legionbatctl\n" {
+		t.Errorf("Unexpected error marker: %q", output)
+	}
+	if _, err := IconTheme("emoji"); err == nil {
+		t.Error("Expected error for unknown icon theme")
+	}
+}
+
+func TestMonitorChanges(t *testing.T) {
+	first := &protocol.StatusData{BatteryLevel: 79, Charging: true}
+	if changes := MonitorChanges(nil, first); len(changes) != 3 {
+		t.Errorf("Expected every value on first reading, got %v", changes)
+	}
+
+	if changes := MonitorChanges(first, first); len(changes) != 0 {
+		t.Errorf("Expected no changes for identical readings, got %v", changes)
+	}
+
+	held := &protocol.StatusData{BatteryLevel: 80, Charging: true, ConservationMode: true}
+	changes := MonitorChanges(first, held)
+	expected := []string{"level 79% -> 80%", "conservation disabled -> enabled"}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], changes[i])
+		}
+	}
+
+	line := FormatMonitorLine(time.Date(2024, 1, 1, 9, 5, 7, 0, time.UTC), changes)
+	if line != "09:05:07  level 79% -> 80%, conservation disabled -> enabled\n" {
+		t.Errorf("Unexpected monitor line: %q", line)
+	}
+}
+
+func TestStatusChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "last-status.json")
+
+	prev, err := LoadLastStatus(path)
+	if err != nil || prev != nil {
+		t.Fatalf("Expected no saved status, got %+v, %v", prev, err)
+	}
+
+	first := &protocol.StatusData{BatteryLevel: 79, Charging: true, Threshold: 80, ConservationEnabled: true}
+	if changes := StatusChanges(nil, first); len(changes) != 10 {
+		t.Errorf("Expected every value on first reading, got %v", changes)
+	}
+	if err := SaveLastStatus(path, first); err != nil {
+		t.Fatalf("SaveLastStatus failed: %v", err)
+	}
+
+	prev, err = LoadLastStatus(path)
+	if err != nil {
+		t.Fatalf("LoadLastStatus failed: %v", err)
+	}
+	if changes := StatusChanges(prev, first); len(changes) != 0 {
+		t.Errorf("Expected no changes against the saved status, got %v", changes)
+	}
+
+	charging := &protocol.StatusData{BatteryLevel: 79, Charging: true, Threshold: 100, ConservationEnabled: true,
+		ChargeFull: true, Schedule: "trip"}
+	changes := StatusChanges(prev, charging)
+	want := "2024-01-01 09:05:07  threshold 80% -> 100%, charge full disabled -> enabled, schedule none -> trip\n"
+	if line := FormatChangesLine(time.Date(2024, 1, 1, 9, 5, 7, 0, time.UTC), changes); line != want {
+		t.Errorf("Unexpected changes line: %q", line)
+	}
+}
+
+func TestParseTimeSpec(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+		wantErr  bool
+	}{
+		{"24h", now.Add(-24 * time.Hour), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{"7d", time.Date(2025, 6, 3, 12, 0, 0, 0, time.UTC), false},
+		{"2025-06-01", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2025-06-01 08:30", time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC), false},
+		{"2025-06-01T08:30:00Z", time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC), false},
+		{"-1h", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseTimeSpec(tt.spec, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimeSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("ParseTimeSpec(%q) = %v, want %v", tt.spec, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderGraph(t *testing.T) {
+	since := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+	until := since.Add(10 * time.Minute)
+
+	entries := []protocol.HistoryEntry{
+		{Time: since, Level: 50, Threshold: 80},
+		{Time: since.Add(5 * time.Minute), Level: 100, Threshold: 80},
+	}
+
+	lines := strings.Split(RenderGraph(entries, since, until, 10, 4), "\n")
+	expected := []string{
+		" 100% ┤┈┈┈┈┈█████",
+		"      │     █████",
+		"      │██████████",
+		"      │██████████",
+		"      └──────────",
+	}
+	for i, want := range expected {
+		if lines[i] != want {
+			t.Errorf("Line %d = %q, want %q", i, lines[i], want)
+		}
+	}
+
+	// Gaps longer than a few minutes stay empty
+	gap := RenderGraph(entries[:1], since, since.Add(2*time.Hour), 4, 2)
+	if !strings.HasPrefix(gap, " 100% ┤┈   \n      │█   \n") {
+		t.Errorf("Expected only the first column filled, got %q", gap)
+	}
+
+	if got := RenderGraph(nil, since, until, 10, 4); !strings.Contains(got, "No battery history") {
+		t.Errorf("Expected a notice without history, got %q", got)
+	}
+}
+
+func TestExportHistory(t *testing.T) {
+	entries := []protocol.HistoryEntry{
+		{Time: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), Event: "sample", Level: 79, ACConnected: true, Threshold: 80},
+		{Time: time.Date(2025, 6, 1, 12, 5, 0, 0, time.UTC), Event: "conservation_on", Level: 80, ACConnected: true, ConservationMode: true, Threshold: 80},
+	}
+
+	var csvOut strings.Builder
+	if err := ExportHistory(&csvOut, entries, "csv"); err != nil {
+		t.Fatalf("CSV export failed: %v", err)
+	}
+	expectedCSV := "time,event,level,ac,conservation,threshold\n" +
+		"2025-06-01T12:00:00Z,sample,79,true,false,80\n" +
+		"2025-06-01T12:05:00Z,conservation_on,80,true,true,80\n"
+	if csvOut.String() != expectedCSV {
+		t.Errorf("Unexpected CSV:\n%s", csvOut.String())
+	}
+
+	var jsonlOut strings.Builder
+	if err := ExportHistory(&jsonlOut, entries, "jsonl"); err != nil {
+		t.Fatalf("JSONL export failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(jsonlOut.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"event":"conservation_on"`) {
+		t.Errorf("Unexpected JSONL:\n%s", jsonlOut.String())
+	}
+
+	if err := ExportHistory(&jsonlOut, entries, "xml"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestClientPool(t *testing.T) {
+	tempDir := t.TempDir()
+	socketPath := filepath.Join(tempDir, "test.sock")
+	statePath := filepath.Join(tempDir, "test_state.json")
+
+	daemonInstance := daemon.NewDaemon(socketPath, statePath)
+	if err := daemonInstance.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	defer daemonInstance.Stop()
+
+	c := NewClient(socketPath)
+	if err := c.EnablePool(0, 0); err == nil {
+		t.Error("Expected an error for an empty pool")
+	}
+	if err := c.EnablePool(2, time.Minute); err != nil {
+		t.Fatalf("Failed to enable pool: %v", err)
+	}
+	defer c.Close()
+	if c.pool.idleTimeout != maxPoolIdleTimeout {
+		t.Errorf("Expected the idle timeout capped at %v, got %v", maxPoolIdleTimeout, c.pool.idleTimeout)
+	}
+
+	// Sequential requests share one connection
+	for range 3 {
+		if err := c.Ping(); err != nil {
+			t.Fatalf("Ping failed: %v", err)
+		}
+	}
+	if len(c.pool.idle) != 1 {
+		t.Fatalf("Expected 1 pooled connection, got %d", len(c.pool.idle))
+	}
+
+	// Concurrent requests open no more connections than the pool holds
+	errs := make(chan error, 8)
+	for range cap(errs) {
+		go func() { errs <- c.Ping() }()
+	}
+	for range cap(errs) {
+		if err := <-errs; err != nil {
+			t.Errorf("Concurrent ping failed: %v", err)
+		}
+	}
+	if n := len(c.pool.idle); n < 1 || n > 2 {
+		t.Errorf("Expected 1 or 2 pooled connections, got %d", n)
+	}
+
+	// A pooled connection the daemon closed is replaced for reads
+	staleSocket := filepath.Join(tempDir, "stale.sock")
+	listener, err := net.Listen("unix", staleSocket)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	stale, err := net.Dial("unix", staleSocket)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	peer, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Failed to accept: %v", err)
+	}
+	peer.Close()
+	staleConn := &pooledConn{conn: stale, codec: protocol.NewCodec(stale), lastUsed: time.Now()}
+	c.pool.idle = append(c.pool.idle, staleConn)
+
+	if err := c.Ping(); err != nil {
+		t.Errorf("Expected the ping to be sent over a new connection: %v", err)
+	}
+	for _, pc := range c.pool.idle {
+		if pc == staleConn {
+			t.Error("Expected the stale connection to be discarded")
+		}
+	}
+
+	// Idle connections are reaped
+	c.pool.closeIdle(time.Now().Add(time.Second))
+	if len(c.pool.idle) != 0 {
+		t.Errorf("Expected idle connections to be closed, got %d", len(c.pool.idle))
+	}
+
+	c.Close()
+	if err := c.Ping(); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed after Close, got %v", err)
+	}
+}
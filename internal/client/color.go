@@ -0,0 +1,116 @@
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// Theme holds the ANSI SGR parameters used for each severity, e.g. "32"
+// for green
+type Theme struct {
+	Good string
+	Warn string
+	Bad  string
+}
+
+// Themes are the selectable color themes
+var Themes = map[string]Theme{
+	"default": {Good: "32", Warn: "33", Bad: "31"},
+	"bright":  {Good: "1;92", Warn: "1;93", Bad: "1;91"},
+	"mono":    {Good: "1", Warn: "4", Bad: "7"},
+}
+
+// DefaultTheme is the theme used unless another one is chosen
+const DefaultTheme = "default"
+
+// colorTheme is the active theme; nil leaves the output uncolored
+var colorTheme *Theme
+
+// SetColorTheme enables colored output with the named theme
+func SetColorTheme(name string) error {
+	theme, ok := Themes[name]
+	if !ok {
+		return fmt.Errorf("unknown color theme %q, available: %s", name, strings.Join(ThemeNames(), ", "))
+	}
+	colorTheme = &theme
+	return nil
+}
+
+// DisableColor turns colored output off
+func DisableColor() {
+	colorTheme = nil
+}
+
+// ThemeNames returns the names of the available themes, sorted
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// severity ranks how good a displayed value is
+type severity int
+
+const (
+	severityGood severity = iota
+	severityWarn
+	severityBad
+)
+
+// colorize wraps text in the theme's style for the severity, if color is on
+func colorize(s severity, text string) string {
+	if colorTheme == nil {
+		return text
+	}
+
+	var style string
+	switch s {
+	case severityGood:
+		style = colorTheme.Good
+	case severityWarn:
+		style = colorTheme.Warn
+	case severityBad:
+		style = colorTheme.Bad
+	}
+	return "\x1b[" + style + "m" + text + "\x1b[0m"
+}
+
+// levelSeverity rates a battery level
+func levelSeverity(level int) severity {
+	switch {
+	case level < 20:
+		return severityBad
+	case level < 50:
+		return severityWarn
+	}
+	return severityGood
+}
+
+// modeSeverity rates the mode shown in the status
+func modeSeverity(status *protocol.StatusData) severity {
+	if status.StorageMode {
+		return severityGood
+	}
+
+	switch status.CurrentMode {
+	case "enabled":
+		return severityGood
+	case "disabled":
+		return severityWarn
+	}
+	return severityBad
+}
+
+// enabledSeverity rates a setting that should normally be enabled
+func enabledSeverity(enabled bool) severity {
+	if enabled {
+		return severityGood
+	}
+	return severityWarn
+}
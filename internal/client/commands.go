@@ -2,6 +2,9 @@ package client
 
 import (
 	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/dom1nux/legionbatctl/internal/protocol"
@@ -13,7 +16,13 @@ type CommandResult struct {
 	Message  string        `json:"message"`
 	Data     interface{}   `json:"data,omitempty"`
 	Error    string        `json:"error,omitempty"`
+	Code     string        `json:"code,omitempty"` // Protocol error code of a failure
 	Duration time.Duration `json:"duration"`
+
+	// Range holds the thresholds the hardware accepts, for a failure over a
+	// threshold outside it
+	Range *protocol.ThresholdRangeData `json:"range,omitempty"`
+	Hint  string                       `json:"hint,omitempty"` // How to fix a failure
 }
 
 // CommandExecutor provides high-level command execution with result formatting
@@ -51,7 +60,10 @@ func newFailureResult(message string, err error, duration time.Duration) *Comman
 		Success:  false,
 		Message:  message,
 		Error:    err.Error(),
+		Code:     protocol.ErrorCode(err),
 		Duration: duration,
+		Range:    protocol.AllowedRange(err),
+		Hint:     protocol.ErrorHint(err),
 	}
 }
 
@@ -68,17 +80,48 @@ func (e *CommandExecutor) ExecuteEnable() *CommandResult {
 	return newSuccessResult("Battery management enabled successfully", duration)
 }
 
+// ExecuteEnableWithThreshold executes the set_threshold and enable commands
+// as one batch
+func (e *CommandExecutor) ExecuteEnableWithThreshold(threshold int) *CommandResult {
+	start := time.Now()
+	err := e.client.EnableWithThreshold(threshold)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to enable battery management", err, duration)
+	}
+
+	return newSuccessResultWithData(
+		fmt.Sprintf("Battery management enabled with a threshold of %d%%", threshold),
+		map[string]interface{}{"threshold": threshold},
+		duration,
+	)
+}
+
 // ExecuteDisable executes the disable command
-func (e *CommandExecutor) ExecuteDisable() *CommandResult {
+func (e *CommandExecutor) ExecuteDisable(forDuration time.Duration) *CommandResult {
 	start := time.Now()
-	err := e.client.Disable()
+	data, err := e.client.Disable(forDuration)
 	duration := time.Since(start)
 
 	if err != nil {
 		return newFailureResult("Failed to disable battery management", err, duration)
 	}
 
-	return newSuccessResult("Battery management disabled successfully", duration)
+	return newSuccessResultWithData("Battery management disabled successfully", data, duration)
+}
+
+// ExecuteChargeFull executes the charge_full command
+func (e *CommandExecutor) ExecuteChargeFull(by time.Time) *CommandResult {
+	start := time.Now()
+	data, err := e.client.ChargeFull(by)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to start charging to full", err, duration)
+	}
+
+	return newSuccessResultWithData(data.Message, data, duration)
 }
 
 // ExecuteSetThreshold executes the set_threshold command
@@ -98,10 +141,40 @@ func (e *CommandExecutor) ExecuteSetThreshold(threshold int) *CommandResult {
 	)
 }
 
+// ExecuteSetThresholds executes the set_threshold command with a start threshold
+func (e *CommandExecutor) ExecuteSetThresholds(threshold, start int) *CommandResult {
+	begin := time.Now()
+	err := e.client.SetChargeThresholds(threshold, start)
+	duration := time.Since(begin)
+
+	if err != nil {
+		return newFailureResult(fmt.Sprintf("Failed to set threshold to %d", threshold), err, duration)
+	}
+
+	return newSuccessResultWithData(
+		fmt.Sprintf("Charge threshold set to %d%%", threshold),
+		map[string]interface{}{"threshold": threshold, "start_threshold": start},
+		duration,
+	)
+}
+
+// ExecuteDryRun asks what a command would change without applying it
+func (e *CommandExecutor) ExecuteDryRun(command string, params map[string]interface{}) *CommandResult {
+	start := time.Now()
+	plan, err := e.client.DryRun(command, params)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to evaluate the change", err, duration)
+	}
+
+	return newSuccessResultWithData("Dry run completed", plan, duration)
+}
+
 // ExecuteStatus executes the status command
-func (e *CommandExecutor) ExecuteStatus() *CommandResult {
+func (e *CommandExecutor) ExecuteStatus(fresh bool) *CommandResult {
 	start := time.Now()
-	status, err := e.client.GetStatus()
+	status, err := e.client.GetStatus(fresh)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -124,22 +197,429 @@ func (e *CommandExecutor) ExecuteDaemonStatus() *CommandResult {
 	return newSuccessResultWithData("Daemon status retrieved successfully", status, duration)
 }
 
+// ExecuteGetLimits executes the get_limits command
+func (e *CommandExecutor) ExecuteGetLimits() *CommandResult {
+	start := time.Now()
+	limits, err := e.client.GetLimits()
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to read charge limits", err, duration)
+	}
+
+	return newSuccessResultWithData("Charge limits retrieved successfully", limits, duration)
+}
+
+// ExecuteSetLimits executes the set_limits command
+func (e *CommandExecutor) ExecuteSetLimits(changes map[string]interface{}) *CommandResult {
+	start := time.Now()
+	limits, err := e.client.SetLimits(changes)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to set charge limits", err, duration)
+	}
+
+	return newSuccessResultWithData("Charge limits updated successfully", limits, duration)
+}
+
+// ExecuteGetPowerMode executes the get_power_mode command
+func (e *CommandExecutor) ExecuteGetPowerMode() *CommandResult {
+	start := time.Now()
+	data, err := e.client.GetPowerMode()
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to read power mode", err, duration)
+	}
+
+	return newSuccessResultWithData("Power mode retrieved successfully", data, duration)
+}
+
+// ExecuteSetPowerMode executes the set_power_mode command
+func (e *CommandExecutor) ExecuteSetPowerMode(mode string) *CommandResult {
+	start := time.Now()
+	data, err := e.client.SetPowerMode(mode)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to set power mode", err, duration)
+	}
+
+	return newSuccessResultWithData(data.Message, data, duration)
+}
+
+// ExecuteGetRapidCharge executes the get_rapid_charge command
+func (e *CommandExecutor) ExecuteGetRapidCharge() *CommandResult {
+	start := time.Now()
+	data, err := e.client.GetRapidCharge()
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to read rapid charge", err, duration)
+	}
+
+	return newSuccessResultWithData("Rapid charge retrieved successfully", data, duration)
+}
+
+// ExecuteSetRapidCharge executes the set_rapid_charge command
+func (e *CommandExecutor) ExecuteSetRapidCharge(enable bool) *CommandResult {
+	start := time.Now()
+	data, err := e.client.SetRapidCharge(enable)
+	duration := time.Since(start)
+
+	if err != nil {
+		action := "disable"
+		if enable {
+			action = "enable"
+		}
+		return newFailureResult(fmt.Sprintf("Failed to %s rapid charge", action), err, duration)
+	}
+
+	return newSuccessResultWithData(data.Message, data, duration)
+}
+
+// ExecuteStorage executes the storage command
+func (e *CommandExecutor) ExecuteStorage(enable bool, target int) *CommandResult {
+	start := time.Now()
+	data, err := e.client.SetStorageMode(enable, target)
+	duration := time.Since(start)
+
+	if err != nil {
+		action := "disable"
+		if enable {
+			action = "enable"
+		}
+		return newFailureResult(fmt.Sprintf("Failed to %s storage mode", action), err, duration)
+	}
+
+	return newSuccessResultWithData(data.Message, data, duration)
+}
+
+// ExecutePause executes the pause command
+func (e *CommandExecutor) ExecutePause(forDuration time.Duration) *CommandResult {
+	start := time.Now()
+	data, err := e.client.Pause(forDuration)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to pause automation", err, duration)
+	}
+
+	return newSuccessResultWithData(data.Message, data, duration)
+}
+
+// ExecuteResume executes the resume command
+func (e *CommandExecutor) ExecuteResume() *CommandResult {
+	start := time.Now()
+	data, err := e.client.Resume()
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to resume automation", err, duration)
+	}
+
+	return newSuccessResultWithData(data.Message, data, duration)
+}
+
+// ExecuteSnapshotCreate executes the snapshot_create command
+func (e *CommandExecutor) ExecuteSnapshotCreate(note string) *CommandResult {
+	start := time.Now()
+	data, err := e.client.CreateSnapshot(note)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to create snapshot", err, duration)
+	}
+
+	return newSuccessResultWithData(fmt.Sprintf("Created snapshot %d", data.ID), data, duration)
+}
+
+// ExecuteSnapshotList executes the snapshot_list command
+func (e *CommandExecutor) ExecuteSnapshotList() *CommandResult {
+	start := time.Now()
+	data, err := e.client.ListSnapshots()
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to list snapshots", err, duration)
+	}
+
+	return newSuccessResultWithData("Snapshots retrieved successfully", data, duration)
+}
+
+// ExecuteSnapshotRollback executes the snapshot_rollback command
+func (e *CommandExecutor) ExecuteSnapshotRollback(id int) *CommandResult {
+	start := time.Now()
+	data, err := e.client.RollbackSnapshot(id)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult(fmt.Sprintf("Failed to roll back to snapshot %d", id), err, duration)
+	}
+
+	return newSuccessResultWithData(data.Message, data, duration)
+}
+
+// ExecuteHealth executes the health command
+func (e *CommandExecutor) ExecuteHealth(trend bool) *CommandResult {
+	start := time.Now()
+	health, err := e.client.GetHealth(trend)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to read battery health", err, duration)
+	}
+
+	return newSuccessResultWithData("Battery health retrieved successfully", health, duration)
+}
+
+// ExecuteAdaptive executes the adaptive command
+func (e *CommandExecutor) ExecuteAdaptive() *CommandResult {
+	start := time.Now()
+	data, err := e.client.GetAdaptive()
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to learn the charge threshold", err, duration)
+	}
+
+	return newSuccessResultWithData("Charge threshold learned successfully", data, duration)
+}
+
+// ExecuteBatteryInfo executes the battery info command
+func (e *CommandExecutor) ExecuteBatteryInfo() *CommandResult {
+	start := time.Now()
+	info, err := e.client.GetBatteryInfo()
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to read battery info", err, duration)
+	}
+
+	return newSuccessResultWithData("Battery info retrieved successfully", info, duration)
+}
+
+// ExecuteHistory executes the history command
+func (e *CommandExecutor) ExecuteHistory(since, until time.Time) *CommandResult {
+	start := time.Now()
+	data, err := e.client.GetHistory(since, until)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to read battery history", err, duration)
+	}
+
+	return newSuccessResultWithData("Battery history retrieved successfully", data, duration)
+}
+
+// ExecuteStats executes the stats command
+func (e *CommandExecutor) ExecuteStats(since, until time.Time) *CommandResult {
+	start := time.Now()
+	data, err := e.client.GetStats(since, until)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to compute battery stats", err, duration)
+	}
+
+	return newSuccessResultWithData("Battery stats computed successfully", data, duration)
+}
+
+// ExecuteAudit executes the audit command
+func (e *CommandExecutor) ExecuteAudit(since, until time.Time) *CommandResult {
+	start := time.Now()
+	data, err := e.client.GetAudit(since, until)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to read the audit log", err, duration)
+	}
+
+	return newSuccessResultWithData("Audit log retrieved successfully", data, duration)
+}
+
+// ExecuteLogs executes the logs command for the last n lines
+func (e *CommandExecutor) ExecuteLogs(n int) *CommandResult {
+	start := time.Now()
+	data, err := e.client.GetLogs(n)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to read the daemon log", err, duration)
+	}
+
+	return newSuccessResultWithData("Daemon log retrieved successfully", data, duration)
+}
+
+// ExecuteLogsSince executes the logs command for the lines after seq
+func (e *CommandExecutor) ExecuteLogsSince(seq uint64) *CommandResult {
+	start := time.Now()
+	data, err := e.client.GetLogsSince(seq)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to read the daemon log", err, duration)
+	}
+
+	return newSuccessResultWithData("Daemon log retrieved successfully", data, duration)
+}
+
+// ExecuteSetLogLevel executes the set_log_level command
+func (e *CommandExecutor) ExecuteSetLogLevel(level string) *CommandResult {
+	start := time.Now()
+	data, err := e.client.SetLogLevel(level)
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to change the log level", err, duration)
+	}
+
+	return newSuccessResultWithData("Log level changed", data, duration)
+}
+
+// ExecuteExplain executes the explain command
+func (e *CommandExecutor) ExecuteExplain() *CommandResult {
+	start := time.Now()
+	data, err := e.client.Explain()
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to explain the conservation mode", err, duration)
+	}
+
+	return newSuccessResultWithData("Decision explained", data, duration)
+}
+
+// ExecuteGetSchedule executes the get_schedule command
+func (e *CommandExecutor) ExecuteGetSchedule() *CommandResult {
+	start := time.Now()
+	schedule, err := e.client.GetSchedule()
+	duration := time.Since(start)
+
+	if err != nil {
+		return newFailureResult("Failed to read schedule", err, duration)
+	}
+
+	return newSuccessResultWithData("Schedule retrieved successfully", schedule, duration)
+}
+
+// ExecuteSetSchedulePaused executes the set_schedule command
+func (e *CommandExecutor) ExecuteSetSchedulePaused(paused bool) *CommandResult {
+	start := time.Now()
+	schedule, err := e.client.SetSchedulePaused(paused)
+	duration := time.Since(start)
+
+	action := "resume"
+	if paused {
+		action = "pause"
+	}
+	if err != nil {
+		return newFailureResult(fmt.Sprintf("Failed to %s schedule", action), err, duration)
+	}
+
+	return newSuccessResultWithData(fmt.Sprintf("Schedule %sd", action), schedule, duration)
+}
+
 // FormatStatus formats status data for human-readable output
 func FormatStatus(status *protocol.StatusData) string {
 	output := "Battery Management Status:\n"
-	output += fmt.Sprintf("  Conservation Management: %s\n", formatBool(status.ConservationEnabled))
-	output += fmt.Sprintf("  Charge Threshold: %d%%\n", status.Threshold)
-	output += fmt.Sprintf("  Current Mode: %s\n", status.CurrentMode)
-	output += fmt.Sprintf("  Battery Level: %d%%\n", status.BatteryLevel)
+	output += fmt.Sprintf("  Conservation Management: %s\n",
+		colorize(enabledSeverity(status.ConservationEnabled), formatBool(status.ConservationEnabled)))
+	output += fmt.Sprintf("  Charge Threshold: %s\n", formatThreshold(status))
+	if status.SuggestedThreshold > 0 {
+		output += fmt.Sprintf("  Suggested Threshold: %d%% (learned from your usage, see: legionbatctl adaptive)\n",
+			status.SuggestedThreshold)
+	}
+	output += fmt.Sprintf("  Current Mode: %s\n", colorize(modeSeverity(status), formatMode(status)))
+	if status.ChargeFull {
+		output += "  Charge Full: in progress (management resumes at 100%)\n"
+	} else if !status.ChargeFullBy.IsZero() {
+		output += fmt.Sprintf("  Charge Full: by %s (charging starts around %s)\n",
+			status.ChargeFullBy.Local().Format("Mon 15:04"), status.ChargeFullStart.Local().Format("15:04"))
+	}
+	if !status.ReenableAt.IsZero() {
+		output += fmt.Sprintf("  Re-enables At: %s\n", status.ReenableAt.Local().Format(time.RFC1123))
+	}
+	if status.Paused {
+		output += fmt.Sprintf("  Automation: %s\n", colorize(severityWarn, formatPause(status, time.Now())))
+	}
+	output += fmt.Sprintf("  Battery Level: %s\n",
+		colorize(levelSeverity(status.BatteryLevel), fmt.Sprintf("%d%%", status.BatteryLevel)))
 	output += fmt.Sprintf("  Conservation Mode: %s\n", formatBool(status.ConservationMode))
 	output += fmt.Sprintf("  Charging Status: %s\n", formatCharging(status.Charging))
+	if status.Adapter != nil {
+		output += fmt.Sprintf("  Power Adapter: %s\n", formatAdapter(status.Adapter))
+	}
+	switch status.RapidCharge {
+	case "on":
+		output += "  Rapid Charge: on (the firmware switches it off while conservation mode holds the charge)\n"
+	case "paused":
+		output += "  Rapid Charge: paused while conservation mode holds the charge, on again when charging resumes\n"
+	}
+	if status.LowChargePower {
+		output += fmt.Sprintf("  Charge Power: %s\n", colorize(severityWarn,
+			fmt.Sprintf("%.0f W, typically %.0f W (weak or failing charger?)", status.ChargePower, status.TypicalChargePower)))
+	}
+	output += fmt.Sprintf("  Last Reading: %s\n", formatReading(status))
 	output += fmt.Sprintf("  Last Action: %s\n", status.LastAction)
 	output += fmt.Sprintf("  Daemon Uptime: %s\n", status.DaemonUptime)
-	output += fmt.Sprintf("  Hardware Supported: %s\n", formatBool(status.HardwareSupported))
+	supported := severityGood
+	if !status.HardwareSupported {
+		supported = severityBad
+	}
+	output += fmt.Sprintf("  Hardware Supported: %s\n", colorize(supported, formatBool(status.HardwareSupported)))
+	if status.UncleanShutdowns > 0 {
+		output += fmt.Sprintf("  Unclean Shutdowns: %s (last: %s)\n",
+			colorize(severityWarn, fmt.Sprint(status.UncleanShutdowns)), status.LastUncleanShutdown.Local().Format(time.RFC1123))
+	}
 
 	return output
 }
 
+// ShortGlyphs holds the symbols used by the one-line status summary and
+// the statusline
+type ShortGlyphs struct {
+	Charging    string   // On AC, battery allowed to charge
+	Discharging string   // On battery power
+	Held        string   // On AC, conservation mode holding the charge
+	Error       string   // Statusline marker when the daemon can't be queried
+	Levels      []string // On battery power by level, from empty to full; replaces Discharging if set
+}
+
+// DefaultShortGlyphs are the glyphs used when none are configured
+var DefaultShortGlyphs = IconThemes[DefaultIconTheme]
+
+// ParseShortGlyphs parses a comma separated "charging,discharging,held" glyph
+// list, keeping the error marker of the default glyphs
+func ParseShortGlyphs(spec string) (ShortGlyphs, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != 3 {
+		return ShortGlyphs{}, fmt.Errorf("glyphs must be \"charging,discharging,held\", got %q", spec)
+	}
+	return ShortGlyphs{Charging: parts[0], Discharging: parts[1], Held: parts[2], Error: DefaultShortGlyphs.Error}, nil
+}
+
+// FormatStatusShort formats status data as a compact one-line summary,
+// e.g. "76% ⇡ held@80 (conservation on)"
+func FormatStatusShort(status *protocol.StatusData, glyphs ShortGlyphs) string {
+	glyph := statusGlyph(status, glyphs)
+
+	policy := "unmanaged"
+	if status.StorageMode {
+		policy = fmt.Sprintf("storage@%d", status.StorageTarget)
+	} else if status.ConservationEnabled {
+		policy = fmt.Sprintf("held@%d", status.Threshold)
+	}
+
+	conservation := "off"
+	if status.ConservationMode {
+		conservation = "on"
+	}
+
+	return fmt.Sprintf("%d%% %s %s (conservation %s)\n", status.BatteryLevel, glyph, policy, conservation)
+}
+
 // FormatDaemonStatus formats daemon status data for human-readable output
 func FormatDaemonStatus(status *protocol.DaemonStatusData) string {
 	output := "Daemon Status:\n"
@@ -147,6 +627,9 @@ func FormatDaemonStatus(status *protocol.DaemonStatusData) string {
 	output += fmt.Sprintf("  PID: %d\n", status.PID)
 	output += fmt.Sprintf("  Uptime: %s\n", status.Uptime)
 	output += fmt.Sprintf("  Version: %s\n", status.Version)
+	if status.Commit != "" {
+		output += fmt.Sprintf("  Commit: %s\n", status.Commit)
+	}
 	output += fmt.Sprintf("  Socket Path: %s\n", status.SocketPath)
 	output += fmt.Sprintf("  State File: %s\n", status.StateFile)
 
@@ -156,19 +639,262 @@ func FormatDaemonStatus(status *protocol.DaemonStatusData) string {
 // FormatEnableResult formats the result of an enable command
 func FormatEnableResult(result *CommandResult) string {
 	if result.Success {
+		if data, ok := result.Data.(map[string]interface{}); ok {
+			if threshold, ok := data["threshold"].(int); ok {
+				return fmt.Sprintf("✓ Battery management enabled. Conservation mode will be activated when battery reaches %d%%.", threshold)
+			}
+		}
 		return "✓ Battery management enabled. Conservation mode will be activated when battery reaches the threshold."
 	} else {
-		return fmt.Sprintf("✗ Failed to enable battery management: %s", result.Error)
+		return FormatFailure("Failed to enable battery management", result)
 	}
 }
 
 // FormatDisableResult formats the result of a disable command
 func FormatDisableResult(result *CommandResult) string {
 	if result.Success {
+		if data, ok := result.Data.(*protocol.DisableData); ok && !data.ReenableAt.IsZero() {
+			return fmt.Sprintf("✓ Battery management disabled until %s. The battery will charge to 100%% until then.",
+				data.ReenableAt.Local().Format("Mon 15:04"))
+		}
 		return "✓ Battery management disabled. The battery will charge to 100%."
 	} else {
-		return fmt.Sprintf("✗ Failed to disable battery management: %s", result.Error)
+		return FormatFailure("Failed to disable battery management", result)
+	}
+}
+
+// FormatChargeFullResult formats the result of charge_full command
+func FormatChargeFullResult(result *CommandResult) string {
+	if result.Success {
+		if data, ok := result.Data.(*protocol.ChargeFullData); ok && !data.By.IsZero() {
+			return fmt.Sprintf("✓ Battery will be full by %s (charging starts around %s). Battery management resumes afterwards.",
+				data.By.Local().Format("Mon 15:04"), data.StartAt.Local().Format("15:04"))
+		}
+		return "✓ Charging to 100%. Battery management will be re-enabled automatically once full."
+	} else {
+		return FormatFailure("Failed to start charging to full", result)
+	}
+}
+
+// FormatStorageResult formats the result of storage command
+func FormatStorageResult(result *CommandResult) string {
+	if result.Success {
+		data, ok := result.Data.(*protocol.StorageData)
+		if !ok {
+			return fmt.Sprintf("✓ %s.\n", result.Message)
+		}
+		if !data.Enabled {
+			return "✓ Storage mode disabled. Regular battery management resumed.\n"
+		}
+
+		output := fmt.Sprintf("✓ Storage mode enabled. The battery is held at %d%%.\n", data.Target)
+		if data.ForceDischarge {
+			output += "  Above the target the battery is discharged even on AC power.\n"
+		} else {
+			output += "  This hardware cannot discharge on AC; unplug to drain above the target.\n"
+		}
+		return output
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// FormatPauseResult formats the result of a pause or resume command
+func FormatPauseResult(result *CommandResult) string {
+	if !result.Success {
+		return FormatFailure(result.Message, result)
+	}
+
+	data, ok := result.Data.(*protocol.PauseData)
+	switch {
+	case !ok:
+		return fmt.Sprintf("✓ %s.\n", result.Message)
+	case !data.Paused:
+		return "✓ Automation resumed. The daemon switches conservation mode again.\n"
+	case data.PausedUntil.IsZero():
+		return "✓ Automation paused until resumed. Conservation mode is left as it is.\n"
+	}
+	return fmt.Sprintf("✓ Automation paused until %s. Conservation mode is left as it is until then.\n",
+		data.PausedUntil.Local().Format("Mon 15:04"))
+}
+
+// FormatSnapshotResult formats the result of a snapshot command
+func FormatSnapshotResult(result *CommandResult) string {
+	if !result.Success {
+		return FormatFailure(result.Message, result)
+	}
+
+	switch data := result.Data.(type) {
+	case *protocol.SnapshotListData:
+		return FormatSnapshots(data.Snapshots)
+	case *protocol.SnapshotRollbackData:
+		output := fmt.Sprintf("✓ %s: %s.\n", data.Message, formatSnapshotSettings(data.Snapshot))
+		if data.ConfigRestored {
+			output += "  Restored the config file with its profiles and schedule"
+			if data.ConfigBackup != "" {
+				output += fmt.Sprintf(", keeping the replaced one as %s", data.ConfigBackup)
+			}
+			output += ".\n"
+		}
+		return output
+	case *protocol.SnapshotData:
+		return fmt.Sprintf("✓ %s: %s.\n  Restore it with: legionbatctl snapshot rollback %d\n",
+			result.Message, formatSnapshotSettings(*data), data.ID)
+	}
+	return fmt.Sprintf("✓ %s.\n", result.Message)
+}
+
+// FormatSnapshots formats the saved snapshots as a table
+func FormatSnapshots(snapshots []protocol.SnapshotData) string {
+	if len(snapshots) == 0 {
+		return "No snapshots saved (create one with: legionbatctl snapshot create).\n"
+	}
+
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\tCREATED\tSETTINGS\tPROFILES\tSCHEDULE\tNOTE\n")
+	for _, s := range snapshots {
+		note := s.Note
+		if note == "" {
+			note = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", s.ID, s.Created.Local().Format("2006-01-02 15:04"),
+			formatSnapshotSettings(s), formatNames(s.Profiles), formatNames(s.Schedule), note)
+	}
+	w.Flush()
+
+	return buf.String()
+}
+
+// formatSnapshotSettings describes the state settings of a snapshot, e.g.
+// "80% (start 70%), enabled"
+func formatSnapshotSettings(s protocol.SnapshotData) string {
+	settings := fmt.Sprintf("%d%%", s.Threshold)
+	if s.StartThreshold > 0 {
+		settings += fmt.Sprintf(" (start %d%%)", s.StartThreshold)
+	}
+	if s.Enabled {
+		settings += ", enabled"
+	} else {
+		settings += ", disabled"
+	}
+	if s.SchedulePaused {
+		settings += ", schedule paused"
+	}
+	return settings
+}
+
+// formatNames lists names comma separated, or "-" if there are none
+func formatNames(names []string) string {
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ",")
+}
+
+// formatPause describes a pause for the status, with the time left
+func formatPause(status *protocol.StatusData, now time.Time) string {
+	if status.PausedUntil.IsZero() {
+		return "paused until resumed (legionbatctl resume)"
+	}
+	left := max(status.PausedUntil.Sub(now), 0).Round(time.Minute)
+	return fmt.Sprintf("paused until %s (%s left)", status.PausedUntil.Local().Format("Mon 15:04"), formatDuration(left))
+}
+
+// formatDuration formats a duration rounded to minutes, e.g. "1h5m" or "42m"
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	s = strings.TrimSuffix(s, "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	if s == "" {
+		return "0m"
+	}
+	return s
+}
+
+// FormatPowerModeResult formats the result of a power mode command
+func FormatPowerModeResult(result *CommandResult) string {
+	if !result.Success {
+		return FormatFailure(result.Message, result)
+	}
+
+	data, ok := result.Data.(*protocol.PowerModeData)
+	if !ok {
+		return fmt.Sprintf("✓ %s.\n", result.Message)
+	}
+
+	var output string
+	if data.Message != "" {
+		output = fmt.Sprintf("✓ %s.\n", data.Message)
+	} else {
+		output = fmt.Sprintf("Power mode: %s (available: %s)\n", data.Mode, strings.Join(data.Choices, ", "))
+	}
+	if data.Profile != "" {
+		output += fmt.Sprintf("  Charge profile %q applies with it (threshold %d%%).\n", data.Profile, data.Threshold)
+	}
+	return output
+}
+
+// FormatAdaptiveResult formats the result of the adaptive command
+func FormatAdaptiveResult(result *CommandResult) string {
+	if !result.Success {
+		return FormatFailure(result.Message, result)
+	}
+
+	data, ok := result.Data.(*protocol.AdaptiveData)
+	if !ok {
+		return fmt.Sprintf("✓ %s.\n", result.Message)
+	}
+
+	output := "Adaptive Threshold:\n"
+	output += fmt.Sprintf("  Mode: %s\n", data.Mode)
+	output += fmt.Sprintf("  Configured Threshold: %d%%\n", data.Threshold)
+	if data.Suggested > 0 {
+		output += fmt.Sprintf("  Learned Threshold: %d%% (bounds %d-%d%%)\n",
+			data.Suggested, data.MinThreshold, data.MaxThreshold)
+		output += fmt.Sprintf("  Discharges: %d over the last %d days (%.1f a week)\n", data.Discharges, data.Days, data.PerWeek)
+		if data.TypicalDepth > 0 {
+			output += fmt.Sprintf("  Typical Depth: %d%%\n", data.TypicalDepth)
+		}
+	} else {
+		output += "  Learned Threshold: none yet\n"
+	}
+	output += fmt.Sprintf("  %s.\n", data.Reason)
+
+	switch {
+	case data.Suggested == 0 || data.Suggested == data.Threshold:
+	case data.Mode == "off":
+		output += "\nSet adaptive.mode to \"suggest\" or \"apply\" in the config file to opt in,\nor run legionbatctl adaptive --apply to set it once.\n"
+	case data.Mode == "suggest":
+		output += "\nRun legionbatctl adaptive --apply to set it.\n"
+	case data.Mode == "apply":
+		output += "\nThe daemon sets it at its next daily check.\n"
+	}
+	return output
+}
+
+// FormatRapidChargeResult formats the result of a rapid charge command
+func FormatRapidChargeResult(result *CommandResult) string {
+	if !result.Success {
+		return FormatFailure(result.Message, result)
+	}
+
+	data, ok := result.Data.(*protocol.RapidChargeData)
+	if !ok {
+		return fmt.Sprintf("✓ %s.\n", result.Message)
+	}
+	if data.Pending {
+		return fmt.Sprintf("Rapid charge: pending\n  %s.\n", data.Message)
 	}
+	if data.Message != "" {
+		return fmt.Sprintf("✓ %s.\n", data.Message)
+	}
+	if data.Enabled {
+		return "Rapid charge: on\n"
+	}
+	return "Rapid charge: off\n"
 }
 
 // FormatStatusResult formats the result of a status command
@@ -179,7 +905,19 @@ func FormatStatusResult(result *CommandResult) string {
 		}
 		return result.Message
 	} else {
-		return fmt.Sprintf("✗ Failed to get status: %s", result.Error)
+		return FormatFailure("Failed to get status", result)
+	}
+}
+
+// FormatStatusShortResult formats the result of a status command as one line
+func FormatStatusShortResult(result *CommandResult, glyphs ShortGlyphs) string {
+	if result.Success {
+		if status, ok := result.Data.(*protocol.StatusData); ok {
+			return FormatStatusShort(status, glyphs)
+		}
+		return result.Message
+	} else {
+		return FormatFailure("Failed to get status", result)
 	}
 }
 
@@ -187,16 +925,312 @@ func FormatStatusResult(result *CommandResult) string {
 func FormatSetThresholdResult(result *CommandResult) string {
 	if result.Success {
 		if data, ok := result.Data.(map[string]interface{}); ok {
-			if threshold, ok := data["threshold"].(int); ok {
+			threshold, ok := data["threshold"].(int)
+			if start, _ := data["start_threshold"].(int); ok && start > 0 {
+				return fmt.Sprintf("✓ Charge threshold set to %d%%. Charging stops at this level and resumes below %d%%.", threshold, start)
+			}
+			if ok {
 				return fmt.Sprintf("✓ Charge threshold set to %d%%. Conservation mode will activate at this level.", threshold)
 			}
 		}
 		return "✓ Charge threshold updated successfully."
 	} else {
-		return fmt.Sprintf("✗ Failed to set threshold: %s", result.Error)
+		return FormatFailure("Failed to set threshold", result)
+	}
+}
+
+// FormatLimits formats charge limits as a table of every control
+func FormatLimits(limits *protocol.LimitsData) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Charge Limits (backend: %s):\n", limits.Backend)
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  CONTROL\tVALUE\tSET WITH\n")
+	fmt.Fprintf(w, "  Conservation Mode\t%s\t--conservation on|off\n", formatOptionalBool(limits.ConservationMode))
+	fmt.Fprintf(w, "  Start Threshold\t%s\t--start <0-100>\n", formatOptionalPercent(limits.StartThreshold))
+	fmt.Fprintf(w, "  End Threshold\t%s\t--end <0-100>\n", formatOptionalPercent(limits.EndThreshold))
+	fmt.Fprintf(w, "  Rapid Charge\t%s\t--rapid-charge on|off\n", formatOptionalBool(limits.RapidCharge))
+	fmt.Fprintf(w, "  Charge Rate\t%s\t--charge-type <type>\n", formatOptionalString(limits.ChargeType))
+	w.Flush()
+
+	if limits.Message != "" {
+		fmt.Fprintf(&buf, "%s\n", limits.Message)
+	}
+
+	return buf.String()
+}
+
+// FormatLimitsResult formats the result of a get_limits or set_limits command
+func FormatLimitsResult(result *CommandResult) string {
+	if result.Success {
+		if limits, ok := result.Data.(*protocol.LimitsData); ok {
+			return FormatLimits(limits)
+		}
+		return result.Message
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// FormatHealth formats battery health data
+func FormatHealth(health *protocol.HealthData) string {
+	output := "Battery Health:\n"
+	output += fmt.Sprintf("  Full Capacity: %s\n", formatCapacity(health.Full, health.Unit))
+	output += fmt.Sprintf("  Design Capacity: %s\n", formatCapacity(health.FullDesign, health.Unit))
+	output += fmt.Sprintf("  Wear: %.1f%%\n", health.Wear)
+	if health.CycleCount > 0 {
+		output += fmt.Sprintf("  Cycle Count: %d\n", health.CycleCount)
+	} else {
+		output += "  Cycle Count: unknown\n"
+	}
+
+	if health.Degraded {
+		output += fmt.Sprintf("⚠ Capacity fade exceeds %d%%. Consider a lower charge threshold or a battery replacement.\n",
+			health.WearWarning)
+	}
+
+	if health.Trend != nil {
+		output += formatHealthTrend(health.Trend, health.WearWarning)
+	}
+
+	return output
+}
+
+// formatHealthTrend formats the capacity trend with a bar per week
+func formatHealthTrend(trend *protocol.HealthTrend, warning int) string {
+	output := "\nCapacity Trend:\n"
+	if len(trend.Points) == 0 {
+		return output + "  No capacity readings yet; the daemon records one a day.\n"
+	}
+
+	// Bars span from just below the lowest point, so the fade is visible
+	low := trend.Points[0].Health
+	for _, point := range trend.Points {
+		low = min(low, point.Health)
+	}
+	low = math.Floor(low) - 1
+	for _, point := range trend.Points {
+		width := int(math.Round((point.Health - low) / (100 - low) * 30))
+		output += fmt.Sprintf("  %s  %5.1f%%  %s\n", point.Time.Local().Format("2006-01-02"), point.Health,
+			strings.Repeat("█", max(width, 1)))
+	}
+
+	if trend.FadePerMonth == 0 {
+		output += "  Fade rate: needs two weeks of readings\n"
+		return output
+	}
+	output += fmt.Sprintf("  Fade rate: %.2f%% of design capacity per month\n", trend.FadePerMonth)
+	if !trend.WarningAt.IsZero() {
+		output += fmt.Sprintf("  Projection: %d%% wear around %s\n", warning, trend.WarningAt.Local().Format("January 2006"))
+	}
+	return output
+}
+
+// FormatHealthResult formats the result of health command
+func FormatHealthResult(result *CommandResult) string {
+	if result.Success {
+		if health, ok := result.Data.(*protocol.HealthData); ok {
+			return FormatHealth(health)
+		}
+		return result.Message
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// FormatBatteryInfo formats detailed battery diagnostics, leaving out
+// readings the battery doesn't report
+func FormatBatteryInfo(info *protocol.BatteryInfoData) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "Battery Info:")
+	fmt.Fprintf(w, "  Level:\t%d%%\n", info.BatteryLevel)
+	if info.Status != "" {
+		fmt.Fprintf(w, "  Status:\t%s\n", info.Status)
+	}
+	fmt.Fprintf(w, "  Power Source:\t%s\n", formatCharging(info.ACConnected))
+	fmt.Fprintf(w, "  Conservation Mode:\t%s\n", formatBool(info.ConservationMode))
+	fmt.Fprintf(w, "  Management:\t%s\n", formatBool(info.ConservationEnabled))
+	fmt.Fprintf(w, "  Threshold:\t%d%% (charging resumes below %d%%)\n", info.Threshold, info.StartThreshold)
+
+	if info.Voltage != 0 {
+		fmt.Fprintf(w, "  Voltage:\t%.2f V\n", info.Voltage)
+	}
+	if info.Current != 0 {
+		fmt.Fprintf(w, "  Current:\t%.2f A\n", info.Current)
+	}
+	if info.Power != 0 {
+		fmt.Fprintf(w, "  Power:\t%.2f W\n", info.Power)
+	}
+	if info.Temperature != 0 {
+		fmt.Fprintf(w, "  Temperature:\t%.1f °C\n", info.Temperature)
+	}
+	for _, field := range []struct{ label, value string }{
+		{"Manufacturer", info.Manufacturer},
+		{"Model", info.Model},
+		{"Serial", info.Serial},
+		{"Technology", info.Technology},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(w, "  %s:\t%s\n", field.label, field.value)
+		}
+	}
+
+	w.Flush()
+	return b.String()
+}
+
+// FormatBatteryInfoResult formats the result of battery info command
+func FormatBatteryInfoResult(result *CommandResult) string {
+	if result.Success {
+		if info, ok := result.Data.(*protocol.BatteryInfoData); ok {
+			return FormatBatteryInfo(info)
+		}
+		return result.Message
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// FormatSchedule formats the schedule rules, marking the active one
+func FormatSchedule(schedule *protocol.ScheduleData) string {
+	if len(schedule.Rules) == 0 {
+		return "No schedule rules configured (add [[schedule]] entries to the config file).\n"
+	}
+
+	var buf strings.Builder
+	state := "active"
+	if schedule.Paused {
+		state = "paused"
+	}
+	fmt.Fprintf(&buf, "Schedule (%s):\n", state)
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "    NAME\tWHEN\tTHRESHOLD\tPROFILE\n")
+	for _, rule := range schedule.Rules {
+		marker := "  "
+		if rule.Active {
+			marker = "* "
+		}
+		threshold := fmt.Sprintf("%d%%", rule.Threshold)
+		switch {
+		case rule.Threshold == 0:
+			threshold = "-"
+		case rule.StartThreshold > 0:
+			threshold = fmt.Sprintf("%d-%d%%", rule.StartThreshold, rule.Threshold)
+		}
+		profile := rule.Profile
+		if profile == "" {
+			profile = "-"
+		}
+		when := rule.Window
+		if rule.Night {
+			when += ", then full"
+		}
+		fmt.Fprintf(w, "  %s%s\t%s\t%s\t%s\n", marker, rule.Name, when, threshold, profile)
+	}
+	w.Flush()
+
+	if !schedule.NextChange.IsZero() {
+		fmt.Fprintf(&buf, "Next change: %s\n", schedule.NextChange.Local().Format("Mon 15:04"))
+	}
+
+	return buf.String()
+}
+
+// FormatPlan formats what a dry run would change
+func FormatPlan(plan *protocol.PlanData) string {
+	output := "Dry run, nothing was changed:\n"
+	if len(plan.Changes) == 0 {
+		output += "  No settings would change\n"
+	}
+	for _, change := range plan.Changes {
+		output += fmt.Sprintf("  %s\n", change)
+	}
+
+	after := "off"
+	if plan.ConservationAfter {
+		after = "on"
+	}
+	if plan.ConservationMode == plan.ConservationAfter {
+		output += fmt.Sprintf("  Conservation mode would stay %s\n", after)
+	} else {
+		output += fmt.Sprintf("  Conservation mode would switch %s now\n", after)
+	}
+
+	for _, note := range plan.Notes {
+		output += fmt.Sprintf("  Note: %s\n", note)
+	}
+	return output
+}
+
+// FormatDryRunResult formats the result of a dry run
+func FormatDryRunResult(result *CommandResult) string {
+	if result.Success {
+		if plan, ok := result.Data.(*protocol.PlanData); ok {
+			return FormatPlan(plan)
+		}
+		return result.Message
+	} else {
+		return FormatFailure(result.Message, result)
 	}
 }
 
+// FormatScheduleResult formats the result of a get_schedule or set_schedule command
+func FormatScheduleResult(result *CommandResult) string {
+	if result.Success {
+		if schedule, ok := result.Data.(*protocol.ScheduleData); ok {
+			return FormatSchedule(schedule)
+		}
+		return result.Message
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// FormatFailure renders a failed command as a short block with the cause and
+// the fix the error carries or, when the error code is known, the next
+// command to run. Thresholds outside the hardware's range are shown with the
+// range.
+func FormatFailure(summary string, result *CommandResult) string {
+	output := colorize(severityBad, "✗ "+summary) + "\n"
+	output += fmt.Sprintf("  Cause: %s\n", result.Error)
+	if result.Hint != "" {
+		return output + fmt.Sprintf("  Fix:   %s\n", result.Hint)
+	}
+	next := SuggestedCommand(result.Code)
+	if result.Range != nil {
+		output += fmt.Sprintf("  Range: %s on this hardware\n", result.Range)
+		next = fmt.Sprintf("legionbatctl set-threshold %d", result.Range.Nearest(80))
+	}
+	if next != "" {
+		output += fmt.Sprintf("  Try:   %s\n", next)
+	}
+	return output
+}
+
+// SuggestedCommand returns the command a user should run next to recover
+// from an error with the given protocol code
+func SuggestedCommand(code string) string {
+	switch code {
+	case protocol.CodeDaemonNotRunning:
+		return "sudo systemctl start legionbatctl"
+	case protocol.CodeDaemonNotResponding:
+		return "sudo systemctl restart legionbatctl"
+	case protocol.CodePermissionDenied:
+		return "journalctl -u legionbatctl -n 20"
+	case protocol.CodeHardwareNotSupported:
+		return "sudo modprobe ideapad_laptop"
+	case protocol.CodeInvalidThreshold:
+		return "legionbatctl set-threshold 80"
+	case protocol.CodeInvalidCommand, protocol.CodeProtocolError:
+		// Usually a CLI/daemon version mismatch after an upgrade
+		return "sudo systemctl restart legionbatctl"
+	}
+	return ""
+}
+
 // formatBool formats a boolean value for display
 func formatBool(b bool) string {
 	if b {
@@ -205,6 +1239,72 @@ func formatBool(b bool) string {
 	return "disabled"
 }
 
+// formatOptionalBool formats a control that may be unsupported
+func formatOptionalBool(b *bool) string {
+	if b == nil {
+		return "unsupported"
+	}
+	return formatBool(*b)
+}
+
+// formatOptionalPercent formats a percentage control that may be unsupported
+func formatOptionalPercent(n *int) string {
+	if n == nil {
+		return "unsupported"
+	}
+	return fmt.Sprintf("%d%%", *n)
+}
+
+// formatOptionalString formats a string control that may be unsupported
+func formatOptionalString(s *string) string {
+	if s == nil {
+		return "unsupported"
+	}
+	return *s
+}
+
+// formatCapacity formats a sysfs capacity (µWh or µAh) in Wh or Ah
+func formatCapacity(value int, unit string) string {
+	if unit == "µAh" {
+		return fmt.Sprintf("%.2f Ah", float64(value)/1e6)
+	}
+	return fmt.Sprintf("%.2f Wh", float64(value)/1e6)
+}
+
+// formatMode formats the current mode, calling out storage mode
+func formatMode(status *protocol.StatusData) string {
+	if !status.StorageMode {
+		return status.CurrentMode
+	}
+	if status.ForceDischarging {
+		return fmt.Sprintf("storage (discharging to %d%%)", status.StorageTarget)
+	}
+	return fmt.Sprintf("storage (holding at %d%%)", status.StorageTarget)
+}
+
+// formatThreshold formats the charge threshold, noting where charging resumes
+// when it differs from the threshold
+func formatThreshold(status *protocol.StatusData) string {
+	var notes []string
+	if status.StartThreshold > 0 && status.StartThreshold < status.Threshold {
+		notes = append(notes, fmt.Sprintf("charging resumes below %d%%", status.StartThreshold))
+	}
+	if status.Schedule != "" {
+		notes = append(notes, fmt.Sprintf("schedule: %s", status.Schedule))
+	}
+	if status.PowerMode != "" {
+		notes = append(notes, fmt.Sprintf("power mode: %s", status.PowerMode))
+	}
+	if status.Docking != "" {
+		notes = append(notes, fmt.Sprintf("docking: %s", strings.ReplaceAll(status.Docking, "_", " ")))
+	}
+
+	if len(notes) == 0 {
+		return fmt.Sprintf("%d%%", status.Threshold)
+	}
+	return fmt.Sprintf("%d%% (%s)", status.Threshold, strings.Join(notes, ", "))
+}
+
 // formatCharging formats charging status for display
 func formatCharging(charging bool) string {
 	if charging {
@@ -213,22 +1313,57 @@ func formatCharging(charging bool) string {
 	return "discharging"
 }
 
+// formatAdapter formats the power adapter for display, e.g. "USB-C PD, 100 W"
+func formatAdapter(adapter *protocol.AdapterData) string {
+	kind := adapter.Type
+	switch adapter.Type {
+	case "usb-c":
+		kind = "USB-C"
+		if adapter.PD {
+			kind += " PD"
+		}
+	case "barrel":
+		kind = "barrel connector"
+	}
+
+	if adapter.Watts <= 0 {
+		return kind + " (power not reported)"
+	}
+	return fmt.Sprintf("%s, %.0f W", kind, adapter.Watts)
+}
+
+// formatReading formats the age of the battery readings for display
+func formatReading(status *protocol.StatusData) string {
+	if status.Fresh {
+		return "live"
+	}
+	if status.ReadingAge == "" {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s ago (cached, use --fresh for a live read)", status.ReadingAge)
+}
+
 // GetThresholdRange returns information about valid threshold range
 func GetThresholdRange() (min, max int, description string) {
 	return 60, 100, "Threshold must be between 60-100% due to hardware conservation mode limitation on Lenovo Legion Slim 7 (2021)"
 }
 
-// CheckDaemonConnection checks if the daemon is available and provides user-friendly error messages
+// CheckDaemonConnection checks if the daemon is available and returns a coded
+// error describing why it is not, for use with SuggestedCommand
 func CheckDaemonConnection(client *Client) error {
-	if !client.IsDaemonRunning() {
-		return fmt.Errorf("daemon is not running. Start it with: sudo legionbatctl daemon")
+	err := client.Ping()
+	if err == nil {
+		return nil
 	}
 
-	if err := client.Ping(); err != nil {
-		return fmt.Errorf("daemon is not responding. Check daemon status with: sudo systemctl status legionbatctl")
+	switch protocol.ErrorCode(err) {
+	case protocol.CodeDaemonNotRunning:
+		return protocol.NewCodedError(protocol.CodeDaemonNotRunning, "daemon is not running")
+	case protocol.CodePermissionDenied:
+		return protocol.ErrSocketPermission
+	default:
+		return protocol.NewCodedError(protocol.CodeDaemonNotResponding, "daemon is not responding")
 	}
-
-	return nil
 }
 
 // RetryOperation executes an operation with retry logic
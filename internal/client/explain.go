@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// FormatExplain formats why conservation mode is on or off
+func FormatExplain(data *protocol.ExplainData) string {
+	mode := "off"
+	if data.ConservationMode {
+		mode = "on"
+	}
+
+	output := fmt.Sprintf("Conservation mode is %s because:\n", mode)
+	for i, step := range data.Steps {
+		output += fmt.Sprintf("  %d. %s\n", i+1, step)
+	}
+
+	output += "\nInputs:\n"
+	output += fmt.Sprintf("  Battery Management: %s\n", formatBool(data.ManagementEnabled))
+	output += fmt.Sprintf("  AC Power: %s\n", formatOnline(data.ACOnline))
+	output += fmt.Sprintf("  Battery Level: %d%%\n", data.BatteryLevel)
+	output += fmt.Sprintf("  Threshold: %d%%", data.Threshold)
+	if data.Override != "" {
+		output += fmt.Sprintf(" (from %s)", data.Override)
+	}
+	output += "\n"
+	output += fmt.Sprintf("  Charging Resumes Below: %d%%\n", data.StartThreshold)
+	output += fmt.Sprintf("  Hysteresis: %d%%\n", data.Hysteresis)
+	output += fmt.Sprintf("  Last Check: %s\n", formatExplainTime(data.LastCheck))
+	output += fmt.Sprintf("  Last Switch: %s\n", formatExplainTime(data.LastSwitch))
+
+	return output
+}
+
+// FormatExplainResult formats the result of an explain command
+func FormatExplainResult(result *CommandResult) string {
+	if result.Success {
+		if data, ok := result.Data.(*protocol.ExplainData); ok {
+			return FormatExplain(data)
+		}
+		return result.Message
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// formatOnline formats the AC adapter state
+func formatOnline(online bool) string {
+	if online {
+		return "connected"
+	}
+	return "disconnected"
+}
+
+// formatExplainTime formats a decision time with its age
+func formatExplainTime(t time.Time) string {
+	if t.IsZero() {
+		return "never (since the daemon started)"
+	}
+	return fmt.Sprintf("%s (%s ago)", t.Local().Format("15:04:05"), time.Since(t).Round(time.Second))
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// graphGap is the longest stretch without history that is bridged with the
+// previous reading; longer gaps are left empty in the graph
+const graphGap = 15 * time.Minute
+
+// graphBlocks are the partial blocks used for sub-row resolution
+var graphBlocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// graphColumn is the battery level and threshold drawn in one column
+type graphColumn struct {
+	level     float64
+	threshold int
+	known     bool
+}
+
+// RenderGraph draws the battery level over [since, until] as a bar chart of
+// width columns and height rows, marking the threshold with a dotted line
+func RenderGraph(entries []protocol.HistoryEntry, since, until time.Time, width, height int) string {
+	if len(entries) == 0 {
+		return "No battery history recorded in this range.\n"
+	}
+
+	columns := graphColumns(entries, since, until, width)
+
+	var buf strings.Builder
+	for row := height - 1; row >= 0; row-- {
+		top := (row + 1) * 100 / height
+		if top%20 == 0 || row == height-1 {
+			fmt.Fprintf(&buf, "%4d%% ┤", top)
+		} else {
+			buf.WriteString("      │")
+		}
+
+		for _, column := range columns {
+			buf.WriteRune(graphCell(column, row, height))
+		}
+		buf.WriteString("\n")
+	}
+
+	layout := "15:04"
+	if until.Sub(since) > 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+	start, end := since.Local().Format(layout), until.Local().Format(layout)
+
+	buf.WriteString("      └" + strings.Repeat("─", width) + "\n")
+	buf.WriteString("       " + start + strings.Repeat(" ", max(width-len(start)-len(end), 1)) + end + "\n")
+	buf.WriteString("       █ battery level  ┈ threshold\n")
+
+	return buf.String()
+}
+
+// graphColumns buckets the entries into width columns, averaging the levels
+// in each bucket and carrying the previous reading across short gaps
+func graphColumns(entries []protocol.HistoryEntry, since, until time.Time, width int) []graphColumn {
+	columns := make([]graphColumn, width)
+	step := until.Sub(since) / time.Duration(width)
+	if step <= 0 {
+		return columns
+	}
+
+	i := 0
+	var last *protocol.HistoryEntry
+	for c := range columns {
+		start := since.Add(time.Duration(c) * step)
+		end := start.Add(step)
+
+		var sum, count int
+		for ; i < len(entries) && entries[i].Time.Before(end); i++ {
+			if entries[i].Time.Before(start) {
+				last = &entries[i]
+				continue
+			}
+			sum += entries[i].Level
+			count++
+			last = &entries[i]
+		}
+
+		switch {
+		case count > 0:
+			columns[c] = graphColumn{level: float64(sum) / float64(count), threshold: last.Threshold, known: true}
+		case last != nil && start.Sub(last.Time) <= graphGap:
+			columns[c] = graphColumn{level: float64(last.Level), threshold: last.Threshold, known: true}
+		}
+	}
+
+	return columns
+}
+
+// graphCell returns the character drawn for a column in the given row,
+// counted from the bottom
+func graphCell(column graphColumn, row, height int) rune {
+	if !column.known {
+		return ' '
+	}
+
+	fill := column.level / 100 * float64(height)
+	switch {
+	case fill >= float64(row+1):
+		return graphBlocks[len(graphBlocks)-1]
+	case fill > float64(row):
+		if block := graphBlocks[int((fill-float64(row))*8)]; block != ' ' {
+			return block
+		}
+	}
+
+	if thresholdRow := min(column.threshold*height/100, height-1); row == thresholdRow {
+		return '┈'
+	}
+	return ' '
+}
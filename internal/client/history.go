@@ -0,0 +1,165 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// timeSpecLayouts are the absolute time formats accepted by ParseTimeSpec
+var timeSpecLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTimeSpec parses a point in time given either as a duration before now
+// ("90m", "24h", "7d") or as an absolute local time ("2006-01-02",
+// "2006-01-02 15:04" or RFC 3339)
+func ParseTimeSpec(spec string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(spec, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+
+	if duration, err := time.ParseDuration(spec); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+
+	for _, layout := range timeSpecLayouts {
+		if t, err := time.ParseInLocation(layout, spec, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q (expected a duration such as 24h or 7d, or a date such as 2006-01-02 15:04)", spec)
+}
+
+// FormatHistory formats history entries as a table, oldest first
+func FormatHistory(data *protocol.HistoryData) string {
+	if len(data.Entries) == 0 {
+		return "No battery history recorded in this range.\n"
+	}
+
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TIME\tLEVEL\tPOWER\tCONSERVATION\tTHRESHOLD\tEVENT\n")
+	for _, entry := range data.Entries {
+		fmt.Fprintf(w, "%s\t%d%%\t%s\t%s\t%d%%\t%s\n",
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			entry.Level,
+			formatCharging(entry.ACConnected),
+			formatBool(entry.ConservationMode),
+			entry.Threshold,
+			formatHistoryEvent(entry.Event))
+	}
+	w.Flush()
+
+	return buf.String()
+}
+
+// FormatHistoryResult formats the result of history command
+func FormatHistoryResult(result *CommandResult) string {
+	if result.Success {
+		if data, ok := result.Data.(*protocol.HistoryData); ok {
+			return FormatHistory(data)
+		}
+		return result.Message
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// ExportFormats lists the formats supported by ExportHistory
+var ExportFormats = []string{"csv", "jsonl"}
+
+// ExportHistory writes history entries to w as CSV with a header row or as
+// JSON lines
+func ExportHistory(w io.Writer, entries []protocol.HistoryEntry, format string) error {
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"time", "event", "level", "ac", "conservation", "threshold"})
+		for _, entry := range entries {
+			writer.Write([]string{
+				entry.Time.Format(time.RFC3339),
+				entry.Event,
+				strconv.Itoa(entry.Level),
+				strconv.FormatBool(entry.ACConnected),
+				strconv.FormatBool(entry.ConservationMode),
+				strconv.Itoa(entry.Threshold),
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+	case "jsonl":
+		encoder := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported export format %q (expected %s)", format, strings.Join(ExportFormats, " or "))
+}
+
+// FormatStats formats history aggregates
+func FormatStats(stats *protocol.StatsData) string {
+	since := "the start of the history"
+	if !stats.Since.IsZero() {
+		since = stats.Since.Local().Format("2006-01-02 15:04")
+	}
+	header := fmt.Sprintf("Battery Stats (%s to %s):\n", since, stats.Until.Local().Format("2006-01-02 15:04"))
+
+	if stats.Entries == 0 {
+		return header + "  No battery history recorded in this range.\n"
+	}
+
+	var buf strings.Builder
+	buf.WriteString(header)
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Observed:\t%s\n", stats.Covered)
+	fmt.Fprintf(w, "  Conservation Mode:\t%s (%.0f%%)\n", stats.ConservationTime, stats.ConservationPercent)
+	fmt.Fprintf(w, "  Conservation Toggles:\t%d\n", stats.Toggles)
+	fmt.Fprintf(w, "  On AC Power:\t%s (%.0f%%)\n", stats.ACTime, stats.ACPercent)
+	fmt.Fprintf(w, "  On Battery:\t%s (%.0f%%)\n", stats.BatteryTime, stats.BatteryPercent)
+	fmt.Fprintf(w, "  Average Level:\t%.0f%%\n", stats.AverageLevel)
+	fmt.Fprintf(w, "  Level Range:\t%d%% - %d%%\n", stats.MinLevel, stats.MaxLevel)
+	w.Flush()
+
+	return buf.String()
+}
+
+// FormatStatsResult formats the result of stats command
+func FormatStatsResult(result *CommandResult) string {
+	if result.Success {
+		if stats, ok := result.Data.(*protocol.StatsData); ok {
+			return FormatStats(stats)
+		}
+		return result.Message
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// formatHistoryEvent formats a history event for display
+func formatHistoryEvent(event string) string {
+	switch event {
+	case "conservation_on":
+		return "conservation switched on"
+	case "conservation_off":
+		return "conservation switched off"
+	}
+	return event
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Icon themes for the glyphs of status --short and statusline
+const (
+	IconsASCII    = "ascii"
+	IconsUnicode  = "unicode"
+	IconsNerdFont = "nerd-font"
+)
+
+// DefaultIconTheme is the icon theme used unless another one is chosen
+const DefaultIconTheme = IconsUnicode
+
+// IconThemes are the selectable icon themes. The nerd-font glyphs are
+// Material Design battery icons, which need a Nerd Font in the bar or
+// terminal.
+var IconThemes = map[string]ShortGlyphs{
+	IconsASCII:   {Charging: "+", Discharging: "-", Held: "=", Error: "!"},
+	IconsUnicode: {Charging: "⇡", Discharging: "⇣", Held: "=", Error: "⚠"},
+	IconsNerdFont: {
+		Charging: "\U000F0084", // nf-md-battery_charging
+		Held:     "\uF1E6",     // nf-fa-plug
+		Error:    "\U000F0083", // nf-md-battery_alert
+		Levels: []string{
+			"\U000F008E", // nf-md-battery_outline
+			"\U000F007A", "\U000F007B", "\U000F007C", "\U000F007D", "\U000F007E",
+			"\U000F007F", "\U000F0080", "\U000F0081", "\U000F0082", // nf-md-battery_10 to 90
+			"\U000F0079", // nf-md-battery
+		},
+	},
+}
+
+// IconTheme returns the glyphs of the named icon theme
+func IconTheme(name string) (ShortGlyphs, error) {
+	glyphs, ok := IconThemes[name]
+	if !ok {
+		return ShortGlyphs{}, fmt.Errorf("unknown icon theme %q, available: %s", name, strings.Join(IconThemeNames(), ", "))
+	}
+	return glyphs, nil
+}
+
+// IconThemeNames returns the names of the available icon themes, sorted
+func IconThemeNames() []string {
+	names := make([]string, 0, len(IconThemes))
+	for name := range IconThemes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// levelGlyph returns the glyph of Levels for a battery level, rounding to
+// the nearest step
+func (g ShortGlyphs) levelGlyph(level int) string {
+	steps := len(g.Levels) - 1
+	index := (min(max(level, 0), 100)*steps + 50) / 100
+	return g.Levels[index]
+}
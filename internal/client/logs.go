@@ -0,0 +1,57 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// FormatLogs formats daemon log lines, noting lines the daemon no longer had
+func FormatLogs(data *protocol.LogsData) string {
+	var buf strings.Builder
+	if data.Dropped > 0 {
+		fmt.Fprintf(&buf, "... %d lines dropped ...\n", data.Dropped)
+	}
+	for _, line := range data.Lines {
+		buf.WriteString(line.Text)
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// FormatLogsResult formats the result of logs command
+func FormatLogsResult(result *CommandResult) string {
+	if result.Success {
+		if data, ok := result.Data.(*protocol.LogsData); ok {
+			return FormatLogs(data)
+		}
+		return result.Message
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// FormatSetLogLevelResult formats the result of set_log_level command
+func FormatSetLogLevelResult(result *CommandResult) string {
+	if result.Success {
+		if data, ok := result.Data.(*protocol.LogLevelData); ok {
+			if data.Level == "" {
+				return "✓ Daemon log levels reset to the configuration.\n"
+			}
+			return fmt.Sprintf("✓ Daemon now logs at %s level until it restarts.\n", data.Level)
+		}
+		return result.Message + "\n"
+	} else {
+		return FormatFailure(result.Message, result)
+	}
+}
+
+// LastLogSeq returns the number of the newest line in data, or seq if there
+// are none, to continue following the log from
+func LastLogSeq(data *protocol.LogsData, seq uint64) uint64 {
+	if len(data.Lines) == 0 {
+		return seq
+	}
+	return data.Lines[len(data.Lines)-1].Seq
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// MonitorChanges describes what changed between two status readings. The
+// first reading (prev == nil) reports every tracked value.
+func MonitorChanges(prev, cur *protocol.StatusData) []string {
+	var changes []string
+
+	if prev == nil || prev.BatteryLevel != cur.BatteryLevel {
+		changes = append(changes, fmt.Sprintf("level %s", formatTransition(prev, cur, func(s *protocol.StatusData) string {
+			return fmt.Sprintf("%d%%", s.BatteryLevel)
+		})))
+	}
+
+	if prev == nil || prev.Charging != cur.Charging {
+		changes = append(changes, fmt.Sprintf("status %s", formatTransition(prev, cur, func(s *protocol.StatusData) string {
+			return formatCharging(s.Charging)
+		})))
+	}
+
+	if prev == nil || prev.ConservationMode != cur.ConservationMode {
+		changes = append(changes, fmt.Sprintf("conservation %s", formatTransition(prev, cur, func(s *protocol.StatusData) string {
+			return formatBool(s.ConservationMode)
+		})))
+	}
+
+	return changes
+}
+
+// FormatMonitorLine formats a timestamped monitor line for the given changes
+func FormatMonitorLine(t time.Time, changes []string) string {
+	return fmt.Sprintf("%s  %s\n", t.Format("15:04:05"), strings.Join(changes, ", "))
+}
+
+// formatTransition renders a value as "old -> new", or just the new value
+// when there is no previous reading
+func formatTransition(prev, cur *protocol.StatusData, value func(*protocol.StatusData) string) string {
+	if prev == nil {
+		return value(cur)
+	}
+	return fmt.Sprintf("%s -> %s", value(prev), value(cur))
+}
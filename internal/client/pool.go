@@ -0,0 +1,214 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+const (
+	// DefaultPoolIdleTimeout is how long a pooled connection is kept open
+	// without being used
+	DefaultPoolIdleTimeout = 5 * time.Second
+
+	// maxPoolIdleTimeout keeps pooled connections from being closed by the
+	// daemon, which drops connections that send no request for
+	// protocol.ReadTimeout
+	maxPoolIdleTimeout = protocol.ReadTimeout - time.Second
+)
+
+// ErrPoolClosed is returned for requests through a pool after Close
+var ErrPoolClosed = errors.New("connection pool closed")
+
+// pool keeps connections to the daemon open between requests, so consumers
+// polling it often don't dial, and negotiate the framing, for every request.
+// At most size connections are open; requests beyond that wait for one to be
+// returned.
+type pool struct {
+	idleTimeout time.Duration
+	slots       chan struct{} // Holds a token per connection in use
+
+	mutex  sync.Mutex
+	idle   []*pooledConn // Least recently used first
+	closed bool
+	stop   chan struct{}
+}
+
+// pooledConn is a connection to the daemon with its negotiated codec
+type pooledConn struct {
+	conn     net.Conn
+	codec    *protocol.Codec
+	lastUsed time.Time
+}
+
+func newPool(size int, idleTimeout time.Duration) *pool {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultPoolIdleTimeout
+	}
+	p := &pool{
+		idleTimeout: min(idleTimeout, maxPoolIdleTimeout),
+		slots:       make(chan struct{}, size),
+		stop:        make(chan struct{}),
+	}
+	go p.reap()
+	return p
+}
+
+// EnablePool has the client keep up to size connections to the daemon open
+// and share them between requests, including concurrent ones. Connections
+// unused for idleTimeout (DefaultPoolIdleTimeout if zero) are closed. Set the
+// framing before enabling the pool, and call Close when done.
+func (c *Client) EnablePool(size int, idleTimeout time.Duration) error {
+	if size < 1 {
+		return fmt.Errorf("pool size must be at least 1, got %d", size)
+	}
+	if c.pool != nil {
+		c.pool.close()
+	}
+	c.pool = newPool(size, idleTimeout)
+	return nil
+}
+
+// send sends a request over a pooled connection. A request failing because
+// the daemon closed an idle connection, e.g. when it was restarted, is sent
+// again over a new one unless it changes settings.
+func (p *pool) send(c *Client, command string, params map[string]interface{}) (*protocol.Response, error) {
+	for {
+		pc, reused, err := p.get(c)
+		if err != nil {
+			return nil, err
+		}
+
+		pc.conn.SetDeadline(time.Now().Add(c.timeout))
+		response, err := c.exchange(pc.codec, command, params)
+		if err == nil {
+			p.put(pc)
+			return response, nil
+		}
+
+		p.discard(pc)
+		if !reused || !isConnClosed(err) || protocol.IsMutatingCommand(command) {
+			return nil, err
+		}
+	}
+}
+
+// get takes an idle connection, or dials one if there is none. reused tells
+// whether the connection was used before.
+func (p *pool) get(c *Client) (pc *pooledConn, reused bool, err error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-time.After(c.timeout):
+		return nil, false, fmt.Errorf("failed to connect to daemon: %w",
+			protocol.NewCodedError(protocol.CodeDaemonNotResponding, "no pooled connection became free"))
+	}
+
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		<-p.slots
+		return nil, false, ErrPoolClosed
+	}
+	if n := len(p.idle); n > 0 {
+		// The most recently used connection is the least likely to be stale
+		pc = p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mutex.Unlock()
+		return pc, true, nil
+	}
+	p.mutex.Unlock()
+
+	conn, err := c.connect()
+	if err != nil {
+		<-p.slots
+		return nil, false, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	codec, err := c.newCodec(conn)
+	if err != nil {
+		conn.Close()
+		<-p.slots
+		return nil, false, err
+	}
+	return &pooledConn{conn: conn, codec: codec}, false, nil
+}
+
+// put returns a connection to the pool after a request
+func (p *pool) put(pc *pooledConn) {
+	pc.lastUsed = time.Now()
+
+	p.mutex.Lock()
+	if p.closed {
+		pc.conn.Close()
+	} else {
+		p.idle = append(p.idle, pc)
+	}
+	p.mutex.Unlock()
+	<-p.slots
+}
+
+// discard closes a connection that failed
+func (p *pool) discard(pc *pooledConn) {
+	pc.conn.Close()
+	<-p.slots
+}
+
+// reap closes connections that have been idle for too long until the pool
+// is closed
+func (p *pool) reap() {
+	ticker := time.NewTicker(p.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			p.closeIdle(now.Add(-p.idleTimeout))
+		}
+	}
+}
+
+// closeIdle closes the idle connections last used before cutoff
+func (p *pool) closeIdle(cutoff time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	kept := p.idle[:0]
+	for _, pc := range p.idle {
+		if pc.lastUsed.Before(cutoff) {
+			pc.conn.Close()
+		} else {
+			kept = append(kept, pc)
+		}
+	}
+	clear(p.idle[len(kept):])
+	p.idle = kept
+}
+
+// close closes the idle connections and stops the reaper. Connections in use
+// are closed when returned.
+func (p *pool) close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	for _, pc := range p.idle {
+		pc.conn.Close()
+	}
+	p.idle = nil
+}
+
+// isConnClosed reports whether err means the daemon closed the connection
+func isConnClosed(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// Statusline styles supported by FormatStatusline
+const (
+	StatuslineWaybar   = "waybar"
+	StatuslinePolybar  = "polybar"
+	StatuslineI3blocks = "i3blocks"
+)
+
+// waybarOutput is the JSON object expected by waybar's custom modules
+type waybarOutput struct {
+	Text       string `json:"text"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+}
+
+// i3blocks colors per status class
+var i3blocksColors = map[string]string{
+	"held":        "#a6e3a1",
+	"charging":    "#89b4fa",
+	"discharging": "#f9e2af",
+	"error":       "#f38ba8",
+}
+
+// IsValidStatuslineStyle checks if a statusline style is supported
+func IsValidStatuslineStyle(style string) bool {
+	switch style {
+	case StatuslineWaybar, StatuslinePolybar, StatuslineI3blocks:
+		return true
+	}
+	return false
+}
+
+// FormatStatusline formats status data in the format expected by the given bar
+func FormatStatusline(status *protocol.StatusData, style string, glyphs ShortGlyphs) (string, error) {
+	class := statusClass(status)
+	text := fmt.Sprintf("%d%% %s", status.BatteryLevel, statusGlyph(status, glyphs))
+	tooltip := statusTooltip(status)
+
+	return renderStatusline(style, text, tooltip, class, status.BatteryLevel)
+}
+
+// FormatStatuslineError formats a failed status query so the bar shows an error
+// marker instead of going blank
+func FormatStatuslineError(style, errMsg string, glyphs ShortGlyphs) (string, error) {
+	return renderStatusline(style, glyphs.Error+" legionbatctl", errMsg, "error", 0)
+}
+
+// renderStatusline renders the common statusline fields for a bar style
+func renderStatusline(style, text, tooltip, class string, percentage int) (string, error) {
+	switch style {
+	case StatuslineWaybar:
+		data, err := json.Marshal(waybarOutput{
+			Text:       text,
+			Tooltip:    tooltip,
+			Class:      class,
+			Percentage: percentage,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to encode waybar output: %w", err)
+		}
+		return string(data) + "\n", nil
+	case StatuslinePolybar:
+		return text + "\n", nil
+	case StatuslineI3blocks:
+		// full_text, short_text and color lines
+		return fmt.Sprintf("%s\n%s\n%s\n", text, text, i3blocksColors[class]), nil
+	}
+	return "", fmt.Errorf("unsupported statusline style: %s", style)
+}
+
+// statusClass returns the CSS-style class describing the charging state
+func statusClass(status *protocol.StatusData) string {
+	switch {
+	case status.Charging && status.ConservationMode:
+		return "held"
+	case status.Charging:
+		return "charging"
+	default:
+		return "discharging"
+	}
+}
+
+// statusGlyph returns the glyph describing the charging state
+func statusGlyph(status *protocol.StatusData, glyphs ShortGlyphs) string {
+	switch statusClass(status) {
+	case "held":
+		return glyphs.Held
+	case "charging":
+		return glyphs.Charging
+	}
+	if len(glyphs.Levels) > 0 {
+		return glyphs.levelGlyph(status.BatteryLevel)
+	}
+	return glyphs.Discharging
+}
+
+// statusTooltip returns a multi-line description for bar tooltips
+func statusTooltip(status *protocol.StatusData) string {
+	management := "disabled"
+	if status.ConservationEnabled {
+		management = fmt.Sprintf("enabled (threshold %d%%)", status.Threshold)
+	}
+	return fmt.Sprintf("Battery: %d%% (%s)\nManagement: %s\nConservation mode: %s",
+		status.BatteryLevel, formatCharging(status.Charging), management, formatBool(status.ConservationMode))
+}
@@ -0,0 +1,800 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dom1nux/legionbatctl/internal/dock"
+	"github.com/dom1nux/legionbatctl/internal/events"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/rules"
+	"github.com/dom1nux/legionbatctl/internal/schedule"
+)
+
+const (
+	DefaultConfigPath = "/etc/legionbatctl.conf"
+)
+
+// Config represents the daemon configuration file
+type Config struct {
+	Logging    LoggingConfig            `toml:"logging"`
+	Management ManagementConfig         `toml:"management"`
+	State      StateConfig              `toml:"state"`
+	Profiles   map[string]ProfileConfig `toml:"profiles"`
+	Schedule   []ScheduleConfig         `toml:"schedule"`
+	PowerModes map[string]string        `toml:"power_modes"` // Platform profile to the charge profile applied with it
+	Docking    []DockingConfig          `toml:"docking"`
+	Rules      []RuleConfig             `toml:"rules"`
+	Health     HealthConfig             `toml:"health"`
+	Adaptive   AdaptiveConfig           `toml:"adaptive"`
+	History    HistoryConfig            `toml:"history"`
+	Metrics    MetricsConfig            `toml:"metrics"`
+	Hardware   HardwareConfig           `toml:"hardware"`
+	Access     AccessConfig             `toml:"access"`
+	Server     ServerConfig             `toml:"server"`
+	Varlink    VarlinkConfig            `toml:"varlink"`
+	GRPC       GRPCConfig               `toml:"grpc"`
+	HTTP       HTTPConfig               `toml:"http"`
+
+	Alerts        AlertsConfig        `toml:"alerts"`
+	Notifications NotificationsConfig `toml:"notifications"`
+	Hooks         HooksConfig         `toml:"hooks"`
+	Webhooks      []WebhookConfig     `toml:"webhooks"`
+
+	// Display is read by the CLI only, usually from the user config overlay
+	Display DisplayConfig `toml:"display"`
+}
+
+// WebhookConfig configures an HTTP endpoint that events are POSTed to as JSON
+type WebhookConfig struct {
+	URL string `toml:"url"`
+
+	// Events lists the event types to send; empty sends all of them
+	Events []string `toml:"events"`
+
+	// Headers are added to every request, e.g. for authentication
+	Headers map[string]string `toml:"headers"`
+
+	// TimeoutSeconds bounds a single attempt; 0 uses the default (10)
+	TimeoutSeconds int `toml:"timeout_seconds"`
+
+	// Attempts is how often a failed delivery is tried; 0 uses the default (3)
+	Attempts int `toml:"attempts"`
+}
+
+// ServerConfig limits the load clients can put on the daemon's socket
+type ServerConfig struct {
+	// MaxConnections caps the connections served at once; further
+	// connections are refused until one closes
+	MaxConnections int `toml:"max_connections"`
+
+	// RateLimit is the sustained number of requests per second allowed per
+	// user, with bursts of up to RateBurst; 0 disables rate limiting
+	RateLimit float64 `toml:"rate_limit"`
+	RateBurst int     `toml:"rate_burst"`
+}
+
+// HTTPConfig configures the local REST API
+type HTTPConfig struct {
+	// Listen is a loopback address such as "127.0.0.1:8787"; empty disables
+	// the API. Changes take effect on restart.
+	Listen string `toml:"listen"`
+
+	// Token is required as a bearer token for requests that change
+	// settings; without it the API is read-only
+	Token string `toml:"token"`
+}
+
+// VarlinkConfig configures the varlink interface (io.legionbatctl)
+type VarlinkConfig struct {
+	// Socket to serve the interface on, e.g. "/run/io.legionbatctl"; empty
+	// disables it. Changes take effect on restart.
+	Socket string `toml:"socket"`
+}
+
+// GRPCConfig configures the gRPC API (api/legionbatctl/v1)
+type GRPCConfig struct {
+	// Socket to serve the API on, e.g. "/run/legionbatctl-grpc.sock"; empty
+	// disables it. Changes take effect on restart.
+	Socket string `toml:"socket"`
+}
+
+// AccessConfig controls who may change settings through the socket. Everyone
+// may read status; changes need root or membership in one of the groups.
+type AccessConfig struct {
+	// Groups whose members may change settings; groups that don't exist on
+	// the system are ignored, and an empty list restricts changes to root
+	Groups []string `toml:"groups"`
+}
+
+// HooksConfig configures the executables run on daemon events
+type HooksConfig struct {
+	// Dir holds the hook executables; empty disables hooks
+	Dir string `toml:"dir"`
+
+	// TimeoutSeconds bounds how long a single hook may run
+	TimeoutSeconds int `toml:"timeout_seconds"`
+}
+
+// AlertsConfig configures the battery-low and battery-critical events,
+// raised once each time the battery falls to a level on battery power
+type AlertsConfig struct {
+	// Low and Critical are battery levels in percent; 0 disables the alert
+	Low      int `toml:"low"`
+	Critical int `toml:"critical"`
+}
+
+// NotificationsConfig configures desktop notifications
+type NotificationsConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// User is the desktop user whose session is notified when the daemon
+	// runs as root; empty notifies the session of the daemon's own user
+	User string `toml:"user"`
+
+	// Events lists the event types to notify about
+	Events []string `toml:"events"`
+}
+
+// DisplayConfig holds the CLI's display preferences. Flags and environment
+// variables still take precedence.
+type DisplayConfig struct {
+	// Color enables colored output on terminals
+	Color bool `toml:"color"`
+
+	// ColorTheme is the color theme: default, bright or mono
+	ColorTheme string `toml:"color_theme"`
+
+	// Icons is the icon theme of status --short and statusline: ascii,
+	// unicode or nerd-font
+	Icons string `toml:"icons"`
+
+	// Glyphs are the status --short glyphs as "charging,discharging,held";
+	// empty keeps the ones of the icon theme
+	Glyphs string `toml:"glyphs"`
+
+	// StatuslineStyle is the statusline output style: waybar, polybar or
+	// i3blocks
+	StatuslineStyle string `toml:"statusline_style"`
+}
+
+// HardwareConfig selects how the daemon accesses the battery
+type HardwareConfig struct {
+	// Backend is "sysfs" (default), "upower", which reads the battery
+	// through UPower while charge controls still go through sysfs, or
+	// "mock", which simulates a battery in memory for development and CI.
+	// LEGIONBATCTL_BACKEND overrides it. Changes take effect on restart.
+	Backend string `toml:"backend"`
+
+	// SysfsRoot prefixes every sysfs path, e.g. a fake sysfs tree for
+	// integration tests, containers or CI; SYSFS_ROOT overrides it. Empty
+	// uses the real /sys.
+	SysfsRoot string `toml:"sysfs_root"`
+}
+
+// MetricsConfig configures metrics export
+type MetricsConfig struct {
+	// Textfile is where the daemon writes OpenMetrics gauges after every
+	// battery check, for node_exporter's textfile collector; empty disables it
+	Textfile string `toml:"textfile"`
+}
+
+// HistoryConfig configures where the daemon records battery history
+type HistoryConfig struct {
+	// Backend is "file" (a JSON lines ring buffer) or "sqlite" (for long
+	// retention; requires a build with the sqlite tag)
+	Backend string `toml:"backend"`
+
+	// Path overrides the default history location of the backend
+	Path string `toml:"path"`
+
+	// RetentionDays prunes entries older than this many days; 0 keeps them
+	// until the file backend's capacity is reached, or forever with sqlite
+	RetentionDays int `toml:"retention_days"`
+
+	// MaxEntries is the capacity of the file backend; 0 uses the default
+	MaxEntries int `toml:"max_entries"`
+}
+
+// HealthConfig configures battery health reporting
+type HealthConfig struct {
+	// WearWarning is the capacity loss in percent from which health is flagged
+	WearWarning int `toml:"wear_warning"`
+}
+
+// AdaptiveConfig configures adaptive threshold learning: the daemon observes
+// how often and how deep the battery is discharged and derives a threshold
+// from it, low for machines that stay plugged in and high for mobile ones
+type AdaptiveConfig struct {
+	// Mode is "off" (the default), "suggest", which shows the learned
+	// threshold in the status, or "apply", which sets it once a day
+	Mode string `toml:"mode"`
+
+	// MinThreshold and MaxThreshold bound the learned threshold
+	MinThreshold int `toml:"min_threshold"`
+	MaxThreshold int `toml:"max_threshold"`
+
+	// Days is how many days of history the threshold is learned from
+	Days int `toml:"days"`
+}
+
+// AdaptiveModes lists the valid adaptive threshold modes
+var AdaptiveModes = []string{"off", "suggest", "apply"}
+
+// ProfileConfig is a named set of charge settings
+type ProfileConfig struct {
+	Threshold      int `toml:"threshold"`
+	StartThreshold int `toml:"start_threshold"` // 0 falls back to the hysteresis
+}
+
+// ScheduleConfig applies a profile or threshold during a recurring time
+// window. Night rules hold the charge from From until shortly before Wake,
+// when the daemon starts charging in time to be full by Wake.
+type ScheduleConfig struct {
+	Name      string   `toml:"name"`
+	Type      string   `toml:"type"` // "window" (default) or "night"
+	Days      []string `toml:"days"` // e.g. ["mon-fri"], ["weekends"]; empty means daily
+	From      string   `toml:"from"` // "HH:MM", for night rules DefaultNightStart if empty
+	To        string   `toml:"to"`   // "HH:MM", before From for overnight windows
+	Wake      string   `toml:"wake"` // "HH:MM" the battery is full by, for night rules
+	Profile   string   `toml:"profile"`
+	Threshold int      `toml:"threshold"` // For night rules, 0 holds the configured threshold
+}
+
+// DockingConfig applies a charge profile while the laptop is set up a
+// certain way, e.g. docked at the desk
+type DockingConfig struct {
+	When    string `toml:"when"` // "docked", "undocked", "lid_closed", "lid_open" or "clamshell"
+	Profile string `toml:"profile"`
+}
+
+// RuleConfig takes an action once when all of its conditions start to hold,
+// e.g. lowering the threshold when plugged in at the office. Unset conditions
+// hold always; at least one must be set.
+type RuleConfig struct {
+	Name string `toml:"name"`
+
+	BatteryBelow int      `toml:"battery_below"`
+	BatteryAbove int      `toml:"battery_above"`
+	AC           string   `toml:"ac"`   // "battery", "ac", "barrel" or "usb-c"
+	Days         []string `toml:"days"` // Like schedule days, with from and to
+	From         string   `toml:"from"` // "HH:MM"
+	To           string   `toml:"to"`   // "HH:MM"
+	Lid          string   `toml:"lid"`  // "open" or "closed"
+	SSID         string   `toml:"ssid"` // Connected Wi-Fi network
+
+	Action         string `toml:"action"` // "set_threshold", "enable", "disable" or "notify"
+	Profile        string `toml:"profile"`
+	Threshold      int    `toml:"threshold"`
+	StartThreshold int    `toml:"start_threshold"`
+	Message        string `toml:"message"`
+}
+
+// DefaultNightStart is when night rules start holding the charge unless
+// configured otherwise
+const DefaultNightStart = "22:00"
+
+// PowerModes lists the platform profiles power_modes may couple with a
+// charge profile
+var PowerModes = []string{"low-power", "cool", "quiet", "balanced", "balanced-performance", "performance", "custom"}
+
+// PowerModeProfile returns the charge profile coupled with a platform
+// profile, if any
+func (c *Config) PowerModeProfile(mode string) (string, ProfileConfig, bool) {
+	name, ok := c.PowerModes[mode]
+	if !ok {
+		return "", ProfileConfig{}, false
+	}
+	profile, ok := c.Profiles[name]
+	return name, profile, ok
+}
+
+// ManagementConfig tunes how the daemon manages conservation mode
+type ManagementConfig struct {
+	// Hysteresis is how many percent the battery may drop below the charge
+	// threshold before charging resumes; 0 resumes just below the threshold
+	Hysteresis int `toml:"hysteresis"`
+
+	// OnStop sets conservation mode when the daemon stops gracefully: "keep"
+	// (the default) leaves it as last written, "enable" and "disable" switch it
+	OnStop string `toml:"on_stop"`
+}
+
+// IsValidOnStop checks if an on_stop action is valid (empty means keep)
+func IsValidOnStop(action string) bool {
+	switch action {
+	case "", "keep", "enable", "disable":
+		return true
+	default:
+		return false
+	}
+}
+
+// StateConfig configures the state file
+type StateConfig struct {
+	// Backups is how many timestamped backups of the state file are kept;
+	// 0 uses the default
+	Backups int `toml:"backups"`
+}
+
+// MaxBackups bounds how many state file backups are kept
+const MaxBackups = 100
+
+// MaxHysteresis bounds the hysteresis so charging always resumes well above empty
+const MaxHysteresis = 20
+
+// LoggingConfig configures where and how the daemon logs
+type LoggingConfig struct {
+	Sinks []SinkConfig `toml:"sinks"`
+}
+
+// SinkConfig configures a single log destination
+type SinkConfig struct {
+	Type  string `toml:"type"`  // "stdout", "stderr" or "file"
+	Path  string `toml:"path"`  // Log file path for "file" sinks
+	Level string `toml:"level"` // "debug", "info", "warn" or "error"
+
+	// DebugSampleRate is the fraction (0-1] of debug records written to this
+	// sink; 0 keeps all of them
+	DebugSampleRate float64 `toml:"debug_sample_rate"`
+
+	// Redact lists extra attribute keys whose values are masked, on top of the
+	// built-in sensitive keys which are always masked
+	Redact []string `toml:"redact"`
+}
+
+// Default returns the configuration used when no config file exists
+func Default() *Config {
+	return &Config{
+		Logging: LoggingConfig{
+			Sinks: []SinkConfig{
+				{Type: "stdout", Level: "info"},
+			},
+		},
+		Health: HealthConfig{
+			WearWarning: 20,
+		},
+		Adaptive: AdaptiveConfig{
+			Mode:         "off",
+			MinThreshold: 60,
+			MaxThreshold: 90,
+			Days:         14,
+		},
+		History: HistoryConfig{
+			Backend: "file",
+		},
+		Hardware: HardwareConfig{
+			Backend: "sysfs",
+		},
+		Access: AccessConfig{
+			Groups: []string{"wheel", "sudo"},
+		},
+		Server: ServerConfig{
+			MaxConnections: 32,
+			RateLimit:      10,
+			RateBurst:      20,
+		},
+		Alerts: AlertsConfig{
+			Low:      15,
+			Critical: 5,
+		},
+		Notifications: NotificationsConfig{
+			Events: []string{"management-enabled", "management-disabled", "charge-paused", "battery-low", "battery-critical", "error"},
+		},
+		Hooks: HooksConfig{
+			Dir:            "/etc/legionbatctl/hooks.d",
+			TimeoutSeconds: 30,
+		},
+		Display: DisplayConfig{
+			Color:           true,
+			ColorTheme:      "default",
+			Icons:           "unicode",
+			StatuslineStyle: "waybar",
+		},
+	}
+}
+
+// Load reads the config file at path, falling back to defaults if it does
+// not exist. Every invalid setting is reported, with its line in the file.
+func Load(path string) (*Config, error) {
+	cfg, _, err := LoadWithWarnings(path)
+	return cfg, err
+}
+
+// Validate checks configuration values, returning the first problem found
+func (c *Config) Validate() error {
+	if problems := c.problems(); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// problems checks every configuration value
+func (c *Config) problems() []*FieldError {
+	var problems []*FieldError
+	add := func(field string, err error) {
+		problems = append(problems, &FieldError{Field: field, Err: err})
+	}
+
+	if len(c.Logging.Sinks) == 0 {
+		add("logging.sinks", ErrNoLogSinks)
+	}
+
+	for i, sink := range c.Logging.Sinks {
+		field := fmt.Sprintf("logging.sinks[%d]", i)
+		switch sink.Type {
+		case "stdout", "stderr":
+		case "file":
+			if sink.Path == "" {
+				add(field+".path", ErrMissingSinkPath)
+			}
+		default:
+			add(field+".type", fmt.Errorf("%w: %q", ErrInvalidSinkType, sink.Type))
+		}
+
+		if !IsValidLogLevel(sink.Level) {
+			add(field+".level", fmt.Errorf("%w: %q", ErrInvalidLogLevel, sink.Level))
+		}
+
+		if sink.DebugSampleRate < 0 || sink.DebugSampleRate > 1 {
+			add(field+".debug_sample_rate", ErrInvalidSampleRate)
+		}
+	}
+
+	if c.Management.Hysteresis < 0 || c.Management.Hysteresis > MaxHysteresis {
+		add("management.hysteresis", ErrInvalidHysteresis)
+	}
+
+	if !IsValidOnStop(c.Management.OnStop) {
+		add("management.on_stop", fmt.Errorf("%w: %q", ErrInvalidOnStop, c.Management.OnStop))
+	}
+
+	if c.State.Backups < 0 || c.State.Backups > MaxBackups {
+		add("state.backups", ErrInvalidBackups)
+	}
+
+	if c.Health.WearWarning < 1 || c.Health.WearWarning > 100 {
+		add("health.wear_warning", ErrInvalidWearWarning)
+	}
+
+	if !slices.Contains(AdaptiveModes, c.Adaptive.Mode) {
+		add("adaptive.mode", fmt.Errorf("%w: %q", ErrInvalidAdaptiveMode, c.Adaptive.Mode))
+	}
+	if err := validateThresholds(c.Adaptive.MinThreshold, 0); err != nil {
+		add("adaptive.min_threshold", err)
+	}
+	if err := validateThresholds(c.Adaptive.MaxThreshold, 0); err != nil {
+		add("adaptive.max_threshold", err)
+	} else if c.Adaptive.MaxThreshold < c.Adaptive.MinThreshold {
+		add("adaptive.max_threshold", ErrInvalidAdaptiveRange)
+	}
+	if c.Adaptive.Days < 1 {
+		add("adaptive.days", ErrInvalidAdaptiveDays)
+	}
+
+	switch c.History.Backend {
+	case "file", "sqlite":
+	default:
+		add("history.backend", fmt.Errorf("%w: %q", ErrInvalidHistoryBackend, c.History.Backend))
+	}
+
+	if c.History.RetentionDays < 0 {
+		add("history.retention_days", ErrInvalidRetention)
+	}
+	if c.History.MaxEntries < 0 {
+		add("history.max_entries", ErrInvalidRetention)
+	}
+
+	if !slices.Contains(hardware.Backends, c.Hardware.Backend) {
+		add("hardware.backend", fmt.Errorf("%w: %q", ErrInvalidHardwareBackend, c.Hardware.Backend))
+	}
+
+	if c.Hardware.SysfsRoot != "" && !filepath.IsAbs(c.Hardware.SysfsRoot) {
+		add("hardware.sysfs_root", fmt.Errorf("%w: %q", ErrInvalidSysfsRoot, c.Hardware.SysfsRoot))
+	}
+
+	if c.Alerts.Low < 0 || c.Alerts.Low > 100 {
+		add("alerts.low", ErrInvalidAlertLevel)
+	}
+	if c.Alerts.Critical < 0 || c.Alerts.Critical > 100 {
+		add("alerts.critical", ErrInvalidAlertLevel)
+	} else if c.Alerts.Critical > 0 && c.Alerts.Low > 0 && c.Alerts.Critical >= c.Alerts.Low {
+		add("alerts.critical", ErrInvalidCriticalLevel)
+	}
+
+	for _, name := range c.Notifications.Events {
+		if !events.IsValidType(name) {
+			add("notifications.events", fmt.Errorf("%w: %q", ErrInvalidEvent, name))
+		}
+	}
+
+	if c.Hooks.TimeoutSeconds < 1 {
+		add("hooks.timeout_seconds", ErrInvalidHookTimeout)
+	}
+
+	if c.Server.MaxConnections < 1 {
+		add("server.max_connections", ErrInvalidMaxConnections)
+	}
+
+	if c.Server.RateLimit < 0 {
+		add("server.rate_limit", ErrInvalidRateLimit)
+	} else if c.Server.RateLimit > 0 && c.Server.RateBurst < 1 {
+		add("server.rate_burst", ErrInvalidRateLimit)
+	}
+
+	if c.HTTP.Listen != "" && !isLoopback(c.HTTP.Listen) {
+		add("http.listen", fmt.Errorf("%w: %q", ErrInvalidHTTPListen, c.HTTP.Listen))
+	}
+
+	for i, webhook := range c.Webhooks {
+		if err := webhook.validate(); err != nil {
+			add(fmt.Sprintf("webhooks[%d]", i), err)
+		}
+	}
+
+	if c.Metrics.Textfile != "" && !strings.HasSuffix(c.Metrics.Textfile, ".prom") {
+		add("metrics.textfile", ErrInvalidTextfile)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Profiles)) {
+		profile := c.Profiles[name]
+		if err := validateThresholds(profile.Threshold, profile.StartThreshold); err != nil {
+			add("profiles."+name, err)
+		}
+	}
+
+	for i, entry := range c.Schedule {
+		if _, err := c.scheduleRule(entry); err != nil {
+			add(fmt.Sprintf("schedule[%d]", i), err)
+		}
+	}
+
+	for _, mode := range slices.Sorted(maps.Keys(c.PowerModes)) {
+		if !slices.Contains(PowerModes, mode) {
+			add("power_modes."+mode, fmt.Errorf("%w: %q", ErrInvalidPowerMode, mode))
+		} else if _, ok := c.Profiles[c.PowerModes[mode]]; !ok {
+			add("power_modes."+mode, fmt.Errorf("%w: %q", ErrUnknownProfile, c.PowerModes[mode]))
+		}
+	}
+
+	for i, rule := range c.Docking {
+		field := fmt.Sprintf("docking[%d]", i)
+		if !dock.IsValidCondition(rule.When) {
+			add(field, fmt.Errorf("%w: %q", ErrInvalidDocking, rule.When))
+		} else if _, ok := c.Profiles[rule.Profile]; !ok {
+			add(field, fmt.Errorf("%w: %q", ErrUnknownProfile, rule.Profile))
+		}
+	}
+
+	names := make(map[string]bool, len(c.Rules))
+	for i, entry := range c.Rules {
+		field := fmt.Sprintf("rules[%d]", i)
+		if _, err := c.rule(entry); err != nil {
+			add(field, err)
+		}
+		if name := ruleName(entry, i); names[name] {
+			add(field, fmt.Errorf("%w: %q", ErrDuplicateRule, name))
+		} else {
+			names[name] = true
+		}
+	}
+
+	return problems
+}
+
+// ScheduleRules compiles the schedule into rules, resolving profile references
+func (c *Config) ScheduleRules() ([]schedule.Rule, error) {
+	rules := make([]schedule.Rule, 0, len(c.Schedule))
+
+	for i, entry := range c.Schedule {
+		name := entry.Name
+		if name == "" {
+			name = fmt.Sprintf("schedule[%d]", i)
+		}
+
+		rule, err := c.scheduleRule(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		rule.Name = name
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// scheduleRule compiles a schedule entry into an unnamed rule
+func (c *Config) scheduleRule(entry ScheduleConfig) (schedule.Rule, error) {
+	from, to := entry.From, entry.To
+	switch entry.Type {
+	case "", "window":
+		if entry.Wake != "" {
+			return schedule.Rule{}, fmt.Errorf("%w: wake is only for night rules", ErrInvalidSchedule)
+		}
+	case "night":
+		if entry.Wake == "" || entry.To != "" {
+			return schedule.Rule{}, fmt.Errorf("%w: night rules end at wake instead of to", ErrInvalidSchedule)
+		}
+		if from == "" {
+			from = DefaultNightStart
+		}
+		to = entry.Wake
+	default:
+		return schedule.Rule{}, fmt.Errorf("%w: unknown type %q", ErrInvalidSchedule, entry.Type)
+	}
+
+	window, err := schedule.ParseWindow(entry.Days, from, to)
+	if err != nil {
+		return schedule.Rule{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	rule := schedule.Rule{Window: window, Night: entry.Type == "night"}
+	switch {
+	case entry.Profile != "" && entry.Threshold != 0:
+		return schedule.Rule{}, fmt.Errorf("%w: set either profile or threshold", ErrInvalidSchedule)
+	case rule.Night && entry.Profile == "" && entry.Threshold == 0:
+		// Holds the configured threshold
+	case entry.Profile != "":
+		profile, ok := c.Profiles[entry.Profile]
+		if !ok {
+			return schedule.Rule{}, fmt.Errorf("%w: %q", ErrUnknownProfile, entry.Profile)
+		}
+		rule.Profile = entry.Profile
+		rule.Threshold = profile.Threshold
+		rule.StartThreshold = profile.StartThreshold
+	default:
+		if err := validateThresholds(entry.Threshold, 0); err != nil {
+			return schedule.Rule{}, err
+		}
+		rule.Threshold = entry.Threshold
+	}
+
+	return rule, nil
+}
+
+// ConditionRules compiles the rules entries, resolving profile references
+func (c *Config) ConditionRules() ([]rules.Rule, error) {
+	compiled := make([]rules.Rule, 0, len(c.Rules))
+
+	for i, entry := range c.Rules {
+		rule, err := c.rule(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ruleName(entry, i), err)
+		}
+		rule.Name = ruleName(entry, i)
+		compiled = append(compiled, rule)
+	}
+
+	return compiled, nil
+}
+
+// ruleName names a rules entry, after its position if it has no name
+func ruleName(entry RuleConfig, i int) string {
+	if entry.Name != "" {
+		return entry.Name
+	}
+	return fmt.Sprintf("rules[%d]", i)
+}
+
+// rule compiles a rules entry into an unnamed rule
+func (c *Config) rule(entry RuleConfig) (rules.Rule, error) {
+	rule := rules.Rule{
+		BatteryBelow: entry.BatteryBelow,
+		BatteryAbove: entry.BatteryAbove,
+		Power:        entry.AC,
+		Lid:          entry.Lid,
+		SSID:         entry.SSID,
+		Action:       entry.Action,
+		Message:      entry.Message,
+	}
+
+	switch {
+	case entry.BatteryBelow < 0 || entry.BatteryBelow > 100 || entry.BatteryAbove < 0 || entry.BatteryAbove > 100:
+		return rules.Rule{}, fmt.Errorf("%w: battery levels must be between 0 and 100", ErrInvalidRule)
+	case entry.AC != "" && !slices.Contains(rules.PowerSources, entry.AC):
+		return rules.Rule{}, fmt.Errorf("%w: ac must be \"battery\", \"ac\", \"barrel\" or \"usb-c\"", ErrInvalidRule)
+	case entry.Lid != "" && entry.Lid != rules.LidOpen && entry.Lid != rules.LidClosed:
+		return rules.Rule{}, fmt.Errorf("%w: lid must be \"open\" or \"closed\"", ErrInvalidRule)
+	case (entry.From == "") != (entry.To == ""), len(entry.Days) > 0 && entry.From == "":
+		return rules.Rule{}, fmt.Errorf("%w: set from and to together, and with days", ErrInvalidRule)
+	}
+
+	if entry.From != "" {
+		window, err := schedule.ParseWindow(entry.Days, entry.From, entry.To)
+		if err != nil {
+			return rules.Rule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+		rule.Window = &window
+	}
+	if !rule.HasConditions() {
+		return rules.Rule{}, fmt.Errorf("%w: set at least one condition", ErrInvalidRule)
+	}
+
+	switch entry.Action {
+	case rules.SetThreshold:
+		switch {
+		case entry.Profile != "" && entry.Threshold != 0:
+			return rules.Rule{}, fmt.Errorf("%w: set either profile or threshold", ErrInvalidRule)
+		case entry.Profile != "":
+			profile, ok := c.Profiles[entry.Profile]
+			if !ok {
+				return rules.Rule{}, fmt.Errorf("%w: %q", ErrUnknownProfile, entry.Profile)
+			}
+			rule.Threshold = profile.Threshold
+			rule.StartThreshold = profile.StartThreshold
+		default:
+			if err := validateThresholds(entry.Threshold, entry.StartThreshold); err != nil {
+				return rules.Rule{}, err
+			}
+			rule.Threshold = entry.Threshold
+			rule.StartThreshold = entry.StartThreshold
+		}
+	case rules.Enable, rules.Disable:
+	case rules.Notify:
+		if entry.Message == "" {
+			return rules.Rule{}, fmt.Errorf("%w: notify needs a message", ErrInvalidRule)
+		}
+	default:
+		return rules.Rule{}, fmt.Errorf("%w: %q", ErrInvalidRuleAction, entry.Action)
+	}
+
+	return rule, nil
+}
+
+// validateThresholds checks a stop threshold and optional start threshold
+func validateThresholds(threshold, start int) error {
+	if threshold < hardware.MinThreshold || threshold > 100 {
+		return ErrInvalidThreshold
+	}
+	if start < 0 || start >= threshold {
+		return ErrInvalidStartThreshold
+	}
+	return nil
+}
+
+// IsValidLogLevel checks if a log level name is valid (empty means info)
+func IsValidLogLevel(level string) bool {
+	switch level {
+	case "", "debug", "info", "warn", "error":
+		return true
+	}
+	return false
+}
+
+// isLoopback reports whether a listen address is bound to loopback only
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// validate checks a webhook's URL, events and limits
+func (w WebhookConfig) validate() error {
+	parsed, err := url.Parse(w.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	for _, name := range w.Events {
+		if !events.IsValidType(name) {
+			return fmt.Errorf("events: %w: %q", ErrInvalidEvent, name)
+		}
+	}
+
+	if w.TimeoutSeconds < 0 || w.Attempts < 0 {
+		return ErrInvalidWebhook
+	}
+
+	return nil
+}
@@ -0,0 +1,863 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadMissingFileUsesDefaults(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.conf"))
+	if err != nil {
+		t.Fatalf("Unexpected error loading missing config: %v", err)
+	}
+
+	if len(cfg.Logging.Sinks) != 1 || cfg.Logging.Sinks[0].Type != "stdout" {
+		t.Errorf("Expected default stdout sink, got %+v", cfg.Logging.Sinks)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legionbatctl.conf")
+	content := `
+[[logging.sinks]]
+type = "stdout"
+level = "info"
+
+[[logging.sinks]]
+type = "file"
+path = "/var/log/legionbatctl-debug.log"
+level = "debug"
+debug_sample_rate = 0.25
+redact = ["ssid"]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading config: %v", err)
+	}
+
+	if len(cfg.Logging.Sinks) != 2 {
+		t.Fatalf("Expected 2 sinks, got %d", len(cfg.Logging.Sinks))
+	}
+
+	debugSink := cfg.Logging.Sinks[1]
+	if debugSink.DebugSampleRate != 0.25 {
+		t.Errorf("Expected sample rate 0.25, got %v", debugSink.DebugSampleRate)
+	}
+	if len(debugSink.Redact) != 1 || debugSink.Redact[0] != "ssid" {
+		t.Errorf("Expected redact list [ssid], got %v", debugSink.Redact)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sink    SinkConfig
+		wantErr error
+	}{
+		{"valid stdout", SinkConfig{Type: "stdout", Level: "debug"}, nil},
+		{"file without path", SinkConfig{Type: "file"}, ErrMissingSinkPath},
+		{"unknown type", SinkConfig{Type: "syslog"}, ErrInvalidSinkType},
+		{"unknown level", SinkConfig{Type: "stdout", Level: "trace"}, ErrInvalidLogLevel},
+		{"sample rate too high", SinkConfig{Type: "stdout", DebugSampleRate: 2}, ErrInvalidSampleRate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Logging.Sinks = []SinkConfig{tt.sink}
+
+			err := cfg.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidateHysteresis(t *testing.T) {
+	for _, hysteresis := range []int{-1, MaxHysteresis + 1} {
+		cfg := Default()
+		cfg.Management.Hysteresis = hysteresis
+		if err := cfg.Validate(); !errors.Is(err, ErrInvalidHysteresis) {
+			t.Errorf("Validate() with hysteresis %d error = %v, want %v", hysteresis, err, ErrInvalidHysteresis)
+		}
+	}
+
+	cfg := Default()
+	cfg.Management.Hysteresis = 5
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with hysteresis 5 error = %v", err)
+	}
+}
+
+func TestConfigValidateOnStop(t *testing.T) {
+	for _, action := range []string{"", "keep", "enable", "disable"} {
+		cfg := Default()
+		cfg.Management.OnStop = action
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Validate() with on_stop %q error = %v", action, err)
+		}
+	}
+
+	cfg := Default()
+	cfg.Management.OnStop = "off"
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidOnStop) {
+		t.Errorf("Validate() with on_stop \"off\" error = %v, want %v", err, ErrInvalidOnStop)
+	}
+}
+
+func TestConfigValidateWearWarning(t *testing.T) {
+	for _, warning := range []int{0, 101} {
+		cfg := Default()
+		cfg.Health.WearWarning = warning
+		if err := cfg.Validate(); !errors.Is(err, ErrInvalidWearWarning) {
+			t.Errorf("Validate() with wear warning %d error = %v, want %v", warning, err, ErrInvalidWearWarning)
+		}
+	}
+}
+
+func TestConfigValidateAdaptive(t *testing.T) {
+	tests := []struct {
+		name     string
+		adaptive AdaptiveConfig
+		wantErr  error
+	}{
+		{"defaults", Default().Adaptive, nil},
+		{"apply", AdaptiveConfig{Mode: "apply", MinThreshold: 70, MaxThreshold: 70, Days: 7}, nil},
+		{"unknown mode", AdaptiveConfig{Mode: "auto", MinThreshold: 60, MaxThreshold: 90, Days: 14}, ErrInvalidAdaptiveMode},
+		{"min out of range", AdaptiveConfig{Mode: "off", MinThreshold: 30, MaxThreshold: 90, Days: 14}, ErrInvalidThreshold},
+		{"max below min", AdaptiveConfig{Mode: "off", MinThreshold: 80, MaxThreshold: 70, Days: 14}, ErrInvalidAdaptiveRange},
+		{"no days", AdaptiveConfig{Mode: "off", MinThreshold: 60, MaxThreshold: 90}, ErrInvalidAdaptiveDays},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Adaptive = tt.adaptive
+			if err := cfg.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidateAlerts(t *testing.T) {
+	tests := []struct {
+		name    string
+		alerts  AlertsConfig
+		wantErr error
+	}{
+		{"defaults", AlertsConfig{Low: 15, Critical: 5}, nil},
+		{"disabled", AlertsConfig{}, nil},
+		{"only critical", AlertsConfig{Critical: 10}, nil},
+		{"low out of range", AlertsConfig{Low: 101}, ErrInvalidAlertLevel},
+		{"negative critical", AlertsConfig{Low: 15, Critical: -1}, ErrInvalidAlertLevel},
+		{"critical above low", AlertsConfig{Low: 10, Critical: 10}, ErrInvalidCriticalLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Alerts = tt.alerts
+			if err := cfg.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legionbatctl.conf")
+	content := `
+[profiles.desk]
+threshold = 60
+start_threshold = 55
+
+[[schedule]]
+name = "workdays"
+days = ["mon-fri"]
+from = "09:00"
+to = "18:00"
+profile = "desk"
+
+[[schedule]]
+days = ["weekends"]
+from = "08:00"
+to = "20:00"
+threshold = 90
+
+[[schedule]]
+name = "weeknights"
+type = "night"
+days = ["sun-thu"]
+wake = "07:00"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	rules, err := cfg.ScheduleRules()
+	if err != nil {
+		t.Fatalf("ScheduleRules() error = %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %d", len(rules))
+	}
+	if rules[0].Profile != "desk" || rules[0].Threshold != 60 || rules[0].StartThreshold != 55 {
+		t.Errorf("Expected rule resolved from profile, got %+v", rules[0])
+	}
+	if rules[1].Name != "schedule[1]" || rules[1].Threshold != 90 {
+		t.Errorf("Expected unnamed threshold rule, got %+v", rules[1])
+	}
+	if night := rules[2]; !night.Night || night.Threshold != 0 || night.Window.String() != "Mon,Tue,Wed,Thu,Sun 22:00-07:00" {
+		t.Errorf("Expected night rule from 22:00 to the wake time, got %+v", night)
+	}
+
+	tests := []struct {
+		name    string
+		entry   ScheduleConfig
+		wantErr error
+	}{
+		{"unknown profile", ScheduleConfig{From: "09:00", To: "10:00", Profile: "travel"}, ErrUnknownProfile},
+		{"profile and threshold", ScheduleConfig{From: "09:00", To: "10:00", Profile: "desk", Threshold: 70}, ErrInvalidSchedule},
+		{"invalid threshold", ScheduleConfig{From: "09:00", To: "10:00", Threshold: 30}, ErrInvalidThreshold},
+		{"invalid window", ScheduleConfig{From: "25:00", To: "10:00", Threshold: 70}, ErrInvalidSchedule},
+		{"unknown type", ScheduleConfig{Type: "weekly", From: "09:00", To: "10:00", Threshold: 70}, ErrInvalidSchedule},
+		{"night without wake", ScheduleConfig{Type: "night", From: "22:00", To: "07:00"}, ErrInvalidSchedule},
+		{"wake without night", ScheduleConfig{From: "22:00", To: "07:00", Wake: "07:00", Threshold: 70}, ErrInvalidSchedule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Schedule = []ScheduleConfig{tt.entry}
+			if err := cfg.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPowerModes(t *testing.T) {
+	cfg := Default()
+	cfg.Profiles = map[string]ProfileConfig{"desk": {Threshold: 60}}
+	cfg.PowerModes = map[string]string{"quiet": "desk"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if name, profile, ok := cfg.PowerModeProfile("quiet"); !ok || name != "desk" || profile.Threshold != 60 {
+		t.Errorf("PowerModeProfile(quiet) = %s, %+v, %v", name, profile, ok)
+	}
+	if _, _, ok := cfg.PowerModeProfile("performance"); ok {
+		t.Error("Expected no profile coupled with performance")
+	}
+
+	tests := []struct {
+		name    string
+		modes   map[string]string
+		wantErr error
+	}{
+		{"unknown profile", map[string]string{"quiet": "travel"}, ErrUnknownProfile},
+		{"unknown power mode", map[string]string{"turbo": "desk"}, ErrInvalidPowerMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.PowerModes = tt.modes
+			if err := cfg.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDocking(t *testing.T) {
+	tests := []struct {
+		name    string
+		docking []DockingConfig
+		wantErr error
+	}{
+		{"valid", []DockingConfig{{When: "clamshell", Profile: "desk"}, {When: "docked", Profile: "desk"}}, nil},
+		{"unknown condition", []DockingConfig{{When: "at_home", Profile: "desk"}}, ErrInvalidDocking},
+		{"unknown profile", []DockingConfig{{When: "docked", Profile: "travel"}}, ErrUnknownProfile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Profiles = map[string]ProfileConfig{"desk": {Threshold: 60}}
+			cfg.Docking = tt.docking
+			if err := cfg.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []RuleConfig
+		wantErr error
+	}{
+		{"valid", []RuleConfig{
+			{Name: "office", AC: "usb-c", SSID: "Office", Action: "set_threshold", Profile: "desk"},
+			{Name: "low", AC: "battery", BatteryBelow: 20, Action: "notify", Message: "Battery low"},
+			{Days: []string{"weekends"}, From: "08:00", To: "20:00", Action: "disable"},
+		}, nil},
+		{"no condition", []RuleConfig{{Action: "enable"}}, ErrInvalidRule},
+		{"unknown power source", []RuleConfig{{AC: "solar", Action: "enable"}}, ErrInvalidRule},
+		{"days without times", []RuleConfig{{Days: []string{"mon"}, Action: "enable"}}, ErrInvalidRule},
+		{"unknown action", []RuleConfig{{Lid: "closed", Action: "hibernate"}}, ErrInvalidRuleAction},
+		{"invalid threshold", []RuleConfig{{Lid: "closed", Action: "set_threshold", Threshold: 30}}, ErrInvalidThreshold},
+		{"notify without message", []RuleConfig{{Lid: "closed", Action: "notify"}}, ErrInvalidRule},
+		{"duplicate name", []RuleConfig{
+			{Name: "desk", Lid: "closed", Action: "enable"},
+			{Name: "desk", Lid: "open", Action: "disable"},
+		}, ErrDuplicateRule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Profiles = map[string]ProfileConfig{"desk": {Threshold: 60}}
+			cfg.Rules = tt.rules
+			if err := cfg.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConditionRules(t *testing.T) {
+	cfg := Default()
+	cfg.Profiles = map[string]ProfileConfig{"desk": {Threshold: 60, StartThreshold: 55}}
+	cfg.Rules = []RuleConfig{
+		{AC: "ac", Action: "set_threshold", Profile: "desk"},
+		{Name: "evening", From: "18:00", To: "23:00", Action: "notify", Message: "Unplug"},
+	}
+
+	compiled, err := cfg.ConditionRules()
+	if err != nil {
+		t.Fatalf("ConditionRules failed: %v", err)
+	}
+	if len(compiled) != 2 {
+		t.Fatalf("Expected 2 rules, got %d", len(compiled))
+	}
+	if compiled[0].Name != "rules[0]" || compiled[0].Threshold != 60 || compiled[0].StartThreshold != 55 {
+		t.Errorf("Expected rules[0] with the desk profile, got %+v", compiled[0])
+	}
+	if compiled[1].Name != "evening" || compiled[1].Window == nil {
+		t.Errorf("Expected evening with a time window, got %+v", compiled[1])
+	}
+}
+
+func TestConfigValidateBackups(t *testing.T) {
+	for _, backups := range []int{-1, MaxBackups + 1} {
+		cfg := Default()
+		cfg.State.Backups = backups
+		if err := cfg.Validate(); !errors.Is(err, ErrInvalidBackups) {
+			t.Errorf("Validate() with backups %d error = %v, want %v", backups, err, ErrInvalidBackups)
+		}
+	}
+}
+
+func TestConfigValidateSysfsRoot(t *testing.T) {
+	cfg := Default()
+	cfg.Hardware.SysfsRoot = "fake/sys"
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidSysfsRoot) {
+		t.Errorf("Validate() with relative sysfs root error = %v, want %v", err, ErrInvalidSysfsRoot)
+	}
+
+	cfg.Hardware.SysfsRoot = "/srv/fake-sysfs"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with absolute sysfs root error = %v", err)
+	}
+}
+
+func TestConfigValidateHistory(t *testing.T) {
+	cfg := Default()
+	cfg.History.Backend = "postgres"
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidHistoryBackend) {
+		t.Errorf("Validate() with backend postgres error = %v, want %v", err, ErrInvalidHistoryBackend)
+	}
+
+	cfg = Default()
+	cfg.History.RetentionDays = -1
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidRetention) {
+		t.Errorf("Validate() with negative retention error = %v, want %v", err, ErrInvalidRetention)
+	}
+
+	cfg = Default()
+	cfg.History = HistoryConfig{Backend: "sqlite", RetentionDays: 365}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with sqlite backend error = %v", err)
+	}
+}
+
+func TestConfigValidateMetricsTextfile(t *testing.T) {
+	cfg := Default()
+	cfg.Metrics.Textfile = "/var/lib/node_exporter/textfile/legionbatctl.txt"
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidTextfile) {
+		t.Errorf("Validate() with .txt textfile error = %v, want %v", err, ErrInvalidTextfile)
+	}
+
+	cfg.Metrics.Textfile = "/var/lib/node_exporter/textfile/legionbatctl.prom"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with .prom textfile error = %v", err)
+	}
+}
+
+func TestConfigValidateHardwareBackend(t *testing.T) {
+	cfg := Default()
+	cfg.Hardware.Backend = "acpi"
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidHardwareBackend) {
+		t.Errorf("Validate() with backend acpi error = %v, want %v", err, ErrInvalidHardwareBackend)
+	}
+
+	cfg.Hardware.Backend = "upower"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with backend upower error = %v", err)
+	}
+}
+
+func TestConfigValidateNotificationEvents(t *testing.T) {
+	cfg := Default()
+	cfg.Notifications.Events = []string{"threshold-reached", "battery-empty"}
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidEvent) {
+		t.Errorf("Validate() with unknown event error = %v, want %v", err, ErrInvalidEvent)
+	}
+}
+
+func TestConfigValidateHookTimeout(t *testing.T) {
+	cfg := Default()
+	cfg.Hooks.TimeoutSeconds = 0
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidHookTimeout) {
+		t.Errorf("Validate() with hook timeout 0 error = %v, want %v", err, ErrInvalidHookTimeout)
+	}
+}
+
+func TestConfigValidateWebhooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook WebhookConfig
+		wantErr error
+	}{
+		{"valid", WebhookConfig{URL: "https://example.com/hook", Events: []string{"error"}}, nil},
+		{"missing url", WebhookConfig{}, ErrInvalidWebhookURL},
+		{"unsupported scheme", WebhookConfig{URL: "ftp://example.com"}, ErrInvalidWebhookURL},
+		{"unknown event", WebhookConfig{URL: "http://hass.local:8123/api/webhook/battery", Events: []string{"battery-empty"}}, ErrInvalidEvent},
+		{"negative attempts", WebhookConfig{URL: "http://localhost/hook", Attempts: -1}, ErrInvalidWebhook},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Webhooks = []WebhookConfig{tt.webhook}
+			if err := cfg.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidateServerLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  ServerConfig
+		wantErr error
+	}{
+		{"default", Default().Server, nil},
+		{"rate limiting disabled", ServerConfig{MaxConnections: 8}, nil},
+		{"no connections", ServerConfig{MaxConnections: 0, RateLimit: 10, RateBurst: 20}, ErrInvalidMaxConnections},
+		{"negative rate", ServerConfig{MaxConnections: 8, RateLimit: -1}, ErrInvalidRateLimit},
+		{"rate without burst", ServerConfig{MaxConnections: 8, RateLimit: 5}, ErrInvalidRateLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Server = tt.server
+			if err := cfg.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidateHTTPListen(t *testing.T) {
+	tests := []struct {
+		listen  string
+		wantErr error
+	}{
+		{"", nil},
+		{"127.0.0.1:8787", nil},
+		{"[::1]:8787", nil},
+		{"localhost:8787", nil},
+		{"0.0.0.0:8787", ErrInvalidHTTPListen},
+		{":8787", ErrInvalidHTTPListen},
+		{"127.0.0.1", ErrInvalidHTTPListen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.listen, func(t *testing.T) {
+			cfg := Default()
+			cfg.HTTP.Listen = tt.listen
+			if err := cfg.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStarter(t *testing.T) {
+	content := Starter(StarterOptions{
+		Notifications: true,
+		NotifyUser:    "alice",
+		Schedule: []ScheduleConfig{
+			{Name: "full-charge", Days: []string{"mon-fri"}, From: "06:00", To: "08:00", Threshold: 100},
+		},
+	})
+	path := filepath.Join(t.TempDir(), "legionbatctl.conf")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load starter config: %v\n%s", err, content)
+	}
+
+	if !cfg.Notifications.Enabled || cfg.Notifications.User != "alice" {
+		t.Errorf("Expected notifications for alice, got %+v", cfg.Notifications)
+	}
+	if len(cfg.Notifications.Events) == 0 {
+		t.Error("Expected the default notification events to be kept")
+	}
+	rules, err := cfg.ScheduleRules()
+	if err != nil || len(rules) != 1 || rules[0].Name != "full-charge" || rules[0].Threshold != 100 {
+		t.Errorf("Expected the full-charge schedule, got %+v (%v)", rules, err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "legionbatctl.conf")
+	content := `[management]
+hysteresis = 30
+on_stopp = "keep"
+
+[[logging.sinks]]
+type = "stdout"
+
+[[logging.sinks]]
+type = "file"
+path = "` + filepath.Join(dir, "missing", "daemon.log") + `"
+level = "loud"
+
+[[schedule]]
+from = "09:00"
+to = "10:00"
+threshold = 30
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, problems, err := Check(path)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	expected := []struct {
+		line    int
+		field   string
+		err     error
+		warning bool
+	}{
+		{2, "management.hysteresis", ErrInvalidHysteresis, false},
+		{3, "management.on_stopp", ErrUnknownSetting, true},
+		{10, "logging.sinks[1].path", ErrMissingPath, true},
+		{11, "logging.sinks[1].level", ErrInvalidLogLevel, false},
+		{13, "schedule[0]", ErrInvalidThreshold, false},
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), problems)
+	}
+	for i, want := range expected {
+		got := problems[i]
+		if got.Line != want.line || got.Field != want.field || !errors.Is(got, want.err) || got.Warning != want.warning {
+			t.Errorf("Problem %d: expected line %d %s %v (warning %v), got %v (warning %v)",
+				i, want.line, want.field, want.err, want.warning, got, got.Warning)
+		}
+	}
+
+	// Load fails on the errors, listing all of them, and not on the warnings
+	_, warnings, err := LoadWithWarnings(path)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 3 || !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("Expected a validation error with 3 errors, got %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("Expected 2 warnings, got %v", warnings)
+	}
+}
+
+func TestCheckTypeError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legionbatctl.conf")
+	if err := os.WriteFile(path, []byte("[management]\n\nhysteresis = \"5\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, problems, err := Check(path)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if len(problems) != 1 || problems[0].Line != 3 || problems[0].Field != "management.hysteresis" {
+		t.Errorf("Expected a type error on line 3, got %v", problems)
+	}
+}
+
+func TestSetInFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legionbatctl.conf")
+	original := `# Managed by hand
+[management]
+hysteresis = 3 # keep it low
+
+[[logging.sinks]]
+type = "stdout"
+level = "info"
+`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	sets := []struct {
+		key, value string
+	}{
+		{"management.hysteresis", "5"},
+		{"management.on_stop", "disable"},
+		{"notifications.events", "error, threshold-reached"},
+		{"server.rate_limit", "2"},
+	}
+	for _, set := range sets {
+		if err := SetInFile(path, set.key, set.value); err != nil {
+			t.Fatalf("SetInFile(%s) error = %v", set.key, err)
+		}
+	}
+
+	want := `# Managed by hand
+[management]
+on_stop = "disable"
+hysteresis = 5 # keep it low
+
+[[logging.sinks]]
+type = "stdout"
+level = "info"
+
+[notifications]
+events = ["error", "threshold-reached"]
+
+[server]
+rate_limit = 2.0
+`
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if string(data) != want {
+		t.Errorf("Unexpected config file:\n%s\nwant:\n%s", data, want)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file mode to be kept, got %v (%v)", info.Mode(), err)
+	}
+
+	errorTests := []struct {
+		key, value string
+		wantErr    error
+	}{
+		{"management.hysteresis", "50", ErrInvalidHysteresis},
+		{"management.hysteresis", "five", ErrInvalidValue},
+		{"logging.sinks", "stdout", ErrNotSettable},
+		{"profiles.desk.threshold", "80", ErrNotSettable},
+		{"management.hysterisis", "5", ErrUnknownKey},
+	}
+	for _, tt := range errorTests {
+		if err := SetInFile(path, tt.key, tt.value); !errors.Is(err, tt.wantErr) {
+			t.Errorf("SetInFile(%s, %s) error = %v, want %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+
+	// Failed changes leave the file alone
+	if after, _ := os.ReadFile(path); string(after) != want {
+		t.Errorf("Expected the file unchanged after failed sets, got:\n%s", after)
+	}
+}
+
+func TestConfigGet(t *testing.T) {
+	cfg := Default()
+	tests := []struct {
+		key, want string
+	}{
+		{"hooks.timeout_seconds", "30"},
+		{"hardware.backend", `"sysfs"`},
+		{"access.groups", `["wheel", "sudo"]`},
+		{"notifications.enabled", "false"},
+	}
+	for _, tt := range tests {
+		if got, err := cfg.Get(tt.key); err != nil || got != tt.want {
+			t.Errorf("Get(%s) = %s, %v, want %s", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "legionbatctl.conf")
+
+	// Without a config file there is nothing to back up
+	backup, err := Import(path, []byte("[management]\nhysteresis = 3\n"))
+	if err != nil || backup != "" {
+		t.Fatalf("Import() = %q, %v, want no backup", backup, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatalf("Failed to chmod config: %v", err)
+	}
+
+	imported := "[management]\nhysteresis = 8\n"
+	backup, err = Import(path, []byte(imported))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if data, _ := os.ReadFile(backup); string(data) != "[management]\nhysteresis = 3\n" {
+		t.Errorf("Expected the previous file in %s, got %q", backup, data)
+	}
+	if info, err := os.Stat(backup); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the backup to keep the file mode, got %v (%v)", info.Mode(), err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != imported {
+		t.Errorf("Expected the imported file, got %q", data)
+	}
+
+	// Invalid files are rejected before anything is written
+	if _, err := Import(path, []byte("[management]\nhysteresis = 80\n")); !errors.Is(err, ErrInvalidHysteresis) {
+		t.Errorf("Expected an invalid hysteresis error, got %v", err)
+	}
+	if _, err := Import(path, []byte("[management\n")); err == nil {
+		t.Error("Expected a syntax error")
+	}
+	if data, _ := os.ReadFile(path); string(data) != imported {
+		t.Errorf("Expected the file unchanged after failed imports, got %q", data)
+	}
+}
+
+func TestApplyOverlay(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	cfg := Default()
+	if err := cfg.ApplyOverlay(filepath.Join(dir, "missing.toml")); err != nil {
+		t.Fatalf("Expected a missing overlay to be ignored, got %v", err)
+	}
+
+	// Display preferences and notified events are the user's
+	overlay := write("user.toml", `[display]
+color = false
+color_theme = "mono"
+
+[notifications]
+events = ["error"]
+`)
+	if err := cfg.ApplyOverlay(overlay); err != nil {
+		t.Fatalf("ApplyOverlay failed: %v", err)
+	}
+	if cfg.Display.Color || cfg.Display.ColorTheme != "mono" || cfg.Display.StatuslineStyle != "waybar" {
+		t.Errorf("Unexpected display preferences: %+v", cfg.Display)
+	}
+	if len(cfg.Notifications.Events) != 1 || cfg.Notifications.Events[0] != "error" {
+		t.Errorf("Expected the overlay's events, got %v", cfg.Notifications.Events)
+	}
+
+	// System policy, unknown and invalid settings leave the config as is
+	tests := map[string]struct {
+		content string
+		want    error
+	}{
+		"system policy":  {"[management]\nhysteresis = 10\n", ErrSystemSetting},
+		"notified user":  {"[notifications]\nuser = \"bob\"\n", ErrSystemSetting},
+		"unknown":        {"[display]\ncolour = false\n", ErrUnknownSetting},
+		"invalid events": {"[notifications]\nevents = [\"battery-empty\"]\n", ErrInvalidEvent},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			before := *cfg
+			err := cfg.ApplyOverlay(write("bad.toml", tt.content))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+			if cfg.Management.Hysteresis != before.Management.Hysteresis || cfg.Notifications.Events[0] != "error" {
+				t.Errorf("Expected the config unchanged, got %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestApplyUserOverlay(t *testing.T) {
+	dir := t.TempDir()
+	overlay := filepath.Join(dir, "user.toml")
+	if err := os.WriteFile(overlay, []byte("[notifications]\nevents = [\"error\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "secret.toml")
+	if err := os.WriteFile(secret, []byte("[notifications]\nevents = [\"battery-low\"]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.toml")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Fatal(err)
+	}
+	large := filepath.Join(dir, "large.toml")
+	if err := os.WriteFile(large, make([]byte, maxUserConfigSize+1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		uid  int
+		want error
+	}{
+		{"owned by the user", overlay, os.Getuid(), nil},
+		{"missing", filepath.Join(dir, "missing.toml"), os.Getuid(), nil},
+		{"owned by someone else", overlay, os.Getuid() + 1, ErrUnsafeUserConfig},
+		{"symlink", link, os.Getuid(), ErrUnsafeUserConfig},
+		{"directory", dir, os.Getuid(), ErrUnsafeUserConfig},
+		{"too large", large, os.Getuid(), ErrUserConfigTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			if err := cfg.ApplyUserOverlay(tt.path, tt.uid); !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+			if tt.want != nil && !slices.Equal(cfg.Notifications.Events, Default().Notifications.Events) {
+				t.Errorf("Expected the config unchanged, got events %v", cfg.Notifications.Events)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+// Common configuration errors
+var (
+	ErrNoLogSinks        = NewConfigError("at least one log sink is required")
+	ErrMissingSinkPath   = NewConfigError("file sinks require a path")
+	ErrInvalidSinkType   = NewConfigError("invalid sink type")
+	ErrInvalidLogLevel   = NewConfigError("invalid log level")
+	ErrInvalidSampleRate = NewConfigError("debug_sample_rate must be between 0 and 1")
+	ErrInvalidHysteresis = NewConfigError("hysteresis must be between 0 and 20")
+	ErrInvalidOnStop     = NewConfigError("on_stop must be \"keep\", \"enable\" or \"disable\"")
+	ErrInvalidBackups    = NewConfigError("backups must be between 0 and 100")
+
+	ErrInvalidWearWarning = NewConfigError("wear_warning must be between 1 and 100")
+
+	ErrInvalidAdaptiveMode  = NewConfigError("adaptive mode must be \"off\", \"suggest\" or \"apply\"")
+	ErrInvalidAdaptiveRange = NewConfigError("max_threshold must not be below min_threshold")
+	ErrInvalidAdaptiveDays  = NewConfigError("adaptive days must be at least 1")
+
+	ErrInvalidHistoryBackend = NewConfigError("history backend must be \"file\" or \"sqlite\"")
+	ErrInvalidRetention      = NewConfigError("retention_days and max_entries must not be negative")
+	ErrInvalidTextfile       = NewConfigError("metrics textfile must end in .prom to be collected")
+
+	ErrInvalidHardwareBackend = NewConfigError("hardware backend must be \"sysfs\", \"upower\" or \"mock\"")
+	ErrInvalidSysfsRoot       = NewConfigError("sysfs_root must be an absolute path")
+
+	ErrInvalidMaxConnections = NewConfigError("max_connections must be at least 1")
+	ErrInvalidHTTPListen     = NewConfigError("http listen address must be a loopback address with a port")
+	ErrInvalidRateLimit      = NewConfigError("rate_limit must not be negative and rate_burst must be at least 1")
+
+	ErrInvalidAlertLevel    = NewConfigError("alert levels must be between 0 and 100")
+	ErrInvalidCriticalLevel = NewConfigError("critical alert level must be below the low one")
+
+	ErrInvalidEvent       = NewConfigError("unknown event type")
+	ErrInvalidHookTimeout = NewConfigError("hook timeout_seconds must be at least 1")
+	ErrInvalidWebhookURL  = NewConfigError("webhook url must be an http or https URL")
+	ErrInvalidWebhook     = NewConfigError("webhook timeout_seconds and attempts must not be negative")
+
+	ErrInvalidThreshold      = NewConfigError("threshold must be between 40 and 100 (60 and 100 without a native end threshold)")
+	ErrInvalidStartThreshold = NewConfigError("start_threshold must be below the threshold")
+	ErrInvalidSchedule       = NewConfigError("invalid schedule")
+	ErrUnknownProfile        = NewConfigError("unknown profile")
+	ErrInvalidPowerMode      = NewConfigError("unknown platform profile")
+	ErrInvalidDocking        = NewConfigError("docking when must be \"docked\", \"undocked\", \"lid_closed\", \"lid_open\" or \"clamshell\"")
+	ErrInvalidRule           = NewConfigError("invalid rule")
+	ErrInvalidRuleAction     = NewConfigError("rule action must be \"set_threshold\", \"enable\", \"disable\" or \"notify\"")
+	ErrDuplicateRule         = NewConfigError("duplicate rule name")
+
+	ErrUnknownKey      = NewConfigError("unknown setting, see: legionbatctl config list")
+	ErrNotSettable     = NewConfigError("setting can't be changed with config set, edit the file")
+	ErrInvalidValue    = NewConfigError("invalid value")
+	ErrCantEditInPlace = NewConfigError("setting spans several lines, edit the file")
+	ErrSystemSetting   = NewConfigError("system policy, only set in the system config file")
+
+	ErrUnsafeUserConfig   = NewConfigError("user config must be a regular file owned by the user, not a symlink")
+	ErrUserConfigTooLarge = NewConfigError("user config is larger than 64 KiB")
+
+	// Warnings: the daemon starts despite them, config validate fails
+	ErrUnknownSetting = NewConfigError("unknown setting")
+	ErrMissingPath    = NewConfigError("path does not exist")
+)
+
+// ConfigError represents a configuration error
+type ConfigError struct {
+	Message string
+}
+
+func NewConfigError(message string) *ConfigError {
+	return &ConfigError{Message: message}
+}
+
+func (e *ConfigError) Error() string {
+	return e.Message
+}
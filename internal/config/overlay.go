@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/BurntSushi/toml"
+)
+
+// UserConfigFile is the user config overlay, relative to the user's config
+// directory
+const UserConfigFile = "legionbatctl/config.toml"
+
+// maxUserConfigSize bounds the user config overlay read by ApplyUserOverlay
+const maxUserConfigSize = 64 << 10
+
+// userSettings are the settings a user config overlay may set: display
+// preferences and the events the user's desktop is notified about.
+// Everything else is system policy and only set in the system config.
+var userSettings = []string{"display", "notifications.events"}
+
+// UserConfigPath returns the current user's config overlay, under
+// XDG_CONFIG_HOME or ~/.config
+func UserConfigPath() string {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, UserConfigFile)
+	}
+	home, _ := os.UserHomeDir()
+	return UserConfigPathIn(home)
+}
+
+// UserConfigPathIn returns the config overlay of the user with the home
+// directory home, for users whose XDG_CONFIG_HOME isn't known, such as the
+// desktop user the daemon notifies
+func UserConfigPathIn(home string) string {
+	return filepath.Join(home, ".config", UserConfigFile)
+}
+
+// ApplyOverlay applies the user config overlay at path, replacing the
+// settings it sets. It fails without changing c if the overlay sets system
+// policy or invalid values. A missing overlay is ignored.
+func (c *Config) ApplyOverlay(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read user config: %w", err)
+	}
+	return c.applyOverlay(path, data)
+}
+
+// ApplyUserOverlay applies the config overlay of the user with the given
+// UID like ApplyOverlay, for a privileged process such as the daemon. The
+// overlay must be a regular file owned by the user; a symlink isn't
+// followed, so the user can't have another file read in its place.
+func (c *Config) ApplyUserOverlay(path string, uid int) error {
+	file, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if errors.Is(err, syscall.ELOOP) {
+		return ErrUnsafeUserConfig
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open user config: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read user config: %w", err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !info.Mode().IsRegular() || !ok || int(stat.Uid) != uid {
+		return ErrUnsafeUserConfig
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxUserConfigSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read user config: %w", err)
+	}
+	if len(data) > maxUserConfigSize {
+		return ErrUserConfigTooLarge
+	}
+	return c.applyOverlay(path, data)
+}
+
+// applyOverlay applies the overlay read from path
+func (c *Config) applyOverlay(path string, data []byte) error {
+	// The keys are checked before decoding over c's lists and tables
+	md, err := toml.Decode(string(data), &Config{})
+	if err != nil {
+		return &ValidationError{Path: path, Errors: []*FieldError{decodeError(err)}}
+	}
+
+	lines := indexLines(data)
+	var problems []*FieldError
+	add := func(key toml.Key, err error) {
+		problems = append(problems, &FieldError{Field: key.String(), Line: lines.find(key.String()), Err: err})
+	}
+	unknown := make(map[string]bool)
+	for _, key := range md.Undecoded() {
+		unknown[key.String()] = true
+	}
+	for _, key := range md.Keys() {
+		switch {
+		case md.Type(key...) == "Hash":
+		case unknown[key.String()]:
+			add(key, ErrUnknownSetting)
+		case !isUserSetting(key.String()):
+			add(key, ErrSystemSetting)
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Path: path, Errors: problems}
+	}
+
+	merged := *c
+	merged.Notifications.Events = slices.Clone(c.Notifications.Events)
+	if _, err := toml.Decode(string(data), &merged); err != nil {
+		return &ValidationError{Path: path, Errors: []*FieldError{decodeError(err)}}
+	}
+	for _, problem := range merged.problems() {
+		if !problem.Warning && isUserSetting(problem.Field) {
+			problem.Line = lines.find(problem.Field)
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Path: path, Errors: problems}
+	}
+
+	*c = merged
+	return nil
+}
+
+// isUserSetting reports whether a user config overlay may set key
+func isUserSetting(key string) bool {
+	for _, setting := range userSettings {
+		if key == setting || strings.HasPrefix(key, setting+".") {
+			return true
+		}
+	}
+	return false
+}
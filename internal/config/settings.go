@@ -0,0 +1,324 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Setting is a single configuration value, addressed by its dotted key such
+// as "management.hysteresis"
+type Setting struct {
+	Key   string
+	Value string // Formatted as in the config file, e.g. 5, "keep" or ["a", "b"]
+}
+
+// Settings lists every setting config set can change, with its value, in
+// the order of the config file's tables. Lists of tables such as log sinks,
+// schedule entries and webhooks are left out.
+func (c *Config) Settings() []Setting {
+	var settings []Setting
+	walkSettings(reflect.ValueOf(c).Elem(), "", func(key string, value reflect.Value) {
+		if settable(value) {
+			settings = append(settings, Setting{Key: key, Value: formatValue(value)})
+		}
+	})
+	return settings
+}
+
+// Get returns the value of a setting, formatted as in the config file
+func (c *Config) Get(key string) (string, error) {
+	value, err := c.setting(key)
+	if err != nil {
+		return "", err
+	}
+	return formatValue(value), nil
+}
+
+// setting finds the field of a setting
+func (c *Config) setting(key string) (reflect.Value, error) {
+	var found reflect.Value
+	var edited bool // Part of profiles or a list of tables, edited in the file
+	walkSettings(reflect.ValueOf(c).Elem(), "", func(name string, value reflect.Value) {
+		switch {
+		case name == key && settable(value):
+			found = value
+		case name == key || strings.HasPrefix(key, name+".") || strings.HasPrefix(key, name+"["):
+			edited = true
+		}
+	})
+
+	switch {
+	case found.IsValid():
+		return found, nil
+	case edited:
+		return reflect.Value{}, fmt.Errorf("%s: %w", key, ErrNotSettable)
+	}
+	return reflect.Value{}, fmt.Errorf("%s: %w", key, ErrUnknownKey)
+}
+
+// settable reports whether config set can change a setting: a scalar or a
+// list of strings
+func settable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Int, reflect.Float64, reflect.Bool:
+		return true
+	case reflect.Slice:
+		return v.Type().Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// walkSettings calls fn for every setting below v that is not a table
+func walkSettings(v reflect.Value, prefix string, fn func(key string, value reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		name := tomlKey(v.Type().Field(i))
+		if name == "" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			walkSettings(field, name, fn)
+		} else {
+			fn(name, field)
+		}
+	}
+}
+
+// tomlKey returns the key of a struct field in the config file
+func tomlKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// formatValue formats a setting as a TOML value
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Int:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64:
+		s := strconv.FormatFloat(v.Float(), 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = strconv.Quote(v.Index(i).String())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return ""
+}
+
+// parseValue converts a value given on the command line to the TOML value
+// of a setting. Lists are given comma separated; an empty value clears them.
+func parseValue(field reflect.Value, value string) (string, error) {
+	parsed := reflect.New(field.Type()).Elem()
+	switch field.Kind() {
+	case reflect.String:
+		parsed.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%w %q, expected a whole number", ErrInvalidValue, value)
+		}
+		parsed.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%w %q, expected a number", ErrInvalidValue, value)
+		}
+		parsed.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%w %q, expected true or false", ErrInvalidValue, value)
+		}
+		parsed.SetBool(b)
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		parsed = reflect.ValueOf(items)
+	}
+	return formatValue(parsed), nil
+}
+
+// SetInFile changes a setting in the config file at path, creating the file
+// if needed. The rest of the file, including comments, is kept. The change is
+// only written if the resulting config is valid.
+func SetInFile(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	field, err := Default().setting(key)
+	if err != nil {
+		return err
+	}
+	literal, err := parseValue(field, value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	updated := setLine(data, key, literal)
+	cfg, problems := checkData(updated)
+	var errs []*FieldError
+	for _, problem := range problems {
+		if !problem.Warning {
+			errs = append(errs, problem)
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Path: path, Errors: errs}
+	}
+	if got, _ := cfg.Get(key); got != literal {
+		return fmt.Errorf("%s: %w", key, ErrCantEditInPlace)
+	}
+
+	return writeFile(path, updated, fileMode(path))
+}
+
+// Import replaces the config file at path with data, if data is a valid
+// configuration. The file it replaces is kept as a timestamped backup next to
+// it, whose path is returned; it is empty if there was no file.
+func Import(path string, data []byte) (string, error) {
+	_, problems := checkData(data)
+	var errs []*FieldError
+	for _, problem := range problems {
+		if !problem.Warning {
+			errs = append(errs, problem)
+		}
+	}
+	if len(errs) > 0 {
+		return "", &ValidationError{Path: "imported file", Errors: errs}
+	}
+
+	var backup string
+	current, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		backup = fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+		if err := writeFile(backup, current, fileMode(path)); err != nil {
+			return "", fmt.Errorf("failed to back up config file: %w", err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := writeFile(path, data, fileMode(path)); err != nil {
+		return backup, err
+	}
+	return backup, nil
+}
+
+// setLine sets key to a TOML value in the contents of a config file: on the
+// line setting it if there is one, otherwise at the start of its table,
+// which is appended if missing
+func setLine(data []byte, key, literal string) []byte {
+	lines := strings.Split(string(data), "\n")
+	index := indexLines(data)
+	table, name := key[:strings.LastIndex(key, ".")], key[strings.LastIndex(key, ".")+1:]
+
+	if line, ok := index[key]; ok {
+		text := lines[line-1]
+		prefix, rest, _ := strings.Cut(text, "=")
+		lines[line-1] = strings.TrimRight(prefix, " ") + " = " + literal + trailingComment(rest)
+		return []byte(strings.Join(lines, "\n"))
+	}
+
+	if line, ok := index[table]; ok {
+		lines = append(lines[:line], append([]string{name + " = " + literal}, lines[line:]...)...)
+		return []byte(strings.Join(lines, "\n"))
+	}
+
+	var b bytes.Buffer
+	b.Write(data)
+	if len(data) > 0 {
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "[%s]\n%s = %s\n", table, name, literal)
+	return b.Bytes()
+}
+
+// trailingComment returns the comment after a value, with the space before it
+func trailingComment(value string) string {
+	var quote rune
+	for i, r := range value {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			start := len(strings.TrimRight(value[:i], " \t"))
+			return value[start:]
+		}
+	}
+	return ""
+}
+
+// fileMode returns the permissions of the config file, or those of a new one
+func fileMode(path string) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode().Perm()
+	}
+	return 0644
+}
+
+// writeFile replaces a file atomically
+func writeFile(path string, data []byte, mode os.FileMode) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	tempPath := temp.Name()
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Chmod(tempPath, mode); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StarterOptions are the answers a new configuration file is written from
+type StarterOptions struct {
+	// Notifications enables desktop notifications for NotifyUser's session
+	Notifications bool
+	NotifyUser    string
+
+	// Schedule entries to add, e.g. a daily window charging to 100%
+	Schedule []ScheduleConfig
+}
+
+// Starter renders a configuration file with the given settings, leaving
+// everything else at its default
+func Starter(opts StarterOptions) string {
+	var b strings.Builder
+	b.WriteString("# legionbatctl configuration, written by legionbatctl init.\n")
+	b.WriteString("# Settings left out use their defaults, see the README for all of them.\n")
+
+	b.WriteString("\n[notifications]\n")
+	fmt.Fprintf(&b, "enabled = %t\n", opts.Notifications)
+	if opts.Notifications && opts.NotifyUser != "" {
+		fmt.Fprintf(&b, "user = %q\n", opts.NotifyUser)
+	}
+
+	for _, entry := range opts.Schedule {
+		b.WriteString("\n[[schedule]]\n")
+		if entry.Name != "" {
+			fmt.Fprintf(&b, "name = %q\n", entry.Name)
+		}
+		if len(entry.Days) > 0 {
+			days := make([]string, len(entry.Days))
+			for i, day := range entry.Days {
+				days[i] = fmt.Sprintf("%q", day)
+			}
+			fmt.Fprintf(&b, "days = [%s]\n", strings.Join(days, ", "))
+		}
+		fmt.Fprintf(&b, "from = %q\n", entry.From)
+		fmt.Fprintf(&b, "to = %q\n", entry.To)
+		if entry.Profile != "" {
+			fmt.Fprintf(&b, "profile = %q\n", entry.Profile)
+		} else {
+			fmt.Fprintf(&b, "threshold = %d\n", entry.Threshold)
+		}
+	}
+
+	return b.String()
+}
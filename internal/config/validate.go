@@ -0,0 +1,281 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FieldError is a problem with one setting of the config file
+type FieldError struct {
+	Field string // e.g. "management.hysteresis" or "logging.sinks[0].level"
+	Line  int    // Line of the setting in the config file, 0 if unknown
+	Err   error
+
+	// Warning marks problems the daemon starts despite, such as unknown
+	// settings and referenced paths that don't exist
+	Warning bool
+}
+
+func (e *FieldError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %v", e.Line, e.Field, e.Err)
+	}
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists every invalid setting of a config file
+type ValidationError struct {
+	Path   string
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("invalid config file %s: %s", e.Path, strings.Join(messages, "; "))
+}
+
+// Unwrap returns the individual errors, so errors.Is matches any of them
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// LoadWithWarnings reads the config file at path like Load, also returning
+// the problems the daemon starts despite
+func LoadWithWarnings(path string) (*Config, []*FieldError, error) {
+	if path == "" {
+		path = DefaultConfigPath
+	}
+
+	cfg, problems, err := Check(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Default(), nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var warnings, errs []*FieldError
+	for _, problem := range problems {
+		if problem.Warning {
+			warnings = append(warnings, problem)
+		} else {
+			errs = append(errs, problem)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, warnings, &ValidationError{Path: path, Errors: errs}
+	}
+	return cfg, warnings, nil
+}
+
+// Check reads the config file at path and reports every problem in it, in
+// file order: syntax errors, values out of range, settings it doesn't know
+// and referenced paths that don't exist. The config is returned even if it
+// has problems, unless it can't be parsed.
+func Check(path string) (*Config, []*FieldError, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg, problems := checkData(data)
+	return cfg, problems, nil
+}
+
+// checkData checks the contents of a config file, as Check does
+func checkData(data []byte) (*Config, []*FieldError) {
+	cfg := Default()
+	md, err := toml.Decode(string(data), cfg)
+	if err != nil {
+		return nil, []*FieldError{decodeError(err)}
+	}
+
+	lines := indexLines(data)
+	problems := cfg.problems()
+
+	for _, key := range md.Undecoded() {
+		// Unknown tables are reported through the settings in them
+		if md.Type(key...) == "Hash" {
+			continue
+		}
+		problems = append(problems, &FieldError{Field: key.String(), Err: ErrUnknownSetting, Warning: true})
+	}
+
+	problems = append(problems, cfg.missingPaths(&md)...)
+
+	for _, problem := range problems {
+		problem.Line = lines.find(problem.Field)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	return cfg, problems
+}
+
+// decodeErrorPattern matches the decoder's errors for values of the wrong
+// type, which are not ParseErrors
+var decodeErrorPattern = regexp.MustCompile(`^toml: (?:line (\d+) )?\(last key "([^"]+)"\): (.*)$`)
+
+// decodeError turns an error parsing the config file into a FieldError
+func decodeError(err error) *FieldError {
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		return &FieldError{Field: parseErr.LastKey, Line: parseErr.Position.Line, Err: errors.New(parseErr.Message)}
+	}
+	if match := decodeErrorPattern.FindStringSubmatch(err.Error()); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return &FieldError{Field: match[2], Line: line, Err: errors.New(match[3])}
+	}
+	return &FieldError{Err: err}
+}
+
+// missingPaths reports the paths set in the config file that don't exist.
+// Defaults are not checked, as features using them are off without them.
+func (c *Config) missingPaths(md *toml.MetaData) []*FieldError {
+	var problems []*FieldError
+	check := func(field, path string) {
+		if _, err := os.Stat(path); err != nil {
+			problems = append(problems, &FieldError{Field: field, Err: fmt.Errorf("%w: %s", ErrMissingPath, path), Warning: true})
+		}
+	}
+
+	for i, sink := range c.Logging.Sinks {
+		if sink.Type == "file" && sink.Path != "" {
+			check(fmt.Sprintf("logging.sinks[%d].path", i), filepath.Dir(sink.Path))
+		}
+	}
+	if md.IsDefined("hooks", "dir") && c.Hooks.Dir != "" {
+		check("hooks.dir", c.Hooks.Dir)
+	}
+	if c.Metrics.Textfile != "" {
+		check("metrics.textfile", filepath.Dir(c.Metrics.Textfile))
+	}
+	if c.History.Path != "" {
+		check("history.path", filepath.Dir(c.History.Path))
+	}
+	if c.Hardware.SysfsRoot != "" {
+		check("hardware.sysfs_root", c.Hardware.SysfsRoot)
+	}
+
+	return problems
+}
+
+// lineIndex maps settings to the line they are set on, e.g.
+// "logging.sinks[1].level" or "management" for a table header
+type lineIndex map[string]int
+
+var (
+	arrayHeader = regexp.MustCompile(`^\[\[\s*([^\]]+?)\s*\]\]`)
+	tableHeader = regexp.MustCompile(`^\[\s*([^\]]+?)\s*\]`)
+	keyLine     = regexp.MustCompile(`^([A-Za-z0-9_\-."' ]+?)\s*=`)
+)
+
+// indexLines finds the line of each table and key in a config file. It reads
+// the file line by line, which is enough for the flat layout of the config:
+// keys inside multi-line strings or arrays are not told apart.
+func indexLines(data []byte) lineIndex {
+	index := lineIndex{}
+	counts := map[string]int{} // Entries seen of each array of tables
+	table := ""
+
+	set := func(key string, line int) {
+		if _, ok := index[key]; !ok {
+			index[key] = line
+		}
+		// Unknown settings are reported without array indices
+		if plain := stripIndices(key); plain != key {
+			if _, ok := index[plain]; !ok {
+				index[plain] = line
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if match := arrayHeader.FindStringSubmatch(text); match != nil {
+			name := unquoteKey(match[1])
+			table = fmt.Sprintf("%s[%d]", name, counts[name])
+			counts[name]++
+			set(table, line)
+		} else if match := tableHeader.FindStringSubmatch(text); match != nil {
+			table = unquoteKey(match[1])
+			set(table, line)
+		} else if match := keyLine.FindStringSubmatch(text); match != nil {
+			key := unquoteKey(match[1])
+			if table != "" {
+				key = table + "." + key
+			}
+			set(key, line)
+		}
+	}
+	return index
+}
+
+// find returns the line of a field, or of the closest table containing it
+func (index lineIndex) find(field string) int {
+	for field != "" {
+		if line, ok := index[field]; ok {
+			return line
+		}
+		cut := strings.LastIndexAny(field, ".[")
+		if cut < 0 {
+			break
+		}
+		field = field[:cut]
+	}
+	return 0
+}
+
+// unquoteKey removes the quotes and spaces around the parts of a dotted key
+func unquoteKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// stripIndices removes array indices from a field, e.g. "webhooks[0].url"
+// becomes "webhooks.url"
+func stripIndices(field string) string {
+	var b strings.Builder
+	skip := false
+	for _, r := range field {
+		switch {
+		case r == '[':
+			skip = true
+		case r == ']':
+			skip = false
+		case !skip:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// caller identifies the process on the other end of a connection
+type caller struct {
+	UID   int
+	GID   int
+	PID   int
+	Known bool // Credentials were read from the socket
+
+	// Trusted callers were authenticated by other means, e.g. the HTTP API
+	// token, and may change settings
+	Trusted bool
+}
+
+// logAttrs returns the caller identity for request logging
+func (c caller) logAttrs() []any {
+	if !c.Known {
+		if c.Trusted {
+			return []any{"caller", "token"}
+		}
+		return []any{"caller", "unknown"}
+	}
+	return []any{"uid", c.UID, "gid", c.GID, "pid", c.PID}
+}
+
+// userGroupIDs returns the IDs of a user's groups, including supplementary
+// ones (replaced in tests)
+var userGroupIDs = func(uid int) ([]string, error) {
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return nil, err
+	}
+	return u.GroupIds()
+}
+
+// groupID resolves a group name (replaced in tests)
+var groupID = func(name string) (string, error) {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return "", err
+	}
+	return g.Gid, nil
+}
+
+// authorize checks that the caller may run the command. Read-only commands
+// are open to everyone; changes need root, the user running the daemon (e.g.
+// an unprivileged development daemon) or one of the configured groups.
+func (d *Daemon) authorize(c caller, command string) error {
+	groups := d.GetConfig().Access.Groups
+	if !protocol.IsMutatingCommand(command) || canChange(c, groups) {
+		return nil
+	}
+
+	if len(groups) == 0 {
+		return protocol.NewHintedError(protocol.CodePermissionDenied,
+			fmt.Sprintf("permission denied: %s requires root", command), "run with sudo")
+	}
+	hint := "run with sudo"
+	for _, name := range groups {
+		if _, err := groupID(name); err == nil {
+			hint = fmt.Sprintf("add your user to the %s group (sudo usermod -aG %s $USER, then log in again), or run with sudo", name, name)
+			break
+		}
+	}
+	return protocol.NewHintedError(protocol.CodePermissionDenied,
+		fmt.Sprintf("permission denied: %s requires root or membership in %s", command, strings.Join(groups, ", ")), hint)
+}
+
+// canChange reports whether the caller is root, runs the daemon or is a
+// member of one of groups
+func canChange(c caller, groups []string) bool {
+	if c.Trusted {
+		return true
+	}
+	if !c.Known {
+		return false
+	}
+	if c.UID == 0 || c.UID == os.Geteuid() {
+		return true
+	}
+	if len(groups) == 0 {
+		return false
+	}
+
+	memberOf := []string{strconv.Itoa(c.GID)}
+	if ids, err := userGroupIDs(c.UID); err == nil {
+		memberOf = append(memberOf, ids...)
+	}
+
+	for _, name := range groups {
+		if gid, err := groupID(name); err == nil && slices.Contains(memberOf, gid) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/events"
+	"github.com/dom1nux/legionbatctl/internal/history"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+const (
+	// adaptiveInterval is how often the threshold is learned anew
+	adaptiveInterval = 24 * time.Hour
+
+	// adaptiveMinDays is the least history, in days, a threshold is learned
+	// from; all of them if fewer days are configured
+	adaptiveMinDays = 7
+
+	// adaptiveReserve is the charge, in percent, left at the end of a
+	// typical discharge
+	adaptiveReserve = 15
+
+	// adaptiveMinDepth is the least depth of a discharge that counts;
+	// unplugging to carry the laptop to another room doesn't
+	adaptiveMinDepth = 5
+
+	// adaptiveStep rounds learned thresholds up to a multiple of it
+	adaptiveStep = 5
+)
+
+// handleAdaptive handles the adaptive command. The threshold is learned in
+// every mode, so it can be looked at before opting in.
+func (d *Daemon) handleAdaptive(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if d.stateManager == nil {
+		return nil, fmt.Errorf("state manager not initialized")
+	}
+
+	data, err := d.adaptiveData(d.GetConfig().Adaptive, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return data, nil
+}
+
+// adaptiveData learns the threshold from the discharges recorded in the
+// history over the configured days up to now. Machines rarely on battery get
+// the lowest threshold; otherwise it covers 9 in 10 discharges with
+// adaptiveReserve to spare.
+func (d *Daemon) adaptiveData(cfg config.AdaptiveConfig, now time.Time) (protocol.AdaptiveData, error) {
+	data := protocol.AdaptiveData{
+		Mode:         cfg.Mode,
+		Threshold:    d.stateManager.GetChargeThreshold(),
+		MinThreshold: cfg.MinThreshold,
+		MaxThreshold: cfg.MaxThreshold,
+		Days:         cfg.Days,
+	}
+	if d.history == nil {
+		data.Reason = "History is disabled, there is nothing to learn from"
+		return data, nil
+	}
+
+	entries, err := d.history.Query(now.AddDate(0, 0, -cfg.Days), now)
+	if err != nil {
+		return data, err
+	}
+
+	needed := min(cfg.Days, adaptiveMinDays)
+	var span time.Duration
+	if len(entries) > 0 {
+		span = now.Sub(entries[0].Time)
+	}
+	if span < time.Duration(needed)*24*time.Hour {
+		data.Reason = fmt.Sprintf("%d of %d days of history recorded, too few to learn from",
+			int(span.Hours()/24), needed)
+		return data, nil
+	}
+
+	var depths []int
+	for _, discharge := range history.Discharges(entries) {
+		if depth := discharge.Depth(); depth >= adaptiveMinDepth {
+			depths = append(depths, depth)
+		}
+	}
+	data.Discharges = len(depths)
+	data.PerWeek = float64(len(depths)) / (span.Hours() / (7 * 24))
+
+	if data.PerWeek < 1 {
+		data.Suggested = cfg.MinThreshold
+		data.Reason = "The battery is rarely discharged, the lowest threshold wears it least"
+		return data, nil
+	}
+
+	slices.Sort(depths)
+	data.TypicalDepth = depths[(len(depths)*9+9)/10-1]
+	threshold := (data.TypicalDepth + adaptiveReserve + adaptiveStep - 1) / adaptiveStep * adaptiveStep
+	data.Suggested = min(max(threshold, cfg.MinThreshold), cfg.MaxThreshold)
+	data.Reason = fmt.Sprintf("9 in 10 discharges go no deeper than %d%%, the threshold leaves %d%% to spare",
+		data.TypicalDepth, adaptiveReserve)
+
+	return data, nil
+}
+
+// checkAdaptive learns the threshold once a day in the suggest and apply
+// modes. A learned threshold differing from the configured one is shown in
+// the status in suggest mode, and set in apply mode. The settings are read
+// from d.adaptive: the startup check runs with d.mutex held.
+func (d *Daemon) checkAdaptive(now time.Time) {
+	cfg := d.adaptive.Load()
+	if cfg == nil || cfg.Mode == "off" {
+		d.suggested.Store(0)
+		return
+	}
+	if now.Sub(d.lastAdaptive) < adaptiveInterval {
+		return
+	}
+	d.lastAdaptive = now
+
+	data, err := d.adaptiveData(*cfg, now)
+	if err != nil {
+		d.logger.Error("Failed to learn the charge threshold", "error", err)
+		return
+	}
+	if data.Suggested == 0 || data.Suggested == data.Threshold {
+		d.suggested.Store(0)
+		return
+	}
+
+	if cfg.Mode == "suggest" {
+		if d.suggested.Swap(int32(data.Suggested)) != int32(data.Suggested) {
+			d.logger.Info("Learned a charge threshold", "threshold", data.Suggested,
+				"configured", data.Threshold, "discharges", data.Discharges, "depth", data.TypicalDepth)
+		}
+		return
+	}
+
+	d.suggested.Store(0)
+	if err := d.stateManager.SetChargeThreshold(data.Suggested); err != nil {
+		d.logger.Error("Failed to apply the learned charge threshold", "error", err)
+		return
+	}
+
+	message := fmt.Sprintf("Charge threshold adapted from %d%% to %d%%. %s",
+		data.Threshold, data.Suggested, data.Reason)
+	d.logger.Info("Applied a learned charge threshold", "threshold", data.Suggested,
+		"previous", data.Threshold, "discharges", data.Discharges, "depth", data.TypicalDepth)
+	d.emit(events.ThresholdChanged, message)
+}
@@ -0,0 +1,164 @@
+package daemon
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dom1nux/legionbatctl/internal/events"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+)
+
+// checkAlerts raises the battery-low and battery-critical events when the
+// battery falls to their levels on battery power. Each is raised once until
+// AC is plugged in again; a critical alert covers the low one. The levels
+// are read from d.alerts: the startup check runs with d.mutex held.
+func (d *Daemon) checkAlerts(level int, acOnline bool) {
+	if acOnline {
+		d.lastAlert = ""
+		return
+	}
+
+	alerts := d.alerts.Load()
+	switch {
+	case alerts.Critical > 0 && level <= alerts.Critical:
+		if d.lastAlert == events.BatteryCritical {
+			return
+		}
+		d.lastAlert = events.BatteryCritical
+		d.logger.Warn("Battery critically low", "battery", level, "level", alerts.Critical)
+		d.emit(events.BatteryCritical, fmt.Sprintf("Battery at %d%%, plug in the charger now", level))
+	case alerts.Low > 0 && level <= alerts.Low:
+		if d.lastAlert != "" {
+			return
+		}
+		d.lastAlert = events.BatteryLow
+		d.logger.Info("Battery low", "battery", level, "level", alerts.Low)
+		d.emit(events.BatteryLow, fmt.Sprintf("Battery at %d%%, plug in the charger soon", level))
+	}
+}
+
+// drainSamples is how often the battery must read lower than before on AC,
+// without rising in between, before it is reported; a single dip under load
+// is not
+const drainSamples = 3
+
+// drainSample tracks the battery level falling while AC is plugged in
+type drainSample struct {
+	start    int  // Level when the battery started falling
+	level    int  // Last level read
+	drops    int  // Readings with a lower level than the one before
+	reported bool // The discharging-on-ac event was raised
+}
+
+// checkDrainOnAC raises the discharging-on-ac event when the battery keeps
+// falling although AC is plugged in, e.g. on a USB-C charger too weak for
+// the laptop's load. Conservation mode can't hold the charge then. Force
+// discharging for storage mode is expected to drain the battery and ignored.
+func (d *Daemon) checkDrainOnAC(level int, acOnline bool) {
+	if !acOnline || d.forceDischarging.Load() {
+		d.drain = nil
+		return
+	}
+
+	if d.drain == nil || level > d.drain.level {
+		d.drain = &drainSample{start: level, level: level}
+		return
+	}
+	if level == d.drain.level {
+		return
+	}
+
+	d.drain.level = level
+	d.drain.drops++
+	if d.drain.drops < drainSamples || d.drain.reported {
+		return
+	}
+
+	d.drain.reported = true
+	d.logger.Warn("Battery discharging on AC", "from", d.drain.start, "battery", level)
+	d.emit(events.DischargingOnAC,
+		fmt.Sprintf("Battery fell from %d%% to %d%% while plugged in, the charger may be too weak", d.drain.start, level))
+}
+
+const (
+	// chargePowerSamples is how many charging readings are averaged before
+	// the charge power is compared with the typical one
+	chargePowerSamples = 5
+
+	// chargePowerMaxLevel is the level up to which charge power is sampled;
+	// charging tapers off as the battery fills up
+	chargePowerMaxLevel = 80
+
+	// lowChargePowerRatio is the fraction of the typical charge power below
+	// which charging is reported as too slow
+	lowChargePowerRatio = 0.5
+)
+
+// powerSample accumulates charge power readings
+type powerSample struct {
+	total float64 // W
+	count int
+}
+
+// checkChargePower reads the charge power while the battery charges and
+// compares its average with the power this machine typically charges at,
+// learned from earlier charging. Charging far slower than usual points to a
+// failing adapter or a low-watt hub; it is raised as the low-charge-power
+// event and shown in the status until AC is unplugged.
+func (d *Daemon) checkChargePower(level int, conservationMode, acOnline bool) {
+	if !acOnline {
+		d.powerSample = nil
+		d.lowChargePower.Store(0)
+		return
+	}
+
+	reader, ok := d.hardware.(hardware.InfoReader)
+	if !ok || conservationMode || level >= chargePowerMaxLevel {
+		d.powerSample = nil
+		return
+	}
+
+	info, err := reader.ReadInfo()
+	if err != nil || info.Status != "Charging" {
+		d.powerSample = nil
+		return
+	}
+	d.recordChargePower(batteryPower(info))
+}
+
+// recordChargePower adds a charge power reading, checking the average once
+// enough readings were taken. Normal averages are learned as typical; low
+// ones are not, so a weak charger doesn't become the norm.
+func (d *Daemon) recordChargePower(watts float64) {
+	if watts <= 0 {
+		return
+	}
+	if d.powerSample == nil {
+		d.powerSample = &powerSample{}
+	}
+	d.powerSample.total += watts
+	d.powerSample.count++
+	if d.powerSample.count < chargePowerSamples {
+		return
+	}
+
+	average := math.Round(d.powerSample.total/float64(d.powerSample.count)*10) / 10
+	d.powerSample = nil
+
+	typical := d.stateManager.GetChargePower()
+	if typical > 0 && average < typical*lowChargePowerRatio {
+		if d.lowChargePower.Swap(math.Float64bits(average)) == 0 {
+			d.logger.Warn("Charging slower than usual", "power", average, "typical", typical)
+			d.emit(events.LowChargePower, fmt.Sprintf(
+				"Charging at %.0f W, typically %.0f W: the charger or hub may be too weak or failing", average, typical))
+		}
+		return
+	}
+
+	d.lowChargePower.Store(0)
+	if err := d.stateManager.RecordChargePower(average); err != nil {
+		d.logger.Error("Failed to record charge power", "error", err)
+	} else {
+		d.logger.Debug("Observed charge power", "power", average, "learned", d.stateManager.GetChargePower())
+	}
+}
@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/audit"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// isAudited reports whether a command is recorded in the audit log
+func isAudited(command string) bool {
+	switch command {
+	case protocol.CmdEnable, protocol.CmdDisable, protocol.CmdSetThreshold, protocol.CmdSnapshotRollback:
+		return true
+	}
+	return false
+}
+
+// auditSetting describes the setting an audited command changes
+func (d *Daemon) auditSetting(command string) string {
+	if d.stateManager == nil {
+		return ""
+	}
+
+	state := d.stateManager.GetState()
+	switch command {
+	case protocol.CmdSnapshotRollback:
+		return d.auditSetting(protocol.CmdSetThreshold) + ", " + d.auditSetting(protocol.CmdEnable)
+	case protocol.CmdSetThreshold:
+		if state.StartThreshold > 0 {
+			return fmt.Sprintf("%d%% (start %d%%)", state.ChargeThreshold, state.StartThreshold)
+		}
+		return fmt.Sprintf("%d%%", state.ChargeThreshold)
+	default:
+		if state.ConservationEnabled {
+			return "enabled"
+		}
+		if !state.ReenableAt.IsZero() {
+			return "disabled until " + state.ReenableAt.Format(time.RFC3339)
+		}
+		return "disabled"
+	}
+}
+
+// recordAudit appends a control command to the audit log, logging rather
+// than failing the command on errors
+func (d *Daemon) recordAudit(ctx context.Context, peer caller, command, before string, cmdErr error) {
+	entry := audit.Entry{
+		Time:    time.Now(),
+		UID:     audit.UnknownUID,
+		Command: command,
+		Old:     before,
+		New:     d.auditSetting(command),
+	}
+	if peer.Known {
+		entry.UID = peer.UID
+		entry.PID = peer.PID
+	}
+	if cmdErr != nil {
+		entry.Error = cmdErr.Error()
+	}
+
+	if err := d.auditLog.Append(entry); err != nil {
+		d.logger.ErrorContext(ctx, "Failed to write audit log", "command", command, "error", err)
+	}
+}
+
+// handleAudit handles the audit command
+func (d *Daemon) handleAudit(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	since, err := timeParam(params, "since")
+	if err != nil {
+		return nil, err
+	}
+	until, err := timeParam(params, "until")
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := d.auditLog.Query(since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	data := protocol.AuditData{Entries: []protocol.AuditEntry{}}
+	for _, entry := range entries {
+		data.Entries = append(data.Entries, protocol.AuditEntry{
+			Time:    entry.Time,
+			UID:     entry.UID,
+			PID:     entry.PID,
+			Command: entry.Command,
+			Old:     entry.Old,
+			New:     entry.New,
+			Error:   entry.Error,
+		})
+	}
+
+	return data, nil
+}
@@ -0,0 +1,199 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+// handleBatch handles the batch command, running the commands in "requests"
+// in order and answering with one result per command. No other request runs
+// while a batch does. Every command is checked before any runs: a batch with
+// an unknown command or one the caller may not run is rejected as a whole.
+// If a command fails, those after it are skipped and the changes of those
+// before it are rolled back, so a batch applies all or nothing.
+func (d *Daemon) handleBatch(ctx context.Context, peer caller, params map[string]interface{}) (interface{}, error) {
+	items, err := parseBatch(params)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if err := d.authorize(peer, item.Command); err != nil {
+			d.logger.WarnContext(ctx, "Request denied", "command", protocol.CmdBatch, "item", item.Command, "error", err)
+			return nil, err
+		}
+	}
+
+	d.requestMutex.Lock()
+	defer d.requestMutex.Unlock()
+
+	saved := d.saveBatchUndo()
+	data := protocol.BatchData{Results: make([]protocol.BatchResult, 0, len(items))}
+	var failed string
+	for _, item := range items {
+		result := protocol.BatchResult{Command: item.Command}
+		if failed != "" {
+			result.Skipped = true
+			result.Error = fmt.Sprintf("skipped after %s failed", failed)
+			data.Results = append(data.Results, result)
+			continue
+		}
+
+		response, err := d.runCommand(ctx, peer, item.Command, item.Params)
+		if err != nil {
+			result.Error = err.Error()
+			result.Code = protocol.ErrorCode(err)
+			failed = item.Command
+		} else {
+			result.Success = true
+			result.Data = response
+		}
+		data.Results = append(data.Results, result)
+	}
+
+	if failed != "" {
+		if err := d.undoBatch(ctx, saved); err != nil {
+			d.logger.ErrorContext(ctx, "Failed to roll back batch", "failed", failed, "error", err)
+			return nil, fmt.Errorf("%s failed and the batch could not be rolled back: %w", failed, err)
+		}
+		d.logger.InfoContext(ctx, "Rolled back batch", "failed", failed)
+		for i := range data.Results {
+			if data.Results[i].Success {
+				data.Results[i].Success = false
+				data.Results[i].RolledBack = true
+				data.Results[i].Error = fmt.Sprintf("rolled back after %s failed", failed)
+			}
+		}
+	}
+	return data, nil
+}
+
+// batchUndo holds what the commands of a batch may change, to put it back
+// if one of them fails
+type batchUndo struct {
+	state    state.Persistent
+	limits   *hardware.Limits // Nil if they couldn't be read
+	profile  string           // Empty without a readable power profile
+	logLevel string
+}
+
+// saveBatchUndo saves the settings, charge limits, power profile and log
+// level before a batch runs
+func (d *Daemon) saveBatchUndo() batchUndo {
+	saved := batchUndo{state: d.stateManager.GetState().Persistent}
+
+	if limits, err := d.hardware.ReadLimits(); err == nil {
+		saved.limits = &limits
+	}
+	if profiler, ok := d.hardware.(hardware.PowerProfiler); ok {
+		saved.profile, _, _ = profiler.ReadPowerProfile()
+	}
+
+	d.mutex.RLock()
+	saved.logLevel = d.logLevel
+	d.mutex.RUnlock()
+
+	return saved
+}
+
+// undoBatch puts back what saveBatchUndo saved, writing only what changed
+func (d *Daemon) undoBatch(ctx context.Context, saved batchUndo) error {
+	if err := d.stateManager.RevertSettings(saved.state); err != nil {
+		return fmt.Errorf("failed to restore settings: %w", err)
+	}
+
+	if saved.limits != nil {
+		current, err := d.hardware.ReadLimits()
+		if err != nil {
+			return fmt.Errorf("failed to read limits: %w", err)
+		}
+		if changes := changedLimits(*saved.limits, current); changes != (hardware.Limits{}) {
+			if err := d.writeHardware(func() error { return d.hardware.SetLimits(changes) }); err != nil {
+				return fmt.Errorf("failed to restore limits: %w", err)
+			}
+		}
+	}
+
+	if profiler, ok := d.hardware.(hardware.PowerProfiler); ok && saved.profile != "" {
+		if current, _, err := profiler.ReadPowerProfile(); err == nil && current != saved.profile {
+			if err := d.writeHardware(func() error { return profiler.SetPowerProfile(saved.profile) }); err != nil {
+				return fmt.Errorf("failed to restore power mode: %w", err)
+			}
+		}
+	}
+
+	d.mutex.Lock()
+	changed := d.logLevel != saved.logLevel
+	d.logLevel = saved.logLevel
+	cfg := d.config
+	d.mutex.Unlock()
+	if changed {
+		if err := d.logger.Configure(d.loggingConfig(cfg)); err != nil {
+			return fmt.Errorf("failed to restore log level: %w", err)
+		}
+	}
+
+	d.requestCheck()
+	return nil
+}
+
+// changedLimits returns the limits of saved that differ from current
+func changedLimits(saved, current hardware.Limits) hardware.Limits {
+	var changes hardware.Limits
+	if saved.ConservationMode != nil && (current.ConservationMode == nil || *current.ConservationMode != *saved.ConservationMode) {
+		changes.ConservationMode = saved.ConservationMode
+	}
+	if saved.StartThreshold != nil && (current.StartThreshold == nil || *current.StartThreshold != *saved.StartThreshold) {
+		changes.StartThreshold = saved.StartThreshold
+	}
+	if saved.EndThreshold != nil && (current.EndThreshold == nil || *current.EndThreshold != *saved.EndThreshold) {
+		changes.EndThreshold = saved.EndThreshold
+	}
+	if saved.RapidCharge != nil && (current.RapidCharge == nil || *current.RapidCharge != *saved.RapidCharge) {
+		changes.RapidCharge = saved.RapidCharge
+	}
+	if saved.ChargeType != nil && (current.ChargeType == nil || *current.ChargeType != *saved.ChargeType) {
+		changes.ChargeType = saved.ChargeType
+	}
+	return changes
+}
+
+// parseBatch reads the commands of a batch request
+func parseBatch(params map[string]interface{}) ([]protocol.BatchItem, error) {
+	list, ok := params["requests"].([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "requests parameter required")
+	}
+	if len(list) > protocol.MaxBatchSize {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams,
+			fmt.Sprintf("too many requests in batch, at most %d allowed", protocol.MaxBatchSize))
+	}
+
+	items := make([]protocol.BatchItem, 0, len(list))
+	for i, value := range list {
+		entry, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, protocol.NewCodedError(protocol.CodeInvalidParams, fmt.Sprintf("invalid request %d in batch", i+1))
+		}
+		command, _ := entry["command"].(string)
+		if !protocol.IsBatchable(command) {
+			return nil, protocol.NewCodedError(protocol.CodeInvalidParams,
+				fmt.Sprintf("command %q can't be part of a batch", command))
+		}
+
+		item := protocol.BatchItem{Command: command, Params: map[string]interface{}{}}
+		if raw, ok := entry["params"]; ok && raw != nil {
+			itemParams, ok := raw.(map[string]interface{})
+			if !ok {
+				return nil, protocol.NewCodedError(protocol.CodeInvalidParams,
+					fmt.Sprintf("invalid params of request %d in batch", i+1))
+			}
+			item.Params = itemParams
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -1,23 +1,118 @@
 package daemon
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/events"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/metrics"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
 )
 
+const (
+	// chargeFullLevel is the battery level at which a charge-full override ends
+	chargeFullLevel = 100
+
+	// defaultChargeRate (percent per hour) is assumed until charging has been
+	// observed; deliberately low so scheduled charges start early, not late
+	defaultChargeRate = 30.0
+
+	// chargeFullMargin is extra time allowed for a scheduled charge-full, as
+	// charging slows down near full
+	chargeFullMargin = 20 * time.Minute
+
+	// chargeRateSpan is how many percent of charging make up one rate sample
+	chargeRateSpan = 5
+
+	// maxChargeFullAhead limits how far ahead a charge-full can be scheduled
+	maxChargeFullAhead = 48 * time.Hour
+)
+
+// chargeSample marks the start of an observed stretch of charging
+type chargeSample struct {
+	level int
+	time  time.Time
+}
+
 // monitorBattery monitors battery level and adjusts conservation mode accordingly
 func (d *Daemon) monitorBattery() {
-	// Create ticker for periodic checks
-	ticker := time.NewTicker(d.checkInterval)
-	defer ticker.Stop()
+	// One timer, rescheduled after every check so changes to the interval
+	// take effect right away
+	timer := time.NewTimer(d.scheduleNextCheck())
+	defer timer.Stop()
+	defer d.clearNextCheck()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			d.checkBatteryAndAdjust()
+			d.writeMetricsTextfile()
+		case <-d.recheck:
+			d.logger.Debug("Checking battery on request")
+			d.checkBatteryAndAdjust()
+			d.writeMetricsTextfile()
+		case checked := <-d.checkNow:
+			d.checkBatteryAndAdjust()
+			d.writeMetricsTextfile()
+			close(checked)
+		case <-d.intervalChanged:
 		case <-d.done:
 			return
 		}
+		timer.Reset(d.scheduleNextCheck())
+	}
+}
+
+// scheduleNextCheck records when the monitor checks next and returns how long
+// to wait until then
+func (d *Daemon) scheduleNextCheck() time.Duration {
+	d.intervalMutex.Lock()
+	defer d.intervalMutex.Unlock()
+
+	d.nextCheck = time.Now().Add(d.checkInterval)
+	return d.checkInterval
+}
+
+// clearNextCheck records that no check is scheduled as the monitor stopped
+func (d *Daemon) clearNextCheck() {
+	d.intervalMutex.Lock()
+	defer d.intervalMutex.Unlock()
+
+	d.nextCheck = time.Time{}
+}
+
+// requestCheck asks the monitor to check the battery right away; requests
+// made while one is pending are merged
+func (d *Daemon) requestCheck() {
+	select {
+	case d.recheck <- struct{}{}:
+	default:
+	}
+}
+
+// CheckNow makes the monitor check the battery right away and waits until it
+// has, e.g. for tests changing the hardware. It returns false if the daemon
+// is not running.
+func (d *Daemon) CheckNow() bool {
+	if !d.IsRunning() {
+		return false
+	}
+
+	checked := make(chan struct{})
+	select {
+	case d.checkNow <- checked:
+	case <-d.done:
+		return false
+	}
+
+	select {
+	case <-checked:
+		return true
+	case <-d.done:
+		return false
 	}
 }
 
@@ -27,23 +122,75 @@ func (d *Daemon) checkBatteryAndAdjust() {
 		return
 	}
 
+	d.applySchedule(time.Now())
+
 	// Read current battery information
 	batteryLevel, conservationMode, charging, err := d.readBatteryInfo()
 	if err != nil {
-		fmt.Printf("Failed to read battery info: %v\n", err)
+		d.logger.Error("Failed to read battery info", "error", err)
+		d.emitError("Failed to read the battery", err)
 		return
 	}
+	d.lastError = ""
 
 	// Update state with current battery info
 	if err := d.stateManager.UpdateBatteryInfo(batteryLevel, conservationMode, charging); err != nil {
-		fmt.Printf("Failed to update battery info in state: %v\n", err)
+		d.logger.Error("Failed to update battery info in state", "error", err)
 		return
 	}
 
+	d.recordSample(batteryLevel, conservationMode, charging, time.Now())
+	d.recordCapacity(batteryLevel, conservationMode, charging, time.Now())
+	d.checkAdaptive(time.Now())
+
+	if reenabled, err := d.stateManager.ReenableIfDue(time.Now()); err != nil {
+		d.logger.Error("Failed to re-enable management after temporary disable", "error", err)
+	} else if reenabled {
+		d.logger.Info("Temporary disable expired, re-enabled battery management")
+	}
+
+	d.trackChargeRate(batteryLevel, conservationMode, charging, time.Now())
+	d.checkNightMode(time.Now())
+
+	// While paused the hardware is left as it is
+	paused := d.automationPaused(time.Now())
+
+	if d.stateManager.IsChargeFull() && batteryLevel >= chargeFullLevel {
+		if err := d.stateManager.FinishChargeFull(); err != nil {
+			d.logger.Error("Failed to finish charge-full", "error", err)
+		} else {
+			d.logger.Info("Battery full, charge-full finished",
+				"battery", batteryLevel, "management_enabled", d.stateManager.GetConservationEnabled())
+		}
+	}
+
+	if by := d.stateManager.GetChargeFullBy(); !by.IsZero() && !d.stateManager.IsChargeFull() && !paused {
+		if start := d.chargeFullStartTime(by, batteryLevel); !time.Now().Before(start) {
+			d.beginScheduledChargeFull(by, batteryLevel, conservationMode)
+		}
+	}
+
+	if !paused {
+		d.adjustForceDischarge(batteryLevel, charging)
+		d.syncEndThreshold()
+	}
+	d.checkAlerts(batteryLevel, charging)
+	d.checkDrainOnAC(batteryLevel, charging)
+	d.checkChargePower(batteryLevel, conservationMode, charging)
+	d.checkAdapter(charging)
+	if !paused {
+		d.checkRules(batteryLevel, charging, time.Now())
+	}
+
+	// Pausing charging is announced once per time AC is plugged in
+	if !charging {
+		d.chargePaused = false
+	}
+
 	// Only process if we're on AC power and management is enabled
-	if !charging || !d.stateManager.GetConservationEnabled() {
-		fmt.Printf("Skipping check: AC connected=%v, conservation enabled=%v\n",
-			charging, d.stateManager.GetConservationEnabled())
+	if !charging || !d.stateManager.GetConservationEnabled() || paused {
+		d.logger.Debug("Skipping check",
+			"ac_connected", charging, "management_enabled", d.stateManager.GetConservationEnabled(), "paused", paused)
 		return
 	}
 
@@ -53,18 +200,28 @@ func (d *Daemon) checkBatteryAndAdjust() {
 
 	// Change conservation mode if needed
 	if shouldEnable && !conservationMode {
-		if err := d.setConservationMode(true); err != nil {
-			fmt.Printf("Failed to enable conservation mode: %v\n", err)
+		if err := d.setConservationMode(context.Background(), true); err != nil {
+			d.logger.Error("Failed to enable conservation mode", "error", err)
+			d.emitError("Failed to enable conservation mode", err)
 		} else {
-			fmt.Printf("Enabled conservation mode (battery: %d%%, threshold: %d%%)\n",
-				batteryLevel, d.stateManager.GetChargeThreshold())
+			d.logger.Info("Enabled conservation mode",
+				"battery", batteryLevel, "threshold", d.stateManager.GetEffectiveThreshold())
+			// The first hold since AC was plugged in is announced as the
+			// charge pausing, later top-ups as the threshold being reached
+			if !d.chargePaused {
+				d.chargePaused = true
+				d.emit(events.ChargePaused, fmt.Sprintf("Charging paused at %d%% to protect the battery", batteryLevel))
+			} else {
+				d.emit(events.ThresholdReached, fmt.Sprintf("Battery reached %d%%, holding the charge", batteryLevel))
+			}
 		}
 	} else if shouldDisable && conservationMode {
-		if err := d.setConservationMode(false); err != nil {
-			fmt.Printf("Failed to disable conservation mode: %v\n", err)
+		if err := d.setConservationMode(context.Background(), false); err != nil {
+			d.logger.Error("Failed to disable conservation mode", "error", err)
+			d.emitError("Failed to disable conservation mode", err)
 		} else {
-			fmt.Printf("Disabled conservation mode (battery: %d%%, threshold: %d%%)\n",
-				batteryLevel, d.stateManager.GetChargeThreshold())
+			d.logger.Info("Disabled conservation mode",
+				"battery", batteryLevel, "threshold", d.stateManager.GetEffectiveThreshold())
 		}
 	}
 
@@ -72,13 +229,195 @@ func (d *Daemon) checkBatteryAndAdjust() {
 	d.adjustCheckInterval(batteryLevel)
 }
 
+// syncEndThreshold writes the threshold in force to a native end threshold,
+// so thresholds below the 60% conservation mode holds are enforced by the
+// firmware. It is lifted to 100% again once management is disabled.
+func (d *Daemon) syncEndThreshold() {
+	if !hardware.HasEndThreshold(d.hardware) {
+		return
+	}
+
+	target := 100
+	if d.stateManager.GetConservationEnabled() {
+		target = max(d.stateManager.GetEffectiveThreshold(), hardware.EndThresholdRange.Min)
+	}
+	// A threshold the user set with set_limits is left alone until
+	// management is enabled
+	if target == d.endThreshold || (target == 100 && d.endThreshold == 0) {
+		return
+	}
+
+	if err := d.writeHardware(func() error { return d.hardware.SetLimits(hardware.Limits{EndThreshold: &target}) }); err != nil {
+		d.logger.Error("Failed to set the end threshold", "threshold", target, "error", err)
+		d.emitError("Failed to set the end threshold", err)
+		return
+	}
+	d.logger.Info("Set the end threshold", "threshold", target)
+	d.endThreshold = target
+}
+
+// writeMetricsTextfile writes the status for node_exporter's textfile
+// collector, if configured
+func (d *Daemon) writeMetricsTextfile() {
+	path := d.GetConfig().Metrics.Textfile
+	if path == "" || d.stateManager == nil {
+		return
+	}
+
+	status := d.statusData(false)
+	if err := metrics.WriteTextfile(path, &status); err != nil {
+		d.logger.Error("Failed to write metrics textfile", "path", path, "error", err)
+	}
+}
+
+// trackChargeRate learns the charge rate from stretches of uninterrupted
+// charging with conservation mode off
+func (d *Daemon) trackChargeRate(level int, conservationMode, charging bool, now time.Time) {
+	if !charging || conservationMode || level >= chargeFullLevel {
+		d.chargeSample = nil
+		return
+	}
+
+	if d.chargeSample == nil || level < d.chargeSample.level {
+		d.chargeSample = &chargeSample{level: level, time: now}
+		return
+	}
+
+	gained := level - d.chargeSample.level
+	if gained < chargeRateSpan {
+		return
+	}
+
+	rate := float64(gained) / now.Sub(d.chargeSample.time).Hours()
+	if err := d.stateManager.RecordChargeRate(rate); err != nil {
+		d.logger.Error("Failed to record charge rate", "error", err)
+	} else {
+		d.logger.Debug("Observed charge rate", "rate", rate, "learned", d.stateManager.GetChargeRate())
+	}
+	d.chargeSample = &chargeSample{level: level, time: now}
+}
+
+// checkAdapter identifies the connected power adapter for the status,
+// logging when a different one is plugged in
+func (d *Daemon) checkAdapter(acOnline bool) {
+	reader, ok := d.hardware.(hardware.AdapterReader)
+	if !acOnline || !ok {
+		d.adapter.Store(nil)
+		return
+	}
+
+	adapter, err := reader.ReadAdapter()
+	if err != nil || adapter == nil {
+		if err != nil {
+			d.logger.Debug("Failed to identify the power adapter", "error", err)
+		}
+		d.adapter.Store(nil)
+		return
+	}
+
+	data := &protocol.AdapterData{Name: adapter.Name, Type: adapter.Type, PD: adapter.PD, Watts: math.Round(adapter.Watts)}
+	if previous := d.adapter.Swap(data); previous == nil || *previous != *data {
+		d.logger.Info("Power adapter connected", "name", data.Name, "type", data.Type, "pd", data.PD, "watts", data.Watts)
+	}
+}
+
+// chargeFullStartTime returns when charging must start for the battery to be
+// full by the deadline, using the learned charge rate
+func (d *Daemon) chargeFullStartTime(by time.Time, level int) time.Time {
+	return chargeStartTime(by, level, d.stateManager.GetChargeRate())
+}
+
+// chargeStartTime computes when charging from level must start to reach full
+// by the deadline at the given rate (percent per hour; 0 uses the default)
+func chargeStartTime(by time.Time, level int, rate float64) time.Time {
+	if rate <= 0 {
+		rate = defaultChargeRate
+	}
+
+	remaining := float64(max(chargeFullLevel-level, 0))
+	duration := time.Duration(remaining / rate * float64(time.Hour))
+	return by.Add(-duration - chargeFullMargin)
+}
+
+// beginScheduledChargeFull suspends management and starts charging for a
+// scheduled charge-full
+func (d *Daemon) beginScheduledChargeFull(by time.Time, level int, conservationMode bool) {
+	if conservationMode {
+		if err := d.setConservationMode(context.Background(), false); err != nil {
+			d.logger.Error("Failed to start scheduled charge-full", "error", err)
+			return
+		}
+	}
+
+	if err := d.stateManager.BeginScheduledChargeFull(); err != nil {
+		d.logger.Error("Failed to start scheduled charge-full", "error", err)
+		return
+	}
+
+	d.logger.Info("Charging to full for scheduled deadline", "by", by, "battery", level)
+}
+
+// reconcileAtStartup re-reads the hardware and brings conservation mode back
+// in line with the persisted policy: a reboot resets the hardware, and a
+// crashed run may have died between a hardware write and the matching state
+// update
+func (d *Daemon) reconcileAtStartup() {
+	if d.stateManager == nil {
+		return
+	}
+
+	batteryLevel, conservationMode, charging, err := d.readBatteryInfo()
+	if err != nil {
+		d.logger.Warn("Reconciliation skipped, failed to read battery info", "error", err)
+		return
+	}
+
+	if err := d.stateManager.UpdateBatteryInfo(batteryLevel, conservationMode, charging); err != nil {
+		d.logger.Error("Failed to update battery info in state", "error", err)
+		return
+	}
+
+	// The previous run may have left the battery force discharging; the
+	// monitor turns it back on if storage mode still needs it
+	d.stopForceDischarge()
+
+	if d.automationPaused(time.Now()) {
+		d.logger.Info("Automation paused, leaving conservation mode as it is",
+			"battery", batteryLevel, "conservation_mode", conservationMode)
+		return
+	}
+
+	// With management disabled the battery is expected to charge to 100%
+	if !d.stateManager.GetConservationEnabled() && !d.stateManager.IsChargeFull() {
+		if conservationMode {
+			if err := d.setConservationMode(context.Background(), false); err != nil {
+				d.logger.Error("Failed to disable leftover conservation mode", "error", err)
+			} else {
+				d.logger.Info("Disabled leftover conservation mode (management disabled)")
+			}
+		}
+		d.resumeRapidCharge(context.Background())
+		return
+	}
+
+	d.checkBatteryAndAdjust()
+	if !d.stateManager.GetConservationMode() {
+		d.resumeRapidCharge(context.Background())
+	}
+	d.logger.Info("Reconciled conservation mode at startup",
+		"battery", batteryLevel, "ac_connected", charging,
+		"management_enabled", d.stateManager.GetConservationEnabled(),
+		"threshold", d.stateManager.GetEffectiveThreshold(),
+		"conservation_mode", d.stateManager.GetConservationMode())
+}
+
 // adjustCheckInterval adjusts the monitoring interval based on battery level
 func (d *Daemon) adjustCheckInterval(batteryLevel int) {
 	if d.stateManager == nil {
 		return
 	}
 
-	threshold := d.stateManager.GetChargeThreshold()
+	threshold := d.stateManager.GetEffectiveThreshold()
 	difference := abs(batteryLevel - threshold)
 
 	var newInterval time.Duration
@@ -95,10 +434,26 @@ func (d *Daemon) adjustCheckInterval(batteryLevel int) {
 	}
 
 	// Update interval if it changed
-	if newInterval != d.checkInterval {
+	d.intervalMutex.Lock()
+	changed := !d.fixedInterval && newInterval != d.checkInterval
+	if changed {
 		d.checkInterval = newInterval
-		fmt.Printf("Adjusted check interval to %v (battery: %d%%, threshold: %d%%)\n",
-			newInterval, batteryLevel, threshold)
+	}
+	d.intervalMutex.Unlock()
+
+	if changed {
+		d.logger.Debug("Adjusted check interval",
+			"interval", newInterval, "battery", batteryLevel, "threshold", threshold)
+		d.rescheduleCheck()
+	}
+}
+
+// rescheduleCheck wakes the monitor to schedule its next check with the
+// current interval; a pending wake-up already picks up later changes
+func (d *Daemon) rescheduleCheck() {
+	select {
+	case d.intervalChanged <- struct{}{}:
+	default:
 	}
 }
 
@@ -115,17 +470,17 @@ func (d *Daemon) GetMonitoringStatus() MonitoringStatus {
 	if d.stateManager == nil {
 		return MonitoringStatus{
 			Enabled:  false,
-			Interval: d.checkInterval,
+			Interval: d.GetCheckInterval(),
 		}
 	}
 
 	return MonitoringStatus{
 		Enabled:          d.stateManager.GetConservationEnabled(),
-		Threshold:        d.stateManager.GetChargeThreshold(),
+		Threshold:        d.stateManager.GetEffectiveThreshold(),
 		CurrentBattery:   d.stateManager.GetBatteryLevel(),
 		ConservationMode: d.stateManager.GetConservationMode(),
 		Charging:         d.stateManager.IsCharging(),
-		Interval:         d.checkInterval,
+		Interval:         d.GetCheckInterval(),
 	}
 }
 
@@ -144,7 +499,14 @@ func (d *Daemon) SetMonitoringInterval(interval time.Duration) {
 	d.SetCheckInterval(interval)
 }
 
-// GetNextCheckTime returns when the next battery check will occur
+// GetNextCheckTime returns when the next battery check will occur, or one
+// interval from now if the monitor isn't running
 func (d *Daemon) GetNextCheckTime() time.Time {
-	return time.Now().Add(d.checkInterval)
+	d.intervalMutex.RLock()
+	defer d.intervalMutex.RUnlock()
+
+	if d.nextCheck.IsZero() {
+		return time.Now().Add(d.checkInterval)
+	}
+	return d.nextCheck
 }
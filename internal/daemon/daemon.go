@@ -1,61 +1,166 @@
 package daemon
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"google.golang.org/grpc"
+
+	"github.com/dom1nux/legionbatctl/internal/audit"
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/dock"
+	"github.com/dom1nux/legionbatctl/internal/events"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/history"
+	"github.com/dom1nux/legionbatctl/internal/logging"
+	"github.com/dom1nux/legionbatctl/internal/paths"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/rules"
+	"github.com/dom1nux/legionbatctl/internal/schedule"
+	"github.com/dom1nux/legionbatctl/internal/snapshot"
 	"github.com/dom1nux/legionbatctl/internal/state"
+	"github.com/dom1nux/legionbatctl/pkg/version"
 )
 
 const (
-	DefaultSocketPath = "/var/run/legionbatctl.sock"
-	DefaultStatePath  = "/etc/legionbatctl.state"
-	DefaultPIDPath    = "/var/run/legionbatctl.pid"
+	DefaultSocketPath  = paths.SystemSocketPath
+	DefaultStatePath   = paths.SystemStatePath
+	DefaultRuntimePath = paths.SystemRuntimePath
+	DefaultPIDPath     = "/var/run/legionbatctl.pid"
+	DefaultHistoryPath = "/var/lib/legionbatctl/history.jsonl"
+	DefaultHistoryDB   = "/var/lib/legionbatctl/history.db"
+	DefaultAuditPath   = "/var/lib/legionbatctl/audit.jsonl"
+	DefaultSnapshotDir = "/var/lib/legionbatctl/snapshots"
 )
 
 // Daemon represents the battery management daemon
 type Daemon struct {
-	socketPath string
-	statePath  string
-	pidPath    string
+	socketPath  string
+	statePath   string
+	runtimePath string // Empty keeps the runtime state in the state file
+	pidPath     string
+	configPath  string
+	historyPath string
 
 	// Core components
-	stateManager *state.Manager
-	listener     net.Listener
+	stateManager    *state.Manager
+	history         history.Recorder // Nil if the history couldn't be opened
+	auditLog        *audit.Log
+	snapshots       *snapshot.Store
+	events          *events.Dispatcher
+	hardware        hardware.Backend
+	inhibitor       *sleepInhibitor // Nil without systemd-inhibit or while not running
+	listener        net.Listener
+	varlinkListener net.Listener // Nil unless the varlink socket is configured
+	grpcServer      *grpc.Server // Nil unless the gRPC socket is configured
+	httpServer      *http.Server // Nil unless the HTTP API is configured
+	limiter         *rateLimiter
+	connections     atomic.Int32  // Connections being served
+	connectionIDs   atomic.Uint64 // Numbers connections for the logs
+	lastSwitch      atomic.Int64  // Unix nanoseconds of the last conservation mode switch
 
 	// Control
-	mutex   sync.RWMutex
-	done    chan bool
-	running bool
+	mutex        sync.RWMutex
+	requestMutex sync.RWMutex // Held exclusively while a batch runs
+	readMutex    sync.Mutex   // Serializes live reads for fresh status requests
+	done         chan bool
+	stopped      chan struct{}      // Closed once Stop has finished cleaning up
+	recheck      chan struct{}      // Requests an immediate battery check
+	checkNow     chan chan struct{} // Requests a check, closing the channel once done
+	running      bool
 
 	// Configuration
-	checkInterval time.Duration
-	logLevel      string
+	scheduler        atomic.Pointer[schedule.Scheduler]
+	chargeSample     *chargeSample // Start of the observed charging stretch (monitor only)
+	endThreshold     int           // Native end threshold last written, 0 if none (monitor only)
+	forceDischarging atomic.Bool   // Storage mode is discharging the battery on AC (read by requests)
+	lastError        string        // Last error event, to avoid repeating it (monitor only)
+	lastAlert        events.Type   // Battery alert raised since AC was unplugged (monitor only)
+	chargePaused     bool          // Charging was paused at the threshold since AC was plugged in (monitor only)
+	drain            *drainSample  // Battery discharging while AC is plugged in (monitor only)
+	powerSample      *powerSample  // Charge power readings being averaged (monitor only)
+	lowChargePower   atomic.Uint64 // math.Float64bits of the charge power found too low, 0 if normal
+	lastCapacity     time.Time     // Last capacity reading recorded in the history (monitor only)
+	lastAdaptive     time.Time     // Last time the threshold was learned (monitor only)
+	nightWake        time.Time     // Wake time night mode last scheduled charging for (monitor only)
+	config           *config.Config
+	logger           *logging.Logger
+	forceTakeover    bool            // Replace an unresponsive daemon's socket at startup
+	writer           *hardwareWriter // Serializes hardware writes while running
+	switchCooldown   time.Duration   // Least time between opposite conservation mode switches
+	logLevel         string          // Overrides the level of every log sink, if set; kept across reloads
+
+	powerModes atomic.Pointer[map[string]state.ThresholdOverride] // Threshold overrides coupled with platform profiles by power_modes
+	docking    atomic.Pointer[[]dockingRule]                      // Threshold overrides applied by lid and display state
+	dockSensor *dock.Sensor                                       // Reads the lid and display state for docking rules
+	rules      atomic.Pointer[[]rules.Rule]                       // Condition-based rules, checked by the monitor
+	ruleEngine *rules.Engine                                      // Tracks which rules hold (monitor only)
+	readSSID   func() (string, error)                             // Reads the Wi-Fi network for rules
+	adapter    atomic.Pointer[protocol.AdapterData]               // Connected power adapter, nil on battery or if it can't be identified
+	adaptive   atomic.Pointer[config.AdaptiveConfig]              // Adaptive threshold settings, nil until the config is loaded
+	alerts     atomic.Pointer[config.AlertsConfig]                // Battery alert levels, read by the monitor
+	suggested  atomic.Int32                                       // Threshold learned in adaptive suggest mode, 0 if none differs
+
+	// Check interval; the monitor adapts it while requests read and set it
+	intervalMutex   sync.RWMutex
+	checkInterval   time.Duration
+	fixedInterval   bool          // Set explicitly, not adapted to the battery level
+	nextCheck       time.Time     // When the monitor checks next, zero if not running
+	intervalChanged chan struct{} // Wakes the monitor to reschedule its next check
 }
 
-// NewDaemon creates a new daemon instance
+// NewDaemon creates a new daemon instance. Empty paths use the defaults for
+// the current user.
 func NewDaemon(socketPath, statePath string) *Daemon {
 	if socketPath == "" {
-		socketPath = DefaultSocketPath
+		socketPath = paths.Defaults().Socket
 	}
 	if statePath == "" {
-		statePath = DefaultStatePath
+		statePath = paths.Defaults().State
 	}
 
-	return &Daemon{
-		socketPath:    socketPath,
-		statePath:     statePath,
-		pidPath:       filepath.Join(filepath.Dir(socketPath), "legionbatctl.pid"),
-		done:          make(chan bool),
-		running:       false,
-		checkInterval: 30 * time.Second, // Default check interval
+	d := &Daemon{
+		socketPath:      socketPath,
+		statePath:       statePath,
+		pidPath:         PIDPathFor(socketPath),
+		configPath:      config.DefaultConfigPath,
+		done:            make(chan bool),
+		stopped:         make(chan struct{}),
+		recheck:         make(chan struct{}, 1),
+		checkNow:        make(chan chan struct{}),
+		intervalChanged: make(chan struct{}, 1),
+		running:         false,
+		config:          config.Default(),
+		hardware:        hardware.NewSysfsBackend(),
+		auditLog:        audit.New(DefaultAuditPath),
+		snapshots:       snapshot.New(DefaultSnapshotDir),
+		dockSensor:      dock.NewSensor(dock.DefaultPaths),
+		ruleEngine:      rules.NewEngine(),
+		readSSID:        rules.ReadSSID,
+		limiter:         newRateLimiter(),
+		logger:          logging.NewDefault(),
+		checkInterval:   30 * time.Second, // Default check interval
+		switchCooldown:  defaultSwitchCooldown,
 	}
+	d.events = events.NewDispatcher(d.logEventError)
+	d.alerts.Store(&d.config.Alerts)
+
+	return d
+}
+
+// PIDPathFor returns where a daemon listening on socketPath writes its PID
+// file unless told otherwise
+func PIDPathFor(socketPath string) string {
+	return filepath.Join(filepath.Dir(socketPath), "legionbatctl.pid")
 }
 
 // Start starts the daemon
@@ -67,19 +172,40 @@ func (d *Daemon) Start() error {
 		return fmt.Errorf("daemon is already running")
 	}
 
+	// Refuse to start next to a running daemon before touching its state,
+	// and clear a socket left over by a crash
+	if err := d.claimSocket(d.forceTakeover); err != nil {
+		return err
+	}
+
 	// Initialize state manager
 	d.stateManager = state.NewManager(d.statePath)
+	d.stateManager.SetRuntimePath(d.runtimePath)
+	d.stateManager.SetBackupLimit(d.config.State.Backups)
+	d.stateManager.SetHysteresis(d.config.Management.Hysteresis)
+	d.applySchedule(time.Now())
 
 	// Load existing state or create default
 	if err := d.stateManager.Load(); err != nil {
 		return fmt.Errorf("failed to load state: %w", err)
 	}
+	if recovery := d.stateManager.Recovery(); recovery != "" {
+		d.logger.Warn("Recovered a corrupted state file", "path", d.statePath, "details", recovery)
+	}
 
 	// Set daemon info in state
 	if err := d.stateManager.SetDaemonInfo(os.Getpid()); err != nil {
 		return fmt.Errorf("failed to set daemon info: %w", err)
 	}
 
+	d.openHistory()
+
+	// Clear the clean shutdown marker, remembering whether the last run crashed
+	wasClean, err := d.stateManager.MarkRunning()
+	if err != nil {
+		return fmt.Errorf("failed to record daemon start: %w", err)
+	}
+
 	// Create socket listener
 	if err := d.createSocketListener(); err != nil {
 		return fmt.Errorf("failed to create socket listener: %w", err)
@@ -91,12 +217,43 @@ func (d *Daemon) Start() error {
 		return fmt.Errorf("failed to write PID file: %w", err)
 	}
 
+	if socketPath := d.config.Varlink.Socket; socketPath != "" {
+		if err := d.startVarlink(socketPath); err != nil {
+			d.logger.Error("Varlink interface disabled", "error", err)
+		}
+	}
+
+	if socketPath := d.config.GRPC.Socket; socketPath != "" {
+		if err := d.startGRPC(socketPath); err != nil {
+			d.logger.Error("gRPC API disabled", "error", err)
+		}
+	}
+
+	if addr := d.config.HTTP.Listen; addr != "" {
+		if err := d.startHTTP(addr); err != nil {
+			d.logger.Error("HTTP API disabled", "listen", addr, "error", err)
+		}
+	}
+
 	// Set running flag
 	d.running = true
+	d.writer = newHardwareWriter(d.switchCooldown)
+	if d.hardware.Name() != "mock" {
+		d.inhibitor = newSleepInhibitor()
+	}
+
+	// Bring the hardware in line with the persisted settings right away
+	// rather than at the first check, as it may have changed while the
+	// daemon was not running
+	if !wasClean {
+		d.logger.Warn("Previous daemon run did not shut down cleanly, running extended reconciliation")
+	}
+	d.reconcileAtStartup()
 
 	// Start goroutines
 	go d.serveConnections()
 	go d.monitorBattery()
+	go d.events.Run(d.done)
 	go d.handleSignals()
 
 	return nil
@@ -108,8 +265,8 @@ func (d *Daemon) Run() error {
 		return err
 	}
 
-	// Block until daemon is stopped
-	<-d.done
+	// Block until daemon is stopped and has cleaned up
+	<-d.stopped
 	return nil
 }
 
@@ -126,6 +283,12 @@ func (d *Daemon) Stop() error {
 	close(d.done)
 	d.running = false
 
+	// Leave conservation mode as configured, then persist the final
+	// readings and the clean shutdown marker
+	d.restoreOnStop()
+	d.recordCleanShutdown()
+	d.writer.close()
+
 	// Close socket listener
 	if d.listener != nil {
 		d.listener.Close()
@@ -134,12 +297,73 @@ func (d *Daemon) Stop() error {
 	// Remove socket file
 	os.Remove(d.socketPath)
 
+	if d.httpServer != nil {
+		d.httpServer.Close()
+	}
+
+	if d.varlinkListener != nil {
+		d.varlinkListener.Close()
+		os.Remove(d.varlinkListener.Addr().String())
+	}
+
+	if d.grpcServer != nil {
+		d.grpcServer.Stop()
+		os.Remove(d.config.GRPC.Socket)
+	}
+
 	// Remove PID file
 	os.Remove(d.pidPath)
 
+	if d.history != nil {
+		d.history.Close()
+	}
+
+	close(d.stopped)
 	return nil
 }
 
+// restoreOnStop switches conservation mode to the configured management.on_stop
+// setting, so what the battery does while the daemon is stopped is explicit
+// (caller must hold the mutex)
+func (d *Daemon) restoreOnStop() {
+	action := d.config.Management.OnStop
+	if action == "" || action == "keep" || d.stateManager == nil {
+		d.logger.Info("Leaving conservation mode as is on stop",
+			"conservation_mode", d.stateManager != nil && d.stateManager.GetConservationMode())
+		return
+	}
+
+	enable := action == "enable"
+	if err := d.setConservationMode(context.Background(), enable); err != nil {
+		d.logger.Error("Failed to restore conservation mode on stop", "on_stop", action, "error", err)
+		return
+	}
+	d.logger.Info("Restored conservation mode on stop", "on_stop", action, "conservation_mode", enable)
+}
+
+// recordCleanShutdown stores the final battery readings and marks the shutdown as clean.
+// Falls back to the last known readings if the hardware can't be read.
+func (d *Daemon) recordCleanShutdown() {
+	if d.stateManager == nil {
+		return
+	}
+
+	if d.forceDischarging.Load() {
+		d.stopForceDischarge()
+	}
+
+	batteryLevel, conservationMode, charging, err := d.readBatteryInfo()
+	if err != nil {
+		batteryLevel = d.stateManager.GetBatteryLevel()
+		conservationMode = d.stateManager.GetConservationMode()
+		charging = d.stateManager.IsCharging()
+	}
+
+	if err := d.stateManager.MarkCleanShutdown(batteryLevel, conservationMode, charging); err != nil {
+		d.logger.Error("Failed to record clean shutdown", "error", err)
+	}
+}
+
 // IsRunning returns whether the daemon is running
 func (d *Daemon) IsRunning() bool {
 	d.mutex.RLock()
@@ -157,9 +381,6 @@ func (d *Daemon) GetState() state.State {
 
 // createSocketListener creates the Unix socket listener
 func (d *Daemon) createSocketListener() error {
-	// Remove existing socket file if it exists
-	os.Remove(d.socketPath)
-
 	// Create socket directory if needed
 	socketDir := filepath.Dir(d.socketPath)
 	if err := os.MkdirAll(socketDir, 0755); err != nil {
@@ -203,7 +424,7 @@ func (d *Daemon) writePIDFile() error {
 // handleSignals handles system signals for graceful shutdown
 func (d *Daemon) handleSignals() {
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1)
 
 	for {
 		select {
@@ -214,8 +435,11 @@ func (d *Daemon) handleSignals() {
 				d.Stop()
 				return
 			case syscall.SIGHUP:
-				// Reload configuration (placeholder for future use)
+				// Reload the config file, keeping the current config if it is invalid
 				d.reloadConfiguration()
+			case syscall.SIGUSR1:
+				// Power supply changed (sent by the udev rules)
+				d.requestCheck()
 			}
 		case <-d.done:
 			return
@@ -225,9 +449,158 @@ func (d *Daemon) handleSignals() {
 
 // reloadConfiguration reloads daemon configuration
 func (d *Daemon) reloadConfiguration() {
-	// Placeholder for future configuration reloading
-	// Currently just log that we received SIGHUP
-	fmt.Printf("Received SIGHUP, configuration reload not implemented yet\n")
+	if err := d.LoadConfig(d.configPath); err != nil {
+		d.logger.Error("Failed to reload configuration", "path", d.configPath, "error", err)
+		return
+	}
+	d.logger.Info("Configuration reloaded", "path", d.configPath)
+}
+
+// handleReloadConfig handles the reload_config command, rereading the
+// configuration file as SIGHUP does but reporting failures to the caller
+func (d *Daemon) handleReloadConfig(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	d.mutex.RLock()
+	path := d.configPath
+	d.mutex.RUnlock()
+
+	if err := d.LoadConfig(path); err != nil {
+		d.logger.ErrorContext(ctx, "Failed to reload configuration", "path", path, "error", err)
+		return nil, fmt.Errorf("failed to reload configuration: %w", err)
+	}
+	d.logger.InfoContext(ctx, "Configuration reloaded", "path", path)
+	return protocol.ReloadConfigData{Path: path}, nil
+}
+
+// loggingConfig returns the configured log sinks with the log level override
+// applied
+func (d *Daemon) loggingConfig(cfg *config.Config) config.LoggingConfig {
+	d.mutex.RLock()
+	level := d.logLevel
+	d.mutex.RUnlock()
+
+	if level == "" {
+		return cfg.Logging
+	}
+
+	sinks := make([]config.SinkConfig, len(cfg.Logging.Sinks))
+	for i, sink := range cfg.Logging.Sinks {
+		sink.Level = level
+		sinks[i] = sink
+	}
+	return config.LoggingConfig{Sinks: sinks}
+}
+
+// LoadConfig loads the configuration file at path and applies it, including
+// the log sinks. The previous configuration stays active if loading fails.
+func (d *Daemon) LoadConfig(path string) error {
+	cfg, warnings, err := config.LoadWithWarnings(path)
+	if err != nil {
+		return err
+	}
+
+	scheduleRules, err := cfg.ScheduleRules()
+	if err != nil {
+		return err
+	}
+	conditionRules, err := cfg.ConditionRules()
+	if err != nil {
+		return err
+	}
+
+	handlers, err := d.eventHandlers(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to set up notifications: %w", err)
+	}
+
+	if err := d.logger.Configure(d.loggingConfig(cfg)); err != nil {
+		return fmt.Errorf("failed to set up logging: %w", err)
+	}
+	for _, warning := range warnings {
+		d.logger.Warn("Problem in configuration file", "path", path, "line", warning.Line, "setting", warning.Field, "error", warning.Err)
+	}
+
+	d.mutex.Lock()
+	d.configPath = path
+	d.config = cfg
+	if d.stateManager != nil {
+		d.stateManager.SetHysteresis(cfg.Management.Hysteresis)
+		d.stateManager.SetBackupLimit(cfg.State.Backups)
+	}
+	d.mutex.Unlock()
+
+	d.scheduler.Store(schedule.New(scheduleRules))
+	d.powerModes.Store(powerModeOverrides(cfg))
+	d.docking.Store(dockingRules(cfg))
+	d.rules.Store(&conditionRules)
+	d.adaptive.Store(&cfg.Adaptive)
+	d.alerts.Store(&cfg.Alerts)
+	d.events.SetHandlers(handlers)
+	d.applySchedule(time.Now())
+
+	return nil
+}
+
+// SetHistoryPath sets where the battery history is stored, overriding the
+// configured path (must be called before Start)
+func (d *Daemon) SetHistoryPath(path string) {
+	d.historyPath = path
+}
+
+// SetAuditPath sets where control commands are recorded (must be called
+// before Start)
+func (d *Daemon) SetAuditPath(path string) {
+	d.auditLog = audit.New(path)
+}
+
+// SetSnapshotDir sets where settings snapshots are kept (must be called
+// before Start)
+func (d *Daemon) SetSnapshotDir(dir string) {
+	d.snapshots = snapshot.New(dir)
+}
+
+// SetDockPaths sets where the lid and display state is read for docking
+// rules (must be called before Start)
+func (d *Daemon) SetDockPaths(paths dock.Paths) {
+	d.dockSensor = dock.NewSensor(paths)
+}
+
+// SetPIDPath sets where the PID file is written (must be called before Start)
+func (d *Daemon) SetPIDPath(path string) {
+	d.pidPath = path
+}
+
+// SetRuntimePath sets where the runtime state (battery readings, PID) is
+// saved apart from the state file (must be called before Start)
+func (d *Daemon) SetRuntimePath(path string) {
+	d.runtimePath = path
+}
+
+// SetForceTakeover makes Start replace the socket of a daemon that accepts
+// connections but does not answer (must be called before Start)
+func (d *Daemon) SetForceTakeover(force bool) {
+	d.forceTakeover = force
+}
+
+// SetLogLevel overrides the level of every configured log sink (must be
+// called before LoadConfig)
+func (d *Daemon) SetLogLevel(level string) error {
+	if !config.IsValidLogLevel(level) {
+		return fmt.Errorf("%w: %q", config.ErrInvalidLogLevel, level)
+	}
+	d.logLevel = level
+	return nil
+}
+
+// SetHardware replaces the hardware backend (must be called before Start)
+func (d *Daemon) SetHardware(backend hardware.Backend) {
+	d.hardware = backend
+}
+
+// GetConfig returns the active configuration
+func (d *Daemon) GetConfig() *config.Config {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.config
 }
 
 // GetPID returns the daemon PID
@@ -245,8 +618,7 @@ func (d *Daemon) GetUptime() time.Duration {
 
 // GetVersion returns daemon version information
 func (d *Daemon) GetVersion() string {
-	// This will be injected at build time
-	return "dev"
+	return version.GetVersionInfo().Version
 }
 
 // GetSocketPath returns the socket path
@@ -269,11 +641,30 @@ func (d *Daemon) SetCheckInterval(interval time.Duration) {
 		interval = 10 * time.Minute // Maximum 10 minutes
 	}
 
+	d.intervalMutex.Lock()
+	changed := interval != d.checkInterval
 	d.checkInterval = interval
+	d.intervalMutex.Unlock()
+
+	if changed {
+		d.rescheduleCheck()
+	}
+}
+
+// SetFixedCheckInterval sets the monitoring interval and stops it from being
+// adapted to the battery level
+func (d *Daemon) SetFixedCheckInterval(interval time.Duration) {
+	d.intervalMutex.Lock()
+	d.fixedInterval = true
+	d.intervalMutex.Unlock()
+
+	d.SetCheckInterval(interval)
 }
 
 // GetCheckInterval returns the current check interval
 func (d *Daemon) GetCheckInterval() time.Duration {
+	d.intervalMutex.RLock()
+	defer d.intervalMutex.RUnlock()
 	return d.checkInterval
 }
 
@@ -1,12 +1,110 @@
 package daemon
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/dom1nux/legionbatctl/api/legionbatctl/v1"
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/dock"
+	"github.com/dom1nux/legionbatctl/internal/events"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/history"
+	"github.com/dom1nux/legionbatctl/internal/paths"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/schedule"
+	"github.com/dom1nux/legionbatctl/internal/state"
+	"github.com/dom1nux/legionbatctl/internal/varlink"
 )
 
+// fakeBackend is an in-memory hardware backend for monitor tests
+type fakeBackend struct {
+	battery        hardware.Battery
+	canDischarge   bool
+	forceDischarge bool
+	rapidCharge    *bool // Nil if unsupported
+	endThreshold   int   // Last end threshold set, 0 if none
+}
+
+func (f *fakeBackend) ForceDischargeSupported() bool { return f.canDischarge }
+
+func (f *fakeBackend) SetForceDischarge(enable bool) error {
+	f.forceDischarge = enable
+	return nil
+}
+
+func (f *fakeBackend) Name() string { return "fake" }
+
+func (f *fakeBackend) ReadBattery() (hardware.Battery, error) { return f.battery, nil }
+
+// SetConservationMode switches conservation mode, switching rapid charge
+// off with it like Legion firmware
+func (f *fakeBackend) SetConservationMode(enable bool) error {
+	f.battery.ConservationMode = enable
+	if enable && f.rapidCharge != nil {
+		f.rapidCharge = new(bool)
+	}
+	return nil
+}
+
+func (f *fakeBackend) ReadLimits() (hardware.Limits, error) {
+	conservation := f.battery.ConservationMode
+	return hardware.Limits{ConservationMode: &conservation, RapidCharge: f.rapidCharge}, nil
+}
+
+func (f *fakeBackend) SetLimits(limits hardware.Limits) error {
+	if limits.ConservationMode != nil {
+		f.SetConservationMode(*limits.ConservationMode)
+	}
+	if limits.EndThreshold != nil {
+		f.endThreshold = *limits.EndThreshold
+	}
+	if limits.RapidCharge != nil {
+		enabled := *limits.RapidCharge
+		f.rapidCharge = &enabled
+		if enabled {
+			f.battery.ConservationMode = false
+		}
+	}
+	return nil
+}
+
+// newTestDaemon creates a daemon with a fresh state and a fake backend,
+// without starting the socket server or monitor
+func newTestDaemon(t *testing.T, battery hardware.Battery) (*Daemon, *fakeBackend) {
+	t.Helper()
+	tempDir := t.TempDir()
+
+	d := NewDaemon(filepath.Join(tempDir, "test.sock"), filepath.Join(tempDir, "test_state.json"))
+	d.stateManager = state.NewManager(d.statePath)
+	if err := d.stateManager.SetChargeThreshold(80); err != nil {
+		t.Fatalf("Failed to set threshold: %v", err)
+	}
+
+	d.SetAuditPath(filepath.Join(tempDir, "audit.jsonl"))
+	d.SetSnapshotDir(filepath.Join(tempDir, "snapshots"))
+
+	backend := &fakeBackend{battery: battery}
+	d.SetHardware(backend)
+
+	return d, backend
+}
+
 func TestNewDaemon(t *testing.T) {
 	daemon := NewDaemon("/tmp/test.sock", "/tmp/test_state.json")
 
@@ -29,13 +127,14 @@ func TestNewDaemon(t *testing.T) {
 
 func TestNewDaemonDefaultPaths(t *testing.T) {
 	daemon := NewDaemon("", "")
+	defaults := paths.Defaults()
 
-	if daemon.socketPath != DefaultSocketPath {
-		t.Errorf("Expected default socket path %s, got %s", DefaultSocketPath, daemon.socketPath)
+	if daemon.socketPath != defaults.Socket {
+		t.Errorf("Expected default socket path %s, got %s", defaults.Socket, daemon.socketPath)
 	}
 
-	if daemon.statePath != DefaultStatePath {
-		t.Errorf("Expected default state path %s, got %s", DefaultStatePath, daemon.statePath)
+	if daemon.statePath != defaults.State {
+		t.Errorf("Expected default state path %s, got %s", defaults.State, daemon.statePath)
 	}
 }
 
@@ -101,10 +200,79 @@ func TestDaemonStartAlreadyRunning(t *testing.T) {
 		t.Error("Expected error when starting already running daemon")
 	}
 
+	// A second daemon on the same socket must not take it over
+	second := NewDaemon(socketPath, filepath.Join(tempDir, "second_state.json"))
+	if err := second.Start(); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("Expected %v for a second daemon, got %v", ErrAlreadyRunning, err)
+	}
+
 	// Clean up
 	daemon.Stop()
 }
 
+func TestClaimSocket(t *testing.T) {
+	previous := socketProbeTimeout
+	socketProbeTimeout = 200 * time.Millisecond
+	defer func() { socketProbeTimeout = previous }()
+
+	t.Run("free", func(t *testing.T) {
+		d := NewDaemon(filepath.Join(t.TempDir(), "test.sock"), "")
+		if err := d.claimSocket(false); err != nil {
+			t.Errorf("claimSocket() error = %v", err)
+		}
+	})
+
+	t.Run("stale socket is removed", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "test.sock")
+		listener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			t.Fatalf("Listen() error = %v", err)
+		}
+		listener.(*net.UnixListener).SetUnlinkOnClose(false)
+		listener.Close()
+
+		d := NewDaemon(socketPath, "")
+		if err := d.claimSocket(false); err != nil {
+			t.Fatalf("claimSocket() error = %v", err)
+		}
+		if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+			t.Error("Expected the stale socket to be removed")
+		}
+	})
+
+	t.Run("unresponsive socket needs force", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "test.sock")
+		listener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			t.Fatalf("Listen() error = %v", err)
+		}
+		defer listener.Close()
+
+		d := NewDaemon(socketPath, "")
+		if err := d.claimSocket(false); !errors.Is(err, ErrSocketUnresponsive) {
+			t.Errorf("claimSocket() error = %v, want %v", err, ErrSocketUnresponsive)
+		}
+		if err := d.claimSocket(true); err != nil {
+			t.Errorf("claimSocket(force) error = %v", err)
+		}
+	})
+
+	t.Run("not a socket", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "test.sock")
+		if err := os.WriteFile(socketPath, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		d := NewDaemon(socketPath, "")
+		if err := d.claimSocket(true); !errors.Is(err, ErrNotSocket) {
+			t.Errorf("claimSocket() error = %v, want %v", err, ErrNotSocket)
+		}
+		if _, err := os.Stat(socketPath); err != nil {
+			t.Error("Expected the file to be kept")
+		}
+	})
+}
+
 func TestDaemonGetters(t *testing.T) {
 	daemon := NewDaemon("/tmp/test.sock", "/tmp/test_state.json")
 
@@ -147,6 +315,74 @@ func TestDaemonSetCheckInterval(t *testing.T) {
 	}
 }
 
+func TestDaemonFixedCheckInterval(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 79, ACOnline: true})
+
+	d.adjustCheckInterval(79)
+	if d.GetCheckInterval() != 15*time.Second {
+		t.Errorf("Expected adaptive interval 15s near the threshold, got %v", d.GetCheckInterval())
+	}
+
+	d.SetFixedCheckInterval(time.Minute)
+	d.adjustCheckInterval(79)
+	if d.GetCheckInterval() != time.Minute {
+		t.Errorf("Expected fixed interval 1m, got %v", d.GetCheckInterval())
+	}
+}
+
+func TestMonitorReschedulesOnIntervalChange(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 50, ACOnline: true})
+	if err := d.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	defer d.Stop()
+
+	// Adapting and setting the interval from different goroutines must not race
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			d.adjustCheckInterval(50 + i%40)
+		}
+		close(done)
+	}()
+	for i := 0; i < 100; i++ {
+		d.GetCheckInterval()
+		d.GetNextCheckTime()
+	}
+	<-done
+
+	d.SetFixedCheckInterval(10 * time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for d.GetNextCheckTime().Before(time.Now().Add(9 * time.Minute)) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Next check at %v was not rescheduled for the 10m interval", d.GetNextCheckTime())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDaemonLogLevelOverride(t *testing.T) {
+	daemon := NewDaemon("/tmp/test.sock", "/tmp/test_state.json")
+
+	if err := daemon.SetLogLevel("trace"); err == nil {
+		t.Error("Expected error for unknown log level")
+	}
+	if err := daemon.SetLogLevel("debug"); err != nil {
+		t.Fatalf("SetLogLevel() error = %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.Logging.Sinks = []config.SinkConfig{{Type: "stdout", Level: "info"}, {Type: "stderr", Level: "error"}}
+	for _, sink := range daemon.loggingConfig(cfg).Sinks {
+		if sink.Level != "debug" {
+			t.Errorf("Sink %s level = %q, want debug", sink.Type, sink.Level)
+		}
+	}
+	if cfg.Logging.Sinks[0].Level != "info" {
+		t.Error("Expected the loaded configuration to be left unchanged")
+	}
+}
+
 func TestMonitoringStatus(t *testing.T) {
 	daemon := NewDaemon("/tmp/test.sock", "/tmp/test_state.json")
 
@@ -174,3 +410,2122 @@ func TestGetNextCheckTime(t *testing.T) {
 		t.Errorf("Expected next check around %v, got %v (diff: %v)", expected, nextCheck, diff)
 	}
 }
+
+func TestDaemonCleanShutdownMarker(t *testing.T) {
+	tempDir := t.TempDir()
+	socketPath := filepath.Join(tempDir, "test.sock")
+	statePath := filepath.Join(tempDir, "test_state.json")
+
+	daemon := NewDaemon(socketPath, statePath)
+	if err := daemon.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+
+	if daemon.GetState().CleanShutdown {
+		t.Error("Expected clean shutdown marker to be cleared while running")
+	}
+
+	if err := daemon.Stop(); err != nil {
+		t.Fatalf("Failed to stop daemon: %v", err)
+	}
+
+	if !daemon.GetState().CleanShutdown {
+		t.Error("Expected clean shutdown marker after graceful stop")
+	}
+
+	// Restarting after a graceful stop must not report a crash
+	restarted := NewDaemon(socketPath, statePath)
+	if err := restarted.Start(); err != nil {
+		t.Fatalf("Failed to restart daemon: %v", err)
+	}
+	defer restarted.Stop()
+
+	if restarted.GetState().UncleanShutdowns != 0 {
+		t.Errorf("Expected no unclean shutdowns, got %d", restarted.GetState().UncleanShutdowns)
+	}
+}
+
+func TestStartReconcilesHardware(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		battery  hardware.Battery
+		wantMode bool
+	}{
+		{"above threshold after reboot", true, hardware.Battery{Level: 90, ACOnline: true}, true},
+		{"below resume level", true, hardware.Battery{Level: 50, ACOnline: true, ConservationMode: true}, false},
+		{"management disabled", false, hardware.Battery{Level: 90, ACOnline: true, ConservationMode: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, backend := newTestDaemon(t, tt.battery)
+			if tt.enabled {
+				if err := d.stateManager.EnableConservation(); err != nil {
+					t.Fatalf("EnableConservation() error = %v", err)
+				}
+			}
+			// The previous run stopped cleanly, e.g. before a reboot
+			if err := d.stateManager.MarkCleanShutdown(tt.battery.Level, false, tt.battery.ACOnline); err != nil {
+				t.Fatalf("MarkCleanShutdown() error = %v", err)
+			}
+
+			if err := d.Start(); err != nil {
+				t.Fatalf("Failed to start daemon: %v", err)
+			}
+			defer d.Stop()
+
+			if backend.battery.ConservationMode != tt.wantMode {
+				t.Errorf("Conservation mode after start = %v, want %v", backend.battery.ConservationMode, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestStartOnBattery(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 50, ACOnline: false})
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("EnableConservation() error = %v", err)
+	}
+
+	// The startup check reads the alert levels with d.mutex held
+	started := make(chan error, 1)
+	go func() { started <- d.Start() }()
+	select {
+	case err := <-started:
+		if err != nil {
+			t.Fatalf("Failed to start daemon: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return on battery power")
+	}
+	d.Stop()
+}
+
+func TestStopRestoresConservationMode(t *testing.T) {
+	tests := []struct {
+		onStop   string
+		wantMode bool
+	}{
+		{"", true},
+		{"keep", true},
+		{"disable", false},
+		{"enable", true},
+	}
+
+	for _, tt := range tests {
+		t.Run("on_stop="+tt.onStop, func(t *testing.T) {
+			d, backend := newTestDaemon(t, hardware.Battery{Level: 90, ACOnline: true, ConservationMode: true})
+			d.config.Management.OnStop = tt.onStop
+			if err := d.stateManager.EnableConservation(); err != nil {
+				t.Fatalf("EnableConservation() error = %v", err)
+			}
+
+			if err := d.Start(); err != nil {
+				t.Fatalf("Failed to start daemon: %v", err)
+			}
+			if err := d.Stop(); err != nil {
+				t.Fatalf("Failed to stop daemon: %v", err)
+			}
+
+			if backend.battery.ConservationMode != tt.wantMode {
+				t.Errorf("Conservation mode after stop = %v, want %v", backend.battery.ConservationMode, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestChargeFullResumesManagement(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 80, ConservationMode: true, ACOnline: true})
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+
+	if _, err := d.handleChargeFull(context.Background(), nil); err != nil {
+		t.Fatalf("charge_full failed: %v", err)
+	}
+	if backend.battery.ConservationMode {
+		t.Error("Expected conservation mode to be disabled for charge-full")
+	}
+
+	// Below full the override stays active and conservation mode stays off
+	backend.battery.Level = 95
+	d.checkBatteryAndAdjust()
+	if !d.stateManager.IsChargeFull() || backend.battery.ConservationMode {
+		t.Error("Expected charge-full to continue below 100%")
+	}
+
+	backend.battery.Level = 100
+	d.checkBatteryAndAdjust()
+	if d.stateManager.IsChargeFull() {
+		t.Error("Expected charge-full to end at 100%")
+	}
+	if !d.stateManager.GetConservationEnabled() {
+		t.Error("Expected management to be re-enabled")
+	}
+	if !backend.battery.ConservationMode {
+		t.Error("Expected conservation mode to be re-applied above the threshold")
+	}
+}
+
+func TestDisableForDuration(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+
+	if _, err := d.handleDisable(context.Background(), map[string]interface{}{"for": "soon"}); err == nil {
+		t.Error("Expected error for invalid duration")
+	}
+
+	response, err := d.handleDisable(context.Background(), map[string]interface{}{"for": "2h"})
+	if err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	reenableAt := response.(protocol.DisableData).ReenableAt
+	if until := time.Until(reenableAt); until < 119*time.Minute || until > 2*time.Hour {
+		t.Errorf("Expected re-enable in about 2h, got %v", until)
+	}
+
+	d.checkBatteryAndAdjust()
+	if d.stateManager.GetConservationEnabled() {
+		t.Error("Expected management to stay disabled before the timer expires")
+	}
+
+	// Expire the timer as if the duration had elapsed
+	if err := d.stateManager.DisableConservationUntil(time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Failed to update state: %v", err)
+	}
+	d.checkBatteryAndAdjust()
+	if !d.stateManager.GetConservationEnabled() {
+		t.Error("Expected management to be re-enabled after the timer expired")
+	}
+}
+
+func TestPauseAutomation(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 85, ACOnline: true})
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+
+	if _, err := d.handlePause(context.Background(), map[string]interface{}{"for": "-1h"}); err == nil {
+		t.Error("Expected error for invalid duration")
+	}
+
+	response, err := d.handlePause(context.Background(), map[string]interface{}{"for": "1h"})
+	if err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if until := time.Until(response.(protocol.PauseData).PausedUntil); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("Expected a pause of about 1h, got %v", until)
+	}
+
+	// Above the threshold on AC conservation mode is left off while paused
+	d.checkBatteryAndAdjust()
+	if backend.battery.ConservationMode {
+		t.Error("Expected conservation mode to be left as it is while paused")
+	}
+	status, _ := d.handleStatus(context.Background(), nil)
+	if data := status.(protocol.StatusData); !data.Paused || data.PausedUntil.IsZero() {
+		t.Errorf("Expected the pause in the status, got paused=%v until %v", data.Paused, data.PausedUntil)
+	}
+
+	// Expire the pause as if the duration had elapsed
+	if err := d.stateManager.Pause(time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Failed to update state: %v", err)
+	}
+	d.checkBatteryAndAdjust()
+	if !backend.battery.ConservationMode {
+		t.Error("Expected conservation mode switched on once the pause expired")
+	}
+
+	// Resuming an indefinite pause switches again
+	backend.battery.ConservationMode = false
+	if _, err := d.handlePause(context.Background(), nil); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	d.checkBatteryAndAdjust()
+	if backend.battery.ConservationMode {
+		t.Error("Expected conservation mode to be left as it is while paused")
+	}
+	if _, err := d.handleResume(context.Background(), nil); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	d.checkBatteryAndAdjust()
+	if !backend.battery.ConservationMode {
+		t.Error("Expected conservation mode switched on after resume")
+	}
+}
+
+func TestSnapshotRollback(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+	path := filepath.Join(t.TempDir(), "legionbatctl.conf")
+	original := "[profiles.desk]\nthreshold = 60\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := d.LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+
+	created, err := d.handleSnapshotCreate(context.Background(), map[string]interface{}{"note": "before tuning"})
+	if err != nil {
+		t.Fatalf("snapshot_create failed: %v", err)
+	}
+	snapshot := created.(protocol.SnapshotData)
+	if snapshot.ID != 1 || snapshot.Threshold != 80 || !snapshot.Enabled || len(snapshot.Profiles) != 1 {
+		t.Errorf("Unexpected snapshot: %+v", snapshot)
+	}
+
+	// Experiment with the settings and the config file
+	if err := d.stateManager.SetChargeThresholds(90, 85); err != nil {
+		t.Fatalf("Failed to set thresholds: %v", err)
+	}
+	if err := d.stateManager.DisableConservation(); err != nil {
+		t.Fatalf("Failed to disable management: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("[profiles.travel]\nthreshold = 100\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if _, err := d.handleSnapshotRollback(context.Background(), map[string]interface{}{"id": float64(2)}); protocol.ErrorCode(err) != protocol.CodeInvalidParams {
+		t.Errorf("Expected %s for a missing snapshot, got %v", protocol.CodeInvalidParams, err)
+	}
+
+	response, err := d.handleSnapshotRollback(context.Background(), map[string]interface{}{"id": float64(1)})
+	if err != nil {
+		t.Fatalf("snapshot_rollback failed: %v", err)
+	}
+	data := response.(protocol.SnapshotRollbackData)
+	if !data.ConfigRestored || data.ConfigBackup == "" {
+		t.Errorf("Expected the config file restored with a backup, got %+v", data)
+	}
+
+	state := d.stateManager.GetState()
+	if state.ChargeThreshold != 80 || state.StartThreshold != 0 || !state.ConservationEnabled {
+		t.Errorf("Expected 80%% enabled restored, got %d%% (start %d%%) enabled=%v",
+			state.ChargeThreshold, state.StartThreshold, state.ConservationEnabled)
+	}
+	if content, _ := os.ReadFile(path); string(content) != original {
+		t.Errorf("Expected the original config file, got %q", content)
+	}
+	if _, ok := d.GetConfig().Profiles["desk"]; !ok {
+		t.Error("Expected the restored config file to be loaded")
+	}
+
+	// An unchanged config file is left alone
+	response, err = d.handleSnapshotRollback(context.Background(), map[string]interface{}{"id": float64(1)})
+	if err != nil {
+		t.Fatalf("snapshot_rollback failed: %v", err)
+	}
+	if response.(protocol.SnapshotRollbackData).ConfigRestored {
+		t.Error("Expected an unchanged config file not to be replaced")
+	}
+
+	listed, err := d.handleSnapshotList(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("snapshot_list failed: %v", err)
+	}
+	if snapshots := listed.(protocol.SnapshotListData).Snapshots; len(snapshots) != 1 || snapshots[0].Note != "before tuning" {
+		t.Errorf("Unexpected snapshots: %+v", snapshots)
+	}
+}
+
+func TestDockingOverridesThreshold(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 65, ACOnline: true})
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+
+	root := t.TempDir()
+	write := func(name, value string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(value+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	write("proc/acpi/button/lid/LID0/state", "state:      open")
+	write("sys/class/drm/card1-eDP-1/status", "connected")
+	write("sys/class/drm/card1-DP-1/status", "disconnected")
+	d.SetDockPaths(dock.DefaultPaths.WithRoot(root))
+
+	path := filepath.Join(t.TempDir(), "legionbatctl.conf")
+	content := `[profiles.desk]
+threshold = 60
+
+[profiles.clamshell]
+threshold = 55
+
+[[docking]]
+when = "clamshell"
+profile = "clamshell"
+
+[[docking]]
+when = "docked"
+profile = "desk"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := d.LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		lid       string
+		display   string
+		threshold int
+		docking   string
+	}{
+		{"undocked", "open", "disconnected", 80, ""},
+		{"docked", "open", "connected", 60, "docked"},
+		{"clamshell wins as listed first", "closed", "connected", 55, "clamshell"},
+		{"lid closed without a display", "closed", "disconnected", 80, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write("proc/acpi/button/lid/LID0/state", "state:      "+tt.lid)
+			write("sys/class/drm/card1-DP-1/status", tt.display)
+			d.applySchedule(time.Now())
+
+			status := d.statusData(false)
+			if status.Threshold != tt.threshold || status.Docking != tt.docking {
+				t.Errorf("Expected %d%% set by %q, got %d%% by %q", tt.threshold, tt.docking, status.Threshold, status.Docking)
+			}
+		})
+	}
+}
+
+func TestRulesRunActions(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 65, ACOnline: true})
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+	d.readSSID = func() (string, error) { return "Office", nil }
+
+	path := filepath.Join(t.TempDir(), "legionbatctl.conf")
+	content := `[[rules]]
+name = "office"
+ac = "ac"
+ssid = "Office"
+action = "set_threshold"
+threshold = 60
+
+[[rules]]
+name = "travel"
+ac = "battery"
+action = "disable"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := d.LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	d.checkBatteryAndAdjust()
+	if threshold := d.stateManager.GetState().ChargeThreshold; threshold != 60 {
+		t.Errorf("Expected the office rule to set 60%%, got %d%%", threshold)
+	}
+
+	backend.battery.ACOnline = false
+	d.checkBatteryAndAdjust()
+	if d.stateManager.GetConservationEnabled() {
+		t.Error("Expected the travel rule to disable management")
+	}
+
+	// A rule that still holds doesn't trigger again
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+	d.checkBatteryAndAdjust()
+	if !d.stateManager.GetConservationEnabled() {
+		t.Error("Expected the travel rule to trigger only once")
+	}
+}
+
+func TestScheduleOverridesThreshold(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 65, ACOnline: true})
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+
+	now := time.Now()
+	window, err := schedule.ParseWindow(nil, now.Add(-time.Hour).Format("15:04"), now.Add(time.Hour).Format("15:04"))
+	if err != nil {
+		t.Fatalf("Failed to parse window: %v", err)
+	}
+	d.scheduler.Store(schedule.New([]schedule.Rule{{Name: "work", Window: window, Threshold: 60}}))
+
+	d.checkBatteryAndAdjust()
+	if d.stateManager.GetEffectiveThreshold() != 60 {
+		t.Errorf("Expected scheduled threshold 60, got %d", d.stateManager.GetEffectiveThreshold())
+	}
+	if !backend.battery.ConservationMode {
+		t.Error("Expected conservation mode above the scheduled threshold")
+	}
+
+	// Pausing the schedule restores the configured threshold of 80
+	response, err := d.handleSetSchedule(context.Background(), map[string]interface{}{"paused": true})
+	if err != nil {
+		t.Fatalf("set_schedule failed: %v", err)
+	}
+	if data := response.(protocol.ScheduleData); !data.Paused || data.Active != "" {
+		t.Errorf("Expected paused schedule without active rule, got %+v", data)
+	}
+	if d.stateManager.GetEffectiveThreshold() != 80 {
+		t.Errorf("Expected configured threshold 80, got %d", d.stateManager.GetEffectiveThreshold())
+	}
+	if backend.battery.ConservationMode {
+		t.Error("Expected conservation mode off below the configured threshold")
+	}
+}
+
+// profileBackend adds a platform profile to the fake backend
+type profileBackend struct {
+	*fakeBackend
+	profile string
+}
+
+func (b *profileBackend) ReadPowerProfile() (string, []string, error) {
+	return b.profile, []string{"quiet", "balanced", "performance"}, nil
+}
+
+func (b *profileBackend) SetPowerProfile(profile string) error {
+	b.profile = profile
+	return nil
+}
+
+func TestPowerModeOverridesThreshold(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 65, ACOnline: true})
+	if _, err := d.handleGetPowerMode(context.Background(), nil); protocol.ErrorCode(err) != protocol.CodeHardwareNotSupported {
+		t.Errorf("Expected HARDWARE_NOT_SUPPORTED without a platform profile, got %v", err)
+	}
+
+	profiles := &profileBackend{fakeBackend: backend, profile: "balanced"}
+	d.SetHardware(profiles)
+	d.config.Profiles = map[string]config.ProfileConfig{"silent": {Threshold: 60}}
+	d.config.PowerModes = map[string]string{"quiet": "silent"}
+	d.powerModes.Store(powerModeOverrides(d.config))
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+
+	if _, err := d.handleSetPowerMode(context.Background(), map[string]interface{}{"mode": "turbo"}); protocol.ErrorCode(err) != protocol.CodeInvalidParams {
+		t.Errorf("Expected INVALID_PARAMS for an unsupported mode, got %v", err)
+	}
+
+	response, err := d.handleSetPowerMode(context.Background(), map[string]interface{}{"mode": "quiet"})
+	if err != nil {
+		t.Fatalf("set_power_mode failed: %v", err)
+	}
+	if data := response.(protocol.PowerModeData); data.Mode != "quiet" || data.Profile != "silent" || data.Threshold != 60 {
+		t.Errorf("Expected quiet mode coupled with the silent profile, got %+v", data)
+	}
+	if d.stateManager.GetEffectiveThreshold() != 60 || !backend.battery.ConservationMode {
+		t.Errorf("Expected conservation mode at the quiet threshold, got %d%%", d.stateManager.GetEffectiveThreshold())
+	}
+	if status := d.statusData(false); status.PowerMode != "quiet" || status.Schedule != "" {
+		t.Errorf("Expected status to report the quiet power mode, got %q (schedule %q)", status.PowerMode, status.Schedule)
+	}
+
+	// Switching outside legionbatctl, e.g. with Fn+Q, is picked up by the monitor
+	profiles.profile = "performance"
+	d.checkBatteryAndAdjust()
+	if d.stateManager.GetEffectiveThreshold() != 80 || backend.battery.ConservationMode {
+		t.Errorf("Expected the configured threshold in performance mode, got %d%%", d.stateManager.GetEffectiveThreshold())
+	}
+}
+
+func TestChargeStartTime(t *testing.T) {
+	by := time.Date(2024, 1, 2, 7, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		level int
+		rate  float64
+		want  time.Time
+	}{
+		{"learned rate", 80, 40, by.Add(-30*time.Minute - chargeFullMargin)},
+		{"default rate", 70, 0, by.Add(-time.Hour - chargeFullMargin)},
+		{"already full", 100, 40, by.Add(-chargeFullMargin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chargeStartTime(by, tt.level, tt.rate); !got.Equal(tt.want) {
+				t.Errorf("chargeStartTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduledChargeFull(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 80, ConservationMode: true, ACOnline: true})
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+
+	// A deadline far enough away keeps the battery held at the threshold
+	by := time.Now().Add(6 * time.Hour).Format(time.RFC3339)
+	if _, err := d.handleChargeFull(context.Background(), map[string]interface{}{"by": by}); err != nil {
+		t.Fatalf("charge_full failed: %v", err)
+	}
+	d.checkBatteryAndAdjust()
+	if d.stateManager.IsChargeFull() || !backend.battery.ConservationMode {
+		t.Error("Expected battery to stay held until charging must start")
+	}
+
+	// Once the deadline is close, charging starts
+	by = time.Now().Add(30 * time.Minute).Format(time.RFC3339)
+	if _, err := d.handleChargeFull(context.Background(), map[string]interface{}{"by": by}); err != nil {
+		t.Fatalf("charge_full failed: %v", err)
+	}
+	d.checkBatteryAndAdjust()
+	if !d.stateManager.IsChargeFull() || backend.battery.ConservationMode {
+		t.Error("Expected charging to start for the deadline")
+	}
+
+	if _, err := d.handleChargeFull(context.Background(), map[string]interface{}{"by": "tomorrow"}); err == nil {
+		t.Error("Expected error for invalid deadline")
+	}
+}
+
+func TestNightMode(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 80, ConservationMode: true, ACOnline: true})
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+
+	// In the night, with the wake time 6 hours away
+	now := time.Now()
+	wake := now.Add(6 * time.Hour).Truncate(time.Minute)
+	window, err := schedule.ParseWindow(nil, now.Add(-time.Hour).Format("15:04"), wake.Format("15:04"))
+	if err != nil {
+		t.Fatalf("Failed to parse window: %v", err)
+	}
+	d.scheduler.Store(schedule.New([]schedule.Rule{{Name: "night", Window: window, Night: true}}))
+
+	d.checkBatteryAndAdjust()
+	if by := d.stateManager.GetChargeFullBy(); !by.Equal(wake) {
+		t.Errorf("Expected charging to full by %v, got %v", wake, by)
+	}
+	if d.stateManager.GetEffectiveThreshold() != 80 || d.stateManager.IsChargeFull() || !backend.battery.ConservationMode {
+		t.Error("Expected the battery held at the configured threshold until charging must start")
+	}
+
+	// Once done, the same night isn't scheduled again
+	if err := d.stateManager.FinishChargeFull(); err != nil {
+		t.Fatalf("Failed to finish charge-full: %v", err)
+	}
+	d.checkBatteryAndAdjust()
+	if by := d.stateManager.GetChargeFullBy(); !by.IsZero() {
+		t.Errorf("Expected no charging scheduled again, got %v", by)
+	}
+}
+
+func TestStorageMode(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+	backend.canDischarge = true
+
+	if _, err := d.handleStorage(context.Background(), map[string]interface{}{"enable": true, "target": float64(90)}); err == nil {
+		t.Error("Expected error for storage target out of range")
+	}
+
+	response, err := d.handleStorage(context.Background(), map[string]interface{}{"enable": true})
+	if err != nil {
+		t.Fatalf("storage failed: %v", err)
+	}
+	if data := response.(protocol.StorageData); !data.Enabled || data.Target != protocol.DefaultStorageTarget {
+		t.Errorf("Expected storage mode at the default target, got %+v", data)
+	}
+	if !backend.battery.ConservationMode || !backend.forceDischarge {
+		t.Error("Expected charging stopped and force discharge above the storage target")
+	}
+
+	// At the target discharging stops while charging stays off
+	backend.battery.Level = 50
+	d.checkBatteryAndAdjust()
+	if !backend.battery.ConservationMode || backend.forceDischarge {
+		t.Error("Expected the battery to be held at the storage target")
+	}
+
+	status, err := d.handleStatus(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if data := status.(protocol.StatusData); !data.StorageMode || data.Threshold != 50 {
+		t.Errorf("Expected status to report storage mode at 50%%, got %+v", data)
+	}
+
+	if _, err := d.handleStorage(context.Background(), map[string]interface{}{"enable": false}); err != nil {
+		t.Fatalf("storage off failed: %v", err)
+	}
+	if d.stateManager.GetEffectiveThreshold() != 80 || backend.battery.ConservationMode {
+		t.Error("Expected regular management at the configured threshold after storage mode")
+	}
+}
+
+func TestStatusFreshness(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 50, ACOnline: true})
+	fresh := map[string]interface{}{"fresh": true}
+
+	status, err := d.handleStatus(context.Background(), fresh)
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if data := status.(protocol.StatusData); !data.Fresh || data.BatteryLevel != 50 {
+		t.Errorf("Expected a live reading of 50%%, got %+v", data)
+	}
+
+	// Within statusFreshness the reading is served again
+	backend.battery.Level = 51
+	status, _ = d.handleStatus(context.Background(), fresh)
+	if data := status.(protocol.StatusData); !data.Fresh || data.BatteryLevel != 50 {
+		t.Errorf("Expected the recent reading to be reused, got %+v", data)
+	}
+
+	// Past it the hardware is read again
+	d.stateManager.UpdateState(func(s *state.State) {
+		s.LastReadingTime = time.Now().Add(-statusFreshness)
+	})
+	status, _ = d.handleStatus(context.Background(), fresh)
+	if data := status.(protocol.StatusData); data.BatteryLevel != 51 {
+		t.Errorf("Expected a new reading of 51%%, got %+v", data)
+	}
+}
+
+// endThresholdBackend is a fake backend with a native end threshold
+type endThresholdBackend struct {
+	*fakeBackend
+}
+
+func (b endThresholdBackend) ThresholdRange() hardware.ThresholdRange {
+	return hardware.EndThresholdRange
+}
+
+func TestThresholdRangeFromBackend(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 50, ACOnline: true})
+	params := map[string]interface{}{"threshold": float64(50)}
+
+	// Conservation mode can't hold the charge below 60%
+	if _, err := d.handleSetThreshold(context.Background(), params); !errors.Is(err, protocol.ErrInvalidThreshold) {
+		t.Fatalf("Expected an invalid threshold error, got %v", err)
+	}
+
+	d.SetHardware(endThresholdBackend{backend})
+	if _, err := d.handleSetThreshold(context.Background(), params); err != nil {
+		t.Fatalf("Expected 50%% to be accepted with a native end threshold, got %v", err)
+	}
+	if threshold := d.stateManager.GetChargeThreshold(); threshold != 50 {
+		t.Errorf("Expected threshold 50, got %d", threshold)
+	}
+
+	params["threshold"] = float64(30)
+	_, err := d.handleSetThreshold(context.Background(), params)
+	if allowed := protocol.AllowedRange(err); allowed == nil || allowed.Min != 40 || allowed.Max != 100 {
+		t.Errorf("Expected the backend's range with the error, got %v (%v)", allowed, err)
+	}
+}
+
+func TestEndThresholdEnforced(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 45, ACOnline: true})
+
+	// Without a native end threshold the monitor only switches conservation mode
+	d.checkBatteryAndAdjust()
+	if backend.endThreshold != 0 {
+		t.Fatalf("Expected no end threshold to be set, got %d", backend.endThreshold)
+	}
+
+	d.SetHardware(endThresholdBackend{backend})
+	d.checkBatteryAndAdjust()
+	if backend.endThreshold != 0 {
+		t.Errorf("Expected the end threshold to be left alone while management is disabled, got %d", backend.endThreshold)
+	}
+
+	if _, err := d.handleEnable(context.Background(), nil); err != nil {
+		t.Fatalf("enable failed: %v", err)
+	}
+	if err := d.stateManager.SetChargeThreshold(50); err != nil {
+		t.Fatalf("Failed to set threshold: %v", err)
+	}
+	d.checkBatteryAndAdjust()
+	if backend.endThreshold != 50 {
+		t.Errorf("Expected the end threshold at 50, got %d", backend.endThreshold)
+	}
+
+	if _, err := d.handleDisable(context.Background(), nil); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	d.checkBatteryAndAdjust()
+	if backend.endThreshold != 100 {
+		t.Errorf("Expected the end threshold lifted to 100, got %d", backend.endThreshold)
+	}
+}
+
+func TestRapidCharge(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 50, ACOnline: true})
+
+	// Without the legion-laptop module rapid charge is unsupported
+	if _, err := d.handleGetRapidCharge(context.Background(), nil); protocol.ErrorCode(err) != protocol.CodeHardwareNotSupported {
+		t.Errorf("Expected HARDWARE_NOT_SUPPORTED, got %v", err)
+	}
+	if _, err := d.handleSetRapidCharge(context.Background(), map[string]interface{}{"enable": true}); protocol.ErrorCode(err) != protocol.CodeHardwareNotSupported {
+		t.Errorf("Expected HARDWARE_NOT_SUPPORTED, got %v", err)
+	}
+
+	backend.rapidCharge = new(bool)
+	if _, err := d.handleSetRapidCharge(context.Background(), map[string]interface{}{"enable": "yes"}); protocol.ErrorCode(err) != protocol.CodeInvalidParams {
+		t.Errorf("Expected INVALID_PARAMS, got %v", err)
+	}
+
+	response, err := d.handleSetRapidCharge(context.Background(), map[string]interface{}{"enable": true})
+	if err != nil {
+		t.Fatalf("set_rapid_charge failed: %v", err)
+	}
+	if data := response.(protocol.RapidChargeData); !data.Enabled || !*backend.rapidCharge {
+		t.Errorf("Expected rapid charge enabled, got %+v", data)
+	}
+
+	response, err = d.handleGetRapidCharge(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("get_rapid_charge failed: %v", err)
+	}
+	if data := response.(protocol.RapidChargeData); !data.Enabled {
+		t.Errorf("Expected rapid charge reported on, got %+v", data)
+	}
+}
+
+func TestRapidChargeWithConservation(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 85, ACOnline: true, ConservationMode: true})
+	backend.rapidCharge = new(bool)
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("EnableConservation() error = %v", err)
+	}
+
+	// Above the threshold rapid charge waits, so conservation mode keeps holding
+	response, err := d.handleSetRapidCharge(context.Background(), map[string]interface{}{"enable": true})
+	if err != nil {
+		t.Fatalf("set_rapid_charge failed: %v", err)
+	}
+	if data := response.(protocol.RapidChargeData); !data.Pending || data.Enabled {
+		t.Errorf("Expected rapid charge pending, got %+v", data)
+	}
+	if *backend.rapidCharge || !backend.battery.ConservationMode {
+		t.Error("Expected conservation mode to keep holding the charge")
+	}
+	d.checkBatteryAndAdjust()
+	if status := d.statusData(false); status.RapidCharge != "paused" {
+		t.Errorf("Expected status to report rapid charge paused, got %q", status.RapidCharge)
+	}
+
+	// Charging resumes with rapid charge
+	backend.battery.Level = 70
+	d.checkBatteryAndAdjust()
+	if backend.battery.ConservationMode || !*backend.rapidCharge {
+		t.Error("Expected rapid charge on once charging resumed")
+	}
+	if status := d.statusData(false); status.RapidCharge != "on" {
+		t.Errorf("Expected status to report rapid charge on, got %q", status.RapidCharge)
+	}
+
+	// At the threshold the firmware switches it off again
+	backend.battery.Level = 80
+	d.checkBatteryAndAdjust()
+	if !backend.battery.ConservationMode || *backend.rapidCharge {
+		t.Error("Expected conservation mode on and rapid charge off at the threshold")
+	}
+	if !d.stateManager.GetRapidCharge() {
+		t.Error("Expected rapid charge to stay wanted")
+	}
+
+	// Both can't be requested at once
+	_, err = d.handleSetLimits(context.Background(), map[string]interface{}{"conservation_mode": true, "rapid_charge": true})
+	if protocol.ErrorCode(err) != protocol.CodeInvalidParams {
+		t.Errorf("Expected INVALID_PARAMS, got %v", err)
+	}
+
+	// With management disabled, rapid charge switches conservation mode off
+	// right away and the state follows
+	if err := d.stateManager.DisableConservation(); err != nil {
+		t.Fatalf("DisableConservation() error = %v", err)
+	}
+	response, err = d.handleSetRapidCharge(context.Background(), map[string]interface{}{"enable": true})
+	if err != nil {
+		t.Fatalf("set_rapid_charge failed: %v", err)
+	}
+	if data := response.(protocol.RapidChargeData); !data.Enabled || !strings.Contains(data.Message, "conservation mode off") {
+		t.Errorf("Expected rapid charge enabled with a note, got %+v", data)
+	}
+	if d.stateManager.GetConservationMode() {
+		t.Error("Expected the state to record conservation mode off")
+	}
+}
+
+func TestCheckAdapter(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 50, ACOnline: true})
+	d.checkAdapter(true)
+	if adapter := d.statusData(false).Adapter; adapter != nil {
+		t.Errorf("Expected no adapter from a backend that can't identify it, got %+v", adapter)
+	}
+
+	mock := hardware.NewMockBackend()
+	d.SetHardware(mock)
+	d.checkAdapter(true)
+	if adapter := d.statusData(false).Adapter; adapter == nil || adapter.Type != "barrel" || adapter.Watts != 230 {
+		t.Errorf("Expected the mock's barrel adapter, got %+v", adapter)
+	}
+
+	d.checkAdapter(false)
+	if adapter := d.statusData(false).Adapter; adapter != nil {
+		t.Errorf("Expected no adapter on battery, got %+v", adapter)
+	}
+}
+
+func TestBatteryWear(t *testing.T) {
+	tests := []struct {
+		full   int
+		design int
+		want   float64
+	}{
+		{57000000, 71250000, 20},
+		{71000000, 71000000, 0},
+		{72000000, 71000000, 0}, // Above design capacity
+		{50000000, 0, 0},        // Unknown design capacity
+	}
+
+	for _, tt := range tests {
+		if got := batteryWear(tt.full, tt.design); got < tt.want-0.01 || got > tt.want+0.01 {
+			t.Errorf("batteryWear(%d, %d) = %.2f, want %.2f", tt.full, tt.design, got, tt.want)
+		}
+	}
+}
+
+func TestApplyBatteryInfo(t *testing.T) {
+	var data protocol.BatteryInfoData
+	applyBatteryInfo(&data, hardware.Info{
+		Status:      "Discharging",
+		VoltageNow:  16000000,
+		CurrentNow:  -2000000,
+		Temperature: 315,
+		Technology:  "Li-ion",
+	})
+
+	if data.Voltage != 16 || data.Current != -2 {
+		t.Errorf("Expected 16 V and -2 A, got %v V and %v A", data.Voltage, data.Current)
+	}
+	if data.Power != 32 {
+		t.Errorf("Expected power derived from voltage and current to be 32 W, got %v", data.Power)
+	}
+	if data.Temperature != 31.5 {
+		t.Errorf("Expected 31.5 °C, got %v", data.Temperature)
+	}
+	if data.Status != "Discharging" || data.Technology != "Li-ion" {
+		t.Errorf("Unexpected identification: %+v", data)
+	}
+}
+
+func TestRecordHistory(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+	d.SetHistoryPath(filepath.Join(t.TempDir(), "history.jsonl"))
+	d.openHistory()
+
+	now := time.Now()
+	d.recordSample(70, false, true, now)
+	d.recordSample(70, false, true, now.Add(time.Minute)) // Unchanged, skipped
+	d.recordSample(71, false, true, now.Add(2*time.Minute))
+	d.recordSample(71, false, true, now.Add(2*time.Minute+sampleInterval))
+
+	if err := d.stateManager.EnableConservation(); err != nil {
+		t.Fatalf("Failed to enable management: %v", err)
+	}
+	backend.battery.Level = 80
+	d.checkBatteryAndAdjust() // Reaches the threshold and switches conservation on
+
+	response, err := d.handleHistory(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	entries := response.(protocol.HistoryData).Entries
+
+	var samples, toggles int
+	for _, entry := range entries {
+		switch entry.Event {
+		case history.EventSample:
+			samples++
+		case history.EventConservationOn:
+			toggles++
+		}
+	}
+	if samples != 4 || toggles != 1 {
+		t.Errorf("Expected 4 samples and 1 toggle, got %d and %d: %+v", samples, toggles, entries)
+	}
+
+	if _, err := d.handleHistory(context.Background(), map[string]interface{}{"since": "yesterday"}); err == nil {
+		t.Error("Expected error for invalid since")
+	}
+}
+
+// healthBackend is a fake backend reporting battery health
+type healthBackend struct {
+	*fakeBackend
+	full int
+}
+
+func (b *healthBackend) ReadHealth() (hardware.Health, error) {
+	return hardware.Health{Full: b.full, FullDesign: 100000, Unit: "µWh"}, nil
+}
+
+func TestHealthTrend(t *testing.T) {
+	d, fake := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+	backend := &healthBackend{fakeBackend: fake, full: 95000}
+	d.SetHardware(backend)
+	d.SetHistoryPath(filepath.Join(t.TempDir(), "history.jsonl"))
+	d.openHistory()
+
+	// Readings every 6 hours for 4 weeks, losing 0.1% of the design capacity
+	// a day; one a day is recorded
+	start := time.Now().AddDate(0, 0, -28)
+	for hour := 0; hour < 28*24; hour += 6 {
+		backend.full = 95000 - hour/24*100
+		d.recordCapacity(70, false, true, start.Add(time.Duration(hour)*time.Hour))
+	}
+
+	// A restarted daemon continues from the last recorded reading
+	d.lastCapacity = time.Time{}
+	d.recordCapacity(70, false, true, start.Add(27*24*time.Hour+18*time.Hour))
+
+	entries, _ := d.history.Query(time.Time{}, time.Time{})
+	if len(entries) != 28 {
+		t.Errorf("Expected 28 daily capacity readings, got %d", len(entries))
+	}
+
+	response, err := d.handleHealth(context.Background(), map[string]interface{}{"trend": true})
+	if err != nil {
+		t.Fatalf("health failed: %v", err)
+	}
+	trend := response.(protocol.HealthData).Trend
+	if trend == nil || len(trend.Points) != 4 {
+		t.Fatalf("Expected a trend with 4 weekly points, got %+v", trend)
+	}
+	if trend.FadePerMonth < 2.9 || trend.FadePerMonth > 3.1 {
+		t.Errorf("Expected a fade of 3%% a month, got %v", trend.FadePerMonth)
+	}
+	if trend.WarningAt.IsZero() {
+		t.Error("Expected a projection of when the wear reaches the warning")
+	}
+
+	response, _ = d.handleHealth(context.Background(), nil)
+	if response.(protocol.HealthData).Trend != nil {
+		t.Error("Expected no trend without the trend param")
+	}
+}
+
+func TestAdaptiveThreshold(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 80, ACOnline: true})
+	d.SetHistoryPath(filepath.Join(t.TempDir(), "history.jsonl"))
+	d.openHistory()
+	cfg := config.Default().Adaptive
+
+	// Docked for three days, too little history
+	now := time.Now()
+	start := now.AddDate(0, 0, -14)
+	record := func(at time.Time, level int, ac bool) {
+		d.appendHistory(history.Entry{Time: at, Event: history.EventSample, Level: level, ACConnected: ac})
+	}
+	for day := 0; day < 3; day++ {
+		record(start.AddDate(0, 0, day), 80, true)
+	}
+	data, err := d.adaptiveData(cfg, start.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatalf("adaptiveData failed: %v", err)
+	}
+	if data.Suggested != 0 || !strings.Contains(data.Reason, "3 of 7 days") {
+		t.Errorf("Expected no threshold from 3 days, got %+v", data)
+	}
+
+	// Docked for a week: the lowest threshold
+	for day := 3; day < 7; day++ {
+		record(start.AddDate(0, 0, day), 80, true)
+	}
+	if data, _ := d.adaptiveData(cfg, start.AddDate(0, 0, 7)); data.Suggested != 60 {
+		t.Errorf("Expected 60%% for a docked machine, got %+v", data)
+	}
+
+	// Then discharged to 20% twice a day, once down to 5%, and unplugged
+	// briefly once a day
+	for day := 7; day < 14; day++ {
+		at := start.AddDate(0, 0, day)
+		low := 20
+		if day == 10 {
+			low = 5
+		}
+		record(at, 80, true)
+		record(at.Add(time.Hour), 78, false)
+		record(at.Add(4*time.Hour), 20, false)
+		record(at.Add(5*time.Hour), 20, true)
+		record(at.Add(8*time.Hour), 79, false)
+		record(at.Add(9*time.Hour), 78, true)
+		record(at.Add(12*time.Hour), 80, true)
+		record(at.Add(13*time.Hour), 78, false)
+		record(at.Add(16*time.Hour), low, false)
+		record(at.Add(17*time.Hour), low, true)
+	}
+	data, _ = d.adaptiveData(cfg, now)
+	if data.Discharges != 14 || data.TypicalDepth != 60 || data.Suggested != 75 {
+		t.Errorf("Expected 14 discharges 60%% deep and 75%%, got %+v", data)
+	}
+
+	// Suggested in the status, but not applied
+	cfg.Mode = "suggest"
+	d.adaptive.Store(&cfg)
+	d.checkAdaptive(now)
+	if status := d.statusData(false); status.SuggestedThreshold != 75 || status.Threshold != 80 {
+		t.Errorf("Expected 75%% suggested, got %d%% (threshold %d%%)", status.SuggestedThreshold, status.Threshold)
+	}
+
+	// Applied once a day
+	d.adaptive.Store(&config.AdaptiveConfig{Mode: "apply", MinThreshold: 60, MaxThreshold: 90, Days: 14})
+	d.checkAdaptive(now.Add(time.Hour))
+	if threshold := d.stateManager.GetChargeThreshold(); threshold != 80 {
+		t.Errorf("Expected the threshold kept within the day, got %d%%", threshold)
+	}
+	d.checkAdaptive(now.Add(adaptiveInterval))
+	if threshold := d.stateManager.GetChargeThreshold(); threshold != 75 {
+		t.Errorf("Expected the learned threshold applied, got %d%%", threshold)
+	}
+	if status := d.statusData(false); status.SuggestedThreshold != 0 {
+		t.Errorf("Expected no suggestion once applied, got %d%%", status.SuggestedThreshold)
+	}
+}
+
+// eventRecorder collects events handed to it by the dispatcher
+type eventRecorder struct {
+	types chan events.Type
+}
+
+func (r *eventRecorder) Name() string { return "recorder" }
+
+func (r *eventRecorder) Handle(event events.Event) error {
+	r.types <- event.Type
+	return nil
+}
+
+func TestMonitorEmitsEvents(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 79, ACOnline: true})
+	recorder := &eventRecorder{types: make(chan events.Type, 20)}
+	d.events.SetHandlers([]events.Handler{recorder})
+
+	done := make(chan bool)
+	defer close(done)
+	go d.events.Run(done)
+
+	if _, err := d.handleEnable(context.Background(), nil); err != nil {
+		t.Fatalf("enable failed: %v", err)
+	}
+	backend.battery.Level = 80
+	d.checkBatteryAndAdjust()
+
+	// The first hold after AC is plugged in pauses the charge, later ones
+	// reach the threshold
+	backend.battery.Level = 70
+	d.checkBatteryAndAdjust()
+	backend.battery.Level = 80
+	d.checkBatteryAndAdjust()
+	backend.battery.ACOnline = false
+	backend.battery.Level = 70
+	d.checkBatteryAndAdjust()
+	backend.battery.ACOnline = true
+	d.checkBatteryAndAdjust()
+	backend.battery.Level = 80
+	d.checkBatteryAndAdjust()
+
+	expected := []events.Type{
+		events.ManagementEnabled, events.ConservationOn, events.ChargePaused,
+		events.ConservationOff, events.ConservationOn, events.ThresholdReached,
+		events.ConservationOff, events.ConservationOn, events.ChargePaused,
+	}
+	for i, eventType := range expected {
+		select {
+		case got := <-recorder.types:
+			if got != eventType {
+				t.Errorf("Event %d = %s, want %s", i, got, eventType)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Event %d (%s) was not delivered", i, eventType)
+		}
+	}
+	select {
+	case got := <-recorder.types:
+		t.Errorf("Unexpected event %s", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestCheckAlerts(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 50})
+	d.config.Alerts.Low = 15
+	d.config.Alerts.Critical = 5
+	recorder := &eventRecorder{types: make(chan events.Type, 10)}
+	d.events.SetHandlers([]events.Handler{recorder})
+
+	done := make(chan bool)
+	defer close(done)
+	go d.events.Run(done)
+
+	// Each alert is raised once per discharge, until AC is plugged in
+	readings := []struct {
+		level    int
+		acOnline bool
+	}{
+		{20, false}, {15, false}, {14, false}, {5, false}, {4, false}, {6, false},
+		{6, true}, {3, false}, {50, true}, {10, false}, {9, false},
+	}
+	for _, reading := range readings {
+		d.checkAlerts(reading.level, reading.acOnline)
+	}
+
+	expected := []events.Type{events.BatteryLow, events.BatteryCritical, events.BatteryCritical, events.BatteryLow}
+	for i, eventType := range expected {
+		select {
+		case got := <-recorder.types:
+			if got != eventType {
+				t.Errorf("Event %d = %s, want %s", i, got, eventType)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Event %d (%s) was not delivered", i, eventType)
+		}
+	}
+	select {
+	case got := <-recorder.types:
+		t.Errorf("Unexpected event %s", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestCheckDrainOnAC(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 50, ACOnline: true})
+	recorder := &eventRecorder{types: make(chan events.Type, 10)}
+	d.events.SetHandlers([]events.Handler{recorder})
+
+	done := make(chan bool)
+	defer close(done)
+	go d.events.Run(done)
+
+	// A rise starts over, and the event is raised once per stretch
+	for _, level := range []int{60, 59, 58, 59, 58, 58, 57, 56, 55, 54} {
+		d.checkDrainOnAC(level, true)
+	}
+
+	select {
+	case got := <-recorder.types:
+		if got != events.DischargingOnAC {
+			t.Errorf("Event = %s, want %s", got, events.DischargingOnAC)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("discharging-on-ac was not delivered")
+	}
+	select {
+	case got := <-recorder.types:
+		t.Errorf("Unexpected event %s", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Unplugged or force discharging, falling is expected
+	for _, level := range []int{54, 53, 52, 51} {
+		d.checkDrainOnAC(level, false)
+	}
+	d.forceDischarging.Store(true)
+	for _, level := range []int{50, 49, 48, 47} {
+		d.checkDrainOnAC(level, true)
+	}
+	if d.drain != nil {
+		t.Errorf("Drain tracked while expected: %+v", d.drain)
+	}
+}
+
+func TestRecordChargePower(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 50, ACOnline: true})
+	recorder := &eventRecorder{types: make(chan events.Type, 10)}
+	d.events.SetHandlers([]events.Handler{recorder})
+
+	done := make(chan bool)
+	defer close(done)
+	go d.events.Run(done)
+
+	record := func(watts float64) {
+		for i := 0; i < chargePowerSamples; i++ {
+			d.recordChargePower(watts)
+		}
+	}
+
+	// The first averages are learned as typical
+	record(60)
+	record(64)
+	if typical := d.stateManager.GetChargePower(); typical < 60 || typical > 64 {
+		t.Fatalf("Typical charge power = %v, want between 60 and 64", typical)
+	}
+
+	// Far below typical is reported once and not learned
+	typical := d.stateManager.GetChargePower()
+	record(20)
+	record(22)
+	status := d.statusData(false)
+	if !status.LowChargePower || status.ChargePower != 22 {
+		t.Errorf("Status low charge power = %v at %v W, want true at 22 W", status.LowChargePower, status.ChargePower)
+	}
+	if d.stateManager.GetChargePower() != typical {
+		t.Errorf("Low charge power was learned: %v", d.stateManager.GetChargePower())
+	}
+
+	select {
+	case got := <-recorder.types:
+		if got != events.LowChargePower {
+			t.Errorf("Event = %s, want %s", got, events.LowChargePower)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("low-charge-power was not delivered")
+	}
+	select {
+	case got := <-recorder.types:
+		t.Errorf("Unexpected event %s", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Unplugging clears it
+	d.checkChargePower(50, false, false)
+	if d.statusData(false).LowChargePower {
+		t.Error("Low charge power still reported after unplugging")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+	d.config.Access.Groups = []string{"wheel", "missing"}
+
+	lookupUser, lookupGroup := userGroupIDs, groupID
+	t.Cleanup(func() {
+		userGroupIDs, groupID = lookupUser, lookupGroup
+	})
+
+	// UID 1000 is in wheel (10) through a supplementary group
+	userGroupIDs = func(uid int) ([]string, error) {
+		if uid == 1000 {
+			return []string{"1000", "10"}, nil
+		}
+		return []string{strconv.Itoa(uid)}, nil
+	}
+	groupID = func(name string) (string, error) {
+		if name == "wheel" {
+			return "10", nil
+		}
+		return "", fmt.Errorf("unknown group %s", name)
+	}
+
+	tests := []struct {
+		name    string
+		peer    caller
+		command string
+		allowed bool
+	}{
+		{"root", caller{UID: 0, Known: true}, protocol.CmdSetThreshold, true},
+		{"group member", caller{UID: 1000, GID: 1000, Known: true}, protocol.CmdDisable, true},
+		{"primary group", caller{UID: 1001, GID: 10, Known: true}, protocol.CmdEnable, true},
+		{"other user", caller{UID: 1002, GID: 1002, Known: true}, protocol.CmdEnable, false},
+		{"other user reading", caller{UID: 1002, GID: 1002, Known: true}, protocol.CmdStatus, true},
+		{"unknown caller", caller{}, protocol.CmdStorage, false},
+		{"unknown caller reading", caller{}, protocol.CmdHistory, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := d.authorize(tt.peer, tt.command)
+			if tt.allowed && err != nil {
+				t.Errorf("Expected %s to be allowed, got %v", tt.command, err)
+			}
+			if !tt.allowed && protocol.ErrorCode(err) != protocol.CodePermissionDenied {
+				t.Errorf("Expected %s to be denied, got %v", tt.command, err)
+			}
+		})
+	}
+
+	// Denials tell how to get access through the first existing group
+	err := d.authorize(caller{UID: 1002, GID: 1002, Known: true}, protocol.CmdEnable)
+	if hint := protocol.ErrorHint(err); !strings.Contains(hint, "sudo usermod -aG wheel") {
+		t.Errorf("Expected a hint to join wheel, got %q", hint)
+	}
+}
+
+func TestPeerCaller(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "peer.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	client, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Failed to accept: %v", err)
+	}
+	defer conn.Close()
+
+	peer, err := peerCaller(conn)
+	if err != nil {
+		t.Fatalf("peerCaller failed: %v", err)
+	}
+	if !peer.Known || peer.UID != os.Getuid() || peer.PID != os.Getpid() {
+		t.Errorf("Expected own credentials, got %+v", peer)
+	}
+}
+
+func TestSleepInhibitor(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+
+	// Stands in for systemd-inhibit: runs the command after the options,
+	// recording that the inhibitor was held until it exits
+	dir := t.TempDir()
+	held := filepath.Join(dir, "held")
+	script := filepath.Join(dir, "systemd-inhibit")
+	content := "#!/bin/sh\nwhile [ \"${1#--}\" != \"$1\" ]; do shift; done\ntouch " + held + "\n\"$@\"\nrm " + held + "\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	d.inhibitor = &sleepInhibitor{path: script}
+
+	release := d.inhibitSleep("test")
+	if _, err := os.Stat(held); err != nil {
+		t.Fatalf("Expected the inhibitor held: %v", err)
+	}
+	release()
+	if _, err := os.Stat(held); !os.IsNotExist(err) {
+		t.Errorf("Expected the inhibitor released, got %v", err)
+	}
+
+	// Without logind the write goes ahead unprotected
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'Failed to connect to bus' >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	if _, err := d.inhibitor.hold("test"); err == nil || !strings.Contains(err.Error(), "Failed to connect to bus") {
+		t.Errorf("Expected the systemd-inhibit error, got %v", err)
+	}
+	d.inhibitSleep("test")()
+}
+
+func TestHardwareWriter(t *testing.T) {
+	cooldown := 100 * time.Millisecond
+	w := newHardwareWriter(cooldown)
+
+	var writes []bool
+	switchTo := func(enable bool) error {
+		return w.submit(hardwareWrite{
+			apply: func() error { writes = append(writes, enable); return nil },
+			mode:  &enable,
+		})
+	}
+
+	if err := switchTo(true); err != nil {
+		t.Fatalf("submit() error = %v", err)
+	}
+	// Repeating the last switch within the cooldown is skipped
+	if err := switchTo(true); err != nil {
+		t.Fatalf("submit() error = %v", err)
+	}
+	if len(writes) != 1 {
+		t.Errorf("Expected the repeated switch to be skipped, got writes %v", writes)
+	}
+
+	// Reversing it waits for the cooldown
+	start := time.Now()
+	if err := switchTo(false); err != nil {
+		t.Fatalf("submit() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < cooldown/2 {
+		t.Errorf("Expected the reversal to wait for the cooldown, took %v", elapsed)
+	}
+
+	// Other writes may change the mode, so the next switch is not skipped
+	if err := w.submit(hardwareWrite{apply: func() error { return nil }}); err != nil {
+		t.Fatalf("submit() error = %v", err)
+	}
+	if err := switchTo(false); err != nil {
+		t.Fatalf("submit() error = %v", err)
+	}
+	if len(writes) != 3 {
+		t.Errorf("Expected 3 conservation mode writes, got %v", writes)
+	}
+
+	w.close()
+	if err := switchTo(true); !errors.Is(err, errWriterStopped) {
+		t.Errorf("submit() after close error = %v, want %v", err, errWriterStopped)
+	}
+}
+
+func TestControlCommandsRequestCheck(t *testing.T) {
+	root := caller{UID: 0, Known: true}
+	tests := []struct {
+		name    string
+		command string
+		params  map[string]interface{}
+		want    bool
+	}{
+		{"set threshold", protocol.CmdSetThreshold, map[string]interface{}{"threshold": 85.0}, true},
+		{"enable", protocol.CmdEnable, nil, true},
+		{"dry run", protocol.CmdSetThreshold, map[string]interface{}{"threshold": 85.0, "dry_run": true}, false},
+		{"invalid threshold", protocol.CmdSetThreshold, map[string]interface{}{"threshold": 10.0}, false},
+		{"status", protocol.CmdStatus, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+
+			d.processRequest(context.Background(), root, protocol.NewRequest(tt.command, tt.params))
+
+			requested := false
+			select {
+			case <-d.recheck:
+				requested = true
+			default:
+			}
+			if requested != tt.want {
+				t.Errorf("Check requested = %v, want %v", requested, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestLogsCarryIDs(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 85, ACOnline: true})
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	if err := d.logger.Configure(config.LoggingConfig{Sinks: []config.SinkConfig{{Type: "file", Path: path, Level: "debug"}}}); err != nil {
+		t.Fatalf("Failed to configure logging: %v", err)
+	}
+
+	request := protocol.NewRequest(protocol.CmdDisable, map[string]interface{}{"for": "2h"})
+	d.processRequest(d.connectionContext(), caller{UID: 0, Known: true}, request)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	// Including lines logged by the handler, not only the request framing
+	var handlerLogged bool
+	for _, line := range lines {
+		if !strings.Contains(line, "conn=1") || !strings.Contains(line, "request_id="+request.ID) {
+			t.Errorf("Expected the connection and request IDs on %q", line)
+		}
+		handlerLogged = handlerLogged || strings.Contains(line, "Battery management disabled temporarily")
+	}
+	if !handlerLogged {
+		t.Errorf("Expected the handler's lines in the log, got %q", lines)
+	}
+}
+
+func TestAuditControlCommands(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+	d.config.Access.Groups = nil
+
+	root := caller{UID: 0, PID: 100, Known: true}
+	other := caller{UID: 1002, GID: 1002, PID: 200, Known: true}
+	requests := []struct {
+		peer    caller
+		command string
+		params  map[string]interface{}
+	}{
+		{root, protocol.CmdEnable, nil},
+		{root, protocol.CmdSetThreshold, map[string]interface{}{"threshold": float64(85)}},
+		{other, protocol.CmdDisable, nil},
+		{other, protocol.CmdStatus, nil},
+	}
+	for _, req := range requests {
+		d.processRequest(context.Background(), req.peer, protocol.NewRequest(req.command, req.params))
+	}
+
+	response := d.processRequest(context.Background(), other, protocol.NewRequest(protocol.CmdAudit, nil))
+	data, ok := response.GetResponse().Data.(protocol.AuditData)
+	if !ok {
+		t.Fatalf("Unexpected audit response: %+v", response.GetResponse())
+	}
+
+	want := []protocol.AuditEntry{
+		{UID: 0, PID: 100, Command: protocol.CmdEnable, Old: "disabled", New: "enabled"},
+		{UID: 0, PID: 100, Command: protocol.CmdSetThreshold, Old: "80%", New: "85%"},
+		{UID: 1002, PID: 200, Command: protocol.CmdDisable, Old: "enabled", New: "enabled"},
+	}
+	if len(data.Entries) != len(want) {
+		t.Fatalf("Expected %d audit entries, got %+v", len(want), data.Entries)
+	}
+	for i, entry := range data.Entries {
+		denied := i == 2
+		if entry.UID != want[i].UID || entry.PID != want[i].PID || entry.Command != want[i].Command ||
+			entry.Old != want[i].Old || entry.New != want[i].New || (entry.Error != "") != denied {
+			t.Errorf("Entry %d = %+v, want %+v", i, entry, want[i])
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 85, ACOnline: true})
+	if err := d.stateManager.UpdateBatteryInfo(85, false, true); err != nil {
+		t.Fatalf("Failed to update battery info: %v", err)
+	}
+
+	root := caller{UID: 0, Known: true}
+	tests := []struct {
+		name        string
+		command     string
+		params      map[string]interface{}
+		wantChanges []string
+		wantAfter   bool
+	}{
+		{"enable", protocol.CmdEnable, nil, []string{"battery management: disabled → enabled"}, true},
+		{"disable", protocol.CmdDisable, nil, nil, false},
+		{"threshold", protocol.CmdSetThreshold, map[string]interface{}{"threshold": float64(90), "start_threshold": float64(70)},
+			[]string{"threshold: 80% → 90% (start 70%)"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]interface{}{"dry_run": true}
+			for key, value := range tt.params {
+				params[key] = value
+			}
+
+			response := d.processRequest(context.Background(), root, protocol.NewRequest(tt.command, params))
+			plan, ok := response.GetResponse().Data.(protocol.PlanData)
+			if !ok {
+				t.Fatalf("Unexpected response: %+v", response.GetResponse())
+			}
+			if strings.Join(plan.Changes, "; ") != strings.Join(tt.wantChanges, "; ") {
+				t.Errorf("Changes = %q, want %q", plan.Changes, tt.wantChanges)
+			}
+			if plan.ConservationMode || plan.ConservationAfter != tt.wantAfter {
+				t.Errorf("Conservation mode %v → %v, want false → %v",
+					plan.ConservationMode, plan.ConservationAfter, tt.wantAfter)
+			}
+		})
+	}
+
+	// Nothing was changed or audited
+	if d.stateManager.GetConservationEnabled() || d.stateManager.GetChargeThreshold() != 80 {
+		t.Errorf("Dry run changed the state: %+v", d.stateManager.GetState())
+	}
+	if backend.battery.ConservationMode {
+		t.Error("Dry run switched conservation mode")
+	}
+	entries, err := d.auditLog.Query(time.Time{}, time.Now())
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Expected no audit entries, got %+v (error %v)", entries, err)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name         string
+		battery      hardware.Battery
+		enabled      bool
+		wantStep     string
+		wantExpected *bool
+	}{
+		{"on battery", hardware.Battery{Level: 90}, true, "On battery power", nil},
+		{"management disabled", hardware.Battery{Level: 90, ACOnline: true}, false, "Battery management is disabled", nil},
+		{"above threshold", hardware.Battery{Level: 85, ACOnline: true}, true, "the next check switches it on", boolPtr(true)},
+		{"held", hardware.Battery{Level: 85, ACOnline: true, ConservationMode: true}, true, "is on as expected", nil},
+		{"hysteresis band", hardware.Battery{Level: 78, ACOnline: true}, true, "between 75% (the threshold minus the 5% hysteresis)", nil},
+		{"below start", hardware.Battery{Level: 60, ACOnline: true, ConservationMode: true}, true, "the next check switches it off", boolPtr(false)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, _ := newTestDaemon(t, tt.battery)
+			d.config.Management.Hysteresis = 5
+			d.stateManager.SetHysteresis(5)
+			if tt.enabled {
+				if err := d.stateManager.EnableConservation(); err != nil {
+					t.Fatalf("Failed to enable: %v", err)
+				}
+			}
+			if err := d.stateManager.UpdateBatteryInfo(tt.battery.Level, tt.battery.ConservationMode, tt.battery.ACOnline); err != nil {
+				t.Fatalf("Failed to update battery info: %v", err)
+			}
+
+			data, err := d.handleExplain(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("handleExplain() error = %v", err)
+			}
+			explain := data.(protocol.ExplainData)
+
+			steps := strings.Join(explain.Steps, "\n")
+			if !strings.Contains(steps, tt.wantStep) {
+				t.Errorf("Steps = %q, want one containing %q", steps, tt.wantStep)
+			}
+			if (explain.ExpectedMode == nil) != (tt.wantExpected == nil) ||
+				(explain.ExpectedMode != nil && *explain.ExpectedMode != *tt.wantExpected) {
+				t.Errorf("ExpectedMode = %v, want %v", explain.ExpectedMode, tt.wantExpected)
+			}
+		})
+	}
+}
+
+func TestHandleLogs(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 50})
+	for i := 1; i <= 3; i++ {
+		d.logger.Info("Check", "n", i)
+	}
+
+	data, err := d.handleLogs(context.Background(), map[string]interface{}{"lines": float64(2)})
+	if err != nil {
+		t.Fatalf("handleLogs() error = %v", err)
+	}
+	logs := data.(protocol.LogsData)
+	if len(logs.Lines) != 2 || !strings.Contains(logs.Lines[1].Text, "n=3") {
+		t.Fatalf("Expected the last 2 lines, got %+v", logs.Lines)
+	}
+
+	// Following picks up only the lines logged since
+	d.logger.Info("Check", "n", 4)
+	data, err = d.handleLogs(context.Background(), map[string]interface{}{"since": float64(logs.Lines[1].Seq)})
+	if err != nil {
+		t.Fatalf("handleLogs() error = %v", err)
+	}
+	if logs := data.(protocol.LogsData); len(logs.Lines) != 1 || !strings.Contains(logs.Lines[0].Text, "n=4") {
+		t.Errorf("Expected the new line only, got %+v", logs.Lines)
+	}
+
+	if _, err := d.handleLogs(context.Background(), map[string]interface{}{"since": "yesterday"}); protocol.ErrorCode(err) != protocol.CodeInvalidParams {
+		t.Errorf("Expected an invalid params error, got %v", err)
+	}
+}
+
+func TestHandleSetLogLevel(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 50})
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	d.config.Logging = config.LoggingConfig{Sinks: []config.SinkConfig{{Type: "file", Path: path, Level: "info"}}}
+
+	if _, err := d.handleSetLogLevel(context.Background(), map[string]interface{}{"level": "debug"}); err != nil {
+		t.Fatalf("handleSetLogLevel() error = %v", err)
+	}
+	d.logger.Debug("Visible at debug")
+
+	if _, err := d.handleSetLogLevel(context.Background(), map[string]interface{}{"level": ""}); err != nil {
+		t.Fatalf("handleSetLogLevel() error = %v", err)
+	}
+	d.logger.Debug("Hidden at the configured level")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	if !strings.Contains(string(data), "Visible at debug") || strings.Contains(string(data), "Hidden") {
+		t.Errorf("Expected debug records only while overridden, got: %s", data)
+	}
+
+	if _, err := d.handleSetLogLevel(context.Background(), map[string]interface{}{"level": "trace"}); protocol.ErrorCode(err) != protocol.CodeInvalidParams {
+		t.Errorf("Expected an invalid params error, got %v", err)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	// A burst of 3 is allowed, the 4th request has to wait for a refill
+	for i := 0; i < 3; i++ {
+		if !limiter.allow(1000, now, 2, 3) {
+			t.Fatalf("Expected request %d within the burst to be allowed", i+1)
+		}
+	}
+	if limiter.allow(1000, now, 2, 3) {
+		t.Error("Expected request beyond the burst to be limited")
+	}
+
+	// Other users have their own bucket
+	if !limiter.allow(0, now, 2, 3) {
+		t.Error("Expected another user to be allowed")
+	}
+
+	// At 2 per second, one token is back after half a second
+	now = now.Add(500 * time.Millisecond)
+	if !limiter.allow(1000, now, 2, 3) || limiter.allow(1000, now, 2, 3) {
+		t.Error("Expected exactly one request after a partial refill")
+	}
+
+	// A rate of 0 disables limiting
+	if !limiter.allow(1000, now, 0, 0) {
+		t.Error("Expected no limit with rate 0")
+	}
+}
+
+func TestHelloSwitchesFraming(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+	d.SetHistoryPath(filepath.Join(t.TempDir(), "history.jsonl"))
+	if err := d.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	defer d.Stop()
+
+	conn, err := net.Dial("unix", d.GetSocketPath())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	codec := protocol.NewCodec(conn)
+
+	if _, err := codec.SendRequest(protocol.CmdHello, map[string]interface{}{"framing": protocol.FramingLength}); err != nil {
+		t.Fatalf("Failed to send hello: %v", err)
+	}
+	msg, err := codec.ReceiveMessage()
+	if err != nil || !msg.GetResponse().Success {
+		t.Fatalf("Hello failed: %v, %+v", err, msg)
+	}
+	codec.SetFraming(protocol.FramingLength)
+
+	if _, err := codec.SendRequest(protocol.CmdStatus, nil); err != nil {
+		t.Fatalf("Failed to send status: %v", err)
+	}
+	msg, err = codec.ReceiveMessage()
+	if err != nil || !msg.GetResponse().Success {
+		t.Fatalf("Status over length framing failed: %v, %+v", err, msg)
+	}
+}
+
+func TestVarlinkCall(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+
+	result, err := d.handleVarlinkCall(nil, "GetStatus", nil)
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if status, ok := result.(map[string]interface{})["status"].(protocol.StatusData); !ok || status.Threshold != 80 {
+		t.Errorf("Unexpected GetStatus result: %+v", result)
+	}
+
+	// Without peer credentials the caller may only read
+	_, err = d.handleVarlinkCall(nil, "Enable", nil)
+	var varlinkErr *varlink.Error
+	if !errors.As(err, &varlinkErr) || varlinkErr.Parameters["code"] != protocol.CodePermissionDenied {
+		t.Errorf("Expected Enable to be denied, got %v", err)
+	}
+
+	if _, err := d.handleVarlinkCall(nil, "Reboot", nil); !errors.As(err, &varlinkErr) || varlinkErr.Name != varlink.ErrMethodNotFound {
+		t.Errorf("Expected %s, got %v", varlink.ErrMethodNotFound, err)
+	}
+}
+
+func TestHTTPAPI(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+	d.config.HTTP.Token = "secret"
+	server := httptest.NewServer(d.httpHandler())
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		token      string
+		wantStatus int
+	}{
+		{"status", http.MethodGet, "/status?fresh=1", "", "", http.StatusOK},
+		{"wrong method", http.MethodPost, "/status", "", "", http.StatusMethodNotAllowed},
+		{"change without token", http.MethodPost, "/threshold", `{"threshold": 85}`, "", http.StatusUnauthorized},
+		{"change with wrong token", http.MethodPost, "/threshold", `{"threshold": 85}`, "guess", http.StatusUnauthorized},
+		{"change with token", http.MethodPost, "/threshold", `{"threshold": 85}`, "secret", http.StatusOK},
+		{"invalid threshold", http.MethodPost, "/threshold", `{"threshold": 20}`, "secret", http.StatusBadRequest},
+		{"malformed body", http.MethodPost, "/enable", `[1, 2]`, "secret", http.StatusBadRequest},
+		{"enable", http.MethodPost, "/enable", "", "secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+
+	if threshold := d.stateManager.GetChargeThreshold(); threshold != 85 {
+		t.Errorf("Expected threshold 85 after the authorized change, got %d", threshold)
+	}
+}
+
+func TestGRPCAPI(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+	socketPath := filepath.Join(t.TempDir(), "grpc.sock")
+	if err := d.startGRPC(socketPath); err != nil {
+		t.Fatalf("startGRPC failed: %v", err)
+	}
+	defer d.grpcServer.Stop()
+
+	done := make(chan bool)
+	defer close(done)
+	go d.events.Run(done)
+
+	conn, err := grpc.NewClient("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewBatteryServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	current, err := client.GetStatus(ctx, &pb.GetStatusRequest{})
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if current.GetThreshold() != 80 || current.GetBatteryLevel() != 70 {
+		t.Errorf("Unexpected status: %v", current)
+	}
+
+	// The test runs the daemon, so its caller may change settings
+	response, err := client.SetThreshold(ctx, &pb.SetThresholdRequest{Threshold: 85})
+	if err != nil {
+		t.Fatalf("SetThreshold failed: %v", err)
+	}
+	if response.GetThreshold() != 85 || d.stateManager.GetChargeThreshold() != 85 {
+		t.Errorf("Expected threshold 85, got %v", response)
+	}
+
+	if _, err := client.SetThreshold(ctx, &pb.SetThresholdRequest{Threshold: 20}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for threshold 20, got %v", err)
+	}
+
+	stream, err := client.WatchEvents(ctx, &pb.WatchEventsRequest{Events: []string{string(events.ChargePaused)}})
+	if err != nil {
+		t.Fatalf("WatchEvents failed: %v", err)
+	}
+	// The stream subscribes asynchronously, so emit until an event arrives
+	go func() {
+		for ctx.Err() == nil {
+			d.events.Emit(events.Event{Type: events.ThresholdReached})
+			d.events.Emit(events.Event{Type: events.ChargePaused, BatteryLevel: 85})
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if event.GetEvent() != string(events.ChargePaused) || event.GetBatteryLevel() != 85 {
+		t.Errorf("Unexpected event: %v", event)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	root := caller{UID: 0, Known: true}
+	other := caller{UID: 1002, GID: 1002, Known: true}
+	item := func(command string, params map[string]interface{}) interface{} {
+		return map[string]interface{}{"command": command, "params": params}
+	}
+
+	tests := []struct {
+		name      string
+		peer      caller
+		requests  []interface{}
+		code      string // Expected error code of the whole batch, if rejected
+		results   []string
+		threshold int
+		enabled   bool
+	}{
+		{
+			name: "applies every command",
+			peer: root,
+			requests: []interface{}{
+				item(protocol.CmdSetThreshold, map[string]interface{}{"threshold": float64(85)}),
+				item(protocol.CmdEnable, nil),
+				item(protocol.CmdStatus, nil),
+			},
+			results:   []string{"ok", "ok", "ok"},
+			threshold: 85,
+			enabled:   true,
+		},
+		{
+			name: "skips the commands after a failure",
+			peer: root,
+			requests: []interface{}{
+				item(protocol.CmdSetThreshold, map[string]interface{}{"threshold": float64(30)}),
+				item(protocol.CmdEnable, nil),
+			},
+			results:   []string{protocol.CodeInvalidThreshold, "skipped"},
+			threshold: 80,
+		},
+		{
+			name: "rolls back the commands before a failure",
+			peer: root,
+			requests: []interface{}{
+				item(protocol.CmdSetThreshold, map[string]interface{}{"threshold": float64(85)}),
+				item(protocol.CmdEnable, nil),
+				item(protocol.CmdSetThreshold, map[string]interface{}{"threshold": float64(30)}),
+				item(protocol.CmdStatus, nil),
+			},
+			results:   []string{"rolled-back", "rolled-back", protocol.CodeInvalidThreshold, "skipped"},
+			threshold: 80,
+		},
+		{
+			name:      "rejects a command that can't be rolled back",
+			peer:      root,
+			requests:  []interface{}{item(protocol.CmdEnable, nil), item(protocol.CmdReloadConfig, nil)},
+			code:      protocol.CodeInvalidParams,
+			threshold: 80,
+		},
+		{
+			name: "rejects a command the caller may not run",
+			peer: other,
+			requests: []interface{}{
+				item(protocol.CmdStatus, nil),
+				item(protocol.CmdEnable, nil),
+			},
+			code:      protocol.CodePermissionDenied,
+			threshold: 80,
+		},
+		{
+			name:      "rejects a nested batch",
+			peer:      root,
+			requests:  []interface{}{item(protocol.CmdBatch, nil)},
+			code:      protocol.CodeInvalidParams,
+			threshold: 80,
+		},
+		{
+			name:      "rejects an empty batch",
+			peer:      root,
+			requests:  []interface{}{},
+			code:      protocol.CodeInvalidParams,
+			threshold: 80,
+		},
+		{
+			name:      "rejects an oversized batch",
+			peer:      root,
+			requests:  make([]interface{}, protocol.MaxBatchSize+1),
+			code:      protocol.CodeInvalidParams,
+			threshold: 80,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+			d.config.Access.Groups = nil
+
+			data, err := d.handleBatch(context.Background(), tt.peer, map[string]interface{}{"requests": tt.requests})
+			if tt.code != "" {
+				if protocol.ErrorCode(err) != tt.code {
+					t.Fatalf("Expected error code %s, got %v", tt.code, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Batch failed: %v", err)
+			} else {
+				results := data.(protocol.BatchData).Results
+				if len(results) != len(tt.results) {
+					t.Fatalf("Expected %d results, got %+v", len(tt.results), results)
+				}
+				for i, result := range results {
+					got := result.Code
+					switch {
+					case result.Success:
+						got = "ok"
+					case result.Skipped:
+						got = "skipped"
+					case result.RolledBack:
+						got = "rolled-back"
+					}
+					if got != tt.results[i] {
+						t.Errorf("Result %d (%s): expected %s, got %s (%s)", i, result.Command, tt.results[i], got, result.Error)
+					}
+				}
+			}
+
+			if threshold := d.stateManager.GetChargeThreshold(); threshold != tt.threshold {
+				t.Errorf("Expected threshold %d, got %d", tt.threshold, threshold)
+			}
+			if enabled := d.stateManager.GetConservationEnabled(); enabled != tt.enabled {
+				t.Errorf("Expected management enabled %v, got %v", tt.enabled, enabled)
+			}
+		})
+	}
+}
+
+func TestBatchRollsBackHardware(t *testing.T) {
+	d, backend := newTestDaemon(t, hardware.Battery{Level: 50, ACOnline: true})
+	backend.rapidCharge = new(bool)
+
+	requests := []interface{}{
+		map[string]interface{}{"command": protocol.CmdSetRapidCharge, "params": map[string]interface{}{"enable": true}},
+		map[string]interface{}{"command": protocol.CmdSetThreshold, "params": map[string]interface{}{"threshold": float64(30)}},
+	}
+	data, err := d.handleBatch(context.Background(), caller{UID: 0, Known: true}, map[string]interface{}{"requests": requests})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+	if results := data.(protocol.BatchData).Results; !results[0].RolledBack {
+		t.Errorf("Expected rapid charge to be rolled back, got %+v", results[0])
+	}
+
+	if *backend.rapidCharge {
+		t.Error("Expected rapid charge switched off again")
+	}
+	if d.stateManager.GetRapidCharge() {
+		t.Error("Expected the rapid charge setting restored")
+	}
+}
+
+func TestHandleReloadConfig(t *testing.T) {
+	d, _ := newTestDaemon(t, hardware.Battery{Level: 70, ACOnline: true})
+	path := filepath.Join(t.TempDir(), "legionbatctl.conf")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+
+	write("[management]\nhysteresis = 3\n")
+	if err := d.LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	write("[management]\nhysteresis = 7\n")
+	data, err := d.handleReloadConfig(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if data.(protocol.ReloadConfigData).Path != path || d.GetConfig().Management.Hysteresis != 7 {
+		t.Errorf("Expected hysteresis 7 from %s, got %d (%+v)", path, d.GetConfig().Management.Hysteresis, data)
+	}
+
+	// An invalid file is reported and the previous configuration kept
+	write("[management]\nhysteresis = 70\n")
+	if _, err := d.handleReloadConfig(context.Background(), nil); err == nil {
+		t.Error("Expected reloading an invalid config to fail")
+	}
+	if d.GetConfig().Management.Hysteresis != 7 {
+		t.Errorf("Expected the previous hysteresis to be kept, got %d", d.GetConfig().Management.Hysteresis)
+	}
+}
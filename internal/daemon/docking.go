@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	"strings"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+// dockSource prefixes the condition of a threshold override set by a
+// docking rule
+const dockSource = "dock:"
+
+// dockingRule is a docking entry compiled into the override of its profile
+type dockingRule struct {
+	when     string
+	override state.ThresholdOverride
+}
+
+// dockingRules compiles the docking entries into threshold overrides, in
+// the order they are checked
+func dockingRules(cfg *config.Config) *[]dockingRule {
+	rules := make([]dockingRule, 0, len(cfg.Docking))
+	for _, entry := range cfg.Docking {
+		profile, ok := cfg.Profiles[entry.Profile]
+		if !ok {
+			continue
+		}
+		rules = append(rules, dockingRule{
+			when: entry.When,
+			override: state.ThresholdOverride{
+				Source:         dockSource + entry.When,
+				Threshold:      profile.Threshold,
+				StartThreshold: profile.StartThreshold,
+			},
+		})
+	}
+	return &rules
+}
+
+// dockOverride returns the threshold override of the first docking rule the
+// lid and displays match, or nil if none does. They are read every time, as
+// nothing tells the daemon when the laptop is docked or the lid is closed.
+func (d *Daemon) dockOverride() *state.ThresholdOverride {
+	rules := d.docking.Load()
+	if rules == nil || len(*rules) == 0 {
+		return nil
+	}
+
+	current, err := d.dockSensor.Read()
+	if err != nil {
+		d.logger.Debug("Failed to read lid and display state", "error", err)
+		return nil
+	}
+	for _, rule := range *rules {
+		if current.Matches(rule.when) {
+			override := rule.override
+			return &override
+		}
+	}
+	return nil
+}
+
+// describeDockCondition describes the condition of a docking override, e.g.
+// "lid closed" for "dock:lid_closed"
+func describeDockCondition(source string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(source, dockSource), "_", " ")
+}
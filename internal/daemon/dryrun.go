@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+// isDryRun reports whether a request only asks what it would change
+func isDryRun(params map[string]interface{}) bool {
+	dryRun, _ := params["dry_run"].(bool)
+	return dryRun
+}
+
+// planEnable describes what the enable command would change
+func (d *Daemon) planEnable() protocol.PlanData {
+	before := d.stateManager.GetState()
+
+	var changes []string
+	if !before.ConservationEnabled {
+		changes = append(changes, "battery management: disabled → enabled")
+	}
+	if before.ChargeFull || !before.ChargeFullBy.IsZero() {
+		changes = append(changes, "charge-full: cancelled")
+	}
+	if before.StorageMode {
+		changes = append(changes, "storage mode: off")
+	}
+
+	return d.plan(changes, func(s *state.State) {
+		s.ConservationEnabled = true
+		s.ChargeFull = false
+		s.StorageMode = false
+	})
+}
+
+// planDisable describes what the disable command would change
+func (d *Daemon) planDisable(reenableAt time.Time) protocol.PlanData {
+	before := d.stateManager.GetState()
+
+	after := "disabled"
+	if !reenableAt.IsZero() {
+		after = "disabled until " + reenableAt.Format(time.RFC3339)
+	}
+
+	var changes []string
+	if current := d.auditSetting(protocol.CmdDisable); current != after {
+		changes = append(changes, fmt.Sprintf("battery management: %s → %s", current, after))
+	}
+	if before.ChargeFull || !before.ChargeFullBy.IsZero() {
+		changes = append(changes, "charge-full: cancelled")
+	}
+	if before.StorageMode {
+		changes = append(changes, "storage mode: off")
+	}
+
+	// Disabling always switches conservation mode off
+	return protocol.PlanData{
+		Changes:           changes,
+		ConservationMode:  d.stateManager.GetConservationMode(),
+		ConservationAfter: false,
+	}
+}
+
+// planSetThreshold describes what the set_threshold command would change; a
+// negative start keeps the hysteresis-based start threshold
+func (d *Daemon) planSetThreshold(threshold, start int) protocol.PlanData {
+	before := d.stateManager.GetState()
+
+	if start < 0 {
+		start = 0
+		if before.StartThreshold < threshold {
+			start = before.StartThreshold
+		}
+	}
+
+	var changes []string
+	old := d.auditSetting(protocol.CmdSetThreshold)
+	updated := fmt.Sprintf("%d%%", threshold)
+	if start > 0 {
+		updated = fmt.Sprintf("%d%% (start %d%%)", threshold, start)
+	}
+	if old != updated {
+		changes = append(changes, fmt.Sprintf("threshold: %s → %s", old, updated))
+	}
+
+	plan := d.plan(changes, func(s *state.State) {
+		s.ChargeThreshold = threshold
+		s.StartThreshold = start
+	})
+	if override := d.stateManager.GetThresholdOverride(); override != nil {
+		plan.Notes = append(plan.Notes, fmt.Sprintf("%s sets %d%% until it ends",
+			describeOverride(override), override.Threshold))
+	}
+	if !before.ConservationEnabled {
+		plan.Notes = append(plan.Notes, "battery management is disabled, the threshold applies once enabled")
+	}
+	return plan
+}
+
+// plan previews the conservation decision after updateFn is applied to the
+// state, the same decision the monitor makes on its next check
+func (d *Daemon) plan(changes []string, updateFn func(*state.State)) protocol.PlanData {
+	current := d.stateManager.GetConservationMode()
+	after := current
+
+	enable, disable := d.stateManager.Preview(updateFn)
+	if enable {
+		after = true
+	} else if disable {
+		after = false
+	}
+
+	return protocol.PlanData{
+		Changes:           changes,
+		ConservationMode:  current,
+		ConservationAfter: after,
+	}
+}
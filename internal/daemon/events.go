@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/events"
+	"github.com/dom1nux/legionbatctl/internal/notify"
+)
+
+// emit dispatches an event with the current battery level and threshold
+func (d *Daemon) emit(eventType events.Type, message string) {
+	event := events.Event{Type: eventType, Message: message}
+	if d.stateManager != nil {
+		event.BatteryLevel = d.stateManager.GetBatteryLevel()
+		event.Threshold = d.stateManager.GetEffectiveThreshold()
+	}
+
+	if !d.events.Emit(event) {
+		d.logger.Warn("Event queue full, dropped event", "event", eventType)
+	}
+}
+
+// emitError dispatches an error event, skipping repeats of the previous error
+// until a battery check succeeds again (monitor only)
+func (d *Daemon) emitError(summary string, err error) {
+	message := fmt.Sprintf("%s: %v", summary, err)
+	if message == d.lastError {
+		return
+	}
+	d.lastError = message
+	d.emit(events.Error, message)
+}
+
+// eventHandlers creates the event handlers enabled in the configuration
+func (d *Daemon) eventHandlers(cfg *config.Config) ([]events.Handler, error) {
+	var handlers []events.Handler
+
+	if cfg.Notifications.Enabled {
+		desktop, err := notify.NewDesktop(cfg.Notifications.User, eventTypes(cfg.Notifications.Events))
+		if err != nil {
+			return nil, err
+		}
+
+		// The notified user may choose the events in their config overlay.
+		// Problems in it are logged without details, which can quote the
+		// file, as the logs are readable through the socket.
+		if home := desktop.Home(); home != "" {
+			userCfg := *cfg
+			path := config.UserConfigPathIn(home)
+			if err := userCfg.ApplyUserOverlay(path, desktop.UID()); err != nil {
+				reason := err.Error()
+				var invalid *config.ValidationError
+				if errors.As(err, &invalid) {
+					reason = "invalid settings"
+				}
+				d.logger.Warn("Ignoring the notified user's config", "path", path, "reason", reason)
+			} else {
+				desktop.SetEvents(eventTypes(userCfg.Notifications.Events))
+			}
+		}
+		handlers = append(handlers, desktop)
+	}
+
+	if cfg.Hooks.Dir != "" {
+		timeout := time.Duration(cfg.Hooks.TimeoutSeconds) * time.Second
+		handlers = append(handlers, notify.NewHooks(cfg.Hooks.Dir, timeout))
+	}
+
+	for _, webhook := range cfg.Webhooks {
+		timeout := time.Duration(webhook.TimeoutSeconds) * time.Second
+		handler, err := notify.NewWebhook(webhook.URL, webhook.Headers, eventTypes(webhook.Events), timeout, webhook.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		handlers = append(handlers, handler)
+	}
+
+	return handlers, nil
+}
+
+// eventTypes converts event type names from the config
+func eventTypes(names []string) []events.Type {
+	types := make([]events.Type, len(names))
+	for i, name := range names {
+		types[i] = events.Type(name)
+	}
+	return types
+}
+
+// logEventError reports a failed event handler
+func (d *Daemon) logEventError(handler string, event events.Event, err error) {
+	d.logger.Warn("Event handler failed", "handler", handler, "event", event.Type, "error", err)
+}
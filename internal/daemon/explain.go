@@ -0,0 +1,128 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+// handleExplain handles the explain command
+func (d *Daemon) handleExplain(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if d.stateManager == nil {
+		return nil, fmt.Errorf("state manager not initialized")
+	}
+
+	current := d.stateManager.GetState()
+	override := d.stateManager.GetThresholdOverride()
+
+	data := protocol.ExplainData{
+		ConservationMode:  current.ConservationMode,
+		ManagementEnabled: current.ConservationEnabled,
+		ACOnline:          current.Charging,
+		BatteryLevel:      current.BatteryLevel,
+		Threshold:         d.stateManager.GetEffectiveThreshold(),
+		StartThreshold:    d.stateManager.GetStartThreshold(),
+		Hysteresis:        d.GetConfig().Management.Hysteresis,
+		LastCheck:         current.LastReadingTime,
+	}
+	if override != nil {
+		data.Override = override.Source
+	}
+	if switched := d.lastSwitch.Load(); switched != 0 {
+		data.LastSwitch = time.Unix(0, switched)
+	}
+
+	data.Steps, data.ExpectedMode = explainDecision(current, override, data.Threshold, data.StartThreshold, data.Hysteresis)
+	return data, nil
+}
+
+// explainDecision walks through the monitor's decision (checkBatteryAndAdjust
+// and the state's ShouldEnable/ShouldDisable rules), returning a step per
+// check and the mode the next check switches to, or nil if it keeps it
+func explainDecision(current state.State, override *state.ThresholdOverride, threshold, start, hysteresis int) ([]string, *bool) {
+	mode := onOff(current.ConservationMode)
+
+	if current.ChargeFull {
+		return []string{
+			"Charge-full is in progress: management is suspended until the battery is full, conservation mode stays " + mode,
+		}, nil
+	}
+
+	if !current.Charging {
+		return []string{"On battery power: conservation mode is only switched on AC, it stays " + mode}, nil
+	}
+	steps := []string{"On AC power"}
+
+	if !current.ConservationEnabled {
+		reason := "Battery management is disabled"
+		if !current.ReenableAt.IsZero() {
+			reason += " until " + current.ReenableAt.Local().Format("Mon 15:04")
+		}
+		return append(steps, reason+": conservation mode is not switched, it stays "+mode), nil
+	}
+	steps = append(steps, "Battery management is enabled")
+
+	if current.Paused {
+		reason := "Automation is paused"
+		if !current.PausedUntil.IsZero() {
+			reason += " until " + current.PausedUntil.Local().Format("Mon 15:04")
+		}
+		return append(steps, reason+": conservation mode is not switched, it stays "+mode), nil
+	}
+
+	if override != nil {
+		switch {
+		case override.Source == storageSource:
+			steps = append(steps, fmt.Sprintf("Storage mode holds the battery at %d%%", override.Threshold))
+		case strings.HasPrefix(override.Source, powerModeSource):
+			steps = append(steps, fmt.Sprintf("The %s power mode sets the threshold to %d%%",
+				strings.TrimPrefix(override.Source, powerModeSource), override.Threshold))
+		case strings.HasPrefix(override.Source, dockSource):
+			steps = append(steps, fmt.Sprintf("The %s docking rule sets the threshold to %d%%",
+				describeDockCondition(override.Source), override.Threshold))
+		default:
+			steps = append(steps, fmt.Sprintf("Schedule rule %q sets the threshold to %d%%", override.Source, override.Threshold))
+		}
+	}
+
+	explicitStart := current.StartThreshold
+	if override != nil {
+		explicitStart = override.StartThreshold
+	}
+	resume := fmt.Sprintf("%d%%", start)
+	if explicitStart <= 0 || explicitStart >= threshold {
+		resume = fmt.Sprintf("%d%% (the threshold minus the %d%% hysteresis)", start, hysteresis)
+	}
+
+	var expected bool
+	switch {
+	case current.BatteryLevel >= threshold:
+		steps = append(steps, fmt.Sprintf("Battery %d%% is at or above the threshold %d%%: conservation mode should be on",
+			current.BatteryLevel, threshold))
+		expected = true
+	case current.BatteryLevel < start:
+		steps = append(steps, fmt.Sprintf("Battery %d%% is below %s, where charging resumes: conservation mode should be off",
+			current.BatteryLevel, resume))
+		expected = false
+	default:
+		return append(steps, fmt.Sprintf("Battery %d%% is between %s and the threshold %d%%: conservation mode stays %s to avoid toggling",
+			current.BatteryLevel, resume, threshold, mode)), nil
+	}
+
+	if expected == current.ConservationMode {
+		return append(steps, "Conservation mode is "+mode+" as expected"), nil
+	}
+	return append(steps, fmt.Sprintf("Conservation mode is %s, the next check switches it %s", mode, onOff(expected))), &expected
+}
+
+// onOff formats a mode as "on" or "off"
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,302 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/dom1nux/legionbatctl/api/legionbatctl/v1"
+	"github.com/dom1nux/legionbatctl/internal/events"
+	"github.com/dom1nux/legionbatctl/internal/logging"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// startGRPC serves the gRPC API (api/legionbatctl/v1) on the configured socket
+func (d *Daemon) startGRPC(socketPath string) error {
+	os.Remove(socketPath)
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0755); err != nil {
+		return fmt.Errorf("failed to create gRPC socket directory: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on gRPC socket %s: %w", socketPath, err)
+	}
+
+	// Access is checked per call from the peer credentials
+	if err := os.Chmod(socketPath, 0666); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set gRPC socket permissions: %w", err)
+	}
+
+	d.grpcServer = grpc.NewServer(grpc.Creds(peerCredentials{}))
+	pb.RegisterBatteryServiceServer(d.grpcServer, &grpcService{daemon: d})
+	go d.grpcServer.Serve(listener)
+	return nil
+}
+
+// peerCredentials identifies gRPC callers from the Unix socket peer
+// credentials; it doesn't encrypt, as the connection never leaves the host
+type peerCredentials struct{}
+
+// peerAuthInfo carries the caller identified at the handshake
+type peerAuthInfo struct {
+	credentials.CommonAuthInfo
+	caller caller
+	err    error
+}
+
+func (peerAuthInfo) AuthType() string { return "peercred" }
+
+func (peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	peer, err := peerCaller(conn)
+	return conn, peerAuthInfo{
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity},
+		caller:         peer,
+		err:            err,
+	}, nil
+}
+
+func (peerCredentials) ClientHandshake(context.Context, string, net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("peer credentials are server-side only")
+}
+
+func (peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+func (c peerCredentials) Clone() credentials.TransportCredentials { return c }
+
+func (peerCredentials) OverrideServerName(string) error { return nil }
+
+// grpcService implements the gRPC API on top of the socket protocol commands
+type grpcService struct {
+	pb.UnimplementedBatteryServiceServer
+	daemon *Daemon
+}
+
+// call runs a socket protocol command for a gRPC call, with the same access
+// control, audit and rate limit, and returns the response data
+func (s *grpcService) call(ctx context.Context, command string, params map[string]interface{}) (interface{}, error) {
+	d := s.daemon
+	logCtx := logging.ContextWith(d.connectionContext(), "via", "grpc")
+
+	var caller caller
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(peerAuthInfo); ok {
+			caller = info.caller
+			if info.err != nil {
+				d.logger.WarnContext(logCtx, "Failed to identify gRPC caller, allowing read-only access", "error", info.err)
+			}
+		}
+	}
+
+	uid := -1
+	if caller.Known {
+		uid = caller.UID
+	}
+
+	var response *protocol.Response
+	if limits := d.GetConfig().Server; d.limiter.allow(uid, time.Now(), limits.RateLimit, limits.RateBurst) {
+		response = d.processRequest(logCtx, caller, protocol.NewRequest(command, params)).GetResponse()
+	} else {
+		response = protocol.NewErrorResponse("", protocol.ErrRateLimited).GetResponse()
+	}
+
+	if !response.Success {
+		return nil, status.Error(grpcCode(response.Code), response.Error)
+	}
+	return response.Data, nil
+}
+
+// grpcCode maps a socket protocol error code to a gRPC status code
+func grpcCode(code string) codes.Code {
+	switch code {
+	case protocol.CodeInvalidThreshold, protocol.CodeInvalidParams, protocol.CodeInvalidCommand:
+		return codes.InvalidArgument
+	case protocol.CodePermissionDenied:
+		return codes.PermissionDenied
+	case protocol.CodeRateLimited:
+		return codes.ResourceExhausted
+	case protocol.CodeHardwareNotSupported:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// timestamp converts a time, leaving zero times unset
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func (s *grpcService) GetStatus(ctx context.Context, request *pb.GetStatusRequest) (*pb.Status, error) {
+	data, err := s.call(ctx, protocol.CmdStatus, map[string]interface{}{"fresh": request.GetFresh()})
+	if err != nil {
+		return nil, err
+	}
+
+	statusData := data.(protocol.StatusData)
+	return &pb.Status{
+		ConservationEnabled: statusData.ConservationEnabled,
+		Threshold:           int32(statusData.Threshold),
+		StartThreshold:      int32(statusData.StartThreshold),
+		CurrentMode:         statusData.CurrentMode,
+		BatteryLevel:        int32(statusData.BatteryLevel),
+		ConservationMode:    statusData.ConservationMode,
+		Charging:            statusData.Charging,
+		LastAction:          statusData.LastAction,
+		DaemonUptime:        statusData.DaemonUptime,
+		HardwareSupported:   statusData.HardwareSupported,
+		ReenableAt:          timestamp(statusData.ReenableAt),
+		Schedule:            statusData.Schedule,
+		StorageMode:         statusData.StorageMode,
+		StorageTarget:       int32(statusData.StorageTarget),
+	}, nil
+}
+
+func (s *grpcService) Enable(ctx context.Context, _ *pb.EnableRequest) (*pb.EnableResponse, error) {
+	data, err := s.call(ctx, protocol.CmdEnable, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	enableData := data.(protocol.EnableData)
+	return &pb.EnableResponse{
+		Message:     enableData.Message,
+		Threshold:   int32(enableData.Threshold),
+		CurrentMode: enableData.CurrentMode,
+	}, nil
+}
+
+func (s *grpcService) Disable(ctx context.Context, request *pb.DisableRequest) (*pb.DisableResponse, error) {
+	params := make(map[string]interface{})
+	if request.GetFor() != "" {
+		params["for"] = request.GetFor()
+	}
+	data, err := s.call(ctx, protocol.CmdDisable, params)
+	if err != nil {
+		return nil, err
+	}
+
+	disableData := data.(protocol.DisableData)
+	return &pb.DisableResponse{
+		Message:     disableData.Message,
+		CurrentMode: disableData.CurrentMode,
+		ReenableAt:  timestamp(disableData.ReenableAt),
+	}, nil
+}
+
+func (s *grpcService) SetThreshold(ctx context.Context, request *pb.SetThresholdRequest) (*pb.SetThresholdResponse, error) {
+	// Numbers arrive as float64 from JSON on the socket
+	params := map[string]interface{}{"threshold": float64(request.GetThreshold())}
+	if request.GetStartThreshold() != 0 {
+		params["start_threshold"] = float64(request.GetStartThreshold())
+	}
+	data, err := s.call(ctx, protocol.CmdSetThreshold, params)
+	if err != nil {
+		return nil, err
+	}
+
+	thresholdData := data.(protocol.SetThresholdData)
+	return &pb.SetThresholdResponse{
+		Message:        thresholdData.Message,
+		Threshold:      int32(thresholdData.Threshold),
+		StartThreshold: int32(thresholdData.StartThreshold),
+	}, nil
+}
+
+func (s *grpcService) GetHealth(ctx context.Context, _ *pb.GetHealthRequest) (*pb.Health, error) {
+	data, err := s.call(ctx, protocol.CmdHealth, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	healthData := data.(protocol.HealthData)
+	return &pb.Health{
+		Full:        int64(healthData.Full),
+		FullDesign:  int64(healthData.FullDesign),
+		Unit:        healthData.Unit,
+		CycleCount:  int32(healthData.CycleCount),
+		Wear:        healthData.Wear,
+		WearWarning: int32(healthData.WearWarning),
+		Degraded:    healthData.Degraded,
+	}, nil
+}
+
+func (s *grpcService) GetHistory(request *pb.GetHistoryRequest, stream grpc.ServerStreamingServer[pb.HistoryEntry]) error {
+	params := make(map[string]interface{})
+	if request.GetSince() != nil {
+		params["since"] = request.GetSince().AsTime().Format(time.RFC3339)
+	}
+	if request.GetUntil() != nil {
+		params["until"] = request.GetUntil().AsTime().Format(time.RFC3339)
+	}
+	data, err := s.call(stream.Context(), protocol.CmdHistory, params)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range data.(protocol.HistoryData).Entries {
+		err := stream.Send(&pb.HistoryEntry{
+			Time:         timestamppb.New(entry.Time),
+			Event:        entry.Event,
+			Level:        int32(entry.Level),
+			Ac:           entry.ACConnected,
+			Conservation: entry.ConservationMode,
+			Threshold:    int32(entry.Threshold),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *grpcService) WatchEvents(request *pb.WatchEventsRequest, stream grpc.ServerStreamingServer[pb.Event]) error {
+	for _, name := range request.GetEvents() {
+		if !events.IsValidType(name) {
+			return status.Errorf(codes.InvalidArgument, "unknown event type %q", name)
+		}
+	}
+
+	// Stopping the server cancels the stream context
+	subscription, cancel := s.daemon.events.Subscribe()
+	defer cancel()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-subscription:
+			if len(request.GetEvents()) > 0 && !slices.Contains(request.GetEvents(), string(event.Type)) {
+				continue
+			}
+			err := stream.Send(&pb.Event{
+				Event:        string(event.Type),
+				Time:         timestamppb.New(event.Time),
+				BatteryLevel: int32(event.BatteryLevel),
+				Threshold:    int32(event.Threshold),
+				Message:      event.Message,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -8,22 +8,68 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/dom1nux/legionbatctl/internal/dock"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
 	"github.com/dom1nux/legionbatctl/internal/protocol"
 )
 
+// Options configures a daemon started by Run
+type Options struct {
+	SocketPath    string
+	StatePath     string
+	RuntimePath   string // Empty keeps the runtime state in the state file
+	ConfigPath    string
+	PIDPath       string        // Empty writes it next to the socket
+	CheckInterval time.Duration // Zero adapts the interval to the battery level
+	LogLevel      string        // Overrides the level of every log sink, if set
+	ForceTakeover bool          // Replace the socket of an unresponsive daemon
+}
+
 // RunDaemon starts the daemon in the current process
-func RunDaemon(socketPath, statePath string) error {
-	daemon := NewDaemon(socketPath, statePath)
+func RunDaemon(socketPath, statePath, configPath string) error {
+	return Run(Options{SocketPath: socketPath, StatePath: statePath, ConfigPath: configPath})
+}
 
-	// Check if already running
-	if isDaemonRunning(socketPath) {
-		return fmt.Errorf("daemon is already running (socket: %s)", socketPath)
+// Run starts the daemon with the given options in the current process and
+// blocks until it shuts down
+func Run(opts Options) error {
+	socketPath, configPath := opts.SocketPath, opts.ConfigPath
+	daemon := NewDaemon(socketPath, opts.StatePath)
+	if opts.PIDPath != "" {
+		daemon.SetPIDPath(opts.PIDPath)
+	}
+	if opts.CheckInterval > 0 {
+		daemon.SetFixedCheckInterval(opts.CheckInterval)
+	}
+	daemon.SetRuntimePath(opts.RuntimePath)
+	daemon.SetForceTakeover(opts.ForceTakeover)
+	if opts.LogLevel != "" {
+		if err := daemon.SetLogLevel(opts.LogLevel); err != nil {
+			return err
+		}
+	}
+
+	if err := daemon.LoadConfig(configPath); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	sysfsRoot := hardware.SysfsRoot(daemon.GetConfig().Hardware.SysfsRoot)
+	backend, err := hardware.NewBackend(hardware.BackendName(daemon.GetConfig().Hardware.Backend), sysfsRoot)
+	if err != nil {
+		return err
+	}
+	daemon.SetHardware(backend)
+	daemon.SetDockPaths(dock.DefaultPaths.WithRoot(sysfsRoot))
+	if sysfsRoot != "" {
+		daemon.logger.Info("Using a custom sysfs root", "root", sysfsRoot)
 	}
 
-	fmt.Printf("legionbatctl daemon starting...\n")
-	fmt.Printf("Socket: %s\n", daemon.GetSocketPath())
-	fmt.Printf("State: %s\n", daemon.GetStatePath())
-	fmt.Printf("PID: %d\n", daemon.GetPID())
+	daemon.logger.Info("legionbatctl daemon starting",
+		"socket", daemon.GetSocketPath(),
+		"state", daemon.GetStatePath(),
+		"config", configPath,
+		"backend", backend.Name(),
+		"pid", daemon.GetPID())
 
 	// Run daemon (blocks until shutdown)
 	return daemon.Run()
@@ -57,7 +103,7 @@ func StopDaemon(socketPath string) error {
 }
 
 // RestartDaemon restarts the daemon
-func RestartDaemon(socketPath, statePath string) error {
+func RestartDaemon(socketPath, statePath, configPath string) error {
 	// Stop existing daemon
 	if err := StopDaemon(socketPath); err != nil {
 		// Don't fail if daemon wasn't running
@@ -65,7 +111,7 @@ func RestartDaemon(socketPath, statePath string) error {
 	}
 
 	// Start new daemon
-	return RunDaemon(socketPath, statePath)
+	return RunDaemon(socketPath, statePath, configPath)
 }
 
 // DaemonStatus returns the status of the daemon
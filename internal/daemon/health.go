@@ -0,0 +1,184 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/history"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// capacityInterval is how often the full charge capacity is recorded
+const capacityInterval = 24 * time.Hour
+
+// handleHealth handles the health command
+func (d *Daemon) handleHealth(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	reader, ok := d.hardware.(hardware.HealthReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s backend cannot read battery health",
+			protocol.ErrHardwareNotSupported, d.hardware.Name())
+	}
+
+	health, err := reader.ReadHealth()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read battery health: %w", hardwareError(err))
+	}
+
+	wear := batteryWear(health.Full, health.FullDesign)
+	warning := d.GetConfig().Health.WearWarning
+
+	data := protocol.HealthData{
+		Full:        health.Full,
+		FullDesign:  health.FullDesign,
+		Unit:        health.Unit,
+		CycleCount:  health.CycleCount,
+		Wear:        wear,
+		WearWarning: warning,
+		Degraded:    wear >= float64(warning),
+	}
+
+	if withTrend, _ := params["trend"].(bool); withTrend {
+		trend, err := d.healthTrend(warning)
+		if err != nil {
+			return nil, err
+		}
+		data.Trend = trend
+	}
+
+	return data, nil
+}
+
+// healthTrend computes the capacity trend from the history, projecting when
+// the wear reaches warning percent
+func (d *Daemon) healthTrend(warning int) (*protocol.HealthTrend, error) {
+	trend := &protocol.HealthTrend{Points: []protocol.HealthPoint{}}
+	if d.history == nil {
+		return trend, nil
+	}
+
+	entries, err := d.history.Query(time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := history.CapacityTrend(entries)
+	for _, point := range capacity.Points {
+		trend.Points = append(trend.Points, protocol.HealthPoint{Time: point.Time, Health: point.Health})
+	}
+	trend.FadePerMonth = capacity.FadePerMonth
+	trend.WarningAt = capacity.Projection(float64(100 - warning))
+	return trend, nil
+}
+
+// recordCapacity records the battery's full charge capacity in the history
+// once a day, for the wear trend
+func (d *Daemon) recordCapacity(level int, conservationMode, charging bool, now time.Time) {
+	reader, ok := d.hardware.(hardware.HealthReader)
+	if d.history == nil || !ok {
+		return
+	}
+
+	// After a restart, continue from the last reading in the history
+	if d.lastCapacity.IsZero() {
+		d.lastCapacity = now.Add(-capacityInterval)
+		entries, err := d.history.Query(now.Add(-capacityInterval), time.Time{})
+		if err == nil {
+			for _, entry := range entries {
+				if entry.Event == history.EventCapacity {
+					d.lastCapacity = entry.Time
+				}
+			}
+		}
+	}
+	if now.Sub(d.lastCapacity) < capacityInterval {
+		return
+	}
+
+	health, err := reader.ReadHealth()
+	if err != nil || health.FullDesign <= 0 {
+		d.logger.Debug("Skipped capacity reading", "error", err)
+		return
+	}
+
+	d.lastCapacity = now
+	d.appendHistory(history.Entry{
+		Time:             now,
+		Event:            history.EventCapacity,
+		Level:            level,
+		ACConnected:      charging,
+		ConservationMode: conservationMode,
+		Threshold:        d.stateManager.GetEffectiveThreshold(),
+		Capacity:         health.Full,
+		DesignCapacity:   health.FullDesign,
+	})
+}
+
+// batteryWear returns the capacity lost compared to the design capacity in
+// percent. Batteries reporting more than their design capacity have no wear.
+func batteryWear(full, design int) float64 {
+	if design <= 0 || full >= design {
+		return 0
+	}
+	return (1 - float64(full)/float64(design)) * 100
+}
+
+// handleBatteryInfo handles the battery_info command. The managed threshold
+// data is always returned; the diagnostic readings only on backends that
+// support them.
+func (d *Daemon) handleBatteryInfo(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if d.stateManager == nil {
+		return nil, fmt.Errorf("state manager not initialized")
+	}
+
+	batteryLevel, conservationMode, charging, err := d.readBatteryInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read battery info: %w", err)
+	}
+
+	data := protocol.BatteryInfoData{
+		BatteryLevel:        batteryLevel,
+		ACConnected:         charging,
+		ConservationMode:    conservationMode,
+		ConservationEnabled: d.stateManager.GetConservationEnabled(),
+		Threshold:           d.stateManager.GetEffectiveThreshold(),
+		StartThreshold:      d.stateManager.GetStartThreshold(),
+	}
+
+	reader, ok := d.hardware.(hardware.InfoReader)
+	if !ok {
+		return data, nil
+	}
+
+	info, err := reader.ReadInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read battery diagnostics: %w", hardwareError(err))
+	}
+	applyBatteryInfo(&data, info)
+
+	return data, nil
+}
+
+// applyBatteryInfo converts the power_supply readings to the protocol units
+func applyBatteryInfo(data *protocol.BatteryInfoData, info hardware.Info) {
+	data.Status = info.Status
+	data.Voltage = float64(info.VoltageNow) / 1e6
+	data.Current = float64(info.CurrentNow) / 1e6
+	data.Power = batteryPower(info)
+	data.Temperature = float64(info.Temperature) / 10
+	data.Manufacturer = info.Manufacturer
+	data.Model = info.ModelName
+	data.Serial = info.SerialNumber
+	data.Technology = info.Technology
+}
+
+// batteryPower returns the power flowing in or out of the battery in watts,
+// derived from voltage and current if not reported
+func batteryPower(info hardware.Info) float64 {
+	if info.PowerNow != 0 {
+		return float64(info.PowerNow) / 1e6
+	}
+	return math.Abs(float64(info.VoltageNow) / 1e6 * float64(info.CurrentNow) / 1e6) // current_now may be signed
+}
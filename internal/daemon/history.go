@@ -0,0 +1,212 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/history"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// sampleInterval is how often an unchanged battery reading is recorded
+const sampleInterval = 5 * time.Minute
+
+// openHistory opens the configured history backend. History is
+// informational, so the daemon runs without it if it can't be opened.
+// Backend changes take effect on restart.
+func (d *Daemon) openHistory() {
+	cfg := d.config.History
+	opts := history.Options{
+		MaxEntries: cfg.MaxEntries,
+		Retention:  time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+	}
+
+	path := cfg.Path
+	if d.historyPath != "" {
+		path = d.historyPath
+	}
+
+	var err error
+	switch cfg.Backend {
+	case "sqlite":
+		if path == "" {
+			path = DefaultHistoryDB
+		}
+		var store *history.SQLStore
+		if store, err = history.OpenSQLite(path, opts); err == nil {
+			d.history = store
+		}
+	default:
+		if path == "" {
+			path = DefaultHistoryPath
+		}
+		var store *history.Store
+		if store, err = history.Open(path, opts); err == nil {
+			d.history = store
+		}
+	}
+
+	if err != nil {
+		d.logger.Warn("History disabled, failed to open history",
+			"backend", cfg.Backend, "path", path, "error", err)
+	}
+}
+
+// recordSample records a battery reading when it differs from the last
+// recorded entry or the sample interval has passed
+func (d *Daemon) recordSample(level int, conservationMode, charging bool, now time.Time) {
+	if d.history == nil {
+		return
+	}
+
+	if last, ok := d.history.Last(); ok &&
+		now.Sub(last.Time) < sampleInterval &&
+		last.Level == level &&
+		last.ACConnected == charging &&
+		last.ConservationMode == conservationMode {
+		return
+	}
+
+	d.appendHistory(history.Entry{
+		Time:             now,
+		Event:            history.EventSample,
+		Level:            level,
+		ACConnected:      charging,
+		ConservationMode: conservationMode,
+		Threshold:        d.stateManager.GetEffectiveThreshold(),
+	})
+}
+
+// recordToggle records a conservation mode change made by the daemon
+func (d *Daemon) recordToggle(enable bool) {
+	if d.history == nil || d.stateManager == nil {
+		return
+	}
+
+	event := history.EventConservationOff
+	if enable {
+		event = history.EventConservationOn
+	}
+
+	d.appendHistory(history.Entry{
+		Time:             time.Now(),
+		Event:            event,
+		Level:            d.stateManager.GetBatteryLevel(),
+		ACConnected:      d.stateManager.IsCharging(),
+		ConservationMode: enable,
+		Threshold:        d.stateManager.GetEffectiveThreshold(),
+	})
+}
+
+// appendHistory appends an entry, logging rather than failing on errors
+func (d *Daemon) appendHistory(entry history.Entry) {
+	if err := d.history.Append(entry); err != nil {
+		d.logger.Error("Failed to record history", "event", entry.Event, "error", err)
+	}
+}
+
+// handleHistory handles the history command
+func (d *Daemon) handleHistory(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	since, err := timeParam(params, "since")
+	if err != nil {
+		return nil, err
+	}
+	until, err := timeParam(params, "until")
+	if err != nil {
+		return nil, err
+	}
+
+	data := protocol.HistoryData{Entries: []protocol.HistoryEntry{}}
+	if d.history == nil {
+		return data, nil
+	}
+
+	entries, err := d.history.Query(since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		data.Entries = append(data.Entries, protocol.HistoryEntry{
+			Time:             entry.Time,
+			Event:            entry.Event,
+			Level:            entry.Level,
+			ACConnected:      entry.ACConnected,
+			ConservationMode: entry.ConservationMode,
+			Threshold:        entry.Threshold,
+		})
+	}
+
+	return data, nil
+}
+
+// handleStats handles the stats command
+func (d *Daemon) handleStats(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	since, err := timeParam(params, "since")
+	if err != nil {
+		return nil, err
+	}
+	until, err := timeParam(params, "until")
+	if err != nil {
+		return nil, err
+	}
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	var entries []history.Entry
+	if d.history != nil {
+		if entries, err = d.history.Query(since, until); err != nil {
+			return nil, err
+		}
+	}
+	stats := history.Summarize(entries, since, until)
+
+	return protocol.StatsData{
+		Since:               since,
+		Until:               until,
+		Covered:             stats.Covered.Round(time.Minute).String(),
+		ConservationTime:    stats.ConservationTime.Round(time.Minute).String(),
+		ConservationPercent: percentOf(stats.ConservationTime, stats.Covered),
+		ACTime:              stats.ACTime.Round(time.Minute).String(),
+		ACPercent:           percentOf(stats.ACTime, stats.Covered),
+		BatteryTime:         stats.BatteryTime.Round(time.Minute).String(),
+		BatteryPercent:      percentOf(stats.BatteryTime, stats.Covered),
+		Toggles:             stats.Toggles,
+		AverageLevel:        stats.AverageLevel,
+		MinLevel:            stats.MinLevel,
+		MaxLevel:            stats.MaxLevel,
+		Entries:             stats.Entries,
+	}, nil
+}
+
+// percentOf returns part as a percentage of total
+func percentOf(part, total time.Duration) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// timeParam reads an optional RFC 3339 time parameter, returning the zero
+// time when it is absent
+func timeParam(params map[string]interface{}, name string) (time.Time, error) {
+	value, ok := params[name]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	spec, ok := value.(string)
+	if !ok {
+		return time.Time{}, protocol.NewCodedError(protocol.CodeInvalidParams,
+			fmt.Sprintf("invalid %s value type", name))
+	}
+
+	t, err := time.Parse(time.RFC3339, spec)
+	if err != nil {
+		return time.Time{}, protocol.NewCodedError(protocol.CodeInvalidParams,
+			fmt.Sprintf("invalid %s time %q", name, spec))
+	}
+	return t, nil
+}
@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/logging"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// httpRoutes maps REST endpoints to socket protocol commands
+var httpRoutes = map[string]struct {
+	method  string
+	command string
+}{
+	"/status":    {http.MethodGet, protocol.CmdStatus},
+	"/enable":    {http.MethodPost, protocol.CmdEnable},
+	"/disable":   {http.MethodPost, protocol.CmdDisable},
+	"/threshold": {http.MethodPost, protocol.CmdSetThreshold},
+}
+
+// startHTTP serves the REST API on a loopback address
+func (d *Daemon) startHTTP(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	d.httpServer = &http.Server{
+		Handler:           d.httpHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	go d.httpServer.Serve(listener)
+	return nil
+}
+
+// httpHandler serves the REST API, mirroring the socket protocol: GET
+// requests take params from the query, POST requests from a JSON body.
+// HTTP clients have no peer credentials, so changes need the configured
+// bearer token.
+func (d *Daemon) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	for path, route := range httpRoutes {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != route.method {
+				w.Header().Set("Allow", route.method)
+				writeHTTPError(w, http.StatusMethodNotAllowed, "", "use "+route.method)
+				return
+			}
+
+			params := make(map[string]interface{})
+			if r.Method == http.MethodPost && r.ContentLength != 0 {
+				body := http.MaxBytesReader(w, r.Body, protocol.MaxMessageSize)
+				if err := json.NewDecoder(body).Decode(&params); err != nil {
+					writeHTTPError(w, http.StatusBadRequest, protocol.CodeInvalidParams, "request body must be a JSON object")
+					return
+				}
+			}
+			for name, values := range r.URL.Query() {
+				params[name] = values[0]
+			}
+			if fresh, ok := params["fresh"].(string); ok {
+				params["fresh"] = fresh == "1" || fresh == "true"
+			}
+
+			d.serveHTTPCommand(w, r, route.command, params)
+		})
+	}
+	return mux
+}
+
+// serveHTTPCommand runs a command for an HTTP client and writes the result
+func (d *Daemon) serveHTTPCommand(w http.ResponseWriter, r *http.Request, command string, params map[string]interface{}) {
+	peer := caller{Trusted: d.validHTTPToken(r)}
+	ctx := logging.ContextWith(d.connectionContext(), "via", "http")
+
+	var response *protocol.Response
+	if limits := d.GetConfig().Server; d.limiter.allow(-1, time.Now(), limits.RateLimit, limits.RateBurst) {
+		response = d.processRequest(ctx, peer, protocol.NewRequest(command, params)).GetResponse()
+	} else {
+		response = protocol.NewErrorResponse("", protocol.ErrRateLimited).GetResponse()
+	}
+
+	switch {
+	case !response.Success && response.Code == protocol.CodePermissionDenied:
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeHTTPError(w, http.StatusUnauthorized, response.Code, "changes over HTTP need the configured bearer token")
+		return
+	case !response.Success:
+		writeHTTPError(w, httpStatus(response.Code), response.Code, response.Error)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Data)
+}
+
+// validHTTPToken reports whether the request carries the configured token
+func (d *Daemon) validHTTPToken(r *http.Request) bool {
+	token := d.GetConfig().HTTP.Token
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token != "" && ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// httpStatus maps a protocol error code to an HTTP status
+func httpStatus(code string) int {
+	switch code {
+	case protocol.CodeInvalidThreshold, protocol.CodeInvalidParams, protocol.CodeInvalidCommand, protocol.CodeProtocolError:
+		return http.StatusBadRequest
+	case protocol.CodeRateLimited:
+		return http.StatusTooManyRequests
+	case protocol.CodeHardwareNotSupported:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// writeHTTPError writes an error as {"error": ..., "code": ...}
+func writeHTTPError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message, "code": code})
+}
@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// inhibitTimeout bounds how long taking a sleep inhibitor may hold up a
+// hardware write
+const inhibitTimeout = time.Second
+
+// errInhibitTimeout is returned when logind doesn't grant an inhibitor in time
+var errInhibitTimeout = errors.New("timed out waiting for the inhibitor")
+
+// sleepInhibitor takes logind delay inhibitors for sleep through
+// systemd-inhibit. A suspend starting while one is held waits until it is
+// released (up to logind's InhibitDelayMaxSec), so a hardware write, its
+// verification and the state recording it all happen on the same side of
+// the suspend.
+type sleepInhibitor struct {
+	path string // systemd-inhibit executable
+}
+
+// newSleepInhibitor returns an inhibitor, or nil if systemd-inhibit isn't
+// installed
+func newSleepInhibitor() *sleepInhibitor {
+	path, err := exec.LookPath("systemd-inhibit")
+	if err != nil {
+		return nil
+	}
+	return &sleepInhibitor{path: path}
+}
+
+// hold takes a delay inhibitor and returns the function releasing it.
+// systemd-inhibit runs its child only once logind granted the inhibitor, so
+// the child's first output confirms it is held; closing the child's stdin
+// ends it and releases the inhibitor.
+func (i *sleepInhibitor) hold(why string) (func(), error) {
+	cmd := exec.Command(i.path, "--what=sleep", "--mode=delay", "--who=legionbatctl", "--why="+why,
+		"sh", "-c", "echo; exec cat")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	held := &firstWrite{done: make(chan struct{})}
+	var stderr bytes.Buffer
+	cmd.Stdout = held
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	select {
+	case <-held.done:
+		return func() {
+			stdin.Close()
+			<-exited
+		}, nil
+	case err := <-exited:
+		return nil, fmt.Errorf("systemd-inhibit failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	case <-time.After(inhibitTimeout):
+		cmd.Process.Kill()
+		<-exited
+		return nil, errInhibitTimeout
+	}
+}
+
+// firstWrite closes done on the first write to it
+type firstWrite struct {
+	once sync.Once
+	done chan struct{}
+}
+
+func (w *firstWrite) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.done) })
+	return len(p), nil
+}
+
+// inhibitSleep delays suspend until the returned function is called. Without
+// an inhibitor the caller goes ahead unprotected.
+func (d *Daemon) inhibitSleep(why string) func() {
+	if d.inhibitor == nil {
+		return func() {}
+	}
+
+	release, err := d.inhibitor.hold(why)
+	if err != nil {
+		d.logger.Debug("Failed to delay suspend during a hardware change", "error", err)
+		return func() {}
+	}
+	return release
+}
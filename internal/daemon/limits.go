@@ -0,0 +1,282 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// handleGetLimits handles the get_limits command
+func (d *Daemon) handleGetLimits(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	limits, err := d.hardware.ReadLimits()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read charge limits: %w", hardwareError(err))
+	}
+
+	return d.limitsData(limits, ""), nil
+}
+
+// handleSetLimits handles the set_limits command. Only the controls present
+// in params are changed; the full set of limits is returned afterwards.
+func (d *Daemon) handleSetLimits(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	changes, err := parseLimitsParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := d.hardware.ReadLimits()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read charge limits: %w", hardwareError(err))
+	}
+
+	if err := validateLimitChanges(current, changes); err != nil {
+		return nil, err
+	}
+
+	d.logger.InfoContext(ctx, "Setting charge limits", "backend", d.hardware.Name(), "params", params)
+	defer d.inhibitSleep("Setting battery charge limits")()
+	if err := d.writeHardware(func() error { return d.hardware.SetLimits(changes) }); err != nil {
+		return nil, fmt.Errorf("failed to set charge limits: %w", hardwareError(err))
+	}
+
+	updated, err := d.hardware.ReadLimits()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read charge limits: %w", hardwareError(err))
+	}
+
+	message := "Charge limits updated"
+	if changes.RapidCharge != nil {
+		message += d.recordRapidCharge(ctx, *changes.RapidCharge, current)
+	}
+	if changes.ConservationMode != nil && d.stateManager != nil && d.stateManager.GetConservationEnabled() {
+		message += " (battery management is enabled and may switch conservation mode again)"
+	}
+
+	return d.limitsData(updated, message), nil
+}
+
+// handleGetRapidCharge handles the get_rapid_charge command
+func (d *Daemon) handleGetRapidCharge(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	limits, err := d.readRapidCharge()
+	if err != nil {
+		return nil, err
+	}
+
+	data := protocol.RapidChargeData{Enabled: *limits.RapidCharge}
+	if !data.Enabled && d.stateManager != nil && d.stateManager.GetRapidCharge() && d.holdingCharge(limits) {
+		data.Pending = true
+		data.Message = fmt.Sprintf("Rapid charge is paused while conservation mode holds the charge, it turns on again below %d%%",
+			d.stateManager.GetStartThreshold())
+	}
+	return data, nil
+}
+
+// handleSetRapidCharge handles the set_rapid_charge command, switching
+// rapid charge on or off as given by the "enable" param. The choice is kept
+// in the state, so rapid charge is switched on again whenever charging
+// resumes.
+func (d *Daemon) handleSetRapidCharge(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	enable, ok := params["enable"].(bool)
+	if !ok {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "enable parameter required")
+	}
+	limits, err := d.readRapidCharge()
+	if err != nil {
+		return nil, err
+	}
+
+	// Switching rapid charge on now would switch conservation mode off and
+	// let the battery charge past the threshold, so it waits for charging
+	// to resume
+	if enable && d.holdingCharge(limits) {
+		if err := d.stateManager.SetRapidCharge(true); err != nil {
+			return nil, fmt.Errorf("failed to save rapid charge: %w", err)
+		}
+		d.logger.InfoContext(ctx, "Rapid charge deferred while conservation mode holds the charge")
+		return protocol.RapidChargeData{
+			Pending: true,
+			Message: fmt.Sprintf("Rapid charge turns on when charging resumes below %d%%, "+
+				"switching it on now would switch conservation mode off", d.stateManager.GetStartThreshold()),
+		}, nil
+	}
+
+	d.logger.InfoContext(ctx, "Setting rapid charge", "backend", d.hardware.Name(), "enable", enable)
+	defer d.inhibitSleep("Switching rapid charge")()
+	if err := d.writeHardware(func() error { return d.hardware.SetLimits(hardware.Limits{RapidCharge: &enable}) }); err != nil {
+		return nil, fmt.Errorf("failed to set rapid charge: %w", hardwareError(err))
+	}
+
+	message := "Rapid charge disabled"
+	if enable {
+		message = "Rapid charge enabled"
+	}
+	message += d.recordRapidCharge(ctx, enable, limits)
+	return protocol.RapidChargeData{Enabled: enable, Message: message}, nil
+}
+
+// readRapidCharge reads the charge limits, failing if the hardware doesn't
+// have rapid charge
+func (d *Daemon) readRapidCharge() (hardware.Limits, error) {
+	limits, err := d.hardware.ReadLimits()
+	if err != nil {
+		return limits, fmt.Errorf("failed to read charge limits: %w", hardwareError(err))
+	}
+	if limits.RapidCharge == nil {
+		return limits, fmt.Errorf("%w: rapid charge is not available on this system (it needs the legion-laptop module)",
+			protocol.ErrHardwareNotSupported)
+	}
+	return limits, nil
+}
+
+// holdingCharge reports whether battery management has conservation mode
+// holding the charge, going by limits read from the hardware
+func (d *Daemon) holdingCharge(limits hardware.Limits) bool {
+	return d.stateManager != nil && d.stateManager.GetConservationEnabled() &&
+		limits.ConservationMode != nil && *limits.ConservationMode
+}
+
+// recordRapidCharge saves rapid charge after it was switched, along with
+// conservation mode, which the firmware switches off with rapid charge
+// on. It returns a note for the response message if that happened.
+func (d *Daemon) recordRapidCharge(ctx context.Context, enable bool, before hardware.Limits) string {
+	if d.stateManager == nil {
+		return ""
+	}
+	if err := d.stateManager.SetRapidCharge(enable); err != nil {
+		d.logger.ErrorContext(ctx, "Failed to save rapid charge in state", "error", err)
+	}
+
+	if !enable || before.ConservationMode == nil || !*before.ConservationMode {
+		return ""
+	}
+	if err := d.stateManager.UpdateConservationMode(false); err != nil {
+		d.logger.ErrorContext(ctx, "Failed to update conservation mode in state", "error", err)
+	}
+	d.recordToggle(false)
+	return " (the firmware switched conservation mode off)"
+}
+
+// resumeRapidCharge switches rapid charge back on while conservation mode
+// is off, if it is wanted: the firmware switches it off with conservation
+// mode on, and a reboot may reset it
+func (d *Daemon) resumeRapidCharge(ctx context.Context) {
+	if d.stateManager == nil || !d.stateManager.GetRapidCharge() {
+		return
+	}
+	limits, err := d.hardware.ReadLimits()
+	if err != nil || limits.RapidCharge == nil || *limits.RapidCharge {
+		return
+	}
+
+	enable := true
+	if err := d.writeHardware(func() error { return d.hardware.SetLimits(hardware.Limits{RapidCharge: &enable}) }); err != nil {
+		d.logger.ErrorContext(ctx, "Failed to switch rapid charge back on", "error", hardwareError(err))
+		return
+	}
+	d.logger.InfoContext(ctx, "Switched rapid charge back on")
+}
+
+// limitsData converts hardware limits to the protocol representation
+func (d *Daemon) limitsData(limits hardware.Limits, message string) protocol.LimitsData {
+	return protocol.LimitsData{
+		Backend:          d.hardware.Name(),
+		ConservationMode: limits.ConservationMode,
+		StartThreshold:   limits.StartThreshold,
+		EndThreshold:     limits.EndThreshold,
+		RapidCharge:      limits.RapidCharge,
+		ChargeType:       limits.ChargeType,
+		Message:          message,
+	}
+}
+
+// parseLimitsParams extracts the requested limit changes from request params
+func parseLimitsParams(params map[string]interface{}) (hardware.Limits, error) {
+	var limits hardware.Limits
+
+	for key, value := range params {
+		switch key {
+		case "conservation_mode", "rapid_charge":
+			enabled, ok := value.(bool)
+			if !ok {
+				return limits, invalidLimitParam(key, "a boolean")
+			}
+			if key == "conservation_mode" {
+				limits.ConservationMode = &enabled
+			} else {
+				limits.RapidCharge = &enabled
+			}
+		case "start_threshold", "end_threshold":
+			number, ok := value.(float64)
+			if !ok || number < 0 || number > 100 || number != float64(int(number)) {
+				return limits, invalidLimitParam(key, "an integer between 0 and 100")
+			}
+			threshold := int(number)
+			if key == "start_threshold" {
+				limits.StartThreshold = &threshold
+			} else {
+				limits.EndThreshold = &threshold
+			}
+		case "charge_type":
+			chargeType, ok := value.(string)
+			if !ok || chargeType == "" {
+				return limits, invalidLimitParam(key, "a non-empty string")
+			}
+			limits.ChargeType = &chargeType
+		default:
+			return limits, protocol.NewCodedError(protocol.CodeInvalidParams, fmt.Sprintf("unknown limit: %s", key))
+		}
+	}
+
+	return limits, nil
+}
+
+// validateLimitChanges checks the requested changes against what the hardware supports
+func validateLimitChanges(current, changes hardware.Limits) error {
+	unsupported := func(name string) error {
+		return fmt.Errorf("%w: %s is not available on this system", protocol.ErrHardwareNotSupported, name)
+	}
+
+	if changes.ConservationMode != nil && current.ConservationMode == nil {
+		return unsupported("conservation mode")
+	}
+	if changes.StartThreshold != nil && current.StartThreshold == nil {
+		return unsupported("start threshold")
+	}
+	if changes.EndThreshold != nil && current.EndThreshold == nil {
+		return unsupported("end threshold")
+	}
+	if changes.RapidCharge != nil && current.RapidCharge == nil {
+		return unsupported("rapid charge")
+	}
+	if changes.ChargeType != nil && current.ChargeType == nil {
+		return unsupported("charge type")
+	}
+
+	// The firmware switches one off when the other is switched on
+	if changes.ConservationMode != nil && *changes.ConservationMode && changes.RapidCharge != nil && *changes.RapidCharge {
+		return protocol.NewCodedError(protocol.CodeInvalidParams,
+			"conservation mode and rapid charge can't both be on, the firmware switches one off with the other")
+	}
+
+	// The start threshold must stay below the end threshold after the change
+	start, end := current.StartThreshold, current.EndThreshold
+	if changes.StartThreshold != nil {
+		start = changes.StartThreshold
+	}
+	if changes.EndThreshold != nil {
+		end = changes.EndThreshold
+	}
+	if start != nil && end != nil && *start >= *end {
+		return protocol.NewCodedError(protocol.CodeInvalidParams,
+			fmt.Sprintf("start threshold (%d) must be below end threshold (%d)", *start, *end))
+	}
+
+	return nil
+}
+
+// invalidLimitParam returns an error for a limit param with the wrong type or range
+func invalidLimitParam(key, expected string) error {
+	return protocol.NewCodedError(protocol.CodeInvalidParams, fmt.Sprintf("%s must be %s", key, expected))
+}
@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/logging"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// handleLogs handles the logs command, returning the recent log lines kept in
+// memory: the last "lines" of them (all by default), or those after the line
+// numbered "since" when following the log
+func (d *Daemon) handleLogs(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	ring := d.logger.Ring()
+	data := protocol.LogsData{Lines: []protocol.LogLine{}}
+
+	var lines []logging.Line
+	if value, ok := params["since"]; ok {
+		since, ok := value.(float64)
+		if !ok || since < 0 {
+			return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "invalid since value")
+		}
+		lines, data.Dropped = ring.Since(uint64(since))
+	} else {
+		count := 0
+		if value, ok := params["lines"]; ok {
+			n, ok := value.(float64)
+			if !ok || n < 0 {
+				return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "invalid lines value")
+			}
+			count = int(n)
+		}
+		lines = ring.Last(count)
+	}
+
+	for _, line := range lines {
+		data.Lines = append(data.Lines, protocol.LogLine{Seq: line.Seq, Text: line.Text})
+	}
+	return data, nil
+}
+
+// handleSetLogLevel handles the set_log_level command, overriding the level
+// of every log sink until the daemon restarts. An empty "level" returns the
+// sinks to their configured levels.
+func (d *Daemon) handleSetLogLevel(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	level, ok := params["level"].(string)
+	if !ok {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "level parameter required")
+	}
+	if level != "" && !config.IsValidLogLevel(level) {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams,
+			fmt.Sprintf("invalid log level %q, use debug, info, warn or error", level))
+	}
+
+	d.mutex.Lock()
+	previous := d.logLevel
+	d.logLevel = level
+	cfg := d.config
+	d.mutex.Unlock()
+
+	if err := d.logger.Configure(d.loggingConfig(cfg)); err != nil {
+		d.mutex.Lock()
+		d.logLevel = previous
+		d.mutex.Unlock()
+		return nil, fmt.Errorf("failed to set up logging: %w", err)
+	}
+
+	if level == "" {
+		d.logger.InfoContext(ctx, "Log levels reset to the configuration")
+	} else {
+		d.logger.InfoContext(ctx, "Log level changed", "level", level)
+	}
+	return protocol.LogLevelData{Level: level}, nil
+}
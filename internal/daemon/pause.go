@@ -0,0 +1,83 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// handlePause handles the pause command. The monitor stops switching the
+// hardware, leaving it and the settings as they are, until resumed or, with
+// a "for" param, until the duration has elapsed. Unlike disable it doesn't
+// switch conservation mode off.
+func (d *Daemon) handlePause(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if d.stateManager == nil {
+		return nil, fmt.Errorf("state manager not initialized")
+	}
+
+	until, err := untilParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.stateManager.Pause(until); err != nil {
+		return nil, fmt.Errorf("failed to pause automation: %w", err)
+	}
+
+	message := "Automation paused until resumed"
+	if !until.IsZero() {
+		message = fmt.Sprintf("Automation paused until %s", until.Format(time.RFC3339))
+	}
+	d.logger.InfoContext(ctx, "Automation paused", "until", until)
+
+	return protocol.PauseData{Message: message, Paused: true, PausedUntil: until}, nil
+}
+
+// handleResume handles the resume command
+func (d *Daemon) handleResume(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if d.stateManager == nil {
+		return nil, fmt.Errorf("state manager not initialized")
+	}
+
+	if err := d.stateManager.Resume(); err != nil {
+		return nil, fmt.Errorf("failed to resume automation: %w", err)
+	}
+	d.logger.InfoContext(ctx, "Automation resumed")
+
+	return protocol.PauseData{Message: "Automation resumed"}, nil
+}
+
+// untilParam reads the optional "for" duration param of a temporary change,
+// returning when the change ends or zero without the param
+func untilParam(params map[string]interface{}) (time.Time, error) {
+	value, ok := params["for"]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	spec, ok := value.(string)
+	if !ok {
+		return time.Time{}, protocol.NewCodedError(protocol.CodeInvalidParams, "invalid duration value type")
+	}
+	duration, err := time.ParseDuration(spec)
+	if err != nil || duration <= 0 {
+		return time.Time{}, protocol.NewCodedError(protocol.CodeInvalidParams,
+			fmt.Sprintf("invalid duration %q (expected e.g. 30m or 2h)", spec))
+	}
+	return time.Now().Add(duration), nil
+}
+
+// automationPaused reports whether the monitor must leave the hardware alone
+// at now, resuming automation once a timed pause has expired
+func (d *Daemon) automationPaused(now time.Time) bool {
+	if resumed, err := d.stateManager.ResumeIfDue(now); err != nil {
+		d.logger.Error("Failed to resume automation after a timed pause", "error", err)
+	} else if resumed {
+		d.logger.Info("Pause expired, resumed automation")
+	}
+
+	paused, _ := d.stateManager.GetPause()
+	return paused
+}
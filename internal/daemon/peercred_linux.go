@@ -0,0 +1,33 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// peerCaller reads the credentials of the connecting process (SO_PEERCRED)
+func peerCaller(conn net.Conn) (caller, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return caller{}, fmt.Errorf("not a unix socket connection")
+	}
+
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return caller{}, err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return caller{}, err
+	}
+	if credErr != nil {
+		return caller{}, fmt.Errorf("failed to read peer credentials: %w", credErr)
+	}
+
+	return caller{UID: int(cred.Uid), GID: int(cred.Gid), PID: int(cred.Pid), Known: true}, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package daemon
+
+import (
+	"errors"
+	"net"
+)
+
+// peerCaller is unsupported off Linux, so callers are treated as read-only
+func peerCaller(conn net.Conn) (caller, error) {
+	return caller{}, errors.New("peer credentials are only supported on Linux")
+}
@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+// powerModeSource prefixes the platform profile of a threshold override set
+// by power_modes, e.g. "power-mode:quiet"
+const powerModeSource = "power-mode:"
+
+// handleGetPowerMode handles the get_power_mode command
+func (d *Daemon) handleGetPowerMode(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	profiler, err := d.powerProfiler()
+	if err != nil {
+		return nil, err
+	}
+
+	mode, choices, err := profiler.ReadPowerProfile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read power mode: %w", hardwareError(err))
+	}
+	return d.powerModeData(mode, choices, ""), nil
+}
+
+// handleSetPowerMode handles the set_power_mode command, switching the
+// platform profile to the "mode" param. A charge profile coupled with the
+// mode in power_modes takes effect right away.
+func (d *Daemon) handleSetPowerMode(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	mode, ok := params["mode"].(string)
+	if !ok || mode == "" {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "mode parameter required")
+	}
+
+	profiler, err := d.powerProfiler()
+	if err != nil {
+		return nil, err
+	}
+	_, choices, err := profiler.ReadPowerProfile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read power mode: %w", hardwareError(err))
+	}
+	if !slices.Contains(choices, mode) {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams,
+			fmt.Sprintf("unsupported power mode %q (expected %s)", mode, strings.Join(choices, ", ")))
+	}
+
+	d.logger.InfoContext(ctx, "Setting power mode", "backend", d.hardware.Name(), "mode", mode)
+	if err := d.writeHardware(func() error { return profiler.SetPowerProfile(mode) }); err != nil {
+		return nil, fmt.Errorf("failed to set power mode: %w", hardwareError(err))
+	}
+
+	d.applySchedule(time.Now())
+	d.checkBatteryAndAdjust()
+
+	return d.powerModeData(mode, choices, fmt.Sprintf("Power mode set to %s", mode)), nil
+}
+
+// powerProfiler returns the backend as a PowerProfiler, failing if it can't
+// switch the platform profile
+func (d *Daemon) powerProfiler() (hardware.PowerProfiler, error) {
+	profiler, ok := d.hardware.(hardware.PowerProfiler)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s backend can't switch the power mode", protocol.ErrHardwareNotSupported, d.hardware.Name())
+	}
+	return profiler, nil
+}
+
+// powerModeData describes the power mode along with the charge profile
+// coupled with it, if any
+func (d *Daemon) powerModeData(mode string, choices []string, message string) protocol.PowerModeData {
+	data := protocol.PowerModeData{Mode: mode, Choices: choices, Message: message}
+	if name, profile, ok := d.GetConfig().PowerModeProfile(mode); ok {
+		data.Profile = name
+		data.Threshold = profile.Threshold
+	}
+	return data
+}
+
+// powerModeOverrides compiles power_modes into the threshold overrides of
+// the coupled charge profiles, by platform profile
+func powerModeOverrides(cfg *config.Config) *map[string]state.ThresholdOverride {
+	overrides := make(map[string]state.ThresholdOverride, len(cfg.PowerModes))
+	for mode := range cfg.PowerModes {
+		if _, profile, ok := cfg.PowerModeProfile(mode); ok {
+			overrides[mode] = state.ThresholdOverride{
+				Source:         powerModeSource + mode,
+				Threshold:      profile.Threshold,
+				StartThreshold: profile.StartThreshold,
+			}
+		}
+	}
+	return &overrides
+}
+
+// powerModeOverride returns the threshold override coupled with the active
+// platform profile, or nil if there is none. The profile is read every time,
+// as it may be switched outside legionbatctl, e.g. with Fn+Q on Legion
+// laptops.
+func (d *Daemon) powerModeOverride() *state.ThresholdOverride {
+	overrides := d.powerModes.Load()
+	if overrides == nil || len(*overrides) == 0 {
+		return nil
+	}
+	profiler, ok := d.hardware.(hardware.PowerProfiler)
+	if !ok {
+		return nil
+	}
+
+	mode, _, err := profiler.ReadPowerProfile()
+	if err != nil {
+		d.logger.Debug("Failed to read power mode", "error", err)
+		return nil
+	}
+	if override, ok := (*overrides)[mode]; ok {
+		return &override
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// maxIdleBuckets is how many per-user buckets are kept before full ones are
+// dropped
+const maxIdleBuckets = 64
+
+// tokenBucket holds the request allowance of one user
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a per-user token bucket rate limiter. Rate and burst are
+// passed on each call so configuration reloads apply immediately.
+type rateLimiter struct {
+	mutex   sync.Mutex
+	buckets map[int]*tokenBucket
+}
+
+// newRateLimiter creates an empty rate limiter
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[int]*tokenBucket)}
+}
+
+// allow takes a token from the user's bucket, which refills at rate tokens
+// per second up to burst, and reports whether one was available. A rate of
+// 0 allows everything.
+func (l *rateLimiter) allow(uid int, now time.Time, rate float64, burst int) bool {
+	if rate <= 0 {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	bucket, ok := l.buckets[uid]
+	if !ok {
+		l.dropFull(now, rate, burst)
+		bucket = &tokenBucket{tokens: float64(burst), last: now}
+		l.buckets[uid] = bucket
+	}
+
+	elapsed := now.Sub(bucket.last).Seconds()
+	bucket.tokens = math.Min(float64(burst), bucket.tokens+elapsed*rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// dropFull forgets buckets that have refilled completely once there are too
+// many, as a new bucket starts out full anyway (caller must hold the mutex)
+func (l *rateLimiter) dropFull(now time.Time, rate float64, burst int) {
+	if len(l.buckets) < maxIdleBuckets {
+		return
+	}
+
+	refill := time.Duration(float64(burst) / rate * float64(time.Second))
+	for uid, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(l.buckets, uid)
+		}
+	}
+}
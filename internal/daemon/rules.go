@@ -0,0 +1,92 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/events"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/rules"
+)
+
+// checkRules evaluates the configured rules and takes the action of each
+// that started to hold. The lid and Wi-Fi network are only read if a rule
+// asks for them.
+func (d *Daemon) checkRules(batteryLevel int, acOnline bool, now time.Time) {
+	compiled := d.rules.Load()
+	if compiled == nil || len(*compiled) == 0 {
+		return
+	}
+
+	facts := rules.Facts{Time: now, BatteryLevel: batteryLevel, OnAC: acOnline}
+	if adapter := d.adapter.Load(); adapter != nil {
+		facts.Adapter = adapter.Type
+	}
+	if rules.NeedsLid(*compiled) {
+		if current, err := d.dockSensor.Read(); err != nil {
+			d.logger.Debug("Failed to read lid state", "error", err)
+		} else if current.LidClosed {
+			facts.Lid = rules.LidClosed
+		} else {
+			facts.Lid = rules.LidOpen
+		}
+	}
+	if rules.NeedsSSID(*compiled) {
+		if ssid, err := d.readSSID(); err != nil {
+			d.logger.Debug("Failed to read Wi-Fi network", "error", err)
+		} else {
+			facts.SSID = ssid
+		}
+	}
+
+	for _, rule := range d.ruleEngine.Evaluate(*compiled, facts) {
+		d.logger.Info("Rule triggered", "rule", rule.Name, "action", rule.Action)
+		if err := d.runRule(rule); err != nil {
+			d.logger.Error("Failed to run rule action", "rule", rule.Name, "action", rule.Action, "error", err)
+			d.emitError(fmt.Sprintf("Rule %s failed", rule.Name), err)
+		}
+	}
+}
+
+// runRule takes the action of a triggered rule
+func (d *Daemon) runRule(rule rules.Rule) error {
+	switch rule.Action {
+	case rules.SetThreshold:
+		allowed := protocol.ThresholdRangeData(hardware.ThresholdRangeOf(d.hardware))
+		if err := protocol.ValidateThresholdRange(rule.Threshold, allowed); err != nil {
+			return err
+		}
+		if err := d.stateManager.SetChargeThresholds(rule.Threshold, rule.StartThreshold); err != nil {
+			return fmt.Errorf("failed to set thresholds: %w", err)
+		}
+		d.emit(events.ThresholdChanged, fmt.Sprintf("Rule %s set the charge threshold to %d%%", rule.Name, rule.Threshold))
+
+	case rules.Enable:
+		if d.stateManager.GetConservationEnabled() {
+			return nil
+		}
+		if err := d.stateManager.EnableConservation(); err != nil {
+			return fmt.Errorf("failed to enable conservation: %w", err)
+		}
+		d.emit(events.ManagementEnabled, fmt.Sprintf("Rule %s enabled management, charging stops at %d%%",
+			rule.Name, d.stateManager.GetEffectiveThreshold()))
+
+	case rules.Disable:
+		if !d.stateManager.GetConservationEnabled() {
+			return nil
+		}
+		if err := d.setConservationMode(context.Background(), false); err != nil {
+			return fmt.Errorf("failed to disable conservation mode: %w", err)
+		}
+		if err := d.stateManager.DisableConservationUntil(time.Time{}); err != nil {
+			return fmt.Errorf("failed to disable conservation: %w", err)
+		}
+		d.emit(events.ManagementDisabled, fmt.Sprintf("Rule %s disabled management, the battery charges to 100%%", rule.Name))
+
+	case rules.Notify:
+		d.emit(events.RuleTriggered, rule.Message)
+	}
+	return nil
+}
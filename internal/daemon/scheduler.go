@@ -0,0 +1,179 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/schedule"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+// applySchedule puts the threshold override in force: storage mode first,
+// then the charge profile coupled with the power mode, then the first
+// docking rule the lid and displays match, then the schedule rule active at
+// now. Without any the configured threshold is restored.
+func (d *Daemon) applySchedule(now time.Time) {
+	if d.stateManager == nil {
+		return
+	}
+
+	override := d.thresholdOverride(now)
+	previous := d.stateManager.GetThresholdOverride()
+
+	if override == nil {
+		if previous != nil {
+			d.stateManager.SetThresholdOverride(nil)
+			d.logger.Info("Threshold override ended, using configured threshold",
+				"source", previous.Source, "threshold", d.stateManager.GetChargeThreshold())
+		}
+		return
+	}
+
+	if previous == nil || *previous != *override {
+		d.stateManager.SetThresholdOverride(override)
+		d.logger.Info("Threshold override active",
+			"source", override.Source, "threshold", override.Threshold)
+	}
+}
+
+// thresholdOverride returns the override that should be in force at now, or nil
+func (d *Daemon) thresholdOverride(now time.Time) *state.ThresholdOverride {
+	if storage, target := d.stateManager.GetStorageMode(); storage {
+		return &state.ThresholdOverride{
+			Source:         storageSource,
+			Threshold:      target,
+			StartThreshold: max(target-storageBand, 0),
+		}
+	}
+
+	if override := d.powerModeOverride(); override != nil {
+		return override
+	}
+
+	if override := d.dockOverride(); override != nil {
+		return override
+	}
+
+	scheduler := d.scheduler.Load()
+	if scheduler == nil || d.stateManager.IsSchedulePaused() {
+		return nil
+	}
+
+	active := scheduler.Active(now)
+	if active == nil || active.Threshold == 0 {
+		return nil
+	}
+	return &state.ThresholdOverride{
+		Source:         active.Name,
+		Threshold:      active.Threshold,
+		StartThreshold: active.StartThreshold,
+	}
+}
+
+// checkNightMode schedules charging to full by the end of the night rule
+// active at now, once per night. The charge rate learned from earlier
+// charging decides when charging starts, as for charge-full --by.
+func (d *Daemon) checkNightMode(now time.Time) {
+	scheduler := d.scheduler.Load()
+	if scheduler == nil || d.stateManager.IsSchedulePaused() || !d.stateManager.GetConservationEnabled() {
+		return
+	}
+
+	active := scheduler.Active(now)
+	if active == nil || !active.Night {
+		return
+	}
+
+	wake := active.Window.End(now)
+	if wake.Equal(d.nightWake) || !d.stateManager.GetChargeFullBy().IsZero() {
+		return
+	}
+	d.nightWake = wake
+
+	if err := d.stateManager.ScheduleChargeFull(wake); err != nil {
+		d.logger.Error("Failed to schedule night mode charging", "rule", active.Name, "error", err)
+		return
+	}
+	d.logger.Info("Night mode holds the charge until charging to full", "rule", active.Name,
+		"by", wake, "start_at", d.chargeFullStartTime(wake, d.stateManager.GetBatteryLevel()))
+}
+
+// describeOverride names the source of a threshold override for messages
+func describeOverride(override *state.ThresholdOverride) string {
+	switch {
+	case override.Source == storageSource:
+		return "storage mode"
+	case strings.HasPrefix(override.Source, powerModeSource):
+		return fmt.Sprintf("the %s power mode", strings.TrimPrefix(override.Source, powerModeSource))
+	case strings.HasPrefix(override.Source, dockSource):
+		return fmt.Sprintf("the %s docking rule", describeDockCondition(override.Source))
+	}
+	return fmt.Sprintf("schedule rule %q", override.Source)
+}
+
+// handleGetSchedule handles the get_schedule command
+func (d *Daemon) handleGetSchedule(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	return d.scheduleData(time.Now()), nil
+}
+
+// handleSetSchedule handles the set_schedule command, which pauses or resumes
+// schedule rules via the "paused" param
+func (d *Daemon) handleSetSchedule(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if d.stateManager == nil {
+		return nil, fmt.Errorf("state manager not initialized")
+	}
+
+	paused, ok := params["paused"].(bool)
+	if !ok {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "paused parameter required")
+	}
+
+	if err := d.stateManager.SetSchedulePaused(paused); err != nil {
+		return nil, fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	now := time.Now()
+	d.applySchedule(now)
+	d.checkBatteryAndAdjust()
+
+	return d.scheduleData(now), nil
+}
+
+// scheduleData describes the schedule and the rule active at now
+func (d *Daemon) scheduleData(now time.Time) protocol.ScheduleData {
+	data := protocol.ScheduleData{Rules: []protocol.ScheduleRuleData{}}
+	if d.stateManager != nil {
+		data.Paused = d.stateManager.IsSchedulePaused()
+	}
+
+	scheduler := d.scheduler.Load()
+	if scheduler == nil {
+		return data
+	}
+
+	var active *schedule.Rule
+	if !data.Paused {
+		active = scheduler.Active(now)
+		data.NextChange = scheduler.NextChange(now)
+	}
+	if active != nil {
+		data.Active = active.Name
+	}
+
+	for _, rule := range scheduler.Rules() {
+		data.Rules = append(data.Rules, protocol.ScheduleRuleData{
+			Name:           rule.Name,
+			Window:         rule.Window.String(),
+			Profile:        rule.Profile,
+			Threshold:      rule.Threshold,
+			StartThreshold: rule.StartThreshold,
+			Active:         active != nil && rule.Name == active.Name,
+			Night:          rule.Night,
+		})
+	}
+
+	return data
+}
@@ -1,16 +1,27 @@
 package daemon
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"math"
 	"net"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/dom1nux/legionbatctl/internal/events"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/logging"
 	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/pkg/version"
 )
 
+// responseWriteTimeout bounds how long a client may take to read a response
+const responseWriteTimeout = 10 * time.Second
+
 // serveConnections handles incoming socket connections
 func (d *Daemon) serveConnections() {
 	for {
@@ -22,44 +33,99 @@ func (d *Daemon) serveConnections() {
 				return
 			default:
 				// Log error but continue accepting connections
-				fmt.Printf("Accept error: %v\n", err)
+				d.logger.Warn("Accept error", "error", err)
 				continue
 			}
 		}
 
+		if int(d.connections.Load()) >= d.GetConfig().Server.MaxConnections {
+			d.refuseConnection(conn)
+			continue
+		}
+
 		// Handle connection in a goroutine
-		go d.handleConnection(conn)
+		d.connections.Add(1)
+		go func() {
+			defer d.connections.Add(-1)
+			d.handleConnection(conn)
+		}()
 	}
 }
 
+// refuseConnection tells a client over the connection limit to retry later
+func (d *Daemon) refuseConnection(conn net.Conn) {
+	defer conn.Close()
+
+	d.logger.Debug("Connection limit reached, refusing connection")
+	conn.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
+	json.NewEncoder(conn).Encode(protocol.NewErrorResponse("", protocol.ErrTooManyConnections))
+}
+
 // handleConnection handles a single client connection
 func (d *Daemon) handleConnection(conn net.Conn) {
 	defer conn.Close()
 
-	// Set connection timeout
-	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	// Every line logged for the connection carries its ID
+	ctx := d.connectionContext()
 
-	decoder := json.NewDecoder(conn)
-	encoder := json.NewEncoder(conn)
+	peer, err := peerCaller(conn)
+	if err != nil {
+		d.logger.WarnContext(ctx, "Failed to identify caller, allowing read-only access", "error", err)
+	}
+
+	// Unidentified callers share one rate limit bucket
+	uid := -1
+	if peer.Known {
+		uid = peer.UID
+	}
+
+	// Each request must arrive, and each response be taken, in time, so a
+	// stalled client can't hold a connection slot
+	codec := protocol.NewCodec(conn)
+	codec.SetMaxMessageSize(protocol.MaxMessageSize)
+	codec.SetReadTimeout(protocol.ReadTimeout)
 
 	for {
-		var msg protocol.Message
-		if err := decoder.Decode(&msg); err != nil {
-			if !isConnectionClosed(err) {
-				fmt.Printf("Decode error: %v\n", err)
+		msg, err := codec.Decode()
+		if msg == nil {
+			switch {
+			case errors.Is(err, os.ErrDeadlineExceeded):
+				d.logger.DebugContext(ctx, "Client sent no request in time, closing connection", "uid", uid)
+			case !isConnectionClosed(err):
+				// The rest of the stream can't be trusted after a bad frame
+				d.logger.WarnContext(ctx, "Decode error", "error", err)
+				conn.SetWriteDeadline(time.Now().Add(responseWriteTimeout))
+				codec.SendErrorResponse("", err)
 			}
 			return
 		}
 
-		// Process request
-		response := d.processRequest(&msg)
+		// Process request, unless it is invalid or the caller is over its rate limit
+		limits := d.GetConfig().Server
+		var response *protocol.Message
+		switch {
+		case err != nil:
+			d.logger.WarnContext(ctx, "Invalid request", "request_id", msg.ID, "error", err)
+			response = protocol.NewErrorResponse(msg.ID, err)
+		case d.limiter.allow(uid, time.Now(), limits.RateLimit, limits.RateBurst):
+			response = d.processRequest(ctx, peer, msg)
+		default:
+			d.logger.DebugContext(ctx, "Rate limit exceeded", "uid", uid, "request_id", msg.ID)
+			response = protocol.NewErrorResponse(msg.ID, protocol.ErrRateLimited)
+		}
 
 		// Send response
-		if err := encoder.Encode(response); err != nil {
-			fmt.Printf("Encode error: %v\n", err)
+		conn.SetWriteDeadline(time.Now().Add(responseWriteTimeout))
+		if err := codec.Encode(response); err != nil {
+			d.logger.WarnContext(ctx, "Encode error", "error", err)
 			return
 		}
 
+		// A hello switches the framing once its response is sent
+		if hello, ok := response.GetResponse().Data.(protocol.HelloData); ok {
+			codec.SetFraming(hello.Framing)
+		}
+
 		// If this is a response message, we're done with this connection
 		if msg.IsResponse() {
 			return
@@ -67,8 +133,15 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 	}
 }
 
-// processRequest processes a single request message
-func (d *Daemon) processRequest(req *protocol.Message) *protocol.Message {
+// connectionContext returns the context for serving a new connection, whose
+// log lines carry the connection's ID
+func (d *Daemon) connectionContext() context.Context {
+	return logging.ContextWith(context.Background(), "conn", d.connectionIDs.Add(1))
+}
+
+// processRequest processes a single request message from the caller. Every
+// line logged while handling it carries the request ID and the caller.
+func (d *Daemon) processRequest(ctx context.Context, peer caller, req *protocol.Message) *protocol.Message {
 	if !req.IsRequest() {
 		return protocol.NewErrorResponse(req.ID, fmt.Errorf("invalid message type"))
 	}
@@ -78,37 +151,160 @@ func (d *Daemon) processRequest(req *protocol.Message) *protocol.Message {
 		return protocol.NewErrorResponse(req.ID, fmt.Errorf("missing request data"))
 	}
 
+	ctx = logging.ContextWith(ctx, append([]any{"request_id", req.ID}, peer.logAttrs()...)...)
+
+	// Following the logs would otherwise fill them with its own polling
+	quiet := request.Command == protocol.CmdLogs
+	if !quiet {
+		d.logger.DebugContext(ctx, "Request received", "command", request.Command, "params", request.Params)
+	}
+
 	var response interface{}
 	var err error
+	if request.Command == protocol.CmdBatch {
+		response, err = d.handleBatch(ctx, peer, request.Params)
+	} else {
+		// Batches run alone, so they never see another request half done
+		d.requestMutex.RLock()
+		response, err = d.runCommand(ctx, peer, request.Command, request.Params)
+		d.requestMutex.RUnlock()
+	}
+	if err != nil {
+		return protocol.NewErrorResponse(req.ID, err)
+	}
+
+	if !quiet {
+		d.logger.DebugContext(ctx, "Request completed", "command", request.Command)
+	}
+	return protocol.NewSuccessResponse(req.ID, response)
+}
+
+// runCommand checks that the caller may run a command, runs it and records
+// it in the audit log if it is audited
+func (d *Daemon) runCommand(ctx context.Context, peer caller, command string, params map[string]interface{}) (interface{}, error) {
+	audited := isAudited(command) && !isDryRun(params)
+	var before string
+	if audited {
+		before = d.auditSetting(command)
+	}
+
+	if err := d.authorize(peer, command); err != nil {
+		d.logger.WarnContext(ctx, "Request denied", "command", command, "error", err)
+		if audited {
+			d.recordAudit(ctx, peer, command, before, err)
+		}
+		return nil, err
+	}
+
+	response, err := d.dispatch(ctx, command, params)
+
+	if audited {
+		d.recordAudit(ctx, peer, command, before, err)
+	}
 
-	switch request.Command {
+	if err != nil {
+		d.logger.WarnContext(ctx, "Request failed", "command", command, "error", err)
+		return nil, err
+	}
+
+	// Reconcile the hardware with the changed settings now rather than at
+	// the next scheduled check
+	if protocol.IsMutatingCommand(command) && !isDryRun(params) {
+		d.requestCheck()
+	}
+
+	return response, nil
+}
+
+// dispatch runs the handler of a command
+func (d *Daemon) dispatch(ctx context.Context, command string, params map[string]interface{}) (interface{}, error) {
+	switch command {
 	case protocol.CmdEnable:
-		response, err = d.handleEnable(request.Params)
+		return d.handleEnable(ctx, params)
 	case protocol.CmdDisable:
-		response, err = d.handleDisable(request.Params)
+		return d.handleDisable(ctx, params)
 	case protocol.CmdStatus:
-		response, err = d.handleStatus(request.Params)
+		return d.handleStatus(ctx, params)
 	case protocol.CmdSetThreshold:
-		response, err = d.handleSetThreshold(request.Params)
+		return d.handleSetThreshold(ctx, params)
 	case protocol.CmdDaemonStatus:
-		response, err = d.handleDaemonStatus(request.Params)
-	default:
-		err = fmt.Errorf("unknown command: %s", request.Command)
+		return d.handleDaemonStatus(ctx, params)
+	case protocol.CmdGetLimits:
+		return d.handleGetLimits(ctx, params)
+	case protocol.CmdSetLimits:
+		return d.handleSetLimits(ctx, params)
+	case protocol.CmdGetPowerMode:
+		return d.handleGetPowerMode(ctx, params)
+	case protocol.CmdSetPowerMode:
+		return d.handleSetPowerMode(ctx, params)
+	case protocol.CmdGetRapidCharge:
+		return d.handleGetRapidCharge(ctx, params)
+	case protocol.CmdSetRapidCharge:
+		return d.handleSetRapidCharge(ctx, params)
+	case protocol.CmdChargeFull:
+		return d.handleChargeFull(ctx, params)
+	case protocol.CmdGetSchedule:
+		return d.handleGetSchedule(ctx, params)
+	case protocol.CmdSetSchedule:
+		return d.handleSetSchedule(ctx, params)
+	case protocol.CmdStorage:
+		return d.handleStorage(ctx, params)
+	case protocol.CmdPause:
+		return d.handlePause(ctx, params)
+	case protocol.CmdResume:
+		return d.handleResume(ctx, params)
+	case protocol.CmdSnapshotCreate:
+		return d.handleSnapshotCreate(ctx, params)
+	case protocol.CmdSnapshotList:
+		return d.handleSnapshotList(ctx, params)
+	case protocol.CmdSnapshotRollback:
+		return d.handleSnapshotRollback(ctx, params)
+	case protocol.CmdHealth:
+		return d.handleHealth(ctx, params)
+	case protocol.CmdAdaptive:
+		return d.handleAdaptive(ctx, params)
+	case protocol.CmdBatteryInfo:
+		return d.handleBatteryInfo(ctx, params)
+	case protocol.CmdHistory:
+		return d.handleHistory(ctx, params)
+	case protocol.CmdStats:
+		return d.handleStats(ctx, params)
+	case protocol.CmdAudit:
+		return d.handleAudit(ctx, params)
+	case protocol.CmdHello:
+		return d.handleHello(ctx, params)
+	case protocol.CmdExplain:
+		return d.handleExplain(ctx, params)
+	case protocol.CmdLogs:
+		return d.handleLogs(ctx, params)
+	case protocol.CmdSetLogLevel:
+		return d.handleSetLogLevel(ctx, params)
+	case protocol.CmdReloadConfig:
+		return d.handleReloadConfig(ctx, params)
 	}
+	return nil, fmt.Errorf("%w: %s", protocol.ErrInvalidCommand, command)
+}
 
-	if err != nil {
-		return protocol.NewErrorResponse(req.ID, err)
+// handleHello handles the hello command, negotiating the framing requested in
+// the "framing" param
+func (d *Daemon) handleHello(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	framing := protocol.FramingLine
+	if requested, ok := params["framing"].(string); ok && protocol.IsValidFraming(requested) {
+		framing = requested
 	}
-
-	return protocol.NewSuccessResponse(req.ID, response)
+	return protocol.HelloData{Framing: framing}, nil
 }
 
 // handleEnable handles the enable command
-func (d *Daemon) handleEnable(params map[string]interface{}) (interface{}, error) {
+func (d *Daemon) handleEnable(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if d.stateManager == nil {
 		return nil, fmt.Errorf("state manager not initialized")
 	}
 
+	if isDryRun(params) {
+		return d.planEnable(), nil
+	}
+
 	// Enable conservation management
 	if err := d.stateManager.EnableConservation(); err != nil {
 		return nil, fmt.Errorf("failed to enable conservation: %w", err)
@@ -116,77 +312,255 @@ func (d *Daemon) handleEnable(params map[string]interface{}) (interface{}, error
 
 	// If conservation should be enabled immediately, do it
 	if d.stateManager.ShouldEnableConservation() {
-		if err := d.setConservationMode(true); err != nil {
+		if err := d.setConservationMode(ctx, true); err != nil {
 			return nil, fmt.Errorf("failed to set conservation mode: %w", err)
 		}
 	}
 
+	d.emit(events.ManagementEnabled, fmt.Sprintf("Charging stops at %d%%", d.stateManager.GetEffectiveThreshold()))
+
 	state := d.stateManager.GetState()
 	return protocol.EnableData{
 		Message:     "Battery management enabled",
-		Threshold:   state.ChargeThreshold,
+		Threshold:   d.stateManager.GetEffectiveThreshold(),
 		CurrentMode: state.CurrentMode,
 	}, nil
 }
 
-// handleDisable handles the disable command
-func (d *Daemon) handleDisable(params map[string]interface{}) (interface{}, error) {
+// handleDisable handles the disable command. An optional "for" param holds a
+// duration (e.g. "2h") after which management is re-enabled automatically.
+func (d *Daemon) handleDisable(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if d.stateManager == nil {
 		return nil, fmt.Errorf("state manager not initialized")
 	}
 
+	reenableAt, err := untilParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDryRun(params) {
+		return d.planDisable(reenableAt), nil
+	}
+
 	// Disable conservation mode first
-	if err := d.setConservationMode(false); err != nil {
+	if err := d.setConservationMode(ctx, false); err != nil {
 		return nil, fmt.Errorf("failed to disable conservation mode: %w", err)
 	}
 
 	// Then disable management
-	if err := d.stateManager.DisableConservation(); err != nil {
+	if err := d.stateManager.DisableConservationUntil(reenableAt); err != nil {
 		return nil, fmt.Errorf("failed to disable conservation: %w", err)
 	}
 
+	message := "Battery management disabled"
+	if !reenableAt.IsZero() {
+		message = fmt.Sprintf("Battery management disabled until %s", reenableAt.Format(time.RFC3339))
+		d.logger.InfoContext(ctx, "Battery management disabled temporarily", "reenable_at", reenableAt)
+	}
+	d.emit(events.ManagementDisabled, message+", the battery charges to 100%")
+
 	state := d.stateManager.GetState()
 	return protocol.DisableData{
-		Message:     "Battery management disabled",
+		Message:     message,
 		CurrentMode: state.CurrentMode,
+		ReenableAt:  state.ReenableAt,
 	}, nil
 }
 
-// handleStatus handles the status command
-func (d *Daemon) handleStatus(params map[string]interface{}) (interface{}, error) {
+// handleChargeFull handles the charge_full command. Management is suspended
+// until the monitor sees a full battery and re-enables it. With a "by" param
+// (RFC 3339) charging is delayed so the battery is full by that time.
+func (d *Daemon) handleChargeFull(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if d.stateManager == nil {
 		return nil, fmt.Errorf("state manager not initialized")
 	}
 
+	if value, ok := params["by"]; ok {
+		return d.scheduleChargeFull(ctx, value)
+	}
+
+	// Disable conservation mode first so charging starts right away
+	if err := d.setConservationMode(ctx, false); err != nil {
+		return nil, fmt.Errorf("failed to disable conservation mode: %w", err)
+	}
+
+	if err := d.stateManager.StartChargeFull(); err != nil {
+		return nil, fmt.Errorf("failed to start charge-full: %w", err)
+	}
+
+	d.logger.InfoContext(ctx, "Charging to full, management is restored once full")
+
+	return protocol.ChargeFullData{
+		Message:      "Charging to 100%, battery management is restored once full",
+		BatteryLevel: d.stateManager.GetBatteryLevel(),
+	}, nil
+}
+
+// scheduleChargeFull schedules a charge-full for the deadline in value
+func (d *Daemon) scheduleChargeFull(ctx context.Context, value interface{}) (interface{}, error) {
+	spec, ok := value.(string)
+	if !ok {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "invalid deadline value type")
+	}
+
+	by, err := time.Parse(time.RFC3339, spec)
+	if err != nil {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, fmt.Sprintf("invalid deadline %q", spec))
+	}
+	if until := time.Until(by); until <= 0 || until > maxChargeFullAhead {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams,
+			fmt.Sprintf("deadline must be within the next %v", maxChargeFullAhead))
+	}
+
+	if err := d.stateManager.ScheduleChargeFull(by); err != nil {
+		return nil, fmt.Errorf("failed to schedule charge-full: %w", err)
+	}
+
+	level := d.stateManager.GetBatteryLevel()
+	startAt := d.chargeFullStartTime(by, level)
+	d.logger.InfoContext(ctx, "Scheduled charge-full", "by", by, "start_at", startAt, "rate", d.stateManager.GetChargeRate())
+
+	return protocol.ChargeFullData{
+		Message:      fmt.Sprintf("Battery will be full by %s", by.Format(time.Kitchen)),
+		BatteryLevel: level,
+		By:           by,
+		StartAt:      startAt,
+	}, nil
+}
+
+// statusFreshness is how old a reading may be and still count as live, so
+// clients polling with fresh reads share one hardware read and state save
+const statusFreshness = time.Second
+
+// handleStatus handles the status command. Battery fields are served from the
+// cached snapshot unless the "fresh" param asks for a live hardware read; a
+// reading taken within statusFreshness is live enough.
+func (d *Daemon) handleStatus(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if d.stateManager == nil {
+		return nil, fmt.Errorf("state manager not initialized")
+	}
+
+	fresh, _ := params["fresh"].(bool)
+	if _, cached := d.stateManager.GetReadingAge(); !cached {
+		fresh = true // Nothing cached yet, fall back to a live read
+	}
+
+	if fresh {
+		if err := d.refreshReading(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.statusData(fresh), nil
+}
+
+// refreshReading reads the battery unless a reading within statusFreshness
+// is cached. Concurrent requests wait for one read instead of each doing it.
+func (d *Daemon) refreshReading(ctx context.Context) error {
+	d.readMutex.Lock()
+	defer d.readMutex.Unlock()
+
+	if age, cached := d.stateManager.GetReadingAge(); cached && age < statusFreshness {
+		return nil
+	}
+
 	// Read current battery information
 	batteryLevel, conservationMode, charging, err := d.readBatteryInfo()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read battery info: %w", err)
+		return fmt.Errorf("failed to read battery info: %w", err)
 	}
 
 	// Update state with current battery info
 	if err := d.stateManager.UpdateBatteryInfo(batteryLevel, conservationMode, charging); err != nil {
 		// Don't fail the request, just log the error
-		fmt.Printf("Failed to update battery info: %v\n", err)
+		d.logger.ErrorContext(ctx, "Failed to update battery info", "error", err)
 	}
+	return nil
+}
 
+// statusData builds the status from the state manager's cached readings
+func (d *Daemon) statusData(fresh bool) protocol.StatusData {
+	var scheduleRule, powerMode, docking string
+	if override := d.stateManager.GetThresholdOverride(); override != nil {
+		switch {
+		case strings.HasPrefix(override.Source, powerModeSource):
+			powerMode = strings.TrimPrefix(override.Source, powerModeSource)
+		case strings.HasPrefix(override.Source, dockSource):
+			docking = strings.TrimPrefix(override.Source, dockSource)
+		case override.Source != storageSource:
+			scheduleRule = override.Source
+		}
+	}
+
+	readingAge, _ := d.stateManager.GetReadingAge()
 	state := d.stateManager.GetState()
+
+	var chargeFullStart time.Time
+	if !state.ChargeFullBy.IsZero() {
+		chargeFullStart = d.chargeFullStartTime(state.ChargeFullBy, state.BatteryLevel)
+	}
+
+	lowChargePower := math.Float64frombits(d.lowChargePower.Load())
+
+	// The learned threshold is moot once the configured one is set to it
+	suggested := int(d.suggested.Load())
+	adaptive := d.adaptive.Load()
+	if adaptive == nil || adaptive.Mode != "suggest" || suggested == state.ChargeThreshold {
+		suggested = 0
+	}
+
 	return protocol.StatusData{
 		ConservationEnabled: state.ConservationEnabled,
-		Threshold:           state.ChargeThreshold,
+		Threshold:           d.stateManager.GetEffectiveThreshold(),
+		StartThreshold:      d.stateManager.GetStartThreshold(),
 		CurrentMode:         state.CurrentMode,
-		BatteryLevel:        batteryLevel,
-		ConservationMode:    conservationMode,
-		Charging:            charging,
+		BatteryLevel:        state.BatteryLevel,
+		ConservationMode:    state.ConservationMode,
+		Charging:            state.Charging,
 		LastAction:          state.LastAction,
 		LastActionTime:      state.LastActionTime,
 		DaemonUptime:        d.GetUptime().String(),
 		HardwareSupported:   true, // TODO: Implement hardware detection
-	}, nil
+		Fresh:               fresh,
+		ReadingAge:          readingAge.Round(time.Second).String(),
+		UncleanShutdowns:    state.UncleanShutdowns,
+		LastUncleanShutdown: state.LastUncleanShutdown,
+		ChargeFull:          state.ChargeFull,
+		ChargeFullBy:        state.ChargeFullBy,
+		ChargeFullStart:     chargeFullStart,
+		ReenableAt:          state.ReenableAt,
+		Paused:              state.Paused,
+		PausedUntil:         state.PausedUntil,
+		Schedule:            scheduleRule,
+		PowerMode:           powerMode,
+		Docking:             docking,
+		SuggestedThreshold:  suggested,
+		Adapter:             d.adapter.Load(),
+		StorageMode:         state.StorageMode,
+		StorageTarget:       state.StorageTarget,
+		ForceDischarging:    d.forceDischarging.Load(),
+		LowChargePower:      lowChargePower > 0,
+		ChargePower:         lowChargePower,
+		TypicalChargePower:  math.Round(d.stateManager.GetChargePower()*10) / 10,
+		RapidCharge:         rapidChargeStatus(state.RapidCharge, state.ConservationMode),
+	}
+}
+
+// rapidChargeStatus describes rapid charge for the status: "on", "paused"
+// while conservation mode holds the charge, or empty if not wanted
+func rapidChargeStatus(wanted, conservationMode bool) string {
+	switch {
+	case !wanted:
+		return ""
+	case conservationMode:
+		return "paused"
+	}
+	return "on"
 }
 
 // handleSetThreshold handles the set_threshold command
-func (d *Daemon) handleSetThreshold(params map[string]interface{}) (interface{}, error) {
+func (d *Daemon) handleSetThreshold(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if d.stateManager == nil {
 		return nil, fmt.Errorf("state manager not initialized")
 	}
@@ -194,39 +568,67 @@ func (d *Daemon) handleSetThreshold(params map[string]interface{}) (interface{},
 	// Extract threshold from params
 	thresholdValue, ok := params["threshold"]
 	if !ok {
-		return nil, fmt.Errorf("threshold parameter required")
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "threshold parameter required")
 	}
 
 	threshold, ok := thresholdValue.(float64)
 	if !ok {
-		return nil, fmt.Errorf("invalid threshold value type")
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "invalid threshold value type")
 	}
 
 	thresholdInt := int(threshold)
 
-	// Validate threshold
-	if err := protocol.ValidateThreshold(thresholdInt); err != nil {
+	// Validate threshold against what the hardware can hold
+	allowed := protocol.ThresholdRangeData(hardware.ThresholdRangeOf(d.hardware))
+	if err := protocol.ValidateThresholdRange(thresholdInt, allowed); err != nil {
 		return nil, err
 	}
 
-	// Set threshold
-	if err := d.stateManager.SetChargeThreshold(thresholdInt); err != nil {
+	// An optional start threshold sets where charging resumes
+	if startValue, ok := params["start_threshold"]; ok {
+		start, ok := startValue.(float64)
+		if !ok {
+			return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "invalid start threshold value type")
+		}
+		if err := protocol.ValidateStartThreshold(int(start), thresholdInt); err != nil {
+			return nil, err
+		}
+		if isDryRun(params) {
+			return d.planSetThreshold(thresholdInt, int(start)), nil
+		}
+		if err := d.stateManager.SetChargeThresholds(thresholdInt, int(start)); err != nil {
+			return nil, fmt.Errorf("failed to set thresholds: %w", err)
+		}
+	} else if isDryRun(params) {
+		return d.planSetThreshold(thresholdInt, -1), nil
+	} else if err := d.stateManager.SetChargeThreshold(thresholdInt); err != nil {
 		return nil, fmt.Errorf("failed to set threshold: %w", err)
 	}
 
+	startThreshold := d.stateManager.GetStartThreshold()
+	message := fmt.Sprintf("Charge threshold set to %d%%, charging resumes below %d%%", thresholdInt, startThreshold)
+	if override := d.stateManager.GetThresholdOverride(); override != nil {
+		message = fmt.Sprintf("Charge threshold set to %d%%; %s sets %d%% until it ends",
+			thresholdInt, describeOverride(override), override.Threshold)
+	}
+
+	d.emit(events.ThresholdChanged, message)
+
 	return protocol.SetThresholdData{
-		Message:   fmt.Sprintf("Charge threshold set to %d%%", thresholdInt),
-		Threshold: thresholdInt,
+		Message:        message,
+		Threshold:      thresholdInt,
+		StartThreshold: startThreshold,
 	}, nil
 }
 
 // handleDaemonStatus handles the daemon_status command
-func (d *Daemon) handleDaemonStatus(params map[string]interface{}) (interface{}, error) {
+func (d *Daemon) handleDaemonStatus(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	return protocol.DaemonStatusData{
 		Running:    d.IsRunning(),
 		PID:        d.GetPID(),
 		Uptime:     d.GetUptime().String(),
 		Version:    d.GetVersion(),
+		Commit:     version.GetVersionInfo().Commit,
 		SocketPath: d.GetSocketPath(),
 		StateFile:  d.GetStatePath(),
 	}, nil
@@ -234,89 +636,63 @@ func (d *Daemon) handleDaemonStatus(params map[string]interface{}) (interface{},
 
 // readBatteryInfo reads current battery information
 func (d *Daemon) readBatteryInfo() (int, bool, bool, error) {
-	// Read battery capacity
-	capacity, err := os.ReadFile("/sys/class/power_supply/BAT0/capacity")
+	battery, err := d.hardware.ReadBattery()
 	if err != nil {
-		return 0, false, false, fmt.Errorf("failed to read battery capacity: %w", err)
+		return battery.Level, battery.ConservationMode, battery.ACOnline, hardwareError(err)
 	}
+	return battery.Level, battery.ConservationMode, battery.ACOnline, nil
+}
 
-	var batteryLevel int
-	_, err = fmt.Sscanf(string(capacity), "%d", &batteryLevel)
-	if err != nil {
-		return 0, false, false, fmt.Errorf("failed to parse battery capacity: %w", err)
+// setConservationMode sets the hardware conservation mode, logging with ctx
+func (d *Daemon) setConservationMode(ctx context.Context, enable bool) error {
+	if enable {
+		d.logger.InfoContext(ctx, "Enabling conservation mode", "backend", d.hardware.Name())
+	} else {
+		d.logger.InfoContext(ctx, "Disabling conservation mode", "backend", d.hardware.Name())
 	}
 
-	// Read conservation mode status
-	conservationData, err := os.ReadFile("/sys/bus/platform/drivers/ideapad_acpi/VPC2004:00/conservation_mode")
-	if err != nil {
-		return batteryLevel, false, false, fmt.Errorf("failed to read conservation mode: %w", err)
-	}
+	// A suspend between the write and recording it would leave the state
+	// out of sync with the hardware
+	defer d.inhibitSleep("Switching battery conservation mode")()
 
-	var conservationMode int
-	_, err = fmt.Sscanf(string(conservationData), "%d", &conservationMode)
-	if err != nil {
-		return batteryLevel, false, false, fmt.Errorf("failed to parse conservation mode: %w", err)
+	if err := d.writeConservationMode(enable); err != nil {
+		return fmt.Errorf("failed to set conservation mode: %w", hardwareError(err))
 	}
 
-	// Read AC adapter status instead of battery charging status
-	// This is more reliable when conservation mode is active
-	acData, err := os.ReadFile("/sys/class/power_supply/ADP1/online")
-	if err != nil {
-		// Fallback to battery status if AC adapter is not available
-		statusData, err := os.ReadFile("/sys/class/power_supply/BAT0/status")
-		if err != nil {
-			return batteryLevel, conservationMode == 1, false, fmt.Errorf("failed to read battery status: %w", err)
-		}
-		status := strings.TrimSpace(string(statusData))
-		charging := status == "Charging"
-		return batteryLevel, conservationMode == 1, charging, nil
+	if d.stateManager == nil || d.stateManager.GetConservationMode() == enable {
+		return nil
 	}
 
-	var acOnline int
-	_, err = fmt.Sscanf(string(acData), "%d", &acOnline)
-	if err != nil {
-		return batteryLevel, conservationMode == 1, false, fmt.Errorf("failed to parse AC adapter status: %w", err)
+	if err := d.stateManager.UpdateConservationMode(enable); err != nil {
+		d.logger.ErrorContext(ctx, "Failed to update conservation mode in state", "error", err)
 	}
+	d.recordToggle(enable)
+	d.lastSwitch.Store(time.Now().UnixNano())
 
-	// AC adapter online (1) means we're connected to power
-	acConnected := acOnline == 1
-
-	return batteryLevel, conservationMode == 1, acConnected, nil
-}
-
-// setConservationMode sets the hardware conservation mode
-func (d *Daemon) setConservationMode(enable bool) error {
-	conservationPath := "/sys/bus/platform/drivers/ideapad_acpi/VPC2004:00/conservation_mode"
-
-	var value string
 	if enable {
-		value = "1"
-		fmt.Printf("Enabling conservation mode (writing 1 to %s)\n", conservationPath)
+		if d.stateManager.GetRapidCharge() {
+			d.logger.InfoContext(ctx, "Rapid charge paused while conservation mode holds the charge")
+		}
+		d.emit(events.ConservationOn, "Conservation mode switched on, the battery stops charging")
 	} else {
-		value = "0"
-		fmt.Printf("Disabling conservation mode (writing 0 to %s)\n", conservationPath)
-	}
-
-	// Write to conservation mode file
-	err := os.WriteFile(conservationPath, []byte(value), 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write conservation mode: %w", err)
+		d.resumeRapidCharge(ctx)
+		d.emit(events.ConservationOff, "Conservation mode switched off, the battery charges again")
 	}
+	return nil
+}
 
-	// Verify the change was applied
-	data, err := os.ReadFile(conservationPath)
-	if err != nil {
-		return fmt.Errorf("failed to verify conservation mode change: %w", err)
-	}
-
-	actualValue := strings.TrimSpace(string(data))
-	expectedValue := strings.TrimSpace(value)
-
-	if actualValue != expectedValue {
-		return fmt.Errorf("conservation mode not updated: expected %s, got %s", expectedValue, actualValue)
+// hardwareError attaches a protocol error code to sysfs access failures so
+// clients can suggest a fix
+func hardwareError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("%w: %v", protocol.ErrSysfsPermission, err)
+	case errors.Is(err, hardware.ErrModuleNotLoaded):
+		return fmt.Errorf("%w: %v", protocol.ErrModuleNotLoaded, err)
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%w: %v", protocol.ErrHardwareNotSupported, err)
 	}
-
-	return nil
+	return err
 }
 
 // isConnectionClosed checks if the error indicates a closed connection
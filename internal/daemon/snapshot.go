@@ -0,0 +1,160 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/snapshot"
+)
+
+// handleSnapshotCreate handles the snapshot_create command, saving the
+// current settings and config file with the optional "note" param
+func (d *Daemon) handleSnapshotCreate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if d.stateManager == nil {
+		return nil, fmt.Errorf("state manager not initialized")
+	}
+
+	note := ""
+	if value, ok := params["note"]; ok {
+		if note, ok = value.(string); !ok {
+			return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "invalid note value type")
+		}
+	}
+
+	d.mutex.RLock()
+	path := d.configPath
+	d.mutex.RUnlock()
+
+	configData, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := d.GetConfig()
+	var rules []string
+	for _, entry := range cfg.Schedule {
+		rules = append(rules, entry.Name)
+	}
+
+	state := d.stateManager.GetState()
+	created, err := d.snapshots.Create(snapshot.Snapshot{
+		Note:           note,
+		Enabled:        state.ConservationEnabled,
+		Threshold:      state.ChargeThreshold,
+		StartThreshold: state.StartThreshold,
+		SchedulePaused: state.SchedulePaused,
+		Config:         string(configData),
+		Profiles:       slices.Sorted(maps.Keys(cfg.Profiles)),
+		Schedule:       rules,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	d.logger.InfoContext(ctx, "Snapshot created", "id", created.ID)
+
+	return snapshotData(created), nil
+}
+
+// handleSnapshotList handles the snapshot_list command
+func (d *Daemon) handleSnapshotList(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	snapshots, err := d.snapshots.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	data := protocol.SnapshotListData{Snapshots: []protocol.SnapshotData{}}
+	for _, s := range snapshots {
+		data.Snapshots = append(data.Snapshots, snapshotData(s))
+	}
+	return data, nil
+}
+
+// handleSnapshotRollback handles the snapshot_rollback command, restoring the
+// settings and config file of the snapshot with the "id" param. The config
+// file is only replaced if it changed, keeping a backup of it.
+func (d *Daemon) handleSnapshotRollback(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if d.stateManager == nil {
+		return nil, fmt.Errorf("state manager not initialized")
+	}
+
+	idValue, ok := params["id"]
+	if !ok {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "id parameter required")
+	}
+	id, ok := idValue.(float64)
+	if !ok {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "invalid id value type")
+	}
+
+	saved, err := d.snapshots.Get(int(id))
+	if errors.Is(err, snapshot.ErrNotFound) {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, fmt.Sprintf("no snapshot %d", int(id)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The hardware may have changed since, so the threshold is checked
+	// before anything is restored
+	allowed := protocol.ThresholdRangeData(hardware.ThresholdRangeOf(d.hardware))
+	if err := protocol.ValidateThresholdRange(saved.Threshold, allowed); err != nil {
+		return nil, err
+	}
+
+	data := protocol.SnapshotRollbackData{Snapshot: snapshotData(saved)}
+
+	d.mutex.RLock()
+	path := d.configPath
+	d.mutex.RUnlock()
+
+	current, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if string(current) != saved.Config {
+		backup, err := config.Import(path, []byte(saved.Config))
+		data.ConfigBackup = backup
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore config file: %w", err)
+		}
+		if err := d.LoadConfig(path); err != nil {
+			return nil, fmt.Errorf("failed to load restored config file: %w", err)
+		}
+		data.ConfigRestored = true
+	}
+
+	if err := d.stateManager.RestoreSettings(saved.Enabled, saved.Threshold, saved.StartThreshold, saved.SchedulePaused); err != nil {
+		return nil, fmt.Errorf("failed to restore settings: %w", err)
+	}
+	d.applySchedule(time.Now())
+
+	data.Message = fmt.Sprintf("Rolled back to snapshot %d", saved.ID)
+	d.logger.InfoContext(ctx, "Rolled back to snapshot", "id", saved.ID, "config_restored", data.ConfigRestored)
+
+	return data, nil
+}
+
+// snapshotData describes a snapshot without its config file, which may hold
+// secrets such as webhook tokens
+func snapshotData(s snapshot.Snapshot) protocol.SnapshotData {
+	return protocol.SnapshotData{
+		ID:             s.ID,
+		Created:        s.Created,
+		Note:           s.Note,
+		Enabled:        s.Enabled,
+		Threshold:      s.Threshold,
+		StartThreshold: s.StartThreshold,
+		SchedulePaused: s.SchedulePaused,
+		Profiles:       s.Profiles,
+		Schedule:       s.Schedule,
+	}
+}
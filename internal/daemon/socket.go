@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// socketProbeTimeout bounds how long a daemon on an existing socket has to
+// answer before it is considered unresponsive (a variable for tests)
+var socketProbeTimeout = 2 * time.Second
+
+var (
+	// ErrAlreadyRunning is returned when another daemon answers on the socket
+	ErrAlreadyRunning = errors.New("daemon is already running")
+
+	// ErrSocketUnresponsive is returned when the socket accepts connections
+	// but nothing answers, e.g. a hung daemon
+	ErrSocketUnresponsive = errors.New("socket accepts connections but nothing answers, a daemon may be hung (use --force-takeover to replace it)")
+
+	// ErrNotSocket is returned when the socket path is taken by something
+	// else, which is never removed
+	ErrNotSocket = errors.New("socket path exists and is not a socket")
+)
+
+// socketStatus describes what was found at the socket path
+type socketStatus int
+
+const (
+	socketFree         socketStatus = iota // Nothing at the path
+	socketStale                            // A socket nobody listens on, left by a crash
+	socketUnresponsive                     // Accepts connections, no answer in time
+	socketLive                             // A daemon answers on it
+	socketForeign                          // Not a socket
+)
+
+// probeSocket finds out whether the socket path is free, left over from a
+// crashed daemon, or in use
+func probeSocket(socketPath string) socketStatus {
+	info, err := os.Lstat(socketPath)
+	if err != nil {
+		return socketFree
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return socketForeign
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, socketProbeTimeout)
+	if err != nil {
+		// Only a refused connection proves nobody listens; anything else,
+		// e.g. a permission error, is treated as in use
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return socketStale
+		}
+		return socketUnresponsive
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(socketProbeTimeout)); err != nil {
+		return socketUnresponsive
+	}
+	codec := protocol.NewCodec(conn)
+	if _, err := codec.SendRequest(protocol.CmdDaemonStatus, nil); err != nil {
+		return socketUnresponsive
+	}
+	if _, err := codec.ReceiveMessage(); err != nil {
+		return socketUnresponsive
+	}
+	return socketLive
+}
+
+// claimSocket makes the socket path available to listen on. A stale socket
+// is removed; an unresponsive one only with force, and a live daemon or a
+// file that isn't a socket are never touched.
+func (d *Daemon) claimSocket(force bool) error {
+	switch probeSocket(d.socketPath) {
+	case socketFree:
+		return nil
+	case socketStale:
+		d.logger.Warn("Removing stale socket left by a previous daemon", "socket", d.socketPath)
+	case socketUnresponsive:
+		if !force {
+			return fmt.Errorf("%w: %s", ErrSocketUnresponsive, d.socketPath)
+		}
+		d.logger.Warn("Taking over socket from an unresponsive daemon", "socket", d.socketPath)
+	case socketLive:
+		return fmt.Errorf("%w (socket: %s)", ErrAlreadyRunning, d.socketPath)
+	case socketForeign:
+		return fmt.Errorf("%w: %s", ErrNotSocket, d.socketPath)
+	}
+
+	if err := os.Remove(d.socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old socket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+const (
+	// storageSource identifies storage mode as the source of a threshold override
+	storageSource = "storage"
+
+	// storageBand is how far below the storage target charging resumes
+	storageBand = 5
+)
+
+// handleStorage handles the storage command. The "enable" param switches
+// storage mode on or off; "target" optionally sets the level to hold.
+func (d *Daemon) handleStorage(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if d.stateManager == nil {
+		return nil, fmt.Errorf("state manager not initialized")
+	}
+
+	enable, ok := params["enable"].(bool)
+	if !ok {
+		return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "enable parameter required")
+	}
+
+	target := protocol.DefaultStorageTarget
+	if value, ok := params["target"]; ok {
+		targetValue, ok := value.(float64)
+		if !ok {
+			return nil, protocol.NewCodedError(protocol.CodeInvalidParams, "invalid target value type")
+		}
+		target = int(targetValue)
+		if err := protocol.ValidateStorageTarget(target); err != nil {
+			return nil, err
+		}
+	}
+
+	var message string
+	if enable {
+		if err := d.stateManager.EnableStorageMode(target); err != nil {
+			return nil, fmt.Errorf("failed to enable storage mode: %w", err)
+		}
+		message = fmt.Sprintf("Storage mode enabled, holding the battery at %d%%", target)
+	} else {
+		if err := d.stateManager.DisableStorageMode(); err != nil {
+			return nil, fmt.Errorf("failed to disable storage mode: %w", err)
+		}
+		d.stopForceDischarge()
+		message = "Storage mode disabled, regular battery management resumed"
+	}
+	d.logger.InfoContext(ctx, message)
+
+	d.applySchedule(time.Now())
+	d.checkBatteryAndAdjust()
+
+	storage, storageTarget := d.stateManager.GetStorageMode()
+	return protocol.StorageData{
+		Message:        message,
+		Enabled:        storage,
+		Target:         storageTarget,
+		ForceDischarge: d.forceDischargeSupported(),
+	}, nil
+}
+
+// adjustForceDischarge discharges the battery on AC power while storage mode
+// holds it above its target, on hardware that supports it
+func (d *Daemon) adjustForceDischarge(level int, charging bool) {
+	discharger, ok := d.hardware.(hardware.Discharger)
+	if !ok || !discharger.ForceDischargeSupported() {
+		return
+	}
+
+	storage, target := d.stateManager.GetStorageMode()
+	want := storage && charging && level > target
+	if want == d.forceDischarging.Load() {
+		return
+	}
+
+	if err := d.writeHardware(func() error { return discharger.SetForceDischarge(want) }); err != nil {
+		d.logger.Error("Failed to switch force discharge", "enable", want, "error", err)
+		return
+	}
+	d.forceDischarging.Store(want)
+	d.logger.Info("Switched force discharge", "enable", want, "battery", level, "target", target)
+}
+
+// stopForceDischarge makes sure the battery is not left force discharging,
+// e.g. when storage mode ends or the daemon stops
+func (d *Daemon) stopForceDischarge() {
+	discharger, ok := d.hardware.(hardware.Discharger)
+	if !ok || !discharger.ForceDischargeSupported() {
+		return
+	}
+
+	if err := d.writeHardware(func() error { return discharger.SetForceDischarge(false) }); err != nil {
+		d.logger.Error("Failed to stop force discharge", "error", err)
+		return
+	}
+	d.forceDischarging.Store(false)
+}
+
+// forceDischargeSupported reports whether the hardware backend can force discharge
+func (d *Daemon) forceDischargeSupported() bool {
+	discharger, ok := d.hardware.(hardware.Discharger)
+	return ok && discharger.ForceDischargeSupported()
+}
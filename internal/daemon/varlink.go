@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/logging"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/varlink"
+	"github.com/dom1nux/legionbatctl/pkg/version"
+)
+
+// varlinkInterface is the name of the daemon's varlink interface
+const varlinkInterface = "io.legionbatctl"
+
+// varlinkDescription defines the daemon's varlink interface. Field names
+// match the JSON socket protocol.
+const varlinkDescription = `# Battery charge management for Lenovo Legion laptops
+interface io.legionbatctl
+
+type Status (
+  conservation_enabled: bool,
+  threshold: int,
+  start_threshold: int,
+  current_mode: string,
+  battery_level: int,
+  conservation_mode: bool,
+  charging: bool,
+  last_action: string,
+  last_action_time: string,
+  daemon_uptime: string,
+  hardware_supported: bool,
+  fresh: bool,
+  reading_age: string,
+  unclean_shutdowns: int,
+  last_unclean_shutdown: string,
+  charge_full: bool,
+  charge_full_by: string,
+  charge_full_start: string,
+  reenable_at: string,
+  schedule: string,
+  storage_mode: bool,
+  storage_target: int,
+  force_discharging: bool
+)
+
+# Returns the management and battery status; fresh reads the hardware
+# instead of the cached snapshot
+method GetStatus(fresh: ?bool) -> (status: Status)
+
+# Enables battery management
+method Enable() -> (message: string, threshold: int, current_mode: string)
+
+# Disables battery management, for a duration such as "2h" if given
+method Disable(for: ?string) -> (message: string, current_mode: string, reenable_at: string)
+
+# Sets the charge threshold and optionally where charging resumes
+method SetThreshold(threshold: int, start_threshold: ?int) -> (message: string, threshold: int, start_threshold: int)
+
+# Returns the battery capacity compared to its design capacity
+method GetHealth() -> (
+  full: int,
+  full_design: int,
+  unit: string,
+  cycle_count: int,
+  wear: float,
+  wear_warning: int,
+  degraded: bool
+)
+
+# A command failed; code is an error code of the socket protocol, e.g.
+# INVALID_THRESHOLD or PERMISSION_DENIED
+error Failed (code: string, message: string)
+`
+
+// varlinkMethods maps varlink methods to socket protocol commands
+var varlinkMethods = map[string]string{
+	"GetStatus":    protocol.CmdStatus,
+	"Enable":       protocol.CmdEnable,
+	"Disable":      protocol.CmdDisable,
+	"SetThreshold": protocol.CmdSetThreshold,
+	"GetHealth":    protocol.CmdHealth,
+}
+
+// startVarlink serves the varlink interface on the configured socket
+func (d *Daemon) startVarlink(socketPath string) error {
+	os.Remove(socketPath)
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0755); err != nil {
+		return fmt.Errorf("failed to create varlink socket directory: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on varlink socket %s: %w", socketPath, err)
+	}
+
+	// Access is checked per call from the peer credentials
+	if err := os.Chmod(socketPath, 0666); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set varlink socket permissions: %w", err)
+	}
+
+	service := &varlink.Service{
+		Vendor:  "legionbatctl",
+		Product: "legionbatctl",
+		Version: version.GetVersionInfo().Version,
+		URL:     "https://github.com/dom1nux/legionbatctl",
+	}
+	service.AddInterface(varlink.Interface{
+		Name:        varlinkInterface,
+		Description: varlinkDescription,
+		Handler:     d.handleVarlinkCall,
+	})
+
+	d.varlinkListener = listener
+	go service.Serve(listener)
+	return nil
+}
+
+// handleVarlinkCall runs a varlink method as the matching socket protocol
+// command, with the same access control, audit and rate limit
+func (d *Daemon) handleVarlinkCall(conn net.Conn, method string, parameters map[string]interface{}) (interface{}, error) {
+	command, ok := varlinkMethods[method]
+	if !ok {
+		return nil, &varlink.Error{
+			Name:       varlink.ErrMethodNotFound,
+			Parameters: map[string]interface{}{"method": varlinkInterface + "." + method},
+		}
+	}
+
+	ctx := logging.ContextWith(d.connectionContext(), "via", "varlink")
+	peer, err := peerCaller(conn)
+	if err != nil {
+		d.logger.WarnContext(ctx, "Failed to identify varlink caller, allowing read-only access", "error", err)
+	}
+
+	uid := -1
+	if peer.Known {
+		uid = peer.UID
+	}
+
+	var response *protocol.Response
+	if limits := d.GetConfig().Server; d.limiter.allow(uid, time.Now(), limits.RateLimit, limits.RateBurst) {
+		response = d.processRequest(ctx, peer, protocol.NewRequest(command, parameters)).GetResponse()
+	} else {
+		response = protocol.NewErrorResponse("", protocol.ErrRateLimited).GetResponse()
+	}
+
+	if !response.Success {
+		return nil, &varlink.Error{
+			Name:       varlinkInterface + ".Failed",
+			Parameters: map[string]interface{}{"code": response.Code, "message": response.Error},
+		}
+	}
+
+	if command == protocol.CmdStatus {
+		return map[string]interface{}{"status": response.Data}, nil
+	}
+	return response.Data, nil
+}
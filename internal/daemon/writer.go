@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"errors"
+	"time"
+)
+
+// defaultSwitchCooldown is the least time between two conservation mode
+// switches in opposite directions
+const defaultSwitchCooldown = 2 * time.Second
+
+// errWriterStopped is returned for writes submitted after the daemon stopped
+var errWriterStopped = errors.New("daemon is stopping, hardware not changed")
+
+// hardwareWrite is a change to the hardware made by the writer
+type hardwareWrite struct {
+	apply  func() error
+	mode   *bool // Conservation mode the write switches to, nil for other writes
+	result chan error
+}
+
+// hardwareWriter performs all hardware writes from a single goroutine, so
+// client requests and the monitor can't interleave a write with another
+// write's verification, and rapid back and forth switching is slowed down
+type hardwareWriter struct {
+	writes   chan hardwareWrite
+	stop     chan struct{}
+	stopped  chan struct{}
+	cooldown time.Duration
+
+	// Last conservation mode switch (writer goroutine only)
+	lastMode   *bool
+	lastSwitch time.Time
+}
+
+// newHardwareWriter starts a writer
+func newHardwareWriter(cooldown time.Duration) *hardwareWriter {
+	w := &hardwareWriter{
+		writes:   make(chan hardwareWrite),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		cooldown: cooldown,
+	}
+	go w.run()
+	return w
+}
+
+// run performs writes one at a time until the writer is stopped
+func (w *hardwareWriter) run() {
+	defer close(w.stopped)
+
+	for {
+		select {
+		case write := <-w.writes:
+			write.result <- w.perform(write)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// perform applies a write. A conservation mode switch repeating the last one
+// within the cooldown is skipped, and one reversing it waits for the
+// cooldown to pass.
+func (w *hardwareWriter) perform(write hardwareWrite) error {
+	if write.mode == nil {
+		// Other writes may switch conservation mode too, e.g. set_limits
+		w.lastMode = nil
+		return write.apply()
+	}
+
+	if w.lastMode != nil {
+		wait := w.cooldown - time.Since(w.lastSwitch)
+		if wait > 0 && *w.lastMode == *write.mode {
+			return nil
+		}
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-w.stop:
+			}
+		}
+	}
+
+	if err := write.apply(); err != nil {
+		// The hardware state is unknown, don't debounce the next switch
+		w.lastMode = nil
+		return err
+	}
+	mode := *write.mode
+	w.lastMode = &mode
+	w.lastSwitch = time.Now()
+	return nil
+}
+
+// submit hands a write to the writer and waits for its result
+func (w *hardwareWriter) submit(write hardwareWrite) error {
+	write.result = make(chan error, 1)
+	select {
+	case w.writes <- write:
+		return <-write.result
+	case <-w.stopped:
+		return errWriterStopped
+	}
+}
+
+// close stops the writer after the write in progress
+func (w *hardwareWriter) close() {
+	close(w.stop)
+	<-w.stopped
+}
+
+// writeHardware applies a hardware write through the writer, or directly
+// while the daemon isn't running
+func (d *Daemon) writeHardware(apply func() error) error {
+	if d.writer == nil {
+		return apply()
+	}
+	return d.writer.submit(hardwareWrite{apply: apply})
+}
+
+// writeConservationMode switches conservation mode through the writer
+func (d *Daemon) writeConservationMode(enable bool) error {
+	apply := func() error { return d.hardware.SetConservationMode(enable) }
+	if d.writer == nil {
+		return apply()
+	}
+	return d.writer.submit(hardwareWrite{apply: apply, mode: &enable})
+}
@@ -0,0 +1,256 @@
+// Package direct changes battery settings without the daemon, for recovery
+// and minimal installs. It uses the daemon's state file and hardware backend,
+// so a daemon started later carries on with the same settings.
+package direct
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+// chargeFullLevel is the battery level at which a charge-full override ends
+const chargeFullLevel = 100
+
+// Controller applies settings straight to the state file and hardware
+type Controller struct {
+	state    *state.Manager
+	hardware hardware.Backend
+}
+
+// Result describes the battery after a change was applied
+type Result struct {
+	BatteryLevel     int
+	Charging         bool
+	Threshold        int
+	StartThreshold   int
+	Managed          bool // Battery management is enabled
+	Paused           bool // Automation is paused, so conservation mode is left alone
+	ConservationMode bool // Conservation mode after applying the change
+	Switched         bool // Conservation mode was switched by the change
+}
+
+// HardwareError wraps a failure to read or control the battery, as opposed
+// to a failure to load or save the settings
+type HardwareError struct {
+	Op  string
+	Err error
+}
+
+func (e *HardwareError) Error() string {
+	return fmt.Sprintf("failed to %s: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the failure and, for common ones, the protocol error with
+// the fix shown to the user
+func (e *HardwareError) Unwrap() []error {
+	switch {
+	case errors.Is(e.Err, fs.ErrPermission):
+		return []error{e.Err, protocol.ErrSysfsPermission}
+	case errors.Is(e.Err, hardware.ErrModuleNotLoaded):
+		return []error{e.Err, protocol.ErrModuleNotLoaded}
+	}
+	return []error{e.Err}
+}
+
+// Open loads the state file, and the runtime state file unless runtimePath is
+// empty, and creates the configured hardware backend
+func Open(statePath, runtimePath string, cfg *config.Config) (*Controller, error) {
+	backend, err := hardware.NewBackend(hardware.BackendName(cfg.Hardware.Backend), hardware.SysfsRoot(cfg.Hardware.SysfsRoot))
+	if err != nil {
+		return nil, &HardwareError{Op: "open hardware backend", Err: err}
+	}
+
+	manager := state.NewManager(statePath)
+	manager.SetRuntimePath(runtimePath)
+	manager.SetBackupLimit(cfg.State.Backups)
+	manager.SetHysteresis(cfg.Management.Hysteresis)
+	if err := manager.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	return NewController(manager, backend), nil
+}
+
+// NewController creates a controller for a loaded state and a backend
+func NewController(manager *state.Manager, backend hardware.Backend) *Controller {
+	return &Controller{state: manager, hardware: backend}
+}
+
+// Enable enables battery management and applies it right away
+func (c *Controller) Enable() (Result, error) {
+	return c.apply(func() error {
+		if err := c.state.EnableConservation(); err != nil {
+			return fmt.Errorf("failed to enable conservation: %w", err)
+		}
+		return nil
+	})
+}
+
+// Disable switches conservation mode off and disables battery management
+func (c *Controller) Disable() (Result, error) {
+	battery, err := c.hardware.ReadBattery()
+	if err != nil {
+		return Result{}, &HardwareError{Op: "read battery", Err: err}
+	}
+
+	switched := false
+	if battery.ConservationMode {
+		if err := c.hardware.SetConservationMode(false); err != nil {
+			return Result{}, &HardwareError{Op: "disable conservation mode", Err: err}
+		}
+		switched = true
+	}
+
+	if err := c.state.UpdateBatteryInfo(battery.Level, false, battery.ACOnline); err != nil {
+		return Result{}, fmt.Errorf("failed to update state: %w", err)
+	}
+	if err := c.state.DisableConservation(); err != nil {
+		return Result{}, fmt.Errorf("failed to disable conservation: %w", err)
+	}
+
+	result := c.result(battery)
+	result.ConservationMode = false
+	result.Switched = switched
+	return result, nil
+}
+
+// SetThreshold sets the charge threshold, and where charging resumes unless
+// start is 0, then applies it right away
+func (c *Controller) SetThreshold(threshold, start int) (Result, error) {
+	allowed := protocol.ThresholdRangeData(hardware.ThresholdRangeOf(c.hardware))
+	if err := protocol.ValidateThresholdRange(threshold, allowed); err != nil {
+		return Result{}, err
+	}
+
+	if start > 0 {
+		if err := protocol.ValidateStartThreshold(start, threshold); err != nil {
+			return Result{}, err
+		}
+	}
+
+	return c.apply(func() error {
+		if start > 0 {
+			if err := c.state.SetChargeThresholds(threshold, start); err != nil {
+				return fmt.Errorf("failed to set thresholds: %w", err)
+			}
+		} else if err := c.state.SetChargeThreshold(threshold); err != nil {
+			return fmt.Errorf("failed to set threshold: %w", err)
+		}
+		return nil
+	})
+}
+
+// Apply reads the battery and switches conservation mode the way the
+// daemon's monitor would
+func (c *Controller) Apply() (Result, error) {
+	return c.apply(nil)
+}
+
+// Check does what one pass of the daemon's monitor does: it ends an expired
+// temporary disable, pause or a finished charge-full, then switches
+// conservation mode to match the settings
+func (c *Controller) Check(now time.Time) (Result, error) {
+	return c.apply(func() error {
+		if _, err := c.state.ReenableIfDue(now); err != nil {
+			return fmt.Errorf("failed to re-enable management: %w", err)
+		}
+		if _, err := c.state.ResumeIfDue(now); err != nil {
+			return fmt.Errorf("failed to resume automation: %w", err)
+		}
+		if c.state.IsChargeFull() && c.state.GetBatteryLevel() >= chargeFullLevel {
+			if err := c.state.FinishChargeFull(); err != nil {
+				return fmt.Errorf("failed to finish charge-full: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Preview reads the battery and reports what Apply would do, without
+// switching conservation mode or writing the state file
+func (c *Controller) Preview() (Result, error) {
+	battery, err := c.hardware.ReadBattery()
+	if err != nil {
+		return Result{}, &HardwareError{Op: "read battery", Err: err}
+	}
+
+	enable, disable := c.state.Preview(func(s *state.State) {
+		s.BatteryLevel = battery.Level
+		s.ConservationMode = battery.ConservationMode
+		s.Charging = battery.ACOnline
+	})
+
+	result := c.result(battery)
+	if result.Paused {
+		return result, nil
+	}
+	if enable && !battery.ConservationMode {
+		result.ConservationMode, result.Switched = true, true
+	} else if disable && battery.ConservationMode {
+		result.ConservationMode, result.Switched = false, true
+	}
+	return result, nil
+}
+
+// apply records a battery reading, makes the change if there is one, and
+// switches conservation mode to match the settings unless automation is
+// paused
+func (c *Controller) apply(change func() error) (Result, error) {
+	battery, err := c.hardware.ReadBattery()
+	if err != nil {
+		return Result{}, &HardwareError{Op: "read battery", Err: err}
+	}
+
+	if err := c.state.UpdateBatteryInfo(battery.Level, battery.ConservationMode, battery.ACOnline); err != nil {
+		return Result{}, fmt.Errorf("failed to update state: %w", err)
+	}
+	if change != nil {
+		if err := change(); err != nil {
+			return Result{}, err
+		}
+	}
+
+	enable := battery.ConservationMode
+	if c.state.ShouldEnableConservation() {
+		enable = true
+	} else if c.state.ShouldDisableConservation() {
+		enable = false
+	}
+
+	result := c.result(battery)
+	if result.Paused || enable == battery.ConservationMode {
+		return result, nil
+	}
+
+	if err := c.hardware.SetConservationMode(enable); err != nil {
+		return result, &HardwareError{Op: "set conservation mode", Err: err}
+	}
+	if err := c.state.UpdateConservationMode(enable); err != nil {
+		return result, fmt.Errorf("failed to update state: %w", err)
+	}
+
+	result.ConservationMode = enable
+	result.Switched = true
+	return result, nil
+}
+
+// result describes the battery reading together with the current settings
+func (c *Controller) result(battery hardware.Battery) Result {
+	paused, _ := c.state.GetPause()
+	return Result{
+		BatteryLevel:     battery.Level,
+		Charging:         battery.ACOnline,
+		Threshold:        c.state.GetEffectiveThreshold(),
+		StartThreshold:   c.state.GetStartThreshold(),
+		Managed:          c.state.GetConservationEnabled(),
+		Paused:           paused,
+		ConservationMode: battery.ConservationMode,
+	}
+}
@@ -0,0 +1,141 @@
+package direct
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+type fakeBackend struct {
+	battery hardware.Battery
+	writes  int
+}
+
+func (f *fakeBackend) Name() string { return "fake" }
+
+func (f *fakeBackend) ReadBattery() (hardware.Battery, error) { return f.battery, nil }
+
+func (f *fakeBackend) SetConservationMode(enable bool) error {
+	f.battery.ConservationMode = enable
+	f.writes++
+	return nil
+}
+
+func (f *fakeBackend) ReadLimits() (hardware.Limits, error) { return hardware.Limits{}, nil }
+
+func (f *fakeBackend) SetLimits(limits hardware.Limits) error { return nil }
+
+func newTestController(t *testing.T, battery hardware.Battery) (*Controller, *fakeBackend, *state.Manager) {
+	t.Helper()
+
+	manager := state.NewManager(filepath.Join(t.TempDir(), "state.json"))
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	backend := &fakeBackend{battery: battery}
+	return NewController(manager, backend), backend, manager
+}
+
+func TestControllerEnable(t *testing.T) {
+	tests := []struct {
+		name         string
+		battery      hardware.Battery
+		wantMode     bool
+		wantSwitched bool
+	}{
+		{"above threshold on AC", hardware.Battery{Level: 85, ACOnline: true}, true, true},
+		{"below threshold on AC", hardware.Battery{Level: 50, ACOnline: true, ConservationMode: true}, false, true},
+		{"on battery", hardware.Battery{Level: 85}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller, backend, manager := newTestController(t, tt.battery)
+
+			result, err := controller.Enable()
+			if err != nil {
+				t.Fatalf("Enable() error = %v", err)
+			}
+			if !manager.GetConservationEnabled() || !result.Managed {
+				t.Error("Expected management enabled")
+			}
+			if result.ConservationMode != tt.wantMode || backend.battery.ConservationMode != tt.wantMode {
+				t.Errorf("Conservation mode = %v (hardware %v), want %v",
+					result.ConservationMode, backend.battery.ConservationMode, tt.wantMode)
+			}
+			if result.Switched != tt.wantSwitched {
+				t.Errorf("Switched = %v, want %v", result.Switched, tt.wantSwitched)
+			}
+		})
+	}
+}
+
+func TestControllerDisable(t *testing.T) {
+	controller, backend, manager := newTestController(t, hardware.Battery{Level: 85, ACOnline: true, ConservationMode: true})
+
+	result, err := controller.Disable()
+	if err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+	if manager.GetConservationEnabled() || result.Managed {
+		t.Error("Expected management disabled")
+	}
+	if backend.battery.ConservationMode || !result.Switched {
+		t.Error("Expected conservation mode switched off")
+	}
+}
+
+func TestControllerSetThreshold(t *testing.T) {
+	controller, backend, manager := newTestController(t, hardware.Battery{Level: 85, ACOnline: true})
+	if _, err := controller.Enable(); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+
+	if _, err := controller.SetThreshold(50, 0); err == nil {
+		t.Error("Expected error for threshold below the minimum")
+	}
+	if _, err := controller.SetThreshold(90, 95); err == nil {
+		t.Error("Expected error for start threshold above the threshold")
+	}
+
+	result, err := controller.SetThreshold(90, 80)
+	if err != nil {
+		t.Fatalf("SetThreshold() error = %v", err)
+	}
+	if manager.GetChargeThreshold() != 90 || result.StartThreshold != 80 {
+		t.Errorf("Thresholds = %d/%d, want 90/80", manager.GetChargeThreshold(), result.StartThreshold)
+	}
+	// 85% is between the start threshold and the threshold, so the held
+	// charge is kept rather than toggling
+	if !backend.battery.ConservationMode || result.Switched {
+		t.Errorf("Expected conservation mode kept on, got mode %v switched %v",
+			backend.battery.ConservationMode, result.Switched)
+	}
+}
+
+func TestControllerCheckPaused(t *testing.T) {
+	controller, backend, manager := newTestController(t, hardware.Battery{Level: 85, ACOnline: true})
+	manager.UpdateState(func(s *state.State) { s.ConservationEnabled = true })
+	if err := manager.Pause(time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+
+	result, err := controller.Check(time.Now())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !result.Paused || result.Switched || backend.writes != 0 {
+		t.Errorf("Expected conservation mode left alone while paused, got %+v with %d writes", result, backend.writes)
+	}
+
+	result, err = controller.Check(time.Now().Add(2 * time.Hour))
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if result.Paused || !result.Switched || !backend.battery.ConservationMode {
+		t.Errorf("Expected conservation mode switched on after the pause expired, got %+v", result)
+	}
+}
@@ -0,0 +1,193 @@
+// Package dock detects how the laptop is set up on the desk: whether the lid
+// is closed and whether it is docked, i.e. drives an external display.
+package dock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrUnavailable is returned when neither the kernel nor logind report the
+// lid or the displays
+var ErrUnavailable = errors.New("lid and display state unavailable")
+
+// Conditions a docking rule can match
+const (
+	Docked    = "docked"   // An external display is connected
+	Undocked  = "undocked" // No external display is connected
+	LidClosed = "lid_closed"
+	LidOpen   = "lid_open"
+	Clamshell = "clamshell" // Docked with the lid closed
+)
+
+// Conditions lists the valid docking rule conditions
+var Conditions = []string{Docked, Undocked, LidClosed, LidOpen, Clamshell}
+
+// IsValidCondition reports whether a docking rule condition is known
+func IsValidCondition(condition string) bool {
+	return slices.Contains(Conditions, condition)
+}
+
+// internalPanels are the DRM connector types of built-in screens
+var internalPanels = []string{"eDP", "LVDS", "DSI"}
+
+// Paths are the kernel interfaces read for the lid and displays
+type Paths struct {
+	LidDir string // ACPI lid buttons, one directory each with a "state" file
+	DRMDir string // DRM devices and connectors, e.g. card1-HDMI-A-1
+}
+
+// DefaultPaths are the standard locations of the kernel interfaces
+var DefaultPaths = Paths{
+	LidDir: "/proc/acpi/button/lid",
+	DRMDir: "/sys/class/drm",
+}
+
+// WithRoot returns the paths below root, e.g. a fake sysfs tree; an empty
+// root returns them unchanged
+func (p Paths) WithRoot(root string) Paths {
+	if root == "" {
+		return p
+	}
+	return Paths{
+		LidDir: filepath.Join(root, p.LidDir),
+		DRMDir: filepath.Join(root, p.DRMDir),
+	}
+}
+
+// State is the lid and display state at one reading
+type State struct {
+	LidClosed bool
+	Displays  []string // Connected external display connectors, e.g. "HDMI-A-1"
+}
+
+// Docked reports whether an external display is connected
+func (s State) Docked() bool {
+	return len(s.Displays) > 0
+}
+
+// Matches reports whether the state satisfies a docking rule condition
+func (s State) Matches(condition string) bool {
+	switch condition {
+	case Docked:
+		return s.Docked()
+	case Undocked:
+		return !s.Docked()
+	case LidClosed:
+		return s.LidClosed
+	case LidOpen:
+		return !s.LidClosed
+	case Clamshell:
+		return s.Docked() && s.LidClosed
+	}
+	return false
+}
+
+// Sensor reads the lid and display state from the kernel, asking logind
+// for what the kernel doesn't expose
+type Sensor struct {
+	paths Paths
+
+	// logind reads a boolean property of logind's manager object
+	logind func(property string) (bool, error)
+}
+
+// NewSensor creates a sensor reading the kernel interfaces at paths
+func NewSensor(paths Paths) *Sensor {
+	return &Sensor{paths: paths, logind: readLogind}
+}
+
+// Read returns the current lid and display state
+func (s *Sensor) Read() (State, error) {
+	var state State
+
+	closed, lidErr := s.lidClosed()
+	if lidErr != nil {
+		closed, lidErr = s.logind("LidClosed")
+	}
+	state.LidClosed = closed
+
+	displays, drmErr := s.externalDisplays()
+	if drmErr != nil {
+		// logind only says whether the system is docked, not with what
+		var docked bool
+		if docked, drmErr = s.logind("Docked"); docked {
+			displays = []string{"dock"}
+		}
+	}
+	state.Displays = displays
+
+	if lidErr != nil && drmErr != nil {
+		return state, fmt.Errorf("%w: %w", ErrUnavailable, errors.Join(lidErr, drmErr))
+	}
+	return state, nil
+}
+
+// lidClosed reads the ACPI lid button, whose state file reads e.g.
+// "state:      closed"
+func (s *Sensor) lidClosed() (bool, error) {
+	lids, err := os.ReadDir(s.paths.LidDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to read lid state: %w", err)
+	}
+	for _, lid := range lids {
+		data, err := os.ReadFile(filepath.Join(s.paths.LidDir, lid.Name(), "state"))
+		if err != nil {
+			continue
+		}
+		return strings.Contains(string(data), "closed"), nil
+	}
+	return false, fmt.Errorf("failed to read lid state: no lid in %s", s.paths.LidDir)
+}
+
+// externalDisplays lists the connected connectors that aren't built-in
+// panels
+func (s *Sensor) externalDisplays() ([]string, error) {
+	entries, err := os.ReadDir(s.paths.DRMDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read displays: %w", err)
+	}
+
+	var displays []string
+	for _, entry := range entries {
+		// Connectors are named after their card, e.g. card1-HDMI-A-1
+		card, connector, ok := strings.Cut(entry.Name(), "-")
+		if !ok || !strings.HasPrefix(card, "card") || isInternalPanel(connector) {
+			continue
+		}
+		status, err := os.ReadFile(filepath.Join(s.paths.DRMDir, entry.Name(), "status"))
+		if err != nil || strings.TrimSpace(string(status)) != "connected" {
+			continue
+		}
+		displays = append(displays, connector)
+	}
+	return displays, nil
+}
+
+// isInternalPanel reports whether a connector drives a built-in screen
+func isInternalPanel(connector string) bool {
+	kind, _, _ := strings.Cut(connector, "-")
+	return slices.Contains(internalPanels, kind)
+}
+
+// readLogind reads a boolean property of logind's manager, whose busctl
+// output reads e.g. "b true"
+func readLogind(property string) (bool, error) {
+	output, err := exec.Command("busctl", "get-property", "org.freedesktop.login1",
+		"/org/freedesktop/login1", "org.freedesktop.login1.Manager", property).Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s from logind: %w", property, err)
+	}
+	switch strings.TrimSpace(string(output)) {
+	case "b true":
+		return true, nil
+	case "b false":
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected %s from logind: %q", property, strings.TrimSpace(string(output)))
+}
@@ -0,0 +1,115 @@
+package dock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSensorRead(t *testing.T) {
+	noLogind := func(property string) (bool, error) { return false, errors.New("no logind") }
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		logind   func(property string) (bool, error)
+		expected State
+		wantErr  bool
+	}{
+		{
+			name: "docked in clamshell",
+			files: map[string]string{
+				"proc/acpi/button/lid/LID0/state":     "state:      closed",
+				"sys/class/drm/card1-eDP-1/status":    "connected",
+				"sys/class/drm/card1-HDMI-A-1/status": "connected",
+				"sys/class/drm/card1-DP-2/status":     "disconnected",
+			},
+			logind:   noLogind,
+			expected: State{LidClosed: true, Displays: []string{"HDMI-A-1"}},
+		},
+		{
+			name: "undocked",
+			files: map[string]string{
+				"proc/acpi/button/lid/LID/state":   "state:      open",
+				"sys/class/drm/card0-eDP-1/status": "connected",
+			},
+			logind:   noLogind,
+			expected: State{},
+		},
+		{
+			name:  "falls back to logind",
+			files: map[string]string{},
+			logind: func(property string) (bool, error) {
+				return property == "Docked", nil
+			},
+			expected: State{Displays: []string{"dock"}},
+		},
+		{
+			name:    "unavailable",
+			files:   map[string]string{},
+			logind:  noLogind,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, value := range tt.files {
+				path := filepath.Join(root, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+				}
+				if err := os.WriteFile(path, []byte(value+"\n"), 0644); err != nil {
+					t.Fatalf("Failed to write %s: %v", path, err)
+				}
+			}
+
+			sensor := NewSensor(DefaultPaths.WithRoot(root))
+			sensor.logind = tt.logind
+
+			state, err := sensor.Read()
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnavailable) {
+					t.Errorf("Expected ErrUnavailable, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if state.LidClosed != tt.expected.LidClosed || !slices.Equal(state.Displays, tt.expected.Displays) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, state)
+			}
+		})
+	}
+}
+
+func TestStateMatches(t *testing.T) {
+	clamshell := State{LidClosed: true, Displays: []string{"DP-1"}}
+	docked := State{Displays: []string{"DP-1"}}
+	closed := State{LidClosed: true}
+
+	tests := []struct {
+		state     State
+		condition string
+		expected  bool
+	}{
+		{clamshell, Clamshell, true},
+		{docked, Clamshell, false},
+		{docked, Docked, true},
+		{closed, Docked, false},
+		{closed, Undocked, true},
+		{closed, LidClosed, true},
+		{docked, LidOpen, true},
+		{docked, "sunny", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.state.Matches(tt.condition); got != tt.expected {
+			t.Errorf("%+v matches %q = %v, want %v", tt.state, tt.condition, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package events dispatches daemon events, such as conservation mode
+// toggles, to notification handlers without blocking the daemon.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type identifies an event
+type Type string
+
+// Event types
+const (
+	ManagementEnabled  Type = "management-enabled"  // Battery management turned on
+	ManagementDisabled Type = "management-disabled" // Battery management turned off
+	ConservationOn     Type = "conservation-on"     // Hardware conservation mode switched on
+	ConservationOff    Type = "conservation-off"    // Hardware conservation mode switched off
+	ThresholdReached   Type = "threshold-reached"   // Battery reached the charge threshold again since AC was plugged in
+	ChargePaused       Type = "charge-paused"       // Charging paused at the threshold for the first time since AC was plugged in
+	ThresholdChanged   Type = "threshold-changed"   // Charge threshold changed
+	BatteryLow         Type = "battery-low"         // Battery fell to the low alert level on battery power
+	BatteryCritical    Type = "battery-critical"    // Battery fell to the critical alert level on battery power
+	DischargingOnAC    Type = "discharging-on-ac"   // Battery kept discharging although AC is plugged in
+	LowChargePower     Type = "low-charge-power"    // Battery charging far slower than usual
+	RuleTriggered      Type = "rule-triggered"      // A notify rule's conditions started to hold
+	Error              Type = "error"               // The daemon failed to manage the battery
+)
+
+// Types lists every event type
+var Types = []Type{
+	ManagementEnabled,
+	ManagementDisabled,
+	ConservationOn,
+	ConservationOff,
+	ThresholdReached,
+	ChargePaused,
+	ThresholdChanged,
+	BatteryLow,
+	BatteryCritical,
+	DischargingOnAC,
+	LowChargePower,
+	RuleTriggered,
+	Error,
+}
+
+// IsValidType checks if an event type name is known
+func IsValidType(name string) bool {
+	for _, t := range Types {
+		if string(t) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// queueSize bounds the events waiting for slow handlers
+const queueSize = 64
+
+// Event describes something that happened in the daemon
+type Event struct {
+	Type         Type      `json:"event"`
+	Time         time.Time `json:"time"`
+	BatteryLevel int       `json:"battery_level"`
+	Threshold    int       `json:"threshold"`
+	Message      string    `json:"message"` // Human-readable description
+}
+
+// Handler receives dispatched events
+type Handler interface {
+	// Name identifies the handler in error reports
+	Name() string
+
+	// Handle processes an event
+	Handle(event Event) error
+}
+
+// Dispatcher queues events and delivers them to the handlers on a separate
+// goroutine, so slow handlers never delay battery management
+type Dispatcher struct {
+	mutex       sync.RWMutex
+	handlers    []Handler
+	subscribers map[chan Event]struct{}
+	queue       chan Event
+	onError     func(handler string, event Event, err error)
+}
+
+// NewDispatcher creates a dispatcher reporting handler failures to onError
+func NewDispatcher(onError func(handler string, event Event, err error)) *Dispatcher {
+	return &Dispatcher{
+		queue:   make(chan Event, queueSize),
+		onError: onError,
+	}
+}
+
+// SetHandlers replaces the handlers receiving events
+func (d *Dispatcher) SetHandlers(handlers []Handler) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.handlers = handlers
+}
+
+// Subscribe returns a channel receiving every event delivered from now on,
+// e.g. for a client watching events, and a function ending the
+// subscription. Events are dropped while the channel is full, so a slow
+// subscriber never delays the handlers.
+func (d *Dispatcher) Subscribe() (<-chan Event, func()) {
+	events := make(chan Event, queueSize)
+
+	d.mutex.Lock()
+	if d.subscribers == nil {
+		d.subscribers = make(map[chan Event]struct{})
+	}
+	d.subscribers[events] = struct{}{}
+	d.mutex.Unlock()
+
+	return events, func() {
+		d.mutex.Lock()
+		delete(d.subscribers, events)
+		d.mutex.Unlock()
+	}
+}
+
+// Emit queues an event, dropping it if the queue is full. Events without a
+// time are stamped with the current time.
+func (d *Dispatcher) Emit(event Event) bool {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	select {
+	case d.queue <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run delivers queued events until done is closed
+func (d *Dispatcher) Run(done <-chan bool) {
+	for {
+		select {
+		case event := <-d.queue:
+			d.deliver(event)
+		case <-done:
+			return
+		}
+	}
+}
+
+// deliver passes an event to every handler and subscriber
+func (d *Dispatcher) deliver(event Event) {
+	d.mutex.RLock()
+	handlers := d.handlers
+	for subscriber := range d.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+	d.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler.Handle(event); err != nil && d.onError != nil {
+			d.onError(handler.Name(), event, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// recorder collects delivered events
+type recorder struct {
+	events chan Event
+	err    error
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Handle(event Event) error {
+	r.events <- event
+	return r.err
+}
+
+func TestDispatcher(t *testing.T) {
+	failures := make(chan string, 1)
+	dispatcher := NewDispatcher(func(handler string, event Event, err error) {
+		failures <- handler + ": " + err.Error()
+	})
+
+	handler := &recorder{events: make(chan Event, 1), err: errors.New("boom")}
+	dispatcher.SetHandlers([]Handler{handler})
+
+	done := make(chan bool)
+	defer close(done)
+	go dispatcher.Run(done)
+
+	if !dispatcher.Emit(Event{Type: ThresholdReached, BatteryLevel: 80}) {
+		t.Fatal("Expected event to be queued")
+	}
+
+	select {
+	case event := <-handler.events:
+		if event.Type != ThresholdReached || event.BatteryLevel != 80 || event.Time.IsZero() {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Event was not delivered")
+	}
+
+	select {
+	case failure := <-failures:
+		if failure != "recorder: boom" {
+			t.Errorf("Unexpected failure report %q", failure)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Handler failure was not reported")
+	}
+}
+
+func TestDispatcherDropsWhenFull(t *testing.T) {
+	dispatcher := NewDispatcher(nil)
+
+	for i := 0; i < queueSize; i++ {
+		if !dispatcher.Emit(Event{Type: ConservationOn}) {
+			t.Fatalf("Expected event %d to be queued", i)
+		}
+	}
+	if dispatcher.Emit(Event{Type: ConservationOn}) {
+		t.Error("Expected event to be dropped with a full queue")
+	}
+}
+
+func TestDispatcherSubscribe(t *testing.T) {
+	dispatcher := NewDispatcher(nil)
+	subscription, cancel := dispatcher.Subscribe()
+
+	done := make(chan bool)
+	defer close(done)
+	go dispatcher.Run(done)
+
+	dispatcher.Emit(Event{Type: ChargePaused, BatteryLevel: 80})
+	select {
+	case event := <-subscription:
+		if event.Type != ChargePaused || event.BatteryLevel != 80 {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Event was not delivered to the subscriber")
+	}
+
+	cancel()
+	dispatcher.Emit(Event{Type: ThresholdReached})
+	select {
+	case event := <-subscription:
+		t.Errorf("Expected no event after cancelling, got %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,53 @@
+package hardware
+
+import (
+	"os"
+	"strings"
+)
+
+// Detection lists which of the sysfs controls the daemon uses are present
+type Detection struct {
+	Battery          bool // Battery capacity is readable
+	ACAdapter        bool
+	ConservationMode bool // ideapad_acpi conservation mode, required for management
+	RapidCharge      bool
+	PlatformProfile  bool   // ACPI platform_profile, for power-mode
+	ForceDischarge   bool   // charge_behaviour offers force-discharge
+	EndThreshold     bool   // charge_control_end_threshold, for thresholds below 60%
+	BatteryModel     string // Manufacturer and model, if the battery reports them
+}
+
+// Supported reports whether the daemon can manage the battery
+func (d Detection) Supported() bool {
+	return d.Battery && d.ConservationMode
+}
+
+// ThresholdRange returns the range of thresholds the detected hardware can hold
+func (d Detection) ThresholdRange() ThresholdRange {
+	return thresholdRange(d.EndThreshold)
+}
+
+// Detect checks which controls exist at the paths
+func Detect(paths Paths) Detection {
+	backend := NewSysfsBackendWithPaths(paths)
+	detection := Detection{
+		Battery:          exists(backend.batteryAttr("capacity")),
+		ACAdapter:        exists(paths.ACOnline),
+		ConservationMode: exists(paths.ConservationMode),
+		RapidCharge:      exists(paths.RapidCharge),
+		PlatformProfile:  exists(paths.PlatformProfile),
+		ForceDischarge:   backend.ForceDischargeSupported(),
+		EndThreshold:     backend.hasEndThreshold(),
+	}
+
+	if info, err := backend.ReadInfo(); err == nil {
+		detection.BatteryModel = strings.TrimSpace(info.Manufacturer + " " + info.ModelName)
+	}
+	return detection
+}
+
+// exists reports whether a sysfs attribute is present
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
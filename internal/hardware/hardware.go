@@ -0,0 +1,180 @@
+package hardware
+
+import "fmt"
+
+// Battery holds a single reading of the battery and power supply
+type Battery struct {
+	Level            int  // Charge level in percent
+	ConservationMode bool // Hardware conservation mode state
+	ACOnline         bool // AC adapter connected
+}
+
+// Limits describes the charge-related controls of the hardware. Nil fields
+// are not supported by the backend (when read) or left unchanged (when set).
+type Limits struct {
+	ConservationMode *bool
+	StartThreshold   *int    // Start charging below this level
+	EndThreshold     *int    // Stop charging at this level
+	RapidCharge      *bool   // Legion rapid charge
+	ChargeType       *string // Charge rate, e.g. "Standard", "Fast", "Trickle"
+}
+
+// ThresholdRange is the range of charge thresholds the hardware can hold, in
+// percent
+type ThresholdRange struct {
+	Min  int
+	Max  int
+	Step int // Granularity, e.g. 5 for firmware taking multiples of 5%
+}
+
+// MinThreshold is the lowest threshold any backend can hold
+const MinThreshold = 40
+
+var (
+	// ConservationRange applies to batteries held through conservation mode,
+	// which charges them to 60% (Legion Slim 7): lower thresholds can't be held
+	ConservationRange = ThresholdRange{Min: 60, Max: 100, Step: 1}
+
+	// EndThresholdRange applies to batteries with a native end threshold
+	// (charge_control_end_threshold)
+	EndThresholdRange = ThresholdRange{Min: MinThreshold, Max: 100, Step: 1}
+)
+
+// ThresholdRanger is implemented by backends whose threshold range depends
+// on the hardware
+type ThresholdRanger interface {
+	// ThresholdRange returns the range of thresholds the hardware can hold
+	ThresholdRange() ThresholdRange
+}
+
+// ThresholdRangeOf returns the range of thresholds backend can hold;
+// ConservationRange unless it tells otherwise
+func ThresholdRangeOf(backend Backend) ThresholdRange {
+	if ranger, ok := backend.(ThresholdRanger); ok {
+		return ranger.ThresholdRange()
+	}
+	return ConservationRange
+}
+
+// HasEndThreshold reports whether backend stops charging at a native end
+// threshold, set through SetLimits
+func HasEndThreshold(backend Backend) bool {
+	return ThresholdRangeOf(backend) == EndThresholdRange
+}
+
+// thresholdRange returns the range of thresholds hardware with or without a
+// native end threshold can hold
+func thresholdRange(endThreshold bool) ThresholdRange {
+	if endThreshold {
+		return EndThresholdRange
+	}
+	return ConservationRange
+}
+
+// Backends lists the names accepted by NewBackend
+var Backends = []string{"sysfs", "upower", "mock"}
+
+// NewBackend creates the backend with the given name. sysfsRoot prefixes
+// the sysfs paths, see SysfsRoot; empty uses the real /sys.
+func NewBackend(name, sysfsRoot string) (Backend, error) {
+	sysfs := NewSysfsBackendWithPaths(DefaultPaths.WithRoot(sysfsRoot))
+
+	switch name {
+	case "", "sysfs":
+		return sysfs, nil
+	case "upower":
+		backend := NewUPowerBackend()
+		backend.SysfsBackend = sysfs
+		return backend, nil
+	case "mock":
+		return NewMockBackend(), nil
+	}
+	return nil, fmt.Errorf("unknown hardware backend %q", name)
+}
+
+// Backend provides access to the battery hardware
+type Backend interface {
+	// Name returns a short identifier for the backend
+	Name() string
+
+	// ReadBattery reads the current battery state
+	ReadBattery() (Battery, error)
+
+	// SetConservationMode switches conservation mode and verifies the change
+	SetConservationMode(enable bool) error
+
+	// ReadLimits reads every charge control the hardware supports
+	ReadLimits() (Limits, error)
+
+	// SetLimits applies the non-nil fields of limits
+	SetLimits(limits Limits) error
+}
+
+// Health describes the battery capacity compared to its design capacity.
+// Capacities are in the unit reported by the hardware.
+type Health struct {
+	Full       int    // Current full charge capacity
+	FullDesign int    // Design capacity
+	Unit       string // "µWh" (energy) or "µAh" (charge)
+	CycleCount int    // Charge cycles, 0 if unknown
+}
+
+// HealthReader is implemented by backends that can read battery health
+type HealthReader interface {
+	// ReadHealth reads the battery capacity and cycle count
+	ReadHealth() (Health, error)
+}
+
+// Info holds diagnostic readings and identification of the battery. Values
+// are in the units of the power_supply class; zero or empty means unreported.
+type Info struct {
+	Status       string // e.g. "Charging", "Discharging", "Not charging"
+	VoltageNow   int    // µV
+	CurrentNow   int    // µA
+	PowerNow     int    // µW
+	Temperature  int    // Tenths of a degree Celsius
+	Manufacturer string
+	ModelName    string
+	SerialNumber string
+	Technology   string // e.g. "Li-ion", "Li-poly"
+}
+
+// InfoReader is implemented by backends that can read battery diagnostics
+type InfoReader interface {
+	// ReadInfo reads the battery's electrical readings and identification
+	ReadInfo() (Info, error)
+}
+
+// Adapter describes the connected power adapter
+type Adapter struct {
+	Name  string  // power_supply name, e.g. "ADP1" or "ucsi-source-psy-USBC000:001"
+	Type  string  // "barrel" or "usb-c"
+	PD    bool    // USB Power Delivery was negotiated
+	Watts float64 // Negotiated or rated power, 0 if not exposed
+}
+
+// AdapterReader is implemented by backends that can identify the power adapter
+type AdapterReader interface {
+	// ReadAdapter reads the connected adapter, nil if none is connected
+	ReadAdapter() (*Adapter, error)
+}
+
+// PowerProfiler is implemented by backends that can switch the platform power
+// profile, e.g. "quiet", "balanced" or "performance"
+type PowerProfiler interface {
+	// ReadPowerProfile reads the active profile and the profiles to choose from
+	ReadPowerProfile() (profile string, choices []string, err error)
+
+	// SetPowerProfile switches the platform profile
+	SetPowerProfile(profile string) error
+}
+
+// Discharger is implemented by backends that can discharge the battery while
+// on AC power
+type Discharger interface {
+	// ForceDischargeSupported reports whether the hardware can force discharge
+	ForceDischargeSupported() bool
+
+	// SetForceDischarge starts or stops discharging on AC power
+	SetForceDischarge(enable bool) error
+}
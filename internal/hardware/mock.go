@@ -0,0 +1,262 @@
+package hardware
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// BackendEnv selects the backend, overriding hardware.backend in the config,
+// e.g. LEGIONBATCTL_BACKEND=mock to develop on a machine without the hardware
+const BackendEnv = "LEGIONBATCTL_BACKEND"
+
+// BackendName returns the backend from LEGIONBATCTL_BACKEND if set,
+// otherwise the configured one
+func BackendName(configured string) string {
+	if name := os.Getenv(BackendEnv); name != "" {
+		return name
+	}
+	return configured
+}
+
+// Simulated battery behaviour of the mock backend
+const (
+	MockChargeRate       = 40.0 // Percent per hour on AC
+	MockDischargeRate    = 12.0 // Percent per hour on battery or force-discharging
+	MockConservationHold = 60   // Level conservation mode charges to and holds
+	mockInitialLevel     = 50
+	mockFullDesign       = 52_000_000 // µWh
+	mockFull             = 47_000_000 // µWh
+	mockAdapterWatts     = 230
+)
+
+// MockBackend simulates a Lenovo battery in memory, for development on other
+// machines and in CI. The level drifts with the time between readings: it
+// rises on AC up to 100%, or up to MockConservationHold with conservation
+// mode on, and falls on battery or while force-discharging. Like Legion
+// firmware, switching rapid charge on turns conservation mode off and vice
+// versa.
+type MockBackend struct {
+	mutex          sync.Mutex
+	now            func() time.Time
+	updated        time.Time // When level was last advanced
+	level          float64
+	acOnline       bool
+	conservation   bool
+	forceDischarge bool
+	rapidCharge    bool
+	powerProfile   string
+}
+
+// mockPowerProfiles are the platform profiles of the mock, as on Legion laptops
+var mockPowerProfiles = []string{"quiet", "balanced", "performance"}
+
+// NewMockBackend creates a mock battery at 50% on AC power
+func NewMockBackend() *MockBackend {
+	return NewMockBackendWithClock(time.Now)
+}
+
+// NewMockBackendWithClock creates a mock battery whose drift follows now,
+// e.g. a simulated clock
+func NewMockBackendWithClock(now func() time.Time) *MockBackend {
+	return &MockBackend{
+		now:          now,
+		updated:      now(),
+		level:        mockInitialLevel,
+		acOnline:     true,
+		powerProfile: "balanced",
+	}
+}
+
+// Name returns the backend name
+func (b *MockBackend) Name() string {
+	return "mock"
+}
+
+// ReadBattery reads the simulated battery
+func (b *MockBackend) ReadBattery() (Battery, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	return Battery{
+		Level:            b.percent(),
+		ConservationMode: b.conservation,
+		ACOnline:         b.acOnline,
+	}, nil
+}
+
+// SetConservationMode switches the simulated conservation mode
+func (b *MockBackend) SetConservationMode(enable bool) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	b.conservation = enable
+	if enable {
+		b.rapidCharge = false
+	}
+	return nil
+}
+
+// ReadLimits reads the simulated controls: conservation mode and rapid charge
+func (b *MockBackend) ReadLimits() (Limits, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	conservation, rapidCharge := b.conservation, b.rapidCharge
+	return Limits{ConservationMode: &conservation, RapidCharge: &rapidCharge}, nil
+}
+
+// SetLimits applies the simulated controls; other limits are ignored
+func (b *MockBackend) SetLimits(limits Limits) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	if limits.ConservationMode != nil {
+		b.conservation = *limits.ConservationMode
+		if b.conservation {
+			b.rapidCharge = false
+		}
+	}
+	if limits.RapidCharge != nil {
+		b.rapidCharge = *limits.RapidCharge
+		if b.rapidCharge {
+			b.conservation = false
+		}
+	}
+	return nil
+}
+
+// ReadHealth reports a slightly worn battery
+func (b *MockBackend) ReadHealth() (Health, error) {
+	return Health{Full: mockFull, FullDesign: mockFullDesign, Unit: "µWh", CycleCount: 120}, nil
+}
+
+// ReadInfo reports the simulated status along with fixed readings
+func (b *MockBackend) ReadInfo() (Info, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	return Info{
+		Status:       b.status(),
+		VoltageNow:   16_800_000,
+		Temperature:  300,
+		Manufacturer: "legionbatctl",
+		ModelName:    "Mock Battery",
+		Technology:   "Li-poly",
+	}, nil
+}
+
+// ReadAdapter reports a barrel adapter while AC is online
+func (b *MockBackend) ReadAdapter() (*Adapter, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.acOnline {
+		return nil, nil
+	}
+	return &Adapter{Name: "ADP1", Type: "barrel", Watts: mockAdapterWatts}, nil
+}
+
+// ReadPowerProfile reads the simulated platform profile
+func (b *MockBackend) ReadPowerProfile() (string, []string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.powerProfile, slices.Clone(mockPowerProfiles), nil
+}
+
+// SetPowerProfile switches the simulated platform profile
+func (b *MockBackend) SetPowerProfile(profile string) error {
+	if !slices.Contains(mockPowerProfiles, profile) {
+		return fmt.Errorf("platform profile %q not supported", profile)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.powerProfile = profile
+	return nil
+}
+
+// ForceDischargeSupported reports that the mock can force discharge
+func (b *MockBackend) ForceDischargeSupported() bool {
+	return true
+}
+
+// SetForceDischarge starts or stops discharging on AC power
+func (b *MockBackend) SetForceDischarge(enable bool) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	b.forceDischarge = enable
+	return nil
+}
+
+// SetLevel sets the simulated charge level in percent
+func (b *MockBackend) SetLevel(level int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	b.level = math.Max(0, math.Min(100, float64(level)))
+}
+
+// SetACOnline plugs the simulated AC adapter in or out
+func (b *MockBackend) SetACOnline(online bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.advance()
+	b.acOnline = online
+}
+
+// advance drifts the level over the time since the last update (caller must
+// hold the mutex)
+func (b *MockBackend) advance() {
+	now := b.now()
+	hours := now.Sub(b.updated).Hours()
+	b.updated = now
+	if hours <= 0 {
+		return
+	}
+
+	switch {
+	case !b.acOnline || b.forceDischarge:
+		b.level = math.Max(0, b.level-MockDischargeRate*hours)
+	case b.conservation:
+		if b.level < MockConservationHold {
+			b.level = math.Min(MockConservationHold, b.level+MockChargeRate*hours)
+		}
+	default:
+		b.level = math.Min(100, b.level+MockChargeRate*hours)
+	}
+}
+
+// percent returns the level as the hardware reports it (caller must hold the
+// mutex)
+func (b *MockBackend) percent() int {
+	return int(math.Round(b.level))
+}
+
+// status returns the power_supply status for the simulated state (caller
+// must hold the mutex)
+func (b *MockBackend) status() string {
+	switch {
+	case !b.acOnline || b.forceDischarge:
+		return "Discharging"
+	case b.percent() >= 100:
+		return "Full"
+	case b.conservation && b.level >= MockConservationHold:
+		return "Not charging"
+	default:
+		return "Charging"
+	}
+}
@@ -0,0 +1,121 @@
+package hardware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMockBackendDrift(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	backend := NewMockBackendWithClock(func() time.Time { return now })
+
+	tests := []struct {
+		name    string
+		setup   func()
+		elapsed time.Duration
+		want    Battery
+	}{
+		{
+			name:    "charges on AC",
+			elapsed: 30 * time.Minute,
+			want:    Battery{Level: 70, ACOnline: true},
+		},
+		{
+			name:    "conservation mode holds once reached",
+			setup:   func() { backend.SetConservationMode(true) },
+			elapsed: time.Hour,
+			want:    Battery{Level: 70, ConservationMode: true, ACOnline: true},
+		},
+		{
+			name:    "discharges on battery",
+			setup:   func() { backend.SetACOnline(false) },
+			elapsed: time.Hour,
+			want:    Battery{Level: 58, ConservationMode: true},
+		},
+		{
+			name:    "conservation mode charges up to its hold level",
+			setup:   func() { backend.SetACOnline(true) },
+			elapsed: time.Hour,
+			want:    Battery{Level: MockConservationHold, ConservationMode: true, ACOnline: true},
+		},
+		{
+			name: "force discharge on AC",
+			setup: func() {
+				backend.SetConservationMode(false)
+				backend.SetForceDischarge(true)
+			},
+			elapsed: 30 * time.Minute,
+			want:    Battery{Level: 54, ACOnline: true},
+		},
+		{
+			name: "stops at full",
+			setup: func() {
+				backend.SetForceDischarge(false)
+				backend.SetLevel(95)
+			},
+			elapsed: time.Hour,
+			want:    Battery{Level: 100, ACOnline: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			now = now.Add(tt.elapsed)
+
+			battery, err := backend.ReadBattery()
+			if err != nil {
+				t.Fatalf("ReadBattery() error = %v", err)
+			}
+			if battery != tt.want {
+				t.Errorf("ReadBattery() = %+v, want %+v", battery, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackendNameFromEnv(t *testing.T) {
+	t.Setenv(BackendEnv, "")
+	if got := BackendName("upower"); got != "upower" {
+		t.Errorf("BackendName() = %s, want the configured backend", got)
+	}
+
+	t.Setenv(BackendEnv, "mock")
+	if got := BackendName("upower"); got != "mock" {
+		t.Errorf("BackendName() = %s, want mock from the environment", got)
+	}
+
+	backend, err := NewBackend(BackendName(""), "")
+	if err != nil {
+		t.Fatalf("NewBackend() error = %v", err)
+	}
+	if backend.Name() != "mock" {
+		t.Errorf("NewBackend() = %s backend, want mock", backend.Name())
+	}
+}
+
+func TestMockBackendRapidCharge(t *testing.T) {
+	backend := NewMockBackend()
+	on := true
+
+	if err := backend.SetLimits(Limits{RapidCharge: &on}); err != nil {
+		t.Fatalf("SetLimits() error = %v", err)
+	}
+	if err := backend.SetConservationMode(true); err != nil {
+		t.Fatalf("SetConservationMode() error = %v", err)
+	}
+	limits, _ := backend.ReadLimits()
+	if !*limits.ConservationMode || *limits.RapidCharge {
+		t.Errorf("Expected conservation mode to switch rapid charge off, got %+v", limits)
+	}
+
+	if err := backend.SetLimits(Limits{RapidCharge: &on}); err != nil {
+		t.Fatalf("SetLimits() error = %v", err)
+	}
+	limits, _ = backend.ReadLimits()
+	if *limits.ConservationMode || !*limits.RapidCharge {
+		t.Errorf("Expected rapid charge to switch conservation mode off, got %+v", limits)
+	}
+}
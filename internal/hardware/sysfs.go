@@ -0,0 +1,464 @@
+package hardware
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrModuleNotLoaded is returned when conservation mode is missing because
+// the ideapad_laptop module providing it isn't loaded
+var ErrModuleNotLoaded = errors.New("ideapad_laptop module not loaded")
+
+// Paths lists the sysfs attributes used by the sysfs backend
+type Paths struct {
+	BatteryDir       string // power_supply directory of the battery
+	ACOnline         string // AC adapter "online" attribute
+	ConservationMode string // ideapad_acpi conservation_mode attribute
+	RapidCharge      string // legion-laptop rapidcharge attribute
+	PlatformProfile  string // ACPI platform_profile attribute, with its choices in platform_profile_choices
+}
+
+// DefaultPaths are the sysfs locations on Lenovo Legion laptops
+var DefaultPaths = Paths{
+	BatteryDir:       "/sys/class/power_supply/BAT0",
+	ACOnline:         "/sys/class/power_supply/ADP1/online",
+	ConservationMode: "/sys/bus/platform/drivers/ideapad_acpi/VPC2004:00/conservation_mode",
+	RapidCharge:      "/sys/bus/platform/drivers/legion/PNP0C09:00/rapidcharge",
+	PlatformProfile:  "/sys/firmware/acpi/platform_profile",
+}
+
+// SysfsRootEnv prefixes every sysfs path, overriding hardware.sysfs_root in
+// the config, e.g. to point the daemon at a fake sysfs tree in tests or
+// containers
+const SysfsRootEnv = "SYSFS_ROOT"
+
+// SysfsRoot returns the sysfs root from SYSFS_ROOT if set, otherwise the
+// configured one; empty means the real /sys
+func SysfsRoot(configured string) string {
+	if root := os.Getenv(SysfsRootEnv); root != "" {
+		return root
+	}
+	return configured
+}
+
+// WithRoot returns the paths below root, e.g. /tmp/fake/sys/class/... for
+// the root /tmp/fake. An empty root leaves them unchanged.
+func (p Paths) WithRoot(root string) Paths {
+	if root == "" {
+		return p
+	}
+	return Paths{
+		BatteryDir:       filepath.Join(root, p.BatteryDir),
+		ACOnline:         filepath.Join(root, p.ACOnline),
+		ConservationMode: filepath.Join(root, p.ConservationMode),
+		RapidCharge:      filepath.Join(root, p.RapidCharge),
+		PlatformProfile:  filepath.Join(root, p.PlatformProfile),
+	}
+}
+
+// SysfsBackend reads and writes the battery controls through sysfs
+type SysfsBackend struct {
+	paths Paths
+}
+
+// NewSysfsBackend creates a sysfs backend using the default paths
+func NewSysfsBackend() *SysfsBackend {
+	return NewSysfsBackendWithPaths(DefaultPaths)
+}
+
+// NewSysfsBackendWithPaths creates a sysfs backend using custom paths
+func NewSysfsBackendWithPaths(paths Paths) *SysfsBackend {
+	return &SysfsBackend{paths: paths}
+}
+
+// Name returns the backend name
+func (b *SysfsBackend) Name() string {
+	return "sysfs"
+}
+
+// Paths returns the sysfs paths used by the backend
+func (b *SysfsBackend) Paths() Paths {
+	return b.paths
+}
+
+// ReadBattery reads the battery level, conservation mode and AC state
+func (b *SysfsBackend) ReadBattery() (Battery, error) {
+	var battery Battery
+
+	level, err := readInt(b.batteryAttr("capacity"))
+	if err != nil {
+		return battery, fmt.Errorf("failed to read battery capacity: %w", err)
+	}
+	battery.Level = level
+
+	conservation, err := readInt(b.paths.ConservationMode)
+	if err != nil {
+		return battery, fmt.Errorf("failed to read conservation mode: %w", moduleError(err))
+	}
+	battery.ConservationMode = conservation == 1
+
+	// AC adapter status is more reliable than the battery status while
+	// conservation mode is active ("Not charging" despite being plugged in)
+	acOnline, err := readInt(b.paths.ACOnline)
+	if err != nil {
+		// Fallback to battery status if AC adapter is not available
+		status, err := readString(b.batteryAttr("status"))
+		if err != nil {
+			return battery, fmt.Errorf("failed to read battery status: %w", err)
+		}
+		battery.ACOnline = status == "Charging"
+		return battery, nil
+	}
+	battery.ACOnline = acOnline == 1
+
+	return battery, nil
+}
+
+// SetConservationMode writes conservation_mode and verifies the change was applied
+func (b *SysfsBackend) SetConservationMode(enable bool) error {
+	value := "0"
+	if enable {
+		value = "1"
+	}
+
+	if err := writeVerified(b.paths.ConservationMode, value); err != nil {
+		return fmt.Errorf("conservation mode: %w", moduleError(err))
+	}
+	return nil
+}
+
+// moduleError marks a missing conservation_mode attribute as ErrModuleNotLoaded
+func moduleError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %w", ErrModuleNotLoaded, err)
+	}
+	return err
+}
+
+// ThresholdRange returns EndThresholdRange for batteries with a native end
+// threshold, and ConservationRange otherwise
+func (b *SysfsBackend) ThresholdRange() ThresholdRange {
+	return thresholdRange(b.hasEndThreshold())
+}
+
+// hasEndThreshold reports whether the battery has a native end threshold
+func (b *SysfsBackend) hasEndThreshold() bool {
+	return exists(b.batteryAttr("charge_control_end_threshold"))
+}
+
+// ReadLimits reads every supported charge control, leaving unsupported ones nil
+func (b *SysfsBackend) ReadLimits() (Limits, error) {
+	var limits Limits
+
+	if value, err := readInt(b.paths.ConservationMode); err == nil {
+		enabled := value == 1
+		limits.ConservationMode = &enabled
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return limits, fmt.Errorf("failed to read conservation mode: %w", err)
+	}
+
+	if value, err := readInt(b.batteryAttr("charge_control_start_threshold")); err == nil {
+		limits.StartThreshold = &value
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return limits, fmt.Errorf("failed to read start threshold: %w", err)
+	}
+
+	if value, err := readInt(b.batteryAttr("charge_control_end_threshold")); err == nil {
+		limits.EndThreshold = &value
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return limits, fmt.Errorf("failed to read end threshold: %w", err)
+	}
+
+	if value, err := readInt(b.paths.RapidCharge); err == nil {
+		enabled := value == 1
+		limits.RapidCharge = &enabled
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return limits, fmt.Errorf("failed to read rapid charge: %w", err)
+	}
+
+	if value, err := readString(b.batteryAttr("charge_type")); err == nil {
+		limits.ChargeType = &value
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return limits, fmt.Errorf("failed to read charge type: %w", err)
+	}
+
+	return limits, nil
+}
+
+// SetLimits writes the non-nil fields of limits
+func (b *SysfsBackend) SetLimits(limits Limits) error {
+	if limits.ConservationMode != nil {
+		if err := b.SetConservationMode(*limits.ConservationMode); err != nil {
+			return err
+		}
+	}
+
+	// The kernel rejects a start threshold at or above the end threshold, so
+	// start must stay below end after each write: end is written first when
+	// it is raised, start first when end is lowered
+	endFirst := true
+	if limits.StartThreshold != nil && limits.EndThreshold != nil {
+		if current, err := readInt(b.batteryAttr("charge_control_end_threshold")); err == nil && *limits.EndThreshold < current {
+			endFirst = false
+		}
+	}
+	if endFirst {
+		if err := b.writeThreshold("end", limits.EndThreshold); err != nil {
+			return err
+		}
+	}
+	if err := b.writeThreshold("start", limits.StartThreshold); err != nil {
+		return err
+	}
+	if !endFirst {
+		if err := b.writeThreshold("end", limits.EndThreshold); err != nil {
+			return err
+		}
+	}
+
+	if limits.RapidCharge != nil {
+		value := "0"
+		if *limits.RapidCharge {
+			value = "1"
+		}
+		if err := writeAttr(b.paths.RapidCharge, value); err != nil {
+			return fmt.Errorf("rapid charge: %w", err)
+		}
+	}
+
+	if limits.ChargeType != nil {
+		if err := writeAttr(b.batteryAttr("charge_type"), *limits.ChargeType); err != nil {
+			return fmt.Errorf("charge type: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// writeThreshold writes the start or end charge threshold, if set
+func (b *SysfsBackend) writeThreshold(which string, value *int) error {
+	if value == nil {
+		return nil
+	}
+	if err := writeAttr(b.batteryAttr("charge_control_"+which+"_threshold"), strconv.Itoa(*value)); err != nil {
+		return fmt.Errorf("%s threshold: %w", which, err)
+	}
+	return nil
+}
+
+// ReadHealth reads the full and design capacity, preferring energy_* over
+// charge_* attributes, and the cycle count where available
+func (b *SysfsBackend) ReadHealth() (Health, error) {
+	health := Health{Unit: "µWh"}
+
+	full, err := readInt(b.batteryAttr("energy_full"))
+	if errors.Is(err, fs.ErrNotExist) {
+		health.Unit = "µAh"
+		full, err = readInt(b.batteryAttr("charge_full"))
+	}
+	if err != nil {
+		return health, fmt.Errorf("failed to read full capacity: %w", err)
+	}
+	health.Full = full
+
+	design := "energy_full_design"
+	if health.Unit == "µAh" {
+		design = "charge_full_design"
+	}
+	if health.FullDesign, err = readInt(b.batteryAttr(design)); err != nil {
+		return health, fmt.Errorf("failed to read design capacity: %w", err)
+	}
+
+	// Not every battery reports its cycle count
+	if cycles, err := readInt(b.batteryAttr("cycle_count")); err == nil {
+		health.CycleCount = cycles
+	}
+
+	return health, nil
+}
+
+// ReadInfo reads the battery status along with whichever diagnostic
+// attributes the battery reports
+func (b *SysfsBackend) ReadInfo() (Info, error) {
+	var info Info
+
+	status, err := readString(b.batteryAttr("status"))
+	if err != nil {
+		return info, fmt.Errorf("failed to read battery status: %w", err)
+	}
+	info.Status = status
+
+	// The remaining attributes are optional and vary between batteries
+	for name, value := range map[string]*int{
+		"voltage_now": &info.VoltageNow,
+		"current_now": &info.CurrentNow,
+		"power_now":   &info.PowerNow,
+		"temp":        &info.Temperature,
+	} {
+		if n, err := readInt(b.batteryAttr(name)); err == nil {
+			*value = n
+		}
+	}
+	for name, value := range map[string]*string{
+		"manufacturer":  &info.Manufacturer,
+		"model_name":    &info.ModelName,
+		"serial_number": &info.SerialNumber,
+		"technology":    &info.Technology,
+	} {
+		if s, err := readString(b.batteryAttr(name)); err == nil {
+			*value = s
+		}
+	}
+
+	return info, nil
+}
+
+// ForceDischargeSupported reports whether charge_behaviour offers force-discharge
+func (b *SysfsBackend) ForceDischargeSupported() bool {
+	behaviours, err := readString(b.batteryAttr("charge_behaviour"))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(behaviours, "force-discharge")
+}
+
+// SetForceDischarge switches charge_behaviour between force-discharge and auto
+func (b *SysfsBackend) SetForceDischarge(enable bool) error {
+	value := "auto"
+	if enable {
+		value = "force-discharge"
+	}
+
+	if err := writeVerified(b.batteryAttr("charge_behaviour"), value); err != nil {
+		return fmt.Errorf("charge behaviour: %w", err)
+	}
+	return nil
+}
+
+// ReadAdapter finds the connected adapter among the power supplies next to
+// the AC adapter. A USB-C source is preferred, as the Mains supply may read
+// online for it too. The power is the negotiated maximum where the supply
+// exposes it, as USB-C sources do through UCSI.
+func (b *SysfsBackend) ReadAdapter() (*Adapter, error) {
+	dir := filepath.Dir(filepath.Dir(b.paths.ACOnline))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list power supplies: %w", err)
+	}
+
+	var mains *Adapter
+	for _, entry := range entries {
+		supply := filepath.Join(dir, entry.Name())
+		kind, err := readString(filepath.Join(supply, "type"))
+		if err != nil || (kind != "Mains" && kind != "USB") {
+			continue
+		}
+		if online, err := readInt(filepath.Join(supply, "online")); err != nil || online != 1 {
+			continue
+		}
+
+		adapter := &Adapter{Name: entry.Name(), Type: "barrel", Watts: supplyWatts(supply)}
+		if kind == "Mains" {
+			mains = adapter
+			continue
+		}
+		adapter.Type = "usb-c"
+		if usbType, err := readString(filepath.Join(supply, "usb_type")); err == nil {
+			adapter.PD = strings.Contains(usbType, "[PD]") || strings.Contains(usbType, "[PD_PPS]")
+		}
+		return adapter, nil
+	}
+	return mains, nil
+}
+
+// supplyWatts computes the maximum power of a power supply from its voltage
+// and current limits, 0 if they aren't exposed
+func supplyWatts(supply string) float64 {
+	voltage, err := readInt(filepath.Join(supply, "voltage_max"))
+	if err != nil {
+		if voltage, err = readInt(filepath.Join(supply, "voltage_now")); err != nil {
+			return 0
+		}
+	}
+	current, err := readInt(filepath.Join(supply, "current_max"))
+	if err != nil {
+		return 0
+	}
+	return float64(voltage) * float64(current) / 1e12
+}
+
+// ReadPowerProfile reads the active platform profile and its choices
+func (b *SysfsBackend) ReadPowerProfile() (string, []string, error) {
+	profile, err := readString(b.paths.PlatformProfile)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read platform profile: %w", err)
+	}
+
+	choices, err := readString(b.paths.PlatformProfile + "_choices")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read platform profile choices: %w", err)
+	}
+	return profile, strings.Fields(choices), nil
+}
+
+// SetPowerProfile switches the platform profile
+func (b *SysfsBackend) SetPowerProfile(profile string) error {
+	if err := writeVerified(b.paths.PlatformProfile, profile); err != nil {
+		return fmt.Errorf("platform profile: %w", err)
+	}
+	return nil
+}
+
+// batteryAttr returns the path of a battery power_supply attribute
+func (b *SysfsBackend) batteryAttr(name string) string {
+	return b.paths.BatteryDir + "/" + name
+}
+
+// readString reads a sysfs attribute with surrounding whitespace removed
+func readString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readInt reads an integer sysfs attribute
+func readInt(path string) (int, error) {
+	value, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return n, nil
+}
+
+// writeAttr writes a charge control attribute (replaced in tests)
+var writeAttr = writeVerified
+
+// writeVerified writes a sysfs attribute and reads it back to verify the change
+func writeVerified(path, value string) error {
+	if err := os.WriteFile(path, []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	actual, err := readString(path)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", path, err)
+	}
+
+	// Some attributes list every choice with the active one in brackets
+	if actual != value && !strings.Contains(actual, "["+value+"]") {
+		return fmt.Errorf("%s not updated: expected %s, got %s", path, value, actual)
+	}
+	return nil
+}
@@ -0,0 +1,490 @@
+package hardware
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// newFakeSysfs creates a fake sysfs tree with the given attribute values
+func newFakeSysfs(t *testing.T, attrs map[string]string) Paths {
+	t.Helper()
+	root := t.TempDir()
+
+	paths := Paths{
+		BatteryDir:       filepath.Join(root, "BAT0"),
+		ACOnline:         filepath.Join(root, "ADP1", "online"),
+		ConservationMode: filepath.Join(root, "ideapad", "conservation_mode"),
+		RapidCharge:      filepath.Join(root, "legion", "rapidcharge"),
+		PlatformProfile:  filepath.Join(root, "acpi", "platform_profile"),
+	}
+
+	for name, value := range attrs {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(value+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	return paths
+}
+
+func TestSysfsReadBattery(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrs    map[string]string
+		expected Battery
+	}{
+		{
+			name: "ac online",
+			attrs: map[string]string{
+				"BAT0/capacity":             "76",
+				"ideapad/conservation_mode": "1",
+				"ADP1/online":               "1",
+			},
+			expected: Battery{Level: 76, ConservationMode: true, ACOnline: true},
+		},
+		{
+			name: "falls back to battery status",
+			attrs: map[string]string{
+				"BAT0/capacity":             "40",
+				"BAT0/status":               "Charging",
+				"ideapad/conservation_mode": "0",
+			},
+			expected: Battery{Level: 40, ConservationMode: false, ACOnline: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewSysfsBackendWithPaths(newFakeSysfs(t, tt.attrs))
+
+			battery, err := backend.ReadBattery()
+			if err != nil {
+				t.Fatalf("ReadBattery failed: %v", err)
+			}
+			if battery != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, battery)
+			}
+		})
+	}
+
+	// Without conservation mode the module providing it isn't loaded
+	backend := NewSysfsBackendWithPaths(newFakeSysfs(t, map[string]string{"BAT0/capacity": "50"}))
+	if _, err := backend.ReadBattery(); !errors.Is(err, ErrModuleNotLoaded) {
+		t.Errorf("Expected ErrModuleNotLoaded, got %v", err)
+	}
+	if err := backend.SetConservationMode(true); !errors.Is(err, ErrModuleNotLoaded) {
+		t.Errorf("Expected ErrModuleNotLoaded, got %v", err)
+	}
+}
+
+func TestSysfsLimits(t *testing.T) {
+	backend := NewSysfsBackendWithPaths(newFakeSysfs(t, map[string]string{
+		"ideapad/conservation_mode":           "0",
+		"BAT0/charge_control_start_threshold": "0",
+		"BAT0/charge_control_end_threshold":   "100",
+	}))
+
+	limits, err := backend.ReadLimits()
+	if err != nil {
+		t.Fatalf("ReadLimits failed: %v", err)
+	}
+	if limits.RapidCharge != nil || limits.ChargeType != nil {
+		t.Error("Expected missing attributes to be reported as unsupported")
+	}
+	if limits.EndThreshold == nil || *limits.EndThreshold != 100 {
+		t.Errorf("Expected end threshold 100, got %v", limits.EndThreshold)
+	}
+
+	start, end, enabled := 40, 80, true
+	err = backend.SetLimits(Limits{ConservationMode: &enabled, StartThreshold: &start, EndThreshold: &end})
+	if err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+
+	limits, err = backend.ReadLimits()
+	if err != nil {
+		t.Fatalf("ReadLimits failed: %v", err)
+	}
+	if *limits.StartThreshold != 40 || *limits.EndThreshold != 80 || !*limits.ConservationMode {
+		t.Errorf("Expected limits to be applied, got start=%d end=%d conservation=%v",
+			*limits.StartThreshold, *limits.EndThreshold, *limits.ConservationMode)
+	}
+}
+
+func TestSysfsLimitsWriteOrder(t *testing.T) {
+	paths := newFakeSysfs(t, map[string]string{
+		"BAT0/charge_control_start_threshold": "70",
+		"BAT0/charge_control_end_threshold":   "80",
+	})
+	startPath := filepath.Join(paths.BatteryDir, "charge_control_start_threshold")
+	endPath := filepath.Join(paths.BatteryDir, "charge_control_end_threshold")
+
+	// Like the kernel, reject a start threshold at or above the end threshold
+	writeAttr = func(path, value string) error {
+		start, _ := readInt(startPath)
+		end, _ := readInt(endPath)
+		n, _ := strconv.Atoi(value)
+		if (path == startPath && n >= end) || (path == endPath && n <= start) {
+			return errors.New("invalid argument")
+		}
+		return writeVerified(path, value)
+	}
+	t.Cleanup(func() { writeAttr = writeVerified })
+
+	backend := NewSysfsBackendWithPaths(paths)
+	steps := []struct {
+		name       string
+		start, end int
+	}{
+		{"lower both past the old start", 40, 50},
+		{"raise both past the old end", 75, 90},
+		{"lower end only", 75, 80},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			start, end := step.start, step.end
+			if err := backend.SetLimits(Limits{StartThreshold: &start, EndThreshold: &end}); err != nil {
+				t.Fatalf("SetLimits failed: %v", err)
+			}
+			limits, err := backend.ReadLimits()
+			if err != nil {
+				t.Fatalf("ReadLimits failed: %v", err)
+			}
+			if *limits.StartThreshold != start || *limits.EndThreshold != end {
+				t.Errorf("Expected %d-%d, got %d-%d", start, end, *limits.StartThreshold, *limits.EndThreshold)
+			}
+		})
+	}
+}
+
+func TestSysfsThresholdRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrs    map[string]string
+		expected ThresholdRange
+	}{
+		{
+			name:     "conservation mode only",
+			attrs:    map[string]string{"ideapad/conservation_mode": "0"},
+			expected: ConservationRange,
+		},
+		{
+			name:     "native end threshold",
+			attrs:    map[string]string{"BAT0/charge_control_end_threshold": "100"},
+			expected: EndThresholdRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewSysfsBackendWithPaths(newFakeSysfs(t, tt.attrs))
+			if bounds := ThresholdRangeOf(backend); bounds != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, bounds)
+			}
+		})
+	}
+
+	if bounds := ThresholdRangeOf(NewMockBackend()); bounds != ConservationRange {
+		t.Errorf("Expected backends without a range to default to %+v, got %+v", ConservationRange, bounds)
+	}
+}
+
+func TestSysfsForceDischarge(t *testing.T) {
+	backend := NewSysfsBackendWithPaths(newFakeSysfs(t, map[string]string{
+		"BAT0/charge_behaviour": "[auto] inhibit-charge force-discharge",
+	}))
+
+	if !backend.ForceDischargeSupported() {
+		t.Fatal("Expected force discharge to be supported")
+	}
+	if err := backend.SetForceDischarge(true); err != nil {
+		t.Fatalf("SetForceDischarge failed: %v", err)
+	}
+
+	unsupported := NewSysfsBackendWithPaths(newFakeSysfs(t, nil))
+	if unsupported.ForceDischargeSupported() {
+		t.Error("Expected force discharge to be unsupported without charge_behaviour")
+	}
+}
+
+func TestSysfsPowerProfile(t *testing.T) {
+	backend := NewSysfsBackendWithPaths(newFakeSysfs(t, map[string]string{
+		"acpi/platform_profile":         "balanced",
+		"acpi/platform_profile_choices": "quiet balanced performance",
+	}))
+
+	profile, choices, err := backend.ReadPowerProfile()
+	if err != nil {
+		t.Fatalf("ReadPowerProfile failed: %v", err)
+	}
+	if profile != "balanced" || len(choices) != 3 || choices[0] != "quiet" {
+		t.Errorf("ReadPowerProfile() = %s, %v", profile, choices)
+	}
+
+	if err := backend.SetPowerProfile("quiet"); err != nil {
+		t.Fatalf("SetPowerProfile failed: %v", err)
+	}
+	if profile, _, _ := backend.ReadPowerProfile(); profile != "quiet" {
+		t.Errorf("Expected the quiet profile, got %s", profile)
+	}
+
+	unsupported := NewSysfsBackendWithPaths(newFakeSysfs(t, nil))
+	if _, _, err := unsupported.ReadPowerProfile(); err == nil {
+		t.Error("Expected an error without platform_profile")
+	}
+}
+
+func TestSysfsReadAdapter(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		want  *Adapter
+	}{
+		{
+			name: "barrel",
+			attrs: map[string]string{
+				"ADP1/type":   "Mains",
+				"ADP1/online": "1",
+			},
+			want: &Adapter{Name: "ADP1", Type: "barrel"},
+		},
+		{
+			name: "USB-C PD preferred over mains",
+			attrs: map[string]string{
+				"ADP1/type":               "Mains",
+				"ADP1/online":             "1",
+				"ucsi-source/type":        "USB",
+				"ucsi-source/online":      "1",
+				"ucsi-source/usb_type":    "C [PD] PD_PPS",
+				"ucsi-source/voltage_max": "20000000",
+				"ucsi-source/current_max": "5000000",
+			},
+			want: &Adapter{Name: "ucsi-source", Type: "usb-c", PD: true, Watts: 100},
+		},
+		{
+			name: "on battery",
+			attrs: map[string]string{
+				"ADP1/type":          "Mains",
+				"ADP1/online":        "0",
+				"ucsi-source/type":   "USB",
+				"ucsi-source/online": "0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewSysfsBackendWithPaths(newFakeSysfs(t, tt.attrs))
+			adapter, err := backend.ReadAdapter()
+			if err != nil {
+				t.Fatalf("ReadAdapter failed: %v", err)
+			}
+			if (adapter == nil) != (tt.want == nil) || (adapter != nil && *adapter != *tt.want) {
+				t.Errorf("ReadAdapter() = %+v, want %+v", adapter, tt.want)
+			}
+		})
+	}
+}
+
+func TestSysfsReadHealth(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrs    map[string]string
+		expected Health
+	}{
+		{
+			name: "energy",
+			attrs: map[string]string{
+				"BAT0/energy_full":        "57000000",
+				"BAT0/energy_full_design": "71000000",
+				"BAT0/cycle_count":        "312",
+			},
+			expected: Health{Full: 57000000, FullDesign: 71000000, Unit: "µWh", CycleCount: 312},
+		},
+		{
+			name: "charge without cycle count",
+			attrs: map[string]string{
+				"BAT0/charge_full":        "4000000",
+				"BAT0/charge_full_design": "4500000",
+			},
+			expected: Health{Full: 4000000, FullDesign: 4500000, Unit: "µAh"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewSysfsBackendWithPaths(newFakeSysfs(t, tt.attrs))
+
+			health, err := backend.ReadHealth()
+			if err != nil {
+				t.Fatalf("ReadHealth failed: %v", err)
+			}
+			if health != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, health)
+			}
+		})
+	}
+}
+
+func TestSysfsReadInfo(t *testing.T) {
+	backend := NewSysfsBackendWithPaths(newFakeSysfs(t, map[string]string{
+		"BAT0/status":        "Charging",
+		"BAT0/voltage_now":   "16843000",
+		"BAT0/current_now":   "2310000",
+		"BAT0/manufacturer":  "Celxpert",
+		"BAT0/model_name":    "L20C4PC1",
+		"BAT0/serial_number": "1234",
+		"BAT0/technology":    "Li-poly",
+	}))
+
+	info, err := backend.ReadInfo()
+	if err != nil {
+		t.Fatalf("ReadInfo failed: %v", err)
+	}
+
+	expected := Info{
+		Status:       "Charging",
+		VoltageNow:   16843000,
+		CurrentNow:   2310000,
+		Manufacturer: "Celxpert",
+		ModelName:    "L20C4PC1",
+		SerialNumber: "1234",
+		Technology:   "Li-poly",
+	}
+	if info != expected {
+		t.Errorf("Expected %+v, got %+v", expected, info)
+	}
+
+	missing := NewSysfsBackendWithPaths(newFakeSysfs(t, nil))
+	if _, err := missing.ReadInfo(); err == nil {
+		t.Error("Expected error without battery status")
+	}
+}
+
+func TestNewBackendWithSysfsRoot(t *testing.T) {
+	root := t.TempDir()
+	attrs := map[string]string{
+		DefaultPaths.BatteryDir + "/capacity": "64",
+		DefaultPaths.ConservationMode:         "1",
+		DefaultPaths.ACOnline:                 "0",
+	}
+	for path, value := range attrs {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(value+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"sysfs", "upower"} {
+		backend, err := NewBackend(name, root)
+		if err != nil {
+			t.Fatalf("NewBackend(%q) error = %v", name, err)
+		}
+
+		var sysfs *SysfsBackend
+		switch b := backend.(type) {
+		case *SysfsBackend:
+			sysfs = b
+		case *UPowerBackend:
+			sysfs = b.SysfsBackend
+		}
+		if got, want := sysfs.Paths().ConservationMode, filepath.Join(root, DefaultPaths.ConservationMode); got != want {
+			t.Errorf("%s conservation mode path = %s, want %s", name, got, want)
+		}
+	}
+
+	backend, err := NewBackend("sysfs", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	battery, err := backend.ReadBattery()
+	if err != nil {
+		t.Fatalf("ReadBattery() error = %v", err)
+	}
+	if want := (Battery{Level: 64, ConservationMode: true}); battery != want {
+		t.Errorf("ReadBattery() = %+v, want %+v", battery, want)
+	}
+}
+
+func TestSysfsRootFromEnv(t *testing.T) {
+	t.Setenv(SysfsRootEnv, "")
+	if got := SysfsRoot("/srv/sysfs"); got != "/srv/sysfs" {
+		t.Errorf("SysfsRoot() = %s, want the configured root", got)
+	}
+
+	t.Setenv(SysfsRootEnv, "/tmp/fake")
+	if got := SysfsRoot("/srv/sysfs"); got != "/tmp/fake" {
+		t.Errorf("SysfsRoot() = %s, want %s from the environment", got, "/tmp/fake")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		attrs     map[string]string
+		expected  Detection
+		supported bool
+	}{
+		{
+			name: "legion laptop",
+			attrs: map[string]string{
+				"BAT0/capacity":             "76",
+				"BAT0/status":               "Charging",
+				"BAT0/manufacturer":         "Celxpert",
+				"BAT0/model_name":           "L20C4PC1",
+				"BAT0/charge_behaviour":     "[auto] inhibit-charge force-discharge",
+				"ADP1/online":               "1",
+				"ideapad/conservation_mode": "0",
+			},
+			expected: Detection{
+				Battery:          true,
+				ACAdapter:        true,
+				ConservationMode: true,
+				ForceDischarge:   true,
+				BatteryModel:     "Celxpert L20C4PC1",
+			},
+			supported: true,
+		},
+		{
+			name: "no conservation mode",
+			attrs: map[string]string{
+				"BAT0/capacity": "50",
+				"ADP1/online":   "0",
+			},
+			expected: Detection{Battery: true, ACAdapter: true},
+		},
+		{
+			name: "native end threshold",
+			attrs: map[string]string{
+				"BAT0/capacity":                     "50",
+				"BAT0/charge_control_end_threshold": "80",
+				"ideapad/conservation_mode":         "0",
+			},
+			expected:  Detection{Battery: true, ConservationMode: true, EndThreshold: true},
+			supported: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detection := Detect(newFakeSysfs(t, tt.attrs))
+			if detection != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, detection)
+			}
+			if detection.Supported() != tt.supported {
+				t.Errorf("Expected supported %v, got %v", tt.supported, detection.Supported())
+			}
+		})
+	}
+}
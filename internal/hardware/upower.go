@@ -0,0 +1,255 @@
+package hardware
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ErrNoUPowerBattery is returned when UPower doesn't list a battery
+var ErrNoUPowerBattery = errors.New("no battery found by UPower")
+
+// UPower device types (the Type property)
+const (
+	upowerLinePower uint32 = 1
+	upowerBattery   uint32 = 2
+)
+
+// upowerStatus maps UPower battery states (the State property) to
+// power_supply status strings
+var upowerStatus = map[uint32]string{
+	1: "Charging",
+	2: "Discharging",
+	3: "Discharging", // Empty
+	4: "Full",
+	5: "Not charging", // Pending charge
+}
+
+// upowerTechnology maps UPower battery technologies (the Technology
+// property) to the names upower prints
+var upowerTechnology = map[uint32]string{
+	1: "lithium-ion",
+	2: "lithium-polymer",
+	3: "lithium-iron-phosphate",
+	4: "lead-acid",
+	5: "nickel-cadmium",
+	6: "nickel-metal-hydride",
+}
+
+// upowerBus reads UPower's devices (replaced in tests)
+type upowerBus interface {
+	// Devices lists the object paths of the devices UPower knows
+	Devices() ([]dbus.ObjectPath, error)
+
+	// Properties reads the org.freedesktop.UPower.Device properties of a device
+	Properties(path dbus.ObjectPath) (map[string]dbus.Variant, error)
+}
+
+// UPowerBackend reads the battery from UPower over the system D-Bus. UPower
+// normalizes battery layouts and exposes richer metadata than the raw sysfs
+// attributes. Charge controls aren't part of UPower, so conservation mode and
+// the other limits are still handled by the embedded sysfs backend.
+type UPowerBackend struct {
+	*SysfsBackend
+
+	bus upowerBus
+}
+
+// NewUPowerBackend creates a UPower backend using the default sysfs paths
+// for the charge controls. The system bus is connected on first use.
+func NewUPowerBackend() *UPowerBackend {
+	return &UPowerBackend{
+		SysfsBackend: NewSysfsBackend(),
+		bus:          systemUPower{},
+	}
+}
+
+// Name returns the backend name
+func (b *UPowerBackend) Name() string {
+	return "upower"
+}
+
+// ReadBattery reads the level and AC state from UPower and conservation mode
+// from sysfs
+func (b *UPowerBackend) ReadBattery() (Battery, error) {
+	var battery Battery
+
+	props, err := b.device(upowerBattery)
+	if err != nil {
+		return battery, err
+	}
+
+	level, ok := props.float("Percentage")
+	if !ok {
+		return battery, errors.New("UPower reported no battery percentage")
+	}
+	battery.Level = int(level + 0.5)
+
+	conservation, err := readInt(b.paths.ConservationMode)
+	if err != nil {
+		return battery, fmt.Errorf("failed to read conservation mode: %w", err)
+	}
+	battery.ConservationMode = conservation == 1
+
+	// Prefer the line power device, as the battery is "pending-charge"
+	// rather than "charging" while conservation mode holds it
+	if line, err := b.device(upowerLinePower); err == nil {
+		battery.ACOnline = line.bool("Online")
+	} else {
+		state := props.uint("State")
+		battery.ACOnline = state != 2 && state != 3 // Neither discharging nor empty
+	}
+
+	return battery, nil
+}
+
+// ReadHealth reads the full and design energy and the cycle count from UPower
+func (b *UPowerBackend) ReadHealth() (Health, error) {
+	health := Health{Unit: "µWh"}
+
+	props, err := b.device(upowerBattery)
+	if err != nil {
+		return health, err
+	}
+
+	full, ok := props.float("EnergyFull")
+	if !ok {
+		return health, errors.New("failed to read full capacity: not reported by UPower")
+	}
+	design, ok := props.float("EnergyFullDesign")
+	if !ok {
+		return health, errors.New("failed to read design capacity: not reported by UPower")
+	}
+	health.Full = micro(full)
+	health.FullDesign = micro(design)
+
+	// Negative when unknown
+	if cycles := props.int("ChargeCycles"); cycles > 0 {
+		health.CycleCount = cycles
+	}
+
+	return health, nil
+}
+
+// ReadInfo reads the battery readings and identification from UPower
+func (b *UPowerBackend) ReadInfo() (Info, error) {
+	var info Info
+
+	props, err := b.device(upowerBattery)
+	if err != nil {
+		return info, err
+	}
+
+	info.Status = upowerStatus[props.uint("State")]
+	if info.Status == "" {
+		info.Status = "Unknown"
+	}
+	if voltage, ok := props.float("Voltage"); ok {
+		info.VoltageNow = micro(voltage)
+	}
+	if rate, ok := props.float("EnergyRate"); ok {
+		info.PowerNow = micro(rate)
+	}
+	if temp, ok := props.float("Temperature"); ok {
+		info.Temperature = int(temp * 10)
+	}
+	info.Manufacturer = props.string("Vendor")
+	info.ModelName = props.string("Model")
+	info.SerialNumber = props.string("Serial")
+	info.Technology = upowerTechnology[props.uint("Technology")]
+
+	return info, nil
+}
+
+// device returns the properties of the first UPower device of the given
+// type. Batteries must power the system, which leaves out those of mice and
+// other peripherals.
+func (b *UPowerBackend) device(kind uint32) (upowerProperties, error) {
+	paths, err := b.bus.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate UPower devices: %w", err)
+	}
+
+	for _, path := range paths {
+		props, err := b.bus.Properties(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read UPower device %s: %w", path, err)
+		}
+		device := upowerProperties(props)
+		if device.uint("Type") != kind || (kind == upowerBattery && !device.bool("PowerSupply")) {
+			continue
+		}
+		return device, nil
+	}
+
+	if kind == upowerBattery {
+		return nil, ErrNoUPowerBattery
+	}
+	return nil, errors.New("no line power device found by UPower")
+}
+
+// upowerProperties are the properties of a UPower device. Missing properties
+// or ones of an unexpected type read as zero.
+type upowerProperties map[string]dbus.Variant
+
+func (p upowerProperties) float(name string) (float64, bool) {
+	value, ok := p[name].Value().(float64)
+	return value, ok
+}
+
+func (p upowerProperties) uint(name string) uint32 {
+	value, _ := p[name].Value().(uint32)
+	return value
+}
+
+func (p upowerProperties) int(name string) int {
+	value, _ := p[name].Value().(int32)
+	return int(value)
+}
+
+func (p upowerProperties) bool(name string) bool {
+	value, _ := p[name].Value().(bool)
+	return value
+}
+
+func (p upowerProperties) string(name string) string {
+	value, _ := p[name].Value().(string)
+	return value
+}
+
+// micro converts a UPower value in a base unit (Wh, W, V) to the micro-units
+// used by power_supply attributes
+func micro(value float64) int {
+	return int(value*1e6 + 0.5)
+}
+
+// systemUPower talks to the UPower daemon on the system bus
+type systemUPower struct{}
+
+const (
+	upowerService = "org.freedesktop.UPower"
+	upowerPath    = "/org/freedesktop/UPower"
+)
+
+func (systemUPower) Devices() ([]dbus.ObjectPath, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+
+	var paths []dbus.ObjectPath
+	err = conn.Object(upowerService, upowerPath).Call(upowerService+".EnumerateDevices", 0).Store(&paths)
+	return paths, err
+}
+
+func (systemUPower) Properties(path dbus.ObjectPath) (map[string]dbus.Variant, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+
+	var props map[string]dbus.Variant
+	err = conn.Object(upowerService, path).Call("org.freedesktop.DBus.Properties.GetAll", 0, upowerService+".Device").Store(&props)
+	return props, err
+}
@@ -0,0 +1,128 @@
+package hardware
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// upowerBatteryDevice holds the properties of a laptop battery as UPower
+// reports them
+var upowerBatteryDevice = map[string]interface{}{
+	"NativePath":       "BAT0",
+	"Vendor":           "Celxpert",
+	"Model":            "L20C4PC1",
+	"Serial":           "1234",
+	"Type":             upowerBattery,
+	"PowerSupply":      true,
+	"State":            uint32(5), // Pending charge
+	"Energy":           45.6,
+	"EnergyFull":       57.3,
+	"EnergyFullDesign": 71.0,
+	"EnergyRate":       0.0,
+	"Voltage":          16.843,
+	"ChargeCycles":     int32(-1),
+	"Percentage":       79.0,
+	"Temperature":      31.5,
+	"Technology":       uint32(2), // Lithium polymer
+}
+
+// fakeUPowerBus answers from canned device properties, in path order
+type fakeUPowerBus struct {
+	paths   []dbus.ObjectPath
+	devices map[dbus.ObjectPath]map[string]interface{}
+}
+
+func (f fakeUPowerBus) Devices() ([]dbus.ObjectPath, error) {
+	return f.paths, nil
+}
+
+func (f fakeUPowerBus) Properties(path dbus.ObjectPath) (map[string]dbus.Variant, error) {
+	device, ok := f.devices[path]
+	if !ok {
+		return nil, errors.New("no such device")
+	}
+	props := make(map[string]dbus.Variant, len(device))
+	for name, value := range device {
+		props[name] = dbus.MakeVariant(value)
+	}
+	return props, nil
+}
+
+// newFakeUPower returns a UPower backend answering from the devices, listed
+// in the given order
+func newFakeUPower(t *testing.T, paths []dbus.ObjectPath, devices map[dbus.ObjectPath]map[string]interface{}, sysfs map[string]string) *UPowerBackend {
+	t.Helper()
+
+	return &UPowerBackend{
+		SysfsBackend: NewSysfsBackendWithPaths(newFakeSysfs(t, sysfs)),
+		bus:          fakeUPowerBus{paths: paths, devices: devices},
+	}
+}
+
+func TestUPowerReadBattery(t *testing.T) {
+	devices := map[dbus.ObjectPath]map[string]interface{}{
+		"/org/freedesktop/UPower/devices/mouse_dev_AA_BB_CC": {"Type": uint32(5), "PowerSupply": false, "Percentage": 50.0},
+		"/org/freedesktop/UPower/devices/battery_BAT0":       upowerBatteryDevice,
+		"/org/freedesktop/UPower/devices/line_power_ADP1":    {"Type": upowerLinePower, "PowerSupply": true, "Online": true},
+	}
+	backend := newFakeUPower(t, []dbus.ObjectPath{
+		"/org/freedesktop/UPower/devices/mouse_dev_AA_BB_CC",
+		"/org/freedesktop/UPower/devices/battery_BAT0",
+		"/org/freedesktop/UPower/devices/line_power_ADP1",
+	}, devices, map[string]string{"ideapad/conservation_mode": "1"})
+
+	battery, err := backend.ReadBattery()
+	if err != nil {
+		t.Fatalf("ReadBattery failed: %v", err)
+	}
+	expected := Battery{Level: 79, ConservationMode: true, ACOnline: true}
+	if battery != expected {
+		t.Errorf("Expected %+v, got %+v", expected, battery)
+	}
+
+	health, err := backend.ReadHealth()
+	if err != nil {
+		t.Fatalf("ReadHealth failed: %v", err)
+	}
+	if health != (Health{Full: 57300000, FullDesign: 71000000, Unit: "µWh"}) {
+		t.Errorf("Unexpected health: %+v", health)
+	}
+
+	info, err := backend.ReadInfo()
+	if err != nil {
+		t.Fatalf("ReadInfo failed: %v", err)
+	}
+	if info.Status != "Not charging" || info.VoltageNow != 16843000 || info.Temperature != 315 ||
+		info.Manufacturer != "Celxpert" || info.Technology != "lithium-polymer" {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+}
+
+func TestUPowerACFromBatteryState(t *testing.T) {
+	discharging := map[string]interface{}{"Type": upowerBattery, "PowerSupply": true, "Percentage": 60.0, "State": uint32(2)}
+	backend := newFakeUPower(t, []dbus.ObjectPath{"/org/freedesktop/UPower/devices/battery_BAT0"},
+		map[dbus.ObjectPath]map[string]interface{}{"/org/freedesktop/UPower/devices/battery_BAT0": discharging},
+		map[string]string{"ideapad/conservation_mode": "0"})
+
+	// Without a line power device the battery state tells whether AC is connected
+	battery, err := backend.ReadBattery()
+	if err != nil {
+		t.Fatalf("ReadBattery failed: %v", err)
+	}
+	if battery.ACOnline || battery.Level != 60 {
+		t.Errorf("Expected 60%% on battery, got %+v", battery)
+	}
+}
+
+func TestUPowerNoBattery(t *testing.T) {
+	backend := newFakeUPower(t, []dbus.ObjectPath{"/org/freedesktop/UPower/devices/line_power_ADP1"},
+		map[dbus.ObjectPath]map[string]interface{}{
+			"/org/freedesktop/UPower/devices/line_power_ADP1": {"Type": upowerLinePower, "Online": false},
+		}, nil)
+
+	if _, err := backend.ReadBattery(); !errors.Is(err, ErrNoUPowerBattery) {
+		t.Errorf("ReadBattery() error = %v, want %v", err, ErrNoUPowerBattery)
+	}
+}
@@ -0,0 +1,264 @@
+// Package history keeps a rolling on-disk record of battery samples,
+// conservation mode toggles and the battery's capacity.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	EventSample          = "sample"           // Periodic battery reading
+	EventConservationOn  = "conservation_on"  // Conservation mode switched on
+	EventConservationOff = "conservation_off" // Conservation mode switched off
+	EventCapacity        = "capacity"         // Daily full charge capacity reading
+)
+
+// DefaultMaxEntries keeps about a month of samples at the default interval.
+// Capacity readings don't count against it, so the wear trend outlives the
+// samples.
+const DefaultMaxEntries = 20000
+
+// Recorder stores history entries. Implementations are safe for concurrent use.
+type Recorder interface {
+	// Append records an entry; entries are appended in time order
+	Append(entry Entry) error
+
+	// Query returns the entries within [since, until], oldest first. A zero
+	// bound is open-ended.
+	Query(since, until time.Time) ([]Entry, error)
+
+	// Last returns the newest entry, or false if the history is empty
+	Last() (Entry, bool)
+
+	// Close releases the underlying storage
+	Close() error
+}
+
+// Options configures a history store
+type Options struct {
+	MaxEntries int           // Capacity of the file store, capacity readings aside; 0 uses DefaultMaxEntries
+	Retention  time.Duration // Entries older than this are pruned; 0 keeps them
+}
+
+// Entry is a snapshot of the battery at the time of an event
+type Entry struct {
+	Time             time.Time `json:"time"`
+	Event            string    `json:"event"`
+	Level            int       `json:"level"`
+	ACConnected      bool      `json:"ac"`
+	ConservationMode bool      `json:"conservation"`
+	Threshold        int       `json:"threshold"`
+
+	// Full charge and design capacity of capacity entries, in the unit
+	// reported by the hardware
+	Capacity       int `json:"capacity,omitempty"`
+	DesignCapacity int `json:"design_capacity,omitempty"`
+}
+
+// Store is a ring buffer of entries backed by a JSON lines file. Entries are
+// appended to the file, which is compacted to the newest entries once it
+// grows a quarter past the capacity.
+type Store struct {
+	path        string
+	maxEntries  int
+	retention   time.Duration
+	mutex       sync.Mutex
+	entries     []Entry
+	fileEntries int // Lines in the file, including dropped entries
+}
+
+// Open loads the history file at path, creating it on the first append.
+// Lines that fail to parse, e.g. a partial write before a crash, are skipped.
+func Open(path string, opts Options) (*Store, error) {
+	maxEntries := opts.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	s := &Store{path: path, maxEntries: maxEntries, retention: opts.Retention}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		s.fileEntries++
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		s.entries = append(s.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	if len(s.entries) > 0 {
+		s.prune(s.entries[len(s.entries)-1].Time)
+	}
+
+	return s, nil
+}
+
+// Append records an entry, dropping the oldest once the store is full
+func (s *Store) Append(entry Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries = append(s.entries, entry)
+	s.prune(entry.Time)
+
+	if s.fileEntries >= s.maxEntries+s.maxEntries/4 {
+		return s.compact()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	s.fileEntries++
+	return nil
+}
+
+// Query returns the entries within [since, until], oldest first. A zero
+// bound is open-ended.
+func (s *Store) Query(since, until time.Time) ([]Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var entries []Entry
+	for _, entry := range s.entries {
+		if !since.IsZero() && entry.Time.Before(since) {
+			continue
+		}
+		if !until.IsZero() && entry.Time.After(until) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Last returns the newest entry, or false if the history is empty
+func (s *Store) Last() (Entry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.entries) == 0 {
+		return Entry{}, false
+	}
+	return s.entries[len(s.entries)-1], true
+}
+
+// Close is a no-op, as the file is only open while appending
+func (s *Store) Close() error {
+	return nil
+}
+
+// prune drops the oldest entries beyond the capacity, keeping capacity
+// readings, and entries older than the retention as of now (caller must hold
+// the mutex). Dropped entries stay in the file until the next compaction.
+func (s *Store) prune(now time.Time) {
+	if len(s.entries) > s.maxEntries {
+		excess := -s.maxEntries
+		for _, entry := range s.entries {
+			if entry.Event != EventCapacity {
+				excess++
+			}
+		}
+
+		kept := s.entries[:0]
+		for _, entry := range s.entries {
+			if excess > 0 && entry.Event != EventCapacity {
+				excess--
+				continue
+			}
+			kept = append(kept, entry)
+		}
+		s.entries = kept
+	}
+
+	if s.retention > 0 {
+		cutoff := now.Add(-s.retention)
+		i := 0
+		for i < len(s.entries) && s.entries[i].Time.Before(cutoff) {
+			i++
+		}
+		s.entries = s.entries[i:]
+	}
+}
+
+// compact atomically rewrites the file with the entries in memory (caller
+// must hold the mutex)
+func (s *Store) compact() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	tempPath := s.path + ".tmp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range s.entries {
+		if err := encoder.Encode(entry); err != nil {
+			os.Remove(tempPath)
+			return fmt.Errorf("failed to write history to temp file: %w", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write history to temp file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Rename(tempPath, s.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to replace history: %w", err)
+	}
+
+	s.fileEntries = len(s.entries)
+	return nil
+}
@@ -0,0 +1,279 @@
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sample(t time.Time, level int) Entry {
+	return Entry{Time: t, Event: EventSample, Level: level, ACConnected: true, Threshold: 80}
+}
+
+func TestStoreAppendAndQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := Open(path, Options{MaxEntries: 100})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := store.Append(sample(base.Add(time.Duration(i)*time.Hour), 70+i)); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	entries, _ := store.Query(base.Add(time.Hour), base.Add(3*time.Hour))
+	if len(entries) != 3 || entries[0].Level != 71 || entries[2].Level != 73 {
+		t.Errorf("Expected levels 71-73, got %+v", entries)
+	}
+	if entries, _ := store.Query(time.Time{}, time.Time{}); len(entries) != 5 {
+		t.Errorf("Expected 5 entries for an open range, got %d", len(entries))
+	}
+
+	// Entries survive a reopen, skipping a torn last line
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open history: %v", err)
+	}
+	file.WriteString(`{"time":"2025-06-`)
+	file.Close()
+
+	reopened, err := Open(path, Options{MaxEntries: 100})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	last, ok := reopened.Last()
+	if !ok || last.Level != 74 || !last.Time.Equal(base.Add(4*time.Hour)) {
+		t.Errorf("Expected last entry at level 74, got %+v", last)
+	}
+}
+
+func TestStoreCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := Open(path, Options{MaxEntries: 8})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for i := 0; i < 30; i++ {
+		if err := store.Append(sample(base.Add(time.Duration(i)*time.Minute), i%100)); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	entries, _ := store.Query(time.Time{}, time.Time{})
+	if len(entries) != 8 || entries[0].Level != 22 {
+		t.Errorf("Expected the newest 8 entries starting at 22, got %d starting at %d", len(entries), entries[0].Level)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines > 10 {
+		t.Errorf("Expected the file to be compacted to at most 10 lines, got %d", lines)
+	}
+
+	reopened, err := Open(path, Options{MaxEntries: 8})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if entries, _ := reopened.Query(time.Time{}, time.Time{}); len(entries) != 8 || entries[7].Level != 29 {
+		t.Errorf("Expected the newest 8 entries after reopening, got %+v", entries)
+	}
+}
+
+func TestStoreKeepsCapacity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := Open(path, Options{MaxEntries: 4})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		entry := sample(base.Add(time.Duration(i)*time.Minute), i)
+		if i%5 == 0 {
+			entry = capacity(base.Add(time.Duration(i)*time.Minute), 90000)
+		}
+		if err := store.Append(entry); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	// All 4 capacity readings and the newest 4 samples
+	entries, _ := store.Query(time.Time{}, time.Time{})
+	var capacities, samples int
+	for _, entry := range entries {
+		if entry.Event == EventCapacity {
+			capacities++
+		} else {
+			samples++
+		}
+	}
+	if capacities != 4 || samples != 4 || entries[len(entries)-1].Level != 19 {
+		t.Errorf("Expected 4 capacity readings and the newest 4 samples, got %+v", entries)
+	}
+}
+
+func capacity(t time.Time, full int) Entry {
+	return Entry{Time: t, Event: EventCapacity, Capacity: full, DesignCapacity: 100000}
+}
+
+func TestCapacityTrend(t *testing.T) {
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Losing 0.1% of the design capacity a day, with samples in between
+	var entries []Entry
+	for day := 0; day < 60; day++ {
+		entries = append(entries, capacity(base.AddDate(0, 0, day), 95000-day*100))
+		entries = append(entries, sample(base.AddDate(0, 0, day).Add(time.Hour), 80))
+	}
+
+	trend := CapacityTrend(entries)
+	if len(trend.Points) != 9 {
+		t.Errorf("Expected one point per week (9), got %d", len(trend.Points))
+	}
+	if latest := trend.Points[len(trend.Points)-1]; latest.Health != 89.1 {
+		t.Errorf("Expected the latest point at 89.1%%, got %v", latest.Health)
+	}
+	if trend.FadePerMonth < 2.99 || trend.FadePerMonth > 3.01 {
+		t.Errorf("Expected a fade of 3%% a month, got %v", trend.FadePerMonth)
+	}
+
+	// 89.1% to 80% at 0.1% a day takes 91 days
+	want := base.AddDate(0, 0, 59+91)
+	if got := trend.Projection(80); got.Sub(want).Abs() > time.Hour {
+		t.Errorf("Projection(80) = %v, want %v", got, want)
+	}
+	if got := trend.Projection(95); !got.IsZero() {
+		t.Errorf("Projection above the current health = %v, want zero", got)
+	}
+
+	// Too short a span for a fade rate
+	if short := CapacityTrend(entries[:10]); short.FadePerMonth != 0 || len(short.Points) != 1 {
+		t.Errorf("Expected a single point without fade rate for 5 days, got %+v", short)
+	}
+}
+
+func TestStoreRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := Open(path, Options{Retention: 48 * time.Hour})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for day := 0; day < 5; day++ {
+		if err := store.Append(sample(base.AddDate(0, 0, day), 60+day)); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	entries, _ := store.Query(time.Time{}, time.Time{})
+	if len(entries) != 3 || entries[0].Level != 62 {
+		t.Errorf("Expected the last 2 days kept, got %+v", entries)
+	}
+
+	// Expired entries are dropped when reopening with a shorter retention
+	reopened, err := Open(path, Options{Retention: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if entries, _ := reopened.Query(time.Time{}, time.Time{}); len(entries) != 2 || entries[0].Level != 63 {
+		t.Errorf("Expected the last day kept after reopening, got %+v", entries)
+	}
+}
+
+func TestOpenSQLiteWithoutDriver(t *testing.T) {
+	if slices.Contains(sql.Drivers(), sqliteDriver) {
+		t.Skip("SQLite driver linked in")
+	}
+
+	if _, err := OpenSQLite(filepath.Join(t.TempDir(), "history.db"), Options{}); !errors.Is(err, ErrSQLiteUnavailable) {
+		t.Errorf("OpenSQLite() error = %v, want %v", err, ErrSQLiteUnavailable)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	entries := []Entry{
+		{Time: at(0), Event: EventSample, Level: 60, ACConnected: true},
+		{Time: at(10), Event: EventSample, Level: 80, ACConnected: true},
+		{Time: at(10), Event: EventConservationOn, Level: 80, ACConnected: true, ConservationMode: true},
+		{Time: at(20), Event: EventSample, Level: 70, ConservationMode: true},
+		// The daemon was stopped for an hour
+		{Time: at(90), Event: EventSample, Level: 40},
+	}
+
+	stats := Summarize(entries, base, at(100))
+
+	if stats.Covered != 30*time.Minute {
+		t.Errorf("Expected 30m covered, got %v", stats.Covered)
+	}
+	if stats.ACTime != 20*time.Minute || stats.BatteryTime != 10*time.Minute {
+		t.Errorf("Expected 20m on AC and 10m on battery, got %v and %v", stats.ACTime, stats.BatteryTime)
+	}
+	if stats.ConservationTime != 10*time.Minute {
+		t.Errorf("Expected 10m in conservation, got %v", stats.ConservationTime)
+	}
+	if stats.Toggles != 1 {
+		t.Errorf("Expected 1 toggle, got %d", stats.Toggles)
+	}
+	if stats.AverageLevel != 60 {
+		t.Errorf("Expected average level 60, got %v", stats.AverageLevel)
+	}
+	if stats.MinLevel != 40 || stats.MaxLevel != 80 || stats.Entries != 5 {
+		t.Errorf("Unexpected range: %+v", stats)
+	}
+
+	if empty := Summarize(nil, base, at(100)); empty != (Stats{}) {
+		t.Errorf("Expected empty stats without entries, got %+v", empty)
+	}
+}
+
+func TestDischarges(t *testing.T) {
+	base := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return base.Add(time.Duration(hours) * time.Hour) }
+	entry := func(hours, level int, ac bool) Entry {
+		return Entry{Time: at(hours), Event: EventSample, Level: level, ACConnected: ac}
+	}
+
+	got := Discharges([]Entry{
+		entry(0, 80, true),
+		// Unplugged at 80%, down to 35%
+		entry(1, 79, false),
+		entry(2, 50, false),
+		entry(3, 35, false),
+		entry(4, 36, true),
+		// Still on battery at the end
+		entry(5, 60, false),
+		entry(6, 55, false),
+	})
+
+	want := []Discharge{
+		{Start: at(1), End: at(4), From: 80, To: 35},
+		{Start: at(5), End: at(6), From: 60, To: 55},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d discharges, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Discharge %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if depth := got[0].Depth(); depth != 45 {
+		t.Errorf("Expected a depth of 45%%, got %d", depth)
+	}
+}
@@ -0,0 +1,211 @@
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// sqliteDriver is the database/sql driver name registered by the pure-Go
+// SQLite driver, linked in by building with the sqlite tag
+const sqliteDriver = "sqlite"
+
+// pruneInterval is how often the SQLite store deletes expired entries
+const pruneInterval = time.Hour
+
+// ErrSQLiteUnavailable is returned when the binary was built without the
+// SQLite driver
+var ErrSQLiteUnavailable = errors.New("SQLite history backend not available in this build (rebuild with -tags sqlite)")
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS history (
+	time         INTEGER NOT NULL, -- Unix time in nanoseconds
+	event        TEXT    NOT NULL,
+	level        INTEGER NOT NULL,
+	ac           INTEGER NOT NULL,
+	conservation INTEGER NOT NULL,
+	threshold    INTEGER NOT NULL,
+	capacity     INTEGER NOT NULL DEFAULT 0,
+	design       INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS history_time ON history (time);`
+
+// sqliteColumns lists the columns added after the first release, which
+// databases created before them lack
+var sqliteColumns = map[string]string{
+	"capacity": "ALTER TABLE history ADD COLUMN capacity INTEGER NOT NULL DEFAULT 0",
+	"design":   "ALTER TABLE history ADD COLUMN design INTEGER NOT NULL DEFAULT 0",
+}
+
+// historyColumns are the columns read into an Entry
+const historyColumns = "time, event, level, ac, conservation, threshold, capacity, design"
+
+// SQLStore keeps the history in an SQLite database, suited to long
+// retention. Entries older than the retention are pruned periodically;
+// without a retention they are kept indefinitely.
+type SQLStore struct {
+	db        *sql.DB
+	retention time.Duration
+	mutex     sync.Mutex
+	last      *Entry
+	lastPrune time.Time
+}
+
+// OpenSQLite opens or creates the SQLite history database at path
+func OpenSQLite(path string, opts Options) (*SQLStore, error) {
+	if !slices.Contains(sql.Drivers(), sqliteDriver) {
+		return nil, ErrSQLiteUnavailable
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create history schema: %w", err)
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &SQLStore{db: db, retention: opts.Retention}
+
+	last, err := s.queryEntries(`SELECT ` + historyColumns + ` FROM history ORDER BY time DESC LIMIT 1`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if len(last) > 0 {
+		s.last = &last[0]
+	}
+
+	return s, nil
+}
+
+// Append records an entry and prunes expired entries at most once per
+// prune interval
+func (s *SQLStore) Append(entry Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.db.Exec(`INSERT INTO history (`+historyColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Time.UnixNano(), entry.Event, entry.Level, entry.ACConnected, entry.ConservationMode, entry.Threshold,
+		entry.Capacity, entry.DesignCapacity)
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	s.last = &entry
+
+	if s.retention > 0 && entry.Time.Sub(s.lastPrune) >= pruneInterval {
+		cutoff := entry.Time.Add(-s.retention)
+		if _, err := s.db.Exec(`DELETE FROM history WHERE time < ?`, cutoff.UnixNano()); err != nil {
+			return fmt.Errorf("failed to prune history: %w", err)
+		}
+		s.lastPrune = entry.Time
+	}
+
+	return nil
+}
+
+// Query returns the entries within [since, until], oldest first. A zero
+// bound is open-ended.
+func (s *SQLStore) Query(since, until time.Time) ([]Entry, error) {
+	from, to := int64(0), int64(1<<63-1)
+	if !since.IsZero() {
+		from = since.UnixNano()
+	}
+	if !until.IsZero() {
+		to = until.UnixNano()
+	}
+
+	return s.queryEntries(`SELECT `+historyColumns+` FROM history WHERE time BETWEEN ? AND ? ORDER BY time`, from, to)
+}
+
+// Last returns the newest entry, or false if the history is empty
+func (s *SQLStore) Last() (Entry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.last == nil {
+		return Entry{}, false
+	}
+	return *s.last, true
+}
+
+// Close closes the database
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// migrateSQLite adds the columns a database created by an earlier version
+// lacks
+func migrateSQLite(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('history')`)
+	if err != nil {
+		return fmt.Errorf("failed to read history schema: %w", err)
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read history schema: %w", err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read history schema: %w", err)
+	}
+
+	for _, column := range slices.Sorted(maps.Keys(sqliteColumns)) {
+		if existing[column] {
+			continue
+		}
+		if _, err := db.Exec(sqliteColumns[column]); err != nil {
+			return fmt.Errorf("failed to add history column %s: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// queryEntries runs a query selecting the history columns
+func (s *SQLStore) queryEntries(query string, args ...any) ([]Entry, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var entry Entry
+		var nanos int64
+		if err := rows.Scan(&nanos, &entry.Event, &entry.Level, &entry.ACConnected,
+			&entry.ConservationMode, &entry.Threshold, &entry.Capacity, &entry.DesignCapacity); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		entry.Time = time.Unix(0, nanos)
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,6 @@
+//go:build sqlite
+
+package history
+
+// Registers the pure-Go SQLite driver (no cgo) for the SQLite history store
+import _ "modernc.org/sqlite"
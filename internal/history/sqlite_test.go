@@ -0,0 +1,105 @@
+//go:build sqlite
+
+package history
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLStoreAppendAndQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := OpenSQLite(path, Options{})
+	if err != nil {
+		t.Fatalf("OpenSQLite failed: %v", err)
+	}
+	if _, ok := store.Last(); ok {
+		t.Error("Expected no last entry in a new database")
+	}
+	for i := 0; i < 5; i++ {
+		if err := store.Append(sample(base.Add(time.Duration(i)*time.Hour), 70+i)); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	capacity := Entry{Time: base.Add(5 * time.Hour), Event: EventCapacity, Level: 80, Capacity: 50000, DesignCapacity: 60000}
+	if err := store.Append(capacity); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	entries, err := store.Query(base.Add(time.Hour), base.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 3 || entries[0].Level != 71 || entries[2].Level != 73 {
+		t.Errorf("Expected levels 71 to 73, got %+v", entries)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Reopening restores the last entry and keeps the capacity columns
+	reopened, err := OpenSQLite(path, Options{})
+	if err != nil {
+		t.Fatalf("OpenSQLite failed: %v", err)
+	}
+	defer reopened.Close()
+	last, ok := reopened.Last()
+	if !ok || !last.Time.Equal(capacity.Time) || last.Capacity != 50000 || last.DesignCapacity != 60000 {
+		t.Errorf("Expected the capacity entry last, got %+v", last)
+	}
+}
+
+func TestSQLStoreRetention(t *testing.T) {
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "history.db"), Options{Retention: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("OpenSQLite failed: %v", err)
+	}
+	defer store.Close()
+
+	for _, at := range []time.Time{base, base.Add(12 * time.Hour), base.Add(48 * time.Hour)} {
+		if err := store.Append(sample(at, 60)); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	entries, err := store.Query(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 1 || !entries[0].Time.Equal(base.Add(48*time.Hour)) {
+		t.Errorf("Expected only the entry within the retention, got %+v", entries)
+	}
+}
+
+func TestSQLStoreMigratesOldSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	// A database created before the capacity columns were added
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE history (time INTEGER NOT NULL, event TEXT NOT NULL, level INTEGER NOT NULL,
+		ac INTEGER NOT NULL, conservation INTEGER NOT NULL, threshold INTEGER NOT NULL);
+		INSERT INTO history VALUES (1, 'sample', 75, 1, 0, 80)`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+
+	store, err := OpenSQLite(path, Options{})
+	if err != nil {
+		t.Fatalf("OpenSQLite failed: %v", err)
+	}
+	defer store.Close()
+
+	if last, ok := store.Last(); !ok || last.Level != 75 || last.Capacity != 0 {
+		t.Errorf("Expected the old entry with no capacity, got %+v", last)
+	}
+}
@@ -0,0 +1,77 @@
+package history
+
+import "time"
+
+// MaxGap is the longest stretch between two entries still counted as
+// observed time. Longer gaps mean the daemon wasn't running or the system was
+// suspended, and are left out of the aggregates.
+const MaxGap = 15 * time.Minute
+
+// Stats aggregates the history over a time range
+type Stats struct {
+	Covered          time.Duration // Observed time within the range
+	ConservationTime time.Duration // Time with conservation mode on
+	ACTime           time.Duration // Time on AC power
+	BatteryTime      time.Duration // Time on battery power
+	Toggles          int           // Conservation mode switches
+	AverageLevel     float64       // Time-weighted battery level
+	MinLevel         int
+	MaxLevel         int
+	Entries          int
+}
+
+// Summarize aggregates entries (oldest first) over [since, until]. Each entry
+// describes the battery until the next one, and the last one until until.
+func Summarize(entries []Entry, since, until time.Time) Stats {
+	var stats Stats
+	var levelTime float64
+	var levelSum int
+
+	for i, entry := range entries {
+		if entry.Time.Before(since) || entry.Time.After(until) {
+			continue
+		}
+
+		if stats.Entries == 0 || entry.Level < stats.MinLevel {
+			stats.MinLevel = entry.Level
+		}
+		if stats.Entries == 0 || entry.Level > stats.MaxLevel {
+			stats.MaxLevel = entry.Level
+		}
+		stats.Entries++
+		levelSum += entry.Level
+
+		if entry.Event == EventConservationOn || entry.Event == EventConservationOff {
+			stats.Toggles++
+		}
+
+		end := until
+		if i+1 < len(entries) && entries[i+1].Time.Before(until) {
+			end = entries[i+1].Time
+		}
+		span := end.Sub(entry.Time)
+		if span <= 0 || span > MaxGap {
+			continue
+		}
+
+		stats.Covered += span
+		levelTime += float64(entry.Level) * span.Seconds()
+		if entry.ConservationMode {
+			stats.ConservationTime += span
+		}
+		if entry.ACConnected {
+			stats.ACTime += span
+		} else {
+			stats.BatteryTime += span
+		}
+	}
+
+	switch {
+	case stats.Covered > 0:
+		stats.AverageLevel = levelTime / stats.Covered.Seconds()
+	case stats.Entries > 0:
+		stats.AverageLevel = float64(levelSum) / float64(stats.Entries)
+	}
+
+	return stats
+}
@@ -0,0 +1,99 @@
+package history
+
+import "time"
+
+// MinTrendSpan is the least time capacity readings must span before a fade
+// rate is computed; capacity readings wobble by a few tenths of a percent
+// from day to day
+const MinTrendSpan = 14 * 24 * time.Hour
+
+// trendStep is the spacing of the points of a trend
+const trendStep = 7 * 24 * time.Hour
+
+// CapacityPoint is the battery's full charge capacity at a time, in percent
+// of its design capacity
+type CapacityPoint struct {
+	Time   time.Time
+	Health float64
+}
+
+// Trend describes how the battery's capacity fades over time
+type Trend struct {
+	Points []CapacityPoint // The last reading of each week, oldest first
+
+	// FadePerMonth is the capacity lost per 30 days in percentage points of
+	// the design capacity, fitted over every reading; 0 until the readings
+	// span MinTrendSpan
+	FadePerMonth float64
+}
+
+// CapacityTrend computes the capacity trend from the capacity entries among
+// entries (oldest first)
+func CapacityTrend(entries []Entry) Trend {
+	var trend Trend
+	var readings []CapacityPoint
+	for _, entry := range entries {
+		if entry.Event != EventCapacity || entry.DesignCapacity <= 0 {
+			continue
+		}
+		readings = append(readings, CapacityPoint{
+			Time:   entry.Time,
+			Health: float64(entry.Capacity) / float64(entry.DesignCapacity) * 100,
+		})
+	}
+	if len(readings) == 0 {
+		return trend
+	}
+
+	first := readings[0].Time
+	for _, reading := range readings {
+		week := int(reading.Time.Sub(first) / trendStep)
+		last := len(trend.Points) - 1
+		if last >= 0 && int(trend.Points[last].Time.Sub(first)/trendStep) == week {
+			trend.Points[last] = reading
+		} else {
+			trend.Points = append(trend.Points, reading)
+		}
+	}
+
+	if readings[len(readings)-1].Time.Sub(first) >= MinTrendSpan {
+		trend.FadePerMonth = -slope(readings, first) * 30
+	}
+	return trend
+}
+
+// Projection returns when the capacity is expected to fall to health percent
+// of the design capacity at the current fade rate, counted from the latest
+// point; zero if the capacity isn't fading or already is that low
+func (t Trend) Projection(health float64) time.Time {
+	if t.FadePerMonth <= 0 || len(t.Points) == 0 {
+		return time.Time{}
+	}
+
+	latest := t.Points[len(t.Points)-1]
+	if latest.Health <= health {
+		return time.Time{}
+	}
+	days := (latest.Health - health) / (t.FadePerMonth / 30)
+	return latest.Time.Add(time.Duration(days * 24 * float64(time.Hour)))
+}
+
+// slope fits a line through the readings by least squares and returns its
+// slope in percentage points per day
+func slope(readings []CapacityPoint, origin time.Time) float64 {
+	var sumX, sumY, sumXY, sumXX float64
+	for _, reading := range readings {
+		x := reading.Time.Sub(origin).Hours() / 24
+		sumX += x
+		sumY += reading.Health
+		sumXY += x * reading.Health
+		sumXX += x * x
+	}
+
+	n := float64(len(readings))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
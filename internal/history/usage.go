@@ -0,0 +1,50 @@
+package history
+
+import "time"
+
+// Discharge is a stretch on battery power
+type Discharge struct {
+	Start time.Time
+	End   time.Time // When AC was plugged in again, or the last entry
+	From  int       // Level when AC was unplugged
+	To    int       // Lowest level reached
+}
+
+// Depth returns how many percent the battery was discharged
+func (d Discharge) Depth() int {
+	return max(d.From-d.To, 0)
+}
+
+// Discharges finds the stretches on battery power among entries (oldest
+// first). A stretch starts from the level of the entry before it if that was
+// on AC, and ends at the next entry on AC. Gaps don't end a stretch: a laptop
+// suspended on battery is still discharged.
+func Discharges(entries []Entry) []Discharge {
+	var result []Discharge
+	var current *Discharge
+
+	for i, entry := range entries {
+		if entry.ACConnected {
+			if current != nil {
+				current.End = entry.Time
+				result = append(result, *current)
+				current = nil
+			}
+			continue
+		}
+
+		if current == nil {
+			current = &Discharge{Start: entry.Time, From: entry.Level, To: entry.Level}
+			if i > 0 && entries[i-1].ACConnected {
+				current.From = max(entries[i-1].Level, entry.Level)
+			}
+		}
+		current.End = entry.Time
+		current.To = min(current.To, entry.Level)
+	}
+	if current != nil {
+		result = append(result, *current)
+	}
+
+	return result
+}
@@ -0,0 +1,378 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+)
+
+// RedactedValue replaces the value of sensitive attributes
+const RedactedValue = "[REDACTED]"
+
+// sensitiveKeys are always redacted regardless of sink configuration, so hook
+// environments and webhook secrets never reach the logs
+var sensitiveKeys = []string{"secret", "token", "password", "authorization", "env", "environment"}
+
+// Logger is a slog logger writing to the configured sinks. Its sinks can be
+// replaced at runtime with Configure; loggers derived with With follow along.
+type Logger struct {
+	*slog.Logger
+	sinks *sinkSwitch
+	ring  *Ring // Keeps the recent lines of every configuration
+}
+
+// sinkSwitch holds the current sink set. Records are written under its read
+// lock, so a replaced set's files are closed only once no record is being
+// written through them.
+type sinkSwitch struct {
+	mutex sync.RWMutex
+	set   *sinkSet
+}
+
+// swap installs set, returning the previous one once writes to it finished
+func (s *sinkSwitch) swap(set *sinkSet) *sinkSet {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous := s.set
+	s.set = set
+	return previous
+}
+
+// sinkSet is the handler for one logging configuration and the files it opened
+type sinkSet struct {
+	handler slog.Handler
+	files   []*os.File
+}
+
+// New creates a logger fanning out to every sink in the configuration
+func New(cfg config.LoggingConfig) (*Logger, error) {
+	logger := &Logger{sinks: &sinkSwitch{}, ring: NewRing(DefaultRingSize)}
+	if err := logger.Configure(cfg); err != nil {
+		return nil, err
+	}
+
+	logger.Logger = slog.New(&swapHandler{sinks: logger.sinks})
+	return logger, nil
+}
+
+// NewDefault creates a logger using the default configuration
+func NewDefault() *Logger {
+	logger, _ := New(config.Default().Logging)
+	return logger
+}
+
+// Configure replaces the logger's sinks. The previous sinks stay active if
+// the new configuration can't be applied.
+func (l *Logger) Configure(cfg config.LoggingConfig) error {
+	set, err := newSinkSet(cfg, l.ring)
+	if err != nil {
+		return err
+	}
+
+	if previous := l.sinks.swap(set); previous != nil {
+		previous.close()
+	}
+	return nil
+}
+
+// contextKey keys the attrs added to a context with ContextWith
+type contextKey struct{}
+
+// ContextWith returns a copy of ctx carrying attrs (as key-value pairs, like
+// With) that every record logged with ctx gets, e.g. the IDs of the request
+// being handled. Attrs already in ctx are kept.
+func ContextWith(ctx context.Context, args ...any) context.Context {
+	attrs := append(contextAttrs(ctx), slog.Group("", args...).Value.Group()...)
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+// contextAttrs returns a copy of the attrs added to ctx with ContextWith
+func contextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	return append([]slog.Attr(nil), attrs...)
+}
+
+// Ring returns the recent log lines, logged at the level of the most verbose
+// sink
+func (l *Logger) Ring() *Ring {
+	return l.ring
+}
+
+// Close closes any log files opened by the logger
+func (l *Logger) Close() error {
+	l.sinks.mutex.Lock()
+	defer l.sinks.mutex.Unlock()
+
+	if l.sinks.set != nil {
+		return l.sinks.set.close()
+	}
+	return nil
+}
+
+// newSinkSet opens the writers for every sink in the configuration, and adds
+// ring at the level of the most verbose sink, redacting what any sink redacts
+func newSinkSet(cfg config.LoggingConfig, ring *Ring) (*sinkSet, error) {
+	set := &sinkSet{}
+	var handlers []slog.Handler
+	ringLevel := slog.LevelError
+	var ringRedact []string
+
+	for i, sink := range cfg.Sinks {
+		var w io.Writer
+		switch sink.Type {
+		case "stdout":
+			w = os.Stdout
+		case "stderr":
+			w = os.Stderr
+		case "file":
+			file, err := os.OpenFile(sink.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+			if err != nil {
+				set.close()
+				return nil, fmt.Errorf("failed to open log sink %d: %w", i, err)
+			}
+			set.files = append(set.files, file)
+			w = file
+		default:
+			set.close()
+			return nil, fmt.Errorf("log sink %d: %w: %q", i, config.ErrInvalidSinkType, sink.Type)
+		}
+
+		handlers = append(handlers, newSinkHandler(w, sink))
+		ringLevel = min(ringLevel, ParseLevel(sink.Level))
+		ringRedact = append(ringRedact, sink.Redact...)
+	}
+
+	if ring != nil && len(cfg.Sinks) > 0 {
+		handlers = append(handlers, newSinkHandler(ring, config.SinkConfig{Level: levelName(ringLevel), Redact: ringRedact}))
+	}
+
+	set.handler = &fanoutHandler{handlers: handlers}
+	return set, nil
+}
+
+// close closes the files opened for the sink set
+func (s *sinkSet) close() error {
+	var firstErr error
+	for _, file := range s.files {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ParseLevel converts a configured level name to a slog level (empty means info)
+func ParseLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// levelName is the configured name of a level, the inverse of ParseLevel
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// newSinkHandler builds the handler for one sink, applying its level, redaction
+// and debug sampling settings
+func newSinkHandler(w io.Writer, sink config.SinkConfig) slog.Handler {
+	keys := append(append([]string{}, sensitiveKeys...), sink.Redact...)
+
+	var handler slog.Handler = slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: ParseLevel(sink.Level),
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			return redactAttr(a, keys)
+		},
+	})
+
+	if sink.DebugSampleRate > 0 && sink.DebugSampleRate < 1 {
+		handler = &samplingHandler{Handler: handler, rate: sink.DebugSampleRate, counter: new(atomic.Uint64)}
+	}
+
+	return handler
+}
+
+// redactAttr masks sensitive attributes, including keys nested in map values
+func redactAttr(a slog.Attr, keys []string) slog.Attr {
+	if isSensitive(a.Key, keys) {
+		return slog.String(a.Key, RedactedValue)
+	}
+	if a.Value.Kind() == slog.KindAny {
+		if m, ok := a.Value.Any().(map[string]interface{}); ok {
+			return slog.Any(a.Key, RedactMap(m, keys))
+		}
+	}
+	return a
+}
+
+// RedactMap returns a copy of m with sensitive keys masked, recursing into nested maps.
+// The built-in sensitive keys are always applied in addition to extra.
+func RedactMap(m map[string]interface{}, extra []string) map[string]interface{} {
+	keys := append(append([]string{}, sensitiveKeys...), extra...)
+
+	redacted := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		switch {
+		case isSensitive(key, keys):
+			redacted[key] = RedactedValue
+		default:
+			if nested, ok := value.(map[string]interface{}); ok {
+				value = RedactMap(nested, extra)
+			}
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
+
+// isSensitive reports whether a key, or any of its "_", "-" or "." separated
+// segments, matches one of the sensitive keys
+func isSensitive(key string, keys []string) bool {
+	key = strings.ToLower(key)
+	segments := strings.FieldsFunc(key, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	})
+
+	for _, sensitive := range keys {
+		sensitive = strings.ToLower(sensitive)
+		if key == sensitive {
+			return true
+		}
+		for _, segment := range segments {
+			if segment == sensitive {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// samplingHandler only passes through a fraction of debug records, so verbose
+// request logging stays affordable on busy sinks
+type samplingHandler struct {
+	slog.Handler
+	rate    float64
+	counter *atomic.Uint64
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level == slog.LevelDebug {
+		// Keep a record each time the running count crosses a whole multiple of 1/rate
+		n := h.counter.Add(1)
+		if uint64(float64(n)*h.rate) == uint64(float64(n-1)*h.rate) {
+			return nil
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), rate: h.rate, counter: h.counter}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), rate: h.rate, counter: h.counter}
+}
+
+// swapHandler forwards records to the current sink set, replaying the attrs
+// and groups added through With/WithGroup on top of it
+type swapHandler struct {
+	sinks *sinkSwitch
+	wraps []func(slog.Handler) slog.Handler
+}
+
+func (h *swapHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	h.sinks.mutex.RLock()
+	defer h.sinks.mutex.RUnlock()
+	return h.sinks.set.handler.Enabled(ctx, level)
+}
+
+func (h *swapHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := contextAttrs(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+
+	// Held while writing, so Configure doesn't close the files underneath
+	h.sinks.mutex.RLock()
+	defer h.sinks.mutex.RUnlock()
+
+	handler := h.sinks.set.handler
+	for _, wrap := range h.wraps {
+		handler = wrap(handler)
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *swapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *swapHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h *swapHandler) with(wrap func(slog.Handler) slog.Handler) slog.Handler {
+	wraps := append(append([]func(slog.Handler) slog.Handler{}, h.wraps...), wrap)
+	return &swapHandler{sinks: h.sinks, wraps: wraps}
+}
+
+// fanoutHandler dispatches records to every sink handler that accepts them
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (h *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
+
+func (h *fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
@@ -0,0 +1,218 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+)
+
+func newFileLogger(t *testing.T, sink config.SinkConfig) (*Logger, string) {
+	t.Helper()
+	sink.Type = "file"
+	sink.Path = filepath.Join(t.TempDir(), "test.log")
+
+	logger, err := New(config.LoggingConfig{Sinks: []config.SinkConfig{sink}})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+	return logger, sink.Path
+}
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	return string(data)
+}
+
+func TestRedaction(t *testing.T) {
+	logger, path := newFileLogger(t, config.SinkConfig{Level: "debug", Redact: []string{"ssid"}})
+
+	logger.Info("Webhook configured", "webhook_secret", "hunter2", "ssid", "home-wifi", "url", "https://example.com")
+	logger.Debug("Request received", "params", map[string]interface{}{
+		"threshold": 80,
+		"hook":      map[string]interface{}{"env": "API_KEY=abc"},
+	})
+
+	output := readLog(t, path)
+	for _, leaked := range []string{"hunter2", "home-wifi", "API_KEY=abc"} {
+		if strings.Contains(output, leaked) {
+			t.Errorf("Expected %q to be redacted, got: %s", leaked, output)
+		}
+	}
+	if !strings.Contains(output, "https://example.com") || !strings.Contains(output, "threshold:80") {
+		t.Errorf("Expected non-sensitive values to be logged, got: %s", output)
+	}
+}
+
+func TestDebugSampling(t *testing.T) {
+	logger, path := newFileLogger(t, config.SinkConfig{Level: "debug", DebugSampleRate: 0.25})
+
+	for i := 0; i < 100; i++ {
+		logger.Debug("Request received")
+	}
+	logger.Info("Not sampled")
+
+	output := readLog(t, path)
+	if got := strings.Count(output, "Request received"); got != 25 {
+		t.Errorf("Expected 25 sampled debug records, got %d", got)
+	}
+	if !strings.Contains(output, "Not sampled") {
+		t.Error("Expected info records to bypass sampling")
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	logger, path := newFileLogger(t, config.SinkConfig{Level: "info"})
+	derived := logger.With("conn", 1)
+
+	derived.Debug("Hidden")
+
+	// Derived loggers pick up the new sinks
+	if err := logger.Configure(config.LoggingConfig{Sinks: []config.SinkConfig{
+		{Type: "file", Path: path, Level: "debug"},
+	}}); err != nil {
+		t.Fatalf("Failed to reconfigure logger: %v", err)
+	}
+	derived.Debug("Visible")
+
+	output := readLog(t, path)
+	if strings.Contains(output, "Hidden") {
+		t.Error("Expected debug record to be dropped at info level")
+	}
+	if !strings.Contains(output, "Visible") || !strings.Contains(output, "conn=1") {
+		t.Errorf("Expected derived logger to use new sinks, got: %s", output)
+	}
+}
+
+func TestConfigureWhileLogging(t *testing.T) {
+	logger, path := newFileLogger(t, config.SinkConfig{Level: "info"})
+
+	// Writers log until the reconfiguration is done, counting their records
+	done := make(chan struct{})
+	var written atomic.Int64
+	var wg, started sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			derived := logger.With("writer", i)
+			derived.Info("Record")
+			written.Add(1)
+			started.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					derived.Info("Record")
+					written.Add(1)
+				}
+			}
+		}()
+	}
+
+	// Every reconfiguration closes the previous file while records are written
+	started.Wait()
+	for range 200 {
+		if err := logger.Configure(config.LoggingConfig{Sinks: []config.SinkConfig{
+			{Type: "file", Path: path, Level: "info"},
+		}}); err != nil {
+			t.Fatalf("Failed to reconfigure logger: %v", err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if got := strings.Count(readLog(t, path), "Record"); got != int(written.Load()) {
+		t.Errorf("Expected %d records, got %d", written.Load(), got)
+	}
+}
+
+func TestRing(t *testing.T) {
+	ring := NewRing(3)
+	for _, text := range []string{"one", "two", "three", "four"} {
+		ring.Write([]byte(text + "\n"))
+	}
+
+	texts := func(lines []Line) string {
+		var parts []string
+		for _, line := range lines {
+			parts = append(parts, fmt.Sprintf("%d:%s", line.Seq, line.Text))
+		}
+		return strings.Join(parts, " ")
+	}
+
+	tests := []struct {
+		name        string
+		since       uint64
+		want        string
+		wantDropped uint64
+	}{
+		{"all kept", 0, "2:two 3:three 4:four", 1},
+		{"after a line", 2, "3:three 4:four", 0},
+		{"up to date", 4, "", 0},
+		{"earlier daemon", 9, "2:two 3:three 4:four", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, dropped := ring.Since(tt.since)
+			if got := texts(lines); got != tt.want || dropped != tt.wantDropped {
+				t.Errorf("Since(%d) = %q, %d dropped, want %q, %d dropped", tt.since, got, dropped, tt.want, tt.wantDropped)
+			}
+		})
+	}
+
+	if got := texts(ring.Last(2)); got != "3:three 4:four" {
+		t.Errorf("Last(2) = %q", got)
+	}
+	if got := texts(ring.Last(0)); got != "2:two 3:three 4:four" {
+		t.Errorf("Last(0) = %q", got)
+	}
+}
+
+func TestLoggerRing(t *testing.T) {
+	logger, _ := newFileLogger(t, config.SinkConfig{Level: "info", Redact: []string{"ssid"}})
+
+	logger.Debug("Hidden")
+	logger.Info("Connected", "ssid", "home-wifi")
+
+	lines := logger.Ring().Last(0)
+	if len(lines) != 1 {
+		t.Fatalf("Expected one line at the sink level, got %v", lines)
+	}
+	if !strings.Contains(lines[0].Text, "Connected") || strings.Contains(lines[0].Text, "home-wifi") {
+		t.Errorf("Expected the line redacted like the sink, got %q", lines[0].Text)
+	}
+}
+
+func TestContextWith(t *testing.T) {
+	logger, path := newFileLogger(t, config.SinkConfig{Level: "info"})
+
+	ctx := ContextWith(context.Background(), "conn", 7)
+	ctx = ContextWith(ctx, "request_id", "req-1")
+	logger.InfoContext(ctx, "Handled")
+	logger.Info("Unrelated")
+
+	lines := strings.Split(strings.TrimSpace(readLog(t, path)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", lines)
+	}
+	if !strings.Contains(lines[0], "conn=7 request_id=req-1") {
+		t.Errorf("Expected the context attrs on the line, got %q", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("Expected no context attrs without the context, got %q", lines[1])
+	}
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultRingSize is how many recent log lines the daemon keeps in memory
+const DefaultRingSize = 1000
+
+// Line is a log line kept in a Ring, numbered in the order it was written
+type Line struct {
+	Seq  uint64
+	Text string
+}
+
+// Ring keeps the most recent log lines in memory, so clients can read the
+// daemon's recent activity and follow new lines without access to its log
+// sinks. It is an io.Writer taking one line per Write, as slog handlers write.
+type Ring struct {
+	mutex sync.Mutex
+	lines []Line // Circular, oldest at start once full
+	start int
+	size  int
+	seq   uint64 // Seq of the last line written
+}
+
+// NewRing creates a ring keeping the last size lines
+func NewRing(size int) *Ring {
+	if size <= 0 {
+		size = DefaultRingSize
+	}
+	return &Ring{size: size}
+}
+
+// Write stores p as the newest line, dropping the oldest if the ring is full
+func (r *Ring) Write(p []byte) (int, error) {
+	text := strings.TrimRight(string(p), "\n")
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.seq++
+	line := Line{Seq: r.seq, Text: text}
+	if len(r.lines) < r.size {
+		r.lines = append(r.lines, line)
+	} else {
+		r.lines[r.start] = line
+		r.start = (r.start + 1) % r.size
+	}
+	return len(p), nil
+}
+
+// Since returns the lines written after the line numbered seq, oldest first,
+// and how many of them were already dropped from the ring. Since(0) returns
+// every line kept.
+func (r *Ring) Since(seq uint64) (lines []Line, dropped uint64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.since(seq)
+}
+
+// Last returns the newest n lines, oldest first; n <= 0 returns every line kept
+func (r *Ring) Last(n int) []Line {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var seq uint64
+	if n > 0 && uint64(n) < r.seq {
+		seq = r.seq - uint64(n)
+	}
+	lines, _ := r.since(seq)
+	return lines
+}
+
+// since implements Since (caller must hold the mutex)
+func (r *Ring) since(seq uint64) (lines []Line, dropped uint64) {
+	// A number past the newest line was handed out by an earlier daemon
+	if seq > r.seq {
+		seq = 0
+	}
+	if seq == r.seq {
+		return nil, 0
+	}
+
+	oldest := r.seq - uint64(len(r.lines)) + 1
+	if seq+1 < oldest {
+		dropped = oldest - seq - 1
+		seq = oldest - 1
+	}
+
+	count := int(r.seq - seq)
+	lines = make([]Line, 0, count)
+	for i := len(r.lines) - count; i < len(r.lines); i++ {
+		lines = append(lines, r.lines[(r.start+i)%len(r.lines)])
+	}
+	return lines, dropped
+}
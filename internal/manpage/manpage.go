@@ -0,0 +1,178 @@
+// Package manpage renders man pages in roff from the cobra command tree.
+package manpage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Header holds the values of the .TH line shared by all pages
+type Header struct {
+	Section string // Manual section, "1" if empty
+	Date    time.Time
+	Source  string // e.g. "legionbatctl 1.2.0"
+	Manual  string // e.g. "legionbatctl Manual"
+}
+
+// GenerateTree writes a page for cmd and each of its available subcommands
+// to dir, named like legionbatctl-set-threshold.1
+func GenerateTree(cmd *cobra.Command, dir string, header Header) error {
+	if header.Section == "" {
+		header.Section = "1"
+	}
+
+	for _, sub := range cmd.Commands() {
+		if !sub.IsAvailableCommand() || sub.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		if err := GenerateTree(sub, dir, header); err != nil {
+			return err
+		}
+	}
+
+	path := filepath.Join(dir, pageName(cmd)+"."+header.Section)
+	if err := os.WriteFile(path, []byte(Render(cmd, header)), 0644); err != nil {
+		return fmt.Errorf("failed to write man page: %w", err)
+	}
+	return nil
+}
+
+// Render returns the page of a single command
+func Render(cmd *cobra.Command, header Header) string {
+	if header.Section == "" {
+		header.Section = "1"
+	}
+
+	var b strings.Builder
+	name := pageName(cmd)
+
+	fmt.Fprintf(&b, ".TH %q %q %q %q %q\n", strings.ToUpper(name), header.Section,
+		header.Date.Format("Jan 2006"), header.Source, header.Manual)
+
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", escape(name), escape(cmd.Short))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, "\\fB%s\\fP\n", escape(cmd.UseLine()))
+
+	b.WriteString(".SH DESCRIPTION\n")
+	description := cmd.Long
+	if description == "" {
+		description = cmd.Short
+	}
+	writeText(&b, description)
+
+	writeFlags(&b, "OPTIONS", cmd.NonInheritedFlags())
+	writeFlags(&b, "OPTIONS INHERITED FROM PARENT COMMANDS", cmd.InheritedFlags())
+
+	if cmd.Example != "" {
+		b.WriteString(".SH EXAMPLE\n.PP\n.nf\n")
+		for _, line := range strings.Split(cmd.Example, "\n") {
+			b.WriteString(escapeLine(line) + "\n")
+		}
+		b.WriteString(".fi\n")
+	}
+
+	var related []string
+	if cmd.HasParent() {
+		related = append(related, pageName(cmd.Parent()))
+	}
+	for _, sub := range cmd.Commands() {
+		if sub.IsAvailableCommand() && !sub.IsAdditionalHelpTopicCommand() {
+			related = append(related, pageName(sub))
+		}
+	}
+	if len(related) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		for i, page := range related {
+			related[i] = fmt.Sprintf("\\fB%s\\fP(%s)", escape(page), header.Section)
+		}
+		b.WriteString(strings.Join(related, ", ") + "\n")
+	}
+
+	return b.String()
+}
+
+// pageName turns the command path into the page name
+func pageName(cmd *cobra.Command) string {
+	return strings.ReplaceAll(cmd.CommandPath(), " ", "-")
+}
+
+// writeFlags writes a section listing the visible flags, if there are any
+func writeFlags(b *strings.Builder, title string, flags *pflag.FlagSet) {
+	var entries []string
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden || flag.Name == "help" {
+			return
+		}
+
+		name := "\\fB\\-\\-" + escape(flag.Name) + "\\fP"
+		if flag.Shorthand != "" {
+			name = "\\fB\\-" + escape(flag.Shorthand) + "\\fP, " + name
+		}
+		varname, usage := pflag.UnquoteUsage(flag)
+		if varname != "" {
+			name += " \\fI" + escape(varname) + "\\fP"
+		}
+		if flag.DefValue != "" && flag.DefValue != "false" && flag.DefValue != "0" && flag.DefValue != "[]" {
+			usage += fmt.Sprintf(" (default %s)", flag.DefValue)
+		}
+		entries = append(entries, ".TP\n"+name+"\n"+escapeLine(usage)+"\n")
+	})
+
+	if len(entries) == 0 {
+		return
+	}
+	b.WriteString(".SH " + title + "\n")
+	b.WriteString(strings.Join(entries, ""))
+}
+
+// writeText writes paragraphs, keeping indented lines (examples, config
+// snippets) verbatim
+func writeText(b *strings.Builder, text string) {
+	b.WriteString(".PP\n")
+	verbatim := false
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		indented := strings.HasPrefix(line, "  ")
+		switch {
+		case line == "":
+			if verbatim {
+				b.WriteString(".fi\n")
+				verbatim = false
+			}
+			b.WriteString(".PP\n")
+			continue
+		case indented && !verbatim:
+			b.WriteString(".nf\n")
+			verbatim = true
+		case !indented && verbatim:
+			b.WriteString(".fi\n")
+			verbatim = false
+		}
+		b.WriteString(escapeLine(line) + "\n")
+	}
+	if verbatim {
+		b.WriteString(".fi\n")
+	}
+}
+
+// escape escapes roff special characters within a line
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	return strings.ReplaceAll(s, "-", "\\-")
+}
+
+// escapeLine escapes a whole line, so it can't be read as a request
+func escapeLine(s string) string {
+	s = escape(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
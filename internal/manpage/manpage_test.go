@@ -0,0 +1,77 @@
+package manpage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func testCommand() *cobra.Command {
+	root := &cobra.Command{Use: "legionbatctl", Short: "Battery control"}
+	root.PersistentFlags().String("config", "/etc/legionbatctl.conf", "Path to configuration file")
+
+	sub := &cobra.Command{
+		Use:     "set-threshold <percentage>",
+		Short:   "Set the threshold",
+		Long:    "Sets the threshold.\n\n  [profiles.desk]\n  threshold = 60\n\n.dot at start",
+		Example: "  legionbatctl set-threshold 80",
+		Run:     func(cmd *cobra.Command, args []string) {},
+	}
+	sub.Flags().IntP("start", "s", 0, "Resume charging below this `level`")
+	root.AddCommand(sub)
+	root.AddCommand(&cobra.Command{Use: "secret", Hidden: true, Run: func(cmd *cobra.Command, args []string) {}})
+
+	return root
+}
+
+func TestRender(t *testing.T) {
+	root := testCommand()
+	sub, _, err := root.Find([]string{"set-threshold"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := Render(sub, Header{Date: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Source: "legionbatctl 1.0"})
+
+	for _, want := range []string{
+		`.TH "LEGIONBATCTL-SET-THRESHOLD" "1" "Jan 2026" "legionbatctl 1.0" ""`,
+		"legionbatctl\\-set\\-threshold \\- Set the threshold\n",
+		".nf\n  [profiles.desk]\n  threshold = 60\n.fi\n",
+		"\\&.dot at start\n",
+		"\\fB\\-s\\fP, \\fB\\-\\-start\\fP \\fIlevel\\fP\nResume charging below this level\n",
+		".SH OPTIONS INHERITED FROM PARENT COMMANDS\n",
+		"(default /etc/legionbatctl.conf)",
+		".SH SEE ALSO\n\\fBlegionbatctl\\fP(1)\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Render() missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestGenerateTree(t *testing.T) {
+	dir := t.TempDir()
+	if err := GenerateTree(testCommand(), dir, Header{}); err != nil {
+		t.Fatalf("GenerateTree() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if got := strings.Join(names, " "); got != "legionbatctl-set-threshold.1 legionbatctl.1" {
+		t.Errorf("GenerateTree() wrote %q", got)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "legionbatctl-secret.1")); err == nil {
+		t.Error("GenerateTree() wrote a page for a hidden command")
+	}
+}
@@ -0,0 +1,89 @@
+// Package metrics renders the battery status in the OpenMetrics text format,
+// e.g. for node_exporter's textfile collector.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+// metric is a single gauge sample
+type metric struct {
+	name  string
+	help  string
+	value float64
+}
+
+// Render writes the status as OpenMetrics gauges
+func Render(w io.Writer, status *protocol.StatusData) error {
+	metrics := []metric{
+		{"legionbatctl_battery_level_percent", "Battery charge level.", float64(status.BatteryLevel)},
+		{"legionbatctl_ac_online", "Whether the AC adapter is connected.", boolValue(status.Charging)},
+		{"legionbatctl_conservation_mode", "Whether hardware conservation mode is on.", boolValue(status.ConservationMode)},
+		{"legionbatctl_management_enabled", "Whether the daemon manages conservation mode.", boolValue(status.ConservationEnabled)},
+		{"legionbatctl_charge_threshold_percent", "Charge level at which charging stops.", float64(status.Threshold)},
+		{"legionbatctl_charge_start_threshold_percent", "Charge level below which charging resumes.", float64(status.StartThreshold)},
+		{"legionbatctl_charge_full_active", "Whether a charge to 100% is in progress.", boolValue(status.ChargeFull)},
+		{"legionbatctl_storage_mode", "Whether storage mode is holding the battery.", boolValue(status.StorageMode)},
+		{"legionbatctl_unclean_shutdowns", "Daemon runs that ended without a clean shutdown.", float64(status.UncleanShutdowns)},
+	}
+
+	buf := bufio.NewWriter(w)
+	for _, m := range metrics {
+		fmt.Fprintf(buf, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(buf, "# TYPE %s gauge\n", m.name)
+		fmt.Fprintf(buf, "%s %g\n", m.name, m.value)
+	}
+	fmt.Fprintln(buf, "# EOF")
+
+	return buf.Flush()
+}
+
+// WriteTextfile atomically replaces the file at path with the rendered
+// status, so the collector never reads a partial file
+func WriteTextfile(path string, status *protocol.StatusData) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create textfile directory: %w", err)
+	}
+
+	// The collector only reads *.prom files, so the temp file is ignored
+	tempPath := path + ".tmp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer file.Close()
+
+	if err := Render(file, status); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tempPath, 0644); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to set textfile permissions: %w", err)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to replace textfile: %w", err)
+	}
+
+	return nil
+}
+
+// boolValue converts a boolean to a 0/1 gauge value
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+func TestWriteTextfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "textfile", "legionbatctl.prom")
+	status := &protocol.StatusData{
+		BatteryLevel:        79,
+		Charging:            true,
+		ConservationEnabled: true,
+		Threshold:           80,
+		StartThreshold:      75,
+	}
+
+	if err := WriteTextfile(path, status); err != nil {
+		t.Fatalf("WriteTextfile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read textfile: %v", err)
+	}
+	output := string(data)
+
+	for _, line := range []string{
+		"# TYPE legionbatctl_battery_level_percent gauge\n",
+		"legionbatctl_battery_level_percent 79\n",
+		"legionbatctl_ac_online 1\n",
+		"legionbatctl_conservation_mode 0\n",
+		"legionbatctl_charge_start_threshold_percent 75\n",
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected %q in textfile:\n%s", line, output)
+		}
+	}
+	if !strings.HasSuffix(output, "# EOF\n") {
+		t.Error("Expected textfile to end with # EOF")
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Expected temp file to be removed")
+	}
+}
@@ -0,0 +1,125 @@
+// Package notify implements event handlers that tell users about daemon
+// events outside of the logs.
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/dom1nux/legionbatctl/internal/events"
+)
+
+// desktopTitles are the notification summaries per event type
+var desktopTitles = map[events.Type]string{
+	events.ManagementEnabled:  "Battery management enabled",
+	events.ManagementDisabled: "Battery management disabled",
+	events.ConservationOn:     "Conservation mode on",
+	events.ConservationOff:    "Conservation mode off",
+	events.ThresholdReached:   "Charge threshold reached",
+	events.ChargePaused:       "Charging paused",
+	events.ThresholdChanged:   "Charge threshold changed",
+	events.BatteryLow:         "Battery low",
+	events.BatteryCritical:    "Battery critically low",
+	events.DischargingOnAC:    "Battery discharging on AC",
+	events.LowChargePower:     "Charging slower than usual",
+	events.RuleTriggered:      "Battery rule triggered",
+	events.Error:              "Battery management error",
+}
+
+// Desktop sends desktop notifications through the org.freedesktop.Notifications
+// service of a user's session, using notify-send. A daemon running as root
+// sends them as the configured user to that user's session bus.
+type Desktop struct {
+	events map[events.Type]bool
+	uid    int
+	gid    int
+	home   string
+
+	// run executes the prepared notify-send command
+	run func(cmd *exec.Cmd) error
+}
+
+// NewDesktop creates a desktop notifier for the given event types. An empty
+// username notifies the session of the user running the daemon.
+func NewDesktop(username string, types []events.Type) (*Desktop, error) {
+	d := &Desktop{
+		uid:  os.Getuid(),
+		gid:  os.Getgid(),
+		home: os.Getenv("HOME"),
+		run:  func(cmd *exec.Cmd) error { return cmd.Run() },
+	}
+	d.SetEvents(types)
+
+	if username != "" {
+		u, err := user.Lookup(username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up notification user: %w", err)
+		}
+		d.uid, _ = strconv.Atoi(u.Uid)
+		d.gid, _ = strconv.Atoi(u.Gid)
+		d.home = u.HomeDir
+	}
+
+	return d, nil
+}
+
+// SetEvents replaces the event types notified about
+func (d *Desktop) SetEvents(types []events.Type) {
+	d.events = make(map[events.Type]bool)
+	for _, t := range types {
+		d.events[t] = true
+	}
+}
+
+// UID returns the ID of the notified user
+func (d *Desktop) UID() int {
+	return d.uid
+}
+
+// Home returns the home directory of the notified user
+func (d *Desktop) Home() string {
+	return d.home
+}
+
+// Name returns the handler name
+func (d *Desktop) Name() string {
+	return "desktop"
+}
+
+// Handle shows a notification for subscribed events
+func (d *Desktop) Handle(event events.Event) error {
+	if !d.events[event.Type] {
+		return nil
+	}
+
+	urgency := "normal"
+	if event.Type == events.Error || event.Type == events.BatteryCritical {
+		urgency = "critical"
+	}
+
+	cmd := exec.Command("notify-send",
+		"--app-name=legionbatctl",
+		"--icon=battery",
+		"--urgency="+urgency,
+		desktopTitles[event.Type],
+		event.Message)
+	cmd.Env = []string{
+		"HOME=" + d.home,
+		"PATH=/usr/local/bin:/usr/bin:/bin",
+		fmt.Sprintf("DBUS_SESSION_BUS_ADDRESS=unix:path=/run/user/%d/bus", d.uid),
+	}
+	if d.uid != os.Getuid() {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Credential: &syscall.Credential{Uid: uint32(d.uid), Gid: uint32(d.gid)},
+		}
+	}
+
+	if err := d.run(cmd); err != nil {
+		return fmt.Errorf("notify-send failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/dom1nux/legionbatctl/internal/events"
+)
+
+func TestDesktopHandle(t *testing.T) {
+	desktop, err := NewDesktop("", []events.Type{events.ThresholdReached, events.Error})
+	if err != nil {
+		t.Fatalf("NewDesktop failed: %v", err)
+	}
+
+	var commands [][]string
+	desktop.run = func(cmd *exec.Cmd) error {
+		commands = append(commands, cmd.Args)
+		return nil
+	}
+
+	for _, event := range []events.Event{
+		{Type: events.ConservationOn, Message: "not subscribed"},
+		{Type: events.ThresholdReached, Message: "Battery reached 80%, holding the charge"},
+		{Type: events.Error, Message: "Failed to read the battery"},
+	} {
+		if err := desktop.Handle(event); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+	}
+
+	if len(commands) != 2 {
+		t.Fatalf("Expected 2 notifications, got %d", len(commands))
+	}
+	if args := commands[0]; args[0] != "notify-send" || !slices.Contains(args, "Charge threshold reached") ||
+		!slices.Contains(args, "Battery reached 80%, holding the charge") {
+		t.Errorf("Unexpected command: %v", args)
+	}
+	if !slices.Contains(commands[1], "--urgency=critical") {
+		t.Errorf("Expected errors to be critical, got %v", commands[1])
+	}
+}
+
+func TestNewDesktopUnknownUser(t *testing.T) {
+	if _, err := NewDesktop("no-such-user-legionbatctl", nil); err == nil {
+		t.Error("Expected error for unknown user")
+	}
+}
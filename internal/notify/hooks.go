@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/events"
+)
+
+// DefaultHooksDir is where hook executables are looked up
+const DefaultHooksDir = "/etc/legionbatctl/hooks.d"
+
+// DefaultHookTimeout bounds how long a single hook may run
+const DefaultHookTimeout = 30 * time.Second
+
+// Hooks runs every executable in a directory on each event, in name order,
+// passing the event in environment variables:
+//
+//	EVENT          event type, e.g. conservation-on or threshold-changed
+//	BATTERY_LEVEL  battery level in percent
+//	THRESHOLD      charge threshold in percent
+//	MESSAGE        human-readable description
+//	EVENT_TIME     time of the event (RFC 3339)
+type Hooks struct {
+	dir     string
+	timeout time.Duration
+}
+
+// NewHooks creates a hook runner for dir. The directory is read on every
+// event, so hooks can be added or removed without a reload.
+func NewHooks(dir string, timeout time.Duration) *Hooks {
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	return &Hooks{dir: dir, timeout: timeout}
+}
+
+// Name returns the handler name
+func (h *Hooks) Name() string {
+	return "hooks"
+}
+
+// Handle runs the hooks for an event. A failing hook doesn't stop the
+// others; all failures are returned together.
+func (h *Hooks) Handle(event events.Event) error {
+	hooks, err := h.executables()
+	if err != nil {
+		return err
+	}
+
+	env := append(os.Environ(),
+		"EVENT="+string(event.Type),
+		"BATTERY_LEVEL="+strconv.Itoa(event.BatteryLevel),
+		"THRESHOLD="+strconv.Itoa(event.Threshold),
+		"MESSAGE="+event.Message,
+		"EVENT_TIME="+event.Time.Format(time.RFC3339),
+	)
+
+	var errs []error
+	for _, path := range hooks {
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		cmd := exec.CommandContext(ctx, path)
+		cmd.Env = env
+		cmd.Dir = h.dir
+		out, err := cmd.CombinedOutput()
+		cancel()
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("hook %s failed: %w: %s", filepath.Base(path), err, truncate(out, 200)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// executables lists the executable regular files in the hooks directory,
+// sorted by name. Files writable by group or others are skipped, as hooks
+// run with the daemon's privileges. A missing directory has no hooks.
+func (h *Hooks) executables() ([]string, error) {
+	entries, err := os.ReadDir(h.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hooks directory: %w", err)
+	}
+
+	var hooks []string
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 || info.Mode().Perm()&0022 != 0 {
+			continue
+		}
+		hooks = append(hooks, filepath.Join(h.dir, entry.Name()))
+	}
+	sort.Strings(hooks)
+
+	return hooks, nil
+}
+
+// truncate shortens hook output for error messages
+func truncate(out []byte, n int) string {
+	if len(out) > n {
+		return string(out[:n]) + "..."
+	}
+	return string(out)
+}
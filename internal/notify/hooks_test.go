@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/events"
+)
+
+func TestHooksHandle(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "out")
+
+	writeHook := func(name, script string, mode os.FileMode) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), mode); err != nil {
+			t.Fatalf("Failed to write hook: %v", err)
+		}
+	}
+	writeHook("10-record", `echo "1 $EVENT $BATTERY_LEVEL $THRESHOLD" >> `+out, 0755)
+	writeHook("20-fail", "echo broken; exit 3", 0755)
+	writeHook("30-record", `echo "3 $MESSAGE" >> `+out, 0755)
+	writeHook("README", `echo "not executable" >> `+out, 0644)
+	writeHook("40-unsafe", `echo "world writable" >> `+out, 0777)
+	os.Chmod(filepath.Join(dir, "40-unsafe"), 0777) // Not masked by the umask
+
+	hooks := NewHooks(dir, 5*time.Second)
+	err := hooks.Handle(events.Event{
+		Type:         events.ConservationOn,
+		Time:         time.Now(),
+		BatteryLevel: 80,
+		Threshold:    80,
+		Message:      "holding",
+	})
+	if err == nil || !strings.Contains(err.Error(), "20-fail") || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Expected the failing hook to be reported, got %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read hook output: %v", err)
+	}
+	if string(data) != "1 conservation-on 80 80\n3 holding\n" {
+		t.Errorf("Unexpected hook output %q", data)
+	}
+}
+
+func TestHooksMissingDir(t *testing.T) {
+	hooks := NewHooks(filepath.Join(t.TempDir(), "missing"), 0)
+	if err := hooks.Handle(events.Event{Type: events.Error}); err != nil {
+		t.Errorf("Expected no error without a hooks directory, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/events"
+)
+
+const (
+	// DefaultWebhookTimeout bounds a single delivery attempt
+	DefaultWebhookTimeout = 10 * time.Second
+
+	// DefaultWebhookAttempts is how often a delivery is tried
+	DefaultWebhookAttempts = 3
+)
+
+// Webhook POSTs events as JSON to a URL. Failed deliveries (network errors
+// and 5xx responses) are retried with exponential backoff; 4xx responses
+// are not, as retrying won't help.
+type Webhook struct {
+	url      string
+	host     string // For error reports, as the URL may carry credentials
+	headers  map[string]string
+	events   map[events.Type]bool // Empty means all events
+	attempts int
+	client   *http.Client
+	backoff  time.Duration // Delay before the first retry, doubled for each further one
+}
+
+// NewWebhook creates a webhook for rawURL. Zero timeout and attempts use the
+// defaults; no types subscribes to every event.
+func NewWebhook(rawURL string, headers map[string]string, types []events.Type,
+	timeout time.Duration, attempts int) (*Webhook, error) {
+	// Errors don't quote the URL whole, as its credentials, path and query
+	// may carry tokens
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.New("invalid webhook URL: failed to parse it")
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		origin := url.URL{Scheme: parsed.Scheme, Host: parsed.Host}
+		return nil, fmt.Errorf("invalid webhook URL %q: must be an http or https URL with a host", origin.String())
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	if attempts <= 0 {
+		attempts = DefaultWebhookAttempts
+	}
+
+	w := &Webhook{
+		url:      rawURL,
+		host:     parsed.Host,
+		headers:  headers,
+		events:   make(map[events.Type]bool),
+		attempts: attempts,
+		client:   &http.Client{Timeout: timeout},
+		backoff:  time.Second,
+	}
+	for _, t := range types {
+		w.events[t] = true
+	}
+
+	return w, nil
+}
+
+// Name returns the handler name
+func (w *Webhook) Name() string {
+	return "webhook " + w.host
+}
+
+// Handle delivers subscribed events
+func (w *Webhook) Handle(event events.Event) error {
+	if len(w.events) > 0 && !w.events[event.Type] {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	delay := w.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := w.post(payload)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == w.attempts {
+			return fmt.Errorf("delivery failed after %d attempt(s): %w", attempt, err)
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post sends the payload once, reporting whether a failure is worth retrying
+func (w *Webhook) post(payload []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "legionbatctl")
+	for key, value := range w.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("request failed: %w", redactURL(err))
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return true, fmt.Errorf("server error: %s", resp.Status)
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("rejected: %s", resp.Status)
+	}
+	return false, nil
+}
+
+// redactURL strips the URL from HTTP client errors, which may contain
+// tokens in the path or query
+func redactURL(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
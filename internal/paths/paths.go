@@ -0,0 +1,114 @@
+// Package paths resolves where the daemon and the CLI find the socket and
+// the state files. Root uses the system locations; other users, e.g. while
+// developing or testing, get per-user locations under the XDG base
+// directories instead of failing on /var/run permissions.
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// System locations, used when running as root
+const (
+	SystemSocketPath  = "/var/run/legionbatctl.sock"
+	SystemStatePath   = "/etc/legionbatctl.state"
+	SystemRuntimePath = "/run/legionbatctl/runtime.json"
+)
+
+// Environment variables overriding the locations
+const (
+	SocketPathEnv  = "SOCKET_PATH"
+	StatePathEnv   = "STATE_PATH"
+	RuntimePathEnv = "RUNTIME_PATH"
+)
+
+// statusCacheFile is where `status --changes` keeps the status it last saw,
+// under the user's cache directory
+const statusCacheFile = "legionbatctl/last-status.json"
+
+// Paths are the locations of the daemon's files
+type Paths struct {
+	Socket  string
+	State   string
+	Runtime string // Runtime state, kept apart from the state file
+}
+
+// Defaults returns the locations for the current user, ignoring the
+// environment variables overriding them
+func Defaults() Paths {
+	return defaults(os.Geteuid(), os.Getenv)
+}
+
+// SocketPath returns SOCKET_PATH if set, otherwise the default socket
+func SocketPath() string {
+	return fromEnv(SocketPathEnv, Defaults().Socket)
+}
+
+// StatePath returns STATE_PATH if set, otherwise the default state file
+func StatePath() string {
+	return fromEnv(StatePathEnv, Defaults().State)
+}
+
+// RuntimePath returns RUNTIME_PATH if set, otherwise the default runtime
+// state file
+func RuntimePath() string {
+	return fromEnv(RuntimePathEnv, Defaults().Runtime)
+}
+
+// StatusCachePath returns where the CLI keeps the status it last saw, for
+// `status --changes`: under XDG_CACHE_HOME, or ~/.cache
+func StatusCachePath() string {
+	return statusCachePath(os.Getenv)
+}
+
+func statusCachePath(getenv func(string) string) string {
+	cacheHome := getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home := getenv("HOME")
+		if home == "" {
+			home = os.TempDir() // Nowhere persistent to go
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, statusCacheFile)
+}
+
+// fromEnv returns the environment variable if set, otherwise fallback
+func fromEnv(env, fallback string) string {
+	if value := os.Getenv(env); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// defaults resolves the locations for the user euid. Without
+// XDG_RUNTIME_DIR the runtime files go to a per-user directory under the
+// temporary directory; without XDG_STATE_HOME the state goes to
+// ~/.local/state.
+func defaults(euid int, getenv func(string) string) Paths {
+	if euid == 0 {
+		return Paths{Socket: SystemSocketPath, State: SystemStatePath, Runtime: SystemRuntimePath}
+	}
+
+	runtimeDir := getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = filepath.Join(os.TempDir(), fmt.Sprintf("legionbatctl-%d", euid))
+	}
+
+	stateHome := getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home := getenv("HOME")
+		if home == "" {
+			home = runtimeDir // Nowhere persistent to go
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+
+	return Paths{
+		Socket:  filepath.Join(runtimeDir, "legionbatctl.sock"),
+		State:   filepath.Join(stateHome, "legionbatctl", "state.json"),
+		Runtime: filepath.Join(runtimeDir, "legionbatctl", "runtime.json"),
+	}
+}
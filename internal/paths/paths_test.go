@@ -0,0 +1,87 @@
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		euid int
+		env  map[string]string
+		want Paths
+	}{
+		{
+			name: "root uses the system locations",
+			euid: 0,
+			env:  map[string]string{"XDG_RUNTIME_DIR": "/run/user/0"},
+			want: Paths{Socket: SystemSocketPath, State: SystemStatePath, Runtime: SystemRuntimePath},
+		},
+		{
+			name: "user with XDG directories",
+			euid: 1000,
+			env:  map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000", "XDG_STATE_HOME": "/home/dev/state"},
+			want: Paths{
+				Socket:  "/run/user/1000/legionbatctl.sock",
+				State:   "/home/dev/state/legionbatctl/state.json",
+				Runtime: "/run/user/1000/legionbatctl/runtime.json",
+			},
+		},
+		{
+			name: "user without XDG_STATE_HOME",
+			euid: 1000,
+			env:  map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000", "HOME": "/home/dev"},
+			want: Paths{
+				Socket:  "/run/user/1000/legionbatctl.sock",
+				State:   "/home/dev/.local/state/legionbatctl/state.json",
+				Runtime: "/run/user/1000/legionbatctl/runtime.json",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := defaults(tt.euid, func(key string) string { return tt.env[key] })
+			if got != tt.want {
+				t.Errorf("defaults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultsWithoutRuntimeDir(t *testing.T) {
+	got := defaults(1000, func(key string) string {
+		if key == "HOME" {
+			return "/home/dev"
+		}
+		return ""
+	})
+
+	if filepath.Base(filepath.Dir(got.Socket)) != "legionbatctl-1000" {
+		t.Errorf("Socket = %s, want it in a per-user temporary directory", got.Socket)
+	}
+}
+
+func TestStatePathFromEnv(t *testing.T) {
+	t.Setenv(StatePathEnv, "/tmp/custom.state")
+	if got := StatePath(); got != "/tmp/custom.state" {
+		t.Errorf("StatePath() = %s, want /tmp/custom.state", got)
+	}
+}
+
+func TestStatusCachePath(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"XDG_CACHE_HOME": "/home/dev/cache", "HOME": "/home/dev"}, "/home/dev/cache/legionbatctl/last-status.json"},
+		{map[string]string{"HOME": "/home/dev"}, "/home/dev/.cache/legionbatctl/last-status.json"},
+	}
+
+	for _, tt := range tests {
+		if got := statusCachePath(func(key string) string { return tt.env[key] }); got != tt.want {
+			t.Errorf("statusCachePath(%v) = %s, want %s", tt.env, got, tt.want)
+		}
+	}
+}
@@ -1,48 +1,293 @@
 package protocol
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"time"
 )
 
-// Codec handles encoding and decoding of protocol messages
+// Framings delimit messages on the stream. Connections start out line framed
+// and can switch to length-prefixed framing with a hello handshake.
+const (
+	// FramingLine sends each message as a line of JSON
+	FramingLine = "line"
+
+	// FramingLength prefixes each JSON message with its size as a 4-byte
+	// big-endian integer, so truncated messages are detected and payloads
+	// need no scanning for the delimiter
+	FramingLength = "length"
+)
+
+// lengthPrefixSize is the size of the length prefix
+const lengthPrefixSize = 4
+
+const (
+	// MaxMessageSize is the largest request the daemon accepts, protecting it
+	// from clients sending unbounded data
+	MaxMessageSize = 64 << 10
+
+	// MaxParams is the most parameters a request may carry
+	MaxParams = 32
+
+	// MaxParamsDepth is how deeply objects and arrays may nest in a
+	// request's parameters, the parameters themselves being depth 1. A
+	// batch's commands take up the rest: their list, each command and its
+	// parameters.
+	MaxParamsDepth = 4
+
+	// MaxParamsValues is the most values a request's parameters may hold,
+	// counting the elements of nested objects and arrays
+	MaxParamsValues = 256
+
+	// ReadTimeout bounds how long the daemon waits for each request, so a
+	// client that stalls mid-message can't hold its connection open
+	ReadTimeout = 10 * time.Second
+)
+
+// Framing errors
+var (
+	ErrMessageTooLarge = NewCodedError(CodeProtocolError, "message exceeds 64 KiB")
+	ErrTooManyParams   = NewCodedError(CodeProtocolError, fmt.Sprintf("request has more than %d parameters", MaxParams))
+	ErrParamsTooDeep   = NewCodedError(CodeProtocolError, fmt.Sprintf("request parameters nest deeper than %d levels", MaxParamsDepth))
+	ErrParamsTooLarge  = NewCodedError(CodeProtocolError, fmt.Sprintf("request parameters hold more than %d values", MaxParamsValues))
+	ErrUnknownType     = NewCodedError(CodeProtocolError, "unknown message type")
+	ErrMalformed       = NewCodedError(CodeProtocolError, "malformed message")
+	ErrTruncated       = NewCodedError(CodeProtocolError, "truncated message")
+)
+
+// Codec handles encoding and decoding of protocol messages. Messages are
+// JSON objects, one per line, until another framing is selected.
 type Codec struct {
-	encoder *json.Encoder
-	decoder *json.Decoder
+	writer  io.Writer
+	reader  *bufio.Reader
+	framing string
+	maxSize int // 0 means unlimited
+
+	// Read deadlines are set per message if the stream supports them
+	deadliner   readDeadliner
+	readTimeout time.Duration // 0 means no deadline
+}
+
+// readDeadliner is implemented by streams such as net.Conn
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
 }
 
-// NewCodec creates a new codec for the given reader/writer
+// NewCodec creates a new line framed codec for the given reader/writer
 func NewCodec(rw io.ReadWriter) *Codec {
+	deadliner, _ := rw.(readDeadliner)
 	return &Codec{
-		encoder: json.NewEncoder(rw),
-		decoder: json.NewDecoder(rw),
+		writer:    rw,
+		reader:    bufio.NewReader(rw),
+		framing:   FramingLine,
+		deadliner: deadliner,
 	}
 }
 
+// SetFraming switches the framing of subsequent messages in both directions
+func (c *Codec) SetFraming(framing string) error {
+	if !IsValidFraming(framing) {
+		return fmt.Errorf("unsupported framing %q", framing)
+	}
+	c.framing = framing
+	return nil
+}
+
+// Framing returns the framing in use
+func (c *Codec) Framing() string {
+	return c.framing
+}
+
+// IsValidFraming reports whether a framing is supported
+func IsValidFraming(framing string) bool {
+	return framing == FramingLine || framing == FramingLength
+}
+
+// SetMaxMessageSize limits the size of decoded messages; larger messages are
+// rejected with ErrMessageTooLarge before being parsed
+func (c *Codec) SetMaxMessageSize(size int) {
+	c.maxSize = size
+}
+
+// SetReadTimeout bounds the time Decode waits for each message, when the
+// stream supports read deadlines. Every message gets the full timeout, however
+// long the connection has been open.
+func (c *Codec) SetReadTimeout(timeout time.Duration) {
+	c.readTimeout = timeout
+}
+
 // Encode writes a message to the writer
 func (c *Codec) Encode(msg *Message) error {
 	if err := msg.Validate(); err != nil {
 		return fmt.Errorf("invalid message: %w", err)
 	}
 
-	return c.encoder.Encode(msg)
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	// Written in one call so a frame is never interleaved or half-sent by us
+	var frame []byte
+	if c.framing == FramingLength {
+		frame = binary.BigEndian.AppendUint32(make([]byte, 0, lengthPrefixSize+len(payload)), uint32(len(payload)))
+		frame = append(frame, payload...)
+	} else {
+		frame = append(payload, '\n')
+	}
+
+	_, err = c.writer.Write(frame)
+	return err
 }
 
-// Decode reads a message from the reader
+// Decode reads a message from the reader. A message that parses but fails
+// validation is returned along with the error, so its ID can be echoed in
+// the error response.
 func (c *Codec) Decode() (*Message, error) {
+	if c.deadliner != nil && c.readTimeout > 0 {
+		if err := c.deadliner.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+			return nil, err
+		}
+	}
+
+	frame, err := c.readFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	// The type is checked before the rest of the message is decoded, so
+	// whatever else a stray message holds is never built
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(frame, &head); err != nil {
+		return nil, fmt.Errorf("decode error: %w: %v", ErrMalformed, err)
+	}
+	if head.Type != "request" && head.Type != "response" {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, head.Type)
+	}
+
 	var msg Message
-	if err := c.decoder.Decode(&msg); err != nil {
-		return nil, fmt.Errorf("decode error: %w", err)
+	if err := json.Unmarshal(frame, &msg); err != nil {
+		return nil, fmt.Errorf("decode error: %w: %v", ErrMalformed, err)
+	}
+
+	if msg.Request != nil {
+		if err := checkParams(msg.Request.Params); err != nil {
+			return &msg, err
+		}
 	}
 
 	if err := msg.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid message: %w", err)
+		return &msg, fmt.Errorf("invalid message: %w", err)
 	}
 
 	return &msg, nil
 }
 
+// checkParams bounds the number, nesting and total size of a request's
+// parameters, which handlers otherwise walk without limits
+func checkParams(params map[string]interface{}) error {
+	if len(params) > MaxParams {
+		return ErrTooManyParams
+	}
+
+	values := 0
+	var walk func(v interface{}, depth int) error
+	walk = func(v interface{}, depth int) error {
+		var children []interface{}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for _, child := range v {
+				children = append(children, child)
+			}
+		case []interface{}:
+			children = v
+		default:
+			return nil
+		}
+
+		if depth > MaxParamsDepth {
+			return ErrParamsTooDeep
+		}
+		values += len(children)
+		if values > MaxParamsValues {
+			return ErrParamsTooLarge
+		}
+		for _, child := range children {
+			if err := walk(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return walk(params, 1)
+}
+
+// readFrame reads the next message in the codec's framing
+func (c *Codec) readFrame() ([]byte, error) {
+	if c.framing == FramingLength {
+		return c.readLengthFrame()
+	}
+	return c.readLine()
+}
+
+// readLengthFrame reads a length-prefixed message, rejecting oversized ones
+// before reading their payload
+func (c *Codec) readLengthFrame() ([]byte, error) {
+	var prefix [lengthPrefixSize]byte
+	if _, err := io.ReadFull(c.reader, prefix[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrTruncated
+		}
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(prefix[:])
+	if c.maxSize > 0 && uint64(size) > uint64(c.maxSize) {
+		return nil, ErrMessageTooLarge
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, frame); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrTruncated
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+// readLine reads the next non-blank line, enforcing the size limit while
+// reading. A final message without a newline is accepted at EOF.
+func (c *Codec) readLine() ([]byte, error) {
+	var frame []byte
+	for {
+		chunk, err := c.reader.ReadSlice('\n')
+		frame = append(frame, chunk...)
+		if c.maxSize > 0 && len(frame) > c.maxSize {
+			return nil, ErrMessageTooLarge
+		}
+
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case err != nil && (err != io.EOF || len(bytes.TrimSpace(frame)) == 0):
+			return nil, err
+		case len(bytes.TrimSpace(frame)) == 0:
+			frame = frame[:0]
+			continue
+		}
+		return frame, nil
+	}
+}
+
 // SendRequest encodes and sends a request message
 func (c *Codec) SendRequest(command string, params map[string]interface{}) (*Message, error) {
 	msg := NewRequest(command, params)
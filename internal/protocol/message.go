@@ -36,14 +36,25 @@ func NewResponse(requestID string, success bool, data interface{}, errMsg string
 	}
 }
 
-// NewErrorResponse creates a new error response message
+// NewErrorResponse creates a new error response message, carrying the code,
+// allowed range and hint of any protocol error in err's chain
 func NewErrorResponse(requestID string, err error) *Message {
 	var errMsg string
 	if err != nil {
 		errMsg = err.Error()
 	}
 
-	return NewResponse(requestID, false, nil, errMsg)
+	msg := NewResponse(requestID, false, nil, errMsg)
+	msg.Response.Code = ErrorCode(err)
+	msg.Response.Range = AllowedRange(err)
+	msg.Response.Hint = ErrorHint(err)
+	return msg
+}
+
+// ResponseError converts a failed response back into a protocol error,
+// preserving its code, allowed range and hint
+func ResponseError(response *Response) *Error {
+	return &Error{Code: response.Code, Message: response.Error, Range: response.Range, Hint: response.Hint}
 }
 
 // NewSuccessResponse creates a new success response message
@@ -57,7 +68,9 @@ func (m *Message) Validate() error {
 		return fmt.Errorf("invalid message type: %s", m.Type)
 	}
 
-	if m.ID == "" {
+	// Errors for requests that were never read, e.g. refused connections,
+	// have no request ID to echo
+	if m.ID == "" && !(m.IsResponse() && m.Response != nil && !m.Response.Success) {
 		return fmt.Errorf("message ID cannot be empty")
 	}
 
@@ -1,8 +1,15 @@
 package protocol
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestMessageValidation(t *testing.T) {
@@ -54,6 +61,15 @@ func TestMessageValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "error response without ID",
+			msg:  NewErrorResponse("", ErrTooManyConnections),
+		},
+		{
+			name:    "success response without ID",
+			msg:     NewSuccessResponse("", nil),
+			wantErr: true,
+		},
 		{
 			name: "request missing request data",
 			msg: &Message{
@@ -167,6 +183,7 @@ func TestIsValidCommand(t *testing.T) {
 		{CmdStatus, true},
 		{CmdSetThreshold, true},
 		{CmdDaemonStatus, true},
+		{CmdLogs, true},
 		{"invalid", false},
 		{"", false},
 	}
@@ -205,6 +222,88 @@ func TestValidateThreshold(t *testing.T) {
 	}
 }
 
+func TestValidateThresholdRange(t *testing.T) {
+	wide := ThresholdRangeData{Min: 40, Max: 100}
+	stepped := ThresholdRangeData{Min: 50, Max: 100, Step: 5}
+	tests := []struct {
+		threshold int
+		allowed   ThresholdRangeData
+		wantErr   bool
+	}{
+		{40, wide, false},
+		{50, wide, false},
+		{39, wide, true},
+		{50, ThresholdRangeData{Min: 60, Max: 100}, true},
+		{101, wide, true},
+		{85, stepped, false},
+		{83, stepped, true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateThresholdRange(tt.threshold, tt.allowed)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateThresholdRange(%d, %v) error = %v, wantErr %v", tt.threshold, tt.allowed, err, tt.wantErr)
+		}
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, ErrInvalidThreshold) {
+			t.Errorf("Expected %v to match ErrInvalidThreshold", err)
+		}
+		if allowed := AllowedRange(err); allowed == nil || *allowed != tt.allowed {
+			t.Errorf("Expected the error to carry %v, got %v", tt.allowed, allowed)
+		}
+	}
+
+	if got := stepped.String(); got != "50-100% in steps of 5" {
+		t.Errorf("String() = %q", got)
+	}
+	for threshold, want := range map[int]int{30: 50, 83: 85, 82: 80, 120: 100} {
+		if got := stepped.Nearest(threshold); got != want {
+			t.Errorf("Nearest(%d) = %d, want %d", threshold, got, want)
+		}
+	}
+}
+
+func TestThresholdPresets(t *testing.T) {
+	for _, preset := range ThresholdPresets {
+		if err := ValidateThreshold(preset.Threshold); err != nil {
+			t.Errorf("Preset %s has an invalid threshold %d", preset.Name, preset.Threshold)
+		}
+		if found, ok := FindThresholdPreset(preset.Name); !ok || found != preset {
+			t.Errorf("FindThresholdPreset(%q) = %+v, %v", preset.Name, found, ok)
+		}
+	}
+
+	if _, ok := FindThresholdPreset("unknown"); ok {
+		t.Error("Expected no preset named unknown")
+	}
+}
+
+func TestValidateStartThreshold(t *testing.T) {
+	tests := []struct {
+		start     int
+		threshold int
+		wantErr   bool
+	}{
+		{0, 80, false},  // Unset
+		{70, 80, false}, // Valid
+		{79, 80, false}, // Just below threshold
+		{80, 80, true},  // Equal to threshold
+		{90, 80, true},  // Above threshold
+		{-1, 80, true},  // Invalid
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("start_%d", tt.start), func(t *testing.T) {
+			err := ValidateStartThreshold(tt.start, tt.threshold)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStartThreshold(%d, %d) error = %v, wantErr %v", tt.start, tt.threshold, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestMessageTypeHelpers(t *testing.T) {
 	// Test request message
 	reqMsg := NewRequest("enable", nil)
@@ -287,3 +386,191 @@ func TestGenerateID(t *testing.T) {
 		t.Errorf("Expected ID length 20, got %d", len(id1))
 	}
 }
+
+func TestErrorCodes(t *testing.T) {
+	wrapped := fmt.Errorf("failed to set threshold: %w", ErrInvalidThreshold)
+	if got := ErrorCode(wrapped); got != CodeInvalidThreshold {
+		t.Errorf("ErrorCode() = %q, want %q", got, CodeInvalidThreshold)
+	}
+
+	if got := ErrorCode(fmt.Errorf("plain error")); got != "" {
+		t.Errorf("ErrorCode() = %q, want empty code", got)
+	}
+
+	msg := NewErrorResponse("test-123", wrapped)
+	if msg.Response.Code != CodeInvalidThreshold {
+		t.Errorf("Expected response code %q, got %q", CodeInvalidThreshold, msg.Response.Code)
+	}
+
+	// Errors rebuilt from a response match the package-level variables
+	if !errors.Is(ResponseError(msg.Response), ErrInvalidThreshold) {
+		t.Error("Expected response error to match ErrInvalidThreshold")
+	}
+
+	// The allowed range survives the round trip through a response
+	allowed := ThresholdRangeData{Min: 40, Max: 100, Step: 1}
+	msg = NewErrorResponse("test-124", fmt.Errorf("failed: %w", ValidateThresholdRange(30, allowed)))
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if got := AllowedRange(ResponseError(decoded.Response)); got == nil || *got != allowed {
+		t.Errorf("Expected range %v after the round trip, got %v", allowed, got)
+	}
+
+	// So does the fix of a hinted error
+	msg = NewErrorResponse("test-125", fmt.Errorf("%w: open /sys/...: permission denied", ErrSysfsPermission))
+	if got := ErrorHint(ResponseError(msg.Response)); got != "run as root" {
+		t.Errorf("Expected hint %q, got %q", "run as root", got)
+	}
+	if !errors.Is(ResponseError(msg.Response), ErrPermissionDenied) {
+		t.Error("Expected the hinted error to match ErrPermissionDenied")
+	}
+}
+
+func TestCodecDecode(t *testing.T) {
+	manyParams := make(map[string]interface{})
+	for i := 0; i <= MaxParams; i++ {
+		manyParams[fmt.Sprintf("p%d", i)] = i
+	}
+	tooMany, _ := json.Marshal(NewRequest(CmdStatus, manyParams))
+
+	tests := []struct {
+		name    string
+		input   string
+		wantMsg bool
+		wantErr error
+	}{
+		{"request", `{"type":"request","id":"1","request":{"command":"status"}}` + "\n", true, nil},
+		{"blank lines and no final newline", "\n  \n" + `{"type":"request","id":"1","request":{"command":"status"}}`, true, nil},
+		{"too large", `{"type":"request","id":"1","request":{"command":"status","params":{"x":"` +
+			strings.Repeat("a", MaxMessageSize) + `"}}}` + "\n", false, ErrMessageTooLarge},
+		{"too many params", string(tooMany) + "\n", true, ErrTooManyParams},
+		{"malformed", "{not json}\n", false, ErrMalformed},
+		{"invalid command", `{"type":"request","id":"1","request":{"command":"reboot"}}` + "\n", true, ErrInvalidCommand},
+		{"unknown type", `{"type":"notify","id":"1","request":{"command":"status"}}` + "\n", false, ErrUnknownType},
+		{"missing type", `{"id":"1"}` + "\n", false, ErrUnknownType},
+		{"params too deep", `{"type":"request","id":"1","request":{"command":"status","params":{"a":{"b":{"c":{"d":[1]}}}}}}` + "\n", true, ErrParamsTooDeep},
+		{"params nested at limit", `{"type":"request","id":"1","request":{"command":"status","params":{"a":{"b":{"c":[1]}}}}}` + "\n", true, nil},
+		{"params too large", `{"type":"request","id":"1","request":{"command":"status","params":{"a":[` +
+			strings.TrimSuffix(strings.Repeat("0,", MaxParamsValues), ",") + `]}}}` + "\n", true, ErrParamsTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conn bytes.Buffer
+			conn.WriteString(tt.input)
+			codec := NewCodec(&conn)
+			codec.SetMaxMessageSize(MaxMessageSize)
+
+			msg, err := codec.Decode()
+			if (msg != nil) != tt.wantMsg {
+				t.Errorf("Decode() message = %v, want message: %v", msg, tt.wantMsg)
+			}
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCodecLengthFraming(t *testing.T) {
+	var conn bytes.Buffer
+	writer := NewCodec(&conn)
+	if err := writer.SetFraming(FramingLength); err != nil {
+		t.Fatalf("SetFraming failed: %v", err)
+	}
+
+	large := map[string]interface{}{"data": strings.Repeat("x", 2*MaxMessageSize)}
+	if err := writer.SendSuccessResponse("1", large); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := writer.SendSuccessResponse("2", nil); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	encoded := conn.Bytes()
+
+	// Responses are not size limited, so large payloads go through
+	reader := NewCodec(bytes.NewBuffer(encoded))
+	reader.SetFraming(FramingLength)
+	for _, id := range []string{"1", "2"} {
+		msg, err := reader.Decode()
+		if err != nil || msg.ID != id {
+			t.Fatalf("Decode() = %v, %v, want message %s", msg, err, id)
+		}
+	}
+
+	// A size limit rejects the frame from its prefix
+	limited := NewCodec(bytes.NewBuffer(encoded))
+	limited.SetFraming(FramingLength)
+	limited.SetMaxMessageSize(MaxMessageSize)
+	if _, err := limited.Decode(); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrMessageTooLarge)
+	}
+
+	// A partially written frame is detected rather than misparsed
+	truncated := NewCodec(bytes.NewBuffer(encoded[:len(encoded)-3]))
+	truncated.SetFraming(FramingLength)
+	truncated.Decode()
+	if _, err := truncated.Decode(); !errors.Is(err, ErrTruncated) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrTruncated)
+	}
+
+	if err := writer.SetFraming("netstring"); err == nil {
+		t.Error("Expected error for unsupported framing")
+	}
+}
+
+func TestCodecReadTimeout(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	codec := NewCodec(server)
+	codec.SetReadTimeout(50 * time.Millisecond)
+
+	// A client that starts a message but never finishes it is cut off
+	go client.Write([]byte(`{"type":"request"`))
+	if _, err := codec.Decode(); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Decode() error = %v, want %v", err, os.ErrDeadlineExceeded)
+	}
+}
+
+func FuzzCodecDecode(f *testing.F) {
+	request, _ := json.Marshal(NewRequest(CmdSetThreshold, map[string]interface{}{"threshold": 80}))
+	f.Add(append(request, '\n'), false)
+	f.Add([]byte(`{"type":"response","id":"1","response":{"success":true,"data":{"a":[1,2]}}}`), false)
+	f.Add([]byte(`{"type":"request","id":"1","request":{"command":"status","params":{"a":{"b":{"c":{"d":[1]}}}}}}`), false)
+	f.Add([]byte("\x00\x00\x00\x02{}"), true)
+	f.Add([]byte("\xff\xff\xff\xff"), true)
+	f.Add([]byte("{\"type\":\"request\",\"id\":"), false)
+
+	f.Fuzz(func(t *testing.T, input []byte, lengthFramed bool) {
+		codec := NewCodec(bytes.NewBuffer(input))
+		codec.SetMaxMessageSize(MaxMessageSize)
+		if lengthFramed {
+			codec.SetFraming(FramingLength)
+		}
+
+		// Decoding stops at the first error, as the daemon does
+		for i := 0; i < 8; i++ {
+			msg, err := codec.Decode()
+			if err != nil {
+				return
+			}
+			if err := msg.Validate(); err != nil {
+				t.Fatalf("Decode() accepted an invalid message: %v", err)
+			}
+			if msg.Request != nil && checkParams(msg.Request.Params) != nil {
+				t.Fatal("Decode() accepted parameters beyond the limits")
+			}
+			if _, err := json.Marshal(msg); err != nil {
+				t.Fatalf("Decoded message does not encode: %v", err)
+			}
+		}
+	})
+}
@@ -1,6 +1,10 @@
 package protocol
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // Message represents a communication message between CLI and daemon
 type Message struct {
@@ -21,6 +25,11 @@ type Response struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	Code    string      `json:"code,omitempty"` // Machine-readable error code, see Code* constants
+
+	// Range holds the allowed thresholds of an INVALID_THRESHOLD error
+	Range *ThresholdRangeData `json:"range,omitempty"`
+	Hint  string              `json:"hint,omitempty"` // How to fix the failure
 }
 
 // Command constants
@@ -30,12 +39,42 @@ const (
 	CmdStatus       = "status"
 	CmdSetThreshold = "set_threshold"
 	CmdDaemonStatus = "daemon_status"
+	CmdGetLimits    = "get_limits"
+	CmdSetLimits    = "set_limits"
+	CmdChargeFull   = "charge_full"
+	CmdGetSchedule  = "get_schedule"
+	CmdSetSchedule  = "set_schedule"
+	CmdStorage      = "storage"
+	CmdHealth       = "health"
+	CmdBatteryInfo  = "battery_info"
+	CmdHistory      = "history"
+	CmdStats        = "stats"
+	CmdAudit        = "audit"
+	CmdHello        = "hello"
+	CmdExplain      = "explain"
+	CmdLogs         = "logs"
+	CmdSetLogLevel  = "set_log_level"
+	CmdBatch        = "batch"
+	CmdReloadConfig = "reload_config"
+
+	CmdGetRapidCharge = "get_rapid_charge"
+	CmdSetRapidCharge = "set_rapid_charge"
+	CmdGetPowerMode   = "get_power_mode"
+	CmdSetPowerMode   = "set_power_mode"
+	CmdAdaptive       = "adaptive"
+	CmdPause          = "pause"
+	CmdResume         = "resume"
+
+	CmdSnapshotCreate   = "snapshot_create"
+	CmdSnapshotList     = "snapshot_list"
+	CmdSnapshotRollback = "snapshot_rollback"
 )
 
 // StatusData represents the data returned by status command
 type StatusData struct {
 	ConservationEnabled bool      `json:"conservation_enabled"`
 	Threshold           int       `json:"threshold"`
+	StartThreshold      int       `json:"start_threshold"` // Charging resumes below this level
 	CurrentMode         string    `json:"current_mode"`
 	BatteryLevel        int       `json:"battery_level"`
 	ConservationMode    bool      `json:"conservation_mode"`
@@ -44,6 +83,38 @@ type StatusData struct {
 	LastActionTime      time.Time `json:"last_action_time"`
 	DaemonUptime        string    `json:"daemon_uptime"`
 	HardwareSupported   bool      `json:"hardware_supported"`
+	Fresh               bool      `json:"fresh"`       // Battery fields were read live for this request
+	ReadingAge          string    `json:"reading_age"` // Age of the battery readings
+	UncleanShutdowns    int       `json:"unclean_shutdowns"`
+	LastUncleanShutdown time.Time `json:"last_unclean_shutdown"`
+	ChargeFull          bool      `json:"charge_full"` // Charging to 100% before management resumes
+	ChargeFullBy        time.Time `json:"charge_full_by"`
+	ChargeFullStart     time.Time `json:"charge_full_start"` // Estimated charging start for ChargeFullBy
+	ReenableAt          time.Time `json:"reenable_at"`       // Management resumes at this time after a temporary disable
+	Paused              bool      `json:"paused"`            // Automatic switching is paused, the hardware is left as it is
+	PausedUntil         time.Time `json:"paused_until"`      // Automation resumes at this time; zero until resumed
+	Schedule            string    `json:"schedule"`          // Schedule rule setting the threshold, if any
+	StorageMode         bool      `json:"storage_mode"`
+	StorageTarget       int       `json:"storage_target"`
+	ForceDischarging    bool      `json:"force_discharging"`              // Storage mode is discharging on AC
+	LowChargePower      bool      `json:"low_charge_power"`               // Charging far slower than usual, e.g. on a weak charger
+	ChargePower         float64   `json:"charge_power,omitempty"`         // W, the low charge power while LowChargePower
+	TypicalChargePower  float64   `json:"typical_charge_power,omitempty"` // W, learned from charging
+	RapidCharge         string    `json:"rapid_charge,omitempty"`         // "on", "paused" while conservation mode holds the charge, or empty if off
+	PowerMode           string    `json:"power_mode,omitempty"`           // Platform profile whose coupled charge profile sets the threshold, if any
+	Docking             string    `json:"docking,omitempty"`              // Docking rule condition whose charge profile sets the threshold, if any
+	SuggestedThreshold  int       `json:"suggested_threshold,omitempty"`  // Learned threshold differing from the configured one, in adaptive suggest mode
+
+	// Adapter is the connected power adapter, if identified
+	Adapter *AdapterData `json:"adapter,omitempty"`
+}
+
+// AdapterData describes the connected power adapter
+type AdapterData struct {
+	Name  string  `json:"name"`            // power_supply name
+	Type  string  `json:"type"`            // "barrel" or "usb-c"
+	PD    bool    `json:"pd,omitempty"`    // USB Power Delivery was negotiated
+	Watts float64 `json:"watts,omitempty"` // Negotiated or rated power, if exposed
 }
 
 // EnableData represents the data returned by enable command
@@ -55,14 +126,67 @@ type EnableData struct {
 
 // DisableData represents the data returned by disable command
 type DisableData struct {
-	Message     string `json:"message"`
-	CurrentMode string `json:"current_mode"`
+	Message     string    `json:"message"`
+	CurrentMode string    `json:"current_mode"`
+	ReenableAt  time.Time `json:"reenable_at"` // Zero unless disabled with a duration
+}
+
+// PauseData represents the data returned by the pause and resume commands
+type PauseData struct {
+	Message     string    `json:"message"`
+	Paused      bool      `json:"paused"`
+	PausedUntil time.Time `json:"paused_until"` // Zero unless paused with a duration
+}
+
+// SnapshotData describes a settings snapshot, as returned by snapshot_create
+type SnapshotData struct {
+	ID             int       `json:"id"`
+	Created        time.Time `json:"created"`
+	Note           string    `json:"note,omitempty"`
+	Enabled        bool      `json:"enabled"`
+	Threshold      int       `json:"threshold"`
+	StartThreshold int       `json:"start_threshold"` // 0 uses the hysteresis
+	SchedulePaused bool      `json:"schedule_paused"`
+	Profiles       []string  `json:"profiles"` // Profile names in the config file
+	Schedule       []string  `json:"schedule"` // Schedule rule names in the config file
+}
+
+// SnapshotListData represents the data returned by snapshot_list, oldest
+// snapshot first
+type SnapshotListData struct {
+	Snapshots []SnapshotData `json:"snapshots"`
+}
+
+// SnapshotRollbackData represents the data returned by snapshot_rollback
+type SnapshotRollbackData struct {
+	Message        string       `json:"message"`
+	Snapshot       SnapshotData `json:"snapshot"`
+	ConfigRestored bool         `json:"config_restored"`         // The config file differed and was replaced
+	ConfigBackup   string       `json:"config_backup,omitempty"` // Where the replaced config file was kept
+}
+
+// ChargeFullData represents the data returned by charge_full command
+type ChargeFullData struct {
+	Message      string    `json:"message"`
+	BatteryLevel int       `json:"battery_level"`
+	By           time.Time `json:"by"`       // Deadline when scheduled with "by"
+	StartAt      time.Time `json:"start_at"` // Estimated charging start for a deadline
 }
 
 // SetThresholdData represents the data returned by set_threshold command
 type SetThresholdData struct {
-	Message   string `json:"message"`
-	Threshold int    `json:"threshold"`
+	Message        string `json:"message"`
+	Threshold      int    `json:"threshold"`
+	StartThreshold int    `json:"start_threshold"` // Charging resumes below this level
+}
+
+// PlanData is returned instead of the usual data by enable, disable and
+// set_threshold when the "dry_run" param is set; nothing is changed
+type PlanData struct {
+	Changes           []string `json:"changes"`            // Settings that would change, e.g. "threshold: 80% → 85%"
+	Notes             []string `json:"notes,omitempty"`    // Caveats, e.g. an active schedule rule
+	ConservationMode  bool     `json:"conservation_mode"`  // Conservation mode now
+	ConservationAfter bool     `json:"conservation_after"` // Conservation mode right after the change
 }
 
 // DaemonStatusData represents the data returned by daemon_status command
@@ -71,10 +195,266 @@ type DaemonStatusData struct {
 	PID        int    `json:"pid"`
 	Uptime     string `json:"uptime"`
 	Version    string `json:"version"`
+	Commit     string `json:"commit,omitempty"`
 	SocketPath string `json:"socket_path"`
 	StateFile  string `json:"state_file"`
 }
 
+// LimitsData represents the data returned by get_limits and set_limits.
+// Nil fields are controls the hardware backend does not support.
+type LimitsData struct {
+	Backend          string  `json:"backend"`
+	ConservationMode *bool   `json:"conservation_mode,omitempty"`
+	StartThreshold   *int    `json:"start_threshold,omitempty"`
+	EndThreshold     *int    `json:"end_threshold,omitempty"`
+	RapidCharge      *bool   `json:"rapid_charge,omitempty"`
+	ChargeType       *string `json:"charge_type,omitempty"`
+	Message          string  `json:"message,omitempty"`
+}
+
+// RapidChargeData represents the data returned by get_rapid_charge and
+// set_rapid_charge
+type RapidChargeData struct {
+	Enabled bool   `json:"enabled"`
+	Pending bool   `json:"pending,omitempty"` // Wanted, but off until conservation mode releases the charge
+	Message string `json:"message,omitempty"`
+}
+
+// PowerModeData represents the data returned by get_power_mode and
+// set_power_mode
+type PowerModeData struct {
+	Mode      string   `json:"mode"`                // Active platform profile
+	Choices   []string `json:"choices"`             // Platform profiles offered by the hardware
+	Profile   string   `json:"profile,omitempty"`   // Charge profile coupled with the mode in power_modes
+	Threshold int      `json:"threshold,omitempty"` // Threshold of the coupled profile
+	Message   string   `json:"message,omitempty"`
+}
+
+// AdaptiveData represents the data returned by adaptive command
+type AdaptiveData struct {
+	Mode         string  `json:"mode"`                // "off", "suggest" or "apply"
+	Threshold    int     `json:"threshold"`           // Configured threshold
+	Suggested    int     `json:"suggested,omitempty"` // Learned threshold, 0 until enough history is recorded
+	MinThreshold int     `json:"min_threshold"`
+	MaxThreshold int     `json:"max_threshold"`
+	Days         int     `json:"days"`          // Days of history learned from
+	Discharges   int     `json:"discharges"`    // Stretches on battery power within them
+	PerWeek      float64 `json:"per_week"`      // Discharges per week
+	TypicalDepth int     `json:"typical_depth"` // Percent, 9 in 10 discharges go no deeper
+	Reason       string  `json:"reason"`        // Why the threshold was suggested, or none
+}
+
+// StorageData represents the data returned by storage command
+type StorageData struct {
+	Message        string `json:"message"`
+	Enabled        bool   `json:"enabled"`
+	Target         int    `json:"target"`
+	ForceDischarge bool   `json:"force_discharge"` // Hardware can discharge on AC to reach the target
+}
+
+// HealthData represents the data returned by health command
+type HealthData struct {
+	Full        int     `json:"full"`        // Current full charge capacity
+	FullDesign  int     `json:"full_design"` // Design capacity
+	Unit        string  `json:"unit"`        // "µWh" or "µAh"
+	CycleCount  int     `json:"cycle_count"` // 0 if unknown
+	Wear        float64 `json:"wear"`        // Capacity lost in percent
+	WearWarning int     `json:"wear_warning"`
+	Degraded    bool    `json:"degraded"` // Wear is at or above WearWarning
+
+	Trend *HealthTrend `json:"trend,omitempty"` // Only with the "trend" param
+}
+
+// HealthTrend describes how the battery capacity fades, from the daily
+// capacity readings in the history
+type HealthTrend struct {
+	Points       []HealthPoint `json:"points"`         // Weekly, oldest first
+	FadePerMonth float64       `json:"fade_per_month"` // Percentage points of design capacity lost per month; 0 until enough history
+	WarningAt    time.Time     `json:"warning_at"`     // Projected date the wear reaches WearWarning, zero if unknown
+}
+
+// HealthPoint is the full charge capacity at a time
+type HealthPoint struct {
+	Time   time.Time `json:"time"`
+	Health float64   `json:"health"` // Percent of the design capacity
+}
+
+// BatteryInfoData represents the data returned by battery_info command.
+// Hardware readings the battery doesn't report are left zero.
+type BatteryInfoData struct {
+	BatteryLevel        int    `json:"battery_level"`
+	Status              string `json:"status"`
+	ACConnected         bool   `json:"ac_connected"`
+	ConservationMode    bool   `json:"conservation_mode"`
+	ConservationEnabled bool   `json:"conservation_enabled"`
+	Threshold           int    `json:"threshold"`
+	StartThreshold      int    `json:"start_threshold"`
+
+	Voltage      float64 `json:"voltage,omitempty"`     // V
+	Current      float64 `json:"current,omitempty"`     // A
+	Power        float64 `json:"power,omitempty"`       // W, derived from voltage and current if not reported
+	Temperature  float64 `json:"temperature,omitempty"` // °C
+	Manufacturer string  `json:"manufacturer,omitempty"`
+	Model        string  `json:"model,omitempty"`
+	Serial       string  `json:"serial,omitempty"`
+	Technology   string  `json:"technology,omitempty"`
+}
+
+// HistoryData represents the data returned by history command
+type HistoryData struct {
+	Entries []HistoryEntry `json:"entries"` // Oldest first
+}
+
+// HistoryEntry is a battery snapshot recorded at a sample or conservation toggle
+type HistoryEntry struct {
+	Time             time.Time `json:"time"`
+	Event            string    `json:"event"` // "sample", "conservation_on", "conservation_off" or "capacity"
+	Level            int       `json:"level"`
+	ACConnected      bool      `json:"ac"`
+	ConservationMode bool      `json:"conservation"`
+	Threshold        int       `json:"threshold"`
+}
+
+// HelloData represents the data returned by hello command: the framing both
+// sides switch to after the response, which is the one the client asked for
+// or line framing if the daemon doesn't support it
+type HelloData struct {
+	Framing string `json:"framing"`
+}
+
+// AuditData represents the data returned by audit command
+type AuditData struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+// AuditEntry records a control command and the setting it changed
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	UID     int       `json:"uid"` // -1 if the caller couldn't be identified
+	PID     int       `json:"pid,omitempty"`
+	Command string    `json:"command"`
+	Old     string    `json:"old"`
+	New     string    `json:"new"`
+	Error   string    `json:"error,omitempty"` // Set if the command failed or was denied
+}
+
+// StatsData represents the data returned by stats command. Times are only
+// counted while the daemon was observing the battery.
+type StatsData struct {
+	Since               time.Time `json:"since"`
+	Until               time.Time `json:"until"`
+	Covered             string    `json:"covered"` // Observed time within the range
+	ConservationTime    string    `json:"conservation_time"`
+	ConservationPercent float64   `json:"conservation_percent"`
+	ACTime              string    `json:"ac_time"`
+	ACPercent           float64   `json:"ac_percent"`
+	BatteryTime         string    `json:"battery_time"`
+	BatteryPercent      float64   `json:"battery_percent"`
+	Toggles             int       `json:"toggles"`
+	AverageLevel        float64   `json:"average_level"`
+	MinLevel            int       `json:"min_level"`
+	MaxLevel            int       `json:"max_level"`
+	Entries             int       `json:"entries"` // History entries in the range, 0 if nothing was recorded
+}
+
+// ScheduleData represents the data returned by get_schedule and set_schedule
+type ScheduleData struct {
+	Paused     bool               `json:"paused"`
+	Active     string             `json:"active"`      // Name of the active rule, if any
+	NextChange time.Time          `json:"next_change"` // When the active rule next changes
+	Rules      []ScheduleRuleData `json:"rules"`
+}
+
+// ScheduleRuleData describes a single schedule rule
+type ScheduleRuleData struct {
+	Name           string `json:"name"`
+	Window         string `json:"window"` // e.g. "Mon,Tue,Wed,Thu,Fri 09:00-18:00"
+	Profile        string `json:"profile,omitempty"`
+	Threshold      int    `json:"threshold"`
+	StartThreshold int    `json:"start_threshold,omitempty"`
+	Active         bool   `json:"active"`
+	Night          bool   `json:"night,omitempty"` // Charges to full by the end of the window
+}
+
+// ExplainData explains the current conservation mode decision, following
+// the daemon's decision logic step by step
+type ExplainData struct {
+	ConservationMode  bool      `json:"conservation_mode"`
+	ExpectedMode      *bool     `json:"expected_mode,omitempty"` // Mode the next check switches to; nil keeps the current one
+	ManagementEnabled bool      `json:"management_enabled"`
+	ACOnline          bool      `json:"ac_online"`
+	BatteryLevel      int       `json:"battery_level"`
+	Threshold         int       `json:"threshold"`
+	StartThreshold    int       `json:"start_threshold"`
+	Hysteresis        int       `json:"hysteresis"`
+	Override          string    `json:"override,omitempty"` // Source of a threshold override, e.g. a schedule rule
+	Steps             []string  `json:"steps"`              // The decision, one check per step
+	LastCheck         time.Time `json:"last_check"`
+	LastSwitch        time.Time `json:"last_switch,omitempty"`
+}
+
+// MaxBatchSize is the most commands a batch request may carry
+const MaxBatchSize = 16
+
+// BatchItem is one command of a batch request, sent as the "requests" param
+type BatchItem struct {
+	Command string                 `json:"command"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// BatchData represents the data returned by batch command, one result per
+// item in order
+type BatchData struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult is the outcome of one command of a batch. Items after a failed
+// one are skipped and those before it rolled back.
+type BatchResult struct {
+	Command    string      `json:"command"`
+	Success    bool        `json:"success"`
+	Skipped    bool        `json:"skipped,omitempty"`
+	RolledBack bool        `json:"rolled_back,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Code       string      `json:"code,omitempty"`
+}
+
+// IsBatchable reports whether a command may be part of a batch; batches
+// don't nest and can't change the connection's framing. Commands changing
+// the config file or snapshots are left out, as a failed batch can't undo
+// them.
+func IsBatchable(cmd string) bool {
+	switch cmd {
+	case CmdBatch, CmdHello, CmdReloadConfig, CmdSnapshotCreate, CmdSnapshotRollback:
+		return false
+	}
+	return IsValidCommand(cmd)
+}
+
+// LogsData represents the data returned by logs command
+type LogsData struct {
+	Lines   []LogLine `json:"lines"`
+	Dropped uint64    `json:"dropped,omitempty"` // Lines after "since" no longer kept by the daemon
+}
+
+// LogLevelData represents the data returned by set_log_level command
+type LogLevelData struct {
+	Level string `json:"level"` // Level of every log sink, empty if they use their configured levels
+}
+
+// ReloadConfigData represents the data returned by reload_config command
+type ReloadConfigData struct {
+	Path string `json:"path"` // Config file the daemon read
+}
+
+// LogLine is a line of the daemon's log, numbered so clients can ask for the
+// lines after it
+type LogLine struct {
+	Seq  uint64 `json:"seq"`
+	Text string `json:"text"`
+}
+
 // IsValidCommand checks if a command string is valid
 func IsValidCommand(cmd string) bool {
 	validCommands := map[string]bool{
@@ -83,11 +463,52 @@ func IsValidCommand(cmd string) bool {
 		CmdStatus:       true,
 		CmdSetThreshold: true,
 		CmdDaemonStatus: true,
+		CmdGetLimits:    true,
+		CmdSetLimits:    true,
+		CmdChargeFull:   true,
+		CmdGetSchedule:  true,
+		CmdSetSchedule:  true,
+		CmdStorage:      true,
+		CmdHealth:       true,
+		CmdBatteryInfo:  true,
+		CmdHistory:      true,
+		CmdStats:        true,
+		CmdAudit:        true,
+		CmdHello:        true,
+		CmdExplain:      true,
+		CmdLogs:         true,
+		CmdSetLogLevel:  true,
+		CmdBatch:        true,
+		CmdReloadConfig: true,
+
+		CmdGetRapidCharge: true,
+		CmdSetRapidCharge: true,
+		CmdGetPowerMode:   true,
+		CmdSetPowerMode:   true,
+		CmdAdaptive:       true,
+		CmdPause:          true,
+		CmdResume:         true,
+
+		CmdSnapshotCreate:   true,
+		CmdSnapshotList:     true,
+		CmdSnapshotRollback: true,
 	}
 	return validCommands[cmd]
 }
 
-// ValidateThreshold validates a threshold value
+// IsMutatingCommand reports whether a command changes battery or daemon
+// settings, as opposed to only reading them
+func IsMutatingCommand(cmd string) bool {
+	switch cmd {
+	case CmdEnable, CmdDisable, CmdSetThreshold, CmdSetLimits, CmdChargeFull, CmdSetSchedule, CmdStorage, CmdSetLogLevel, CmdReloadConfig,
+		CmdSetRapidCharge, CmdSetPowerMode, CmdPause, CmdResume, CmdSnapshotCreate, CmdSnapshotRollback:
+		return true
+	}
+	return false
+}
+
+// ValidateThreshold validates a threshold value for conservation mode
+// hardware, which can't hold thresholds below 60%
 func ValidateThreshold(threshold int) error {
 	if threshold < 60 || threshold > 100 {
 		return ErrInvalidThreshold
@@ -95,24 +516,197 @@ func ValidateThreshold(threshold int) error {
 	return nil
 }
 
+// ThresholdRangeData is the range of thresholds the hardware can hold, sent
+// with errors for thresholds outside it
+type ThresholdRangeData struct {
+	Min  int `json:"min"`
+	Max  int `json:"max"`
+	Step int `json:"step,omitempty"` // Granularity; any whole percentage if 0 or 1
+}
+
+// String formats the range, e.g. "40-100%" or "40-100% in steps of 5"
+func (r ThresholdRangeData) String() string {
+	if r.Step > 1 {
+		return fmt.Sprintf("%d-%d%% in steps of %d", r.Min, r.Max, r.Step)
+	}
+	return fmt.Sprintf("%d-%d%%", r.Min, r.Max)
+}
+
+// Nearest returns the threshold in the range closest to threshold
+func (r ThresholdRangeData) Nearest(threshold int) int {
+	threshold = min(max(threshold, r.Min), r.Max)
+	if r.Step > 1 {
+		threshold = r.Min + (threshold-r.Min+r.Step/2)/r.Step*r.Step
+		if threshold > r.Max {
+			threshold -= r.Step
+		}
+	}
+	return threshold
+}
+
+// ValidateThresholdRange validates a threshold value against the range the
+// hardware can hold. The error carries the range, see AllowedRange.
+func ValidateThresholdRange(threshold int, allowed ThresholdRangeData) error {
+	if threshold < allowed.Min || threshold > allowed.Max {
+		return &Error{
+			Code:    CodeInvalidThreshold,
+			Message: fmt.Sprintf("threshold must be between %d and %d", allowed.Min, allowed.Max),
+			Range:   &allowed,
+		}
+	}
+	if allowed.Step > 1 && (threshold-allowed.Min)%allowed.Step != 0 {
+		return &Error{
+			Code:    CodeInvalidThreshold,
+			Message: fmt.Sprintf("threshold must be a multiple of %d from %d", allowed.Step, allowed.Min),
+			Range:   &allowed,
+		}
+	}
+	return nil
+}
+
+// ValidateStartThreshold validates a start threshold against the stop
+// threshold it belongs to; 0 means unset
+func ValidateStartThreshold(start, threshold int) error {
+	if start < 0 || start >= threshold {
+		return ErrInvalidStartThreshold
+	}
+	return nil
+}
+
+// ThresholdPreset is a named, recommended charge threshold
+type ThresholdPreset struct {
+	Name        string
+	Threshold   int
+	Description string
+}
+
+// ThresholdPresets are the presets set-threshold --preset accepts, from the
+// gentlest on the battery to full capacity
+var ThresholdPresets = []ThresholdPreset{
+	{"longevity", 60, "Least wear, for a laptop that stays plugged in"},
+	{"balanced", 80, "Most of the capacity with far less wear than a full charge"},
+	{"max", 100, "Full capacity, for days away from a charger"},
+}
+
+// FindThresholdPreset returns the preset with the given name
+func FindThresholdPreset(name string) (ThresholdPreset, bool) {
+	for _, preset := range ThresholdPresets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return ThresholdPreset{}, false
+}
+
+// Storage mode target bounds and default, in percent
+const (
+	DefaultStorageTarget = 50
+	MinStorageTarget     = 30
+	MaxStorageTarget     = 80
+)
+
+// ValidateStorageTarget validates a storage mode target level
+func ValidateStorageTarget(target int) error {
+	if target < MinStorageTarget || target > MaxStorageTarget {
+		return ErrInvalidStorageTarget
+	}
+	return nil
+}
+
+// Error codes carried in responses so clients can react without string matching
+const (
+	CodeInvalidThreshold     = "INVALID_THRESHOLD"
+	CodeDaemonNotRunning     = "DAEMON_NOT_RUNNING"
+	CodeDaemonNotResponding  = "DAEMON_NOT_RESPONDING"
+	CodeHardwareNotSupported = "HARDWARE_NOT_SUPPORTED"
+	CodePermissionDenied     = "PERMISSION_DENIED"
+	CodeInvalidCommand       = "INVALID_COMMAND"
+	CodeInvalidParams        = "INVALID_PARAMS"
+	CodeProtocolError        = "PROTOCOL_ERROR"
+	CodeInternalError        = "INTERNAL_ERROR"
+	CodeRateLimited          = "RATE_LIMITED"
+)
+
 // Common errors
 var (
-	ErrInvalidThreshold     = NewError("threshold must be between 60 and 100")
-	ErrDaemonNotRunning     = NewError("daemon not running")
-	ErrHardwareNotSupported = NewError("hardware not supported")
-	ErrPermissionDenied     = NewError("permission denied")
-	ErrInvalidCommand       = NewError("invalid command")
+	ErrInvalidThreshold      = NewCodedError(CodeInvalidThreshold, "threshold must be between 60 and 100")
+	ErrInvalidStartThreshold = NewCodedError(CodeInvalidThreshold, "start threshold must be below the charge threshold")
+	ErrInvalidStorageTarget  = NewCodedError(CodeInvalidThreshold, "storage target must be between 30 and 80")
+	ErrDaemonNotRunning      = NewCodedError(CodeDaemonNotRunning, "daemon not running")
+	ErrHardwareNotSupported  = NewCodedError(CodeHardwareNotSupported, "hardware not supported")
+	ErrPermissionDenied      = NewCodedError(CodePermissionDenied, "permission denied")
+	ErrInvalidCommand        = NewCodedError(CodeInvalidCommand, "invalid command")
+	ErrRateLimited           = NewCodedError(CodeRateLimited, "too many requests, slow down")
+	ErrTooManyConnections    = NewCodedError(CodeRateLimited, "too many connections")
+)
+
+// Common failures with the fix shown to the user
+var (
+	ErrSysfsPermission  = NewHintedError(CodePermissionDenied, "permission denied", "run as root")
+	ErrModuleNotLoaded  = NewHintedError(CodeHardwareNotSupported, "hardware not supported", "sudo modprobe ideapad_laptop")
+	ErrSocketPermission = NewHintedError(CodePermissionDenied, "permission denied connecting to the daemon socket",
+		"run with sudo, or add your user to the group owning the socket")
 )
 
 // Error represents a protocol error
 type Error struct {
+	Code    string
 	Message string
+	Range   *ThresholdRangeData // Allowed thresholds, for CodeInvalidThreshold
+	Hint    string              // How to fix the failure, e.g. "run as root"
 }
 
 func NewError(message string) *Error {
 	return &Error{Message: message}
 }
 
+// NewCodedError creates a protocol error with a machine-readable code
+func NewCodedError(code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// NewHintedError creates a coded protocol error telling the user how to fix it
+func NewHintedError(code, message, hint string) *Error {
+	return &Error{Code: code, Message: message, Hint: hint}
+}
+
 func (e *Error) Error() string {
 	return e.Message
 }
+
+// Is reports whether target is a protocol error with the same code, so
+// errors decoded from a response match the package-level error variables
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && e.Code != "" && e.Code == t.Code
+}
+
+// AllowedRange returns the threshold range carried by the first protocol
+// error in err's chain, or nil if it carries none
+func AllowedRange(err error) *ThresholdRangeData {
+	var protoErr *Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Range
+	}
+	return nil
+}
+
+// ErrorHint returns the fix suggested by the first protocol error in err's
+// chain, or an empty string if there is none
+func ErrorHint(err error) string {
+	var protoErr *Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Hint
+	}
+	return ""
+}
+
+// ErrorCode returns the code of the first protocol error in err's chain,
+// or an empty string if there is none
+func ErrorCode(err error) string {
+	var protoErr *Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code
+	}
+	return ""
+}
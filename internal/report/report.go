@@ -0,0 +1,204 @@
+// Package report renders a battery report from the daemon's history, in the
+// spirit of Windows' powercfg /batteryreport: charge sessions, time spent at
+// each level, conservation mode activity and the capacity trend.
+package report
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Formats lists the output formats of Render
+var Formats = []string{"html", "markdown"}
+
+// sessionGap is the longest stretch between two history entries still
+// counted as observed; longer gaps mean the daemon wasn't running or the
+// system was suspended
+const sessionGap = 15 * time.Minute
+
+// Report holds everything shown in a battery report
+type Report struct {
+	Generated time.Time
+	Since     time.Time
+	Until     time.Time
+	Stats     *protocol.StatsData
+	Health    *protocol.HealthData // Nil if the backend can't read battery health
+	Sessions  []Session
+	Levels    []LevelBand
+}
+
+// Session is a stretch on AC power
+type Session struct {
+	Start        time.Time
+	End          time.Time
+	StartLevel   int
+	EndLevel     int
+	Conservation time.Duration // Time conservation mode held the charge
+}
+
+// Duration returns how long the session lasted
+func (s Session) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// LevelBand is the time spent within a range of battery levels
+type LevelBand struct {
+	From, To int // Percent, inclusive
+	Time     time.Duration
+	Percent  float64 // Share of the observed time
+}
+
+// Build assembles a report from the history entries (oldest first) and
+// stats of [since, until], and the battery health with its trend, if known
+func Build(entries []protocol.HistoryEntry, stats *protocol.StatsData, health *protocol.HealthData, since, until time.Time) *Report {
+	return &Report{
+		Generated: time.Now(),
+		Since:     since,
+		Until:     until,
+		Stats:     stats,
+		Health:    health,
+		Sessions:  sessions(entries, until),
+		Levels:    levelBands(entries, until),
+	}
+}
+
+// span returns how long entry i describes the battery: until the next entry,
+// or until until for the last one. Gaps longer than sessionGap count as 0.
+func span(entries []protocol.HistoryEntry, i int, until time.Time) time.Duration {
+	end := until
+	if i+1 < len(entries) {
+		end = entries[i+1].Time
+	}
+	d := end.Sub(entries[i].Time)
+	if d <= 0 || d > sessionGap {
+		return 0
+	}
+	return d
+}
+
+// sessions finds the stretches on AC power. A gap in the history ends a
+// session, as the laptop may have been unplugged meanwhile.
+func sessions(entries []protocol.HistoryEntry, until time.Time) []Session {
+	var result []Session
+	var current *Session
+	end := func() {
+		if current != nil {
+			result = append(result, *current)
+			current = nil
+		}
+	}
+
+	for i, entry := range entries {
+		if !entry.ACConnected {
+			end()
+			continue
+		}
+		if current == nil {
+			current = &Session{Start: entry.Time, StartLevel: entry.Level}
+		}
+		current.End = entry.Time
+		current.EndLevel = entry.Level
+
+		d := span(entries, i, until)
+		current.End = current.End.Add(d)
+		if entry.ConservationMode {
+			current.Conservation += d
+		}
+		if d == 0 {
+			end()
+		}
+	}
+	end()
+
+	return result
+}
+
+// levelBands sums the time spent in each tenth of the battery range
+func levelBands(entries []protocol.HistoryEntry, until time.Time) []LevelBand {
+	bands := make([]LevelBand, 10)
+	for i := range bands {
+		bands[i].From = i * 10
+		bands[i].To = i*10 + 9
+	}
+	bands[9].To = 100
+
+	var total time.Duration
+	for i, entry := range entries {
+		d := span(entries, i, until)
+		band := min(max(entry.Level, 0)/10, 9)
+		bands[band].Time += d
+		total += d
+	}
+
+	if total > 0 {
+		for i := range bands {
+			bands[i].Percent = float64(bands[i].Time) / float64(total) * 100
+		}
+	}
+	return bands
+}
+
+// Render writes the report in the given format, "html" or "markdown"
+func (r *Report) Render(w io.Writer, format string) error {
+	switch format {
+	case "html":
+		tmpl, err := htmltemplate.New("report.html.tmpl").Funcs(htmltemplate.FuncMap(funcs)).
+			ParseFS(templates, "templates/report.html.tmpl")
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(w, r)
+	case "markdown":
+		tmpl, err := template.New("report.md.tmpl").Funcs(funcs).ParseFS(templates, "templates/report.md.tmpl")
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(w, r)
+	}
+	return fmt.Errorf("unsupported report format %q (expected html or markdown)", format)
+}
+
+// FormatFor returns the format matching the extension of path, html for
+// anything but .md
+func FormatFor(path string) string {
+	if strings.HasSuffix(path, ".md") || strings.HasSuffix(path, ".markdown") {
+		return "markdown"
+	}
+	return "html"
+}
+
+// funcs are the helpers available to the templates
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string {
+		return t.Local().Format("2006-01-02 15:04")
+	},
+	"day": func(t time.Time) string {
+		return t.Local().Format("2006-01-02")
+	},
+	"duration": formatDuration,
+	"bar": func(percent float64) string {
+		return strings.Repeat("█", int(percent/100*40+0.5))
+	},
+	"percent": func(value float64) string {
+		return fmt.Sprintf("%.1f%%", value)
+	},
+}
+
+// formatDuration formats a duration in hours and minutes, e.g. "3h05m"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
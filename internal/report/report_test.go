@@ -0,0 +1,126 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+)
+
+func entry(t time.Time, level int, ac, conservation bool) protocol.HistoryEntry {
+	return protocol.HistoryEntry{Time: t, Event: "sample", Level: level, ACConnected: ac, ConservationMode: conservation, Threshold: 80}
+}
+
+func testEntries(base time.Time) []protocol.HistoryEntry {
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	return []protocol.HistoryEntry{
+		// On battery for 20 minutes
+		entry(at(0), 55, false, false),
+		entry(at(10), 50, false, false),
+		// Charging to the threshold, then held for 10 minutes
+		entry(at(20), 50, true, false),
+		entry(at(30), 65, true, false),
+		entry(at(40), 80, true, true),
+		// Unplugged, then suspended for an hour
+		entry(at(50), 79, false, true),
+		entry(at(55), 78, false, false),
+		// Plugged in after resume
+		entry(at(120), 70, true, false),
+	}
+}
+
+func TestSessions(t *testing.T) {
+	base := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	until := base.Add(130 * time.Minute)
+
+	got := sessions(testEntries(base), until)
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 sessions, got %+v", got)
+	}
+
+	first := got[0]
+	if first.Duration() != 30*time.Minute || first.StartLevel != 50 || first.EndLevel != 80 ||
+		first.Conservation != 10*time.Minute {
+		t.Errorf("Unexpected first session: %+v", first)
+	}
+	if second := got[1]; second.Duration() != 10*time.Minute || second.StartLevel != 70 {
+		t.Errorf("Unexpected second session: %+v", second)
+	}
+}
+
+func TestLevelBands(t *testing.T) {
+	base := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	bands := levelBands(testEntries(base), base.Add(130*time.Minute))
+	if len(bands) != 10 || bands[9].To != 100 {
+		t.Fatalf("Expected 10 bands up to 100%%, got %+v", bands)
+	}
+
+	// The suspended hour is left out: 65 minutes observed
+	want := map[int]time.Duration{5: 30 * time.Minute, 6: 10 * time.Minute, 7: 15 * time.Minute, 8: 10 * time.Minute}
+	for _, band := range bands {
+		if band.Time != want[band.From/10] {
+			t.Errorf("Band %d-%d%%: %v, want %v", band.From, band.To, band.Time, want[band.From/10])
+		}
+	}
+	if percent := bands[6].Percent + bands[8].Percent; percent < 30.76 || percent > 30.77 {
+		t.Errorf("Expected 20 of 65 minutes at 60-69%% and 80-89%%, got %v%%", percent)
+	}
+}
+
+func TestRender(t *testing.T) {
+	base := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	until := base.Add(130 * time.Minute)
+	stats := &protocol.StatsData{Entries: 8, Covered: "1h15m0s", Toggles: 1, AverageLevel: 64}
+	health := &protocol.HealthData{
+		Wear:        12.5,
+		WearWarning: 20,
+		Trend: &protocol.HealthTrend{
+			Points:       []protocol.HealthPoint{{Time: base.AddDate(0, 0, -7), Health: 87.6}, {Time: base, Health: 87.5}},
+			FadePerMonth: 0.4,
+			WarningAt:    base.AddDate(1, 6, 0),
+		},
+	}
+	r := Build(testEntries(base), stats, health, base, until)
+
+	for _, format := range Formats {
+		var out bytes.Buffer
+		if err := r.Render(&out, format); err != nil {
+			t.Fatalf("Render(%s) failed: %v", format, err)
+		}
+		for _, want := range []string{"Battery Report", "1h15m0s", "50% → 80%", "12.5%", "0.40%", "87.5%", "2028-04-01"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("Render(%s) output lacks %q:\n%s", format, want, out.String())
+			}
+		}
+	}
+
+	// Without health data the section says so
+	var out bytes.Buffer
+	if err := Build(nil, &protocol.StatsData{}, nil, base, until).Render(&out, "markdown"); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "No history recorded") || !strings.Contains(out.String(), "doesn't report its capacity") {
+		t.Errorf("Unexpected empty report:\n%s", out.String())
+	}
+
+	if err := r.Render(&out, "pdf"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestFormatFor(t *testing.T) {
+	tests := map[string]string{
+		"battery-report.html": "html",
+		"report.md":           "markdown",
+		"report.markdown":     "markdown",
+		"-":                   "html",
+	}
+	for path, want := range tests {
+		if got := FormatFor(path); got != want {
+			t.Errorf("FormatFor(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package rules evaluates declarative rules: conditions on the battery,
+// power source, time, lid and network that trigger an action once when they
+// start to hold.
+package rules
+
+import (
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/schedule"
+)
+
+// Actions a rule can take
+const (
+	SetThreshold = "set_threshold" // Set the charge threshold, as set-threshold does
+	Enable       = "enable"        // Enable battery management
+	Disable      = "disable"       // Disable battery management
+	Notify       = "notify"        // Dispatch a rule-triggered event
+)
+
+// Actions lists the valid rule actions
+var Actions = []string{SetThreshold, Enable, Disable, Notify}
+
+// Power sources a rule can require
+const (
+	OnBattery = "battery" // AC unplugged
+	OnAC      = "ac"      // Any power adapter
+	Barrel    = "barrel"  // The barrel charger
+	USBC      = "usb-c"   // A USB-C charger
+)
+
+// PowerSources lists the valid power source conditions
+var PowerSources = []string{OnBattery, OnAC, Barrel, USBC}
+
+// Lid states a rule can require
+const (
+	LidOpen   = "open"
+	LidClosed = "closed"
+)
+
+// Facts are what rules are evaluated against at one check
+type Facts struct {
+	Time         time.Time
+	BatteryLevel int
+	OnAC         bool
+	Adapter      string // "barrel" or "usb-c"; empty on battery or if unknown
+	Lid          string // "open" or "closed"; empty if unknown
+	SSID         string // Connected Wi-Fi network; empty if none or unknown
+}
+
+// Rule is a set of conditions, all of which must hold, and the action taken
+// when they start to. Unset conditions hold always.
+type Rule struct {
+	Name string
+
+	BatteryBelow int              // Battery level below this percentage
+	BatteryAbove int              // Battery level above this percentage
+	Power        string           // One of PowerSources
+	Window       *schedule.Window // Recurring time range
+	Lid          string           // LidOpen or LidClosed
+	SSID         string           // Connected Wi-Fi network
+
+	Action         string
+	Threshold      int    // For SetThreshold
+	StartThreshold int    // For SetThreshold; 0 uses the hysteresis
+	Message        string // For Notify
+}
+
+// HasConditions reports whether the rule sets any condition
+func (r Rule) HasConditions() bool {
+	return r.BatteryBelow > 0 || r.BatteryAbove > 0 || r.Power != "" || r.Window != nil || r.Lid != "" || r.SSID != ""
+}
+
+// Matches reports whether every condition of the rule holds for facts. A
+// lid or network condition doesn't hold while it is unknown.
+func (r Rule) Matches(facts Facts) bool {
+	switch {
+	case r.BatteryBelow > 0 && facts.BatteryLevel >= r.BatteryBelow:
+		return false
+	case r.BatteryAbove > 0 && facts.BatteryLevel <= r.BatteryAbove:
+		return false
+	case r.Window != nil && !r.Window.Contains(facts.Time):
+		return false
+	case r.Lid != "" && facts.Lid != r.Lid:
+		return false
+	case r.SSID != "" && facts.SSID != r.SSID:
+		return false
+	}
+
+	switch r.Power {
+	case OnBattery:
+		return !facts.OnAC
+	case OnAC:
+		return facts.OnAC
+	case Barrel, USBC:
+		return facts.OnAC && facts.Adapter == r.Power
+	}
+	return true
+}
+
+// Engine remembers which rules held at the last evaluation, so each rule
+// triggers once when its conditions start to hold rather than at every
+// check. It isn't safe for concurrent use.
+type Engine struct {
+	matching map[string]bool
+}
+
+// NewEngine creates an engine for which no rule held yet, so rules that hold
+// at the first evaluation trigger
+func NewEngine() *Engine {
+	return &Engine{matching: make(map[string]bool)}
+}
+
+// Evaluate checks the rules against facts and returns those that started to
+// hold, in order. Rules are told apart by name.
+func (e *Engine) Evaluate(rules []Rule, facts Facts) []Rule {
+	var triggered []Rule
+	matching := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if !rule.Matches(facts) {
+			continue
+		}
+		matching[rule.Name] = true
+		if !e.matching[rule.Name] {
+			triggered = append(triggered, rule)
+		}
+	}
+	e.matching = matching
+	return triggered
+}
+
+// NeedsLid reports whether any of the rules has a lid condition
+func NeedsLid(rules []Rule) bool {
+	for _, rule := range rules {
+		if rule.Lid != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsSSID reports whether any of the rules has a network condition
+func NeedsSSID(rules []Rule) bool {
+	for _, rule := range rules {
+		if rule.SSID != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/schedule"
+)
+
+func TestRuleMatches(t *testing.T) {
+	window, err := schedule.ParseWindow([]string{"mon-fri"}, "09:00", "18:00")
+	if err != nil {
+		t.Fatalf("ParseWindow failed: %v", err)
+	}
+	monday := time.Date(2026, 10, 12, 10, 0, 0, 0, time.Local)
+	desk := Facts{Time: monday, BatteryLevel: 70, OnAC: true, Adapter: USBC, Lid: LidClosed, SSID: "Office"}
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		facts    Facts
+		expected bool
+	}{
+		{"every condition", Rule{BatteryAbove: 50, Power: USBC, Window: &window, Lid: LidClosed, SSID: "Office"}, desk, true},
+		{"battery below", Rule{BatteryBelow: 70}, desk, false},
+		{"battery above", Rule{BatteryAbove: 70}, desk, false},
+		{"other adapter", Rule{Power: Barrel}, desk, false},
+		{"any adapter", Rule{Power: OnAC}, desk, true},
+		{"on battery", Rule{Power: OnBattery}, Facts{BatteryLevel: 40}, true},
+		{"outside the window", Rule{Window: &window}, Facts{Time: monday.Add(9 * time.Hour)}, false},
+		{"unknown lid", Rule{Lid: LidOpen}, Facts{}, false},
+		{"other network", Rule{SSID: "Home"}, desk, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(tt.facts); got != tt.expected {
+				t.Errorf("Matches() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEngineEvaluate(t *testing.T) {
+	rules := []Rule{
+		{Name: "low", BatteryBelow: 30, Power: OnBattery, Action: Notify},
+		{Name: "docked", Power: OnAC, Action: SetThreshold, Threshold: 60},
+	}
+	engine := NewEngine()
+
+	steps := []struct {
+		facts    Facts
+		expected []string
+	}{
+		{Facts{BatteryLevel: 80, OnAC: true}, []string{"docked"}},
+		{Facts{BatteryLevel: 80, OnAC: true}, nil}, // Still holds, doesn't trigger again
+		{Facts{BatteryLevel: 25}, []string{"low"}},
+		{Facts{BatteryLevel: 20}, nil},
+		{Facts{BatteryLevel: 20, OnAC: true}, []string{"docked"}},
+	}
+
+	for i, step := range steps {
+		var names []string
+		for _, rule := range engine.Evaluate(rules, step.facts) {
+			names = append(names, rule.Name)
+		}
+		if len(names) != len(step.expected) || (len(names) > 0 && names[0] != step.expected[0]) {
+			t.Errorf("Step %d: expected %v triggered, got %v", i, step.expected, names)
+		}
+	}
+}
+
+func TestActiveSSID(t *testing.T) {
+	output := "no:Neighbour\nyes:Cafe\\: Guest\nno:Other\n"
+	if ssid := activeSSID(output); ssid != "Cafe: Guest" {
+		t.Errorf("Expected Cafe: Guest, got %q", ssid)
+	}
+	if ssid := activeSSID("no:Neighbour\n"); ssid != "" {
+		t.Errorf("Expected no network, got %q", ssid)
+	}
+}
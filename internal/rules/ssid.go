@@ -0,0 +1,32 @@
+package rules
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ReadSSID returns the Wi-Fi network the laptop is connected to, or empty if
+// it isn't. It asks iwgetid and falls back to NetworkManager's nmcli.
+func ReadSSID() (string, error) {
+	if output, err := exec.Command("iwgetid", "--raw").Output(); err == nil {
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	output, err := exec.Command("nmcli", "--terse", "--fields", "active,ssid", "device", "wifi").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the Wi-Fi network: %w", err)
+	}
+	return activeSSID(string(output)), nil
+}
+
+// activeSSID picks the active network from nmcli's terse output, whose
+// lines read e.g. "yes:HomeWifi"; colons in the name are escaped as "\:"
+func activeSSID(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if ssid, ok := strings.CutPrefix(line, "yes:"); ok {
+			return strings.ReplaceAll(ssid, `\:`, ":")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,210 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// minutesPerDay is the number of minutes in a day
+const minutesPerDay = 24 * 60
+
+// dayNames maps day abbreviations to time.Weekday
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Window is a recurring weekly time window, e.g. weekdays 09:00-18:00.
+// Windows whose end is before their start run overnight into the next day.
+type Window struct {
+	days [7]bool
+	from int // Minutes after midnight
+	to   int // Minutes after midnight
+}
+
+// ParseWindow parses a window from day specs ("mon", "mon-fri", "weekdays",
+// "weekends", "daily") and "HH:MM" start and end times. No days means daily.
+func ParseWindow(days []string, from, to string) (Window, error) {
+	var w Window
+	var err error
+
+	if w.from, err = parseClock(from); err != nil {
+		return w, fmt.Errorf("invalid start time: %w", err)
+	}
+	if w.to, err = parseClock(to); err != nil {
+		return w, fmt.Errorf("invalid end time: %w", err)
+	}
+	if w.from == w.to {
+		return w, fmt.Errorf("start and end time must differ")
+	}
+
+	if len(days) == 0 {
+		days = []string{"daily"}
+	}
+	for _, spec := range days {
+		if err := w.addDays(spec); err != nil {
+			return w, err
+		}
+	}
+
+	return w, nil
+}
+
+// addDays marks the days described by spec as active
+func (w *Window) addDays(spec string) error {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+
+	switch spec {
+	case "daily":
+		for i := range w.days {
+			w.days[i] = true
+		}
+		return nil
+	case "weekdays":
+		spec = "mon-fri"
+	case "weekends":
+		spec = "sat-sun"
+	}
+
+	first, last, isRange := strings.Cut(spec, "-")
+	start, ok := dayNames[first]
+	if !ok {
+		return fmt.Errorf("invalid day %q", first)
+	}
+	end := start
+	if isRange {
+		if end, ok = dayNames[last]; !ok {
+			return fmt.Errorf("invalid day %q", last)
+		}
+	}
+
+	// Ranges may wrap around the week, e.g. "fri-mon"
+	for day := start; ; day = (day + 1) % 7 {
+		w.days[day] = true
+		if day == end {
+			break
+		}
+	}
+	return nil
+}
+
+// Contains reports whether t falls inside the window
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+
+	if w.from < w.to {
+		return w.days[t.Weekday()] && minute >= w.from && minute < w.to
+	}
+
+	// Overnight window: the evening part belongs to today, the morning part
+	// to a window that started yesterday
+	if minute >= w.from {
+		return w.days[t.Weekday()]
+	}
+	return minute < w.to && w.days[(t.Weekday()+6)%7]
+}
+
+// End returns when the occurrence of the window containing t ends
+func (w Window) End(t time.Time) time.Time {
+	end := time.Date(t.Year(), t.Month(), t.Day(), w.to/60, w.to%60, 0, 0, t.Location())
+	if !end.After(t) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// String returns a human-readable description of the window
+func (w Window) String() string {
+	var days []string
+	for _, day := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday,
+		time.Thursday, time.Friday, time.Saturday, time.Sunday} {
+		if w.days[day] {
+			days = append(days, day.String()[:3])
+		}
+	}
+
+	dayList := strings.Join(days, ",")
+	if len(days) == 7 {
+		dayList = "daily"
+	}
+	return fmt.Sprintf("%s %s-%s", dayList, formatClock(w.from), formatClock(w.to))
+}
+
+// Rule applies charge settings while its window is active
+type Rule struct {
+	Name           string
+	Window         Window
+	Profile        string // Profile the settings came from, if any
+	Threshold      int
+	StartThreshold int // 0 falls back to the configured hysteresis
+
+	// Night rules charge to full by the end of their window; a zero
+	// Threshold holds the configured one until then
+	Night bool
+}
+
+// Scheduler picks the active rule for a point in time. When windows
+// overlap, the rule listed first wins.
+type Scheduler struct {
+	rules []Rule
+}
+
+// New creates a scheduler for the given rules
+func New(rules []Rule) *Scheduler {
+	return &Scheduler{rules: rules}
+}
+
+// Rules returns the scheduled rules in priority order
+func (s *Scheduler) Rules() []Rule {
+	return s.rules
+}
+
+// Active returns the rule active at t, or nil if none applies
+func (s *Scheduler) Active(t time.Time) *Rule {
+	for i := range s.rules {
+		if s.rules[i].Window.Contains(t) {
+			return &s.rules[i]
+		}
+	}
+	return nil
+}
+
+// NextChange returns when the active rule next changes after t, or the zero
+// time if it never does
+func (s *Scheduler) NextChange(t time.Time) time.Time {
+	if len(s.rules) == 0 {
+		return time.Time{}
+	}
+
+	current := s.Active(t)
+	next := t.Truncate(time.Minute)
+
+	// Windows repeat weekly, so a change must happen within a week and a day
+	for i := 0; i < 8*minutesPerDay; i++ {
+		next = next.Add(time.Minute)
+		if s.Active(next) != current {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// formatClock formats minutes after midnight as "HH:MM"
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
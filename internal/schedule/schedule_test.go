@@ -0,0 +1,126 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns a local time in the week of Monday 2024-01-01
+func at(weekday time.Weekday, hour, minute int) time.Time {
+	return time.Date(2024, 1, 1+int(weekday+6)%7, hour, minute, 0, 0, time.Local)
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    []string
+		from    string
+		to      string
+		want    string
+		wantErr bool
+	}{
+		{"weekdays", []string{"mon-fri"}, "09:00", "18:00", "Mon,Tue,Wed,Thu,Fri 09:00-18:00", false},
+		{"alias", []string{"weekends"}, "10:00", "12:30", "Sat,Sun 10:00-12:30", false},
+		{"daily default", nil, "22:00", "06:00", "daily 22:00-06:00", false},
+		{"wrapping range", []string{"fri-mon"}, "08:00", "09:00", "Mon,Fri,Sat,Sun 08:00-09:00", false},
+		{"invalid day", []string{"funday"}, "09:00", "18:00", "", true},
+		{"invalid time", []string{"mon"}, "9am", "18:00", "", true},
+		{"empty window", []string{"mon"}, "09:00", "09:00", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := ParseWindow(tt.days, tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && window.String() != tt.want {
+				t.Errorf("ParseWindow() = %q, want %q", window.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	work, _ := ParseWindow([]string{"weekdays"}, "09:00", "18:00")
+	night, _ := ParseWindow([]string{"fri"}, "22:00", "06:00")
+
+	tests := []struct {
+		name   string
+		window Window
+		time   time.Time
+		want   bool
+	}{
+		{"work start", work, at(time.Monday, 9, 0), true},
+		{"work end is exclusive", work, at(time.Monday, 18, 0), false},
+		{"work weekend", work, at(time.Saturday, 12, 0), false},
+		{"night evening", night, at(time.Friday, 23, 0), true},
+		{"night morning after", night, at(time.Saturday, 5, 59), true},
+		{"night morning before", night, at(time.Friday, 5, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.time); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.time, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowEnd(t *testing.T) {
+	work, _ := ParseWindow([]string{"weekdays"}, "09:00", "18:00")
+	night, _ := ParseWindow([]string{"fri"}, "22:00", "07:00")
+
+	tests := []struct {
+		name   string
+		window Window
+		time   time.Time
+		want   time.Time
+	}{
+		{"same day", work, at(time.Monday, 10, 0), at(time.Monday, 18, 0)},
+		{"night evening", night, at(time.Friday, 23, 0), at(time.Saturday, 7, 0)},
+		{"night morning", night, at(time.Saturday, 5, 0), at(time.Saturday, 7, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.End(tt.time); !got.Equal(tt.want) {
+				t.Errorf("End(%v) = %v, want %v", tt.time, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerActive(t *testing.T) {
+	lunch, _ := ParseWindow([]string{"mon-fri"}, "12:00", "13:00")
+	work, _ := ParseWindow([]string{"mon-fri"}, "09:00", "18:00")
+	scheduler := New([]Rule{
+		{Name: "lunch", Window: lunch, Threshold: 90},
+		{Name: "work", Window: work, Threshold: 60},
+	})
+
+	if rule := scheduler.Active(at(time.Monday, 12, 30)); rule == nil || rule.Name != "lunch" {
+		t.Errorf("Expected first matching rule to win, got %v", rule)
+	}
+	if rule := scheduler.Active(at(time.Monday, 10, 0)); rule == nil || rule.Name != "work" {
+		t.Errorf("Expected work rule, got %v", rule)
+	}
+	if rule := scheduler.Active(at(time.Sunday, 10, 0)); rule != nil {
+		t.Errorf("Expected no rule on Sunday, got %v", rule.Name)
+	}
+
+	next := scheduler.NextChange(at(time.Monday, 10, 15))
+	if !next.Equal(at(time.Monday, 12, 0)) {
+		t.Errorf("Expected next change at 12:00, got %v", next)
+	}
+
+	next = scheduler.NextChange(at(time.Friday, 18, 30))
+	if !next.Equal(at(time.Monday, 9, 0).AddDate(0, 0, 7)) {
+		t.Errorf("Expected next change on the following Monday, got %v", next)
+	}
+
+	if !New(nil).NextChange(at(time.Monday, 9, 0)).IsZero() {
+		t.Error("Expected no change without rules")
+	}
+}
@@ -0,0 +1,49 @@
+package simulate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FormatReport formats a report with one line per step, followed by its
+// unmet expectations
+func FormatReport(report Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Scenario: %s\n", report.Scenario)
+
+	passed := 0
+	for _, step := range report.Steps {
+		mark := "✓"
+		if len(step.Failures) > 0 {
+			mark = "✗"
+		} else {
+			passed++
+		}
+
+		power := "on battery"
+		if step.Battery.Charging {
+			power = "on AC"
+		}
+		switches := "switches"
+		if step.Switches == 1 {
+			switches = "switch"
+		}
+		fmt.Fprintf(&b, "  %s %s  %s: level %d%%, %s, conservation %s (%d %s)\n",
+			mark, formatElapsed(step.Elapsed), step.Name, step.Battery.BatteryLevel, power,
+			onOff(step.Battery.ConservationMode), step.Switches, switches)
+
+		for _, failure := range step.Failures {
+			fmt.Fprintf(&b, "      %s\n", failure)
+		}
+	}
+
+	fmt.Fprintf(&b, "%d of %d steps passed\n", passed, len(report.Steps))
+	return b.String()
+}
+
+// formatElapsed formats simulated time as hours and minutes, e.g. "+1:30"
+func formatElapsed(elapsed time.Duration) string {
+	minutes := int(elapsed.Round(time.Minute).Minutes())
+	return fmt.Sprintf("+%d:%02d", minutes/60, minutes%60)
+}
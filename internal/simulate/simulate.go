@@ -0,0 +1,279 @@
+// Package simulate plays scripted battery and AC transitions back against
+// the mock hardware backend and checks the conservation mode decisions made
+// for them, to validate policy changes before they touch real hardware.
+//
+// Each check runs the same logic as auto mode, i.e. one check of the daemon:
+// the battery is read, the reading recorded and conservation mode switched
+// when the settings call for it.
+package simulate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/dom1nux/legionbatctl/internal/config"
+	"github.com/dom1nux/legionbatctl/internal/direct"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+	"github.com/dom1nux/legionbatctl/internal/protocol"
+	"github.com/dom1nux/legionbatctl/internal/state"
+)
+
+// DefaultInterval is the simulated time between checks unless the scenario
+// sets one
+const DefaultInterval = time.Minute
+
+// ErrInvalidScenario is returned for a scenario that can't be played
+var ErrInvalidScenario = errors.New("invalid scenario")
+
+// Scenario is a scripted sequence of battery and AC transitions with the
+// expected decisions
+type Scenario struct {
+	Name     string        `toml:"name"`
+	Interval time.Duration `toml:"interval"` // Simulated time between checks
+	Settings Settings      `toml:"settings"`
+	Battery  Battery       `toml:"battery"`
+	Steps    []Step        `toml:"step"`
+}
+
+// Settings are the battery management settings at the start
+type Settings struct {
+	Threshold      int   `toml:"threshold"`
+	StartThreshold int   `toml:"start_threshold"` // 0 falls back to the hysteresis
+	Hysteresis     int   `toml:"hysteresis"`
+	Managed        *bool `toml:"managed"` // Battery management enabled; default true
+}
+
+// Battery is the simulated battery at the start
+type Battery struct {
+	Level            int   `toml:"level"`
+	AC               *bool `toml:"ac"` // Default true
+	ConservationMode bool  `toml:"conservation_mode"`
+}
+
+// Step changes the battery or settings, lets simulated time pass with a
+// check every interval, and then checks the expectations
+type Step struct {
+	Name      string        `toml:"name"`
+	Level     *int          `toml:"level"`
+	AC        *bool         `toml:"ac"`
+	Threshold *int          `toml:"threshold"`
+	Managed   *bool         `toml:"managed"`
+	After     time.Duration `toml:"after"` // 0 runs a single check
+	Expect    Expect        `toml:"expect"`
+}
+
+// Expect lists what must hold after a step; unset fields aren't checked
+type Expect struct {
+	ConservationMode *bool `toml:"conservation_mode"`
+	LevelMin         *int  `toml:"level_min"`
+	LevelMax         *int  `toml:"level_max"`
+	Switches         *int  `toml:"switches"` // Conservation mode switches during the step
+}
+
+// StepResult is the outcome of a step
+type StepResult struct {
+	Name     string
+	Elapsed  time.Duration // Simulated time since the start
+	Battery  direct.Result // After the last check of the step
+	Switches int
+	Failures []string // Unmet expectations
+}
+
+// Report is the outcome of a scenario
+type Report struct {
+	Scenario string
+	Steps    []StepResult
+}
+
+// Passed reports whether every expectation was met
+func (r Report) Passed() bool {
+	for _, step := range r.Steps {
+		if len(step.Failures) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Load reads a scenario file
+func Load(path string) (*Scenario, error) {
+	var scenario Scenario
+	if _, err := toml.DecodeFile(path, &scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+	if scenario.Name == "" {
+		scenario.Name = filepath.Base(path)
+	}
+	if err := scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &scenario, nil
+}
+
+// Validate checks that the scenario can be played
+func (s *Scenario) Validate() error {
+	if err := protocol.ValidateThreshold(s.Settings.Threshold); err != nil {
+		return fmt.Errorf("%w: settings.threshold: %v", ErrInvalidScenario, err)
+	}
+	if err := protocol.ValidateStartThreshold(s.Settings.StartThreshold, s.Settings.Threshold); err != nil {
+		return fmt.Errorf("%w: settings.start_threshold: %v", ErrInvalidScenario, err)
+	}
+	if s.Settings.Hysteresis < 0 || s.Settings.Hysteresis > config.MaxHysteresis {
+		return fmt.Errorf("%w: settings.hysteresis: %v", ErrInvalidScenario, config.ErrInvalidHysteresis)
+	}
+	if s.Interval < 0 {
+		return fmt.Errorf("%w: interval must not be negative", ErrInvalidScenario)
+	}
+	if s.Battery.Level < 0 || s.Battery.Level > 100 {
+		return fmt.Errorf("%w: battery.level must be between 0 and 100", ErrInvalidScenario)
+	}
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("%w: no steps", ErrInvalidScenario)
+	}
+
+	for i, step := range s.Steps {
+		if step.Level != nil && (*step.Level < 0 || *step.Level > 100) {
+			return fmt.Errorf("%w: step %d: level must be between 0 and 100", ErrInvalidScenario, i+1)
+		}
+		if step.Threshold != nil {
+			if err := protocol.ValidateThreshold(*step.Threshold); err != nil {
+				return fmt.Errorf("%w: step %d: threshold: %v", ErrInvalidScenario, i+1, err)
+			}
+		}
+		if step.After < 0 {
+			return fmt.Errorf("%w: step %d: after must not be negative", ErrInvalidScenario, i+1)
+		}
+	}
+	return nil
+}
+
+// Run plays the scenario, keeping the simulated state file in a temporary
+// directory
+func Run(s *Scenario) (Report, error) {
+	dir, err := os.MkdirTemp("", "legionbatctl-simulate-")
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	return run(s, filepath.Join(dir, "state.json"))
+}
+
+// run plays the scenario with the state file at statePath
+func run(s *Scenario, statePath string) (Report, error) {
+	interval := s.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	backend := hardware.NewMockBackendWithClock(func() time.Time { return now })
+	backend.SetLevel(s.Battery.Level)
+	backend.SetACOnline(s.Battery.AC == nil || *s.Battery.AC)
+	backend.SetConservationMode(s.Battery.ConservationMode)
+
+	manager := state.NewManager(statePath)
+	if err := manager.Load(); err != nil {
+		return Report{}, err
+	}
+	manager.SetHysteresis(s.Settings.Hysteresis)
+	if err := manager.SetChargeThresholds(s.Settings.Threshold, s.Settings.StartThreshold); err != nil {
+		return Report{}, err
+	}
+	if err := setManaged(manager, s.Settings.Managed == nil || *s.Settings.Managed); err != nil {
+		return Report{}, err
+	}
+
+	controller := direct.NewController(manager, backend)
+	report := Report{Scenario: s.Name}
+
+	for i, step := range s.Steps {
+		if step.Level != nil {
+			backend.SetLevel(*step.Level)
+		}
+		if step.AC != nil {
+			backend.SetACOnline(*step.AC)
+		}
+		if step.Threshold != nil {
+			if err := manager.SetChargeThreshold(*step.Threshold); err != nil {
+				return report, err
+			}
+		}
+		if step.Managed != nil {
+			if err := setManaged(manager, *step.Managed); err != nil {
+				return report, err
+			}
+		}
+
+		result := StepResult{Name: step.Name}
+		if result.Name == "" {
+			result.Name = fmt.Sprintf("step %d", i+1)
+		}
+
+		// Check right away, then every interval until the step is over
+		end := now.Add(step.After)
+		for {
+			battery, err := controller.Check(now)
+			if err != nil {
+				return report, fmt.Errorf("%s: %w", result.Name, err)
+			}
+			result.Battery = battery
+			if battery.Switched {
+				result.Switches++
+			}
+			if !now.Before(end) {
+				break
+			}
+			now = now.Add(min(interval, end.Sub(now)))
+		}
+
+		result.Elapsed = now.Sub(start)
+		result.Failures = step.Expect.check(result)
+		report.Steps = append(report.Steps, result)
+	}
+
+	return report, nil
+}
+
+// setManaged enables or disables battery management
+func setManaged(manager *state.Manager, managed bool) error {
+	if managed {
+		return manager.EnableConservation()
+	}
+	return manager.DisableConservation()
+}
+
+// check lists the expectations the step result doesn't meet
+func (e Expect) check(result StepResult) []string {
+	var failures []string
+
+	if e.ConservationMode != nil && result.Battery.ConservationMode != *e.ConservationMode {
+		failures = append(failures, fmt.Sprintf("conservation mode %s, expected %s",
+			onOff(result.Battery.ConservationMode), onOff(*e.ConservationMode)))
+	}
+	if e.LevelMin != nil && result.Battery.BatteryLevel < *e.LevelMin {
+		failures = append(failures, fmt.Sprintf("level %d%%, expected at least %d%%", result.Battery.BatteryLevel, *e.LevelMin))
+	}
+	if e.LevelMax != nil && result.Battery.BatteryLevel > *e.LevelMax {
+		failures = append(failures, fmt.Sprintf("level %d%%, expected at most %d%%", result.Battery.BatteryLevel, *e.LevelMax))
+	}
+	if e.Switches != nil && result.Switches != *e.Switches {
+		failures = append(failures, fmt.Sprintf("%d conservation mode switches, expected %d", result.Switches, *e.Switches))
+	}
+
+	return failures
+}
+
+// onOff names a conservation mode state
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
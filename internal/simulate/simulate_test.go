@@ -0,0 +1,99 @@
+package simulate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunExampleScenarios(t *testing.T) {
+	paths, err := filepath.Glob("../../examples/scenarios/*.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no example scenarios found")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			scenario, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			report, err := run(scenario, filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if !report.Passed() {
+				t.Errorf("scenario failed:\n%s", FormatReport(report))
+			}
+		})
+	}
+}
+
+func TestRunReportsUnmetExpectations(t *testing.T) {
+	on, level := true, 90
+	scenario := &Scenario{
+		Name:     "expects too much",
+		Interval: 10 * time.Minute,
+		Settings: Settings{Threshold: 80},
+		Battery:  Battery{Level: 50},
+		Steps: []Step{
+			{Name: "short charge", After: 30 * time.Minute, Expect: Expect{ConservationMode: &on, LevelMin: &level}},
+		},
+	}
+	if err := scenario.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	report, err := run(scenario, filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if report.Passed() {
+		t.Fatal("Passed() = true, want false")
+	}
+
+	step := report.Steps[0]
+	if step.Battery.BatteryLevel != 70 || step.Elapsed != 30*time.Minute {
+		t.Errorf("step ended at level %d after %v, want 70 after 30m", step.Battery.BatteryLevel, step.Elapsed)
+	}
+	if len(step.Failures) != 2 {
+		t.Errorf("Failures = %q, want 2", step.Failures)
+	}
+
+	output := FormatReport(report)
+	for _, want := range []string{"✗ +0:30  short charge", "conservation mode off, expected on", "0 of 1 steps passed"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("FormatReport() missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestLoadInvalidScenario(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"threshold too low", "[settings]\nthreshold = 40\n[[step]]\nafter = \"1m\"\n"},
+		{"no steps", "[settings]\nthreshold = 80\n"},
+		{"level out of range", "[settings]\nthreshold = 80\n[[step]]\nlevel = 120\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scenario.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); !errors.Is(err, ErrInvalidScenario) {
+				t.Errorf("Load() error = %v, want %v", err, ErrInvalidScenario)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Package snapshot keeps numbered copies of the battery management settings
+// so they can be restored after experimenting.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned for a snapshot that doesn't exist
+var ErrNotFound = errors.New("snapshot not found")
+
+// Snapshot is a copy of the settings at one point in time: those the state
+// file keeps and the config file, which holds the profiles and schedule
+type Snapshot struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created"`
+	Note    string    `json:"note,omitempty"`
+
+	Enabled        bool `json:"enabled"`
+	Threshold      int  `json:"threshold"`
+	StartThreshold int  `json:"start_threshold"` // 0 uses the hysteresis
+	SchedulePaused bool `json:"schedule_paused"`
+
+	// Config is the content of the config file; empty if there was none
+	Config string `json:"config"`
+
+	// Names of the profiles and schedule rules in Config, for listing
+	Profiles []string `json:"profiles,omitempty"`
+	Schedule []string `json:"schedule,omitempty"`
+}
+
+// Store keeps snapshots as one JSON file each in a directory
+type Store struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// New returns the store in dir, which is created with the first snapshot
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory holding the snapshots
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Create saves a snapshot under the next free ID and returns it with the ID
+// and, unless set, the creation time filled in
+func (s *Store) Create(snapshot Snapshot) (Snapshot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	snapshots, err := s.list()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot.ID = 1
+	if len(snapshots) > 0 {
+		snapshot.ID = snapshots[len(snapshots)-1].ID + 1
+	}
+	if snapshot.Created.IsZero() {
+		snapshot.Created = time.Now()
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	// The config file may hold webhook tokens, so snapshots are private
+	path := s.path(snapshot.ID)
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0600); err != nil {
+		os.Remove(tempPath)
+		return Snapshot{}, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return Snapshot{}, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// List returns the snapshots, oldest first. Files that fail to parse are
+// skipped.
+func (s *Store) List() ([]Snapshot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.list()
+}
+
+// Get returns the snapshot with the given ID
+func (s *Store) Get(id int) (Snapshot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	snapshot, err := s.read(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Snapshot{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return snapshot, err
+}
+
+// list reads the snapshots; the caller holds the mutex
+func (s *Store) list() ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if _, err := strconv.Atoi(name); err != nil {
+			continue
+		}
+		snapshot, err := s.read(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	slices.SortFunc(snapshots, func(a, b Snapshot) int { return a.ID - b.ID })
+	return snapshots, nil
+}
+
+// read reads the snapshot file at path
+func (s *Store) read(path string) (Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+// path returns the file of the snapshot with the given ID
+func (s *Store) path(id int) string {
+	return filepath.Join(s.dir, strconv.Itoa(id)+".json")
+}
@@ -0,0 +1,56 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	store := New(dir)
+
+	snapshots, err := store.List()
+	if err != nil || len(snapshots) != 0 {
+		t.Fatalf("Expected no snapshots before the first, got %v, %v", snapshots, err)
+	}
+
+	created := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	first, err := store.Create(Snapshot{Created: created, Note: "before tuning", Enabled: true, Threshold: 80})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	second, err := store.Create(Snapshot{Threshold: 60, StartThreshold: 55, Config: "[profiles.desk]\nthreshold = 60\n", Profiles: []string{"desk"}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if first.ID != 1 || second.ID != 2 || second.Created.IsZero() {
+		t.Errorf("Expected IDs 1 and 2 with creation times, got %+v and %+v", first, second)
+	}
+
+	// Files that aren't snapshots are ignored
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(dir, "7.json"), []byte("{"), 0644)
+
+	snapshots, err = store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Note != "before tuning" || snapshots[1].Profiles[0] != "desk" {
+		t.Errorf("Unexpected snapshots: %+v", snapshots)
+	}
+
+	got, err := store.Get(2)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Threshold != 60 || got.StartThreshold != 55 || got.Config != second.Config {
+		t.Errorf("Expected the second snapshot, got %+v", got)
+	}
+
+	if _, err := store.Get(3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultBackupLimit is how many state file backups are kept unless
+// configured otherwise
+const DefaultBackupLimit = 5
+
+// backupTimeLayout is the timestamp in backup file names, which sorts in
+// time order
+const backupTimeLayout = "20060102T150405.000000000Z"
+
+// BackupFile is a backup of the state file
+type BackupFile struct {
+	Path string
+	Time time.Time // When the backup was taken
+}
+
+// SetBackupLimit sets how many backups are kept; older ones are removed when
+// a new backup is taken. 0 uses DefaultBackupLimit.
+func (m *Manager) SetBackupLimit(limit int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.backupLimit = limit
+}
+
+// Backup creates a backup of the current state file
+func (m *Manager) Backup() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	unlock, err := m.lockFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return m.backup()
+}
+
+// Backups lists the backups of the state file, newest first
+func (m *Manager) Backups() ([]BackupFile, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.backups()
+}
+
+// Restore restores the state from the newest backup
+func (m *Manager) Restore() error {
+	return m.RestoreBackup(1)
+}
+
+// RestoreBackup restores the state from the nth newest backup, counting from
+// 1. The current state file is backed up first, so a restore can be undone.
+// The state doesn't need to be loaded first.
+func (m *Manager) RestoreBackup(n int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	unlock, err := m.lockFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	backups, err := m.backups()
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return ErrNoBackup
+	}
+	if n < 1 || n > len(backups) {
+		return fmt.Errorf("%w: backup %d, there are %d", ErrNoBackup, n, len(backups))
+	}
+	backup := backups[n-1]
+
+	state, err := readStateFile(backup.Path)
+	if err != nil {
+		return fmt.Errorf("backup %s: %w", filepath.Base(backup.Path), err)
+	}
+
+	// The backup of a split state file holds no runtime state
+	if m.runtimePath != "" {
+		if err := m.loadRuntime(&state.Runtime); err != nil {
+			return err
+		}
+	}
+
+	if err := m.backup(); err != nil {
+		return err
+	}
+
+	state.LastAction = "restore_backup"
+	state.LastActionTime = time.Now()
+	m.state = state
+	m.saved = State{} // Always write the restored state
+	return m.saveStateAtomic()
+}
+
+// backup copies the state file to a new timestamped backup and removes the
+// oldest ones beyond the limit. Nothing is copied if the newest backup is
+// identical. (requires write lock and the state file lock)
+func (m *Manager) backup() error {
+	data, err := os.ReadFile(m.statePath)
+	if os.IsNotExist(err) {
+		return nil // No file to backup
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file for backup: %w", err)
+	}
+
+	backups, err := m.backups()
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 {
+		newest, err := os.ReadFile(backups[0].Path)
+		if err == nil && bytes.Equal(newest, data) {
+			return nil
+		}
+	}
+
+	backupPath := m.statePath + ".backup-" + time.Now().UTC().Format(backupTimeLayout)
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+
+	backups, err = m.backups()
+	if err != nil {
+		return err
+	}
+	limit := m.backupLimit
+	if limit <= 0 {
+		limit = DefaultBackupLimit
+	}
+	for _, old := range backups[min(limit, len(backups)):] {
+		os.Remove(old.Path) // Ignore error, it is retried with the next backup
+	}
+
+	return nil
+}
+
+// backups lists the backups next to the state file, newest first. The single
+// .backup file of older versions is listed by its modification time.
+// (requires read lock)
+func (m *Manager) backups() ([]BackupFile, error) {
+	dir, base := filepath.Split(m.statePath)
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []BackupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+
+		if name == base+".backup" {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			backups = append(backups, BackupFile{Path: path, Time: info.ModTime()})
+			continue
+		}
+
+		stamp, ok := strings.CutPrefix(name, base+".backup-")
+		if !ok {
+			continue
+		}
+		taken, err := time.Parse(backupTimeLayout, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, BackupFile{Path: path, Time: taken})
+	}
+
+	slices.SortFunc(backups, func(a, b BackupFile) int {
+		return b.Time.Compare(a.Time)
+	})
+	return backups, nil
+}
+
+// newestUsableBackup returns the state from the newest backup that passes
+// verification, or nil if there is none (requires read lock)
+func (m *Manager) newestUsableBackup() (*State, BackupFile) {
+	backups, err := m.backups()
+	if err != nil {
+		return nil, BackupFile{}
+	}
+	for _, backup := range backups {
+		if state, err := readStateFile(backup.Path); err == nil {
+			return state, backup
+		}
+	}
+	return nil, BackupFile{}
+}
+
+// removeBackups removes all backups of the state file (requires write lock)
+func (m *Manager) removeBackups() {
+	backups, _ := m.backups()
+	for _, backup := range backups {
+		os.Remove(backup.Path) // Ignore error
+	}
+}
@@ -4,11 +4,13 @@ import "fmt"
 
 // Common state management errors
 var (
-	ErrInvalidThreshold    = NewStateError("threshold must be between 60 and 100")
+	ErrInvalidThreshold    = NewStateError("threshold must be between 40 and 100")
 	ErrInvalidBatteryLevel = NewStateError("battery level must be between 0 and 100")
 	ErrInvalidPID          = NewStateError("PID must be positive")
 	ErrInvalidMode         = NewStateError("invalid current mode")
 	ErrNoBackup            = NewStateError("no backup file found")
+	ErrCorruptState        = NewStateError("state file is corrupted")
+	ErrStateLocked         = NewStateError("state file is locked by another process")
 )
 
 // StateError represents a state management error
@@ -0,0 +1,64 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// lockTimeout is how long a process waits for another one to release the
+// state file lock
+var lockTimeout = 5 * time.Second
+
+// lockRetryInterval is how often a held lock is tried again
+const lockRetryInterval = 20 * time.Millisecond
+
+// lockPath returns the lock file guarding the state file. The state file is
+// replaced on every save, so it can't carry the lock itself.
+func (m *Manager) lockPath() string {
+	return m.statePath + ".lock"
+}
+
+// lockFile takes the advisory lock serializing state file access between
+// processes, e.g. the daemon and the auto command, waiting up to lockTimeout.
+// Nested calls while it is held return a no-op unlock. (requires write lock)
+func (m *Manager) lockFile() (func(), error) {
+	if m.fileLock != nil {
+		return func() {}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.lockPath()), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	file, err := os.OpenFile(m.lockPath(), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock state file: %w", err)
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, ErrStateLocked
+		}
+		time.Sleep(lockRetryInterval)
+	}
+
+	m.fileLock = file
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+		m.fileLock = nil
+	}, nil
+}
@@ -1,48 +1,211 @@
 package state
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
 )
 
-// Load loads the state from file
+// checkedState and checkedPersistent are the formats of the state file: the
+// state with a checksum over it, so a damaged file is detected on load
+type checkedState struct {
+	Checksum string `json:"checksum,omitempty"`
+	*State
+}
+
+type checkedPersistent struct {
+	Checksum string `json:"checksum,omitempty"`
+	*Persistent
+}
+
+// Load loads the state from file, and the runtime state from its own file
+// if it exists. A corrupted state file is replaced by the newest usable
+// backup, or by the defaults if there is none; Recovery reports what happened.
 func (m *Manager) Load() error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	// If file doesn't exist, create default state
-	if _, err := os.Stat(m.statePath); os.IsNotExist(err) {
+	// Hold the lock across reading, recovering and backing up, so another
+	// process doesn't save in between
+	unlock, err := m.lockFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	m.recovery = ""
+	state, err := readStateFile(m.statePath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// If file doesn't exist, create default state
 		m.state = createDefaultState()
 		return m.saveStateAtomic()
+	case errors.Is(err, ErrCorruptState):
+		return m.recoverState(err)
+	case err != nil:
+		return err
+	}
+
+	// State files written before the runtime state was split off also hold
+	// the runtime fields; a runtime file is more recent
+	if err := m.loadRuntime(&state.Runtime); err != nil {
+		return err
+	}
+
+	m.state = state
+	m.saved = *state
+
+	// Keep the verified file as a backup to recover from
+	return m.backup()
+}
+
+// Recovery describes how a corrupted state file was recovered by the last
+// Load, or returns an empty string if the state file was intact
+func (m *Manager) Recovery() string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.recovery
+}
+
+// recoverState replaces a corrupted state file with the newest usable backup,
+// or with the defaults if there is none, and records the recovery in the
+// state. The corrupted file is kept next to it for inspection.
+func (m *Manager) recoverState(cause error) error {
+	os.Rename(m.statePath, m.statePath+".corrupt") // Ignore error
+
+	state, backup := m.newestUsableBackup()
+	if state != nil {
+		state.LastAction = "recovered_backup"
+		m.recovery = fmt.Sprintf("%v; restored the backup from %s", cause, backup.Time.Format(time.RFC3339))
+	} else {
+		state = createDefaultState()
+		state.LastAction = "recovered_defaults"
+		m.recovery = fmt.Sprintf("%v; no usable backup, reset to defaults", cause)
+	}
+	state.LastActionTime = time.Now()
+
+	if err := m.loadRuntime(&state.Runtime); err != nil {
+		return err
+	}
+
+	m.state = state
+	m.saved = State{} // Nothing valid is on disk
+	return m.saveStateAtomic()
+}
+
+// readStateFile reads and verifies a state file. Unknown fields, a checksum
+// mismatch or invalid values are reported as ErrCorruptState; a file without
+// a checksum, e.g. edited by hand, is only checked for the latter two.
+func readStateFile(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	// Files from before the shutdown marker was added don't have it; their
+	// last stop isn't known to be unclean
+	state := State{Persistent: Persistent{CleanShutdown: true}}
+	file := checkedState{State: &state}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptState, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("%w: unexpected data after the state", ErrCorruptState)
 	}
 
-	// Read existing state file
-	data, err := os.ReadFile(m.statePath)
+	if file.Checksum != "" {
+		// Depending on where the runtime state is kept, the file holds the
+		// whole state or only its persistent part
+		whole, err := checksum(&state)
+		if err != nil {
+			return nil, err
+		}
+		persistent, err := checksum(&state.Persistent)
+		if err != nil {
+			return nil, err
+		}
+		if file.Checksum != whole && file.Checksum != persistent {
+			return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptState)
+		}
+	}
+
+	if err := validateStateFields(&state); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptState, err)
+	}
+
+	return &state, nil
+}
+
+// checksum returns the SHA-256 of the JSON encoding of value
+func checksum(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
 	if err != nil {
-		return fmt.Errorf("failed to read state file: %w", err)
+		return "", fmt.Errorf("failed to encode state: %w", err)
 	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
 
-	var state State
-	if err := json.Unmarshal(data, &state); err != nil {
-		// If JSON is corrupted, create default state
-		m.state = createDefaultState()
-		return fmt.Errorf("failed to unmarshal state file, using defaults: %w", err)
+// loadRuntime reads the runtime state file into runtime, if there is one.
+// It doesn't survive a reboot, so a missing file is not an error.
+func (m *Manager) loadRuntime(runtime *Runtime) error {
+	if m.runtimePath == "" {
+		return nil
 	}
 
-	// Validate loaded state
-	m.state = &state
-	if err := m.validateState(); err != nil {
-		// If state is invalid, create default state
-		m.state = createDefaultState()
-		return fmt.Errorf("invalid state file, using defaults: %w", err)
+	data, err := os.ReadFile(m.runtimePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read runtime state file: %w", err)
 	}
 
+	var loaded Runtime
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		// Only readings and daemon info are lost; the next reading restores them
+		return nil
+	}
+	*runtime = loaded
 	return nil
 }
 
+// refresh re-reads the persistent state if another process, e.g. the auto
+// command, saved it since this manager last did, so a read-modify-write
+// starts from it instead of overwriting it. An unreadable file is left for
+// the save to replace. (requires write lock and state file lock)
+func (m *Manager) refresh() {
+	state, err := readStateFile(m.statePath)
+	if err != nil || state.Persistent == m.saved.Persistent {
+		return
+	}
+	m.state.Persistent = state.Persistent
+	m.saved.Persistent = state.Persistent
+}
+
+// update applies updateFn to the state as it is on disk and saves it, holding
+// the state file lock throughout (requires write lock)
+func (m *Manager) update(updateFn func(*State)) error {
+	unlock, err := m.lockFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	m.refresh()
+	updateFn(m.state)
+	return m.saveStateAtomic()
+}
+
 // Save saves the current state to file (requires write lock)
 func (m *Manager) Save() error {
 	m.mutex.Lock()
@@ -51,21 +214,67 @@ func (m *Manager) Save() error {
 	return m.saveStateAtomic()
 }
 
-// saveStateAtomic saves the state atomically using temp file + rename
+// saveStateAtomic saves the parts of the state that changed since the last
+// save: the persistent part to the state file and the runtime part to the
+// runtime file. Without a runtime file everything goes to the state file.
 func (m *Manager) saveStateAtomic() error {
 	// Validate state before saving
 	if err := m.validateState(); err != nil {
 		return fmt.Errorf("invalid state: %w", err)
 	}
 
+	if *m.state == m.saved {
+		return nil
+	}
+	unlock, err := m.lockFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if m.runtimePath == "" {
+		sum, err := checksum(m.state)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(m.statePath, checkedState{Checksum: sum, State: m.state}); err != nil {
+			return err
+		}
+		m.saved = *m.state
+		return nil
+	}
+
+	if m.state.Persistent != m.saved.Persistent {
+		sum, err := checksum(&m.state.Persistent)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(m.statePath, checkedPersistent{Checksum: sum, Persistent: &m.state.Persistent}); err != nil {
+			return err
+		}
+		m.saved.Persistent = m.state.Persistent
+	}
+
+	if m.state.Runtime != m.saved.Runtime {
+		if err := writeFileAtomic(m.runtimePath, &m.state.Runtime); err != nil {
+			return fmt.Errorf("runtime state: %w", err)
+		}
+		m.saved.Runtime = m.state.Runtime
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes value as JSON to path using temp file + rename
+func writeFileAtomic(path string, value interface{}) error {
 	// Ensure directory exists
-	dir := filepath.Dir(m.statePath)
+	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create state directory: %w", err)
 	}
 
 	// Create temporary file
-	tempPath := m.statePath + ".tmp"
+	tempPath := path + ".tmp"
 	file, err := os.Create(tempPath)
 	if err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
@@ -75,7 +284,7 @@ func (m *Manager) saveStateAtomic() error {
 	// Write JSON with indentation for readability
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(m.state); err != nil {
+	if err := encoder.Encode(value); err != nil {
 		os.Remove(tempPath) // Clean up temp file
 		return fmt.Errorf("failed to write state to temp file: %w", err)
 	}
@@ -93,13 +302,13 @@ func (m *Manager) saveStateAtomic() error {
 	}
 
 	// Atomic rename
-	if err := os.Rename(tempPath, m.statePath); err != nil {
+	if err := os.Rename(tempPath, path); err != nil {
 		os.Remove(tempPath) // Clean up temp file
 		return fmt.Errorf("failed to rename temp file: %w", err)
 	}
 
 	// Set appropriate permissions
-	if err := os.Chmod(m.statePath, 0644); err != nil {
+	if err := os.Chmod(path, 0644); err != nil {
 		return fmt.Errorf("failed to set permissions on state file: %w", err)
 	}
 
@@ -114,77 +323,40 @@ func (m *Manager) validateState() error {
 // createDefaultState creates a default state
 func createDefaultState() *State {
 	return &State{
-		ConservationEnabled: false,
-		ChargeThreshold:     80, // Default threshold for battery health
-		CurrentMode:         "unknown",
-		LastAction:          "init",
-		LastActionTime:      time.Now(),
-		BatteryLevel:        0,
-		ConservationMode:    false,
-		Charging:            false,
-		PID:                 0,
-		StartTime:           time.Now(),
+		Persistent: Persistent{
+			ConservationEnabled: false,
+			ChargeThreshold:     80, // Default threshold for battery health
+			CurrentMode:         "unknown",
+			LastAction:          "init",
+			LastActionTime:      time.Now(),
+			CleanShutdown:       true, // A fresh install has no crash to report
+		},
+		Runtime: Runtime{
+			StartTime: time.Now(),
+		},
 	}
 }
 
-// Backup creates a backup of the current state file
-func (m *Manager) Backup() error {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-
-	if _, err := os.Stat(m.statePath); os.IsNotExist(err) {
-		return nil // No file to backup
-	}
-
-	backupPath := m.statePath + ".backup"
-	data, err := os.ReadFile(m.statePath)
-	if err != nil {
-		return fmt.Errorf("failed to read state file for backup: %w", err)
-	}
-
-	if err := os.WriteFile(backupPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write backup file: %w", err)
-	}
-
-	return nil
-}
-
-// Restore restores state from backup
-func (m *Manager) Restore() error {
+// Remove removes the state file
+func (m *Manager) Remove() error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	backupPath := m.statePath + ".backup"
-	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
-		return ErrNoBackup
-	}
-
-	data, err := os.ReadFile(backupPath)
+	unlock, err := m.lockFile()
 	if err != nil {
-		return fmt.Errorf("failed to read backup file: %w", err)
+		return err
 	}
-
-	var state State
-	if err := json.Unmarshal(data, &state); err != nil {
-		return fmt.Errorf("failed to unmarshal backup file: %w", err)
-	}
-
-	m.state = &state
-	return m.saveStateAtomic()
-}
-
-// Remove removes the state file
-func (m *Manager) Remove() error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer unlock()
 
 	if err := os.Remove(m.statePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove state file: %w", err)
 	}
 
-	// Also remove backup if it exists
-	backupPath := m.statePath + ".backup"
-	os.Remove(backupPath) // Ignore error
+	// Also remove backups and runtime state if they exist
+	m.removeBackups()
+	if m.runtimePath != "" {
+		os.Remove(m.runtimePath) // Ignore error
+	}
 
 	return nil
 }
@@ -1,25 +1,79 @@
 package state
 
 import (
+	"os"
 	"sync"
 	"time"
 )
 
-// State represents the current runtime state of the battery management system
+// State represents the current state of the battery management system. The
+// persistent part is saved to the state file; the runtime part changes with
+// every battery reading and is saved separately, e.g. under /run, so it
+// doesn't keep rewriting the state file.
 type State struct {
+	Persistent
+	Runtime
+}
+
+// Persistent is the part of the state that must survive a reboot: what the
+// user asked for, and what the daemon learned or must remember
+type Persistent struct {
 	// Configuration
 	ConservationEnabled bool `json:"conservation_enabled"`
 	ChargeThreshold     int  `json:"charge_threshold"`
+	StartThreshold      int  `json:"start_threshold"` // Resume charging below this level; 0 uses the hysteresis
 
 	// Runtime State
 	CurrentMode    string    `json:"current_mode"` // "enabled", "disabled", "unknown"
-	LastAction     string    `json:"last_action"`  // "enable", "disable", "set_threshold", "auto"
+	LastAction     string    `json:"last_action"`  // "enable", "disable", "set_threshold", ...
 	LastActionTime time.Time `json:"last_action_time"`
 
+	// Overrides
+	ChargeFull   bool      `json:"charge_full"`    // One-off charge to 100%; management resumes once full
+	ChargeFullBy time.Time `json:"charge_full_by"` // Deadline for a scheduled charge-full
+	ReenableAt   time.Time `json:"reenable_at"`    // Temporary disable; management resumes at this time
+
+	// Management was disabled when the charge-full started, so it stays
+	// disabled once full. Inverted so older state files re-enable it.
+	ChargeFullWasDisabled bool `json:"charge_full_was_disabled"`
+
+	// Pausing stops the daemon from switching the hardware, leaving it and
+	// the settings as they are
+	Paused      bool      `json:"paused"`
+	PausedUntil time.Time `json:"paused_until"` // Automation resumes at this time; zero until resumed
+
+	// Scheduling
+	SchedulePaused bool `json:"schedule_paused"` // Ignore schedule rules and use the charge threshold
+
+	// Storage mode holds the battery at a low level for long-term storage
+	StorageMode   bool `json:"storage_mode"`
+	StorageTarget int  `json:"storage_target"`
+
+	// Rapid charge wanted while charging. Legion firmware allows only one of
+	// rapid charge and conservation mode, so it is off while the charge is held.
+	RapidCharge bool `json:"rapid_charge"`
+
+	// Charge rate learned from observed charging, in percent per hour
+	ChargeRate float64 `json:"charge_rate"`
+
+	// Charge power learned from observed charging, in watts
+	ChargePower float64 `json:"charge_power"`
+
+	// Shutdown Tracking
+	CleanShutdown       bool      `json:"clean_shutdown"` // Set on graceful stop, cleared while running
+	LastShutdownTime    time.Time `json:"last_shutdown_time"`
+	UncleanShutdowns    int       `json:"unclean_shutdowns"`
+	LastUncleanShutdown time.Time `json:"last_unclean_shutdown"`
+}
+
+// Runtime is the volatile part of the state: battery readings and the
+// running daemon
+type Runtime struct {
 	// Battery Information
-	BatteryLevel     int  `json:"battery_level"`
-	ConservationMode bool `json:"conservation_mode"` // Hardware conservation mode state
-	Charging         bool `json:"charging"`
+	BatteryLevel     int       `json:"battery_level"`
+	ConservationMode bool      `json:"conservation_mode"` // Hardware conservation mode state
+	Charging         bool      `json:"charging"`
+	LastReadingTime  time.Time `json:"last_reading_time"` // When the battery fields were last read from hardware
 
 	// Daemon Information
 	PID       int       `json:"pid"`
@@ -28,9 +82,24 @@ type State struct {
 
 // Manager manages the state with thread-safe operations and persistence
 type Manager struct {
-	statePath string
-	mutex     sync.RWMutex
-	state     *State
+	statePath   string
+	runtimePath string // Empty keeps the runtime state in the state file
+	mutex       sync.RWMutex
+	state       *State
+	saved       State              // Last saved state, to skip writes that change nothing
+	recovery    string             // How Load recovered a corrupted state file, if it did
+	fileLock    *os.File           // Held state file lock, nil if not held
+	backupLimit int                // Backups to keep; 0 uses DefaultBackupLimit
+	hysteresis  int                // From the daemon config, not persisted
+	override    *ThresholdOverride // From the active schedule rule, not persisted
+}
+
+// ThresholdOverride temporarily replaces the configured charge thresholds,
+// e.g. while a schedule rule is active
+type ThresholdOverride struct {
+	Source         string // What applied the override, e.g. the schedule rule name
+	Threshold      int
+	StartThreshold int // 0 falls back to the hysteresis
 }
 
 // NewManager creates a new state manager
@@ -38,11 +107,19 @@ func NewManager(statePath string) *Manager {
 	return &Manager{
 		statePath: statePath,
 		state: &State{
-			CurrentMode: "unknown", // Initialize with valid default
+			Persistent: Persistent{CurrentMode: "unknown"}, // Initialize with valid default
 		},
 	}
 }
 
+// SetRuntimePath sets where the runtime state is saved, e.g. under /run
+// (must be called before Load)
+func (m *Manager) SetRuntimePath(path string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.runtimePath = path
+}
+
 // GetState returns a copy of the current state (thread-safe)
 func (m *Manager) GetState() State {
 	m.mutex.RLock()
@@ -88,19 +165,23 @@ func (m *Manager) IsCharging() bool {
 	return m.state.Charging
 }
 
-// UpdateState performs an atomic update of the state
+// UpdateState performs an atomic update of the state, starting from changes
+// other processes saved
 func (m *Manager) UpdateState(updateFn func(*State)) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	updateFn(m.state)
-	return m.saveStateAtomic()
+	return m.update(updateFn)
 }
 
 // EnableConservation enables battery management
 func (m *Manager) EnableConservation() error {
 	return m.UpdateState(func(s *State) {
 		s.ConservationEnabled = true
+		s.ChargeFull = false
+		s.ChargeFullBy = time.Time{}
+		s.ReenableAt = time.Time{}
+		s.StorageMode = false
 		s.CurrentMode = "enabled"
 		s.LastAction = "enable"
 		s.LastActionTime = time.Now()
@@ -109,18 +190,413 @@ func (m *Manager) EnableConservation() error {
 
 // DisableConservation disables battery management
 func (m *Manager) DisableConservation() error {
+	return m.DisableConservationUntil(time.Time{})
+}
+
+// DisableConservationUntil disables battery management until the given time,
+// after which the daemon re-enables it. A zero time disables it indefinitely.
+func (m *Manager) DisableConservationUntil(until time.Time) error {
 	return m.UpdateState(func(s *State) {
 		s.ConservationEnabled = false
+		s.ChargeFull = false
+		s.ChargeFullBy = time.Time{}
+		s.ReenableAt = until
+		s.StorageMode = false
 		s.CurrentMode = "disabled"
 		s.LastAction = "disable"
 		s.LastActionTime = time.Now()
 	})
 }
 
-// SetChargeThreshold sets the charge threshold
+// RestoreSettings puts back the settings of a snapshot, ending temporary
+// overrides such as charge-full and storage mode as enable and disable do
+func (m *Manager) RestoreSettings(enabled bool, threshold, start int, schedulePaused bool) error {
+	return m.UpdateState(func(s *State) {
+		s.ConservationEnabled = enabled
+		s.ChargeThreshold = threshold
+		s.StartThreshold = start
+		s.SchedulePaused = schedulePaused
+		s.ChargeFull = false
+		s.ChargeFullBy = time.Time{}
+		s.ReenableAt = time.Time{}
+		s.StorageMode = false
+		s.CurrentMode = "disabled"
+		if enabled {
+			s.CurrentMode = "enabled"
+		}
+		s.LastAction = "rollback"
+		s.LastActionTime = time.Now()
+	})
+}
+
+// RevertSettings puts back the settings and overrides of saved, taken from
+// GetState, keeping what the daemon learned and recorded since
+func (m *Manager) RevertSettings(saved Persistent) error {
+	return m.UpdateState(func(s *State) {
+		s.ConservationEnabled = saved.ConservationEnabled
+		s.ChargeThreshold = saved.ChargeThreshold
+		s.StartThreshold = saved.StartThreshold
+		s.CurrentMode = saved.CurrentMode
+		s.LastAction = saved.LastAction
+		s.LastActionTime = saved.LastActionTime
+		s.ChargeFull = saved.ChargeFull
+		s.ChargeFullWasDisabled = saved.ChargeFullWasDisabled
+		s.ChargeFullBy = saved.ChargeFullBy
+		s.ReenableAt = saved.ReenableAt
+		s.Paused = saved.Paused
+		s.PausedUntil = saved.PausedUntil
+		s.SchedulePaused = saved.SchedulePaused
+		s.StorageMode = saved.StorageMode
+		s.StorageTarget = saved.StorageTarget
+		s.RapidCharge = saved.RapidCharge
+	})
+}
+
+// StartChargeFull suspends battery management until the battery is full
+func (m *Manager) StartChargeFull() error {
+	return m.UpdateState(func(s *State) {
+		if !s.ChargeFull {
+			s.ChargeFullWasDisabled = !s.ConservationEnabled
+		}
+		s.ConservationEnabled = false
+		s.ChargeFull = true
+		s.ChargeFullBy = time.Time{}
+		s.ReenableAt = time.Time{}
+		s.StorageMode = false
+		s.CurrentMode = "disabled"
+		s.LastAction = "charge_full"
+		s.LastActionTime = time.Now()
+	})
+}
+
+// ScheduleChargeFull arranges for the battery to be full by the given time.
+// Management continues until the daemon starts charging in time for it.
+func (m *Manager) ScheduleChargeFull(by time.Time) error {
+	return m.UpdateState(func(s *State) {
+		s.ChargeFull = false
+		s.ChargeFullBy = by
+		s.LastAction = "charge_full_by"
+		s.LastActionTime = time.Now()
+	})
+}
+
+// BeginScheduledChargeFull starts charging for a scheduled charge-full,
+// keeping the deadline for reference
+func (m *Manager) BeginScheduledChargeFull() error {
+	return m.UpdateState(func(s *State) {
+		if !s.ChargeFull {
+			s.ChargeFullWasDisabled = !s.ConservationEnabled
+		}
+		s.ConservationEnabled = false
+		s.ChargeFull = true
+		s.CurrentMode = "disabled"
+		s.LastAction = "charge_full"
+		s.LastActionTime = time.Now()
+	})
+}
+
+// GetChargeFullBy returns the deadline of a scheduled charge-full, or the zero time
+func (m *Manager) GetChargeFullBy() time.Time {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.state.ChargeFullBy
+}
+
+// RecordChargeRate folds an observed charge rate (percent per hour) into the
+// learned rate, weighting recent observations more
+func (m *Manager) RecordChargeRate(rate float64) error {
+	return m.UpdateState(func(s *State) {
+		if s.ChargeRate == 0 {
+			s.ChargeRate = rate
+			return
+		}
+		s.ChargeRate = 0.7*s.ChargeRate + 0.3*rate
+	})
+}
+
+// GetChargeRate returns the learned charge rate in percent per hour, or 0 if
+// no charging has been observed yet
+func (m *Manager) GetChargeRate() float64 {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.state.ChargeRate
+}
+
+// RecordChargePower folds an observed charge power (watts) into the learned
+// typical charge power, weighting recent observations more
+func (m *Manager) RecordChargePower(watts float64) error {
+	return m.UpdateState(func(s *State) {
+		if s.ChargePower == 0 {
+			s.ChargePower = watts
+			return
+		}
+		s.ChargePower = 0.7*s.ChargePower + 0.3*watts
+	})
+}
+
+// GetChargePower returns the learned typical charge power in watts, or 0 if
+// no charging has been observed yet
+func (m *Manager) GetChargePower() float64 {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.state.ChargePower
+}
+
+// FinishChargeFull ends a charge-full override, re-enabling battery
+// management unless it was disabled when the charge-full started
+func (m *Manager) FinishChargeFull() error {
+	return m.UpdateState(func(s *State) {
+		s.ConservationEnabled = !s.ChargeFullWasDisabled
+		s.ChargeFull = false
+		s.ChargeFullWasDisabled = false
+		s.ChargeFullBy = time.Time{}
+		s.CurrentMode = "disabled"
+		if s.ConservationEnabled {
+			s.CurrentMode = "enabled"
+		}
+		s.LastAction = "charge_full_done"
+		s.LastActionTime = time.Now()
+	})
+}
+
+// IsChargeFull returns whether a charge-full override is in progress
+func (m *Manager) IsChargeFull() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.state.ChargeFull
+}
+
+// ReenableIfDue re-enables battery management when a temporary disable has
+// expired, reporting whether it did so
+func (m *Manager) ReenableIfDue(now time.Time) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.state.ReenableAt.IsZero() || now.Before(m.state.ReenableAt) {
+		return false, nil
+	}
+
+	// Check again on the state on disk, which may have been changed since
+	reenabled := false
+	err := m.update(func(s *State) {
+		if s.ReenableAt.IsZero() || now.Before(s.ReenableAt) {
+			return
+		}
+		s.ConservationEnabled = true
+		s.ReenableAt = time.Time{}
+		s.CurrentMode = "enabled"
+		s.LastAction = "auto_reenable"
+		s.LastActionTime = now
+		reenabled = true
+	})
+	return reenabled, err
+}
+
+// Pause stops automatic switching until Resume, or until the given time
+// unless it is zero
+func (m *Manager) Pause(until time.Time) error {
+	return m.UpdateState(func(s *State) {
+		s.Paused = true
+		s.PausedUntil = until
+		s.LastAction = "pause"
+		s.LastActionTime = time.Now()
+	})
+}
+
+// Resume resumes automatic switching after Pause
+func (m *Manager) Resume() error {
+	return m.UpdateState(func(s *State) {
+		s.Paused = false
+		s.PausedUntil = time.Time{}
+		s.LastAction = "resume"
+		s.LastActionTime = time.Now()
+	})
+}
+
+// GetPause returns whether automatic switching is paused, and until when
+// (zero until resumed)
+func (m *Manager) GetPause() (bool, time.Time) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.state.Paused, m.state.PausedUntil
+}
+
+// ResumeIfDue resumes automatic switching when a timed pause has expired,
+// reporting whether it did so
+func (m *Manager) ResumeIfDue(now time.Time) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.state.Paused || m.state.PausedUntil.IsZero() || now.Before(m.state.PausedUntil) {
+		return false, nil
+	}
+
+	// Check again on the state on disk, which may have been changed since
+	resumed := false
+	err := m.update(func(s *State) {
+		if !s.Paused || s.PausedUntil.IsZero() || now.Before(s.PausedUntil) {
+			return
+		}
+		s.Paused = false
+		s.PausedUntil = time.Time{}
+		s.LastAction = "auto_resume"
+		s.LastActionTime = now
+		resumed = true
+	})
+	return resumed, err
+}
+
+// EnableStorageMode holds the battery at the target level for long-term
+// storage, replacing any pending override
+func (m *Manager) EnableStorageMode(target int) error {
+	return m.UpdateState(func(s *State) {
+		s.ConservationEnabled = true
+		s.StorageMode = true
+		s.StorageTarget = target
+		s.ChargeFull = false
+		s.ChargeFullBy = time.Time{}
+		s.ReenableAt = time.Time{}
+		s.CurrentMode = "enabled"
+		s.LastAction = "storage_on"
+		s.LastActionTime = time.Now()
+	})
+}
+
+// DisableStorageMode returns to regular battery management
+func (m *Manager) DisableStorageMode() error {
+	return m.UpdateState(func(s *State) {
+		s.StorageMode = false
+		s.LastAction = "storage_off"
+		s.LastActionTime = time.Now()
+	})
+}
+
+// GetStorageMode returns whether storage mode is active and its target level
+func (m *Manager) GetStorageMode() (bool, int) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.state.StorageMode, m.state.StorageTarget
+}
+
+// SetHysteresis sets how far below the threshold the battery may drop before
+// charging resumes
+func (m *Manager) SetHysteresis(hysteresis int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.hysteresis = hysteresis
+}
+
+// SetThresholdOverride replaces the configured thresholds until cleared with nil
+func (m *Manager) SetThresholdOverride(override *ThresholdOverride) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.override = override
+}
+
+// GetThresholdOverride returns the active threshold override, or nil
+func (m *Manager) GetThresholdOverride() *ThresholdOverride {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.override
+}
+
+// GetEffectiveThreshold returns the threshold currently in force, taking an
+// active override into account
+func (m *Manager) GetEffectiveThreshold() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.threshold(m.state)
+}
+
+// GetStartThreshold returns the level below which charging resumes
+func (m *Manager) GetStartThreshold() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.startThreshold(m.state)
+}
+
+// threshold computes the threshold in force for s (caller must hold the mutex)
+func (m *Manager) threshold(s *State) int {
+	if m.override != nil {
+		return m.override.Threshold
+	}
+	return s.ChargeThreshold
+}
+
+// startThreshold computes the resume level for s (caller must hold the
+// mutex). An explicit start threshold takes precedence over the configured
+// hysteresis.
+func (m *Manager) startThreshold(s *State) int {
+	threshold := m.threshold(s)
+
+	start := s.StartThreshold
+	if m.override != nil {
+		start = m.override.StartThreshold
+	}
+
+	if start > 0 && start < threshold {
+		return start
+	}
+	return max(threshold-m.hysteresis, 0)
+}
+
+// SetSchedulePaused pauses or resumes schedule rules
+func (m *Manager) SetSchedulePaused(paused bool) error {
+	return m.UpdateState(func(s *State) {
+		s.SchedulePaused = paused
+		s.LastAction = "resume_schedule"
+		if paused {
+			s.LastAction = "pause_schedule"
+		}
+		s.LastActionTime = time.Now()
+	})
+}
+
+// IsSchedulePaused returns whether schedule rules are paused
+func (m *Manager) IsSchedulePaused() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.state.SchedulePaused
+}
+
+// SetRapidCharge records whether rapid charge is wanted while charging
+func (m *Manager) SetRapidCharge(enable bool) error {
+	return m.UpdateState(func(s *State) {
+		s.RapidCharge = enable
+		s.LastAction = "rapid_charge_off"
+		if enable {
+			s.LastAction = "rapid_charge_on"
+		}
+		s.LastActionTime = time.Now()
+	})
+}
+
+// GetRapidCharge returns whether rapid charge is wanted while charging
+func (m *Manager) GetRapidCharge() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.state.RapidCharge
+}
+
+// SetChargeThreshold sets the charge threshold. A start threshold that is no
+// longer below the new threshold is cleared.
 func (m *Manager) SetChargeThreshold(threshold int) error {
 	return m.UpdateState(func(s *State) {
 		s.ChargeThreshold = threshold
+		if s.StartThreshold >= threshold {
+			s.StartThreshold = 0
+		}
+		s.LastAction = "set_threshold"
+		s.LastActionTime = time.Now()
+	})
+}
+
+// SetChargeThresholds sets both the stop and start charge thresholds; a zero
+// start threshold falls back to the configured hysteresis
+func (m *Manager) SetChargeThresholds(threshold, start int) error {
+	return m.UpdateState(func(s *State) {
+		s.ChargeThreshold = threshold
+		s.StartThreshold = start
 		s.LastAction = "set_threshold"
 		s.LastActionTime = time.Now()
 	})
@@ -132,8 +608,15 @@ func (m *Manager) UpdateBatteryInfo(level int, conservationMode, charging bool)
 		s.BatteryLevel = level
 		s.ConservationMode = conservationMode
 		s.Charging = charging
-		s.LastAction = "auto"
-		s.LastActionTime = time.Now()
+		s.LastReadingTime = time.Now()
+	})
+}
+
+// UpdateConservationMode records a hardware conservation mode change made by
+// the daemon, keeping the other battery readings
+func (m *Manager) UpdateConservationMode(mode bool) error {
+	return m.UpdateState(func(s *State) {
+		s.ConservationMode = mode
 	})
 }
 
@@ -145,15 +628,51 @@ func (m *Manager) SetDaemonInfo(pid int) error {
 	})
 }
 
+// MarkRunning clears the clean shutdown marker for a starting daemon and reports
+// whether the previous run ended cleanly. An unclean shutdown is recorded in the
+// state so it remains visible to users after the fact.
+func (m *Manager) MarkRunning() (bool, error) {
+	var wasClean bool
+	err := m.UpdateState(func(s *State) {
+		wasClean = s.CleanShutdown
+		if !wasClean {
+			s.UncleanShutdowns++
+			s.LastUncleanShutdown = time.Now()
+			s.LastAction = "unclean_shutdown"
+			s.LastActionTime = s.LastUncleanShutdown
+		}
+		s.CleanShutdown = false
+	})
+	return wasClean, err
+}
+
+// MarkCleanShutdown persists the final battery readings together with the
+// clean shutdown marker
+func (m *Manager) MarkCleanShutdown(level int, conservationMode, charging bool) error {
+	return m.UpdateState(func(s *State) {
+		s.BatteryLevel = level
+		s.ConservationMode = conservationMode
+		s.Charging = charging
+		s.LastReadingTime = time.Now()
+		s.CleanShutdown = true
+		s.LastShutdownTime = s.LastReadingTime
+	})
+}
+
 // ShouldEnableConservation determines if conservation mode should be enabled
 func (m *Manager) ShouldEnableConservation() bool {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
+	return m.shouldEnable(m.state)
+}
+
+// shouldEnable applies the enable rule to s (caller must hold the mutex)
+func (m *Manager) shouldEnable(s *State) bool {
 	// Only enable if management is enabled AND on AC power AND battery >= threshold
-	return m.state.ConservationEnabled &&
-		m.state.Charging &&
-		m.state.BatteryLevel >= m.state.ChargeThreshold
+	return s.ConservationEnabled &&
+		s.Charging &&
+		s.BatteryLevel >= m.threshold(s)
 }
 
 // ShouldDisableConservation determines if conservation mode should be disabled
@@ -161,10 +680,39 @@ func (m *Manager) ShouldDisableConservation() bool {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
-	// Only disable if management is enabled AND on AC power AND battery < threshold
-	return m.state.ConservationEnabled &&
-		m.state.Charging &&
-		m.state.BatteryLevel < m.state.ChargeThreshold
+	return m.shouldDisable(m.state)
+}
+
+// shouldDisable applies the disable rule to s (caller must hold the mutex)
+func (m *Manager) shouldDisable(s *State) bool {
+	// Only disable if management is enabled AND on AC power AND battery has
+	// dropped below the start threshold (threshold minus hysteresis)
+	return s.ConservationEnabled &&
+		s.Charging &&
+		s.BatteryLevel < m.startThreshold(s)
+}
+
+// Preview reports what ShouldEnableConservation and ShouldDisableConservation
+// would return after updateFn, without changing or saving the state
+func (m *Manager) Preview(updateFn func(*State)) (enable, disable bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	preview := *m.state
+	updateFn(&preview)
+	return m.shouldEnable(&preview), m.shouldDisable(&preview)
+}
+
+// GetReadingAge returns how old the cached battery readings are, or false if
+// the hardware has not been read yet
+func (m *Manager) GetReadingAge() (time.Duration, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.state.LastReadingTime.IsZero() {
+		return 0, false
+	}
+	return time.Since(m.state.LastReadingTime), true
 }
 
 // GetUptime returns the daemon uptime
@@ -180,8 +728,9 @@ func (m *Manager) GetUptime() time.Duration {
 
 // validateStateFields validates state field values (internal helper)
 func validateStateFields(state *State) error {
-	// Validate threshold
-	if state.ChargeThreshold < 60 || state.ChargeThreshold > 100 {
+	// Validate threshold; the hardware backend checks its own range when
+	// the threshold is set, 40 is the lowest any backend can hold
+	if state.ChargeThreshold < 40 || state.ChargeThreshold > 100 {
 		return ErrInvalidThreshold
 	}
 
@@ -227,12 +776,12 @@ func (m *Manager) Reset() error {
 	defer m.mutex.Unlock()
 
 	m.state = &State{
-		ConservationEnabled: false,
-		ChargeThreshold:     80, // Default threshold
-		CurrentMode:         "unknown",
-		BatteryLevel:        0,
-		ConservationMode:    false,
-		Charging:            false,
+		Persistent: Persistent{
+			ConservationEnabled: false,
+			ChargeThreshold:     80, // Default threshold
+			CurrentMode:         "unknown",
+			CleanShutdown:       true,
+		},
 	}
 
 	return m.saveStateAtomic()
@@ -1,8 +1,11 @@
 package state
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -256,7 +259,7 @@ func TestStateManager_Validate(t *testing.T) {
 	}
 
 	// Test invalid threshold
-	manager.state.ChargeThreshold = 50
+	manager.state.ChargeThreshold = 30
 	err = manager.Validate()
 	if err != ErrInvalidThreshold {
 		t.Errorf("Expected ErrInvalidThreshold, got %v", err)
@@ -414,7 +417,7 @@ func TestStateErrors(t *testing.T) {
 		err  *StateError
 		want string
 	}{
-		{"invalid threshold", ErrInvalidThreshold, "threshold must be between 60 and 100"},
+		{"invalid threshold", ErrInvalidThreshold, "threshold must be between 40 and 100"},
 		{"invalid battery level", ErrInvalidBatteryLevel, "battery level must be between 0 and 100"},
 		{"invalid PID", ErrInvalidPID, "PID must be positive"},
 		{"invalid mode", ErrInvalidMode, "invalid current mode"},
@@ -438,3 +441,727 @@ func TestStateErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestStateManager_ShutdownMarker(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "test_state.json")
+	manager := NewManager(statePath)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	// A fresh state should not report a crash
+	wasClean, err := manager.MarkRunning()
+	if err != nil {
+		t.Fatalf("Unexpected error marking running: %v", err)
+	}
+	if !wasClean {
+		t.Error("Expected fresh state to count as a clean shutdown")
+	}
+
+	// Starting again without a clean shutdown records an unclean one
+	wasClean, err = manager.MarkRunning()
+	if err != nil {
+		t.Fatalf("Unexpected error marking running: %v", err)
+	}
+	if wasClean {
+		t.Error("Expected missing marker to be reported as unclean shutdown")
+	}
+
+	state := manager.GetState()
+	if state.UncleanShutdowns != 1 {
+		t.Errorf("Expected 1 unclean shutdown, got %d", state.UncleanShutdowns)
+	}
+	if state.LastUncleanShutdown.IsZero() {
+		t.Error("Expected unclean shutdown time to be recorded")
+	}
+
+	// A clean shutdown persists the marker and final readings
+	if err := manager.MarkCleanShutdown(77, true, true); err != nil {
+		t.Fatalf("Unexpected error marking clean shutdown: %v", err)
+	}
+
+	reloaded := NewManager(statePath)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload state: %v", err)
+	}
+	state = reloaded.GetState()
+	if !state.CleanShutdown {
+		t.Error("Expected clean shutdown marker to be persisted")
+	}
+	if state.BatteryLevel != 77 {
+		t.Errorf("Expected final battery level 77, got %d", state.BatteryLevel)
+	}
+}
+
+func TestStateManager_ShutdownMarkerMissing(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	// A state file written before the shutdown marker was added
+	content := `{"conservation_enabled": true, "charge_threshold": 80, "current_mode": "enabled",
+		"last_action": "enable", "last_action_time": "2025-01-10T08:00:00Z",
+		"battery_level": 80, "conservation_mode": true, "charging": false,
+		"pid": 1234, "start_time": "2025-01-10T07:59:00Z"}`
+	if err := os.WriteFile(statePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager(statePath)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	wasClean, err := manager.MarkRunning()
+	if err != nil {
+		t.Fatalf("Unexpected error marking running: %v", err)
+	}
+	if !wasClean {
+		t.Error("Expected a state file without the marker to count as a clean shutdown")
+	}
+	if state := manager.GetState(); state.UncleanShutdowns != 0 {
+		t.Errorf("Expected no unclean shutdown, got %d", state.UncleanShutdowns)
+	}
+}
+
+func TestStateManager_ReadingAge(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "test_state.json")
+	manager := NewManager(statePath)
+	manager.state.ChargeThreshold = 80
+
+	if _, ok := manager.GetReadingAge(); ok {
+		t.Error("Expected no cached reading initially")
+	}
+
+	if err := manager.UpdateBatteryInfo(70, false, true); err != nil {
+		t.Fatalf("Unexpected error updating battery info: %v", err)
+	}
+
+	age, ok := manager.GetReadingAge()
+	if !ok {
+		t.Fatal("Expected cached reading after update")
+	}
+	if age < 0 || age > time.Second {
+		t.Errorf("Expected fresh reading age, got %v", age)
+	}
+}
+
+func TestStateManager_ChargeFull(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "test_state.json")
+	manager := NewManager(statePath)
+	manager.state.ChargeThreshold = 80
+
+	if err := manager.EnableConservation(); err != nil {
+		t.Fatalf("Unexpected error enabling conservation: %v", err)
+	}
+
+	if err := manager.StartChargeFull(); err != nil {
+		t.Fatalf("Unexpected error starting charge-full: %v", err)
+	}
+	if !manager.IsChargeFull() || manager.GetConservationEnabled() {
+		t.Error("Expected management to be suspended during charge-full")
+	}
+
+	// The override must survive a daemon restart
+	reloaded := NewManager(statePath)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload state: %v", err)
+	}
+	if !reloaded.IsChargeFull() {
+		t.Error("Expected charge-full to be persisted")
+	}
+
+	if err := reloaded.FinishChargeFull(); err != nil {
+		t.Fatalf("Unexpected error finishing charge-full: %v", err)
+	}
+	if reloaded.IsChargeFull() || !reloaded.GetConservationEnabled() {
+		t.Error("Expected management to be re-enabled after charge-full")
+	}
+
+	// An explicit disable cancels the override
+	if err := reloaded.StartChargeFull(); err != nil {
+		t.Fatalf("Unexpected error starting charge-full: %v", err)
+	}
+	if err := reloaded.DisableConservation(); err != nil {
+		t.Fatalf("Unexpected error disabling conservation: %v", err)
+	}
+	if reloaded.IsChargeFull() {
+		t.Error("Expected disable to cancel charge-full")
+	}
+
+	// Started with management disabled, it stays disabled once full
+	if err := reloaded.StartChargeFull(); err != nil {
+		t.Fatalf("Unexpected error starting charge-full: %v", err)
+	}
+	restarted := NewManager(statePath)
+	if err := restarted.Load(); err != nil {
+		t.Fatalf("Failed to reload state: %v", err)
+	}
+	if err := restarted.FinishChargeFull(); err != nil {
+		t.Fatalf("Unexpected error finishing charge-full: %v", err)
+	}
+	if restarted.IsChargeFull() || restarted.GetConservationEnabled() {
+		t.Error("Expected management to stay disabled after charge-full")
+	}
+	if mode := restarted.GetState().CurrentMode; mode != "disabled" {
+		t.Errorf("Expected mode disabled, got %s", mode)
+	}
+}
+
+func TestStateManager_Pause(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "test_state.json")
+	manager := NewManager(statePath)
+	manager.state.ChargeThreshold = 80
+
+	until := time.Now().Add(time.Hour)
+	if err := manager.Pause(until); err != nil {
+		t.Fatalf("Unexpected error pausing: %v", err)
+	}
+
+	// The pause must survive a daemon restart
+	reloaded := NewManager(statePath)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload state: %v", err)
+	}
+	if paused, pausedUntil := reloaded.GetPause(); !paused || !pausedUntil.Equal(until) {
+		t.Errorf("Expected a pause until %v to be persisted, got %v until %v", until, paused, pausedUntil)
+	}
+
+	if resumed, err := reloaded.ResumeIfDue(until.Add(-time.Minute)); err != nil || resumed {
+		t.Errorf("Expected no resume before the deadline (resumed=%v, err=%v)", resumed, err)
+	}
+	if resumed, err := reloaded.ResumeIfDue(until); err != nil || !resumed {
+		t.Errorf("Expected a resume at the deadline (resumed=%v, err=%v)", resumed, err)
+	}
+	if paused, _ := reloaded.GetPause(); paused {
+		t.Error("Expected automation resumed")
+	}
+
+	// A pause without a deadline lasts until resumed
+	if err := reloaded.Pause(time.Time{}); err != nil {
+		t.Fatalf("Unexpected error pausing: %v", err)
+	}
+	if resumed, _ := reloaded.ResumeIfDue(time.Now().Add(24 * time.Hour)); resumed {
+		t.Error("Expected an indefinite pause to never resume by itself")
+	}
+	if err := reloaded.Resume(); err != nil {
+		t.Fatalf("Unexpected error resuming: %v", err)
+	}
+	if paused, _ := reloaded.GetPause(); paused || reloaded.GetChargeThreshold() != 80 {
+		t.Error("Expected resume to end the pause and keep the settings")
+	}
+}
+
+func TestStateManager_ReenableIfDue(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "test_state.json")
+	manager := NewManager(statePath)
+	manager.state.ChargeThreshold = 80
+
+	until := time.Now().Add(2 * time.Hour)
+	if err := manager.DisableConservationUntil(until); err != nil {
+		t.Fatalf("Unexpected error disabling conservation: %v", err)
+	}
+
+	// The timer must survive a daemon restart
+	reloaded := NewManager(statePath)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload state: %v", err)
+	}
+	if !reloaded.GetState().ReenableAt.Equal(until) {
+		t.Errorf("Expected re-enable time %v to be persisted, got %v", until, reloaded.GetState().ReenableAt)
+	}
+
+	reenabled, err := reloaded.ReenableIfDue(until.Add(-time.Minute))
+	if err != nil || reenabled {
+		t.Errorf("Expected no re-enable before the deadline (reenabled=%v, err=%v)", reenabled, err)
+	}
+
+	reenabled, err = reloaded.ReenableIfDue(until)
+	if err != nil || !reenabled {
+		t.Errorf("Expected re-enable at the deadline (reenabled=%v, err=%v)", reenabled, err)
+	}
+	state := reloaded.GetState()
+	if !state.ConservationEnabled || !state.ReenableAt.IsZero() {
+		t.Error("Expected management re-enabled and timer cleared")
+	}
+
+	// A plain disable has no timer
+	if err := reloaded.DisableConservation(); err != nil {
+		t.Fatalf("Unexpected error disabling conservation: %v", err)
+	}
+	if reenabled, _ := reloaded.ReenableIfDue(time.Now().Add(24 * time.Hour)); reenabled {
+		t.Error("Expected indefinite disable to never re-enable")
+	}
+}
+
+func TestStateManager_Hysteresis(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "test_state.json")
+	manager := NewManager(statePath)
+	manager.state.ConservationEnabled = true
+	manager.state.ChargeThreshold = 80
+	manager.state.Charging = true
+	manager.SetHysteresis(5)
+
+	if manager.GetStartThreshold() != 75 {
+		t.Errorf("Expected start threshold 75, got %d", manager.GetStartThreshold())
+	}
+
+	tests := []struct {
+		level         int
+		shouldEnable  bool
+		shouldDisable bool
+	}{
+		{80, true, false},
+		{79, false, false}, // Inside the hysteresis band, keep the current mode
+		{75, false, false},
+		{74, false, true},
+	}
+
+	for _, tt := range tests {
+		manager.state.BatteryLevel = tt.level
+		if got := manager.ShouldEnableConservation(); got != tt.shouldEnable {
+			t.Errorf("Level %d: ShouldEnableConservation() = %v, want %v", tt.level, got, tt.shouldEnable)
+		}
+		if got := manager.ShouldDisableConservation(); got != tt.shouldDisable {
+			t.Errorf("Level %d: ShouldDisableConservation() = %v, want %v", tt.level, got, tt.shouldDisable)
+		}
+	}
+}
+
+func TestStateManager_StartThreshold(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "test_state.json")
+	manager := NewManager(statePath)
+	manager.SetHysteresis(5)
+
+	if err := manager.SetChargeThresholds(80, 70); err != nil {
+		t.Fatalf("Unexpected error setting thresholds: %v", err)
+	}
+	if manager.GetStartThreshold() != 70 {
+		t.Errorf("Expected explicit start threshold 70 to override hysteresis, got %d", manager.GetStartThreshold())
+	}
+
+	// Lowering the threshold below the start threshold clears it
+	if err := manager.SetChargeThreshold(65); err != nil {
+		t.Fatalf("Unexpected error setting threshold: %v", err)
+	}
+	if manager.GetState().StartThreshold != 0 {
+		t.Errorf("Expected start threshold to be cleared, got %d", manager.GetState().StartThreshold)
+	}
+	if manager.GetStartThreshold() != 60 {
+		t.Errorf("Expected start threshold from hysteresis 60, got %d", manager.GetStartThreshold())
+	}
+}
+
+func TestStateManager_Preview(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "test_state.json")
+	manager := NewManager(statePath)
+	manager.state.ChargeThreshold = 80
+	manager.state.BatteryLevel = 85
+	manager.state.Charging = true
+	manager.SetHysteresis(5)
+
+	enable, disable := manager.Preview(func(s *State) { s.ConservationEnabled = true })
+	if !enable || disable {
+		t.Errorf("Preview(enable) = %v, %v, want true, false", enable, disable)
+	}
+
+	enable, disable = manager.Preview(func(s *State) {
+		s.ConservationEnabled = true
+		s.ChargeThreshold = 95
+		s.StartThreshold = 90
+	})
+	if enable || !disable {
+		t.Errorf("Preview(threshold 95) = %v, %v, want false, true", enable, disable)
+	}
+
+	// The state itself is unchanged
+	if manager.GetConservationEnabled() || manager.GetChargeThreshold() != 80 {
+		t.Errorf("Preview changed the state: %+v", manager.GetState())
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("Preview saved the state, stat error = %v", err)
+	}
+}
+
+func TestStateManager_ChargeRate(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "test_state.json")
+	manager := NewManager(statePath)
+	manager.state.ChargeThreshold = 80
+
+	if manager.GetChargeRate() != 0 {
+		t.Errorf("Expected no charge rate initially, got %v", manager.GetChargeRate())
+	}
+
+	if err := manager.RecordChargeRate(40); err != nil {
+		t.Fatalf("Unexpected error recording charge rate: %v", err)
+	}
+	if manager.GetChargeRate() != 40 {
+		t.Errorf("Expected first sample to be used as is, got %v", manager.GetChargeRate())
+	}
+
+	if err := manager.RecordChargeRate(50); err != nil {
+		t.Fatalf("Unexpected error recording charge rate: %v", err)
+	}
+	if rate := manager.GetChargeRate(); rate <= 40 || rate >= 50 {
+		t.Errorf("Expected smoothed rate between samples, got %v", rate)
+	}
+}
+
+func TestStateManager_ChargePower(t *testing.T) {
+	manager := NewManager(filepath.Join(t.TempDir(), "test_state.json"))
+	manager.state.ChargeThreshold = 80
+
+	if manager.GetChargePower() != 0 {
+		t.Errorf("Expected no charge power initially, got %v", manager.GetChargePower())
+	}
+
+	if err := manager.RecordChargePower(60); err != nil {
+		t.Fatalf("Unexpected error recording charge power: %v", err)
+	}
+	if err := manager.RecordChargePower(40); err != nil {
+		t.Fatalf("Unexpected error recording charge power: %v", err)
+	}
+	if power := manager.GetChargePower(); power <= 40 || power >= 60 {
+		t.Errorf("Expected smoothed power between samples, got %v", power)
+	}
+}
+
+func TestStateManager_RuntimePath(t *testing.T) {
+	tempDir := t.TempDir()
+	statePath := filepath.Join(tempDir, "state.json")
+	runtimePath := filepath.Join(tempDir, "run", "runtime.json")
+
+	manager := NewManager(statePath)
+	manager.SetRuntimePath(runtimePath)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := manager.SetChargeThreshold(85); err != nil {
+		t.Fatalf("SetChargeThreshold() error = %v", err)
+	}
+
+	before, err := os.Stat(statePath)
+	if err != nil {
+		t.Fatalf("state file not written: %v", err)
+	}
+
+	// Readings go to the runtime file and leave the state file alone
+	time.Sleep(10 * time.Millisecond)
+	if err := manager.UpdateBatteryInfo(72, false, true); err != nil {
+		t.Fatalf("UpdateBatteryInfo() error = %v", err)
+	}
+	after, err := os.Stat(statePath)
+	if err != nil {
+		t.Fatalf("state file missing: %v", err)
+	}
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Error("battery reading rewrote the state file")
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "battery_level") {
+		t.Errorf("state file holds runtime fields:\n%s", data)
+	}
+
+	reloaded := NewManager(statePath)
+	reloaded.SetRuntimePath(runtimePath)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	state := reloaded.GetState()
+	if state.ChargeThreshold != 85 || state.BatteryLevel != 72 || !state.Charging {
+		t.Errorf("reloaded threshold %d, level %d, charging %v; want 85, 72, true",
+			state.ChargeThreshold, state.BatteryLevel, state.Charging)
+	}
+
+	// Without the runtime file, e.g. after a reboot, the settings remain
+	if err := os.Remove(runtimePath); err != nil {
+		t.Fatal(err)
+	}
+	rebooted := NewManager(statePath)
+	rebooted.SetRuntimePath(runtimePath)
+	if err := rebooted.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	state = rebooted.GetState()
+	if state.ChargeThreshold != 85 || state.BatteryLevel != 0 {
+		t.Errorf("after reboot threshold %d, level %d; want 85, 0", state.ChargeThreshold, state.BatteryLevel)
+	}
+}
+
+func TestStateManager_LoadCombinedStateFile(t *testing.T) {
+	tempDir := t.TempDir()
+	statePath := filepath.Join(tempDir, "state.json")
+
+	// A state file from before the split holds both parts
+	legacy := NewManager(statePath)
+	legacy.state.ChargeThreshold = 90
+	legacy.state.BatteryLevel = 64
+	if err := legacy.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	manager := NewManager(statePath)
+	manager.SetRuntimePath(filepath.Join(tempDir, "runtime.json"))
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	state := manager.GetState()
+	if state.ChargeThreshold != 90 || state.BatteryLevel != 64 {
+		t.Errorf("threshold %d, level %d; want 90, 64", state.ChargeThreshold, state.BatteryLevel)
+	}
+}
+
+func TestStateManager_CorruptionRecovery(t *testing.T) {
+	tests := []struct {
+		name       string
+		corrupt    func(data []byte) []byte
+		backup     bool
+		wantAction string
+		threshold  int
+	}{
+		{
+			name:       "truncated file restores backup",
+			corrupt:    func(data []byte) []byte { return data[:len(data)/2] },
+			backup:     true,
+			wantAction: "recovered_backup",
+			threshold:  85,
+		},
+		{
+			name: "checksum mismatch restores backup",
+			corrupt: func(data []byte) []byte {
+				return []byte(strings.Replace(string(data), `"charge_threshold": 85`, `"charge_threshold": 95`, 1))
+			},
+			backup:     true,
+			wantAction: "recovered_backup",
+			threshold:  85,
+		},
+		{
+			name: "unknown field without backup resets to defaults",
+			corrupt: func(data []byte) []byte {
+				return []byte(strings.Replace(string(data), "{", `{"bogus": 1,`, 1))
+			},
+			wantAction: "recovered_defaults",
+			threshold:  80,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statePath := filepath.Join(t.TempDir(), "state.json")
+
+			manager := NewManager(statePath)
+			if err := manager.Load(); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if err := manager.SetChargeThreshold(85); err != nil {
+				t.Fatalf("SetChargeThreshold() error = %v", err)
+			}
+			if tt.backup {
+				if err := manager.Backup(); err != nil {
+					t.Fatalf("Backup() error = %v", err)
+				}
+			}
+
+			data, err := os.ReadFile(statePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(statePath, tt.corrupt(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			recovered := NewManager(statePath)
+			if err := recovered.Load(); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if recovered.Recovery() == "" {
+				t.Error("Recovery() is empty after loading a corrupted file")
+			}
+
+			state := recovered.GetState()
+			if state.LastAction != tt.wantAction {
+				t.Errorf("LastAction = %q, want %q", state.LastAction, tt.wantAction)
+			}
+			if state.ChargeThreshold != tt.threshold {
+				t.Errorf("ChargeThreshold = %d, want %d", state.ChargeThreshold, tt.threshold)
+			}
+			if _, err := os.Stat(statePath + ".corrupt"); err != nil {
+				t.Errorf("corrupted file not kept: %v", err)
+			}
+
+			// The rewritten file loads cleanly
+			reloaded := NewManager(statePath)
+			if err := reloaded.Load(); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if reloaded.Recovery() != "" {
+				t.Errorf("Recovery() = %q after loading the recovered file", reloaded.Recovery())
+			}
+		})
+	}
+}
+
+func TestStateManager_LoadWithoutChecksum(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	// A file edited by hand, with the checksum removed
+	content := `{"conservation_enabled": true, "charge_threshold": 75, "current_mode": "enabled"}`
+	if err := os.WriteFile(statePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager(statePath)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if manager.Recovery() != "" {
+		t.Errorf("Recovery() = %q, want none", manager.Recovery())
+	}
+	if got := manager.GetChargeThreshold(); got != 75 {
+		t.Errorf("ChargeThreshold = %d, want 75", got)
+	}
+}
+
+func TestStateManager_BackupRotation(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	manager := NewManager(statePath)
+	manager.SetBackupLimit(2)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, threshold := range []int{70, 75, 90} {
+		if err := manager.SetChargeThreshold(threshold); err != nil {
+			t.Fatalf("SetChargeThreshold() error = %v", err)
+		}
+		if err := manager.Backup(); err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+	}
+
+	// An unchanged state file is not backed up again
+	if err := manager.Backup(); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	backups, err := manager.Backups()
+	if err != nil {
+		t.Fatalf("Backups() error = %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Backups() = %d backups, want 2", len(backups))
+	}
+	if !backups[0].Time.After(backups[1].Time) {
+		t.Errorf("Backups() not newest first: %v, %v", backups[0].Time, backups[1].Time)
+	}
+
+	// The second newest backup holds threshold 75
+	restored := NewManager(statePath)
+	restored.SetBackupLimit(2)
+	if err := restored.RestoreBackup(2); err != nil {
+		t.Fatalf("RestoreBackup(2) error = %v", err)
+	}
+	if got := restored.GetChargeThreshold(); got != 75 {
+		t.Errorf("ChargeThreshold after restore = %d, want 75", got)
+	}
+
+	reloaded := NewManager(statePath)
+	reloaded.SetBackupLimit(2)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := reloaded.GetState(); got.ChargeThreshold != 75 || got.LastAction != "restore_backup" {
+		t.Errorf("reloaded threshold %d, last action %q; want 75, restore_backup", got.ChargeThreshold, got.LastAction)
+	}
+
+	if err := restored.RestoreBackup(3); !errors.Is(err, ErrNoBackup) {
+		t.Errorf("RestoreBackup(3) error = %v, want %v", err, ErrNoBackup)
+	}
+}
+
+func TestStateManager_FileLock(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	manager := NewManager(statePath)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Another process holding the lock, e.g. the auto command
+	other, err := os.OpenFile(statePath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := syscall.Flock(int(other.Fd()), syscall.LOCK_EX); err != nil {
+		t.Fatal(err)
+	}
+
+	oldTimeout := lockTimeout
+	lockTimeout = 50 * time.Millisecond
+	defer func() { lockTimeout = oldTimeout }()
+
+	if err := manager.SetChargeThreshold(85); !errors.Is(err, ErrStateLocked) {
+		t.Errorf("SetChargeThreshold() while locked error = %v, want %v", err, ErrStateLocked)
+	}
+	if err := NewManager(statePath).Load(); !errors.Is(err, ErrStateLocked) {
+		t.Errorf("Load() while locked error = %v, want %v", err, ErrStateLocked)
+	}
+
+	// A save waits for the lock to be released
+	lockTimeout = 5 * time.Second
+	released := make(chan struct{})
+	go func() {
+		defer close(released)
+		time.Sleep(50 * time.Millisecond)
+		syscall.Flock(int(other.Fd()), syscall.LOCK_UN)
+	}()
+	if err := manager.SetChargeThreshold(85); err != nil {
+		t.Errorf("SetChargeThreshold() after unlock error = %v", err)
+	}
+	<-released
+
+	reloaded := NewManager(statePath)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := reloaded.GetChargeThreshold(); got != 85 {
+		t.Errorf("ChargeThreshold = %d, want 85", got)
+	}
+}
+
+func TestStateManager_ChangesFromOtherProcess(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	daemon := NewManager(statePath)
+	if err := daemon.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// Another process, e.g. the auto command, changes the threshold
+	other := NewManager(statePath)
+	if err := other.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := other.SetChargeThreshold(70); err != nil {
+		t.Fatalf("SetChargeThreshold() error = %v", err)
+	}
+
+	// The daemon's next change starts from it rather than overwriting it
+	if err := daemon.SetRapidCharge(true); err != nil {
+		t.Fatalf("SetRapidCharge() error = %v", err)
+	}
+	if got := daemon.GetChargeThreshold(); got != 70 {
+		t.Errorf("ChargeThreshold = %d, want 70", got)
+	}
+
+	reloaded := NewManager(statePath)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if reloaded.GetChargeThreshold() != 70 || !reloaded.GetRapidCharge() {
+		t.Errorf("Expected both changes saved, got threshold %d and rapid charge %v",
+			reloaded.GetChargeThreshold(), reloaded.GetRapidCharge())
+	}
+}
@@ -0,0 +1,102 @@
+// Package systemd generates the systemd service unit for the daemon.
+package systemd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// ServiceName is the name of the installed unit
+	ServiceName = "legionbatctl.service"
+
+	// DefaultUnitDir is where administrator units are installed
+	DefaultUnitDir = "/etc/systemd/system"
+)
+
+// sysfsPaths are the sysfs trees the daemon writes charge controls to
+var sysfsPaths = []string{
+	"/sys/bus/platform/drivers/ideapad_acpi",
+	"/sys/class/power_supply",
+	"/sys/devices",
+}
+
+// UnitOptions configures the generated unit
+type UnitOptions struct {
+	Binary      string // Absolute path of the legionbatctl binary
+	SocketPath  string
+	StatePath   string
+	RuntimePath string
+	ConfigPath  string
+}
+
+// Unit renders the service unit. The daemon runs as root to write sysfs, so
+// the unit sandboxes it: the filesystem is read-only apart from the paths
+// it needs, and kernel tunables, modules and devices are off limits.
+func Unit(opts UnitOptions) string {
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		fmt.Fprintf(&b, format+"\n", args...)
+	}
+
+	line("[Unit]")
+	line("Description=legionbatctl Battery Management Daemon")
+	line("Documentation=https://github.com/dom1nux/legionbatctl")
+	line("")
+	line("[Service]")
+	line("Type=simple")
+	line("ExecStart=%s daemon", opts.Binary)
+	line("ExecReload=/bin/kill -HUP $MAINPID")
+	line("Restart=on-failure")
+	line("RestartSec=5s")
+	line("Environment=SOCKET_PATH=%s", opts.SocketPath)
+	line("Environment=STATE_PATH=%s", opts.StatePath)
+	line("Environment=RUNTIME_PATH=%s", opts.RuntimePath)
+	line("Environment=CONFIG_PATH=%s", opts.ConfigPath)
+	line("")
+	line("# Hardening")
+	line("NoNewPrivileges=yes")
+	line("ProtectSystem=strict")
+	line("StateDirectory=legionbatctl")
+	line("RuntimeDirectory=legionbatctl")
+	line("ReadWritePaths=%s", strings.Join(writablePaths(opts), " "))
+	line("ProtectHome=read-only")
+	line("PrivateTmp=yes")
+	line("PrivateDevices=yes")
+	line("ProtectKernelModules=yes")
+	line("ProtectKernelLogs=yes")
+	line("ProtectControlGroups=yes")
+	line("ProtectClock=yes")
+	line("ProtectHostname=yes")
+	line("RestrictAddressFamilies=AF_UNIX AF_INET AF_INET6")
+	line("RestrictNamespaces=yes")
+	line("RestrictRealtime=yes")
+	line("RestrictSUIDSGID=yes")
+	line("LockPersonality=yes")
+	line("SystemCallArchitectures=native")
+	line("MemoryMax=64M")
+	line("")
+	line("[Install]")
+	line("WantedBy=multi-user.target")
+
+	return b.String()
+}
+
+// writablePaths lists the directories the daemon writes to besides its
+// StateDirectory. The state and runtime files are replaced atomically, so
+// their whole directories must be writable. Sysfs paths are optional ("-") as they depend
+// on the hardware.
+func writablePaths(opts UnitOptions) []string {
+	var paths []string
+	for _, path := range []string{filepath.Dir(opts.SocketPath), filepath.Dir(opts.StatePath), filepath.Dir(opts.RuntimePath)} {
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	for _, path := range sysfsPaths {
+		paths = append(paths, "-"+path)
+	}
+	return paths
+}
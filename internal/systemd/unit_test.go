@@ -0,0 +1,61 @@
+package systemd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     UnitOptions
+		contains []string
+	}{
+		{
+			name: "defaults",
+			opts: UnitOptions{
+				Binary:      "/usr/bin/legionbatctl",
+				SocketPath:  "/var/run/legionbatctl.sock",
+				StatePath:   "/etc/legionbatctl.state",
+				RuntimePath: "/run/legionbatctl/runtime.json",
+				ConfigPath:  "/etc/legionbatctl.conf",
+			},
+			contains: []string{
+				"ExecStart=/usr/bin/legionbatctl daemon\n",
+				"Environment=SOCKET_PATH=/var/run/legionbatctl.sock\n",
+				"Environment=STATE_PATH=/etc/legionbatctl.state\n",
+				"Environment=RUNTIME_PATH=/run/legionbatctl/runtime.json\n",
+				"Environment=CONFIG_PATH=/etc/legionbatctl.conf\n",
+				"ProtectSystem=strict\n",
+				"RuntimeDirectory=legionbatctl\n",
+				"ReadWritePaths=/var/run /etc /run/legionbatctl -/sys/bus/platform/drivers/ideapad_acpi",
+				"WantedBy=multi-user.target\n",
+			},
+		},
+		{
+			name: "shared directory listed once",
+			opts: UnitOptions{
+				Binary:      "/usr/local/bin/legionbatctl",
+				SocketPath:  "/run/legionbatctl/daemon.sock",
+				StatePath:   "/run/legionbatctl/state",
+				RuntimePath: "/run/legionbatctl/runtime.json",
+				ConfigPath:  "/etc/legionbatctl.conf",
+			},
+			contains: []string{
+				"ExecStart=/usr/local/bin/legionbatctl daemon\n",
+				"ReadWritePaths=/run/legionbatctl -/sys",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := Unit(tt.opts)
+			for _, want := range tt.contains {
+				if !strings.Contains(unit, want) {
+					t.Errorf("Unit() missing %q:\n%s", want, unit)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+// Package testutil runs the daemon end to end for integration tests: a real
+// daemon serving a temporary socket, reading and writing a fake sysfs tree,
+// driven through the client while the tests change the simulated battery.
+package testutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+	"github.com/dom1nux/legionbatctl/internal/daemon"
+	"github.com/dom1nux/legionbatctl/internal/hardware"
+)
+
+// Options describe the battery and configuration a harness starts with
+type Options struct {
+	Level    int  // Battery level in percent
+	ACOnline bool // Whether the AC adapter is plugged in
+
+	// ConservationMode is the conservation mode the firmware reports before
+	// the daemon starts
+	ConservationMode bool
+
+	// Config is appended to the generated config file, e.g.
+	// "[management]\nhysteresis = 5"
+	Config string
+}
+
+// Harness is a running daemon against a fake sysfs tree
+type Harness struct {
+	t testing.TB
+
+	Root       string // Fake sysfs root
+	SocketPath string
+	StatePath  string
+	ConfigPath string
+	LogPath    string // Daemon log, at debug level
+
+	Daemon *daemon.Daemon
+	Client *client.Client
+
+	paths hardware.Paths
+}
+
+// New starts a daemon for the duration of the test. The daemon is stopped
+// and its files removed when the test finishes.
+func New(t testing.TB, opts Options) *Harness {
+	t.Helper()
+	dir := t.TempDir()
+
+	h := &Harness{
+		t:          t,
+		Root:       filepath.Join(dir, "sysfs"),
+		SocketPath: filepath.Join(dir, "legionbatctl.sock"),
+		StatePath:  filepath.Join(dir, "state.json"),
+		ConfigPath: filepath.Join(dir, "config.toml"),
+		LogPath:    filepath.Join(dir, "daemon.log"),
+	}
+	h.paths = hardware.DefaultPaths.WithRoot(h.Root)
+
+	h.writeTree(opts)
+	h.writeConfig(dir, opts.Config)
+
+	d := daemon.NewDaemon(h.SocketPath, h.StatePath)
+	d.SetRuntimePath(filepath.Join(dir, "runtime.json"))
+	d.SetHistoryPath(filepath.Join(dir, "history.jsonl"))
+	d.SetAuditPath(filepath.Join(dir, "audit.jsonl"))
+	d.SetSnapshotDir(filepath.Join(dir, "snapshots"))
+	if err := d.LoadConfig(h.ConfigPath); err != nil {
+		t.Fatalf("Failed to load harness config: %v", err)
+	}
+
+	backend, err := hardware.NewBackend("sysfs", h.Root)
+	if err != nil {
+		t.Fatalf("Failed to create sysfs backend: %v", err)
+	}
+	d.SetHardware(backend)
+
+	if err := d.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	t.Cleanup(func() { d.Stop() })
+
+	h.Daemon = d
+	h.Client = client.NewClient(h.SocketPath)
+	return h
+}
+
+// writeTree creates the sysfs attributes the daemon reads and writes
+func (h *Harness) writeTree(opts Options) {
+	h.t.Helper()
+
+	for _, dir := range []string{h.paths.BatteryDir, filepath.Dir(h.paths.ACOnline), filepath.Dir(h.paths.ConservationMode)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			h.t.Fatalf("Failed to create fake sysfs tree: %v", err)
+		}
+	}
+
+	h.writeAttr(h.paths.ConservationMode, boolAttr(opts.ConservationMode))
+	h.writeAttr(filepath.Join(h.paths.BatteryDir, "charge_behaviour"), "[auto] inhibit-charge force-discharge")
+	h.SetACOnline(opts.ACOnline)
+	h.SetLevel(opts.Level)
+}
+
+// writeConfig writes a config file keeping the daemon's logs and hooks in dir
+func (h *Harness) writeConfig(dir, extra string) {
+	h.t.Helper()
+
+	config := fmt.Sprintf(`[[logging.sinks]]
+type = "file"
+path = %q
+level = "debug"
+
+[hooks]
+dir = %q
+
+`, h.LogPath, filepath.Join(dir, "hooks.d")) + extra
+
+	if err := os.WriteFile(h.ConfigPath, []byte(config), 0644); err != nil {
+		h.t.Fatalf("Failed to write harness config: %v", err)
+	}
+}
+
+// SetLevel changes the battery level the firmware reports, along with the
+// battery status matching it
+func (h *Harness) SetLevel(level int) {
+	h.t.Helper()
+	h.writeAttr(filepath.Join(h.paths.BatteryDir, "capacity"), strconv.Itoa(level))
+	h.writeAttr(filepath.Join(h.paths.BatteryDir, "status"), h.status(level))
+}
+
+// SetACOnline plugs the AC adapter in or out
+func (h *Harness) SetACOnline(online bool) {
+	h.t.Helper()
+	h.writeAttr(h.paths.ACOnline, boolAttr(online))
+}
+
+// Level returns the battery level the firmware reports
+func (h *Harness) Level() int {
+	h.t.Helper()
+	level, err := strconv.Atoi(h.readAttr(filepath.Join(h.paths.BatteryDir, "capacity")))
+	if err != nil {
+		h.t.Fatalf("Failed to parse battery level: %v", err)
+	}
+	return level
+}
+
+// ACOnline reports whether the AC adapter is plugged in
+func (h *Harness) ACOnline() bool {
+	h.t.Helper()
+	return h.readAttr(h.paths.ACOnline) == "1"
+}
+
+// ConservationMode reports the conservation mode last written by the daemon
+func (h *Harness) ConservationMode() bool {
+	h.t.Helper()
+	return h.readAttr(h.paths.ConservationMode) == "1"
+}
+
+// Check makes the daemon check the battery and waits until it has
+func (h *Harness) Check() {
+	h.t.Helper()
+	if !h.Daemon.CheckNow() {
+		h.t.Fatal("Daemon is not running")
+	}
+}
+
+// ChargeTo raises the battery level one percent at a time up to target, as
+// the firmware would, with the daemon checking after each step. Charging
+// stops early when conservation mode holds the battery or AC is unplugged.
+// It returns the level reached.
+func (h *Harness) ChargeTo(target int) int {
+	h.t.Helper()
+
+	level := h.Level()
+	for level < target && h.ACOnline() && !h.ConservationMode() {
+		level++
+		h.SetLevel(level)
+		h.Check()
+	}
+	return level
+}
+
+// DischargeTo lowers the battery level one percent at a time down to target,
+// with the daemon checking after each step, and returns the level reached.
+// The battery discharges whether or not AC is plugged in, as it does under
+// heavy load.
+func (h *Harness) DischargeTo(target int) int {
+	h.t.Helper()
+
+	level := h.Level()
+	for level > target {
+		level--
+		h.SetLevel(level)
+		h.Check()
+	}
+	return level
+}
+
+// status returns the battery status sysfs reports at level
+func (h *Harness) status(level int) string {
+	switch {
+	case !h.ACOnline():
+		return "Discharging"
+	case level >= 100:
+		return "Full"
+	case h.ConservationMode():
+		return "Not charging"
+	default:
+		return "Charging"
+	}
+}
+
+func (h *Harness) writeAttr(path, value string) {
+	h.t.Helper()
+	if err := os.WriteFile(path, []byte(value+"\n"), 0644); err != nil {
+		h.t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func (h *Harness) readAttr(path string) string {
+	h.t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func boolAttr(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
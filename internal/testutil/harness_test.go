@@ -0,0 +1,84 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHysteresis(t *testing.T) {
+	h := New(t, Options{Level: 70, ACOnline: true, Config: "[management]\nhysteresis = 5\n"})
+
+	if err := h.Client.SetThreshold(80); err != nil {
+		t.Fatalf("SetThreshold failed: %v", err)
+	}
+	if err := h.Client.Enable(); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+	h.Check()
+
+	// Charging stops at the threshold
+	if level := h.ChargeTo(100); level != 80 || !h.ConservationMode() {
+		t.Fatalf("Charged to %d%% with conservation mode %v, want 80%% and on", level, h.ConservationMode())
+	}
+
+	// Within the hysteresis the battery is not topped up again
+	h.DischargeTo(76)
+	if !h.ConservationMode() {
+		t.Error("Expected conservation mode to stay on within the hysteresis")
+	}
+
+	// Below it charging resumes, up to the threshold again
+	h.DischargeTo(74)
+	if h.ConservationMode() {
+		t.Error("Expected conservation mode off below the hysteresis")
+	}
+	if level := h.ChargeTo(100); level != 80 {
+		t.Errorf("Charged to %d%%, want 80%%", level)
+	}
+}
+
+func TestChargeFull(t *testing.T) {
+	h := New(t, Options{Level: 80, ACOnline: true, ConservationMode: true})
+	if err := h.Client.Enable(); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+
+	if _, err := h.Client.ChargeFull(time.Time{}); err != nil {
+		t.Fatalf("ChargeFull failed: %v", err)
+	}
+	if h.ConservationMode() {
+		t.Fatal("Expected conservation mode off while charging to full")
+	}
+
+	if level := h.ChargeTo(100); level != 100 {
+		t.Fatalf("Charged to %d%%, want 100%%", level)
+	}
+
+	status, err := h.Client.GetStatus(false)
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if status.ChargeFull || !status.ConservationEnabled || !status.ConservationMode {
+		t.Errorf("Expected management to resume after a full charge, got charge_full %v, management %v, conservation mode %v",
+			status.ChargeFull, status.ConservationEnabled, status.ConservationMode)
+	}
+}
+
+func TestUnpluggedBatteryIsLeftAlone(t *testing.T) {
+	h := New(t, Options{Level: 90, ACOnline: false})
+	if err := h.Client.Enable(); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+	h.Check()
+
+	// Without AC the daemon has nothing to hold back
+	if h.ConservationMode() {
+		t.Error("Expected conservation mode to stay off on battery")
+	}
+
+	h.SetACOnline(true)
+	h.Check()
+	if !h.ConservationMode() {
+		t.Error("Expected conservation mode on once plugged in above the threshold")
+	}
+}
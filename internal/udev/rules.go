@@ -0,0 +1,65 @@
+// Package udev generates udev rules for the battery controls.
+package udev
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultRulesPath is where the generated rules are usually installed
+const DefaultRulesPath = "/etc/udev/rules.d/90-legionbatctl.rules"
+
+// RulesOptions configures the generated rules
+type RulesOptions struct {
+	Group   string // Group granted write access to the charge controls
+	PIDPath string // PID file of the daemon to signal on power supply events
+}
+
+// controls lists the writable attributes of each device, matched by udev keys
+var controls = []struct {
+	comment    string
+	match      string
+	attributes []string
+}{
+	{
+		comment:    "Conservation mode (ideapad_acpi)",
+		match:      `SUBSYSTEM=="platform", DRIVER=="ideapad_acpi"`,
+		attributes: []string{"conservation_mode"},
+	},
+	{
+		comment:    "Rapid charge (legion-laptop)",
+		match:      `SUBSYSTEM=="platform", DRIVER=="legion"`,
+		attributes: []string{"rapidcharge"},
+	},
+	{
+		comment:    "Charge thresholds and charge type",
+		match:      `SUBSYSTEM=="power_supply", ATTR{type}=="Battery"`,
+		attributes: []string{"charge_control_start_threshold", "charge_control_end_threshold", "charge_type"},
+	},
+}
+
+// Rules renders the rules. The first set hands the charge controls to the
+// group when the devices appear, so its members can write them without the
+// daemon; the last one signals the daemon (SIGUSR1) to check the battery as
+// soon as a power supply changes instead of waiting for its next poll.
+func Rules(opts RulesOptions) string {
+	var b strings.Builder
+
+	b.WriteString("# Generated by legionbatctl generate udev-rules\n")
+	for _, control := range controls {
+		files := make([]string, len(control.attributes))
+		for i, attribute := range control.attributes {
+			files[i] = "/sys%p/" + attribute
+		}
+
+		fmt.Fprintf(&b, "\n# %s\n", control.comment)
+		fmt.Fprintf(&b, `ACTION=="add", %s, RUN+="/bin/sh -c 'chgrp %s %s; chmod g+w %s'"`+"\n",
+			control.match, opts.Group, strings.Join(files, " "), strings.Join(files, " "))
+	}
+
+	b.WriteString("\n# Re-check the battery when AC is plugged in or unplugged\n")
+	fmt.Fprintf(&b, `ACTION=="change", SUBSYSTEM=="power_supply", RUN+="/bin/sh -c 'kill -USR1 $$(cat %s) 2>/dev/null || true'"`+"\n",
+		opts.PIDPath)
+
+	return b.String()
+}
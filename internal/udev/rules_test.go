@@ -0,0 +1,20 @@
+package udev
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	rules := Rules(RulesOptions{Group: "power", PIDPath: "/run/legionbatctl.pid"})
+
+	for _, want := range []string{
+		`DRIVER=="ideapad_acpi", RUN+="/bin/sh -c 'chgrp power /sys%p/conservation_mode; chmod g+w /sys%p/conservation_mode'"`,
+		`ATTR{type}=="Battery", RUN+="/bin/sh -c 'chgrp power /sys%p/charge_control_start_threshold`,
+		`ACTION=="change", SUBSYSTEM=="power_supply", RUN+="/bin/sh -c 'kill -USR1 $$(cat /run/legionbatctl.pid) 2>/dev/null || true'"`,
+	} {
+		if !strings.Contains(rules, want) {
+			t.Errorf("Rules() missing %q:\n%s", want, rules)
+		}
+	}
+}
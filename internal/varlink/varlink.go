@@ -0,0 +1,238 @@
+// Package varlink implements a minimal varlink server: JSON calls and replies
+// terminated by a NUL byte, plus the org.varlink.service introspection
+// interface, so tools like varlinkctl can talk to the daemon.
+package varlink
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// maxCallSize caps a single call, like the size limit of the JSON socket
+const maxCallSize = 64 << 10
+
+// Errors of the org.varlink.service interface
+const (
+	ErrInterfaceNotFound = "org.varlink.service.InterfaceNotFound"
+	ErrMethodNotFound    = "org.varlink.service.MethodNotFound"
+	ErrInvalidParameter  = "org.varlink.service.InvalidParameter"
+)
+
+// serviceDescription describes the org.varlink.service interface
+const serviceDescription = `# The Varlink Service Interface is provided by every varlink service. It
+# describes the service and the interfaces it implements.
+interface org.varlink.service
+
+# Get a list of all the interfaces a service provides and information
+# about the implementation.
+method GetInfo() -> (
+  vendor: string,
+  product: string,
+  version: string,
+  url: string,
+  interfaces: []string
+)
+
+# Get the description of an interface that is implemented by this service.
+method GetInterfaceDescription(interface: string) -> (description: string)
+
+error InterfaceNotFound (interface: string)
+error MethodNotFound (method: string)
+error MethodNotImplemented (method: string)
+error InvalidParameter (parameter: string)
+`
+
+// Call is a method call received from a client
+type Call struct {
+	Method     string                 `json:"method"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Oneway     bool                   `json:"oneway,omitempty"` // The client expects no reply
+}
+
+// reply is sent back for a call
+type reply struct {
+	Parameters interface{} `json:"parameters,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Error is a varlink error reply
+type Error struct {
+	Name       string
+	Parameters map[string]interface{}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %v", e.Name, e.Parameters)
+}
+
+// Handler serves the methods of an interface. The returned value must
+// encode to a JSON object; a returned *Error is sent as is, other errors
+// are up to the handler to convert.
+type Handler func(conn net.Conn, method string, parameters map[string]interface{}) (interface{}, error)
+
+// Interface is a varlink interface implemented by a service
+type Interface struct {
+	Name        string // e.g. "io.legionbatctl"
+	Description string // Interface definition in the varlink IDL
+	Handler     Handler
+}
+
+// Service serves varlink interfaces on a listener
+type Service struct {
+	Vendor, Product, Version, URL string
+
+	interfaces []Interface
+}
+
+// AddInterface registers an interface with the service
+func (s *Service) AddInterface(iface Interface) {
+	s.interfaces = append(s.interfaces, iface)
+}
+
+// Serve accepts connections until the listener is closed
+func (s *Service) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serves calls on a connection until it is closed or a call can't
+// be read
+func (s *Service) ServeConn(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		call, err := readCall(reader)
+		if err != nil {
+			return
+		}
+
+		result := s.dispatch(conn, call)
+		if call.Oneway {
+			continue
+		}
+
+		payload, err := json.Marshal(result)
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write(append(payload, 0)); err != nil {
+			return
+		}
+	}
+}
+
+// readCall reads a NUL-terminated call
+func readCall(reader *bufio.Reader) (Call, error) {
+	var frame []byte
+	for {
+		chunk, err := reader.ReadSlice(0)
+		frame = append(frame, chunk...)
+		if len(frame) > maxCallSize {
+			return Call{}, fmt.Errorf("call exceeds %d bytes", maxCallSize)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil {
+			return Call{}, err
+		}
+		break
+	}
+
+	var call Call
+	if err := json.Unmarshal(frame[:len(frame)-1], &call); err != nil {
+		return Call{}, fmt.Errorf("malformed call: %w", err)
+	}
+	return call, nil
+}
+
+// dispatch routes a call to the service interface or a registered one
+func (s *Service) dispatch(conn net.Conn, call Call) reply {
+	dot := strings.LastIndex(call.Method, ".")
+	if dot < 0 {
+		return errorReply(&Error{Name: ErrMethodNotFound, Parameters: map[string]interface{}{"method": call.Method}})
+	}
+	ifaceName, method := call.Method[:dot], call.Method[dot+1:]
+
+	if ifaceName == "org.varlink.service" {
+		return s.serviceCall(method, call.Parameters)
+	}
+
+	for _, iface := range s.interfaces {
+		if iface.Name != ifaceName {
+			continue
+		}
+
+		result, err := iface.Handler(conn, method, call.Parameters)
+		if err != nil {
+			var varlinkErr *Error
+			if !errors.As(err, &varlinkErr) {
+				varlinkErr = &Error{Name: ifaceName + ".Failed", Parameters: map[string]interface{}{"message": err.Error()}}
+			}
+			return errorReply(varlinkErr)
+		}
+		if result == nil {
+			result = struct{}{}
+		}
+		return reply{Parameters: result}
+	}
+
+	return errorReply(&Error{Name: ErrInterfaceNotFound, Parameters: map[string]interface{}{"interface": ifaceName}})
+}
+
+// serviceCall implements the org.varlink.service interface
+func (s *Service) serviceCall(method string, parameters map[string]interface{}) reply {
+	switch method {
+	case "GetInfo":
+		names := []string{"org.varlink.service"}
+		for _, iface := range s.interfaces {
+			names = append(names, iface.Name)
+		}
+		return reply{Parameters: map[string]interface{}{
+			"vendor":     s.Vendor,
+			"product":    s.Product,
+			"version":    s.Version,
+			"url":        s.URL,
+			"interfaces": names,
+		}}
+	case "GetInterfaceDescription":
+		name, ok := parameters["interface"].(string)
+		if !ok {
+			return errorReply(&Error{Name: ErrInvalidParameter, Parameters: map[string]interface{}{"parameter": "interface"}})
+		}
+		if name == "org.varlink.service" {
+			return reply{Parameters: map[string]string{"description": serviceDescription}}
+		}
+		for _, iface := range s.interfaces {
+			if iface.Name == name {
+				return reply{Parameters: map[string]string{"description": iface.Description}}
+			}
+		}
+		return errorReply(&Error{Name: ErrInterfaceNotFound, Parameters: map[string]interface{}{"interface": name}})
+	}
+
+	return errorReply(&Error{Name: ErrMethodNotFound, Parameters: map[string]interface{}{"method": "org.varlink.service." + method}})
+}
+
+// errorReply converts an error into a reply
+func errorReply(err *Error) reply {
+	var parameters interface{}
+	if len(err.Parameters) > 0 {
+		parameters = err.Parameters
+	}
+	return reply{Error: err.Name, Parameters: parameters}
+}
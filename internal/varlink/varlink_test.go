@@ -0,0 +1,84 @@
+package varlink
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServiceCalls(t *testing.T) {
+	service := &Service{Vendor: "test", Product: "test", Version: "1", URL: "https://example.com"}
+	service.AddInterface(Interface{
+		Name:        "org.example.ping",
+		Description: "interface org.example.ping\nmethod Ping(text: string) -> (text: string)\n",
+		Handler: func(conn net.Conn, method string, parameters map[string]interface{}) (interface{}, error) {
+			if method != "Ping" {
+				return nil, &Error{Name: ErrMethodNotFound, Parameters: map[string]interface{}{"method": method}}
+			}
+			if text, ok := parameters["text"].(string); ok {
+				return map[string]string{"text": text}, nil
+			}
+			return nil, errors.New("missing text")
+		},
+	})
+
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "varlink.sock"))
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go service.Serve(listener)
+	defer listener.Close()
+
+	conn, err := net.Dial("unix", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	call := func(method string, parameters map[string]interface{}) (map[string]interface{}, string) {
+		t.Helper()
+		payload, _ := json.Marshal(Call{Method: method, Parameters: parameters})
+		if _, err := conn.Write(append(payload, 0)); err != nil {
+			t.Fatalf("Failed to send call: %v", err)
+		}
+		frame, err := reader.ReadBytes(0)
+		if err != nil {
+			t.Fatalf("Failed to read reply: %v", err)
+		}
+		var r struct {
+			Parameters map[string]interface{} `json:"parameters"`
+			Error      string                 `json:"error"`
+		}
+		if err := json.Unmarshal(frame[:len(frame)-1], &r); err != nil {
+			t.Fatalf("Malformed reply %q: %v", frame, err)
+		}
+		return r.Parameters, r.Error
+	}
+
+	info, errName := call("org.varlink.service.GetInfo", nil)
+	if errName != "" || info["vendor"] != "test" || len(info["interfaces"].([]interface{})) != 2 {
+		t.Errorf("GetInfo = %v, %s", info, errName)
+	}
+
+	description, errName := call("org.varlink.service.GetInterfaceDescription", map[string]interface{}{"interface": "org.example.ping"})
+	if errName != "" || !strings.Contains(description["description"].(string), "method Ping") {
+		t.Errorf("GetInterfaceDescription = %v, %s", description, errName)
+	}
+
+	if result, errName := call("org.example.ping.Ping", map[string]interface{}{"text": "hi"}); errName != "" || result["text"] != "hi" {
+		t.Errorf("Ping = %v, %s", result, errName)
+	}
+
+	if result, errName := call("org.example.ping.Ping", nil); errName != "org.example.ping.Failed" || result["message"] != "missing text" {
+		t.Errorf("Ping without text = %v, %s", result, errName)
+	}
+
+	if _, errName := call("org.example.pong.Ping", nil); errName != ErrInterfaceNotFound {
+		t.Errorf("Expected %s, got %s", ErrInterfaceNotFound, errName)
+	}
+}
@@ -0,0 +1,46 @@
+// Package client is the public Go client for the legionbatctl daemon socket,
+// for integrations such as status bars and exporters outside this module.
+// Clients polling the daemon often can share connections through a pool.
+package client
+
+import (
+	"time"
+
+	"github.com/dom1nux/legionbatctl/internal/client"
+)
+
+// Client talks to the daemon over its socket
+type Client = client.Client
+
+const (
+	// DefaultSocketPath is the system daemon's socket
+	DefaultSocketPath = client.DefaultSocketPath
+
+	// DefaultTimeout bounds a request to the daemon
+	DefaultTimeout = client.DefaultTimeout
+
+	// DefaultPoolIdleTimeout is how long a pooled connection is kept open
+	// without being used
+	DefaultPoolIdleTimeout = client.DefaultPoolIdleTimeout
+)
+
+// ErrPoolClosed is returned for requests through a pool after Close
+var ErrPoolClosed = client.ErrPoolClosed
+
+// NewClient creates a client opening a connection per request. An empty
+// socket path uses SOCKET_PATH, or the default socket for the current user.
+func NewClient(socketPath string) *Client {
+	return client.NewClient(socketPath)
+}
+
+// NewPooledClient creates a client keeping up to size connections to the
+// daemon open and sharing them between requests, including concurrent ones.
+// Connections unused for idleTimeout (DefaultPoolIdleTimeout if zero) are
+// closed. Call Close when done.
+func NewPooledClient(socketPath string, size int, idleTimeout time.Duration) (*Client, error) {
+	c := client.NewClient(socketPath)
+	if err := c.EnablePool(size, idleTimeout); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/dom1nux/legionbatctl/internal/daemon"
+)
+
+func TestNewPooledClient(t *testing.T) {
+	tempDir := t.TempDir()
+	socketPath := filepath.Join(tempDir, "test.sock")
+
+	daemonInstance := daemon.NewDaemon(socketPath, filepath.Join(tempDir, "test_state.json"))
+	if err := daemonInstance.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	defer daemonInstance.Stop()
+
+	if _, err := NewPooledClient(socketPath, 0, 0); err == nil {
+		t.Error("Expected an error for an empty pool")
+	}
+
+	c, err := NewPooledClient(socketPath, 2, 0)
+	if err != nil {
+		t.Fatalf("NewPooledClient failed: %v", err)
+	}
+	for range 3 {
+		if err := c.Ping(); err != nil {
+			t.Fatalf("Ping failed: %v", err)
+		}
+	}
+
+	c.Close()
+	if err := c.Ping(); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed after Close, got %v", err)
+	}
+}
@@ -3,15 +3,20 @@ package version
 import (
 	"fmt"
 	"runtime"
+	"runtime/debug"
 )
 
-// These variables are set at build time using ldflags
+// These variables are set at build time using ldflags. Without them, e.g.
+// after go install, they come from the build info Go embeds.
 var (
 	Version   = "dev"
 	Commit    = "unknown"
 	BuildDate = "unknown"
 )
 
+// readBuildInfo reads the build info embedded in the binary (replaced in tests)
+var readBuildInfo = debug.ReadBuildInfo
+
 // Info contains version information
 type Info struct {
 	Version   string
@@ -21,15 +26,45 @@ type Info struct {
 	Platform  string
 }
 
-// GetVersionInfo returns version information
+// GetVersionInfo returns version information, filling in what ldflags
+// didn't set from the build info
 func GetVersionInfo() Info {
-	return Info{
+	info := Info{
 		Version:   Version,
 		Commit:    Commit,
 		BuildDate: BuildDate,
 		GoVersion: runtime.Version(),
 		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
 	}
+	if build, ok := readBuildInfo(); ok {
+		info = withBuildInfo(info, build)
+	}
+	return info
+}
+
+// withBuildInfo fills in the version from the module version, as set by go
+// install, and the commit from the VCS stamp of builds in a checkout, whose
+// commit time stands in for the build date
+func withBuildInfo(info Info, build *debug.BuildInfo) Info {
+	if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+		info.Version = build.Main.Version
+	}
+
+	settings := make(map[string]string)
+	for _, setting := range build.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	if revision := settings["vcs.revision"]; info.Commit == "unknown" && revision != "" {
+		info.Commit = revision[:min(len(revision), 12)]
+		if settings["vcs.modified"] == "true" {
+			info.Commit += "-dirty"
+		}
+	}
+	if built := settings["vcs.time"]; info.BuildDate == "unknown" && built != "" {
+		info.BuildDate = built
+	}
+
+	return info
 }
 
 // String returns a formatted version string
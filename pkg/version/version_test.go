@@ -0,0 +1,49 @@
+package version
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestWithBuildInfo(t *testing.T) {
+	unset := Info{Version: "dev", Commit: "unknown", BuildDate: "unknown"}
+	stamped := []debug.BuildSetting{
+		{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+		{Key: "vcs.time", Value: "2026-10-01T12:00:00Z"},
+		{Key: "vcs.modified", Value: "true"},
+	}
+
+	tests := []struct {
+		name     string
+		info     Info
+		build    debug.BuildInfo
+		expected Info
+	}{
+		{
+			name:     "go install",
+			info:     unset,
+			build:    debug.BuildInfo{Main: debug.Module{Version: "v1.4.0"}},
+			expected: Info{Version: "v1.4.0", Commit: "unknown", BuildDate: "unknown"},
+		},
+		{
+			name:     "build in a checkout",
+			info:     unset,
+			build:    debug.BuildInfo{Main: debug.Module{Version: "(devel)"}, Settings: stamped},
+			expected: Info{Version: "dev", Commit: "0123456789ab-dirty", BuildDate: "2026-10-01T12:00:00Z"},
+		},
+		{
+			name:     "ldflags win",
+			info:     Info{Version: "v1.5.0", Commit: "fedcba987654", BuildDate: "2026-10-02T08:00:00"},
+			build:    debug.BuildInfo{Main: debug.Module{Version: "v1.4.0"}, Settings: stamped},
+			expected: Info{Version: "v1.5.0", Commit: "fedcba987654", BuildDate: "2026-10-02T08:00:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withBuildInfo(tt.info, &tt.build); got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}